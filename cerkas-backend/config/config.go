@@ -28,6 +28,40 @@ type Config struct {
 	DefaultTTL    int64  `envconfig:"DEFAULT_TTL" default:"3600"`
 
 	InternalSecretKey string `envconfig:"INTERNAL_SECRET_KEY" default:"INTERNAL_SECRET_KEY"`
+	AuthTokenSecret   string `envconfig:"AUTH_TOKEN_SECRET" default:""`
+	AdminRole         string `envconfig:"ADMIN_ROLE" default:"admin"`
+
+	EventRelayInterval  int `envconfig:"EVENT_RELAY_INTERVAL" default:"1"`
+	EventRelayBatchSize int `envconfig:"EVENT_RELAY_BATCH_SIZE" default:"100"`
+	EventRelayLease     int `envconfig:"EVENT_RELAY_LEASE" default:"60"`
+	EventMaxAttempts    int `envconfig:"EVENT_MAX_ATTEMPTS" default:"10"`
+
+	WebhookWorkerInterval int `envconfig:"WEBHOOK_WORKER_INTERVAL" default:"5"`
+	WebhookBatchSize      int `envconfig:"WEBHOOK_BATCH_SIZE" default:"50"`
+	WebhookMaxAttempts    int `envconfig:"WEBHOOK_MAX_ATTEMPTS" default:"8"`
+	WebhookTimeout        int `envconfig:"WEBHOOK_TIMEOUT" default:"10"`
+	WebhookRetryBaseDelay int `envconfig:"WEBHOOK_RETRY_BASE_DELAY" default:"30"`
+	// WebhookAllowPrivateHosts lets webhooks reach loopback, link-local and private addresses, for local setups
+	WebhookAllowPrivateHosts bool `envconfig:"WEBHOOK_ALLOW_PRIVATE_HOSTS" default:"false"`
+
+	SchedulerInterval  int    `envconfig:"SCHEDULER_INTERVAL" default:"30"`
+	SchedulerBatchSize int    `envconfig:"SCHEDULER_BATCH_SIZE" default:"20"`
+	ScheduleExportDir  string `envconfig:"SCHEDULE_EXPORT_DIR" default:"exports"`
+
+	JobWorkerConcurrency int `envconfig:"JOB_WORKER_CONCURRENCY" default:"2"`
+	JobPollInterval      int `envconfig:"JOB_POLL_INTERVAL" default:"1"`
+	JobLease             int `envconfig:"JOB_LEASE" default:"300"`
+	JobMaxAttempts       int `envconfig:"JOB_MAX_ATTEMPTS" default:"3"`
+	JobRetryBaseDelay    int `envconfig:"JOB_RETRY_BASE_DELAY" default:"10"`
+
+	FileStorageDriver string `envconfig:"FILE_STORAGE_DRIVER" default:"local"`
+	FileStorageDir    string `envconfig:"FILE_STORAGE_DIR" default:"files"`
+	FileMaxSize       int64  `envconfig:"FILE_MAX_SIZE" default:"52428800"`
+
+	ThumbnailSizes     []int `envconfig:"THUMBNAIL_SIZES" default:"64,256"`
+	ThumbnailMaxPixels int   `envconfig:"THUMBNAIL_MAX_PIXELS" default:"40000000"`
+
+	OpenAPICacheTTL int `envconfig:"OPENAPI_CACHE_TTL" default:"300"`
 }
 
 func Get() Config {
@@ -0,0 +1,84 @@
+package entity
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	ChangeRequestStatusPending  = "pending"
+	ChangeRequestStatusApproved = "approved"
+	ChangeRequestStatusRejected = "rejected"
+
+	ApprovalRequested RecordEventType = "approval.requested"
+	ApprovalApproved  RecordEventType = "approval.approved"
+	ApprovalRejected  RecordEventType = "approval.rejected"
+)
+
+var (
+	// ApprovalEventTypes are the approval events a webhook can subscribe to, the requester learns the decision on
+	// a change request from them
+	ApprovalEventTypes = []string{
+		string(ApprovalRequested),
+		string(ApprovalApproved),
+		string(ApprovalRejected),
+	}
+
+	ErrorApprovalEventInvalid       = errors.New("approval policy event type must be one of create, update or delete")
+	ErrorApprovalForbidden          = errors.New("you can not decide on this change request")
+	ErrorChangeRequestDecided       = errors.New("change request is already decided")
+	ErrorChangeRequestStatusInvalid = errors.New("change request status must be pending, approved or rejected")
+)
+
+// ApprovalPolicy holds back the mutations of EventTypes on an object matching its condition until one of
+// ApproverRoles approves them. The condition reads the record after the mutation by field code and the record
+// before it by "old." and the field code.
+type ApprovalPolicy struct {
+	ID            int64         `json:"id"`
+	Serial        string        `json:"serial"`
+	TenantCode    string        `json:"tenant_code"`
+	ObjectCode    string        `json:"object_code"`
+	Name          string        `json:"name"`
+	EventTypes    []string      `json:"event_types"`
+	Condition     []FilterGroup `json:"condition"`
+	ApproverRoles []string      `json:"approver_roles"`
+	IsActive      bool          `json:"is_active"`
+	CreatedBy     string        `json:"created_by"`
+	CreatedAt     time.Time     `json:"created_at"`
+}
+
+// ChangeRequest is a mutation waiting for approval, it is applied as it was requested once approved
+type ChangeRequest struct {
+	ID            int64               `json:"id"`
+	Serial        string              `json:"serial"`
+	TenantCode    string              `json:"tenant_code"`
+	ProductCode   string              `json:"product_code"`
+	ObjectCode    string              `json:"object_code"`
+	RecordSerial  string              `json:"record_serial"`
+	PolicySerial  string              `json:"policy_serial"`
+	Action        string              `json:"action"`
+	Mutation      DataMutationRequest `json:"mutation"`
+	Status        string              `json:"status"`
+	RequestedBy   string              `json:"requested_by"`
+	RequesterRole string              `json:"requester_role"`
+	RequestedAt   time.Time           `json:"requested_at"`
+	DecidedBy     string              `json:"decided_by"`
+	DecidedAt     *time.Time          `json:"decided_at"`
+	Comment       string              `json:"comment"`
+}
+
+type ChangeRequestQuery struct {
+	TenantCode string `json:"tenant_code"`
+	ObjectCode string `json:"object_code"`
+	Status     string `json:"status"`
+}
+
+// ChangeRequestDecision approves or rejects a change request
+type ChangeRequestDecision struct {
+	TenantCode string `json:"tenant_code"`
+	Serial     string `json:"serial"`
+	Approve    bool   `json:"-"`
+	Comment    string `json:"comment"`
+	UserSerial string `json:"-"`
+	UserRole   string `json:"-"`
+}
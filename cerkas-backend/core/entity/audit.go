@@ -0,0 +1,29 @@
+package entity
+
+import "time"
+
+const (
+	AuditActionCreate = "create"
+	AuditActionUpdate = "update"
+	AuditActionDelete = "delete"
+)
+
+type AuditChange struct {
+	FieldCode string `json:"field_code"`
+	FieldName string `json:"field_name"`
+	OldValue  any    `json:"old_value"`
+	NewValue  any    `json:"new_value"`
+}
+
+type AuditLog struct {
+	ID           int64         `json:"id"`
+	Serial       string        `json:"serial"`
+	TenantCode   string        `json:"tenant_code"`
+	ProductCode  string        `json:"product_code"`
+	ObjectCode   string        `json:"object_code"`
+	RecordSerial string        `json:"record_serial"`
+	Action       string        `json:"action"`
+	ActorSerial  string        `json:"actor_serial"`
+	Changes      []AuditChange `json:"changes"`
+	CreatedAt    time.Time     `json:"created_at"`
+}
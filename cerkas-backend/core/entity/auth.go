@@ -0,0 +1,19 @@
+package entity
+
+import "errors"
+
+var (
+	ErrorUnauthorized  = errors.New("the bearer token is invalid or expired")
+	ErrorAdminRequired = errors.New("only an admin can do this")
+	ErrorLoginRequired = errors.New("only a signed in user can do this")
+)
+
+// ContextKeyUser is the gin context key of the UserClaims of an authenticated request
+const ContextKeyUser = "user"
+
+// UserClaims are the user and role a bearer token is issued for, ExpiresAt is a unix timestamp
+type UserClaims struct {
+	UserSerial string `json:"sub"`
+	UserRole   string `json:"role"`
+	ExpiresAt  int64  `json:"exp"`
+}
@@ -1,5 +1,7 @@
 package entity
 
+import "errors"
+
 type FilterGroupOperator string
 type FilterOperator string
 
@@ -22,6 +24,7 @@ const (
 	FilterOperatorGreaterThanEqual FilterOperator = "greater_than_equal"
 	FilterOperatorLessThan         FilterOperator = "less_than"
 	FilterOperatorLessThanEqual    FilterOperator = "less_than_equal"
+	FilterOperatorIn               FilterOperator = "in"
 
 	FieldColumnName            = "field_name"
 	FieldDataType              = "data_type"
@@ -32,6 +35,35 @@ const (
 	FieldOriginalFieldCode     = "original_field_code"
 	ForeignTable               = "foreign_table"
 	ForeignReferenceColumnName = "foreign_reference_column_name"
+
+	FieldKindColumn  = "column"
+	FieldKindFormula = "formula"
+	FieldKindRollup  = "rollup"
+
+	RollupFunctionCount = "count"
+	RollupFunctionSum   = "sum"
+	RollupFunctionAvg   = "avg"
+	RollupFunctionMin   = "min"
+	RollupFunctionMax   = "max"
+
+	StructureTypeList     = "list"
+	StructureTypeKanban   = "kanban"
+	StructureTypeCalendar = "calendar"
+)
+
+var (
+	ErrorKanbanGroupByEmpty    = errors.New("kanban group_by is empty")
+	ErrorKanbanGroupByInvalid  = errors.New("kanban group_by must be a field of the object")
+	ErrorCalendarStartEmpty    = errors.New("calendar start_field is empty")
+	ErrorCalendarWindowInvalid = errors.New("calendar window must be dates with start before end")
+	ErrorIncludeNotFound       = errors.New("include is not a relation pointing at the object")
+	ErrorIncludeAmbiguous      = errors.New("include matches more than one relation, set its relation_field")
+	ErrorLookupFieldInvalid    = errors.New("lookup field is not a reference field of the object")
+	ErrorLookupFilterInvalid   = errors.New("lookup filter must be a field of the referenced object")
+	ErrorFormulaInvalid        = errors.New("formula field does not compile")
+
+	ErrorFilterGroupOperatorInvalid = errors.New("filter group operator must be AND or OR")
+	ErrorFilterOperatorInvalid      = errors.New("filter operator is not supported")
 )
 
 var (
@@ -44,6 +76,7 @@ var (
 		FilterOperatorGreaterThanEqual: ">=",
 		FilterOperatorLessThan:         "<",
 		FilterOperatorLessThanEqual:    "<=",
+		FilterOperatorIn:               "IN",
 	}
 
 	OperatorLIKEList = []FilterOperator{
@@ -70,6 +103,7 @@ type DataSource struct {
 	Username    string                 `json:"username"`
 	Password    string                 `json:"password"`
 	DBName      string                 `json:"db_name"`
+	Driver      string                 `json:"driver"`
 	Configs     map[string]interface{} `json:"configs"`
 	Tenant      Tenants                `json:"tenant"`
 }
@@ -119,6 +153,29 @@ type ObjectFields struct {
 	Relation          string                 `json:"relation"`
 	IsSystem          bool                   `json:"is_system"`
 	DefaultValue      string                 `json:"default_value"`
+	Kind              string                 `json:"kind"`
+	Expression        string                 `json:"expression"`
+	Rollup            *RollupConfig          `json:"rollup"`
+}
+
+// RollupConfig aggregates the records of ObjectCode referring to a record through RelationField. The relation can be
+// left empty when ObjectCode refers to the record only once, FieldCode can be left empty to count the records.
+// A materialized rollup is a physical column, recomputed whenever a record of ObjectCode changes.
+type RollupConfig struct {
+	ObjectCode    string        `json:"object_code"`
+	RelationField string        `json:"relation_field"`
+	Function      string        `json:"function"`
+	FieldCode     string        `json:"field_code"`
+	Filters       []FilterGroup `json:"filters"`
+	Materialized  bool          `json:"materialized"`
+}
+
+// RollupField is a materialized rollup field of ObjectCode, with the relation its records are aggregated through
+type RollupField struct {
+	ObjectCode string          `json:"object_code"`
+	FieldCode  string          `json:"field_code"`
+	Rollup     RollupConfig    `json:"rollup"`
+	Relation   ReverseRelation `json:"relation"`
 }
 
 type DataType struct {
@@ -171,6 +228,78 @@ type CatalogQuery struct {
 	ProductSerial   string           `json:"product_serial"`
 	RawQuery        string           `json:"raw_query"`
 	ViewContentCode string           `json:"view_content_code"`
+	AsOf            string           `json:"as_of"`
+	StructureType   string           `json:"structure_type"`
+	Kanban          *KanbanQuery     `json:"kanban"`
+	Calendar        *CalendarQuery   `json:"calendar"`
+	Includes        []Include        `json:"includes"`
+	UserSerial      string           `json:"-"`
+	UserRole        string           `json:"-"`
+}
+
+// Include is a one-to-many relation embedded in a detail record, the children are the records of
+// ObjectCode whose RelationField refers to the record
+type Include struct {
+	Name          string           `json:"name"`
+	ObjectCode    string           `json:"object_code"`
+	RelationField string           `json:"relation_field"`
+	Fields        map[string]Field `json:"fields"`
+	Filters       []FilterGroup    `json:"filters"`
+	Orders        []Order          `json:"orders"`
+	Limit         int              `json:"limit"`
+}
+
+// ReverseRelation is a field of another object referring to an object, by foreign key or by object field
+type ReverseRelation struct {
+	ObjectCode      string `json:"object_code"`
+	RelationField   string `json:"relation_field"`
+	ReferencedField string `json:"referenced_field"`
+}
+
+// LookupQuery searches the records a reference field of ObjectCode can point to. Search matches the display name field
+// of the referenced object, Filters narrow the records by the values of dependent fields.
+type LookupQuery struct {
+	TenantCode  string            `json:"tenant_code"`
+	ProductCode string            `json:"product_code"`
+	ObjectCode  string            `json:"object_code"`
+	FieldCode   string            `json:"field_code"`
+	Search      string            `json:"q"`
+	Filters     map[string]string `json:"filters"`
+	Limit       int               `json:"limit"`
+}
+
+// LookupOption is a record a reference field can point to
+type LookupOption struct {
+	Serial       any `json:"serial"`
+	DisplayValue any `json:"display_value"`
+}
+
+type KanbanQuery struct {
+	GroupBy  string         `json:"group_by"`
+	Columns  []string       `json:"columns"`
+	PageSize int            `json:"page_size"`
+	Pages    map[string]int `json:"pages"`
+}
+
+type CalendarQuery struct {
+	StartField string `json:"start_field"`
+	EndField   string `json:"end_field"`
+	Start      string `json:"start"`
+	End        string `json:"end"`
+}
+
+type GroupCount struct {
+	Value any `json:"value"`
+	Count int `json:"count"`
+}
+
+type KanbanColumn struct {
+	Value     any                   `json:"value"`
+	TotalData int                   `json:"total_data"`
+	Page      int                   `json:"page"`
+	PageSize  int                   `json:"page_size"`
+	TotalPage int                   `json:"total_page"`
+	Items     []map[string]DataItem `json:"items"`
 }
 
 type DataItem struct {
@@ -188,6 +317,10 @@ type CatalogResponse struct {
 	TotalData int                   `json:"total_data"`
 	TotalPage int                   `json:"total_page"`
 	Items     []map[string]DataItem `json:"items"`
+
+	StructureType string         `json:"structure_type,omitempty"`
+	Columns       []KanbanColumn `json:"columns,omitempty"`
+	Calendar      *CalendarQuery `json:"calendar,omitempty"`
 }
 
 type DataMutationRequest struct {
@@ -197,6 +330,7 @@ type DataMutationRequest struct {
 	TenantCode  string     `json:"tenant_code"`
 	ProductCode string     `json:"product_code"`
 	UserSerial  string     `json:"user_serial"`
+	UserRole    string     `json:"-"`
 }
 
 type ForeignKeyInfo struct {
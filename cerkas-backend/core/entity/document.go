@@ -0,0 +1,61 @@
+package entity
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	DocumentFormatHTML = "html"
+	DocumentFormatPDF  = "pdf"
+)
+
+var (
+	ErrorDocumentTemplateInvalid = errors.New("document template is invalid")
+	ErrorDocumentTemplateExists  = errors.New("document template code is already used by this object")
+	ErrorDocumentPageSizeInvalid = errors.New("document page size must be a4 or letter")
+	ErrorDocumentFormatInvalid   = errors.New("document format must be html or pdf")
+	ErrorDocumentRenderFailed    = errors.New("failed to render the document")
+)
+
+// DocumentTemplate renders a record of an object as a document. Body is an html/template reading the fields of the
+// record by field code, the fields of a related record by the a__b field code and the related lists of Includes by
+// their name. The values are text formatted in the locale of the tenant, a reference field reads as the display name
+// of the record it refers to.
+type DocumentTemplate struct {
+	ID         int64     `json:"id"`
+	Serial     string    `json:"serial"`
+	TenantCode string    `json:"tenant_code"`
+	ObjectCode string    `json:"object_code"`
+	Code       string    `json:"code"`
+	Name       string    `json:"name"`
+	Body       string    `json:"body"`
+	Includes   []Include `json:"includes"`
+	PageSize   string    `json:"page_size"`
+	IsActive   bool      `json:"is_active"`
+	CreatedBy  string    `json:"created_by"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// TenantLocale is how the documents of a tenant write numbers, dates and times
+type TenantLocale struct {
+	Locale   string `json:"locale"`
+	Timezone string `json:"timezone"`
+}
+
+type RenderDocumentRequest struct {
+	TenantCode   string `json:"tenant_code"`
+	ProductCode  string `json:"product_code"`
+	ObjectCode   string `json:"object_code"`
+	RecordSerial string `json:"record_serial"`
+	TemplateCode string `json:"template_code"`
+	Format       string `json:"format"`
+	UserSerial   string `json:"-"`
+	UserRole     string `json:"-"`
+}
+
+type RenderedDocument struct {
+	FileName    string
+	ContentType string
+	Content     []byte
+}
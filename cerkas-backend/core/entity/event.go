@@ -0,0 +1,64 @@
+package entity
+
+import "time"
+
+type RecordEventType string
+
+const (
+	RecordCreated RecordEventType = "record.created"
+	RecordUpdated RecordEventType = "record.updated"
+	RecordDeleted RecordEventType = "record.deleted"
+
+	// RecordPurged is published when a soft deleted record is removed for good, OldData holds its file fields
+	RecordPurged RecordEventType = "record.purged"
+
+	OutboxStatusPending    = "pending"
+	OutboxStatusDispatched = "dispatched"
+	OutboxStatusFailed     = "failed"
+)
+
+// RecordEvent is published for every committed mutation of an object record
+type RecordEvent struct {
+	ID           int64               `json:"id"`
+	Serial       string              `json:"serial"`
+	Type         RecordEventType     `json:"type"`
+	TenantCode   string              `json:"tenant_code"`
+	ProductCode  string              `json:"product_code"`
+	ObjectCode   string              `json:"object_code"`
+	RecordSerial string              `json:"record_serial"`
+	ActorSerial  string              `json:"actor_serial"`
+	Items        []DataItem          `json:"items"`
+	Data         map[string]DataItem `json:"data"`
+	OldData      map[string]DataItem `json:"old_data"`
+	OccurredAt   time.Time           `json:"occurred_at"`
+}
+
+// Action returns the audit action of the event
+func (e RecordEvent) Action() string {
+	switch e.Type {
+	case RecordCreated:
+		return AuditActionCreate
+	case RecordUpdated:
+		return AuditActionUpdate
+	case RecordDeleted:
+		return AuditActionDelete
+	}
+
+	return string(e.Type)
+}
+
+// IsRecordChange tells a record mutation from an event emitted by a rule
+func (e RecordEvent) IsRecordChange() bool {
+	return e.Type == RecordCreated || e.Type == RecordUpdated || e.Type == RecordDeleted
+}
+
+// OutboxEvent is a record event waiting in the outbox with its dispatch state
+type OutboxEvent struct {
+	Event                RecordEvent `json:"event"`
+	Status               string      `json:"status"`
+	Attempts             int         `json:"attempts"`
+	CompletedSubscribers []string    `json:"completed_subscribers"`
+	NextAttemptAt        time.Time   `json:"next_attempt_at"`
+	LastError            string      `json:"last_error"`
+	DispatchedAt         *time.Time  `json:"dispatched_at"`
+}
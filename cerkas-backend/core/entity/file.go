@@ -0,0 +1,71 @@
+package entity
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	DataTypeFile = "file"
+
+	// FileRuleMaxSize is the validation rule of a file field limiting the size of a file, in bytes
+	FileRuleMaxSize = "max_size"
+	// FileRuleMimeTypes is the validation rule of a file field listing the accepted mime types, like image/* or
+	// application/pdf
+	FileRuleMimeTypes = "mime_types"
+)
+
+var (
+	ErrorFileFieldInvalid = errors.New("field is not a file field of the object")
+	ErrorFileEmpty        = errors.New("file is empty")
+	ErrorFileTooLarge     = errors.New("file is larger than the field allows")
+	ErrorFileTypeInvalid  = errors.New("file type is not accepted by the field")
+	ErrorFileNotFound     = errors.New("field has no file")
+
+	ErrorThumbnailSizeInvalid = errors.New("thumbnail size is not configured")
+	ErrorThumbnailUnavailable = errors.New("file has no thumbnail")
+)
+
+// FileAttachment is the value of a file field, the content lives in the file storage under StorageKey
+type FileAttachment struct {
+	Serial     string    `json:"serial"`
+	FileName   string    `json:"file_name"`
+	MimeType   string    `json:"mime_type"`
+	Size       int64     `json:"size"`
+	StorageKey string    `json:"storage_key"`
+	UploadedBy string    `json:"uploaded_by"`
+	UploadedAt time.Time `json:"uploaded_at"`
+}
+
+// FileField is a file field of an object with its validation rules, the rules of the field win over the ones of the
+// file data type
+type FileField struct {
+	FieldCode       string         `json:"field_code"`
+	ValidationRules map[string]any `json:"validation_rules"`
+}
+
+// FileRequest addresses the file field of a record
+type FileRequest struct {
+	TenantCode   string `json:"tenant_code"`
+	ProductCode  string `json:"product_code"`
+	ObjectCode   string `json:"object_code"`
+	RecordSerial string `json:"record_serial"`
+	FieldCode    string `json:"field_code"`
+	UserSerial   string `json:"-"`
+	UserRole     string `json:"-"`
+}
+
+// FileDisplayValue is the display value of a file field in list responses, the urls are relative to the api root and
+// Thumbnails is keyed by size for images only
+type FileDisplayValue struct {
+	FileName   string            `json:"file_name"`
+	MimeType   string            `json:"mime_type"`
+	Size       int64             `json:"size"`
+	URL        string            `json:"url"`
+	Thumbnails map[string]string `json:"thumbnails,omitempty"`
+}
+
+// String returns the file name, exports show a file field by its name
+func (v FileDisplayValue) String() string {
+	return v.FileName
+}
@@ -0,0 +1,93 @@
+package entity
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+const (
+	JobStatusQueued    = "queued"
+	JobStatusRunning   = "running"
+	JobStatusSucceeded = "succeeded"
+	JobStatusFailed    = "failed"
+	JobStatusCancelled = "cancelled"
+
+	JobTypeImportData     = "import_data"
+	JobTypeExportView     = "export_view"
+	JobTypeRefreshRollups = "refresh_rollups"
+)
+
+var (
+	ErrorJobTypeUnknown    = errors.New("job type has no handler")
+	ErrorJobCancelled      = errors.New("job was cancelled")
+	ErrorJobFinished       = errors.New("job is already finished")
+	ErrorJobPayloadInvalid = errors.New("job payload is invalid")
+	ErrorJobForbidden      = errors.New("job belongs to another user")
+)
+
+// Job is a long operation run by the workers of the job queue. A failed job is retried with backoff until it has
+// run MaxAttempts times. Progress goes from 0 to 100, ProgressMessage tells what the job is doing.
+type Job struct {
+	ID              int64           `json:"id"`
+	Serial          string          `json:"serial"`
+	TenantCode      string          `json:"tenant_code"`
+	JobType         string          `json:"job_type"`
+	Payload         json.RawMessage `json:"payload"`
+	Status          string          `json:"status"`
+	Progress        int             `json:"progress"`
+	ProgressMessage string          `json:"progress_message"`
+	Result          json.RawMessage `json:"result"`
+	Error           string          `json:"error"`
+	Attempts        int             `json:"attempts"`
+	MaxAttempts     int             `json:"max_attempts"`
+	CancelRequested bool            `json:"cancel_requested"`
+	RunAt           time.Time       `json:"run_at"`
+	CreatedBy       string          `json:"created_by"`
+	CreatedAt       time.Time       `json:"created_at"`
+	StartedAt       *time.Time      `json:"started_at"`
+	FinishedAt      *time.Time      `json:"finished_at"`
+}
+
+// IsFinished tells a job that will not run again
+func (j Job) IsFinished() bool {
+	return j.Status == JobStatusSucceeded || j.Status == JobStatusFailed || j.Status == JobStatusCancelled
+}
+
+type JobQuery struct {
+	TenantCode string `json:"tenant_code"`
+	JobType    string `json:"job_type"`
+	Status     string `json:"status"`
+	CreatedBy  string `json:"created_by"`
+	Page       int    `json:"page"`
+	PageSize   int    `json:"page_size"`
+}
+
+type JobResponse struct {
+	Page      int   `json:"page"`
+	PageSize  int   `json:"page_size"`
+	TotalData int   `json:"total_data"`
+	TotalPage int   `json:"total_page"`
+	Items     []Job `json:"items"`
+}
+
+// ImportDataPayload creates a record of ObjectCode per item of Records
+type ImportDataPayload struct {
+	ProductCode string           `json:"product_code"`
+	ObjectCode  string           `json:"object_code"`
+	Records     []map[string]any `json:"records"`
+}
+
+// ExportViewPayload writes the records of a view to the csv file Path, relative to the export directory of the tenant
+type ExportViewPayload struct {
+	ProductCode     string        `json:"product_code"`
+	ObjectCode      string        `json:"object_code"`
+	ViewContentCode string        `json:"view_content_code"`
+	Filters         []FilterGroup `json:"filters"`
+	Path            string        `json:"path"`
+}
+
+// RefreshRollupsPayload recomputes the materialized rollup fields of ObjectCode
+type RefreshRollupsPayload struct {
+	ObjectCode string `json:"object_code"`
+}
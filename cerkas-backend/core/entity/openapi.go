@@ -0,0 +1,13 @@
+package entity
+
+// OpenAPIRequest asks for the OpenAPI document of the objects of a tenant, with the paths of a product
+type OpenAPIRequest struct {
+	TenantCode  string `json:"tenant_code"`
+	ProductCode string `json:"product_code"`
+}
+
+// OpenAPIDocument is a generated OpenAPI document in json, its ETag changes with the content
+type OpenAPIDocument struct {
+	ETag    string
+	Content []byte
+}
@@ -0,0 +1,79 @@
+package entity
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	RuleTimingBefore = "before"
+	RuleTimingAfter  = "after"
+
+	RuleActionSetField     = "set_field"
+	RuleActionReject       = "reject"
+	RuleActionCreateRecord = "create_record"
+	RuleActionEmitEvent    = "emit_event"
+
+	// RuleOldValuePrefix reads a condition field from the record before the mutation, "old.status" for example
+	RuleOldValuePrefix = "old."
+
+	// RuleValueNow is replaced by the time a rule runs, other values starting with $ are replaced by the field
+	// of the record they name, "$serial" for example
+	RuleValueNow = "$now"
+
+	// RuleEventPrefix prefixes the type of the events emitted by rules
+	RuleEventPrefix = "rule."
+)
+
+var (
+	RuleTimings = []string{
+		RuleTimingBefore,
+		RuleTimingAfter,
+	}
+
+	RuleActionTypes = []string{
+		RuleActionSetField,
+		RuleActionReject,
+		RuleActionCreateRecord,
+		RuleActionEmitEvent,
+	}
+
+	ErrorRuleRejected      = errors.New("rejected by rule")
+	ErrorRuleRecursion     = errors.New("rules trigger each other too deep")
+	ErrorRuleTimingInvalid = errors.New("rule timing must be before or after")
+	ErrorRuleEventInvalid  = errors.New("rule event type must be one of create, update or delete")
+	ErrorRuleActionInvalid = errors.New("rule action is invalid")
+)
+
+// ObjectRule runs its actions when a mutation of EventTypes on an object matches its condition. Rules of the same
+// timing run by Sequence, then by creation.
+type ObjectRule struct {
+	ID         int64         `json:"id"`
+	Serial     string        `json:"serial"`
+	TenantCode string        `json:"tenant_code"`
+	ObjectCode string        `json:"object_code"`
+	Name       string        `json:"name"`
+	Timing     string        `json:"timing"`
+	EventTypes []string      `json:"event_types"`
+	Condition  []FilterGroup `json:"condition"`
+	Actions    []RuleAction  `json:"actions"`
+	Sequence   int           `json:"sequence"`
+	IsActive   bool          `json:"is_active"`
+	CreatedBy  string        `json:"created_by"`
+	CreatedAt  time.Time     `json:"created_at"`
+}
+
+// RuleAction is one step of a rule:
+//   - set_field sets FieldCode to Value
+//   - reject fails the mutation with Message
+//   - create_record creates a record of ObjectCode with Items
+//   - emit_event publishes an event named EventName with the record
+type RuleAction struct {
+	Type       string         `json:"type"`
+	FieldCode  string         `json:"field_code,omitempty"`
+	Value      any            `json:"value,omitempty"`
+	Message    string         `json:"message,omitempty"`
+	ObjectCode string         `json:"object_code,omitempty"`
+	Items      map[string]any `json:"items,omitempty"`
+	EventName  string         `json:"event_name,omitempty"`
+}
@@ -0,0 +1,97 @@
+package entity
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	ScheduledJobTypeExportView     = "export_view"
+	ScheduledJobTypePurgeDeleted   = "purge_deleted"
+	ScheduledJobTypeRefreshRollups = "refresh_rollups"
+	ScheduledJobTypeQuerySnapshot  = "query_snapshot"
+
+	ScheduledJobRunStatusRunning   = "running"
+	ScheduledJobRunStatusSucceeded = "succeeded"
+	ScheduledJobRunStatusFailed    = "failed"
+
+	// ScheduleTimestampPlaceholder in the path of an export is replaced by the time of the run
+	ScheduleTimestampPlaceholder = "{timestamp}"
+)
+
+var (
+	ScheduledJobTypes = []string{
+		ScheduledJobTypeExportView,
+		ScheduledJobTypePurgeDeleted,
+		ScheduledJobTypeRefreshRollups,
+		ScheduledJobTypeQuerySnapshot,
+	}
+
+	ErrorScheduledJobTypeInvalid   = errors.New("scheduled job type must be one of export_view, purge_deleted, refresh_rollups or query_snapshot")
+	ErrorScheduledJobCronInvalid   = errors.New("scheduled job cron expression is invalid")
+	ErrorScheduledJobConfigInvalid = errors.New("scheduled job config is invalid")
+)
+
+// ScheduledJob runs a built-in job of a tenant on a cron schedule. The cron expression is read in Timezone,
+// UTC when it is empty.
+type ScheduledJob struct {
+	ID             int64              `json:"id"`
+	Serial         string             `json:"serial"`
+	TenantCode     string             `json:"tenant_code"`
+	Name           string             `json:"name"`
+	JobType        string             `json:"job_type"`
+	CronExpression string             `json:"cron_expression"`
+	Timezone       string             `json:"timezone"`
+	Config         ScheduledJobConfig `json:"config"`
+	IsActive       bool               `json:"is_active"`
+	NextRunAt      time.Time          `json:"next_run_at"`
+	LastRunAt      *time.Time         `json:"last_run_at"`
+	CreatedBy      string             `json:"created_by"`
+	CreatedAt      time.Time          `json:"created_at"`
+}
+
+// ScheduledJobConfig holds the settings of every job type, each job type reads its own:
+//   - export_view writes the records of ViewContentCode to the csv file Path, relative to the export directory
+//   - purge_deleted removes the records soft deleted more than Days days ago
+//   - refresh_rollups recomputes the materialized rollup fields of the object
+//   - query_snapshot appends the result of RawQuery to TargetTable, stamped with the time of the run
+type ScheduledJobConfig struct {
+	ProductCode     string        `json:"product_code,omitempty"`
+	ObjectCode      string        `json:"object_code,omitempty"`
+	ViewContentCode string        `json:"view_content_code,omitempty"`
+	Filters         []FilterGroup `json:"filters,omitempty"`
+	Path            string        `json:"path,omitempty"`
+	Days            int           `json:"days,omitempty"`
+	RawQuery        string        `json:"raw_query,omitempty"`
+	TargetTable     string        `json:"target_table,omitempty"`
+}
+
+// ScheduledJobRun is a run of a scheduled job, Result summarizes what the run did
+type ScheduledJobRun struct {
+	ID         int64      `json:"id"`
+	Serial     string     `json:"serial"`
+	JobSerial  string     `json:"job_serial"`
+	TenantCode string     `json:"tenant_code"`
+	JobType    string     `json:"job_type"`
+	Status     string     `json:"status"`
+	Result     string     `json:"result"`
+	Error      string     `json:"error"`
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at"`
+}
+
+type ScheduledJobRunQuery struct {
+	TenantCode string `json:"tenant_code"`
+	JobSerial  string `json:"job_serial"`
+	Status     string `json:"status"`
+	Page       int    `json:"page"`
+	PageSize   int    `json:"page_size"`
+}
+
+type ScheduledJobRunResponse struct {
+	Page      int               `json:"page"`
+	PageSize  int               `json:"page_size"`
+	TotalData int               `json:"total_data"`
+	TotalPage int               `json:"total_page"`
+	Items     []ScheduledJobRun `json:"items"`
+}
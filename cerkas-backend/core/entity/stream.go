@@ -0,0 +1,22 @@
+package entity
+
+import "time"
+
+const (
+	StreamEventCreate = "create"
+	StreamEventUpdate = "update"
+	StreamEventDelete = "delete"
+	StreamEventPing   = "ping"
+
+	// StreamHeartbeatInterval keeps idle streams open through proxies
+	StreamHeartbeatInterval = 15 * time.Second
+)
+
+// RecordChange is a change of a record in a view, pushed to the view stream. Item has the shape of an item of
+// GetObjectData, a deleted record, or one that left the view, only has its serial.
+type RecordChange struct {
+	Event        string              `json:"event"`
+	RecordSerial string              `json:"record_serial"`
+	Item         map[string]DataItem `json:"item"`
+	OccurredAt   time.Time           `json:"occurred_at"`
+}
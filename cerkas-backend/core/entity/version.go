@@ -0,0 +1,36 @@
+package entity
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrorInvalidAsOf          = errors.New("as_of must be a RFC3339 timestamp or a date")
+	ErrorRestoreNeedsApproval = errors.New("a deleted record can not be restored while its update needs approval")
+)
+
+type RecordVersion struct {
+	ID           int64               `json:"id"`
+	Serial       string              `json:"serial"`
+	TenantCode   string              `json:"tenant_code"`
+	ProductCode  string              `json:"product_code"`
+	ObjectCode   string              `json:"object_code"`
+	RecordSerial string              `json:"record_serial"`
+	Version      int                 `json:"version"`
+	Action       string              `json:"action"`
+	ActorSerial  string              `json:"actor_serial"`
+	IsDeleted    bool                `json:"is_deleted"`
+	Data         map[string]DataItem `json:"data"`
+	CreatedAt    time.Time           `json:"created_at"`
+}
+
+type RestoreVersionRequest struct {
+	TenantCode   string `json:"tenant_code"`
+	ProductCode  string `json:"product_code"`
+	ObjectCode   string `json:"object_code"`
+	RecordSerial string `json:"record_serial"`
+	Version      int    `json:"version"`
+	UserSerial   string `json:"user_serial"`
+	UserRole     string `json:"user_role"`
+}
@@ -1,17 +1,74 @@
 package entity
 
+import (
+	"errors"
+	"time"
+)
+
+const (
+	PROPS       = "props"
+	FIELDS      = "fields"
+	CLASS_NAME  = "class_name"
+	CHILDREN    = "children"
+	TYPE        = "type"
+	DATA_SOURCE = "data_source"
+)
+
+// layout component types
+const (
+	LayoutWebView     = "webView"
+	LayoutMobileView  = "mobileView"
+	LayoutTable       = "table"
+	LayoutDetail      = "detail"
+	LayoutForm        = "form"
+	LayoutKanban      = "kanban"
+	LayoutCalendar    = "calendar"
+	LayoutChart       = "chart"
+	LayoutTabs        = "tabs"
+	LayoutSection     = "section"
+	LayoutRelatedList = "related-list"
+)
+
+// kinds of layout props, as named by json
+const (
+	PropString  = "string"
+	PropNumber  = "number"
+	PropBoolean = "boolean"
+	PropArray   = "array"
+	PropObject  = "object"
+)
+
+var (
+	ErrorViewCodeEmpty       = errors.New("view code is empty")
+	ErrorViewLayoutTypeEmpty = errors.New("view layout type is empty")
+	ErrorViewCodeExists      = errors.New("view code is already used by a view of the same layout type")
+	ErrorViewDefaultRequired = errors.New("a layout type needs a default view, make another view the default instead")
+	ErrorViewInUse           = errors.New("view schema or layout is still used by a view content")
+	ErrorViewFieldInvalid    = errors.New("view schema refers to a field the object does not have")
+	ErrorViewUserEmpty       = errors.New("a personal view needs a user")
+	ErrorViewForbidden       = errors.New("only an admin can change the views, schemas and layouts shared with every user")
+)
+
+// layers of a view, from the most specific to the global one. The settings of a layer override the ones of the layers below.
 const (
-	PROPS      = "props"
-	FIELDS     = "fields"
-	CLASS_NAME = "class_name"
+	ViewLayerUser          = "user"
+	ViewLayerTenantProduct = "tenant_product"
+	ViewLayerTenant        = "tenant"
+	ViewLayerProduct       = "product"
+	ViewLayerGlobal        = "global"
 )
 
+var ViewLayers = []string{ViewLayerUser, ViewLayerTenantProduct, ViewLayerTenant, ViewLayerProduct, ViewLayerGlobal}
+
 type GetViewContentByKeysRequest struct {
 	TenantCode      string `json:"tenant_code"`
 	ProductCode     string `json:"product_code"`
 	ObjectCode      string `json:"object_code"`
 	ViewContentCode string `json:"view_content_code"`
 	LayoutType      string `json:"layout_type"`
+	RecordSerial    string `json:"record_serial"`
+	UserSerial      string `json:"-"`
+	UserRole        string `json:"-"`
 }
 
 type ViewLayout struct {
@@ -53,3 +110,54 @@ type ViewContent struct {
 	IsDefault     bool       `json:"is_default"`
 	IsShownInList bool       `json:"is_shown_in_list"`
 }
+
+// ViewContentRecord is a view_content row as written by the view management endpoints
+type ViewContentRecord struct {
+	Serial           string     `json:"serial"`
+	Code             string     `json:"code"`
+	Name             string     `json:"name"`
+	TenantCode       string     `json:"tenant_code"`
+	ProductCode      string     `json:"product_code"`
+	ObjectCode       string     `json:"object_code"`
+	OwnerSerial      string     `json:"owner_serial"`
+	SharedRole       string     `json:"shared_role"`
+	ViewSchemaSerial string     `json:"view_schema_serial"`
+	ViewLayoutSerial string     `json:"view_layout_serial"`
+	LayoutType       string     `json:"layout_type"`
+	IsDefault        bool       `json:"is_default"`
+	IsShownInList    bool       `json:"is_shown_in_list"`
+	IsFavorite       bool       `json:"is_favorite"`
+	Layer            string     `json:"layer,omitempty"`
+	CreatedAt        *time.Time `json:"created_at,omitempty"`
+	UpdatedAt        *time.Time `json:"updated_at,omitempty"`
+}
+
+// CloneViewRequest names the copy of a view content, schema or layout
+type CloneViewRequest struct {
+	Code       string `json:"code"`
+	Name       string `json:"name"`
+	UserSerial string `json:"user_serial"`
+	UserRole   string `json:"-"`
+}
+
+// SavePersonalViewRequest saves the current filters, orders and columns of a list as a view owned by the user
+type SavePersonalViewRequest struct {
+	Code            string           `json:"code"`
+	Name            string           `json:"name"`
+	LayoutType      string           `json:"layout_type"`
+	ViewContentCode string           `json:"view_content_code"`
+	Filters         []FilterGroup    `json:"filters"`
+	Orders          []Order          `json:"orders"`
+	Fields          map[string]Field `json:"fields"`
+	SharedRole      string           `json:"shared_role"`
+	IsDefault       bool             `json:"is_default"`
+}
+
+// ViewResolution shows how a view is resolved along its layers. Sources names the layer each display field and
+// layout setting came from, keyed by its dotted path.
+type ViewResolution struct {
+	Layers       []ViewContentRecord `json:"layers"`
+	DisplayField map[string]any      `json:"display_field"`
+	LayoutConfig map[string]any      `json:"layout_config"`
+	Sources      map[string]string   `json:"sources"`
+}
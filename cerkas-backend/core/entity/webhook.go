@@ -0,0 +1,89 @@
+package entity
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	WebhookDeliveryStatusPending   = "pending"
+	WebhookDeliveryStatusRetrying  = "retrying"
+	WebhookDeliveryStatusDelivered = "delivered"
+	WebhookDeliveryStatusDead      = "dead"
+
+	WebhookSignatureHeader = "X-Cerkas-Signature"
+	WebhookTimestampHeader = "X-Cerkas-Timestamp"
+	WebhookEventHeader     = "X-Cerkas-Event"
+	WebhookDeliveryHeader  = "X-Cerkas-Delivery"
+)
+
+var (
+	WebhookEventTypes = []string{
+		AuditActionCreate,
+		AuditActionUpdate,
+		AuditActionDelete,
+	}
+
+	ErrorWebhookURLEmpty         = errors.New("webhook url is empty")
+	ErrorWebhookURLInvalid       = errors.New("webhook url must be an http or https url of a public host")
+	ErrorWebhookEventTypeInvalid = errors.New("webhook event type must be one of create, update, delete or an approval event")
+)
+
+type WebhookSubscription struct {
+	ID         int64         `json:"id"`
+	Serial     string        `json:"serial"`
+	TenantCode string        `json:"tenant_code"`
+	ObjectCode string        `json:"object_code"`
+	EventTypes []string      `json:"event_types"`
+	URL        string        `json:"url"`
+	Secret     string        `json:"secret,omitempty"`
+	Filters    []FilterGroup `json:"filters"`
+	IsActive   bool          `json:"is_active"`
+	CreatedBy  string        `json:"created_by"`
+	CreatedAt  time.Time     `json:"created_at"`
+}
+
+type WebhookPayload struct {
+	Event        string              `json:"event"`
+	TenantCode   string              `json:"tenant_code"`
+	ProductCode  string              `json:"product_code"`
+	ObjectCode   string              `json:"object_code"`
+	RecordSerial string              `json:"record_serial"`
+	ActorSerial  string              `json:"actor_serial"`
+	Data         map[string]DataItem `json:"data"`
+	OldData      map[string]DataItem `json:"old_data,omitempty"`
+	OccurredAt   time.Time           `json:"occurred_at"`
+}
+
+type WebhookDelivery struct {
+	ID                 int64      `json:"id"`
+	Serial             string     `json:"serial"`
+	EventSerial        string     `json:"event_serial"`
+	SubscriptionSerial string     `json:"subscription_serial"`
+	TenantCode         string     `json:"tenant_code"`
+	EventType          string     `json:"event_type"`
+	Payload            string     `json:"payload"`
+	Status             string     `json:"status"`
+	Attempts           int        `json:"attempts"`
+	NextAttemptAt      time.Time  `json:"next_attempt_at"`
+	LastError          string     `json:"last_error"`
+	ResponseStatus     int        `json:"response_status"`
+	DeliveredAt        *time.Time `json:"delivered_at"`
+	CreatedAt          time.Time  `json:"created_at"`
+}
+
+type WebhookDeliveryQuery struct {
+	TenantCode         string `json:"tenant_code"`
+	SubscriptionSerial string `json:"subscription_serial"`
+	Status             string `json:"status"`
+	Page               int    `json:"page"`
+	PageSize           int    `json:"page_size"`
+}
+
+type WebhookDeliveryResponse struct {
+	Page      int               `json:"page"`
+	PageSize  int               `json:"page_size"`
+	TotalData int               `json:"total_data"`
+	TotalPage int               `json:"total_page"`
+	Items     []WebhookDelivery `json:"items"`
+}
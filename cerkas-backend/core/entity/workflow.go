@@ -0,0 +1,58 @@
+package entity
+
+import (
+	"errors"
+	"time"
+)
+
+// StateAny in the from states of a transition matches every state
+const StateAny = "*"
+
+var (
+	ErrorTransitionInvalid       = errors.New("transition is not allowed by the state machine")
+	ErrorTransitionForbidden     = errors.New("transition is not allowed for your role")
+	ErrorTransitionFieldRequired = errors.New("transition needs fields that are empty")
+)
+
+// StateMachine is the lifecycle of the records of an object, kept in an option field. A new record starts in
+// InitialState when it is set, and the field then only changes through Transitions.
+type StateMachine struct {
+	InitialState string            `json:"initial_state"`
+	States       []string          `json:"states"`
+	Transitions  []StateTransition `json:"transitions"`
+}
+
+// StateTransition moves a record from one of From to To. Roles limits who may run it, RequiredFields must hold a
+// value once it ran.
+type StateTransition struct {
+	Code           string   `json:"code"`
+	Name           string   `json:"name"`
+	From           []string `json:"from"`
+	To             string   `json:"to"`
+	Roles          []string `json:"roles"`
+	RequiredFields []string `json:"required_fields"`
+}
+
+// AvailableTransition is a transition a user can run on a record, MissingFields are the required fields the record
+// does not hold yet and have to be sent with it
+type AvailableTransition struct {
+	FieldCode     string          `json:"field_code"`
+	CurrentState  string          `json:"current_state"`
+	Transition    StateTransition `json:"transition"`
+	MissingFields []string        `json:"missing_fields"`
+}
+
+// StateTransitionLog is a transition a record went through
+type StateTransitionLog struct {
+	ID           int64     `json:"id"`
+	Serial       string    `json:"serial"`
+	TenantCode   string    `json:"tenant_code"`
+	ObjectCode   string    `json:"object_code"`
+	RecordSerial string    `json:"record_serial"`
+	FieldCode    string    `json:"field_code"`
+	Transition   string    `json:"transition"`
+	FromState    string    `json:"from_state"`
+	ToState      string    `json:"to_state"`
+	ActorSerial  string    `json:"actor_serial"`
+	CreatedAt    time.Time `json:"created_at"`
+}
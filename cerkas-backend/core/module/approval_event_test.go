@@ -0,0 +1,68 @@
+package module
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cerkas/cerkas-backend/config"
+	"github.com/cerkas/cerkas-backend/core/entity"
+)
+
+func approvalEvent(eventType entity.RecordEventType) entity.RecordEvent {
+	return entity.RecordEvent{
+		Serial:       "8f0c7d0e-3a55-4d1b-9f44-0c7a1e2b3c4d",
+		Type:         eventType,
+		TenantCode:   "acme",
+		ProductCode:  "crm",
+		ObjectCode:   "invoice",
+		RecordSerial: "invoice-1",
+		ActorSerial:  "user-1",
+		OccurredAt:   time.Now(),
+	}
+}
+
+func TestApprovalEventsSkipTheAuditLog(t *testing.T) {
+	auditRepo := &fakeAuditRepository{}
+	uc := NewAuditUsecase(config.Config{}, auditRepo, nil)
+
+	for _, eventType := range entity.ApprovalEventTypes {
+		if err := uc.HandleRecordEvent(context.Background(), approvalEvent(entity.RecordEventType(eventType))); err != nil {
+			t.Fatalf("audit subscriber failed on %v: %v", eventType, err)
+		}
+	}
+
+	if len(auditRepo.logs) != 0 {
+		t.Fatalf("approval events must not be audited as record changes, got %v logs", len(auditRepo.logs))
+	}
+}
+
+func TestApprovalEventsAreDeliveredToWebhooks(t *testing.T) {
+	webhookRepo := &fakeWebhookRepository{
+		subscriptions: []entity.WebhookSubscription{{
+			Serial:     "subscription-1",
+			TenantCode: "acme",
+			ObjectCode: "invoice",
+			EventTypes: entity.ApprovalEventTypes,
+			URL:        "http://receiver.invalid",
+			IsActive:   true,
+		}},
+	}
+	uc := NewWebhookUsecase(config.Config{}, webhookRepo, nil)
+
+	for _, eventType := range entity.ApprovalEventTypes {
+		if err := uc.HandleRecordEvent(context.Background(), approvalEvent(entity.RecordEventType(eventType))); err != nil {
+			t.Fatalf("webhook subscriber failed on %v: %v", eventType, err)
+		}
+	}
+
+	if len(webhookRepo.deliveries) != len(entity.ApprovalEventTypes) {
+		t.Fatalf("expected %v deliveries, got %v", len(entity.ApprovalEventTypes), len(webhookRepo.deliveries))
+	}
+
+	for i, delivery := range webhookRepo.deliveries {
+		if delivery.EventType != entity.ApprovalEventTypes[i] {
+			t.Errorf("delivery %v has event type %v, want %v", i, delivery.EventType, entity.ApprovalEventTypes[i])
+		}
+	}
+}
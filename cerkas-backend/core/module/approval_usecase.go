@@ -0,0 +1,180 @@
+package module
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/cerkas/cerkas-backend/config"
+	"github.com/cerkas/cerkas-backend/core/entity"
+	"github.com/cerkas/cerkas-backend/core/repository"
+	"github.com/cerkas/cerkas-backend/pkg/helper"
+)
+
+type ApprovalUsecase interface {
+	CreatePolicy(ctx context.Context, request entity.ApprovalPolicy, userRole string) (resp entity.ApprovalPolicy, err error)
+	GetPolicies(ctx context.Context, tenantCode, objectCode string) (resp []entity.ApprovalPolicy, err error)
+	DeletePolicy(ctx context.Context, tenantCode, serial, userRole string) (err error)
+	GetChangeRequests(ctx context.Context, request entity.ChangeRequestQuery) (resp []entity.ChangeRequest, err error)
+	DecideChangeRequest(ctx context.Context, request entity.ChangeRequestDecision) (resp entity.ChangeRequest, err error)
+}
+
+type approvalUsecase struct {
+	cfg          config.Config
+	approvalRepo repository.ApprovalRepository
+	catalogUc    CatalogUsecase
+	transactor   repository.Transactor
+	eventBus     EventBus
+}
+
+func NewApprovalUsecase(cfg config.Config, approvalRepo repository.ApprovalRepository, catalogUc CatalogUsecase, transactor repository.Transactor, eventBus EventBus) ApprovalUsecase {
+	return &approvalUsecase{
+		cfg:          cfg,
+		approvalRepo: approvalRepo,
+		catalogUc:    catalogUc,
+		transactor:   transactor,
+		eventBus:     eventBus,
+	}
+}
+
+// CreatePolicy is kept to admins, like DeletePolicy, so the author of a held back change can not lift the policy
+func (uc *approvalUsecase) CreatePolicy(ctx context.Context, request entity.ApprovalPolicy, userRole string) (resp entity.ApprovalPolicy, err error) {
+	if !isAdmin(uc.cfg, userRole) {
+		return resp, entity.ErrorAdminRequired
+	}
+
+	if len(request.EventTypes) == 0 {
+		request.EventTypes = entity.WebhookEventTypes
+	}
+
+	for _, eventType := range request.EventTypes {
+		if !helper.Contains(entity.WebhookEventTypes, eventType) {
+			return resp, entity.ErrorApprovalEventInvalid
+		}
+	}
+
+	request.Serial, err = helper.GenerateUUUID()
+	if err != nil {
+		return resp, err
+	}
+
+	if request.Name == "" {
+		request.Name = request.Serial
+	}
+
+	request.IsActive = true
+	request.CreatedAt = time.Now()
+
+	return uc.approvalRepo.CreatePolicy(ctx, request)
+}
+
+func (uc *approvalUsecase) GetPolicies(ctx context.Context, tenantCode, objectCode string) (resp []entity.ApprovalPolicy, err error) {
+	return uc.approvalRepo.GetPolicies(ctx, tenantCode, objectCode)
+}
+
+func (uc *approvalUsecase) DeletePolicy(ctx context.Context, tenantCode, serial, userRole string) (err error) {
+	if !isAdmin(uc.cfg, userRole) {
+		return entity.ErrorAdminRequired
+	}
+
+	if serial == "" {
+		return entity.ErrorSerialEmpty
+	}
+
+	return uc.approvalRepo.DeletePolicy(ctx, tenantCode, serial)
+}
+
+func (uc *approvalUsecase) GetChangeRequests(ctx context.Context, request entity.ChangeRequestQuery) (resp []entity.ChangeRequest, err error) {
+	switch request.Status {
+	case "", entity.ChangeRequestStatusPending, entity.ChangeRequestStatusApproved, entity.ChangeRequestStatusRejected:
+	default:
+		return resp, entity.ErrorChangeRequestStatusInvalid
+	}
+
+	return uc.approvalRepo.GetChangeRequests(ctx, request)
+}
+
+// DecideChangeRequest approves or rejects a pending change request. An approved mutation is applied through the
+// catalog usecase as it was requested, by its requester, in the transaction of the decision.
+func (uc *approvalUsecase) DecideChangeRequest(ctx context.Context, request entity.ChangeRequestDecision) (resp entity.ChangeRequest, err error) {
+	if request.Serial == "" {
+		return resp, entity.ErrorSerialEmpty
+	}
+
+	err = uc.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		changeRequest, err := uc.approvalRepo.GetChangeRequestForUpdate(ctx, request.TenantCode, request.Serial)
+		if err != nil {
+			return err
+		}
+
+		if changeRequest.Status != entity.ChangeRequestStatusPending {
+			return fmt.Errorf("%w: %v", entity.ErrorChangeRequestDecided, changeRequest.Status)
+		}
+
+		policy, err := uc.approvalRepo.GetPolicy(ctx, changeRequest.PolicySerial)
+		if err != nil {
+			return err
+		}
+
+		// the point of an approval is a second, known person
+		if request.UserSerial == "" {
+			return fmt.Errorf("%w: it needs a signed in user", entity.ErrorApprovalForbidden)
+		}
+
+		if request.UserSerial == changeRequest.RequestedBy {
+			return fmt.Errorf("%w: it was requested by you", entity.ErrorApprovalForbidden)
+		}
+
+		if len(policy.ApproverRoles) > 0 && !helper.Contains(policy.ApproverRoles, request.UserRole) {
+			return fmt.Errorf("%w: it needs one of the roles %v", entity.ErrorApprovalForbidden, policy.ApproverRoles)
+		}
+
+		now := time.Now()
+		changeRequest.DecidedBy = request.UserSerial
+		changeRequest.DecidedAt = &now
+		changeRequest.Comment = request.Comment
+
+		eventType := entity.ApprovalRejected
+		changeRequest.Status = entity.ChangeRequestStatusRejected
+
+		if request.Approve {
+			eventType = entity.ApprovalApproved
+			changeRequest.Status = entity.ChangeRequestStatusApproved
+
+			if err := uc.applyChangeRequest(ctx, changeRequest); err != nil {
+				return err
+			}
+		}
+
+		if err := uc.approvalRepo.UpdateChangeRequest(ctx, changeRequest); err != nil {
+			return err
+		}
+
+		resp = changeRequest
+
+		return uc.eventBus.Publish(ctx, changeRequestEvent(eventType, changeRequest, request.UserSerial))
+	})
+
+	return resp, err
+}
+
+// applyChangeRequest runs the mutation of an approved change request through the normal mutation path
+func (uc *approvalUsecase) applyChangeRequest(ctx context.Context, changeRequest entity.ChangeRequest) (err error) {
+	ctx = context.WithValue(ctx, approvedMutationKey{}, true)
+
+	mutation := changeRequest.Mutation
+	mutation.UserRole = changeRequest.RequesterRole
+
+	switch changeRequest.Action {
+	case entity.AuditActionCreate:
+		_, err = uc.catalogUc.CreateObjectData(ctx, mutation)
+	case entity.AuditActionUpdate:
+		_, err = uc.catalogUc.UpdateObjectData(ctx, mutation)
+	case entity.AuditActionDelete:
+		err = uc.catalogUc.DeleteObjectData(ctx, mutation)
+	default:
+		err = fmt.Errorf("unknown change request action %v", changeRequest.Action)
+	}
+
+	return err
+}
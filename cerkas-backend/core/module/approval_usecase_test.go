@@ -0,0 +1,47 @@
+package module
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/cerkas/cerkas-backend/config"
+	"github.com/cerkas/cerkas-backend/core/entity"
+)
+
+func TestApprovalPoliciesAreKeptToAdmins(t *testing.T) {
+	approvalRepo := &fakeApprovalRepository{}
+	uc := NewApprovalUsecase(config.Config{AdminRole: "admin"}, approvalRepo, nil, nil, nil)
+	policy := entity.ApprovalPolicy{TenantCode: "acme", ObjectCode: "invoice", CreatedBy: "user-1"}
+
+	for _, userRole := range []string{"", "sales"} {
+		if _, err := uc.CreatePolicy(context.Background(), policy, userRole); !errors.Is(err, entity.ErrorAdminRequired) {
+			t.Errorf("role %q created a policy, err %v", userRole, err)
+		}
+	}
+
+	created, err := uc.CreatePolicy(context.Background(), policy, "admin")
+	if err != nil {
+		t.Fatalf("admin could not create a policy: %v", err)
+	}
+
+	for _, userRole := range []string{"", "sales"} {
+		if err := uc.DeletePolicy(context.Background(), "acme", created.Serial, userRole); !errors.Is(err, entity.ErrorAdminRequired) {
+			t.Errorf("role %q deleted a policy, err %v", userRole, err)
+		}
+	}
+
+	if err := uc.DeletePolicy(context.Background(), "acme", created.Serial, "admin"); err != nil {
+		t.Fatalf("admin could not delete the policy: %v", err)
+	}
+
+	if len(approvalRepo.policies) != 0 {
+		t.Fatalf("policy is still stored after it was deleted")
+	}
+}
+
+func TestAdminRoleMustBeSet(t *testing.T) {
+	if isAdmin(config.Config{}, "") {
+		t.Fatalf("an anonymous request is an admin when ADMIN_ROLE is empty")
+	}
+}
@@ -0,0 +1,166 @@
+package module
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/cerkas/cerkas-backend/config"
+	"github.com/cerkas/cerkas-backend/core/entity"
+	"github.com/cerkas/cerkas-backend/core/repository"
+	"github.com/cerkas/cerkas-backend/pkg/helper"
+)
+
+type AuditUsecase interface {
+	HandleRecordEvent(ctx context.Context, event entity.RecordEvent) (err error)
+	GetRecordHistory(ctx context.Context, request entity.CatalogQuery) (resp []entity.AuditLog, err error)
+}
+
+type auditUsecase struct {
+	cfg         config.Config
+	auditRepo   repository.AuditRepository
+	catalogRepo repository.CatalogRepository
+}
+
+func NewAuditUsecase(cfg config.Config, auditRepo repository.AuditRepository, catalogRepo repository.CatalogRepository) AuditUsecase {
+	return &auditUsecase{
+		cfg:         cfg,
+		auditRepo:   auditRepo,
+		catalogRepo: catalogRepo,
+	}
+}
+
+// HandleRecordEvent appends a mutation with its field level diff to the audit log.
+// The audit log serial is the event serial, a redelivered event is only logged once.
+func (uc *auditUsecase) HandleRecordEvent(ctx context.Context, event entity.RecordEvent) (err error) {
+	// events emitted by rules and approvals do not change the record
+	if !event.IsRecordChange() {
+		return nil
+	}
+
+	return uc.auditRepo.CreateAuditLog(ctx, entity.AuditLog{
+		Serial:       event.Serial,
+		TenantCode:   event.TenantCode,
+		ProductCode:  event.ProductCode,
+		ObjectCode:   event.ObjectCode,
+		RecordSerial: event.RecordSerial,
+		Action:       event.Action(),
+		ActorSerial:  event.ActorSerial,
+		Changes:      diffDataItems(event.Action(), event.Items, event.OldData),
+		CreatedAt:    event.OccurredAt,
+	})
+}
+
+func (uc *auditUsecase) GetRecordHistory(ctx context.Context, request entity.CatalogQuery) (resp []entity.AuditLog, err error) {
+	if request.Serial == "" {
+		return resp, entity.ErrorSerialEmpty
+	}
+
+	// audit logs are stored by serial
+	recordSerial, err := resolveRecordSerial(ctx, uc.catalogRepo, request)
+	if err != nil {
+		return resp, err
+	}
+
+	resp, err = uc.auditRepo.GetAuditLogsByRecord(ctx, request.TenantCode, request.ObjectCode, recordSerial)
+	if err != nil {
+		return resp, err
+	}
+
+	// resolve display names from object fields
+	displayNames := map[string]string{}
+
+	object, _ := uc.catalogRepo.GetObjectByCode(ctx, request.ObjectCode, request.TenantCode)
+	if object.Serial != "" {
+		request.ObjectSerial = object.Serial
+
+		objectFields, err := uc.catalogRepo.GetObjectFieldsByObjectCode(ctx, request)
+		if err != nil {
+			return resp, err
+		}
+
+		for fieldCode, field := range objectFields {
+			if data, ok := field.(entity.ObjectFields); ok && data.DisplayName != "" {
+				displayNames[fieldCode] = data.DisplayName
+			}
+		}
+	}
+
+	for i, auditLog := range resp {
+		for j, change := range auditLog.Changes {
+			change.FieldName = helper.CapitalizeWords(helper.ReplaceUnderscoreWithSpace(change.FieldCode))
+			if displayName, ok := displayNames[change.FieldCode]; ok {
+				change.FieldName = displayName
+			}
+
+			resp[i].Changes[j] = change
+		}
+	}
+
+	return resp, nil
+}
+
+// mutationRecordSerial returns the serial of a mutated record, preferring the real serial over a code used as identifier
+func mutationRecordSerial(request entity.DataMutationRequest, oldItems map[string]entity.DataItem) string {
+	if oldSerial, ok := oldItems["serial"]; ok && oldSerial.Value != nil {
+		return fmt.Sprintf("%v", normalizeValue(oldSerial.Value))
+	}
+
+	return request.Serial
+}
+
+// diffDataItems returns the changed fields of a mutation
+func diffDataItems(action string, newItems []entity.DataItem, oldItems map[string]entity.DataItem) []entity.AuditChange {
+	changes := []entity.AuditChange{}
+
+	switch action {
+	case entity.AuditActionDelete:
+		for fieldCode, item := range oldItems {
+			changes = append(changes, entity.AuditChange{
+				FieldCode: fieldCode,
+				OldValue:  normalizeValue(item.Value),
+			})
+		}
+	default:
+		for _, item := range newItems {
+			var oldValue any
+			if oldItem, ok := oldItems[item.FieldCode]; ok {
+				oldValue = normalizeValue(oldItem.Value)
+			}
+
+			newValue := normalizeValue(item.Value)
+			if action == entity.AuditActionUpdate && isSameValue(oldValue, newValue) {
+				continue
+			}
+
+			changes = append(changes, entity.AuditChange{
+				FieldCode: item.FieldCode,
+				OldValue:  oldValue,
+				NewValue:  newValue,
+			})
+		}
+	}
+
+	return changes
+}
+
+// normalizeValue converts raw database values into json friendly values
+func normalizeValue(value any) any {
+	switch v := value.(type) {
+	case []byte:
+		return string(v)
+	case time.Time:
+		return v.Format(time.RFC3339)
+	default:
+		return v
+	}
+}
+
+// isSameValue compares values loosely, the database and the request rarely agree on the go type
+func isSameValue(oldValue, newValue any) bool {
+	if oldValue == nil || newValue == nil {
+		return oldValue == nil && newValue == nil
+	}
+
+	return fmt.Sprintf("%v", oldValue) == fmt.Sprintf("%v", newValue)
+}
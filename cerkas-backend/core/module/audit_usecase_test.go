@@ -0,0 +1,32 @@
+package module
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cerkas/cerkas-backend/config"
+	"github.com/cerkas/cerkas-backend/core/entity"
+)
+
+func TestGetRecordHistoryResolvesTheCode(t *testing.T) {
+	serial := "6f1c2a8e-0b5d-4c1e-9a4f-3d2b1c0e9f87"
+	auditRepo := &fakeAuditRepository{logs: []entity.AuditLog{
+		{ObjectCode: "invoice", RecordSerial: serial, Action: entity.AuditActionCreate},
+		{ObjectCode: "invoice", RecordSerial: "another", Action: entity.AuditActionCreate},
+	}}
+	catalogRepo := &fakeCatalogRepository{records: map[string][]map[string]any{
+		"invoice": {{"serial": serial, "code": "INV-001"}},
+	}}
+	uc := NewAuditUsecase(config.Config{}, auditRepo, catalogRepo)
+
+	for _, recordSerial := range []string{serial, "INV-001"} {
+		history, err := uc.GetRecordHistory(context.Background(), entity.CatalogQuery{TenantCode: "acme", ObjectCode: "invoice", Serial: recordSerial})
+		if err != nil {
+			t.Fatalf("GetRecordHistory(%v): %v", recordSerial, err)
+		}
+
+		if len(history) != 1 || history[0].RecordSerial != serial {
+			t.Errorf("history of %v is %+v, want the log of %v", recordSerial, history, serial)
+		}
+	}
+}
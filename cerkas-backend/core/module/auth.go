@@ -0,0 +1,8 @@
+package module
+
+import "github.com/cerkas/cerkas-backend/config"
+
+// isAdmin reports whether a role may change what applies to every user of a tenant, an anonymous request never may
+func isAdmin(cfg config.Config, userRole string) bool {
+	return userRole != "" && userRole == cfg.AdminRole
+}
@@ -0,0 +1,110 @@
+package module
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/cerkas/cerkas-backend/core/entity"
+	"github.com/cerkas/cerkas-backend/pkg/helper"
+)
+
+// approvedMutationKey marks the context of a mutation applied on approval, it is not held back again
+type approvedMutationKey struct{}
+
+// ApprovalPendingError is returned instead of applying a mutation held back by an approval policy, the mutation is
+// stored in ChangeRequest until it is approved
+type ApprovalPendingError struct {
+	ChangeRequest entity.ChangeRequest
+}
+
+func (e *ApprovalPendingError) Error() string {
+	return fmt.Sprintf("the change needs approval, change request %v is pending", e.ChangeRequest.Serial)
+}
+
+// isApprovalPending reports whether err holds a mutation back for approval. A mutation caused by a rule that is held
+// back does not fail the mutation that triggered the rule, its change request is committed with it.
+func isApprovalPending(err error) bool {
+	var pending *ApprovalPendingError
+	return errors.As(err, &pending)
+}
+
+// holdForApproval stores a change request when an approval policy matches a mutation. Only mutations applied on
+// approval, with the mutations their rules cause, are never held back.
+func (uc *catalogUsecase) holdForApproval(ctx context.Context, action string, request entity.DataMutationRequest, oldItems map[string]entity.DataItem) (resp *entity.ChangeRequest, err error) {
+	if approved, _ := ctx.Value(approvedMutationKey{}).(bool); approved {
+		return nil, nil
+	}
+
+	policies, err := uc.approvalRepo.GetActivePolicies(ctx, request.TenantCode, request.ObjectCode, action)
+	if err != nil || len(policies) == 0 {
+		return nil, err
+	}
+
+	values := ruleValues(mutationRecord(action, request, oldItems), oldItems)
+	for _, policy := range policies {
+		if !matchFilters(policy.Condition, values) {
+			continue
+		}
+
+		changeRequest := entity.ChangeRequest{
+			TenantCode:    request.TenantCode,
+			ProductCode:   request.ProductCode,
+			ObjectCode:    request.ObjectCode,
+			RecordSerial:  mutationRecordSerial(request, oldItems),
+			PolicySerial:  policy.Serial,
+			Action:        action,
+			Mutation:      request,
+			Status:        entity.ChangeRequestStatusPending,
+			RequestedBy:   request.UserSerial,
+			RequesterRole: request.UserRole,
+			RequestedAt:   time.Now(),
+		}
+
+		changeRequest.Serial, err = helper.GenerateUUUID()
+		if err != nil {
+			return nil, err
+		}
+
+		changeRequest, err = uc.approvalRepo.CreateChangeRequest(ctx, changeRequest)
+		if err != nil {
+			return nil, err
+		}
+
+		if err = uc.eventBus.Publish(ctx, changeRequestEvent(entity.ApprovalRequested, changeRequest, request.UserSerial)); err != nil {
+			return nil, err
+		}
+
+		return &changeRequest, nil
+	}
+
+	return nil, nil
+}
+
+// changeRequestEvent builds the event telling the requester and the approvers about a change request
+func changeRequestEvent(eventType entity.RecordEventType, changeRequest entity.ChangeRequest, actorSerial string) entity.RecordEvent {
+	data := map[string]entity.DataItem{}
+	for fieldCode, value := range map[string]any{
+		"change_request_serial": changeRequest.Serial,
+		"action":                changeRequest.Action,
+		"status":                changeRequest.Status,
+		"requested_by":          changeRequest.RequestedBy,
+		"decided_by":            changeRequest.DecidedBy,
+		"comment":               changeRequest.Comment,
+	} {
+		data[fieldCode] = entity.DataItem{FieldCode: fieldCode, Value: value}
+	}
+
+	return entity.RecordEvent{
+		Type:         eventType,
+		TenantCode:   changeRequest.TenantCode,
+		ProductCode:  changeRequest.ProductCode,
+		ObjectCode:   changeRequest.ObjectCode,
+		RecordSerial: changeRequest.RecordSerial,
+		ActorSerial:  actorSerial,
+		Items:        changeRequest.Mutation.Items,
+		Data:         data,
+		OccurredAt:   time.Now(),
+	}
+}
@@ -0,0 +1,88 @@
+package module
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/cerkas/cerkas-backend/config"
+	"github.com/cerkas/cerkas-backend/core/entity"
+)
+
+func newIncludeTestUsecase() *catalogUsecase {
+	catalogRepo := &fakeCatalogRepository{
+		records: map[string][]map[string]any{
+			"invoice_line": {
+				{"serial": "l1", "invoice": "i1", "product": "pen"},
+				{"serial": "l2", "invoice": "i2", "product": "ink"},
+				{"serial": "l3", "invoice": "i1", "product": "pad"},
+				{"serial": "l4", "invoice": "i1", "product": "cap"},
+			},
+			"payment": {
+				{"serial": "p1", "invoice": "i1", "refund_of": nil},
+			},
+		},
+		relations: map[string][]entity.ReverseRelation{"invoice": {
+			{ObjectCode: "invoice_line", RelationField: "invoice", ReferencedField: "serial"},
+			{ObjectCode: "payment", RelationField: "invoice", ReferencedField: "serial"},
+			{ObjectCode: "payment", RelationField: "refund_of", ReferencedField: "serial"},
+		}},
+	}
+
+	return NewCatalogUsecase(config.Config{}, catalogRepo, nil, nil, nil, nil, nil, nil, nil).(*catalogUsecase)
+}
+
+func TestEmbedIncludesAddsTheChildrenOfTheRecord(t *testing.T) {
+	uc := newIncludeTestUsecase()
+	record := map[string]entity.DataItem{"serial": {FieldCode: "serial", Value: "i1"}}
+	request := entity.CatalogQuery{ObjectCode: "invoice", Includes: []entity.Include{
+		{Name: "lines", ObjectCode: "invoice_line", Limit: 2},
+		{ObjectCode: "payment", RelationField: "invoice"},
+	}}
+
+	if err := uc.embedIncludes(context.Background(), request, record); err != nil {
+		t.Fatalf("embedIncludes: %v", err)
+	}
+
+	lines, ok := record["lines"].Value.(entity.CatalogResponse)
+	if !ok || record["lines"].DataType != dataTypeCollection {
+		t.Fatalf("lines is %+v, want a collection", record["lines"])
+	}
+
+	if lines.TotalData != 3 || len(lines.Items) != 2 {
+		t.Errorf("lines hold %v of %v children, want the first 2 of 3", len(lines.Items), lines.TotalData)
+	}
+
+	for _, item := range lines.Items {
+		if item["invoice"].Value != "i1" {
+			t.Errorf("line %v belongs to %v", item["serial"].Value, item["invoice"].Value)
+		}
+	}
+
+	// an include is named after its object unless it has a name
+	if payments, ok := record["payment"].Value.(entity.CatalogResponse); !ok || payments.TotalData != 1 {
+		t.Errorf("payment is %+v, want the one payment of i1", record["payment"])
+	}
+}
+
+func TestEmbedIncludesRejectsUnclearIncludes(t *testing.T) {
+	tests := []struct {
+		name    string
+		include entity.Include
+		want    error
+	}{
+		{"unknown object", entity.Include{ObjectCode: "shipment"}, entity.ErrorIncludeNotFound},
+		{"object referring twice", entity.Include{ObjectCode: "payment"}, entity.ErrorIncludeAmbiguous},
+		{"name of a field", entity.Include{Name: "serial", ObjectCode: "invoice_line"}, entity.ErrorIncludeAmbiguous},
+	}
+
+	for _, test := range tests {
+		uc := newIncludeTestUsecase()
+		record := map[string]entity.DataItem{"serial": {FieldCode: "serial", Value: "i1"}}
+		request := entity.CatalogQuery{ObjectCode: "invoice", Includes: []entity.Include{test.include}}
+
+		if err := uc.embedIncludes(context.Background(), request, record); !errors.Is(err, test.want) {
+			t.Errorf("%v: embedIncludes = %v, want %v", test.name, err, test.want)
+		}
+	}
+}
@@ -0,0 +1,312 @@
+package module
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/cerkas/cerkas-backend/core/entity"
+)
+
+const (
+	// defaultLookupLimit is the number of options a lookup returns unless asked otherwise
+	defaultLookupLimit = 20
+
+	// maxLookupLimit bounds the options of a lookup, a picker is not meant to list a whole object
+	maxLookupLimit = 100
+)
+
+// LookupFieldValues searches the records a reference field can point to. The referenced object comes from the object
+// field settings, or from the foreign key of the column when the object field has no target.
+func (uc *catalogUsecase) LookupFieldValues(ctx context.Context, request entity.LookupQuery) (resp []entity.LookupOption, err error) {
+	lookup, err := uc.getReferenceLookup(ctx, entity.CatalogQuery{
+		TenantCode:  request.TenantCode,
+		ProductCode: request.ProductCode,
+		ObjectCode:  request.ObjectCode,
+	}, request.FieldCode)
+	if err != nil {
+		return resp, err
+	}
+
+	targetQuery, columnCodes := lookup.query, lookup.columnCodes
+	valueField, displayField := lookup.valueField, lookup.displayField
+
+	filterGroup := entity.FilterGroup{
+		Operator: entity.FilterOperator(entity.FilterOperatorAnd),
+		Filters:  map[string]entity.FilterItem{},
+	}
+
+	if request.Search != "" {
+		filterGroup.Filters[displayField] = entity.FilterItem{
+			FieldName: displayField,
+			Operator:  entity.FilterOperatorContains,
+			Value:     request.Search,
+		}
+	}
+
+	// dependent fields narrow the options, a city lookup filtered by the selected province for example
+	for fieldCode, value := range request.Filters {
+		if !columnCodes[fieldCode] {
+			return resp, fmt.Errorf("%w: %v", entity.ErrorLookupFilterInvalid, fieldCode)
+		}
+
+		filterGroup.Filters[fieldCode] = entity.FilterItem{
+			FieldName: fieldCode,
+			Operator:  entity.FilterOperatorEqual,
+			Value:     value,
+		}
+	}
+
+	if len(filterGroup.Filters) > 0 {
+		targetQuery.Filters = []entity.FilterGroup{filterGroup}
+	}
+
+	targetQuery.Fields = map[string]entity.Field{
+		valueField:   {FieldCode: valueField},
+		displayField: {FieldCode: displayField},
+	}
+	targetQuery.Orders = []entity.Order{{FieldName: displayField, Direction: "asc"}}
+	targetQuery.Page = 1
+	targetQuery.PageSize = min(max(request.Limit, 0), maxLookupLimit)
+	if targetQuery.PageSize == 0 {
+		targetQuery.PageSize = defaultLookupLimit
+	}
+
+	results, err := uc.catalogRepo.GetObjectData(ctx, targetQuery)
+	if err != nil {
+		return resp, err
+	}
+
+	resp = []entity.LookupOption{}
+	for _, item := range results.Items {
+		resp = append(resp, entity.LookupOption{
+			Serial:       item[valueField].Value,
+			DisplayValue: item[displayField].Value,
+		})
+	}
+
+	return resp, nil
+}
+
+// referenceLookup is the object a reference field points to, with the field it refers to and the field naming its
+// records
+type referenceLookup struct {
+	query        entity.CatalogQuery
+	columnCodes  map[string]bool
+	valueField   string
+	displayField string
+}
+
+// getReferenceLookup resolves the object a reference field of the request object points to
+func (uc *catalogUsecase) getReferenceLookup(ctx context.Context, request entity.CatalogQuery, fieldCode string) (resp referenceLookup, err error) {
+	target, err := uc.catalogRepo.GetReferenceTarget(ctx, request.TenantCode, request.ObjectCode, fieldCode)
+	if err != nil {
+		return resp, err
+	}
+
+	if target.ForeignTable == "" {
+		target, err = uc.catalogRepo.GetForeignKeyInfo(ctx, request.ObjectCode, fieldCode, request.TenantCode)
+		if err != nil {
+			return resp, err
+		}
+	}
+
+	if target.ForeignTable == "" {
+		return resp, entity.ErrorLookupFieldInvalid
+	}
+
+	resp.valueField = target.ForeignColumn
+	if resp.valueField == "" {
+		resp.valueField = "serial"
+	}
+
+	resp.query = entity.CatalogQuery{
+		TenantCode:  request.TenantCode,
+		ProductCode: request.ProductCode,
+		ObjectCode:  target.ForeignTable,
+	}
+
+	columns, _, _, _, err := uc.catalogRepo.GetColumnList(ctx, resp.query)
+	if err != nil {
+		return resp, err
+	}
+
+	resp.columnCodes = map[string]bool{}
+	for _, column := range columns {
+		if columnCode, ok := column[entity.FieldColumnCode].(string); ok {
+			resp.columnCodes[columnCode] = true
+		}
+	}
+
+	resp.displayField, err = uc.lookupDisplayField(ctx, resp.query, resp.columnCodes, resp.valueField)
+	if err != nil {
+		return resp, err
+	}
+
+	return resp, nil
+}
+
+// ResolveDisplayValues sets the display value of the reference fields of records of the request object to the display
+// name of the record they refer to. The referenced records of a target object are read with a single query.
+func (uc *catalogUsecase) ResolveDisplayValues(ctx context.Context, request entity.CatalogQuery, records []map[string]entity.DataItem) (err error) {
+	if len(records) == 0 {
+		return nil
+	}
+
+	referenceFields, err := uc.referenceFields(ctx, request)
+	if err != nil {
+		return err
+	}
+
+	// reference fields pointing at the same field of the same object share their lookup
+	lookups := map[string]referenceLookup{}
+	lookupFields := map[string][]string{}
+	lookupValues := map[string]map[string]bool{}
+	for _, fieldCode := range referenceFields {
+		lookup, err := uc.getReferenceLookup(ctx, request, fieldCode)
+		if errors.Is(err, entity.ErrorLookupFieldInvalid) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		if lookup.displayField == lookup.valueField {
+			continue
+		}
+
+		key := lookup.query.ObjectCode + "." + lookup.valueField
+		if _, ok := lookups[key]; !ok {
+			lookups[key] = lookup
+			lookupValues[key] = map[string]bool{}
+		}
+		lookupFields[key] = append(lookupFields[key], fieldCode)
+
+		for _, record := range records {
+			if item, ok := record[fieldCode]; ok && item.Value != nil {
+				lookupValues[key][fmt.Sprintf("%v", normalizeValue(item.Value))] = true
+			}
+		}
+	}
+
+	for _, key := range sortedKeys(lookups) {
+		if len(lookupValues[key]) == 0 {
+			continue
+		}
+
+		displayValues, err := uc.referenceDisplayValues(ctx, lookups[key], sortedKeys(lookupValues[key]))
+		if err != nil {
+			return err
+		}
+
+		for _, fieldCode := range lookupFields[key] {
+			for _, record := range records {
+				item, ok := record[fieldCode]
+				if !ok || item.Value == nil {
+					continue
+				}
+
+				if displayValue := displayValues[fmt.Sprintf("%v", normalizeValue(item.Value))]; displayValue != nil {
+					item.DisplayValue = displayValue
+					record[fieldCode] = item
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// referenceFields lists the fields of an object referring to another object, by object field or by foreign key
+func (uc *catalogUsecase) referenceFields(ctx context.Context, request entity.CatalogQuery) (resp []string, err error) {
+	fieldCodes := map[string]bool{}
+
+	object, _ := uc.catalogRepo.GetObjectByCode(ctx, request.ObjectCode, request.TenantCode)
+	if object.Serial != "" {
+		request.ObjectSerial = object.Serial
+
+		objectFields, err := uc.catalogRepo.GetObjectFieldsByObjectCode(ctx, request)
+		if err != nil {
+			return resp, err
+		}
+
+		for fieldCode, value := range objectFields {
+			if objectField, ok := value.(entity.ObjectFields); ok && objectField.TargetObject.Serial != "" {
+				fieldCodes[fieldCode] = true
+			}
+		}
+	}
+
+	columns, _, _, _, err := uc.catalogRepo.GetColumnList(ctx, entity.CatalogQuery{TenantCode: request.TenantCode, ObjectCode: request.ObjectCode})
+	if err != nil {
+		return resp, err
+	}
+
+	for _, column := range columns {
+		if foreignTable, _ := column[entity.FieldForeignTableName].(string); foreignTable != "" {
+			fieldCodes[column[entity.FieldColumnCode].(string)] = true
+		}
+	}
+
+	return sortedKeys(fieldCodes), nil
+}
+
+// referenceDisplayValues reads the display names of the referenced records keyed by the value referring to them,
+// records that are gone are left out
+func (uc *catalogUsecase) referenceDisplayValues(ctx context.Context, lookup referenceLookup, values []string) (map[string]any, error) {
+	query := lookup.query
+	query.Fields = map[string]entity.Field{
+		lookup.valueField:   {FieldCode: lookup.valueField},
+		lookup.displayField: {FieldCode: lookup.displayField},
+	}
+	query.Filters = []entity.FilterGroup{{
+		Operator: entity.FilterOperator(entity.FilterOperatorAnd),
+		Filters: map[string]entity.FilterItem{
+			lookup.valueField: {
+				FieldName: lookup.valueField,
+				Operator:  entity.FilterOperatorIn,
+				Value:     values,
+			},
+		},
+	}}
+	query.Page = 1
+	query.PageSize = len(values)
+
+	results, err := uc.catalogRepo.GetObjectData(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := map[string]any{}
+	for _, item := range results.Items {
+		resp[fmt.Sprintf("%v", normalizeValue(item[lookup.valueField].Value))] = item[lookup.displayField].Value
+	}
+
+	return resp, nil
+}
+
+// lookupDisplayField returns the display name field of the referenced object, falling back to its name column and
+// then to the referenced field itself
+func (uc *catalogUsecase) lookupDisplayField(ctx context.Context, targetQuery entity.CatalogQuery, columnCodes map[string]bool, valueField string) (string, error) {
+	object, _ := uc.catalogRepo.GetObjectByCode(ctx, targetQuery.ObjectCode, targetQuery.TenantCode)
+	if object.Serial != "" {
+		targetQuery.ObjectSerial = object.Serial
+
+		objectFields, err := uc.catalogRepo.GetObjectFieldsByObjectCode(ctx, targetQuery)
+		if err != nil {
+			return "", err
+		}
+
+		for _, fieldCode := range sortedKeys(objectFields) {
+			if objectField, ok := objectFields[fieldCode].(entity.ObjectFields); ok && objectField.IsDisplayName && columnCodes[fieldCode] {
+				return fieldCode, nil
+			}
+		}
+	}
+
+	if columnCodes["name"] {
+		return "name", nil
+	}
+
+	return valueField, nil
+}
@@ -0,0 +1,70 @@
+package module
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cerkas/cerkas-backend/config"
+	"github.com/cerkas/cerkas-backend/core/entity"
+)
+
+func TestResolveDisplayValuesReadsEachTargetOnce(t *testing.T) {
+	catalogRepo := &fakeCatalogRepository{
+		columns: map[string][]string{
+			"invoice":  {"serial", "customer", "approver", "creator"},
+			"customer": {"serial", "name"},
+			"user":     {"serial", "name"},
+		},
+		references: map[string]entity.ForeignKeyInfo{
+			"invoice.customer": {ForeignTable: "customer", ForeignColumn: "serial"},
+			"invoice.approver": {ForeignTable: "user", ForeignColumn: "serial"},
+			"invoice.creator":  {ForeignTable: "user", ForeignColumn: "serial"},
+		},
+		records: map[string][]map[string]any{
+			"customer": {
+				{"serial": "c-1", "name": "Acme"},
+				{"serial": "c-2", "name": "Globex"},
+			},
+			"user": {
+				{"serial": "u-1", "name": "Ana"},
+				{"serial": "u-2", "name": "Budi"},
+			},
+		},
+	}
+	uc := NewCatalogUsecase(config.Config{}, catalogRepo, nil, nil, nil, nil, nil, nil, nil)
+
+	records := []map[string]entity.DataItem{
+		{"customer": {Value: "c-1"}, "approver": {Value: "u-1"}, "creator": {Value: "u-2"}},
+		{"customer": {Value: "c-2"}, "approver": {Value: "u-2"}, "creator": {Value: "u-2"}},
+		{"customer": {Value: "c-1"}, "approver": {Value: nil}, "creator": {Value: "u-9"}},
+	}
+
+	err := uc.ResolveDisplayValues(context.Background(), entity.CatalogQuery{TenantCode: "acme", ObjectCode: "invoice"}, records)
+	if err != nil {
+		t.Fatalf("ResolveDisplayValues failed: %v", err)
+	}
+
+	if len(catalogRepo.queries) != 2 {
+		t.Fatalf("want one query per target object, got %v", len(catalogRepo.queries))
+	}
+
+	for _, query := range catalogRepo.queries {
+		filter := query.Filters[0].Filters["serial"]
+		if filter.Operator != entity.FilterOperatorIn {
+			t.Errorf("%v is read with the %v operator, want in", query.ObjectCode, filter.Operator)
+		}
+	}
+
+	want := []map[string]any{
+		{"customer": "Acme", "approver": "Ana", "creator": "Budi"},
+		{"customer": "Globex", "approver": "Budi", "creator": "Budi"},
+		{"customer": "Acme", "approver": nil, "creator": nil},
+	}
+	for i, record := range records {
+		for fieldCode, displayValue := range want[i] {
+			if got := record[fieldCode].DisplayValue; got != displayValue {
+				t.Errorf("record %v %v display value = %v, want %v", i, fieldCode, got, displayValue)
+			}
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package module
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cerkas/cerkas-backend/core/entity"
+	"github.com/cerkas/cerkas-backend/pkg/helper"
+)
+
+// refreshRollups recomputes the materialized rollup fields of the records a mutated record referred to, before and
+// after the mutation, so moving a line to another order updates both orders
+func (uc *catalogUsecase) refreshRollups(ctx context.Context, request entity.DataMutationRequest, oldItems, newItems map[string]entity.DataItem) (err error) {
+	rollups, err := uc.catalogRepo.GetMaterializedRollups(ctx, request.TenantCode, request.ObjectCode)
+	if err != nil {
+		return err
+	}
+
+	for _, rollup := range rollups {
+		values := []string{}
+		for _, items := range []map[string]entity.DataItem{oldItems, newItems} {
+			item, ok := items[rollup.Relation.RelationField]
+			if !ok || item.Value == nil {
+				continue
+			}
+
+			if value := fmt.Sprintf("%v", item.Value); !helper.Contains(values, value) {
+				values = append(values, value)
+			}
+		}
+
+		if err = uc.catalogRepo.RefreshRollup(ctx, request.TenantCode, rollup, values); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,82 @@
+package module
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/cerkas/cerkas-backend/core/entity"
+)
+
+func orderTotalRollup(function string) entity.RollupField {
+	return entity.RollupField{
+		ObjectCode: "order",
+		FieldCode:  "total",
+		Rollup:     entity.RollupConfig{ObjectCode: "order_line", RelationField: "order", Function: function, FieldCode: "amount", Materialized: true},
+		Relation:   entity.ReverseRelation{ObjectCode: "order_line", RelationField: "order", ReferencedField: "serial"},
+	}
+}
+
+func TestRefreshRollupsUpdatesTheRecordsBeforeAndAfter(t *testing.T) {
+	tests := []struct {
+		name     string
+		oldItems map[string]entity.DataItem
+		newItems map[string]entity.DataItem
+		want     []string
+	}{
+		{"created", nil, map[string]entity.DataItem{"order": {Value: "o1"}}, []string{"o1"}},
+		{"updated in place", map[string]entity.DataItem{"order": {Value: "o1"}}, map[string]entity.DataItem{"order": {Value: "o1"}}, []string{"o1"}},
+		{"moved", map[string]entity.DataItem{"order": {Value: "o1"}}, map[string]entity.DataItem{"order": {Value: "o2"}}, []string{"o1", "o2"}},
+		{"detached", map[string]entity.DataItem{"order": {Value: "o1"}}, map[string]entity.DataItem{"order": {Value: nil}}, []string{"o1"}},
+		{"no relation", map[string]entity.DataItem{"amount": {Value: 3}}, nil, nil},
+	}
+
+	for _, test := range tests {
+		catalogRepo := &fakeCatalogRepository{rollups: map[string][]entity.RollupField{"order_line": {orderTotalRollup(entity.RollupFunctionSum)}}}
+		uc := &catalogUsecase{catalogRepo: catalogRepo}
+
+		request := entity.DataMutationRequest{TenantCode: "acme", ObjectCode: "order_line"}
+		if err := uc.refreshRollups(context.Background(), request, test.oldItems, test.newItems); err != nil {
+			t.Fatalf("%v: refreshRollups = %v", test.name, err)
+		}
+
+		if got := catalogRepo.refreshed["order.total"]; fmt.Sprint(got) != fmt.Sprint(test.want) {
+			t.Errorf("%v: refreshed %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestRefreshRollupsFailsOnABrokenRollup(t *testing.T) {
+	catalogRepo := &fakeCatalogRepository{rollups: map[string][]entity.RollupField{"order_line": {orderTotalRollup("median")}}}
+	uc := &catalogUsecase{catalogRepo: catalogRepo}
+
+	request := entity.DataMutationRequest{TenantCode: "acme", ObjectCode: "order_line"}
+	if err := uc.refreshRollups(context.Background(), request, nil, map[string]entity.DataItem{"order": {Value: "o1"}}); err == nil {
+		t.Errorf("refreshRollups of an unknown function succeeds")
+	}
+
+	if err := uc.refreshRollups(context.Background(), entity.DataMutationRequest{TenantCode: "acme", ObjectCode: "order"}, nil, nil); err != nil {
+		t.Errorf("object without rollups: refreshRollups = %v", err)
+	}
+}
+
+func TestRefreshRollupsJobHandler(t *testing.T) {
+	catalogRepo := &fakeCatalogRepository{rollups: map[string][]entity.RollupField{"order_line": {orderTotalRollup(entity.RollupFunctionSum)}}}
+	handler := NewRefreshRollupsJobHandler(catalogRepo)
+
+	payload, _ := json.Marshal(entity.RefreshRollupsPayload{ObjectCode: "order"})
+	result, err := handler(context.Background(), entity.Job{TenantCode: "acme", Payload: payload}, nil)
+	if err != nil {
+		t.Fatalf("handler = %v", err)
+	}
+
+	if got := fmt.Sprint(result.(map[string]any)["refreshed_fields"]); got != "[total]" {
+		t.Errorf("refreshed_fields = %v, want [total]", got)
+	}
+
+	if _, err := handler(context.Background(), entity.Job{TenantCode: "acme", Payload: []byte(`{}`)}, nil); !errors.Is(err, entity.ErrorJobPayloadInvalid) {
+		t.Errorf("no object: handler = %v, want %v", err, entity.ErrorJobPayloadInvalid)
+	}
+}
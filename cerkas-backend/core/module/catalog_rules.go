@@ -0,0 +1,253 @@
+package module
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cerkas/cerkas-backend/core/entity"
+	"github.com/cerkas/cerkas-backend/pkg/helper"
+)
+
+// Rules run inside the transaction of a mutation by Sequence, then by creation; before rules change the mutation and
+// after rules update the record once more. A rule does not run again for the mutations its own actions cause.
+
+// maxRuleDepth bounds the chain of rules triggering mutations that run rules again
+const maxRuleDepth = 5
+
+type ruleChainKey struct{}
+
+// ruleChain returns the serials of the rules whose actions caused the mutation running in ctx
+func ruleChain(ctx context.Context) []string {
+	chain, _ := ctx.Value(ruleChainKey{}).([]string)
+	return chain
+}
+
+func withRuleChain(ctx context.Context, serial string) context.Context {
+	chain := append(append([]string{}, ruleChain(ctx)...), serial)
+	return context.WithValue(ctx, ruleChainKey{}, chain)
+}
+
+// activeRules returns the rules of a mutation to run in their order, leaving out the ones that caused it
+func (uc *catalogUsecase) activeRules(ctx context.Context, request entity.DataMutationRequest, timing, action string) (resp []entity.ObjectRule, err error) {
+	rules, err := uc.ruleRepo.GetActiveRules(ctx, request.TenantCode, request.ObjectCode, timing, action)
+	if err != nil {
+		return resp, err
+	}
+
+	sort.SliceStable(rules, func(i, j int) bool {
+		if rules[i].Sequence != rules[j].Sequence {
+			return rules[i].Sequence < rules[j].Sequence
+		}
+		if !rules[i].CreatedAt.Equal(rules[j].CreatedAt) {
+			return rules[i].CreatedAt.Before(rules[j].CreatedAt)
+		}
+
+		return rules[i].Serial < rules[j].Serial
+	})
+
+	chain := ruleChain(ctx)
+	for _, rule := range rules {
+		if !helper.Contains(chain, rule.Serial) {
+			resp = append(resp, rule)
+		}
+	}
+
+	if len(resp) > 0 && len(chain) >= maxRuleDepth {
+		return nil, fmt.Errorf("%w: %v", entity.ErrorRuleRecursion, strings.Join(chain, " > "))
+	}
+
+	return resp, nil
+}
+
+// runBeforeRules runs the before rules of a mutation and returns the mutation with the fields they set
+func (uc *catalogUsecase) runBeforeRules(ctx context.Context, action string, request entity.DataMutationRequest, oldItems map[string]entity.DataItem) (entity.DataMutationRequest, error) {
+	rules, err := uc.activeRules(ctx, request, entity.RuleTimingBefore, action)
+	if err != nil || len(rules) == 0 {
+		return request, err
+	}
+
+	record := mutationRecord(action, request, oldItems)
+	for _, rule := range rules {
+		if !matchFilters(rule.Condition, ruleValues(record, oldItems)) {
+			continue
+		}
+
+		ruleCtx := withRuleChain(ctx, rule.Serial)
+		for _, ruleAction := range rule.Actions {
+			if ruleAction.Type != entity.RuleActionSetField {
+				if err := uc.runRuleAction(ruleCtx, rule, ruleAction, request, record); err != nil {
+					return request, err
+				}
+
+				continue
+			}
+
+			// a deleted record has no values left to set
+			if action == entity.AuditActionDelete {
+				continue
+			}
+
+			item := entity.DataItem{FieldCode: ruleAction.FieldCode, Value: resolveRuleValue(ruleAction.Value, record)}
+			request.Items = setDataItem(request.Items, item)
+			record[item.FieldCode] = item
+		}
+	}
+
+	return request, nil
+}
+
+// runAfterRules runs the after rules of a mutation, the fields they set are written by one more update of the record
+func (uc *catalogUsecase) runAfterRules(ctx context.Context, action string, request entity.DataMutationRequest, oldItems, newItems map[string]entity.DataItem) (err error) {
+	rules, err := uc.activeRules(ctx, request, entity.RuleTimingAfter, action)
+	if err != nil || len(rules) == 0 {
+		return err
+	}
+
+	record := map[string]entity.DataItem{}
+	for fieldCode, item := range newItems {
+		record[fieldCode] = item
+	}
+
+	for _, rule := range rules {
+		if !matchFilters(rule.Condition, ruleValues(record, oldItems)) {
+			continue
+		}
+
+		ruleCtx := withRuleChain(ctx, rule.Serial)
+		update := entity.DataMutationRequest{
+			Serial:      mutationRecordSerial(request, oldItems),
+			ObjectCode:  request.ObjectCode,
+			TenantCode:  request.TenantCode,
+			ProductCode: request.ProductCode,
+			UserSerial:  request.UserSerial,
+			UserRole:    request.UserRole,
+		}
+
+		for _, ruleAction := range rule.Actions {
+			if ruleAction.Type != entity.RuleActionSetField {
+				if err := uc.runRuleAction(ruleCtx, rule, ruleAction, request, record); err != nil {
+					return err
+				}
+
+				continue
+			}
+
+			if action == entity.AuditActionDelete {
+				continue
+			}
+
+			item := entity.DataItem{FieldCode: ruleAction.FieldCode, Value: resolveRuleValue(ruleAction.Value, record)}
+			update.Items = setDataItem(update.Items, item)
+			record[item.FieldCode] = item
+		}
+
+		if len(update.Items) > 0 {
+			if _, err := uc.UpdateObjectData(ruleCtx, update); err != nil && !isApprovalPending(err) {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// runRuleAction runs the actions of a rule other than set_field, record is the record the rule matched
+func (uc *catalogUsecase) runRuleAction(ctx context.Context, rule entity.ObjectRule, action entity.RuleAction, request entity.DataMutationRequest, record map[string]entity.DataItem) (err error) {
+	switch action.Type {
+	case entity.RuleActionReject:
+		message := action.Message
+		if message == "" {
+			message = rule.Name
+		}
+
+		return fmt.Errorf("%w: %v", entity.ErrorRuleRejected, message)
+	case entity.RuleActionCreateRecord:
+		items := []entity.DataItem{}
+		for _, fieldCode := range sortedKeys(action.Items) {
+			items = append(items, entity.DataItem{FieldCode: fieldCode, Value: resolveRuleValue(action.Items[fieldCode], record)})
+		}
+
+		_, err = uc.CreateObjectData(ctx, entity.DataMutationRequest{
+			ObjectCode:  action.ObjectCode,
+			TenantCode:  request.TenantCode,
+			ProductCode: request.ProductCode,
+			UserSerial:  request.UserSerial,
+			UserRole:    request.UserRole,
+			Items:       items,
+		})
+		if isApprovalPending(err) {
+			return nil
+		}
+
+		return err
+	case entity.RuleActionEmitEvent:
+		return uc.eventBus.Publish(ctx, entity.RecordEvent{
+			Type:         entity.RecordEventType(entity.RuleEventPrefix + action.EventName),
+			TenantCode:   request.TenantCode,
+			ProductCode:  request.ProductCode,
+			ObjectCode:   request.ObjectCode,
+			RecordSerial: mutationRecordSerial(request, record),
+			ActorSerial:  request.UserSerial,
+			Items:        request.Items,
+			Data:         normalizeDataItems(record),
+			OccurredAt:   time.Now(),
+		})
+	}
+
+	return fmt.Errorf("%w: unknown type %q", entity.ErrorRuleActionInvalid, action.Type)
+}
+
+// mutationRecord returns the record as a mutation will write it, a deleted record keeps its last values
+func mutationRecord(action string, request entity.DataMutationRequest, oldItems map[string]entity.DataItem) map[string]entity.DataItem {
+	record := map[string]entity.DataItem{}
+	for fieldCode, item := range oldItems {
+		record[fieldCode] = item
+	}
+
+	if action != entity.AuditActionDelete {
+		for _, item := range request.Items {
+			record[item.FieldCode] = item
+		}
+	}
+
+	return record
+}
+
+// ruleValues flattens a record for the condition of a rule, the values before the mutation are prefixed with "old."
+func ruleValues(record, oldItems map[string]entity.DataItem) map[string]any {
+	values := dataItemValues(record)
+	for fieldCode, item := range oldItems {
+		values[entity.RuleOldValuePrefix+fieldCode] = item.Value
+	}
+
+	return values
+}
+
+// resolveRuleValue replaces $now by the current time and $field by the value of the field in record
+func resolveRuleValue(value any, record map[string]entity.DataItem) any {
+	text, ok := value.(string)
+	if !ok || !strings.HasPrefix(text, "$") {
+		return value
+	}
+
+	if text == entity.RuleValueNow {
+		return time.Now().Format(time.RFC3339)
+	}
+
+	return normalizeValue(record[strings.TrimPrefix(text, "$")].Value)
+}
+
+// setDataItem sets the item of a field in the items of a mutation
+func setDataItem(items []entity.DataItem, item entity.DataItem) []entity.DataItem {
+	for i := range items {
+		if items[i].FieldCode == item.FieldCode {
+			items[i].Value = item.Value
+			return items
+		}
+	}
+
+	return append(items, item)
+}
@@ -0,0 +1,396 @@
+package module
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cerkas/cerkas-backend/core/entity"
+	"golang.org/x/text/cases"
+	"golang.org/x/text/language"
+)
+
+const (
+	// defaultKanbanPageSize is the number of cards loaded per kanban column
+	defaultKanbanPageSize = 20
+
+	// defaultCalendarPageSize bounds the records of a calendar window
+	defaultCalendarPageSize = 1000
+)
+
+// getKanbanData groups the records by an option or reference field, every column is counted and paged on its own
+func (uc *catalogUsecase) getKanbanData(ctx context.Context, request entity.CatalogQuery, viewSchemaQuery map[string]any) (resp entity.CatalogResponse, err error) {
+	kanban := entity.KanbanQuery{}
+	if request.Kanban != nil {
+		kanban = *request.Kanban
+	}
+
+	if kanban.GroupBy == "" {
+		kanban.GroupBy, _ = viewSchemaQuery["group_by"].(string)
+	}
+
+	if kanban.GroupBy == "" {
+		return resp, entity.ErrorKanbanGroupByEmpty
+	}
+
+	if strings.Contains(kanban.GroupBy, "__") {
+		return resp, entity.ErrorKanbanGroupByInvalid
+	}
+
+	if kanban.PageSize < 1 {
+		kanban.PageSize = request.PageSize
+	}
+
+	if kanban.PageSize < 1 {
+		kanban.PageSize = defaultKanbanPageSize
+	}
+
+	columns := []entity.KanbanColumn{}
+	if len(kanban.Columns) > 0 {
+		for _, value := range kanban.Columns {
+			columns = append(columns, entity.KanbanColumn{Value: value})
+		}
+	} else {
+		columns, err = uc.getKanbanColumns(ctx, request, kanban.GroupBy)
+		if err != nil {
+			return resp, err
+		}
+	}
+
+	resp.StructureType = entity.StructureTypeKanban
+	resp.PageSize = kanban.PageSize
+
+	for _, column := range columns {
+		columnRequest := request
+		columnRequest.Filters = append(append([]entity.FilterGroup{}, request.Filters...), entity.FilterGroup{
+			Operator: entity.FilterOperator(entity.FilterOperatorAnd),
+			Filters: map[string]entity.FilterItem{
+				kanban.GroupBy: {FieldName: kanban.GroupBy, Operator: entity.FilterOperatorEqual, Value: column.Value},
+			},
+		})
+		columnRequest.Page = max(kanban.Pages[kanbanColumnKey(column.Value)], 1)
+		columnRequest.PageSize = kanban.PageSize
+
+		results, err := uc.queryObjectData(ctx, columnRequest)
+		if err != nil {
+			return resp, err
+		}
+
+		column.TotalData = results.TotalData
+		column.Page = results.Page
+		column.PageSize = results.PageSize
+		column.TotalPage = results.TotalPage
+		column.Items = results.Items
+
+		resp.TotalData += results.TotalData
+		resp.Columns = append(resp.Columns, column)
+	}
+
+	return resp, nil
+}
+
+// getKanbanColumns lists the options of the group field in their configured order, followed by the other values
+// found in the records. Records without a value get a trailing column.
+func (uc *catalogUsecase) getKanbanColumns(ctx context.Context, request entity.CatalogQuery, groupBy string) (columns []entity.KanbanColumn, err error) {
+	object, _ := uc.catalogRepo.GetObjectByCode(ctx, request.ObjectCode, request.TenantCode)
+	if object.Serial != "" {
+		request.ObjectSerial = object.Serial
+		request.TenantSerial = object.Tenant.Serial
+
+		objectFields, err := uc.GetObjectFieldsByObjectCode(ctx, request)
+		if err != nil {
+			return columns, err
+		}
+
+		if field, ok := objectFields[groupBy].(entity.ObjectFields); ok {
+			options, ok := field.ValidationRules["options"].([]any)
+			if !ok {
+				options, _ = field.DataType.FieldOptions["options"].([]any)
+			}
+
+			columns = kanbanOptionColumns(options)
+		}
+	}
+
+	groupCounts, err := uc.catalogRepo.GetGroupCounts(ctx, request, groupBy)
+	if err != nil {
+		return columns, err
+	}
+
+	hasEmpty := false
+	for _, groupCount := range groupCounts {
+		if groupCount.Value == nil {
+			hasEmpty = true
+			continue
+		}
+
+		found := false
+		for _, column := range columns {
+			if kanbanColumnKey(column.Value) == kanbanColumnKey(groupCount.Value) {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			columns = append(columns, entity.KanbanColumn{Value: groupCount.Value})
+		}
+	}
+
+	if hasEmpty {
+		columns = append(columns, entity.KanbanColumn{Value: nil})
+	}
+
+	return columns, nil
+}
+
+// kanbanOptionColumns reads the options of a field, either plain values or objects with a value and a label
+func kanbanOptionColumns(options []any) (columns []entity.KanbanColumn) {
+	for _, option := range options {
+		optionMap, ok := option.(map[string]any)
+		if !ok {
+			columns = append(columns, entity.KanbanColumn{Value: option})
+			continue
+		}
+
+		if value, ok := optionMap["value"]; ok {
+			columns = append(columns, entity.KanbanColumn{Value: value})
+		}
+	}
+
+	return columns
+}
+
+// kanbanColumnKey is the key of a column in the page map of the request, the column of records without a value has an empty key
+func kanbanColumnKey(value any) string {
+	if value == nil {
+		return ""
+	}
+
+	return fmt.Sprint(value)
+}
+
+// getCalendarData returns the records whose date, or date range, overlaps the requested window.
+// The window defaults to the current month.
+func (uc *catalogUsecase) getCalendarData(ctx context.Context, request entity.CatalogQuery, viewSchemaQuery map[string]any) (resp entity.CatalogResponse, err error) {
+	calendar := entity.CalendarQuery{}
+	if request.Calendar != nil {
+		calendar = *request.Calendar
+	}
+
+	if calendar.StartField == "" {
+		calendar.StartField, _ = viewSchemaQuery["start_field"].(string)
+	}
+
+	if calendar.EndField == "" {
+		calendar.EndField, _ = viewSchemaQuery["end_field"].(string)
+	}
+
+	if calendar.StartField == "" {
+		return resp, entity.ErrorCalendarStartEmpty
+	}
+
+	start, end, err := calendarWindow(calendar)
+	if err != nil {
+		return resp, err
+	}
+
+	calendar.Start = start.Format(time.RFC3339)
+	calendar.End = end.Format(time.RFC3339)
+
+	request.Filters = append([]entity.FilterGroup{}, request.Filters...)
+	if calendar.EndField == "" {
+		request.Filters = append(request.Filters,
+			entity.FilterGroup{
+				Operator: entity.FilterOperator(entity.FilterOperatorAnd),
+				Filters: map[string]entity.FilterItem{
+					calendar.StartField: {FieldName: calendar.StartField, Operator: entity.FilterOperatorGreaterThanEqual, Value: calendar.Start},
+				},
+			},
+			entity.FilterGroup{
+				Operator: entity.FilterOperator(entity.FilterOperatorAnd),
+				Filters: map[string]entity.FilterItem{
+					calendar.StartField: {FieldName: calendar.StartField, Operator: entity.FilterOperatorLessThanEqual, Value: calendar.End},
+				},
+			},
+		)
+	} else {
+		// a range overlaps the window when it starts before the window ends and ends after the window starts,
+		// a range without an end only overlaps when it starts within the window
+		request.Filters = append(request.Filters,
+			entity.FilterGroup{
+				Operator: entity.FilterOperator(entity.FilterOperatorAnd),
+				Filters: map[string]entity.FilterItem{
+					calendar.StartField: {FieldName: calendar.StartField, Operator: entity.FilterOperatorLessThanEqual, Value: calendar.End},
+				},
+			},
+			entity.FilterGroup{
+				Operator: entity.FilterOperator(entity.FilterOperatorOr),
+				Filters: map[string]entity.FilterItem{
+					calendar.EndField:   {FieldName: calendar.EndField, Operator: entity.FilterOperatorGreaterThanEqual, Value: calendar.Start},
+					calendar.StartField: {FieldName: calendar.StartField, Operator: entity.FilterOperatorGreaterThanEqual, Value: calendar.Start},
+				},
+			},
+		)
+	}
+
+	if len(request.Orders) == 0 {
+		request.Orders = []entity.Order{{FieldName: calendar.StartField, Direction: "asc"}}
+	}
+
+	if request.Page < 1 {
+		request.Page = 1
+	}
+
+	if request.PageSize < 1 {
+		request.PageSize = defaultCalendarPageSize
+	}
+
+	resp, err = uc.queryObjectData(ctx, request)
+	if err != nil {
+		return resp, err
+	}
+
+	resp.StructureType = entity.StructureTypeCalendar
+	resp.Calendar = &calendar
+
+	return resp, nil
+}
+
+// calendarWindow parses the window of a calendar query, a missing start is the start of the current month
+// and a missing end is a month after the start
+func calendarWindow(calendar entity.CalendarQuery) (start, end time.Time, err error) {
+	if calendar.Start == "" {
+		now := time.Now()
+		start = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	} else {
+		start, err = parseTimeValue(calendar.Start)
+		if err != nil {
+			return start, end, entity.ErrorCalendarWindowInvalid
+		}
+	}
+
+	if calendar.End == "" {
+		end = start.AddDate(0, 1, 0).Add(-time.Second)
+	} else {
+		end, err = parseTimeValue(calendar.End)
+		if err != nil {
+			return start, end, entity.ErrorCalendarWindowInvalid
+		}
+
+		// a date only end covers the whole day
+		if len(calendar.End) == len(entity.DefaultDateFormat) {
+			end = end.AddDate(0, 0, 1).Add(-time.Second)
+		}
+	}
+
+	if end.Before(start) {
+		return start, end, entity.ErrorCalendarWindowInvalid
+	}
+
+	return start, end, nil
+}
+
+const (
+	// defaultIncludeLimit is the number of children embedded per include
+	defaultIncludeLimit = 10
+
+	// dataTypeCollection is the data type of an embedded include, its value is a CatalogResponse
+	dataTypeCollection = "Collection"
+)
+
+// embedIncludes adds the one-to-many relations of the request to a detail record, every include becomes an item
+// holding the first page of its children
+func (uc *catalogUsecase) embedIncludes(ctx context.Context, request entity.CatalogQuery, record map[string]entity.DataItem) (err error) {
+	relations, err := uc.catalogRepo.GetReverseRelations(ctx, request.TenantCode, request.ObjectCode)
+	if err != nil {
+		return err
+	}
+
+	for _, include := range request.Includes {
+		relation, err := matchReverseRelation(relations, include)
+		if err != nil {
+			return err
+		}
+
+		if include.Name == "" {
+			include.Name = relation.ObjectCode
+		}
+
+		if _, ok := record[include.Name]; ok {
+			return fmt.Errorf("%w: %v clashes with a field", entity.ErrorIncludeAmbiguous, include.Name)
+		}
+
+		if include.Limit < 1 {
+			include.Limit = defaultIncludeLimit
+		}
+
+		filters := append([]entity.FilterGroup{}, include.Filters...)
+		filters = append(filters, entity.FilterGroup{
+			Operator: entity.FilterOperator(entity.FilterOperatorAnd),
+			Filters: map[string]entity.FilterItem{
+				relation.RelationField: {
+					FieldName: relation.RelationField,
+					Operator:  entity.FilterOperatorEqual,
+					Value:     normalizeValue(record[relation.ReferencedField].Value),
+				},
+			},
+		})
+
+		children, err := uc.queryObjectData(ctx, entity.CatalogQuery{
+			TenantCode:  request.TenantCode,
+			ProductCode: request.ProductCode,
+			ObjectCode:  relation.ObjectCode,
+			Fields:      include.Fields,
+			Filters:     filters,
+			Orders:      include.Orders,
+			Page:        1,
+			PageSize:    include.Limit,
+			AsOf:        request.AsOf,
+		})
+		if err != nil {
+			return err
+		}
+
+		record[include.Name] = entity.DataItem{
+			CompleteFieldCode: include.Name,
+			FieldCode:         include.Name,
+			FieldName:         strings.ReplaceAll(cases.Title(language.English).String(include.Name), "_", " "),
+			DataType:          dataTypeCollection,
+			Value:             children,
+		}
+	}
+
+	return nil
+}
+
+// matchReverseRelation finds the relation of an include by object code, and by relation field when the object refers to the record more than once
+func matchReverseRelation(relations []entity.ReverseRelation, include entity.Include) (relation entity.ReverseRelation, err error) {
+	objectCode := include.ObjectCode
+	if objectCode == "" {
+		objectCode = include.Name
+	}
+
+	matches := []entity.ReverseRelation{}
+	for _, relation := range relations {
+		if relation.ObjectCode != objectCode {
+			continue
+		}
+
+		if include.RelationField != "" && relation.RelationField != include.RelationField {
+			continue
+		}
+
+		matches = append(matches, relation)
+	}
+
+	switch len(matches) {
+	case 0:
+		return relation, fmt.Errorf("%w: %v", entity.ErrorIncludeNotFound, objectCode)
+	case 1:
+		return matches[0], nil
+	default:
+		return relation, fmt.Errorf("%w: %v", entity.ErrorIncludeAmbiguous, objectCode)
+	}
+}
@@ -0,0 +1,149 @@
+package module
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/cerkas/cerkas-backend/config"
+	"github.com/cerkas/cerkas-backend/core/entity"
+)
+
+func TestKanbanColumnsFollowTheOptions(t *testing.T) {
+	catalogRepo := &fakeCatalogRepository{
+		records: map[string][]map[string]any{"deal": {
+			{"serial": "d1", "stage": "won"},
+			{"serial": "d2", "stage": "lead"},
+			{"serial": "d3", "stage": "lead"},
+			{"serial": "d4", "stage": "lost"},
+			{"serial": "d5", "stage": nil},
+			{"serial": "d6", "stage": "lead"},
+		}},
+		objectFields: map[string]map[string]any{"deal": {
+			"stage": entity.ObjectFields{ValidationRules: map[string]any{"options": []any{
+				map[string]any{"value": "lead", "label": "Lead"},
+				"won",
+				"negotiation",
+			}}},
+		}},
+	}
+	uc := NewCatalogUsecase(config.Config{}, catalogRepo, nil, nil, nil, nil, nil, nil, nil).(*catalogUsecase)
+
+	request := entity.CatalogQuery{
+		TenantCode: "acme",
+		ObjectCode: "deal",
+		Kanban:     &entity.KanbanQuery{GroupBy: "stage", PageSize: 2, Pages: map[string]int{"lead": 2}},
+	}
+	resp, err := uc.getKanbanData(context.Background(), request, nil)
+	if err != nil {
+		t.Fatalf("getKanbanData: %v", err)
+	}
+
+	// options in their order, then the values found in the records, then the records without a value
+	got := []string{}
+	for _, column := range resp.Columns {
+		got = append(got, fmt.Sprintf("%v:%v/%v", column.Value, len(column.Items), column.TotalData))
+	}
+
+	want := "[lead:1/3 won:1/1 negotiation:0/0 lost:1/1 <nil>:1/1]"
+	if fmt.Sprint(got) != want {
+		t.Errorf("columns are %v, want %v", got, want)
+	}
+
+	if resp.TotalData != 6 || resp.StructureType != entity.StructureTypeKanban {
+		t.Errorf("kanban counts %v records as %v, want 6 as kanban", resp.TotalData, resp.StructureType)
+	}
+
+	if resp.Columns[0].Page != 2 || resp.Columns[0].Items[0]["serial"].Value != "d6" {
+		t.Errorf("lead column is on page %v with %v, want the second page", resp.Columns[0].Page, resp.Columns[0].Items)
+	}
+}
+
+func TestKanbanGroupBy(t *testing.T) {
+	uc := NewCatalogUsecase(config.Config{}, &fakeCatalogRepository{}, nil, nil, nil, nil, nil, nil, nil).(*catalogUsecase)
+
+	tests := []struct {
+		name   string
+		kanban *entity.KanbanQuery
+		schema map[string]any
+		want   error
+	}{
+		{"missing", nil, nil, entity.ErrorKanbanGroupByEmpty},
+		{"related field", &entity.KanbanQuery{GroupBy: "owner__name"}, nil, entity.ErrorKanbanGroupByInvalid},
+		{"from the view schema", nil, map[string]any{"group_by": "stage"}, nil},
+	}
+
+	for _, test := range tests {
+		_, err := uc.getKanbanData(context.Background(), entity.CatalogQuery{ObjectCode: "deal", Kanban: test.kanban}, test.schema)
+		if !errors.Is(err, test.want) {
+			t.Errorf("%v: getKanbanData = %v, want %v", test.name, err, test.want)
+		}
+	}
+}
+
+func TestCalendarListsTheRecordsOverlappingTheWindow(t *testing.T) {
+	catalogRepo := &fakeCatalogRepository{records: map[string][]map[string]any{"event": {
+		{"serial": "before", "starts_at": "2024-02-20T09:00:00Z", "ends_at": "2024-02-25T09:00:00Z"},
+		{"serial": "overlapping", "starts_at": "2024-02-27T09:00:00Z", "ends_at": "2024-03-02T09:00:00Z"},
+		{"serial": "inside", "starts_at": "2024-03-10T09:00:00Z", "ends_at": nil},
+		{"serial": "last day", "starts_at": "2024-03-31T18:00:00Z", "ends_at": nil},
+		{"serial": "after", "starts_at": "2024-04-01T09:00:00Z", "ends_at": "2024-04-02T09:00:00Z"},
+		{"serial": "open before", "starts_at": "2024-02-10T09:00:00Z", "ends_at": nil},
+	}}}
+	uc := NewCatalogUsecase(config.Config{}, catalogRepo, nil, nil, nil, nil, nil, nil, nil).(*catalogUsecase)
+
+	request := entity.CatalogQuery{
+		ObjectCode: "event",
+		Calendar:   &entity.CalendarQuery{Start: "2024-03-01", End: "2024-03-31"},
+	}
+	resp, err := uc.getCalendarData(context.Background(), request, map[string]any{"start_field": "starts_at", "end_field": "ends_at"})
+	if err != nil {
+		t.Fatalf("getCalendarData: %v", err)
+	}
+
+	got := []string{}
+	for _, item := range resp.Items {
+		got = append(got, item["serial"].Value.(string))
+	}
+	sort.Strings(got)
+
+	if want := "[inside last day overlapping]"; fmt.Sprint(got) != want {
+		t.Errorf("calendar lists %v, want %v", got, want)
+	}
+
+	if resp.StructureType != entity.StructureTypeCalendar || resp.Calendar.StartField != "starts_at" {
+		t.Errorf("calendar response is %+v", resp.Calendar)
+	}
+}
+
+func TestCalendarWindow(t *testing.T) {
+	tests := []struct {
+		name      string
+		calendar  entity.CalendarQuery
+		wantStart string
+		wantEnd   string
+		wantErr   bool
+	}{
+		{"a month from the start", entity.CalendarQuery{Start: "2024-02-01"}, "2024-02-01T00:00:00Z", "2024-02-29T23:59:59Z", false},
+		{"a date only end covers the day", entity.CalendarQuery{Start: "2024-02-01", End: "2024-02-07"}, "2024-02-01T00:00:00Z", "2024-02-07T23:59:59Z", false},
+		{"end before start", entity.CalendarQuery{Start: "2024-02-07", End: "2024-02-01"}, "", "", true},
+		{"not a date", entity.CalendarQuery{Start: "next week"}, "", "", true},
+	}
+
+	for _, test := range tests {
+		start, end, err := calendarWindow(test.calendar)
+		if test.wantErr {
+			if !errors.Is(err, entity.ErrorCalendarWindowInvalid) {
+				t.Errorf("%v: calendarWindow = %v, want an invalid window", test.name, err)
+			}
+			continue
+		}
+
+		if err != nil || start.Format(time.RFC3339) != test.wantStart || end.Format(time.RFC3339) != test.wantEnd {
+			t.Errorf("%v: calendarWindow = %v - %v, %v, want %v - %v", test.name, start, end, err, test.wantStart, test.wantEnd)
+		}
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/cerkas/cerkas-backend/config"
 	"github.com/cerkas/cerkas-backend/core/entity"
 	"github.com/cerkas/cerkas-backend/core/repository"
+	"github.com/cerkas/cerkas-backend/pkg/helper"
 )
 
 type CatalogUsecase interface {
@@ -22,19 +23,35 @@ type CatalogUsecase interface {
 	DeleteObjectData(ctx context.Context, request entity.DataMutationRequest) (err error)
 	GetObjectFieldsByObjectCode(ctx context.Context, request entity.CatalogQuery) (resp map[string]any, err error)
 	GetContentLayoutByKeys(ctx context.Context, request entity.GetViewContentByKeysRequest, catalogQuery entity.CatalogQuery) (resp entity.ViewContentResponse, err error)
+	LookupFieldValues(ctx context.Context, request entity.LookupQuery) (resp []entity.LookupOption, err error)
+	ResolveDisplayValues(ctx context.Context, request entity.CatalogQuery, records []map[string]entity.DataItem) (err error)
+	GetRecordTransitions(ctx context.Context, request entity.CatalogQuery) (resp []entity.AvailableTransition, err error)
+	GetTransitionHistory(ctx context.Context, request entity.CatalogQuery) (resp []entity.StateTransitionLog, err error)
 }
 
 type catalogUsecase struct {
-	cfg         config.Config
-	catalogRepo repository.CatalogRepository
-	viewRepo    repository.ViewRepository
+	cfg          config.Config
+	catalogRepo  repository.CatalogRepository
+	viewRepo     repository.ViewRepository
+	versionRepo  repository.VersionRepository
+	ruleRepo     repository.RuleRepository
+	workflowRepo repository.WorkflowRepository
+	approvalRepo repository.ApprovalRepository
+	transactor   repository.Transactor
+	eventBus     EventBus
 }
 
-func NewCatalogUsecase(cfg config.Config, catalogRepo repository.CatalogRepository, viewRepo repository.ViewRepository) CatalogUsecase {
+func NewCatalogUsecase(cfg config.Config, catalogRepo repository.CatalogRepository, viewRepo repository.ViewRepository, versionRepo repository.VersionRepository, ruleRepo repository.RuleRepository, workflowRepo repository.WorkflowRepository, approvalRepo repository.ApprovalRepository, transactor repository.Transactor, eventBus EventBus) CatalogUsecase {
 	return &catalogUsecase{
-		cfg:         cfg,
-		catalogRepo: catalogRepo,
-		viewRepo:    viewRepo,
+		cfg:          cfg,
+		catalogRepo:  catalogRepo,
+		viewRepo:     viewRepo,
+		versionRepo:  versionRepo,
+		ruleRepo:     ruleRepo,
+		workflowRepo: workflowRepo,
+		approvalRepo: approvalRepo,
+		transactor:   transactor,
+		eventBus:     eventBus,
 	}
 }
 
@@ -84,6 +101,8 @@ func (uc *catalogUsecase) GetObjectData(ctx context.Context, request entity.Cata
 		ObjectCode:      request.ObjectCode,
 		ViewContentCode: request.ViewContentCode,
 		LayoutType:      "record",
+		UserSerial:      request.UserSerial,
+		UserRole:        request.UserRole,
 	}, request)
 	if err != nil {
 		return resp, err
@@ -94,48 +113,7 @@ func (uc *catalogUsecase) GetObjectData(ctx context.Context, request entity.Cata
 	}
 
 	viewSchemaRecord := viewContent.ViewContent.ViewSchema
-	viewSchemaQuery := viewSchemaRecord.Query
-	viewSchemaQueryFilters := []any{}
-
-	if _, ok := viewSchemaQuery["filters"]; ok {
-		if filters, ok := viewSchemaQuery["filters"].([]any); ok {
-			viewSchemaQueryFilters = filters
-		}
-	}
-
-	for _, filter := range viewSchemaQueryFilters {
-		filterGroup := entity.FilterGroup{}
-		if filterMap, ok := filter.(map[string]any); ok {
-			if operator, ok := filterMap["operator"].(string); ok {
-				filterGroup.Operator = entity.FilterOperator(operator)
-			}
-
-			if filterItem, ok := filterMap["filter_item"].(map[string]any); ok {
-				fmt.Printf("filterItem: %+v\n", filterItem)
-				filterGroup.Filters = make(map[string]entity.FilterItem)
-				for key, item := range filterItem {
-					if itemMap, ok := item.(map[string]any); ok {
-						filterItem := entity.FilterItem{}
-						if fieldCode, ok := itemMap["field_code"].(string); ok {
-							filterItem.FieldName = fieldCode
-						}
-						if operator, ok := itemMap["operator"].(string); ok {
-							filterItem.Operator = entity.FilterOperator(operator)
-						}
-						if value, ok := itemMap["value"]; ok {
-							filterItem.Value = value
-						}
-
-						if filterItem.Value != "" {
-							filterGroup.Filters[key] = filterItem
-						}
-					}
-				}
-			}
-		}
-
-		combinedQuery.Filters = append(combinedQuery.Filters, filterGroup)
-	}
+	combinedQuery.Filters = viewSchemaFilters(viewSchemaRecord.Query)
 
 	// combine combonedQuery with request
 	if len(request.Filters) > 0 {
@@ -166,6 +144,11 @@ func (uc *catalogUsecase) GetObjectData(ctx context.Context, request entity.Cata
 
 	request.Filters = combinedQuery.Filters
 
+	// the orders of the request replace the saved orders of the view schema
+	if len(request.Orders) == 0 {
+		request.Orders = viewSchemaOrders(viewSchemaRecord.Query)
+	}
+
 	// combine request.Fields with view schema fields
 	viewSchemaFields := viewSchemaRecord.DisplayField
 	if len(viewSchemaFields) > 0 {
@@ -201,7 +184,30 @@ func (uc *catalogUsecase) GetObjectData(ctx context.Context, request entity.Cata
 
 	request.Fields = combinedQuery.Fields
 
-	results, err := uc.catalogRepo.GetObjectData(ctx, request)
+	// the structure type of the request wins over the one of the view schema
+	structureType := request.StructureType
+	if structureType == "" {
+		structureType = viewSchemaRecord.StructureType
+	}
+
+	switch structureType {
+	case entity.StructureTypeKanban:
+		return uc.getKanbanData(ctx, request, viewSchemaRecord.Query)
+	case entity.StructureTypeCalendar:
+		return uc.getCalendarData(ctx, request, viewSchemaRecord.Query)
+	}
+
+	return uc.queryObjectData(ctx, request)
+}
+
+// queryObjectData fetches a page of records and maps the object field names and data types onto them
+func (uc *catalogUsecase) queryObjectData(ctx context.Context, request entity.CatalogQuery) (resp entity.CatalogResponse, err error) {
+	var results entity.CatalogResponse
+	if request.AsOf != "" {
+		results, err = uc.getObjectDataAsOf(ctx, request)
+	} else {
+		results, err = uc.catalogRepo.GetObjectData(ctx, request)
+	}
 	if err != nil {
 		return resp, err
 	}
@@ -222,6 +228,11 @@ func (uc *catalogUsecase) GetObjectData(ctx context.Context, request entity.Cata
 
 	// iterate object fields and map to response
 	for i, items := range results.Items {
+		recordSerial := ""
+		if serial, ok := items["serial"]; ok && serial.Value != nil {
+			recordSerial = fmt.Sprintf("%v", serial.Value)
+		}
+
 		for j, item := range items {
 			// put field code to complete field code, and assign new value to field code with column name only after splitted
 			// example: item.FieldCode = "object.field_code" => item.CompleteFieldCode = "object.field_code" and item.FieldCode = "field_code"
@@ -258,6 +269,11 @@ func (uc *catalogUsecase) GetObjectData(ctx context.Context, request entity.Cata
 
 				// set custom data type
 				item.DataType = data.DataType.Name
+
+				// file fields of the record itself link to the file and its thumbnails
+				if data.DataType.Code == entity.DataTypeFile && !strings.Contains(j, "__") {
+					item.DisplayValue = fileDisplayValue(request, recordSerial, fieldCode, item.Value, uc.cfg.ThumbnailSizes)
+				}
 			}
 
 			if requestDisplayName, ok := request.Fields[item.CompleteFieldCode]; ok {
@@ -278,7 +294,16 @@ func (uc *catalogUsecase) GetObjectData(ctx context.Context, request entity.Cata
 func (uc *catalogUsecase) GetObjectDetail(ctx context.Context, request entity.CatalogQuery, serial string) (resp map[string]entity.DataItem, err error) {
 	request.Serial = serial
 
-	return uc.catalogRepo.GetObjectDetail(ctx, request)
+	if request.AsOf != "" {
+		resp, err = uc.getObjectDetailAsOf(ctx, request)
+	} else {
+		resp, err = uc.catalogRepo.GetObjectDetail(ctx, request)
+	}
+	if err != nil || resp == nil || len(request.Includes) == 0 {
+		return resp, err
+	}
+
+	return resp, uc.embedIncludes(ctx, request, resp)
 }
 
 func (uc *catalogUsecase) GetDataByRawQuery(ctx context.Context, request entity.CatalogQuery) (resp entity.CatalogResponse, err error) {
@@ -286,15 +311,368 @@ func (uc *catalogUsecase) GetDataByRawQuery(ctx context.Context, request entity.
 }
 
 func (uc *catalogUsecase) CreateObjectData(ctx context.Context, request entity.DataMutationRequest) (resp entity.CatalogResponse, err error) {
-	return uc.catalogRepo.CreateObjectData(ctx, request)
+	// the serial is generated here so the event of the new record can refer to it
+	if request.Serial == "" {
+		for _, item := range request.Items {
+			if item.FieldCode == "serial" && item.Value != nil {
+				request.Serial = fmt.Sprintf("%v", item.Value)
+			}
+		}
+	}
+
+	if request.Serial == "" {
+		request.Serial, err = helper.GenerateUUUID()
+		if err != nil {
+			return resp, err
+		}
+	}
+
+	// a change request held for approval is committed, the mutation itself is not applied
+	var pending *entity.ChangeRequest
+	err = uc.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		changeRequest, err := uc.holdForApproval(ctx, entity.AuditActionCreate, request, nil)
+		if err != nil || changeRequest != nil {
+			pending = changeRequest
+			return err
+		}
+
+		request, err := uc.runBeforeRules(ctx, entity.AuditActionCreate, request, nil)
+		if err != nil {
+			return err
+		}
+
+		transitions, err := uc.applyStateMachines(ctx, &request, nil)
+		if err != nil {
+			return err
+		}
+
+		resp, err = uc.catalogRepo.CreateObjectData(ctx, request)
+		if err != nil {
+			return err
+		}
+
+		if err = uc.recordTransitions(ctx, request, nil, transitions); err != nil {
+			return err
+		}
+
+		return uc.completeMutation(ctx, entity.RecordCreated, request, nil)
+	})
+
+	if err == nil && pending != nil {
+		return resp, &ApprovalPendingError{ChangeRequest: *pending}
+	}
+
+	return resp, err
 }
 
 func (uc *catalogUsecase) UpdateObjectData(ctx context.Context, request entity.DataMutationRequest) (resp entity.CatalogResponse, err error) {
-	return uc.catalogRepo.UpdateObjectData(ctx, request)
+	var pending *entity.ChangeRequest
+	err = uc.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		oldItems, err := uc.getMutationRecord(ctx, request)
+		if err != nil {
+			return err
+		}
+
+		changeRequest, err := uc.holdForApproval(ctx, entity.AuditActionUpdate, request, oldItems)
+		if err != nil || changeRequest != nil {
+			pending = changeRequest
+			return err
+		}
+
+		request, err := uc.runBeforeRules(ctx, entity.AuditActionUpdate, request, oldItems)
+		if err != nil {
+			return err
+		}
+
+		transitions, err := uc.applyStateMachines(ctx, &request, oldItems)
+		if err != nil {
+			return err
+		}
+
+		resp, err = uc.catalogRepo.UpdateObjectData(ctx, request)
+		if err != nil {
+			return err
+		}
+
+		if err = uc.recordTransitions(ctx, request, oldItems, transitions); err != nil {
+			return err
+		}
+
+		return uc.completeMutation(ctx, entity.RecordUpdated, request, oldItems)
+	})
+
+	if err == nil && pending != nil {
+		return resp, &ApprovalPendingError{ChangeRequest: *pending}
+	}
+
+	return resp, err
 }
 
 func (uc *catalogUsecase) DeleteObjectData(ctx context.Context, request entity.DataMutationRequest) (err error) {
-	return uc.catalogRepo.DeleteObjectData(ctx, request)
+	var pending *entity.ChangeRequest
+	err = uc.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		oldItems, err := uc.getMutationRecord(ctx, request)
+		if err != nil {
+			return err
+		}
+
+		changeRequest, err := uc.holdForApproval(ctx, entity.AuditActionDelete, request, oldItems)
+		if err != nil || changeRequest != nil {
+			pending = changeRequest
+			return err
+		}
+
+		if _, err = uc.runBeforeRules(ctx, entity.AuditActionDelete, request, oldItems); err != nil {
+			return err
+		}
+
+		if err = uc.catalogRepo.DeleteObjectData(ctx, request); err != nil {
+			return err
+		}
+
+		return uc.completeMutation(ctx, entity.RecordDeleted, request, oldItems)
+	})
+
+	if err == nil && pending != nil {
+		return &ApprovalPendingError{ChangeRequest: *pending}
+	}
+
+	return err
+}
+
+// getMutationRecord returns the current values of the record targeted by an update or delete
+func (uc *catalogUsecase) getMutationRecord(ctx context.Context, request entity.DataMutationRequest) (resp map[string]entity.DataItem, err error) {
+	if request.Serial == "" {
+		return resp, entity.ErrorSerialEmpty
+	}
+
+	resp, err = uc.catalogRepo.GetObjectDetail(ctx, entity.CatalogQuery{
+		Serial:      request.Serial,
+		ObjectCode:  request.ObjectCode,
+		TenantCode:  request.TenantCode,
+		ProductCode: request.ProductCode,
+	})
+	if err != nil {
+		return resp, err
+	}
+
+	if len(resp) == 0 {
+		return resp, entity.ErrorNotFound
+	}
+
+	return resp, nil
+}
+
+// completeMutation refreshes the materialized rollups over the record, publishes the event of the mutation with the
+// record before and after it and runs the after rules. It runs in the transaction of the mutation, a deleted record
+// keeps its last values.
+func (uc *catalogUsecase) completeMutation(ctx context.Context, eventType entity.RecordEventType, request entity.DataMutationRequest, oldItems map[string]entity.DataItem) (err error) {
+	newItems := oldItems
+	if eventType != entity.RecordDeleted {
+		newItems, err = uc.catalogRepo.GetObjectDetail(ctx, entity.CatalogQuery{
+			Serial:      mutationRecordSerial(request, oldItems),
+			ObjectCode:  request.ObjectCode,
+			TenantCode:  request.TenantCode,
+			ProductCode: request.ProductCode,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if err = uc.refreshRollups(ctx, request, oldItems, newItems); err != nil {
+		return err
+	}
+
+	event := newRecordEvent(eventType, request, oldItems, newItems)
+	if err = uc.eventBus.Publish(ctx, event); err != nil {
+		return err
+	}
+
+	return uc.runAfterRules(ctx, event.Action(), request, oldItems, newItems)
+}
+
+// resolveRecordSerial returns the serial of the record of request, a record identified by code is read to find it
+func resolveRecordSerial(ctx context.Context, catalogRepo repository.CatalogRepository, request entity.CatalogQuery) (recordSerial string, err error) {
+	if helper.IsUUID(request.Serial) {
+		return request.Serial, nil
+	}
+
+	current, err := catalogRepo.GetObjectDetail(ctx, entity.CatalogQuery{
+		Serial:     request.Serial,
+		ObjectCode: request.ObjectCode,
+		TenantCode: request.TenantCode,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if serial, ok := current["serial"]; ok && serial.Value != nil {
+		return fmt.Sprintf("%v", normalizeValue(serial.Value)), nil
+	}
+
+	return request.Serial, nil
+}
+
+// getObjectDetailAsOf reads a record from the version store as it was at request.AsOf
+func (uc *catalogUsecase) getObjectDetailAsOf(ctx context.Context, request entity.CatalogQuery) (resp map[string]entity.DataItem, err error) {
+	asOf, err := parseTimeValue(request.AsOf)
+	if err != nil {
+		return resp, entity.ErrorInvalidAsOf
+	}
+
+	// versions are stored by serial
+	recordSerial, err := resolveRecordSerial(ctx, uc.catalogRepo, request)
+	if err != nil {
+		return resp, err
+	}
+
+	version, err := uc.versionRepo.GetRecordVersionAsOf(ctx, request.TenantCode, request.ObjectCode, recordSerial, asOf)
+	if err != nil {
+		return resp, err
+	}
+
+	if version.IsDeleted {
+		return resp, entity.ErrorNotFound
+	}
+
+	return selectVersionFields(version.Data, request.Fields), nil
+}
+
+// getObjectDataAsOf lists the records of an object as they were at request.AsOf. Filters and orders are applied
+// in memory on the stored snapshots, relationship fields (with __) are not part of a snapshot and stay empty.
+func (uc *catalogUsecase) getObjectDataAsOf(ctx context.Context, request entity.CatalogQuery) (resp entity.CatalogResponse, err error) {
+	asOf, err := parseTimeValue(request.AsOf)
+	if err != nil {
+		return resp, entity.ErrorInvalidAsOf
+	}
+
+	versions, err := uc.versionRepo.GetObjectVersionsAsOf(ctx, request.TenantCode, request.ObjectCode, asOf)
+	if err != nil {
+		return resp, err
+	}
+
+	records := []map[string]entity.DataItem{}
+	for _, version := range versions {
+		if version.IsDeleted || !matchFilters(request.Filters, dataItemValues(version.Data)) {
+			continue
+		}
+
+		records = append(records, version.Data)
+	}
+
+	sortRecords(records, request.Orders)
+
+	if request.Page < 1 {
+		request.Page = 1
+	}
+
+	if request.PageSize < 1 {
+		request.PageSize = 10
+	}
+
+	start := min((request.Page-1)*request.PageSize, len(records))
+	end := min(start+request.PageSize, len(records))
+
+	for _, record := range records[start:end] {
+		resp.Items = append(resp.Items, selectVersionFields(record, request.Fields))
+	}
+
+	resp.Page = request.Page
+	resp.PageSize = request.PageSize
+	resp.TotalData = len(records)
+	resp.TotalPage = int(helper.GenerateTotalPage(int64(resp.TotalData), int64(request.PageSize)))
+
+	return resp, nil
+}
+
+// selectVersionFields keeps the requested fields of a snapshot, all fields are kept when none is requested
+func selectVersionFields(data map[string]entity.DataItem, fields map[string]entity.Field) map[string]entity.DataItem {
+	if len(fields) == 0 {
+		return data
+	}
+
+	result := make(map[string]entity.DataItem, len(fields))
+	for key := range fields {
+		item, ok := data[key]
+		if !ok {
+			item = entity.DataItem{FieldCode: key, DataType: "text"}
+		}
+
+		result[key] = item
+	}
+
+	return result
+}
+
+// viewSchemaFilters converts the stored filters of a view schema query into filter groups
+func viewSchemaFilters(viewSchemaQuery map[string]any) []entity.FilterGroup {
+	filterGroups := []entity.FilterGroup{}
+	viewSchemaQueryFilters := []any{}
+
+	if _, ok := viewSchemaQuery["filters"]; ok {
+		if filters, ok := viewSchemaQuery["filters"].([]any); ok {
+			viewSchemaQueryFilters = filters
+		}
+	}
+
+	for _, filter := range viewSchemaQueryFilters {
+		filterGroup := entity.FilterGroup{}
+		if filterMap, ok := filter.(map[string]any); ok {
+			if operator, ok := filterMap["operator"].(string); ok {
+				filterGroup.Operator = entity.FilterOperator(operator)
+			}
+
+			if filterItem, ok := filterMap["filter_item"].(map[string]any); ok {
+				filterGroup.Filters = make(map[string]entity.FilterItem)
+				for key, item := range filterItem {
+					if itemMap, ok := item.(map[string]any); ok {
+						filterItem := entity.FilterItem{}
+						if fieldCode, ok := itemMap["field_code"].(string); ok {
+							filterItem.FieldName = fieldCode
+						}
+						if operator, ok := itemMap["operator"].(string); ok {
+							filterItem.Operator = entity.FilterOperator(operator)
+						}
+						if value, ok := itemMap["value"]; ok {
+							filterItem.Value = value
+						}
+
+						if filterItem.Value != "" {
+							filterGroup.Filters[key] = filterItem
+						}
+					}
+				}
+			}
+		}
+
+		filterGroups = append(filterGroups, filterGroup)
+	}
+
+	return filterGroups
+}
+
+// viewSchemaOrders converts the stored orders of a view schema query into orders
+func viewSchemaOrders(viewSchemaQuery map[string]any) []entity.Order {
+	orders := []entity.Order{}
+
+	viewSchemaQueryOrders, _ := viewSchemaQuery["orders"].([]any)
+	for _, order := range viewSchemaQueryOrders {
+		orderMap, ok := order.(map[string]any)
+		if !ok {
+			continue
+		}
+
+		fieldName, _ := orderMap["field_name"].(string)
+		direction, _ := orderMap["direction"].(string)
+		if fieldName == "" {
+			continue
+		}
+
+		orders = append(orders, entity.Order{FieldName: fieldName, Direction: direction})
+	}
+
+	return orders
 }
 
 func (uc *catalogUsecase) GetContentLayoutByKeys(ctx context.Context, request entity.GetViewContentByKeysRequest, catalogQuery entity.CatalogQuery) (resp entity.ViewContentResponse, err error) {
@@ -303,6 +681,12 @@ func (uc *catalogUsecase) GetContentLayoutByKeys(ctx context.Context, request en
 		return resp, err
 	}
 
+	// the display fields and the layout config are merged along the layers of the view
+	resolution, err := resolveViewChain(ctx, uc.viewRepo, request)
+	if err != nil {
+		return resp, err
+	}
+
 	// Convert map to struct
 	if err = mapToStructSnakeCase(viewContentRecord, &resp.ViewContent); err != nil {
 		return resp, err
@@ -408,6 +792,7 @@ func (uc *catalogUsecase) GetContentLayoutByKeys(ctx context.Context, request en
 				return resp, err
 			}
 
+			viewSchemaSt.DisplayField = resolution.DisplayField
 			resp.ViewContent.ViewSchema = viewSchemaSt
 		}
 	}
@@ -430,6 +815,7 @@ func (uc *catalogUsecase) GetContentLayoutByKeys(ctx context.Context, request en
 				return resp, err
 			}
 
+			viewLayoutSt.LayoutConfig = resolution.LayoutConfig
 			resp.ViewContent.ViewLayout = viewLayoutSt
 		}
 	}
@@ -483,10 +869,8 @@ func (uc *catalogUsecase) GetContentLayoutByKeys(ctx context.Context, request en
 	resp.Fields = originalFields
 
 	// fetching layout
-	resp.Layout, err = handleViewLayout(resp.ViewContent.ViewLayout.LayoutConfig, resp.Fields, request)
-	if err != nil {
-		return resp, err
-	}
+	// the catalog only reads the view schema, layouts are resolved by the view usecase
+	resp.Layout = resp.ViewContent.ViewLayout.LayoutConfig
 
 	return resp, nil
 }
@@ -0,0 +1,221 @@
+package module
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cerkas/cerkas-backend/core/entity"
+	"github.com/cerkas/cerkas-backend/pkg/helper"
+)
+
+// applyStateMachines checks the state fields a mutation sets against their state machines and returns the
+// transitions it runs. A new record without a state gets the initial state of the machine.
+func (uc *catalogUsecase) applyStateMachines(ctx context.Context, request *entity.DataMutationRequest, oldItems map[string]entity.DataItem) (resp []entity.StateTransitionLog, err error) {
+	machines, err := uc.workflowRepo.GetStateMachines(ctx, request.TenantCode, request.ObjectCode)
+	if err != nil || len(machines) == 0 {
+		return resp, err
+	}
+
+	// the record as it will be written
+	record := map[string]entity.DataItem{}
+	for fieldCode, item := range oldItems {
+		record[fieldCode] = item
+	}
+	for _, item := range request.Items {
+		record[item.FieldCode] = item
+	}
+
+	for _, fieldCode := range sortedKeys(machines) {
+		machine := machines[fieldCode]
+
+		item, isSet := findDataItem(request.Items, fieldCode)
+		if oldItems == nil && !isSet && machine.InitialState != "" {
+			item = entity.DataItem{FieldCode: fieldCode, Value: machine.InitialState}
+			request.Items = setDataItem(request.Items, item)
+			isSet = true
+		}
+
+		if !isSet {
+			continue
+		}
+
+		fromState := stateValue(oldItems[fieldCode].Value)
+		toState := stateValue(item.Value)
+		if oldItems != nil && fromState == toState {
+			continue
+		}
+
+		if len(machine.States) > 0 && !helper.Contains(machine.States, toState) {
+			return resp, fmt.Errorf("%w: %v is not a state of %v", entity.ErrorTransitionInvalid, toState, fieldCode)
+		}
+
+		transitionLog := entity.StateTransitionLog{FieldCode: fieldCode, FromState: fromState, ToState: toState}
+
+		if oldItems == nil {
+			if machine.InitialState != "" && toState != machine.InitialState {
+				return resp, fmt.Errorf("%w: a new record starts in %v", entity.ErrorTransitionInvalid, machine.InitialState)
+			}
+
+			resp = append(resp, transitionLog)
+			continue
+		}
+
+		transition, err := matchTransition(machine, fieldCode, fromState, toState, request.UserRole)
+		if err != nil {
+			return resp, err
+		}
+
+		if missingFields := missingStateFields(transition, record); len(missingFields) > 0 {
+			return resp, fmt.Errorf("%w: %v", entity.ErrorTransitionFieldRequired, strings.Join(missingFields, ", "))
+		}
+
+		transitionLog.Transition = transition.Code
+		resp = append(resp, transitionLog)
+	}
+
+	return resp, nil
+}
+
+// recordTransitions stores the transitions a mutation ran, with its actor
+func (uc *catalogUsecase) recordTransitions(ctx context.Context, request entity.DataMutationRequest, oldItems map[string]entity.DataItem, logs []entity.StateTransitionLog) (err error) {
+	now := time.Now()
+	for i := range logs {
+		logs[i].Serial, err = helper.GenerateUUUID()
+		if err != nil {
+			return err
+		}
+
+		logs[i].TenantCode = request.TenantCode
+		logs[i].ObjectCode = request.ObjectCode
+		logs[i].RecordSerial = mutationRecordSerial(request, oldItems)
+		logs[i].ActorSerial = request.UserSerial
+		logs[i].CreatedAt = now
+	}
+
+	return uc.workflowRepo.CreateTransitionLogs(ctx, logs)
+}
+
+// GetRecordTransitions lists the transitions the user can run on a record, for every state field of its object
+func (uc *catalogUsecase) GetRecordTransitions(ctx context.Context, request entity.CatalogQuery) (resp []entity.AvailableTransition, err error) {
+	if request.Serial == "" {
+		return resp, entity.ErrorSerialEmpty
+	}
+
+	machines, err := uc.workflowRepo.GetStateMachines(ctx, request.TenantCode, request.ObjectCode)
+	if err != nil {
+		return resp, err
+	}
+
+	record, err := uc.catalogRepo.GetObjectDetail(ctx, entity.CatalogQuery{
+		Serial:      request.Serial,
+		ObjectCode:  request.ObjectCode,
+		TenantCode:  request.TenantCode,
+		ProductCode: request.ProductCode,
+	})
+	if err != nil {
+		return resp, err
+	}
+
+	if len(record) == 0 {
+		return resp, entity.ErrorNotFound
+	}
+
+	resp = []entity.AvailableTransition{}
+	for _, fieldCode := range sortedKeys(machines) {
+		currentState := stateValue(record[fieldCode].Value)
+
+		for _, transition := range machines[fieldCode].Transitions {
+			if !matchesState(transition.From, currentState) || transition.To == currentState || !roleAllowed(transition, request.UserRole) {
+				continue
+			}
+
+			resp = append(resp, entity.AvailableTransition{
+				FieldCode:     fieldCode,
+				CurrentState:  currentState,
+				Transition:    transition,
+				MissingFields: missingStateFields(transition, record),
+			})
+		}
+	}
+
+	return resp, nil
+}
+
+// GetTransitionHistory lists the transitions a record went through, oldest first
+func (uc *catalogUsecase) GetTransitionHistory(ctx context.Context, request entity.CatalogQuery) (resp []entity.StateTransitionLog, err error) {
+	if request.Serial == "" {
+		return resp, entity.ErrorSerialEmpty
+	}
+
+	// transitions are logged by serial
+	recordSerial, err := resolveRecordSerial(ctx, uc.catalogRepo, request)
+	if err != nil {
+		return resp, err
+	}
+
+	return uc.workflowRepo.GetTransitionLogs(ctx, request.TenantCode, request.ObjectCode, recordSerial)
+}
+
+// matchTransition finds the transition from fromState to toState the role may run
+func matchTransition(machine entity.StateMachine, fieldCode, fromState, toState, role string) (resp entity.StateTransition, err error) {
+	isFound := false
+	for _, transition := range machine.Transitions {
+		if transition.To != toState || !matchesState(transition.From, fromState) {
+			continue
+		}
+
+		isFound = true
+		if roleAllowed(transition, role) {
+			return transition, nil
+		}
+	}
+
+	if isFound {
+		return resp, fmt.Errorf("%w: %v from %v to %v", entity.ErrorTransitionForbidden, fieldCode, fromState, toState)
+	}
+
+	return resp, fmt.Errorf("%w: %v from %v to %v", entity.ErrorTransitionInvalid, fieldCode, fromState, toState)
+}
+
+func matchesState(states []string, state string) bool {
+	return helper.Contains(states, entity.StateAny) || helper.Contains(states, state)
+}
+
+func roleAllowed(transition entity.StateTransition, role string) bool {
+	return len(transition.Roles) == 0 || helper.Contains(transition.Roles, role)
+}
+
+// missingStateFields returns the required fields of a transition the record holds no value for
+func missingStateFields(transition entity.StateTransition, record map[string]entity.DataItem) []string {
+	missingFields := []string{}
+	for _, fieldCode := range transition.RequiredFields {
+		if stateValue(record[fieldCode].Value) == "" {
+			missingFields = append(missingFields, fieldCode)
+		}
+	}
+
+	return missingFields
+}
+
+// stateValue is the text of a state field value, empty when it has none
+func stateValue(value any) string {
+	value = normalizeValue(value)
+	if value == nil {
+		return ""
+	}
+
+	return fmt.Sprintf("%v", value)
+}
+
+// findDataItem returns the item of a field in the items of a mutation
+func findDataItem(items []entity.DataItem, fieldCode string) (entity.DataItem, bool) {
+	for _, item := range items {
+		if item.FieldCode == fieldCode {
+			return item, true
+		}
+	}
+
+	return entity.DataItem{}, false
+}
@@ -0,0 +1,31 @@
+package module
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cerkas/cerkas-backend/core/entity"
+)
+
+func TestGetTransitionHistoryResolvesTheCode(t *testing.T) {
+	serial := "6f1c2a8e-0b5d-4c1e-9a4f-3d2b1c0e9f87"
+	workflowRepo := &fakeWorkflowRepository{logs: []entity.StateTransitionLog{
+		{ObjectCode: "invoice", RecordSerial: serial, ToState: "paid"},
+		{ObjectCode: "invoice", RecordSerial: "another", ToState: "paid"},
+	}}
+	catalogRepo := &fakeCatalogRepository{records: map[string][]map[string]any{
+		"invoice": {{"serial": serial, "code": "INV-001"}},
+	}}
+	uc := &catalogUsecase{catalogRepo: catalogRepo, workflowRepo: workflowRepo}
+
+	for _, recordSerial := range []string{serial, "INV-001"} {
+		history, err := uc.GetTransitionHistory(context.Background(), entity.CatalogQuery{TenantCode: "acme", ObjectCode: "invoice", Serial: recordSerial})
+		if err != nil {
+			t.Fatalf("GetTransitionHistory(%v): %v", recordSerial, err)
+		}
+
+		if len(history) != 1 || history[0].RecordSerial != serial {
+			t.Errorf("history of %v is %+v, want the transition of %v", recordSerial, history, serial)
+		}
+	}
+}
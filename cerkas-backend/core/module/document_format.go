@@ -0,0 +1,180 @@
+package module
+
+import (
+	"fmt"
+	"html/template"
+	"strconv"
+	"strings"
+	"text/template/parse"
+	"time"
+
+	"github.com/cerkas/cerkas-backend/core/entity"
+	"github.com/cerkas/cerkas-backend/pkg/helper"
+)
+
+// numericDataTypes are the column types read as text by the database driver that are still numbers
+var numericDataTypes = []string{"numeric", "decimal", "money", "real", "double precision", "double", "float", "integer", "bigint", "smallint", "int"}
+
+// documentFormatter writes the values of a record the way the tenant of the document reads them
+type documentFormatter struct {
+	format   helper.LocaleFormat
+	location *time.Location
+}
+
+func newDocumentFormatter(tenantLocale entity.TenantLocale) documentFormatter {
+	location, err := time.LoadLocation(tenantLocale.Timezone)
+	if err != nil || tenantLocale.Timezone == "" {
+		location = time.UTC
+	}
+
+	return documentFormatter{
+		format:   helper.GetLocaleFormat(tenantLocale.Locale),
+		location: location,
+	}
+}
+
+// record is the data of a template, the formatted values of a record by field code and its related lists as lists of
+// such records
+func (f documentFormatter) record(record map[string]entity.DataItem) map[string]any {
+	data := map[string]any{}
+	for fieldCode, item := range record {
+		if children, ok := item.Value.(entity.CatalogResponse); ok {
+			items := []map[string]any{}
+			for _, child := range children.Items {
+				items = append(items, f.record(child))
+			}
+
+			data[fieldCode] = items
+			continue
+		}
+
+		data[fieldCode] = f.value(item)
+	}
+
+	return data
+}
+
+// value formats the display value of a field, the value itself when it has none
+func (f documentFormatter) value(item entity.DataItem) string {
+	value := item.DisplayValue
+	if value == nil {
+		value = item.Value
+	}
+
+	switch value := value.(type) {
+	case nil:
+		return ""
+	case entity.FileDisplayValue:
+		return value.FileName
+	case time.Time:
+		if item.DataType == "date" {
+			return value.Format(f.format.DateLayout)
+		}
+
+		return value.In(f.location).Format(f.format.DateTimeLayout)
+	case bool:
+		if value {
+			return f.format.True
+		}
+
+		return f.format.False
+	case int:
+		return f.format.FormatNumber(strconv.Itoa(value))
+	case int32:
+		return f.format.FormatNumber(strconv.FormatInt(int64(value), 10))
+	case int64:
+		return f.format.FormatNumber(strconv.FormatInt(value, 10))
+	case float32:
+		return f.format.FormatNumber(strconv.FormatFloat(float64(value), 'f', -1, 32))
+	case float64:
+		return f.format.FormatNumber(strconv.FormatFloat(value, 'f', -1, 64))
+	case []byte:
+		return f.text(item.DataType, string(value))
+	case string:
+		return f.text(item.DataType, value)
+	case map[string]any:
+		if attachment, ok := parseAttachment(value); ok {
+			return attachment.FileName
+		}
+	}
+
+	return fmt.Sprintf("%v", value)
+}
+
+// text formats a value read as text, numeric columns often are to keep their precision
+func (f documentFormatter) text(dataType, value string) string {
+	if helper.Contains(numericDataTypes, strings.ToLower(dataType)) {
+		return f.format.FormatNumber(value)
+	}
+
+	return value
+}
+
+// templateRelationFields lists the a__b fields a template reads from the fields of related records. Only the fields of
+// the record itself are listed, the dot of a range or with block is another value than the record.
+func templateRelationFields(tmpl *template.Template) map[string]entity.Field {
+	fields := map[string]entity.Field{}
+	for _, definedTemplate := range tmpl.Templates() {
+		if definedTemplate.Tree != nil {
+			collectRelationFields(definedTemplate.Tree.Root, false, fields)
+		}
+	}
+
+	return fields
+}
+
+func collectRelationFields(node parse.Node, nested bool, fields map[string]entity.Field) {
+	addField := func(fieldCode string) {
+		if strings.Contains(fieldCode, "__") {
+			fields[fieldCode] = entity.Field{FieldCode: fieldCode}
+		}
+	}
+
+	switch node := node.(type) {
+	case *parse.ListNode:
+		if node == nil {
+			return
+		}
+
+		for _, child := range node.Nodes {
+			collectRelationFields(child, nested, fields)
+		}
+	case *parse.ActionNode:
+		collectRelationFields(node.Pipe, nested, fields)
+	case *parse.TemplateNode:
+		collectRelationFields(node.Pipe, nested, fields)
+	case *parse.IfNode:
+		collectRelationFields(node.Pipe, nested, fields)
+		collectRelationFields(node.List, nested, fields)
+		collectRelationFields(node.ElseList, nested, fields)
+	case *parse.RangeNode:
+		collectRelationFields(node.Pipe, nested, fields)
+		collectRelationFields(node.List, true, fields)
+		collectRelationFields(node.ElseList, nested, fields)
+	case *parse.WithNode:
+		collectRelationFields(node.Pipe, nested, fields)
+		collectRelationFields(node.List, true, fields)
+		collectRelationFields(node.ElseList, nested, fields)
+	case *parse.PipeNode:
+		if node == nil {
+			return
+		}
+
+		for _, command := range node.Cmds {
+			collectRelationFields(command, nested, fields)
+		}
+	case *parse.CommandNode:
+		for _, arg := range node.Args {
+			collectRelationFields(arg, nested, fields)
+		}
+	case *parse.FieldNode:
+		if !nested {
+			addField(node.Ident[0])
+		}
+	case *parse.VariableNode:
+		// $ is the record in a nested block too
+		if len(node.Ident) > 1 && node.Ident[0] == "$" {
+			addField(node.Ident[1])
+		}
+	}
+}
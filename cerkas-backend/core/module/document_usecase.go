@@ -0,0 +1,251 @@
+package module
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"strings"
+	"time"
+
+	"github.com/cerkas/cerkas-backend/config"
+	"github.com/cerkas/cerkas-backend/core/entity"
+	"github.com/cerkas/cerkas-backend/core/repository"
+	"github.com/cerkas/cerkas-backend/pkg/helper"
+	"github.com/cerkas/cerkas-backend/pkg/pdf"
+)
+
+// defaultPageSize is the page size of a template without one
+const defaultPageSize = "a4"
+
+type DocumentUsecase interface {
+	CreateTemplate(ctx context.Context, request entity.DocumentTemplate, userRole string) (resp entity.DocumentTemplate, err error)
+	GetTemplates(ctx context.Context, tenantCode, objectCode string) (resp []entity.DocumentTemplate, err error)
+	DeleteTemplate(ctx context.Context, tenantCode, serial, userRole string) (err error)
+	RenderDocument(ctx context.Context, request entity.RenderDocumentRequest) (resp entity.RenderedDocument, err error)
+}
+
+type documentUsecase struct {
+	cfg          config.Config
+	documentRepo repository.DocumentRepository
+	catalogRepo  repository.CatalogRepository
+	catalogUc    CatalogUsecase
+}
+
+func NewDocumentUsecase(cfg config.Config, documentRepo repository.DocumentRepository, catalogRepo repository.CatalogRepository, catalogUc CatalogUsecase) DocumentUsecase {
+	return &documentUsecase{
+		cfg:          cfg,
+		documentRepo: documentRepo,
+		catalogRepo:  catalogRepo,
+		catalogUc:    catalogUc,
+	}
+}
+
+// CreateTemplate stores a document template of an object, only an admin can add what every user prints with
+func (uc *documentUsecase) CreateTemplate(ctx context.Context, request entity.DocumentTemplate, userRole string) (resp entity.DocumentTemplate, err error) {
+	if !isAdmin(uc.cfg, userRole) {
+		return resp, entity.ErrorAdminRequired
+	}
+
+	if request.Code == "" || strings.TrimSpace(request.Body) == "" {
+		return resp, fmt.Errorf("%w: code and body are required", entity.ErrorDocumentTemplateInvalid)
+	}
+
+	// a template is checked when it is saved, not when a user first prints with it
+	if _, err := parseDocumentTemplate(request); err != nil {
+		return resp, err
+	}
+
+	request.PageSize = strings.ToLower(request.PageSize)
+	if request.PageSize == "" {
+		request.PageSize = defaultPageSize
+	}
+
+	if _, ok := pdf.PageSizes[request.PageSize]; !ok {
+		return resp, entity.ErrorDocumentPageSizeInvalid
+	}
+
+	_, err = uc.documentRepo.GetTemplateByCode(ctx, request.TenantCode, request.ObjectCode, request.Code)
+	if err == nil {
+		return resp, entity.ErrorDocumentTemplateExists
+	}
+	if !errors.Is(err, entity.ErrorNotFound) {
+		return resp, err
+	}
+
+	request.Serial, err = helper.GenerateUUUID()
+	if err != nil {
+		return resp, err
+	}
+
+	if request.Name == "" {
+		request.Name = request.Code
+	}
+
+	request.IsActive = true
+	request.CreatedAt = time.Now()
+
+	return uc.documentRepo.CreateTemplate(ctx, request)
+}
+
+func (uc *documentUsecase) GetTemplates(ctx context.Context, tenantCode, objectCode string) (resp []entity.DocumentTemplate, err error) {
+	return uc.documentRepo.GetTemplates(ctx, tenantCode, objectCode)
+}
+
+func (uc *documentUsecase) DeleteTemplate(ctx context.Context, tenantCode, serial, userRole string) (err error) {
+	if !isAdmin(uc.cfg, userRole) {
+		return entity.ErrorAdminRequired
+	}
+
+	if serial == "" {
+		return entity.ErrorSerialEmpty
+	}
+
+	return uc.documentRepo.DeleteTemplate(ctx, tenantCode, serial)
+}
+
+// RenderDocument renders a record with a template of its object, as html or as pdf laid out from that html. The
+// record is read through the catalog usecase like any detail request, with the related lists of the template and the
+// related fields its placeholders name.
+func (uc *documentUsecase) RenderDocument(ctx context.Context, request entity.RenderDocumentRequest) (resp entity.RenderedDocument, err error) {
+	if request.Format == "" {
+		request.Format = entity.DocumentFormatHTML
+	}
+
+	if request.Format != entity.DocumentFormatHTML && request.Format != entity.DocumentFormatPDF {
+		return resp, entity.ErrorDocumentFormatInvalid
+	}
+
+	if request.RecordSerial == "" {
+		return resp, entity.ErrorSerialEmpty
+	}
+
+	documentTemplate, err := uc.documentRepo.GetTemplateByCode(ctx, request.TenantCode, request.ObjectCode, request.TemplateCode)
+	if err != nil {
+		return resp, err
+	}
+
+	if !documentTemplate.IsActive {
+		return resp, entity.ErrorNotFound
+	}
+
+	tmpl, err := parseDocumentTemplate(documentTemplate)
+	if err != nil {
+		return resp, err
+	}
+
+	record, err := uc.getDocumentRecord(ctx, request, documentTemplate, tmpl)
+	if err != nil {
+		return resp, err
+	}
+
+	tenantLocale, err := uc.catalogRepo.GetTenantLocale(ctx, request.TenantCode)
+	if err != nil {
+		return resp, err
+	}
+
+	var body bytes.Buffer
+	if err := tmpl.Execute(&body, newDocumentFormatter(tenantLocale).record(record)); err != nil {
+		return resp, fmt.Errorf("%w: %v", entity.ErrorDocumentRenderFailed, err)
+	}
+
+	fileName := fmt.Sprintf("%v-%v", documentTemplate.Code, request.RecordSerial)
+	if request.Format == entity.DocumentFormatHTML {
+		return entity.RenderedDocument{
+			FileName:    fileName + ".html",
+			ContentType: "text/html; charset=utf-8",
+			Content:     body.Bytes(),
+		}, nil
+	}
+
+	var content bytes.Buffer
+	if err := pdf.RenderHTML(&content, body.String(), pdf.PageSizes[documentTemplate.PageSize]); err != nil {
+		return resp, fmt.Errorf("%w: %v", entity.ErrorDocumentRenderFailed, err)
+	}
+
+	return entity.RenderedDocument{
+		FileName:    fileName + ".pdf",
+		ContentType: "application/pdf",
+		Content:     content.Bytes(),
+	}, nil
+}
+
+// getDocumentRecord reads the record of a document with the related lists of the template, the fields of related
+// records are read by a second query since a detail query with fields returns only those fields. The reference fields
+// of the record and of its related lists are resolved to the display name of the record they refer to.
+func (uc *documentUsecase) getDocumentRecord(ctx context.Context, request entity.RenderDocumentRequest, documentTemplate entity.DocumentTemplate, tmpl *template.Template) (record map[string]entity.DataItem, err error) {
+	query := entity.CatalogQuery{
+		TenantCode:  request.TenantCode,
+		ProductCode: request.ProductCode,
+		ObjectCode:  request.ObjectCode,
+		UserSerial:  request.UserSerial,
+		UserRole:    request.UserRole,
+	}
+
+	detailQuery := query
+	detailQuery.Includes = documentTemplate.Includes
+
+	record, err = uc.catalogUc.GetObjectDetail(ctx, detailQuery, request.RecordSerial)
+	if err != nil {
+		return record, err
+	}
+
+	if record == nil {
+		return record, entity.ErrorNotFound
+	}
+
+	if relationFields := templateRelationFields(tmpl); len(relationFields) > 0 {
+		relationQuery := query
+		relationQuery.Fields = relationFields
+
+		related, err := uc.catalogUc.GetObjectDetail(ctx, relationQuery, request.RecordSerial)
+		if err != nil {
+			return record, err
+		}
+
+		for fieldCode := range relationFields {
+			if item, ok := related[fieldCode]; ok {
+				record[fieldCode] = item
+			}
+		}
+	}
+
+	if err := uc.catalogUc.ResolveDisplayValues(ctx, query, []map[string]entity.DataItem{record}); err != nil {
+		return record, err
+	}
+
+	for _, include := range documentTemplate.Includes {
+		name, objectCode := include.Name, include.ObjectCode
+		if objectCode == "" {
+			objectCode = name
+		}
+		if name == "" {
+			name = objectCode
+		}
+
+		children, ok := record[name].Value.(entity.CatalogResponse)
+		if !ok {
+			continue
+		}
+
+		childQuery := query
+		childQuery.ObjectCode = objectCode
+		if err := uc.catalogUc.ResolveDisplayValues(ctx, childQuery, children.Items); err != nil {
+			return record, err
+		}
+	}
+
+	return record, nil
+}
+
+// parseDocumentTemplate parses the body of a template, a placeholder naming a field the record lacks fails the render
+// instead of printing an empty value
+func parseDocumentTemplate(documentTemplate entity.DocumentTemplate) (*template.Template, error) {
+	tmpl, err := template.New(documentTemplate.Code).Option("missingkey=error").Parse(documentTemplate.Body)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", entity.ErrorDocumentTemplateInvalid, err)
+	}
+
+	return tmpl, nil
+}
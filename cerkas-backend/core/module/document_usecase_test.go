@@ -0,0 +1,129 @@
+package module
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/cerkas/cerkas-backend/config"
+	"github.com/cerkas/cerkas-backend/core/entity"
+)
+
+func TestDocumentTemplatesAreKeptToAdmins(t *testing.T) {
+	documentRepo := &fakeDocumentRepository{}
+	uc := NewDocumentUsecase(config.Config{AdminRole: "admin"}, documentRepo, nil, nil)
+	template := entity.DocumentTemplate{TenantCode: "acme", ObjectCode: "invoice", Code: "invoice", Body: "<h1>{{ .Record.name }}</h1>"}
+
+	for _, userRole := range []string{"", "sales"} {
+		if _, err := uc.CreateTemplate(context.Background(), template, userRole); !errors.Is(err, entity.ErrorAdminRequired) {
+			t.Errorf("role %q created a template, err %v", userRole, err)
+		}
+	}
+
+	created, err := uc.CreateTemplate(context.Background(), template, "admin")
+	if err != nil {
+		t.Fatalf("admin could not create a template: %v", err)
+	}
+
+	for _, userRole := range []string{"", "sales"} {
+		if err := uc.DeleteTemplate(context.Background(), "acme", created.Serial, userRole); !errors.Is(err, entity.ErrorAdminRequired) {
+			t.Errorf("role %q deleted a template, err %v", userRole, err)
+		}
+	}
+
+	if err := uc.DeleteTemplate(context.Background(), "acme", created.Serial, "admin"); err != nil {
+		t.Fatalf("admin could not delete the template: %v", err)
+	}
+
+	if len(documentRepo.templates) != 0 {
+		t.Fatalf("template is still stored after it was deleted")
+	}
+}
+
+func newTestDocumentUsecase(templates ...entity.DocumentTemplate) DocumentUsecase {
+	catalogRepo := &fakeCatalogRepository{
+		records: map[string][]map[string]any{
+			"invoice": {{"serial": "i1", "code": "INV-1", "customer": "Smith & Sons", "total": 1250.5, "paid": true}},
+		},
+		locale: entity.TenantLocale{Locale: "de"},
+	}
+
+	return NewDocumentUsecase(config.Config{AdminRole: "admin"}, &fakeDocumentRepository{templates: templates}, catalogRepo, &fakeCatalogUsecase{catalogRepo: catalogRepo})
+}
+
+func invoiceTemplate(code, body string) entity.DocumentTemplate {
+	return entity.DocumentTemplate{TenantCode: "acme", ObjectCode: "invoice", Code: code, Body: body, PageSize: "letter", IsActive: true}
+}
+
+func invoiceDocument(templateCode, format string) entity.RenderDocumentRequest {
+	return entity.RenderDocumentRequest{TenantCode: "acme", ProductCode: "crm", ObjectCode: "invoice", RecordSerial: "i1", TemplateCode: templateCode, Format: format}
+}
+
+func TestRenderDocumentFormatsTheRecord(t *testing.T) {
+	uc := newTestDocumentUsecase(invoiceTemplate("invoice", "<h1>{{ .code }}</h1><p>{{ .customer }}</p><p>{{ .total }} {{ .paid }}</p>"))
+
+	document, err := uc.RenderDocument(context.Background(), invoiceDocument("invoice", ""))
+	if err != nil {
+		t.Fatalf("RenderDocument = %v", err)
+	}
+
+	if want := "<h1>INV-1</h1><p>Smith &amp; Sons</p><p>1.250,5 Ja</p>"; string(document.Content) != want {
+		t.Errorf("html = %s, want %s", document.Content, want)
+	}
+
+	if document.FileName != "invoice-i1.html" || document.ContentType != "text/html; charset=utf-8" {
+		t.Errorf("document = %v %v, want an html file", document.FileName, document.ContentType)
+	}
+
+	document, err = uc.RenderDocument(context.Background(), invoiceDocument("invoice", entity.DocumentFormatPDF))
+	if err != nil {
+		t.Fatalf("pdf: RenderDocument = %v", err)
+	}
+
+	if document.FileName != "invoice-i1.pdf" || document.ContentType != "application/pdf" {
+		t.Errorf("document = %v %v, want a pdf file", document.FileName, document.ContentType)
+	}
+
+	if !bytes.HasPrefix(document.Content, []byte("%PDF-")) || !bytes.Contains(document.Content, []byte("/MediaBox [0 0 612 792]")) {
+		t.Errorf("pdf is not laid out on letter pages")
+	}
+}
+
+func TestRenderDocumentFails(t *testing.T) {
+	inactive := invoiceTemplate("draft", "<p>{{ .code }}</p>")
+	inactive.IsActive = false
+
+	uc := newTestDocumentUsecase(
+		invoiceTemplate("invoice", "<p>{{ .code }}</p>"),
+		invoiceTemplate("broken", "<p>{{ .code </p>"),
+		invoiceTemplate("due", "<p>{{ .due_date }}</p>"),
+		inactive,
+	)
+
+	unknownRecord := invoiceDocument("invoice", "")
+	unknownRecord.RecordSerial = "i9"
+
+	noRecord := invoiceDocument("invoice", "")
+	noRecord.RecordSerial = ""
+
+	tests := []struct {
+		name    string
+		request entity.RenderDocumentRequest
+		want    error
+	}{
+		{"unknown format", invoiceDocument("invoice", "docx"), entity.ErrorDocumentFormatInvalid},
+		{"no record", noRecord, entity.ErrorSerialEmpty},
+		{"unknown template", invoiceDocument("receipt", ""), entity.ErrorNotFound},
+		{"inactive template", invoiceDocument("draft", ""), entity.ErrorNotFound},
+		{"template that does not parse", invoiceDocument("broken", ""), entity.ErrorDocumentTemplateInvalid},
+		{"field the record lacks", invoiceDocument("due", entity.DocumentFormatPDF), entity.ErrorDocumentRenderFailed},
+		{"unknown record", unknownRecord, entity.ErrorNotFound},
+	}
+
+	for _, test := range tests {
+		if _, err := uc.RenderDocument(context.Background(), test.request); !errors.Is(err, test.want) {
+			t.Errorf("%v: RenderDocument = %v, want %v", test.name, err, test.want)
+		}
+	}
+}
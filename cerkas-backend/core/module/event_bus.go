@@ -0,0 +1,182 @@
+package module
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/cerkas/cerkas-backend/config"
+	"github.com/cerkas/cerkas-backend/core/entity"
+	"github.com/cerkas/cerkas-backend/core/repository"
+	"github.com/cerkas/cerkas-backend/pkg/helper"
+)
+
+// EventHandler handles a record event. Events are delivered at least once, handlers must be idempotent.
+type EventHandler func(ctx context.Context, event entity.RecordEvent) (err error)
+
+type EventBus interface {
+	Publish(ctx context.Context, events ...entity.RecordEvent) (err error)
+	Subscribe(name string, handler EventHandler)
+	Dispatch(ctx context.Context) (dispatched int, err error)
+	RunRelay(ctx context.Context)
+}
+
+type eventSubscriber struct {
+	name    string
+	handler EventHandler
+}
+
+type eventBus struct {
+	cfg        config.Config
+	outboxRepo repository.OutboxRepository
+
+	mu          sync.RWMutex
+	subscribers []eventSubscriber
+}
+
+func NewEventBus(cfg config.Config, outboxRepo repository.OutboxRepository) EventBus {
+	return &eventBus{
+		cfg:        cfg,
+		outboxRepo: outboxRepo,
+	}
+}
+
+// Publish writes the events to the outbox. Called with the context of a transaction, the events are
+// committed or rolled back together with the mutation.
+func (b *eventBus) Publish(ctx context.Context, events ...entity.RecordEvent) (err error) {
+	for i := range events {
+		if events[i].Serial == "" {
+			events[i].Serial, err = helper.GenerateUUUID()
+			if err != nil {
+				return err
+			}
+		}
+
+		if events[i].OccurredAt.IsZero() {
+			events[i].OccurredAt = time.Now()
+		}
+	}
+
+	return b.outboxRepo.CreateEvents(ctx, events)
+}
+
+// Subscribe registers a handler under a unique name, the name is how the outbox remembers which
+// subscribers already handled an event
+func (b *eventBus) Subscribe(name string, handler EventHandler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.subscribers = append(b.subscribers, eventSubscriber{name: name, handler: handler})
+}
+
+// Dispatch hands a batch of due outbox events to the subscribers, in order per record.
+// Failed subscribers are retried with backoff, the ones that succeeded are not called again.
+func (b *eventBus) Dispatch(ctx context.Context) (dispatched int, err error) {
+	lease := time.Duration(b.cfg.EventRelayLease) * time.Second
+
+	events, err := b.outboxRepo.ClaimEvents(ctx, b.cfg.EventRelayBatchSize, lease)
+	if err != nil {
+		return 0, err
+	}
+
+	b.mu.RLock()
+	subscribers := append([]eventSubscriber{}, b.subscribers...)
+	b.mu.RUnlock()
+
+	for _, event := range events {
+		event.Attempts++
+		event.LastError = ""
+
+		for _, subscriber := range subscribers {
+			if helper.Contains(event.CompletedSubscribers, subscriber.name) {
+				continue
+			}
+
+			if err := handleEvent(ctx, subscriber, event.Event); err != nil {
+				event.LastError = fmt.Sprintf("%v: %v", subscriber.name, err)
+				continue
+			}
+
+			event.CompletedSubscribers = append(event.CompletedSubscribers, subscriber.name)
+		}
+
+		switch {
+		case event.LastError == "":
+			now := time.Now()
+			event.Status = entity.OutboxStatusDispatched
+			event.DispatchedAt = &now
+			dispatched++
+		case event.Attempts >= b.cfg.EventMaxAttempts:
+			// a failed event no longer holds back the next events of its record
+			event.Status = entity.OutboxStatusFailed
+			log.Printf("event %v of %v.%v %v failed after %v attempts: %v", event.Event.Type, event.Event.TenantCode, event.Event.ObjectCode, event.Event.RecordSerial, event.Attempts, event.LastError)
+		default:
+			event.NextAttemptAt = time.Now().Add(time.Second << min(event.Attempts, 10))
+		}
+
+		if err := b.outboxRepo.UpdateEvent(ctx, event); err != nil {
+			log.Printf("failed to update outbox event %v: %v", event.Event.Serial, err)
+		}
+	}
+
+	return dispatched, nil
+}
+
+// RunRelay dispatches the outbox until ctx is done
+func (b *eventBus) RunRelay(ctx context.Context) {
+	interval := time.Duration(b.cfg.EventRelayInterval) * time.Second
+	if interval <= 0 {
+		interval = time.Second
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			// keep going while there is work, the next event of a record is only claimable once the previous one is done
+			for {
+				dispatched, err := b.Dispatch(ctx)
+				if err != nil {
+					log.Println("failed to dispatch events:", err)
+				}
+
+				if err != nil || dispatched == 0 {
+					break
+				}
+			}
+		}
+	}
+}
+
+// handleEvent calls a subscriber, a panicking subscriber fails the event instead of the relay
+func handleEvent(ctx context.Context, subscriber eventSubscriber, event entity.RecordEvent) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("panic: %v", recovered)
+		}
+	}()
+
+	return subscriber.handler(ctx, event)
+}
+
+// newRecordEvent builds the event of a mutation, oldItems and newItems are the record before and after it
+func newRecordEvent(eventType entity.RecordEventType, request entity.DataMutationRequest, oldItems, newItems map[string]entity.DataItem) entity.RecordEvent {
+	return entity.RecordEvent{
+		Type:         eventType,
+		TenantCode:   request.TenantCode,
+		ProductCode:  request.ProductCode,
+		ObjectCode:   request.ObjectCode,
+		RecordSerial: mutationRecordSerial(request, oldItems),
+		ActorSerial:  request.UserSerial,
+		Items:        request.Items,
+		Data:         normalizeDataItems(newItems),
+		OldData:      normalizeDataItems(oldItems),
+		OccurredAt:   time.Now(),
+	}
+}
@@ -0,0 +1,104 @@
+package module
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cerkas/cerkas-backend/config"
+	"github.com/cerkas/cerkas-backend/core/entity"
+)
+
+func TestPublishFillsSerialAndTime(t *testing.T) {
+	outboxRepo := &fakeOutboxRepository{}
+	bus := NewEventBus(config.Config{}, outboxRepo)
+
+	if err := bus.Publish(context.Background(), entity.RecordEvent{Type: entity.RecordCreated}, entity.RecordEvent{Type: entity.RecordUpdated}); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+
+	if len(outboxRepo.events) != 2 {
+		t.Fatalf("outbox holds %v events, want 2", len(outboxRepo.events))
+	}
+
+	for _, event := range outboxRepo.events {
+		if event.Event.Serial == "" || event.Event.OccurredAt.IsZero() {
+			t.Errorf("event %+v has no serial or time", event.Event)
+		}
+	}
+
+	if outboxRepo.events[0].Event.Serial == outboxRepo.events[1].Event.Serial {
+		t.Errorf("events share the serial %v", outboxRepo.events[0].Event.Serial)
+	}
+}
+
+func TestDispatchRetriesOnlyTheFailedSubscribers(t *testing.T) {
+	outboxRepo := &fakeOutboxRepository{}
+	bus := NewEventBus(config.Config{EventMaxAttempts: 3, EventRelayBatchSize: 10}, outboxRepo)
+
+	calls := map[string]int{}
+	bus.Subscribe("audit", func(ctx context.Context, event entity.RecordEvent) error {
+		calls["audit"]++
+		return nil
+	})
+	bus.Subscribe("webhook", func(ctx context.Context, event entity.RecordEvent) error {
+		calls["webhook"]++
+		if calls["webhook"] == 1 {
+			return errors.New("receiver is down")
+		}
+		return nil
+	})
+
+	if err := bus.Publish(context.Background(), entity.RecordEvent{Type: entity.RecordCreated}); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+
+	if dispatched, err := bus.Dispatch(context.Background()); err != nil || dispatched != 0 {
+		t.Fatalf("first dispatch = %v, %v, want nothing dispatched", dispatched, err)
+	}
+
+	event := outboxRepo.events[0]
+	if event.Status != entity.OutboxStatusPending || event.LastError == "" || !event.NextAttemptAt.After(time.Now()) {
+		t.Fatalf("failed event is %+v, want it pending with a backoff", event)
+	}
+
+	// the backoff is over
+	outboxRepo.events[0].NextAttemptAt = time.Now()
+
+	if dispatched, err := bus.Dispatch(context.Background()); err != nil || dispatched != 1 {
+		t.Fatalf("second dispatch = %v, %v, want the event dispatched", dispatched, err)
+	}
+
+	if calls["audit"] != 1 || calls["webhook"] != 2 {
+		t.Errorf("subscribers were called %v, want audit once and webhook twice", calls)
+	}
+
+	if outboxRepo.events[0].Status != entity.OutboxStatusDispatched || outboxRepo.events[0].DispatchedAt == nil {
+		t.Errorf("event is %+v, want it dispatched", outboxRepo.events[0])
+	}
+}
+
+func TestDispatchFailsAnEventAfterItsAttempts(t *testing.T) {
+	outboxRepo := &fakeOutboxRepository{}
+	bus := NewEventBus(config.Config{EventMaxAttempts: 2, EventRelayBatchSize: 10}, outboxRepo)
+	bus.Subscribe("broken", func(ctx context.Context, event entity.RecordEvent) error {
+		panic("nil map")
+	})
+
+	if err := bus.Publish(context.Background(), entity.RecordEvent{Type: entity.RecordDeleted}); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+
+	for attempt := 1; attempt <= 2; attempt++ {
+		outboxRepo.events[0].NextAttemptAt = time.Now()
+		if _, err := bus.Dispatch(context.Background()); err != nil {
+			t.Fatalf("dispatch %v: %v", attempt, err)
+		}
+	}
+
+	event := outboxRepo.events[0]
+	if event.Status != entity.OutboxStatusFailed || event.Attempts != 2 {
+		t.Fatalf("event is %+v, want it failed after 2 attempts", event)
+	}
+}
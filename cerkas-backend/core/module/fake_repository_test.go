@@ -0,0 +1,1015 @@
+package module
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/cerkas/cerkas-backend/core/entity"
+	"github.com/cerkas/cerkas-backend/core/repository"
+	"github.com/cerkas/cerkas-backend/pkg/helper"
+)
+
+// eventTypeColumnWidth is the width of audit_log.action and webhook_delivery.event_type
+const eventTypeColumnWidth = 64
+
+// fakeAuditRepository keeps audit logs in memory and rejects an action the column can not hold
+type fakeAuditRepository struct {
+	repository.AuditRepository
+
+	mu   sync.Mutex
+	logs []entity.AuditLog
+}
+
+func (r *fakeAuditRepository) CreateAuditLog(ctx context.Context, auditLog entity.AuditLog) (err error) {
+	if len(auditLog.Action) > eventTypeColumnWidth {
+		return fmt.Errorf("value too long for type character varying(%v): %v", eventTypeColumnWidth, auditLog.Action)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.logs = append(r.logs, auditLog)
+
+	return nil
+}
+
+func (r *fakeAuditRepository) GetAuditLogsByRecord(ctx context.Context, tenantCode, objectCode, recordSerial string) (resp []entity.AuditLog, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, auditLog := range r.logs {
+		if auditLog.ObjectCode == objectCode && auditLog.RecordSerial == recordSerial {
+			resp = append(resp, auditLog)
+		}
+	}
+
+	return resp, nil
+}
+
+// fakeWebhookRepository keeps subscriptions and deliveries in memory
+type fakeWebhookRepository struct {
+	repository.WebhookRepository
+
+	mu            sync.Mutex
+	subscriptions []entity.WebhookSubscription
+	deliveries    []entity.WebhookDelivery
+}
+
+func (r *fakeWebhookRepository) CreateSubscription(ctx context.Context, subscription entity.WebhookSubscription) (resp entity.WebhookSubscription, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.subscriptions = append(r.subscriptions, subscription)
+	return subscription, nil
+}
+
+func (r *fakeWebhookRepository) GetActiveSubscriptions(ctx context.Context, tenantCode, objectCode, eventType string) (resp []entity.WebhookSubscription, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, subscription := range r.subscriptions {
+		if subscription.IsActive && subscription.TenantCode == tenantCode && subscription.ObjectCode == objectCode && helper.Contains(subscription.EventTypes, eventType) {
+			resp = append(resp, subscription)
+		}
+	}
+
+	return resp, nil
+}
+
+func (r *fakeWebhookRepository) GetSubscriptionsBySerials(ctx context.Context, serials []string) (resp []entity.WebhookSubscription, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, subscription := range r.subscriptions {
+		if helper.Contains(serials, subscription.Serial) {
+			resp = append(resp, subscription)
+		}
+	}
+
+	return resp, nil
+}
+
+func (r *fakeWebhookRepository) CreateDeliveries(ctx context.Context, deliveries []entity.WebhookDelivery) (err error) {
+	for _, delivery := range deliveries {
+		if len(delivery.EventType) > eventTypeColumnWidth {
+			return fmt.Errorf("value too long for type character varying(%v): %v", eventTypeColumnWidth, delivery.EventType)
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.deliveries = append(r.deliveries, deliveries...)
+
+	return nil
+}
+
+// ClaimDueDeliveries returns the pending and retrying deliveries that are due, the lease is not modelled
+func (r *fakeWebhookRepository) ClaimDueDeliveries(ctx context.Context, limit int, lease time.Duration) (resp []entity.WebhookDelivery, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for _, delivery := range r.deliveries {
+		if len(resp) == limit {
+			break
+		}
+
+		due := delivery.Status == entity.WebhookDeliveryStatusPending || delivery.Status == entity.WebhookDeliveryStatusRetrying
+		if due && !delivery.NextAttemptAt.After(now) {
+			resp = append(resp, delivery)
+		}
+	}
+
+	return resp, nil
+}
+
+func (r *fakeWebhookRepository) UpdateDelivery(ctx context.Context, delivery entity.WebhookDelivery) (err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.deliveries {
+		if r.deliveries[i].Serial == delivery.Serial {
+			r.deliveries[i] = delivery
+			return nil
+		}
+	}
+
+	return entity.ErrorNotFound
+}
+
+func (r *fakeWebhookRepository) delivery(serial string) entity.WebhookDelivery {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, delivery := range r.deliveries {
+		if delivery.Serial == serial {
+			return delivery
+		}
+	}
+
+	return entity.WebhookDelivery{}
+}
+
+// fakeCatalogRepository keeps the records of objects in memory, reference fields point at another object by foreign
+// key. It counts the data queries, so tests can tell how often records are read.
+type fakeCatalogRepository struct {
+	repository.CatalogRepository
+
+	objects      []entity.Objects
+	version      string
+	columns      map[string][]string
+	columnTypes  map[string]string
+	references   map[string]entity.ForeignKeyInfo
+	records      map[string][]map[string]any
+	fileFields   map[string][]entity.FileField
+	objectFields map[string]map[string]any
+	relations    map[string][]entity.ReverseRelation
+	rollups      map[string][]entity.RollupField
+	refreshed    map[string][]string
+	locale       entity.TenantLocale
+	queries      []entity.CatalogQuery
+}
+
+// GetObjectByCode finds the objects that have object fields, the serial of an object is its code
+func (r *fakeCatalogRepository) GetObjectByCode(ctx context.Context, objectCode, tenantCode string) (resp entity.Objects, err error) {
+	if _, ok := r.objectFields[objectCode]; ok {
+		resp.Serial = objectCode
+	}
+
+	return resp, nil
+}
+
+func (r *fakeCatalogRepository) GetReferenceTarget(ctx context.Context, tenantCode, objectCode, fieldCode string) (resp entity.ForeignKeyInfo, err error) {
+	return resp, nil
+}
+
+func (r *fakeCatalogRepository) GetForeignKeyInfo(ctx context.Context, tableName, columnName, schemaName string) (resp entity.ForeignKeyInfo, err error) {
+	return r.references[tableName+"."+columnName], nil
+}
+
+func (r *fakeCatalogRepository) GetColumnList(ctx context.Context, request entity.CatalogQuery) (columns []map[string]interface{}, columnStrings string, joinQueryMap map[string]string, joinQueryOrder []string, err error) {
+	for _, columnCode := range r.columns[request.ObjectCode] {
+		column := map[string]interface{}{entity.FieldColumnCode: columnCode}
+		if dataType, ok := r.columnTypes[request.ObjectCode+"."+columnCode]; ok {
+			column[entity.FieldDataType] = dataType
+		}
+		if reference, ok := r.references[request.ObjectCode+"."+columnCode]; ok {
+			column[entity.FieldForeignTableName] = reference.ForeignTable
+		}
+		columns = append(columns, column)
+	}
+
+	return columns, columnStrings, joinQueryMap, joinQueryOrder, nil
+}
+
+// GetObjectData lists the records matching the filters in their stored order, a page is cut when PageSize is set
+func (r *fakeCatalogRepository) GetObjectData(ctx context.Context, request entity.CatalogQuery) (resp entity.CatalogResponse, err error) {
+	r.queries = append(r.queries, request)
+
+	for _, record := range r.records[request.ObjectCode] {
+		if !matchFilters(request.Filters, record) {
+			continue
+		}
+
+		item := map[string]entity.DataItem{}
+		for fieldCode, value := range record {
+			item[fieldCode] = entity.DataItem{FieldCode: fieldCode, Value: value}
+		}
+		resp.Items = append(resp.Items, item)
+	}
+
+	resp.TotalData = len(resp.Items)
+	if request.PageSize > 0 {
+		page := max(request.Page, 1)
+		start := min((page-1)*request.PageSize, len(resp.Items))
+		end := min(start+request.PageSize, len(resp.Items))
+
+		resp.Items = resp.Items[start:end]
+		resp.Page = page
+		resp.PageSize = request.PageSize
+		resp.TotalPage = (resp.TotalData + request.PageSize - 1) / request.PageSize
+	}
+
+	return resp, nil
+}
+
+// GetGroupCounts counts the records matching the filters per value of a field, in the order values are found
+func (r *fakeCatalogRepository) GetGroupCounts(ctx context.Context, request entity.CatalogQuery, fieldName string) (resp []entity.GroupCount, err error) {
+	index := map[any]int{}
+	for _, record := range r.records[request.ObjectCode] {
+		if !matchFilters(request.Filters, record) {
+			continue
+		}
+
+		value := record[fieldName]
+		if i, ok := index[value]; ok {
+			resp[i].Count++
+			continue
+		}
+
+		index[value] = len(resp)
+		resp = append(resp, entity.GroupCount{Value: value, Count: 1})
+	}
+
+	return resp, nil
+}
+
+func (r *fakeCatalogRepository) GetReverseRelations(ctx context.Context, tenantCode, objectCode string) (resp []entity.ReverseRelation, err error) {
+	return r.relations[objectCode], nil
+}
+
+func (r *fakeCatalogRepository) GetDataTypeBySerials(ctx context.Context, serials []string) (resp []entity.DataType, err error) {
+	return resp, nil
+}
+
+func (r *fakeCatalogRepository) GetFileFields(ctx context.Context, tenantCode, objectCode string) (resp []entity.FileField, err error) {
+	return r.fileFields[objectCode], nil
+}
+
+// PurgeDeletedData removes the records holding a deleted_at before the time
+func (r *fakeCatalogRepository) PurgeDeletedData(ctx context.Context, tenantCode, objectCode string, before time.Time, fieldCodes []string) (purged []map[string]any, err error) {
+	kept := []map[string]any{}
+	for _, record := range r.records[objectCode] {
+		deletedAt, ok := record["deleted_at"].(time.Time)
+		if !ok || !deletedAt.Before(before) {
+			kept = append(kept, record)
+			continue
+		}
+
+		item := map[string]any{"serial": record["serial"]}
+		for _, fieldCode := range fieldCodes {
+			item[fieldCode] = record[fieldCode]
+		}
+		purged = append(purged, item)
+	}
+
+	r.records[objectCode] = kept
+	return purged, nil
+}
+
+// fakeTransactor runs the function without a transaction
+type fakeTransactor struct{}
+
+func (fakeTransactor) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	return fn(ctx)
+}
+
+// fakeEventBus keeps the published events
+type fakeEventBus struct {
+	EventBus
+
+	events []entity.RecordEvent
+}
+
+func (b *fakeEventBus) Publish(ctx context.Context, events ...entity.RecordEvent) (err error) {
+	b.events = append(b.events, events...)
+	return nil
+}
+
+// fakeFileStorage keeps the files in memory and the keys it was asked to delete
+type fakeFileStorage struct {
+	repository.FileStorage
+
+	files   map[string][]byte
+	deleted []string
+}
+
+func (s *fakeFileStorage) Save(ctx context.Context, key string, content io.Reader) (size int64, err error) {
+	data, err := io.ReadAll(content)
+	if err != nil {
+		return 0, err
+	}
+
+	if s.files == nil {
+		s.files = map[string][]byte{}
+	}
+
+	s.files[key] = data
+	return int64(len(data)), nil
+}
+
+func (s *fakeFileStorage) Open(ctx context.Context, key string) (content io.ReadCloser, err error) {
+	data, ok := s.files[key]
+	if !ok {
+		return nil, entity.ErrorNotFound
+	}
+
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (s *fakeFileStorage) Delete(ctx context.Context, key string) (err error) {
+	delete(s.files, key)
+	s.deleted = append(s.deleted, key)
+	return nil
+}
+
+// GetObjectDetail finds a record by its serial or its code, a soft deleted record is not found
+func (r *fakeCatalogRepository) GetObjectDetail(ctx context.Context, request entity.CatalogQuery) (resp map[string]entity.DataItem, err error) {
+	for _, record := range r.records[request.ObjectCode] {
+		if record["serial"] != request.Serial && record["code"] != request.Serial || record["deleted_at"] != nil {
+			continue
+		}
+
+		resp = map[string]entity.DataItem{}
+		for fieldCode, value := range record {
+			resp[fieldCode] = entity.DataItem{FieldCode: fieldCode, Value: value}
+		}
+
+		return resp, nil
+	}
+
+	return resp, nil
+}
+
+func (r *fakeCatalogRepository) GetObjectsByTenant(ctx context.Context, tenantCode string) (resp []entity.Objects, err error) {
+	return r.objects, nil
+}
+
+func (r *fakeCatalogRepository) GetMetadataVersion(ctx context.Context, tenantCode string) (version string, err error) {
+	return r.version, nil
+}
+
+func (r *fakeCatalogRepository) GetTenantLocale(ctx context.Context, tenantCode string) (resp entity.TenantLocale, err error) {
+	return r.locale, nil
+}
+
+func (r *fakeCatalogRepository) GetObjectFieldsByObjectCode(ctx context.Context, request entity.CatalogQuery) (resp map[string]any, err error) {
+	return r.objectFields[request.ObjectCode], nil
+}
+
+// RestoreObjectData clears the deleted_at of a record
+func (r *fakeCatalogRepository) RestoreObjectData(ctx context.Context, request entity.DataMutationRequest) (err error) {
+	for _, record := range r.records[request.ObjectCode] {
+		if record["serial"] == request.Serial {
+			delete(record, "deleted_at")
+			return nil
+		}
+	}
+
+	return entity.ErrorNotFound
+}
+
+// fakeApprovalRepository keeps approval policies in memory
+type fakeApprovalRepository struct {
+	repository.ApprovalRepository
+
+	policies map[string]entity.ApprovalPolicy
+}
+
+func (r *fakeApprovalRepository) CreatePolicy(ctx context.Context, policy entity.ApprovalPolicy) (resp entity.ApprovalPolicy, err error) {
+	if r.policies == nil {
+		r.policies = map[string]entity.ApprovalPolicy{}
+	}
+
+	r.policies[policy.Serial] = policy
+	return policy, nil
+}
+
+func (r *fakeApprovalRepository) DeletePolicy(ctx context.Context, tenantCode, serial string) (err error) {
+	if _, ok := r.policies[serial]; !ok {
+		return entity.ErrorNotFound
+	}
+
+	delete(r.policies, serial)
+	return nil
+}
+
+// fakeScheduleRepository keeps scheduled jobs and their runs in memory, the runs are written by the goroutines of the
+// scheduler
+type fakeScheduleRepository struct {
+	repository.ScheduleRepository
+
+	mu   sync.Mutex
+	jobs []entity.ScheduledJob
+	runs []entity.ScheduledJobRun
+}
+
+func (r *fakeScheduleRepository) CreateJob(ctx context.Context, job entity.ScheduledJob) (resp entity.ScheduledJob, err error) {
+	r.jobs = append(r.jobs, job)
+	return job, nil
+}
+
+// fakeRuleRepository keeps rules in memory, GetActiveRules returns them in the order they were stored
+type fakeRuleRepository struct {
+	repository.RuleRepository
+
+	rules []entity.ObjectRule
+}
+
+func (r *fakeRuleRepository) CreateRule(ctx context.Context, rule entity.ObjectRule) (resp entity.ObjectRule, err error) {
+	r.rules = append(r.rules, rule)
+	return rule, nil
+}
+
+func (r *fakeRuleRepository) GetActiveRules(ctx context.Context, tenantCode, objectCode, timing, eventType string) (resp []entity.ObjectRule, err error) {
+	for _, rule := range r.rules {
+		if rule.ObjectCode == objectCode && rule.Timing == timing && rule.IsActive && helper.Contains(rule.EventTypes, eventType) {
+			resp = append(resp, rule)
+		}
+	}
+
+	return resp, nil
+}
+
+func (r *fakeRuleRepository) DeleteRule(ctx context.Context, tenantCode, serial string) (err error) {
+	for i, rule := range r.rules {
+		if rule.Serial == serial {
+			r.rules = append(r.rules[:i], r.rules[i+1:]...)
+			return nil
+		}
+	}
+
+	return entity.ErrorNotFound
+}
+
+// fakeJobRepository keeps jobs in memory
+type fakeJobRepository struct {
+	repository.JobRepository
+
+	jobs []entity.Job
+}
+
+func (r *fakeJobRepository) CreateJob(ctx context.Context, job entity.Job) (resp entity.Job, err error) {
+	r.jobs = append(r.jobs, job)
+	return job, nil
+}
+
+func (r *fakeJobRepository) GetJob(ctx context.Context, tenantCode, serial string) (resp entity.Job, err error) {
+	for _, job := range r.jobs {
+		if job.TenantCode == tenantCode && job.Serial == serial {
+			return job, nil
+		}
+	}
+
+	return resp, entity.ErrorNotFound
+}
+
+func (r *fakeJobRepository) GetJobs(ctx context.Context, request entity.JobQuery) (resp []entity.Job, total int64, err error) {
+	for _, job := range r.jobs {
+		if job.TenantCode == request.TenantCode && (request.CreatedBy == "" || job.CreatedBy == request.CreatedBy) {
+			resp = append(resp, job)
+		}
+	}
+
+	return resp, int64(len(resp)), nil
+}
+
+// ClaimJob starts an attempt of the first queued job that is due
+func (r *fakeJobRepository) ClaimJob(ctx context.Context, lease time.Duration) (resp entity.Job, claimed bool, err error) {
+	now := time.Now()
+	for i, job := range r.jobs {
+		if job.Status == entity.JobStatusQueued && !job.RunAt.After(now) {
+			r.jobs[i].Status = entity.JobStatusRunning
+			r.jobs[i].Attempts++
+			r.jobs[i].StartedAt = &now
+			return r.jobs[i], true, nil
+		}
+	}
+
+	return resp, false, nil
+}
+
+// UpdateJobProgress stores the progress of a running job and tells whether a cancel was requested for it
+func (r *fakeJobRepository) UpdateJobProgress(ctx context.Context, job entity.Job, lease time.Duration) (cancelRequested bool, err error) {
+	for i := range r.jobs {
+		if r.jobs[i].Serial == job.Serial {
+			r.jobs[i].Progress = job.Progress
+			r.jobs[i].ProgressMessage = job.ProgressMessage
+			return r.jobs[i].CancelRequested, nil
+		}
+	}
+
+	return false, entity.ErrorNotFound
+}
+
+func (r *fakeJobRepository) FinishJob(ctx context.Context, job entity.Job) (err error) {
+	for i := range r.jobs {
+		if r.jobs[i].Serial == job.Serial {
+			r.jobs[i] = job
+			return nil
+		}
+	}
+
+	return entity.ErrorNotFound
+}
+
+func (r *fakeJobRepository) CancelJob(ctx context.Context, tenantCode, serial string) (resp entity.Job, err error) {
+	for i, job := range r.jobs {
+		if job.TenantCode == tenantCode && job.Serial == serial {
+			r.jobs[i].Status = entity.JobStatusCancelled
+			return r.jobs[i], nil
+		}
+	}
+
+	return resp, entity.ErrorNotFound
+}
+
+// fakeDocumentRepository keeps document templates in memory
+type fakeDocumentRepository struct {
+	repository.DocumentRepository
+
+	templates []entity.DocumentTemplate
+}
+
+func (r *fakeDocumentRepository) CreateTemplate(ctx context.Context, template entity.DocumentTemplate) (resp entity.DocumentTemplate, err error) {
+	r.templates = append(r.templates, template)
+	return template, nil
+}
+
+func (r *fakeDocumentRepository) GetTemplateByCode(ctx context.Context, tenantCode, objectCode, code string) (resp entity.DocumentTemplate, err error) {
+	for _, template := range r.templates {
+		if template.TenantCode == tenantCode && template.ObjectCode == objectCode && template.Code == code {
+			return template, nil
+		}
+	}
+
+	return resp, entity.ErrorNotFound
+}
+
+func (r *fakeDocumentRepository) DeleteTemplate(ctx context.Context, tenantCode, serial string) (err error) {
+	for i, template := range r.templates {
+		if template.TenantCode == tenantCode && template.Serial == serial {
+			r.templates = append(r.templates[:i], r.templates[i+1:]...)
+			return nil
+		}
+	}
+
+	return entity.ErrorNotFound
+}
+
+// fakeWorkflowRepository keeps transition logs in memory
+type fakeWorkflowRepository struct {
+	repository.WorkflowRepository
+
+	logs []entity.StateTransitionLog
+}
+
+func (r *fakeWorkflowRepository) GetTransitionLogs(ctx context.Context, tenantCode, objectCode, recordSerial string) (resp []entity.StateTransitionLog, err error) {
+	for _, transitionLog := range r.logs {
+		if transitionLog.ObjectCode == objectCode && transitionLog.RecordSerial == recordSerial {
+			resp = append(resp, transitionLog)
+		}
+	}
+
+	return resp, nil
+}
+
+// fakeVersionRepository keeps record versions in memory
+type fakeVersionRepository struct {
+	repository.VersionRepository
+
+	versions []entity.RecordVersion
+}
+
+func (r *fakeVersionRepository) GetRecordVersion(ctx context.Context, tenantCode, objectCode, recordSerial string, version int) (resp entity.RecordVersion, err error) {
+	for _, recordVersion := range r.versions {
+		if recordVersion.ObjectCode == objectCode && recordVersion.RecordSerial == recordSerial && recordVersion.Version == version {
+			return recordVersion, nil
+		}
+	}
+
+	return resp, entity.ErrorNotFound
+}
+
+// fakeCatalogUsecase writes updates to the records of a fakeCatalogRepository and keeps the mutations. Its one
+// view lists the records matching the filters of the view schema, a view without a serial is not found.
+type fakeCatalogUsecase struct {
+	CatalogUsecase
+
+	catalogRepo *fakeCatalogRepository
+	mutations   []entity.DataMutationRequest
+	view        entity.ViewContentResponse
+}
+
+func (uc *fakeCatalogUsecase) UpdateObjectData(ctx context.Context, request entity.DataMutationRequest) (resp entity.CatalogResponse, err error) {
+	uc.mutations = append(uc.mutations, request)
+
+	for _, record := range uc.catalogRepo.records[request.ObjectCode] {
+		if record["serial"] == request.Serial {
+			for _, item := range request.Items {
+				record[item.FieldCode] = item.Value
+			}
+			return resp, nil
+		}
+	}
+
+	return resp, entity.ErrorNotFound
+}
+
+func (uc *fakeCatalogUsecase) GetObjectDetail(ctx context.Context, request entity.CatalogQuery, serial string) (resp map[string]entity.DataItem, err error) {
+	return uc.catalogRepo.GetObjectDetail(ctx, entity.CatalogQuery{TenantCode: request.TenantCode, ObjectCode: request.ObjectCode, Serial: serial})
+}
+
+func (uc *fakeCatalogUsecase) GetObjectFieldsByObjectCode(ctx context.Context, request entity.CatalogQuery) (resp map[string]any, err error) {
+	return uc.catalogRepo.GetObjectFieldsByObjectCode(ctx, request)
+}
+
+// ResolveDisplayValues keeps the values of the records, the fake has no references to resolve
+func (uc *fakeCatalogUsecase) ResolveDisplayValues(ctx context.Context, request entity.CatalogQuery, records []map[string]entity.DataItem) (err error) {
+	return nil
+}
+
+// fakeOutboxRepository keeps outbox events in memory, ClaimEvents returns the pending events that are due
+type fakeOutboxRepository struct {
+	repository.OutboxRepository
+
+	events []entity.OutboxEvent
+}
+
+func (r *fakeOutboxRepository) CreateEvents(ctx context.Context, events []entity.RecordEvent) (err error) {
+	for _, event := range events {
+		r.events = append(r.events, entity.OutboxEvent{Event: event, Status: entity.OutboxStatusPending})
+	}
+
+	return nil
+}
+
+func (r *fakeOutboxRepository) ClaimEvents(ctx context.Context, limit int, lease time.Duration) (resp []entity.OutboxEvent, err error) {
+	for _, event := range r.events {
+		if event.Status == entity.OutboxStatusPending && !event.NextAttemptAt.After(time.Now()) {
+			resp = append(resp, event)
+		}
+	}
+
+	return resp, nil
+}
+
+func (r *fakeOutboxRepository) UpdateEvent(ctx context.Context, event entity.OutboxEvent) (err error) {
+	for i := range r.events {
+		if r.events[i].Event.Serial == event.Event.Serial {
+			r.events[i] = event
+			return nil
+		}
+	}
+
+	return entity.ErrorNotFound
+}
+
+func (uc *fakeCatalogUsecase) GetContentLayoutByKeys(ctx context.Context, request entity.GetViewContentByKeysRequest, catalogQuery entity.CatalogQuery) (resp entity.ViewContentResponse, err error) {
+	return uc.view, nil
+}
+
+func (uc *fakeCatalogUsecase) GetObjectData(ctx context.Context, request entity.CatalogQuery) (resp entity.CatalogResponse, err error) {
+	request.Filters = append(request.Filters, viewSchemaFilters(uc.view.ViewContent.ViewSchema.Query)...)
+	return uc.catalogRepo.GetObjectData(ctx, request)
+}
+
+// fakeViewRepository keeps view contents, schemas and layouts in memory, views are listed in the order they were made
+type fakeViewRepository struct {
+	repository.ViewRepository
+
+	contents  []entity.ViewContentRecord
+	schemas   []entity.ViewSchema
+	layouts   []entity.ViewLayout
+	favorites map[string][]string
+	chain     []entity.ViewContentRecord
+}
+
+func (r *fakeViewRepository) GetViewContent(ctx context.Context, tenantCode, productCode, objectCode, code, layoutType string) (resp entity.ViewContentRecord, err error) {
+	for _, content := range r.contents {
+		if content.TenantCode == tenantCode && content.ProductCode == productCode && content.ObjectCode == objectCode && content.Code == code && content.LayoutType == layoutType {
+			return content, nil
+		}
+	}
+
+	return resp, entity.ErrorNotFound
+}
+
+func (r *fakeViewRepository) GetViewContents(ctx context.Context, tenantCode, productCode, objectCode, layoutType, ownerSerial string) (resp []entity.ViewContentRecord, err error) {
+	for _, content := range r.contents {
+		if content.TenantCode == tenantCode && content.ProductCode == productCode && content.ObjectCode == objectCode && content.LayoutType == layoutType && content.OwnerSerial == ownerSerial {
+			resp = append(resp, content)
+		}
+	}
+
+	return resp, nil
+}
+
+func (r *fakeViewRepository) CreateViewContent(ctx context.Context, record entity.ViewContentRecord, userSerial string) (resp entity.ViewContentRecord, err error) {
+	r.contents = append(r.contents, record)
+	return record, nil
+}
+
+func (r *fakeViewRepository) UpdateViewContent(ctx context.Context, record entity.ViewContentRecord, userSerial string) (resp entity.ViewContentRecord, err error) {
+	for i, content := range r.contents {
+		if content.Serial == record.Serial {
+			r.contents[i] = record
+			return record, nil
+		}
+	}
+
+	return resp, entity.ErrorNotFound
+}
+
+func (r *fakeViewRepository) DeleteViewContent(ctx context.Context, serial, userSerial string) (err error) {
+	for i, content := range r.contents {
+		if content.Serial == serial {
+			r.contents = append(r.contents[:i], r.contents[i+1:]...)
+			return nil
+		}
+	}
+
+	return entity.ErrorNotFound
+}
+
+// SetDefaultViewContent makes record the only default among the views of its layout type and owner
+func (r *fakeViewRepository) SetDefaultViewContent(ctx context.Context, record entity.ViewContentRecord, userSerial string) (err error) {
+	for i, content := range r.contents {
+		if content.TenantCode == record.TenantCode && content.ProductCode == record.ProductCode && content.ObjectCode == record.ObjectCode && content.LayoutType == record.LayoutType && content.OwnerSerial == record.OwnerSerial {
+			r.contents[i].IsDefault = content.Serial == record.Serial
+		}
+	}
+
+	return nil
+}
+
+func (r *fakeViewRepository) IsViewSchemaUsed(ctx context.Context, serial string) (used bool, err error) {
+	for _, content := range r.contents {
+		used = used || content.ViewSchemaSerial == serial
+	}
+
+	return used, nil
+}
+
+func (r *fakeViewRepository) IsViewLayoutUsed(ctx context.Context, serial string) (used bool, err error) {
+	for _, content := range r.contents {
+		used = used || content.ViewLayoutSerial == serial
+	}
+
+	return used, nil
+}
+
+func (r *fakeViewRepository) GetViewSchema(ctx context.Context, serial string) (resp entity.ViewSchema, err error) {
+	for _, schema := range r.schemas {
+		if schema.Serial == serial {
+			return schema, nil
+		}
+	}
+
+	return resp, entity.ErrorNotFound
+}
+
+func (r *fakeViewRepository) CreateViewSchema(ctx context.Context, schema entity.ViewSchema, userSerial string) (resp entity.ViewSchema, err error) {
+	r.schemas = append(r.schemas, schema)
+	return schema, nil
+}
+
+func (r *fakeViewRepository) DeleteViewSchema(ctx context.Context, serial, userSerial string) (err error) {
+	for i, schema := range r.schemas {
+		if schema.Serial == serial {
+			r.schemas = append(r.schemas[:i], r.schemas[i+1:]...)
+			return nil
+		}
+	}
+
+	return entity.ErrorNotFound
+}
+
+func (r *fakeViewRepository) GetViewLayout(ctx context.Context, serial string) (resp entity.ViewLayout, err error) {
+	for _, layout := range r.layouts {
+		if layout.Serial == serial {
+			return layout, nil
+		}
+	}
+
+	return resp, entity.ErrorNotFound
+}
+
+func (r *fakeViewRepository) CreateViewLayout(ctx context.Context, layout entity.ViewLayout, userSerial string) (resp entity.ViewLayout, err error) {
+	r.layouts = append(r.layouts, layout)
+	return layout, nil
+}
+
+func (r *fakeViewRepository) DeleteViewLayout(ctx context.Context, serial, userSerial string) (err error) {
+	for i, layout := range r.layouts {
+		if layout.Serial == serial {
+			r.layouts = append(r.layouts[:i], r.layouts[i+1:]...)
+			return nil
+		}
+	}
+
+	return entity.ErrorNotFound
+}
+
+// GetViewContentByKeys finds the view named by request, or the shared default of the layout type when none is named
+func (r *fakeViewRepository) GetViewContentByKeys(ctx context.Context, request entity.GetViewContentByKeysRequest) (resp map[string]entity.DataItem, err error) {
+	for _, content := range r.contents {
+		if content.TenantCode != request.TenantCode || content.ObjectCode != request.ObjectCode || content.LayoutType != request.LayoutType {
+			continue
+		}
+
+		if content.Code == request.ViewContentCode || (request.ViewContentCode == "" && content.IsDefault && content.OwnerSerial == "") {
+			return map[string]entity.DataItem{
+				entity.VIEW_LAYOUT_SERIAL: {Value: content.ViewLayoutSerial},
+			}, nil
+		}
+	}
+
+	return resp, entity.ErrorNotFound
+}
+
+// GetAvailableViewContents lists the shared views, the views of userSerial and the views shared with userRole
+func (r *fakeViewRepository) GetAvailableViewContents(ctx context.Context, tenantCode, productCode, objectCode, userSerial, userRole string) (resp []entity.ViewContentRecord, err error) {
+	for _, content := range r.contents {
+		if content.TenantCode != tenantCode || content.ProductCode != productCode || content.ObjectCode != objectCode {
+			continue
+		}
+
+		if content.OwnerSerial == "" || content.OwnerSerial == userSerial || (content.SharedRole != "" && content.SharedRole == userRole) {
+			resp = append(resp, content)
+		}
+	}
+
+	return resp, nil
+}
+
+func (r *fakeViewRepository) GetFavoriteViewSerials(ctx context.Context, userSerial string) (resp []string, err error) {
+	return r.favorites[userSerial], nil
+}
+
+func (r *fakeViewRepository) AddViewFavorite(ctx context.Context, userSerial, viewContentSerial string) (err error) {
+	if r.favorites == nil {
+		r.favorites = map[string][]string{}
+	}
+
+	if !helper.Contains(r.favorites[userSerial], viewContentSerial) {
+		r.favorites[userSerial] = append(r.favorites[userSerial], viewContentSerial)
+	}
+
+	return nil
+}
+
+func (r *fakeViewRepository) RemoveViewFavorite(ctx context.Context, userSerial, viewContentSerial string) (err error) {
+	favorites := []string{}
+	for _, serial := range r.favorites[userSerial] {
+		if serial != viewContentSerial {
+			favorites = append(favorites, serial)
+		}
+	}
+
+	r.favorites[userSerial] = favorites
+	return nil
+}
+
+// GetViewContentChain returns the layers of chain, the most specific layer first
+func (r *fakeViewRepository) GetViewContentChain(ctx context.Context, request entity.GetViewContentByKeysRequest) (resp []entity.ViewContentRecord, err error) {
+	return r.chain, nil
+}
+
+// GetMaterializedRollups lists the rollups aggregating the records of objectCode
+func (r *fakeCatalogRepository) GetMaterializedRollups(ctx context.Context, tenantCode, objectCode string) (resp []entity.RollupField, err error) {
+	return r.rollups[objectCode], nil
+}
+
+// RefreshRollup keeps the values a rollup was refreshed for by its object and field, an unknown function fails the way
+// it fails to compile
+func (r *fakeCatalogRepository) RefreshRollup(ctx context.Context, tenantCode string, rollup entity.RollupField, values []string) (err error) {
+	switch rollup.Rollup.Function {
+	case entity.RollupFunctionCount, entity.RollupFunctionSum, entity.RollupFunctionAvg, entity.RollupFunctionMin, entity.RollupFunctionMax:
+	default:
+		return fmt.Errorf("unknown rollup function %q", rollup.Rollup.Function)
+	}
+
+	if r.refreshed == nil {
+		r.refreshed = map[string][]string{}
+	}
+
+	key := rollup.ObjectCode + "." + rollup.FieldCode
+	r.refreshed[key] = append(r.refreshed[key], values...)
+	return nil
+}
+
+// RefreshObjectRollups refreshes every rollup field of objectCode
+func (r *fakeCatalogRepository) RefreshObjectRollups(ctx context.Context, tenantCode, objectCode string) (resp []string, err error) {
+	for _, rollups := range r.rollups {
+		for _, rollup := range rollups {
+			if rollup.ObjectCode == objectCode {
+				resp = append(resp, rollup.FieldCode)
+			}
+		}
+	}
+
+	return resp, nil
+}
+
+func (r *fakeScheduleRepository) GetDueJobs(ctx context.Context, now time.Time, limit int) (resp []entity.ScheduledJob, err error) {
+	for _, job := range r.jobs {
+		if job.IsActive && !job.NextRunAt.After(now) && (limit <= 0 || len(resp) < limit) {
+			resp = append(resp, job)
+		}
+	}
+
+	return resp, nil
+}
+
+// AdvanceJob moves a job to its next run unless another replica already moved it
+func (r *fakeScheduleRepository) AdvanceJob(ctx context.Context, job entity.ScheduledJob, nextRunAt time.Time) (advanced bool, err error) {
+	for i := range r.jobs {
+		if r.jobs[i].Serial == job.Serial && r.jobs[i].NextRunAt.Equal(job.NextRunAt) {
+			r.jobs[i].NextRunAt = nextRunAt
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func (r *fakeScheduleRepository) CreateRun(ctx context.Context, run entity.ScheduledJobRun) (resp entity.ScheduledJobRun, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.runs = append(r.runs, run)
+	return run, nil
+}
+
+func (r *fakeScheduleRepository) UpdateRun(ctx context.Context, run entity.ScheduledJobRun) (err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.runs {
+		if r.runs[i].Serial == run.Serial {
+			r.runs[i] = run
+			return nil
+		}
+	}
+
+	return entity.ErrorNotFound
+}
+
+// fakeLocker hands out every lock once at a time, wait returns when every lock handed out is released
+type fakeLocker struct {
+	mu   sync.Mutex
+	held map[string]bool
+	wg   sync.WaitGroup
+}
+
+func (l *fakeLocker) TryLock(ctx context.Context, key string) (release func(), acquired bool, err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.held[key] {
+		return nil, false, nil
+	}
+
+	if l.held == nil {
+		l.held = map[string]bool{}
+	}
+
+	l.held[key] = true
+	l.wg.Add(1)
+
+	return func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+
+		delete(l.held, key)
+		l.wg.Done()
+	}, true, nil
+}
+
+func (l *fakeLocker) wait() {
+	l.wg.Wait()
+}
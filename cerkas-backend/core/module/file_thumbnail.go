@@ -0,0 +1,201 @@
+package module
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"log"
+	"mime"
+	"net/url"
+	"path"
+	"slices"
+	"strconv"
+
+	"github.com/cerkas/cerkas-backend/core/entity"
+	"github.com/cerkas/cerkas-backend/pkg/helper"
+)
+
+// thumbnailDecoders are the image types with thumbnails, all of them decoded in pure go
+var thumbnailDecoders = map[string]func(io.Reader) (image.Image, error){
+	"image/jpeg": jpeg.Decode,
+	"image/png":  png.Decode,
+	"image/gif":  gif.Decode,
+}
+
+// GetThumbnail opens a thumbnail of an image attachment. Thumbnails are made at upload in the configured sizes, a
+// missing one, like one of a size added later, is made on demand and cached in the file storage.
+func (uc *fileUsecase) GetThumbnail(ctx context.Context, request entity.FileRequest, size int) (mimeType string, content io.ReadCloser, err error) {
+	if !slices.Contains(uc.cfg.ThumbnailSizes, size) {
+		return "", nil, fmt.Errorf("%w: %v", entity.ErrorThumbnailSizeInvalid, size)
+	}
+
+	attachment, err := uc.getAttachment(ctx, request)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if !hasThumbnails(attachment.MimeType) {
+		return "", nil, entity.ErrorThumbnailUnavailable
+	}
+
+	mimeType = thumbnailMimeType(attachment.MimeType)
+
+	content, err = uc.storage.Open(ctx, thumbnailKey(attachment.StorageKey, size))
+	if !errors.Is(err, entity.ErrorNotFound) {
+		return mimeType, content, err
+	}
+
+	img, err := uc.decodeImage(ctx, attachment)
+	if err != nil {
+		return "", nil, err
+	}
+
+	thumbnail, err := uc.saveThumbnail(ctx, attachment, img, size)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return mimeType, io.NopCloser(bytes.NewReader(thumbnail)), nil
+}
+
+// generateThumbnails makes the thumbnails of an uploaded image. A failure is only logged, the thumbnail is made again
+// when it is first asked for.
+func (uc *fileUsecase) generateThumbnails(ctx context.Context, attachment entity.FileAttachment) {
+	if !hasThumbnails(attachment.MimeType) || len(uc.cfg.ThumbnailSizes) == 0 {
+		return
+	}
+
+	img, err := uc.decodeImage(ctx, attachment)
+	if err != nil {
+		log.Printf("failed to make the thumbnails of file %v: %v", attachment.StorageKey, err)
+		return
+	}
+
+	for _, size := range uc.cfg.ThumbnailSizes {
+		if _, err := uc.saveThumbnail(ctx, attachment, img, size); err != nil {
+			log.Printf("failed to make the %vpx thumbnail of file %v: %v", size, attachment.StorageKey, err)
+		}
+	}
+}
+
+// decodeImage decodes a stored image, the dimensions are checked first so a small file can not expand into an image
+// that exhausts the memory
+func (uc *fileUsecase) decodeImage(ctx context.Context, attachment entity.FileAttachment) (img image.Image, err error) {
+	decode := thumbnailDecoders[mediaType(attachment.MimeType)]
+
+	content, err := uc.storage.Open(ctx, attachment.StorageKey)
+	if errors.Is(err, entity.ErrorNotFound) {
+		return nil, entity.ErrorFileNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer content.Close()
+
+	data, err := io.ReadAll(content)
+	if err != nil {
+		return nil, err
+	}
+
+	imageConfig, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", entity.ErrorThumbnailUnavailable, err)
+	}
+
+	if uc.cfg.ThumbnailMaxPixels > 0 && imageConfig.Width*imageConfig.Height > uc.cfg.ThumbnailMaxPixels {
+		return nil, fmt.Errorf("%w: the image has more than %v pixels", entity.ErrorThumbnailUnavailable, uc.cfg.ThumbnailMaxPixels)
+	}
+
+	img, err = decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", entity.ErrorThumbnailUnavailable, err)
+	}
+
+	return img, nil
+}
+
+// saveThumbnail scales an image to a size and stores it, it returns the encoded thumbnail
+func (uc *fileUsecase) saveThumbnail(ctx context.Context, attachment entity.FileAttachment, img image.Image, size int) (thumbnail []byte, err error) {
+	var buffer bytes.Buffer
+
+	scaled := helper.Thumbnail(img, size)
+	if thumbnailMimeType(attachment.MimeType) == "image/png" {
+		err = png.Encode(&buffer, scaled)
+	} else {
+		err = jpeg.Encode(&buffer, scaled, &jpeg.Options{Quality: 85})
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err = uc.storage.Save(ctx, thumbnailKey(attachment.StorageKey, size), bytes.NewReader(buffer.Bytes())); err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// thumbnailKey is the key of a thumbnail of a stored file, next to the file so it is found from the file alone
+func thumbnailKey(storageKey string, size int) string {
+	return fmt.Sprintf("%v.thumb%v", storageKey, size)
+}
+
+// thumbnailMimeType keeps the transparency of png and gif images, any other image is thumbnailed as jpeg
+func thumbnailMimeType(mimeType string) string {
+	switch mediaType(mimeType) {
+	case "image/png", "image/gif":
+		return "image/png"
+	default:
+		return "image/jpeg"
+	}
+}
+
+func hasThumbnails(mimeType string) bool {
+	_, ok := thumbnailDecoders[mediaType(mimeType)]
+	return ok
+}
+
+// mediaType strips the parameters of a mime type, like the charset of text/plain; charset=utf-8
+func mediaType(mimeType string) string {
+	mediaType, _, err := mime.ParseMediaType(mimeType)
+	if err != nil {
+		return mimeType
+	}
+
+	return mediaType
+}
+
+// fileDisplayValue is the display value of a file field of a listed record, with the urls of the file and of its
+// thumbnails. The thumbnail urls carry the attachment serial so a cached thumbnail is not shown after a new upload. A
+// field without an attachment keeps its value.
+func fileDisplayValue(request entity.CatalogQuery, recordSerial, fieldCode string, value any, thumbnailSizes []int) any {
+	attachment, ok := parseAttachment(value)
+	if !ok || recordSerial == "" {
+		return value
+	}
+
+	fileURL := "/" + path.Join("t", url.PathEscape(request.TenantCode), "p", url.PathEscape(request.ProductCode), "o", url.PathEscape(request.ObjectCode),
+		"data", url.PathEscape(recordSerial), "files", url.PathEscape(fieldCode))
+
+	displayValue := entity.FileDisplayValue{
+		FileName: attachment.FileName,
+		MimeType: attachment.MimeType,
+		Size:     attachment.Size,
+		URL:      fileURL,
+	}
+
+	if hasThumbnails(attachment.MimeType) && len(thumbnailSizes) > 0 {
+		displayValue.Thumbnails = map[string]string{}
+		for _, size := range thumbnailSizes {
+			displayValue.Thumbnails[strconv.Itoa(size)] = fmt.Sprintf("%v/thumbnails/%v?v=%v", fileURL, size, url.QueryEscape(attachment.Serial))
+		}
+	}
+
+	return displayValue
+}
@@ -0,0 +1,132 @@
+package module
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/cerkas/cerkas-backend/config"
+	"github.com/cerkas/cerkas-backend/core/entity"
+)
+
+func testPNG(t *testing.T, width, height int) []byte {
+	t.Helper()
+
+	var buffer bytes.Buffer
+	if err := png.Encode(&buffer, image.NewRGBA(image.Rect(0, 0, width, height))); err != nil {
+		t.Fatalf("png.Encode: %v", err)
+	}
+
+	return buffer.Bytes()
+}
+
+func TestUploadedImagesGetTheirThumbnails(t *testing.T) {
+	uc, storage, _ := newTestFileUsecase(config.Config{FileMaxSize: 1 << 20, ThumbnailSizes: []int{16, 64}})
+
+	attachment, err := uc.UploadFile(context.Background(), invoiceFile("i1", "photo"), "photo.png", bytes.NewReader(testPNG(t, 200, 100)))
+	if err != nil {
+		t.Fatalf("UploadFile = %v", err)
+	}
+
+	for size, wantWidth := range map[int]int{16: 16, 64: 64} {
+		data, ok := storage.files[thumbnailKey(attachment.StorageKey, size)]
+		if !ok {
+			t.Errorf("%vpx thumbnail was not made at upload", size)
+			continue
+		}
+
+		thumb, err := png.DecodeConfig(bytes.NewReader(data))
+		if err != nil || thumb.Width != wantWidth || thumb.Height != wantWidth/2 {
+			t.Errorf("%vpx thumbnail is %vx%v, err %v", size, thumb.Width, thumb.Height, err)
+		}
+	}
+
+	// a thumbnail lost from the storage is made again on demand
+	delete(storage.files, thumbnailKey(attachment.StorageKey, 16))
+
+	mimeType, content, err := uc.GetThumbnail(context.Background(), invoiceFile("i1", "photo"), 16)
+	if err != nil {
+		t.Fatalf("GetThumbnail = %v", err)
+	}
+	content.Close()
+
+	if _, ok := storage.files[thumbnailKey(attachment.StorageKey, 16)]; mimeType != "image/png" || !ok {
+		t.Errorf("GetThumbnail = %v, cached %v, want a cached png", mimeType, ok)
+	}
+
+	if _, _, err := uc.GetThumbnail(context.Background(), invoiceFile("i1", "photo"), 32); !errors.Is(err, entity.ErrorThumbnailSizeInvalid) {
+		t.Errorf("size not configured: GetThumbnail = %v, want %v", err, entity.ErrorThumbnailSizeInvalid)
+	}
+
+	err = uc.HandleRecordEvent(context.Background(), entity.RecordEvent{
+		Type:         entity.RecordDeleted,
+		TenantCode:   "acme",
+		ObjectCode:   "invoice",
+		RecordSerial: "i1",
+		OldData:      map[string]entity.DataItem{"photo": {Value: attachmentValue(attachment)}},
+	})
+	if err != nil {
+		t.Fatalf("HandleRecordEvent = %v", err)
+	}
+
+	if len(storage.files) != 0 {
+		t.Errorf("files left after the record is deleted: %v", len(storage.files))
+	}
+}
+
+func TestThumbnailsOfFilesThatAreNotImages(t *testing.T) {
+	tests := []struct {
+		name      string
+		field     string
+		content   []byte
+		maxPixels int
+	}{
+		{"pdf", "contract", []byte(testPDF), 0},
+		{"image over the pixel limit", "photo", testPNG(t, 100, 100), 5000},
+		{"broken image", "photo", []byte("\x89PNG\r\n\x1a\n" + strings.Repeat("x", 32)), 0},
+	}
+
+	for _, test := range tests {
+		uc, storage, _ := newTestFileUsecase(config.Config{FileMaxSize: 1 << 20, ThumbnailSizes: []int{16}, ThumbnailMaxPixels: test.maxPixels})
+
+		attachment, err := uc.UploadFile(context.Background(), invoiceFile("i1", test.field), "upload", bytes.NewReader(test.content))
+		if err != nil {
+			t.Fatalf("%v: UploadFile = %v", test.name, err)
+		}
+
+		if _, ok := storage.files[thumbnailKey(attachment.StorageKey, 16)]; ok {
+			t.Errorf("%v: a thumbnail was made", test.name)
+		}
+
+		if _, _, err := uc.GetThumbnail(context.Background(), invoiceFile("i1", test.field), 16); !errors.Is(err, entity.ErrorThumbnailUnavailable) {
+			t.Errorf("%v: GetThumbnail = %v, want %v", test.name, err, entity.ErrorThumbnailUnavailable)
+		}
+	}
+}
+
+func TestFileDisplayValueLinksTheThumbnails(t *testing.T) {
+	request := entity.CatalogQuery{TenantCode: "acme", ProductCode: "crm", ObjectCode: "invoice"}
+	photo := attachmentValue(entity.FileAttachment{Serial: "f 1", FileName: "photo.png", MimeType: "image/png", StorageKey: "acme/invoice/i1/photo/f 1"})
+
+	value, ok := fileDisplayValue(request, "i1", "photo", photo, []int{64}).(entity.FileDisplayValue)
+	if !ok {
+		t.Fatalf("fileDisplayValue is not a FileDisplayValue")
+	}
+
+	if value.URL != "/t/acme/p/crm/o/invoice/data/i1/files/photo" || value.Thumbnails["64"] != "/t/acme/p/crm/o/invoice/data/i1/files/photo/thumbnails/64?v=f+1" {
+		t.Errorf("display value = %+v", value)
+	}
+
+	pdf := attachmentValue(entity.FileAttachment{Serial: "f2", MimeType: "application/pdf", StorageKey: "acme/invoice/i1/contract/f2"})
+	if value, _ := fileDisplayValue(request, "i1", "contract", pdf, []int{64}).(entity.FileDisplayValue); value.Thumbnails != nil {
+		t.Errorf("pdf has thumbnails %v", value.Thumbnails)
+	}
+
+	if value := fileDisplayValue(request, "i1", "photo", nil, []int{64}); value != nil {
+		t.Errorf("empty field shows %v, want nil", value)
+	}
+}
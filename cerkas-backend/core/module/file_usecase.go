@@ -0,0 +1,352 @@
+package module
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/cerkas/cerkas-backend/config"
+	"github.com/cerkas/cerkas-backend/core/entity"
+	"github.com/cerkas/cerkas-backend/core/repository"
+	"github.com/cerkas/cerkas-backend/pkg/helper"
+	"github.com/gabriel-vasile/mimetype"
+)
+
+// fileSniffSize is how much of an upload is read to detect its mime type
+const fileSniffSize = 3072
+
+type FileUsecase interface {
+	UploadFile(ctx context.Context, request entity.FileRequest, fileName string, content io.Reader) (resp entity.FileAttachment, err error)
+	DownloadFile(ctx context.Context, request entity.FileRequest) (resp entity.FileAttachment, content io.ReadCloser, err error)
+	DeleteFile(ctx context.Context, request entity.FileRequest) (err error)
+	GetThumbnail(ctx context.Context, request entity.FileRequest, size int) (mimeType string, content io.ReadCloser, err error)
+	HandleRecordEvent(ctx context.Context, event entity.RecordEvent) (err error)
+}
+
+type fileUsecase struct {
+	cfg         config.Config
+	catalogRepo repository.CatalogRepository
+	catalogUc   CatalogUsecase
+	storage     repository.FileStorage
+}
+
+func NewFileUsecase(cfg config.Config, catalogRepo repository.CatalogRepository, catalogUc CatalogUsecase, storage repository.FileStorage) FileUsecase {
+	return &fileUsecase{
+		cfg:         cfg,
+		catalogRepo: catalogRepo,
+		catalogUc:   catalogUc,
+		storage:     storage,
+	}
+}
+
+// UploadFile stores a file and sets it as the value of a file field through the catalog usecase, so rules and
+// approvals apply as they do to any update. The mime type is sniffed from the content, the name of the file is not
+// trusted. The file replaced by the upload is removed once the update is committed.
+func (uc *fileUsecase) UploadFile(ctx context.Context, request entity.FileRequest, fileName string, content io.Reader) (resp entity.FileAttachment, err error) {
+	field, err := uc.getFileField(ctx, request)
+	if err != nil {
+		return resp, err
+	}
+
+	if _, err = uc.getRecord(ctx, &request); err != nil {
+		return resp, err
+	}
+
+	maxSize := uc.cfg.FileMaxSize
+	if size, ok := field.ValidationRules[entity.FileRuleMaxSize].(float64); ok && size > 0 {
+		maxSize = int64(size)
+	}
+
+	reader := bufio.NewReaderSize(content, fileSniffSize)
+	head, err := reader.Peek(fileSniffSize)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return resp, err
+	}
+
+	if len(head) == 0 {
+		return resp, entity.ErrorFileEmpty
+	}
+
+	mimeType := mimetype.Detect(head)
+	if !acceptsMimeType(field.ValidationRules[entity.FileRuleMimeTypes], mimeType) {
+		return resp, fmt.Errorf("%w: %v", entity.ErrorFileTypeInvalid, mimeType.String())
+	}
+
+	resp = entity.FileAttachment{
+		FileName:   path.Base(strings.ReplaceAll(fileName, `\`, "/")),
+		MimeType:   mimeType.String(),
+		UploadedBy: request.UserSerial,
+		UploadedAt: time.Now(),
+	}
+
+	resp.Serial, err = helper.GenerateUUUID()
+	if err != nil {
+		return resp, err
+	}
+
+	resp.StorageKey = fileStorageKey(request, resp.Serial)
+
+	// one byte over the limit is enough to reject the file
+	resp.Size, err = uc.storage.Save(ctx, resp.StorageKey, io.LimitReader(reader, maxSize+1))
+	if err != nil {
+		return resp, err
+	}
+
+	if resp.Size > maxSize {
+		uc.removeFile(ctx, resp.StorageKey)
+		return resp, fmt.Errorf("%w: the limit is %v bytes", entity.ErrorFileTooLarge, maxSize)
+	}
+
+	_, err = uc.catalogUc.UpdateObjectData(ctx, entity.DataMutationRequest{
+		Serial:      request.RecordSerial,
+		ObjectCode:  request.ObjectCode,
+		TenantCode:  request.TenantCode,
+		ProductCode: request.ProductCode,
+		UserSerial:  request.UserSerial,
+		UserRole:    request.UserRole,
+		Items:       []entity.DataItem{{FieldCode: request.FieldCode, Value: attachmentValue(resp)}},
+	})
+
+	// a file held for approval is kept, the change request refers to it
+	var pending *ApprovalPendingError
+	if err != nil && !errors.As(err, &pending) {
+		uc.removeFile(ctx, resp.StorageKey)
+		return resp, err
+	}
+
+	uc.generateThumbnails(ctx, resp)
+
+	return resp, err
+}
+
+// DownloadFile opens the file of a file field, the caller closes the content
+func (uc *fileUsecase) DownloadFile(ctx context.Context, request entity.FileRequest) (resp entity.FileAttachment, content io.ReadCloser, err error) {
+	resp, err = uc.getAttachment(ctx, request)
+	if err != nil {
+		return resp, nil, err
+	}
+
+	content, err = uc.storage.Open(ctx, resp.StorageKey)
+	if errors.Is(err, entity.ErrorNotFound) {
+		return resp, nil, entity.ErrorFileNotFound
+	}
+
+	return resp, content, err
+}
+
+// DeleteFile clears a file field, the file itself is removed once the update is committed
+func (uc *fileUsecase) DeleteFile(ctx context.Context, request entity.FileRequest) (err error) {
+	if _, err = uc.getFileField(ctx, request); err != nil {
+		return err
+	}
+
+	record, err := uc.getRecord(ctx, &request)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := parseAttachment(record[request.FieldCode].Value); !ok {
+		return entity.ErrorFileNotFound
+	}
+
+	_, err = uc.catalogUc.UpdateObjectData(ctx, entity.DataMutationRequest{
+		Serial:      request.RecordSerial,
+		ObjectCode:  request.ObjectCode,
+		TenantCode:  request.TenantCode,
+		ProductCode: request.ProductCode,
+		UserSerial:  request.UserSerial,
+		UserRole:    request.UserRole,
+		Items:       []entity.DataItem{{FieldCode: request.FieldCode, Value: nil}},
+	})
+
+	return err
+}
+
+// HandleRecordEvent removes the files a mutation let go of, with their thumbnails: every file of a deleted or purged
+// record and the files replaced or cleared by an update. It runs after the mutation is committed, versions of the record keep the metadata of a removed
+// file but not its content.
+func (uc *fileUsecase) HandleRecordEvent(ctx context.Context, event entity.RecordEvent) (err error) {
+	if event.Type != entity.RecordUpdated && event.Type != entity.RecordDeleted && event.Type != entity.RecordPurged {
+		return nil
+	}
+
+	for _, fieldCode := range sortedKeys(event.OldData) {
+		oldAttachment, ok := parseAttachment(event.OldData[fieldCode].Value)
+		if !ok {
+			continue
+		}
+
+		if event.Type == entity.RecordUpdated {
+			if attachment, ok := parseAttachment(event.Data[fieldCode].Value); ok && attachment.StorageKey == oldAttachment.StorageKey {
+				continue
+			}
+		}
+
+		// only keys of the record are removed, a value copied from another record is not its file
+		request := entity.FileRequest{
+			TenantCode:   event.TenantCode,
+			ObjectCode:   event.ObjectCode,
+			RecordSerial: event.RecordSerial,
+			FieldCode:    fieldCode,
+		}
+		if oldAttachment.StorageKey != fileStorageKey(request, oldAttachment.Serial) {
+			continue
+		}
+
+		if err := uc.storage.Delete(ctx, oldAttachment.StorageKey); err != nil {
+			return err
+		}
+
+		for _, size := range uc.cfg.ThumbnailSizes {
+			if err := uc.storage.Delete(ctx, thumbnailKey(oldAttachment.StorageKey, size)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (uc *fileUsecase) getFileField(ctx context.Context, request entity.FileRequest) (resp entity.FileField, err error) {
+	if request.RecordSerial == "" {
+		return resp, entity.ErrorSerialEmpty
+	}
+
+	fields, err := uc.catalogRepo.GetFileFields(ctx, request.TenantCode, request.ObjectCode)
+	if err != nil {
+		return resp, err
+	}
+
+	for _, field := range fields {
+		if field.FieldCode == request.FieldCode {
+			return field, nil
+		}
+	}
+
+	return resp, entity.ErrorFileFieldInvalid
+}
+
+// getAttachment returns the attachment of a file field, one with a key outside the field is treated as missing
+func (uc *fileUsecase) getAttachment(ctx context.Context, request entity.FileRequest) (resp entity.FileAttachment, err error) {
+	if _, err = uc.getFileField(ctx, request); err != nil {
+		return resp, err
+	}
+
+	record, err := uc.getRecord(ctx, &request)
+	if err != nil {
+		return resp, err
+	}
+
+	resp, ok := parseAttachment(record[request.FieldCode].Value)
+	if !ok || resp.StorageKey != fileStorageKey(request, resp.Serial) {
+		return resp, entity.ErrorFileNotFound
+	}
+
+	return resp, nil
+}
+
+// getRecord reads the record of a file request, a record addressed by its code is given its serial so the storage keys
+// match the ones of its events
+func (uc *fileUsecase) getRecord(ctx context.Context, request *entity.FileRequest) (resp map[string]entity.DataItem, err error) {
+	resp, err = uc.catalogRepo.GetObjectDetail(ctx, entity.CatalogQuery{
+		Serial:      request.RecordSerial,
+		ObjectCode:  request.ObjectCode,
+		TenantCode:  request.TenantCode,
+		ProductCode: request.ProductCode,
+	})
+	if err != nil {
+		return resp, err
+	}
+
+	if len(resp) == 0 {
+		return resp, entity.ErrorNotFound
+	}
+
+	if serial, ok := resp["serial"]; ok && serial.Value != nil {
+		request.RecordSerial = fmt.Sprintf("%v", serial.Value)
+	}
+
+	return resp, nil
+}
+
+// removeFile removes a file nothing refers to, a failure leaves an orphan behind and is only logged
+func (uc *fileUsecase) removeFile(ctx context.Context, key string) {
+	if err := uc.storage.Delete(context.WithoutCancel(ctx), key); err != nil {
+		log.Printf("failed to remove file %v: %v", key, err)
+	}
+}
+
+// fileStorageKey is the key of an attachment of a record field. It is derived rather than read from the record, so a
+// value copied or forged into a field can not reach the files of another record.
+func fileStorageKey(request entity.FileRequest, attachmentSerial string) string {
+	return path.Join(request.TenantCode, request.ObjectCode, request.RecordSerial, request.FieldCode, attachmentSerial)
+}
+
+// acceptsMimeType checks a mime type against the mime_types rule of a field, a pattern like image/* accepts a whole
+// family. A field without the rule accepts every type.
+func acceptsMimeType(rule any, mimeType *mimetype.MIME) bool {
+	patterns, ok := rule.([]any)
+	if !ok || len(patterns) == 0 {
+		return true
+	}
+
+	for _, pattern := range patterns {
+		pattern, ok := pattern.(string)
+		if !ok {
+			continue
+		}
+
+		if family, ok := strings.CutSuffix(pattern, "/*"); ok {
+			if strings.HasPrefix(mimeType.String(), family+"/") {
+				return true
+			}
+
+			continue
+		}
+
+		// Is also matches the aliases of the type, like application/x-pdf for application/pdf
+		if mimeType.Is(pattern) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// attachmentValue returns an attachment the way a json column is written and read back
+func attachmentValue(attachment entity.FileAttachment) map[string]any {
+	value := map[string]any{}
+
+	data, _ := json.Marshal(attachment)
+	_ = json.Unmarshal(data, &value)
+
+	return value
+}
+
+// parseAttachment reads the value of a file field, an empty field has no attachment
+func parseAttachment(value any) (resp entity.FileAttachment, ok bool) {
+	var data []byte
+	switch value := value.(type) {
+	case nil:
+		return resp, false
+	case string:
+		data = []byte(value)
+	case []byte:
+		data = value
+	default:
+		data, _ = json.Marshal(value)
+	}
+
+	if err := json.Unmarshal(data, &resp); err != nil || resp.StorageKey == "" {
+		return resp, false
+	}
+
+	return resp, true
+}
@@ -0,0 +1,152 @@
+package module
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/cerkas/cerkas-backend/config"
+	"github.com/cerkas/cerkas-backend/core/entity"
+	"github.com/gabriel-vasile/mimetype"
+)
+
+const testPDF = "%PDF-1.4\n%%EOF\n"
+
+func newTestFileUsecase(cfg config.Config) (FileUsecase, *fakeFileStorage, *fakeCatalogUsecase) {
+	catalogRepo := &fakeCatalogRepository{
+		records: map[string][]map[string]any{
+			"invoice": {{"serial": "i1", "code": "INV-1"}},
+		},
+		fileFields: map[string][]entity.FileField{
+			"invoice": {
+				{FieldCode: "contract", ValidationRules: map[string]any{entity.FileRuleMaxSize: 64.0, entity.FileRuleMimeTypes: []any{"application/pdf"}}},
+				{FieldCode: "photo", ValidationRules: map[string]any{entity.FileRuleMimeTypes: []any{"image/*"}}},
+				{FieldCode: "scan"},
+			},
+		},
+	}
+	storage := &fakeFileStorage{}
+	catalogUc := &fakeCatalogUsecase{catalogRepo: catalogRepo}
+
+	return NewFileUsecase(cfg, catalogRepo, catalogUc, storage), storage, catalogUc
+}
+
+func invoiceFile(recordSerial, fieldCode string) entity.FileRequest {
+	return entity.FileRequest{TenantCode: "acme", ProductCode: "crm", ObjectCode: "invoice", RecordSerial: recordSerial, FieldCode: fieldCode, UserSerial: "user-1"}
+}
+
+func TestUploadFileStoresItUnderTheRecord(t *testing.T) {
+	uc, storage, catalogUc := newTestFileUsecase(config.Config{FileMaxSize: 1024})
+
+	attachment, err := uc.UploadFile(context.Background(), invoiceFile("INV-1", "contract"), `C:\docs\contract.pdf`, strings.NewReader(testPDF))
+	if err != nil {
+		t.Fatalf("UploadFile = %v", err)
+	}
+
+	if attachment.FileName != "contract.pdf" || attachment.MimeType != "application/pdf" || attachment.Size != int64(len(testPDF)) {
+		t.Errorf("attachment = %+v, want contract.pdf of %v bytes", attachment, len(testPDF))
+	}
+
+	if attachment.StorageKey != "acme/invoice/i1/contract/"+attachment.Serial || string(storage.files[attachment.StorageKey]) != testPDF {
+		t.Errorf("file is stored as %v, want it under the serial of the record", attachment.StorageKey)
+	}
+
+	if len(catalogUc.mutations) != 1 || catalogUc.mutations[0].Serial != "i1" || catalogUc.mutations[0].Items[0].FieldCode != "contract" {
+		t.Errorf("mutations = %+v, want the contract of i1 set", catalogUc.mutations)
+	}
+
+	downloaded, content, err := uc.DownloadFile(context.Background(), invoiceFile("i1", "contract"))
+	if err != nil {
+		t.Fatalf("DownloadFile = %v", err)
+	}
+	defer content.Close()
+
+	if data, _ := io.ReadAll(content); downloaded.Serial != attachment.Serial || string(data) != testPDF {
+		t.Errorf("downloaded %v with %q, want the uploaded file", downloaded.Serial, data)
+	}
+}
+
+func TestUploadFileChecksTheFile(t *testing.T) {
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+	tests := []struct {
+		name    string
+		request entity.FileRequest
+		content []byte
+		want    error
+	}{
+		{"larger than the field allows", invoiceFile("i1", "contract"), []byte(testPDF + strings.Repeat(" ", 64)), entity.ErrorFileTooLarge},
+		{"larger than the default", invoiceFile("i1", "scan"), bytes.Repeat([]byte("a"), 129), entity.ErrorFileTooLarge},
+		{"type the field does not accept", invoiceFile("i1", "contract"), png, entity.ErrorFileTypeInvalid},
+		{"type outside the family", invoiceFile("i1", "photo"), []byte(testPDF), entity.ErrorFileTypeInvalid},
+		{"empty", invoiceFile("i1", "scan"), nil, entity.ErrorFileEmpty},
+		{"not a file field", invoiceFile("i1", "total"), []byte(testPDF), entity.ErrorFileFieldInvalid},
+		{"no record", invoiceFile("", "scan"), []byte(testPDF), entity.ErrorSerialEmpty},
+		{"unknown record", invoiceFile("i9", "scan"), []byte(testPDF), entity.ErrorNotFound},
+	}
+
+	for _, test := range tests {
+		uc, storage, catalogUc := newTestFileUsecase(config.Config{FileMaxSize: 128})
+
+		_, err := uc.UploadFile(context.Background(), test.request, "upload", bytes.NewReader(test.content))
+		if !errors.Is(err, test.want) {
+			t.Errorf("%v: UploadFile = %v, want %v", test.name, err, test.want)
+		}
+
+		if len(storage.files) != 0 || len(catalogUc.mutations) != 0 {
+			t.Errorf("%v: %v files stored and %v mutations, want none", test.name, len(storage.files), len(catalogUc.mutations))
+		}
+	}
+
+	uc, _, _ := newTestFileUsecase(config.Config{FileMaxSize: 128})
+	if _, err := uc.UploadFile(context.Background(), invoiceFile("i1", "photo"), "photo.png", bytes.NewReader(png)); err != nil {
+		t.Errorf("png photo: UploadFile = %v", err)
+	}
+}
+
+func TestAcceptsMimeType(t *testing.T) {
+	pdf := mimetype.Detect([]byte(testPDF))
+
+	tests := []struct {
+		name string
+		rule any
+		want bool
+	}{
+		{"no rule", nil, true},
+		{"empty rule", []any{}, true},
+		{"exact type", []any{"text/plain", "application/pdf"}, true},
+		{"alias", []any{"application/x-pdf"}, true},
+		{"family", []any{"application/*"}, true},
+		{"other family", []any{"image/*"}, false},
+		{"other type", []any{"text/plain"}, false},
+		{"not a list", "application/pdf", true},
+	}
+
+	for _, test := range tests {
+		if got := acceptsMimeType(test.rule, pdf); got != test.want {
+			t.Errorf("%v: acceptsMimeType = %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestDownloadFileOfAnotherRecordIsNotFound(t *testing.T) {
+	uc, storage, catalogUc := newTestFileUsecase(config.Config{FileMaxSize: 1024})
+	storage.files = map[string][]byte{"acme/invoice/i2/contract/f1": []byte(testPDF)}
+
+	// a value copied from another record does not reach its file
+	catalogUc.catalogRepo.records["invoice"][0]["contract"] = map[string]any{
+		"serial":      "f1",
+		"storage_key": "acme/invoice/i2/contract/f1",
+	}
+
+	if _, _, err := uc.DownloadFile(context.Background(), invoiceFile("i1", "contract")); !errors.Is(err, entity.ErrorFileNotFound) {
+		t.Errorf("DownloadFile = %v, want %v", err, entity.ErrorFileNotFound)
+	}
+
+	if _, _, err := uc.DownloadFile(context.Background(), invoiceFile("i1", "scan")); !errors.Is(err, entity.ErrorFileNotFound) {
+		t.Errorf("empty field: DownloadFile = %v, want %v", err, entity.ErrorFileNotFound)
+	}
+}
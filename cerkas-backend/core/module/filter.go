@@ -0,0 +1,176 @@
+package module
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cerkas/cerkas-backend/core/entity"
+)
+
+// matchFilters evaluates filter groups against record values in memory, with the same semantics as the
+// sql built by the catalog repository: groups are combined with AND, items inside a group with the group operator.
+func matchFilters(filters []entity.FilterGroup, values map[string]any) bool {
+	for _, filterGroup := range filters {
+		if len(filterGroup.Filters) == 0 {
+			continue
+		}
+
+		isOr := strings.EqualFold(string(filterGroup.Operator), string(entity.FilterOperatorOr))
+
+		groupMatched := !isOr
+		for fieldName, filter := range filterGroup.Filters {
+			if fieldName == "" {
+				fieldName = filter.FieldName
+			}
+
+			matched := matchFilterItem(filter, values[fieldName])
+			if isOr && matched {
+				groupMatched = true
+				break
+			}
+
+			if !isOr && !matched {
+				groupMatched = false
+				break
+			}
+		}
+
+		if !groupMatched {
+			return false
+		}
+	}
+
+	return true
+}
+
+func matchFilterItem(filter entity.FilterItem, value any) bool {
+	switch filter.Operator {
+	case entity.FilterOperatorEqual:
+		return compareValues(value, filter.Value) == 0
+	case entity.FilterOperatorNotEqual:
+		return compareValues(value, filter.Value) != 0
+	case entity.FilterOperatorContains:
+		return value != nil && strings.Contains(strings.ToLower(fmt.Sprintf("%v", value)), strings.ToLower(fmt.Sprintf("%v", filter.Value)))
+	case entity.FilterOperatorNotContains:
+		return value == nil || !strings.Contains(strings.ToLower(fmt.Sprintf("%v", value)), strings.ToLower(fmt.Sprintf("%v", filter.Value)))
+	case entity.FilterOperatorGreaterThan:
+		return value != nil && compareValues(value, filter.Value) > 0
+	case entity.FilterOperatorGreaterThanEqual:
+		return value != nil && compareValues(value, filter.Value) >= 0
+	case entity.FilterOperatorLessThan:
+		return value != nil && compareValues(value, filter.Value) < 0
+	case entity.FilterOperatorLessThanEqual:
+		return value != nil && compareValues(value, filter.Value) <= 0
+	case entity.FilterOperatorIn:
+		for _, item := range filterValues(filter.Value) {
+			if compareValues(value, item) == 0 {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// filterValues returns the values of an in filter, a single value is a list of one
+func filterValues(value any) []any {
+	switch v := value.(type) {
+	case []any:
+		return v
+	case []string:
+		values := []any{}
+		for _, item := range v {
+			values = append(values, item)
+		}
+		return values
+	default:
+		return []any{v}
+	}
+}
+
+// compareValues compares two loosely typed values, numerically or chronologically when both sides allow it
+func compareValues(a, b any) int {
+	a = normalizeValue(a)
+	b = normalizeValue(b)
+
+	if a == nil || b == nil {
+		switch {
+		case a == nil && b == nil:
+			return 0
+		case a == nil:
+			return -1
+		default:
+			return 1
+		}
+	}
+
+	aString := fmt.Sprintf("%v", a)
+	bString := fmt.Sprintf("%v", b)
+
+	aNumber, aErr := strconv.ParseFloat(aString, 64)
+	bNumber, bErr := strconv.ParseFloat(bString, 64)
+	if aErr == nil && bErr == nil {
+		switch {
+		case aNumber < bNumber:
+			return -1
+		case aNumber > bNumber:
+			return 1
+		default:
+			return 0
+		}
+	}
+
+	aTime, aErr := parseTimeValue(aString)
+	bTime, bErr := parseTimeValue(bString)
+	if aErr == nil && bErr == nil {
+		return aTime.Compare(bTime)
+	}
+
+	return strings.Compare(aString, bString)
+}
+
+// sortRecords sorts records in memory by the request orders
+func sortRecords(records []map[string]entity.DataItem, orders []entity.Order) {
+	if len(orders) == 0 {
+		return
+	}
+
+	sort.SliceStable(records, func(i, j int) bool {
+		for _, order := range orders {
+			result := compareValues(records[i][order.FieldName].Value, records[j][order.FieldName].Value)
+			if result == 0 {
+				continue
+			}
+
+			if strings.EqualFold(order.Direction, "desc") {
+				return result > 0
+			}
+
+			return result < 0
+		}
+
+		return false
+	})
+}
+
+// parseTimeValue parses RFC3339 timestamps and plain dates
+func parseTimeValue(value string) (time.Time, error) {
+	if parsed, err := time.Parse(time.RFC3339, value); err == nil {
+		return parsed, nil
+	}
+
+	return time.Parse(entity.DefaultDateFormat, value)
+}
+
+// dataItemValues flattens a record into field code and value pairs
+func dataItemValues(items map[string]entity.DataItem) map[string]any {
+	values := make(map[string]any, len(items))
+	for key, item := range items {
+		values[key] = item.Value
+	}
+
+	return values
+}
@@ -0,0 +1,122 @@
+package module
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/cerkas/cerkas-backend/config"
+	"github.com/cerkas/cerkas-backend/core/entity"
+	"github.com/cerkas/cerkas-backend/core/repository"
+)
+
+// importProgressStep is how many records an import creates between two progress reports
+const importProgressStep = 50
+
+// ImportRecordError is a record an import could not create, Index is its position in the payload
+type ImportRecordError struct {
+	Index int    `json:"index"`
+	Error string `json:"error"`
+}
+
+// ImportResult summarizes an import, records held for approval are counted apart from the created ones
+type ImportResult struct {
+	Created int                 `json:"created"`
+	Pending int                 `json:"pending"`
+	Failed  []ImportRecordError `json:"failed"`
+}
+
+// NewImportDataJobHandler creates the records of an import through the catalog usecase, so rules, state machines and
+// approvals apply as they do to a single record. A record that fails does not fail the import, it is listed in the
+// result instead, retrying the import would create the other records twice.
+func NewImportDataJobHandler(catalogUc CatalogUsecase) JobHandler {
+	return TypedJobHandler(func(ctx context.Context, job entity.Job, payload entity.ImportDataPayload, reporter JobReporter) (any, error) {
+		if payload.ObjectCode == "" {
+			return nil, fmt.Errorf("%w: import needs object_code", entity.ErrorJobPayloadInvalid)
+		}
+
+		result := ImportResult{Failed: []ImportRecordError{}}
+		for i, record := range payload.Records {
+			request := entity.DataMutationRequest{
+				ObjectCode:  payload.ObjectCode,
+				TenantCode:  job.TenantCode,
+				ProductCode: payload.ProductCode,
+				UserSerial:  job.CreatedBy,
+			}
+
+			for _, fieldCode := range sortedKeys(record) {
+				request.Items = append(request.Items, entity.DataItem{FieldCode: fieldCode, Value: record[fieldCode]})
+			}
+
+			var pending *ApprovalPendingError
+			_, err := catalogUc.CreateObjectData(ctx, request)
+			switch {
+			case err == nil:
+				result.Created++
+			case errors.As(err, &pending):
+				result.Pending++
+			case ctx.Err() != nil:
+				return result, ctx.Err()
+			default:
+				result.Failed = append(result.Failed, ImportRecordError{Index: i, Error: err.Error()})
+			}
+
+			if (i+1)%importProgressStep == 0 {
+				message := fmt.Sprintf("imported %v of %v records", i+1, len(payload.Records))
+				if err := reporter.Report(ctx, (i+1)*100/len(payload.Records), message); err != nil {
+					return result, err
+				}
+			}
+		}
+
+		return result, nil
+	})
+}
+
+// NewExportViewJobHandler writes the records of a view to a csv file under the export directory of the tenant
+func NewExportViewJobHandler(cfg config.Config, catalogUc CatalogUsecase) JobHandler {
+	return TypedJobHandler(func(ctx context.Context, job entity.Job, payload entity.ExportViewPayload, reporter JobReporter) (any, error) {
+		if payload.ObjectCode == "" || payload.ViewContentCode == "" {
+			return nil, fmt.Errorf("%w: export needs object_code and view_content_code", entity.ErrorJobPayloadInvalid)
+		}
+
+		if payload.Path == "" {
+			payload.Path = fmt.Sprintf("%v/%v.csv", payload.ObjectCode, job.Serial)
+		}
+
+		path, err := exportFilePath(cfg.ScheduleExportDir, job.TenantCode, payload.Path)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", entity.ErrorJobPayloadInvalid, err)
+		}
+
+		exported, err := exportViewFile(ctx, catalogUc, path, job.TenantCode, job.CreatedBy, payload, func(exported, total int) error {
+			progress := 100
+			if total > 0 {
+				progress = exported * 100 / total
+			}
+
+			return reporter.Report(ctx, progress, fmt.Sprintf("exported %v of %v records", exported, total))
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		return map[string]any{"path": path, "exported": exported}, nil
+	})
+}
+
+// NewRefreshRollupsJobHandler recomputes the materialized rollup fields of an object
+func NewRefreshRollupsJobHandler(catalogRepo repository.CatalogRepository) JobHandler {
+	return TypedJobHandler(func(ctx context.Context, job entity.Job, payload entity.RefreshRollupsPayload, reporter JobReporter) (any, error) {
+		if payload.ObjectCode == "" {
+			return nil, fmt.Errorf("%w: refresh needs object_code", entity.ErrorJobPayloadInvalid)
+		}
+
+		fieldCodes, err := catalogRepo.RefreshObjectRollups(ctx, job.TenantCode, payload.ObjectCode)
+		if err != nil {
+			return nil, err
+		}
+
+		return map[string]any{"refreshed_fields": fieldCodes}, nil
+	})
+}
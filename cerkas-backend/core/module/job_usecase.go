@@ -0,0 +1,383 @@
+package module
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/cerkas/cerkas-backend/config"
+	"github.com/cerkas/cerkas-backend/core/entity"
+	"github.com/cerkas/cerkas-backend/core/repository"
+	"github.com/cerkas/cerkas-backend/pkg/helper"
+)
+
+// JobHandler runs a job of one type, the result is stored with the job as json. A job failing with
+// ErrorJobPayloadInvalid is not retried, any other error is retried until the job runs out of attempts.
+type JobHandler func(ctx context.Context, job entity.Job, reporter JobReporter) (result any, err error)
+
+// JobReporter stores the progress of a running job. Report fails with ErrorJobCancelled once the job is cancelled,
+// the handler is expected to stop then.
+type JobReporter interface {
+	Report(ctx context.Context, progress int, message string) (err error)
+}
+
+// TypedJobHandler decodes the payload of a job into T before handing it to handle
+func TypedJobHandler[T any](handle func(ctx context.Context, job entity.Job, payload T, reporter JobReporter) (result any, err error)) JobHandler {
+	return func(ctx context.Context, job entity.Job, reporter JobReporter) (any, error) {
+		var payload T
+		if err := json.Unmarshal(job.Payload, &payload); err != nil {
+			return nil, fmt.Errorf("%w: %v", entity.ErrorJobPayloadInvalid, err)
+		}
+
+		return handle(ctx, job, payload, reporter)
+	}
+}
+
+type JobUsecase interface {
+	Register(jobType string, handler JobHandler)
+	SubmitJob(ctx context.Context, request entity.Job) (resp entity.Job, err error)
+	GetJob(ctx context.Context, tenantCode, serial, userSerial, userRole string) (resp entity.Job, err error)
+	GetJobs(ctx context.Context, request entity.JobQuery, userSerial, userRole string) (resp entity.JobResponse, err error)
+	CancelJob(ctx context.Context, tenantCode, serial, userSerial, userRole string) (resp entity.Job, err error)
+	ProcessNext(ctx context.Context) (processed bool, err error)
+	RunWorkers(ctx context.Context)
+}
+
+type jobUsecase struct {
+	cfg     config.Config
+	jobRepo repository.JobRepository
+
+	mu       sync.RWMutex
+	handlers map[string]JobHandler
+}
+
+func NewJobUsecase(cfg config.Config, jobRepo repository.JobRepository) JobUsecase {
+	return &jobUsecase{
+		cfg:      cfg,
+		jobRepo:  jobRepo,
+		handlers: map[string]JobHandler{},
+	}
+}
+
+// Register sets the handler of a job type, jobs of types without a handler can not be submitted
+func (uc *jobUsecase) Register(jobType string, handler JobHandler) {
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+
+	uc.handlers[jobType] = handler
+}
+
+// SubmitJob queues a job of the signed in user in CreatedBy, only the user and admins can follow or cancel it
+func (uc *jobUsecase) SubmitJob(ctx context.Context, request entity.Job) (resp entity.Job, err error) {
+	if request.CreatedBy == "" {
+		return resp, entity.ErrorLoginRequired
+	}
+
+	if _, ok := uc.handler(request.JobType); !ok {
+		return resp, fmt.Errorf("%w: %v", entity.ErrorJobTypeUnknown, request.JobType)
+	}
+
+	if len(request.Payload) > 0 && !json.Valid(request.Payload) {
+		return resp, entity.ErrorJobPayloadInvalid
+	}
+
+	request.Serial, err = helper.GenerateUUUID()
+	if err != nil {
+		return resp, err
+	}
+
+	if request.MaxAttempts <= 0 {
+		request.MaxAttempts = max(uc.cfg.JobMaxAttempts, 1)
+	}
+
+	request.Status = entity.JobStatusQueued
+	request.CreatedAt = time.Now()
+	request.RunAt = request.CreatedAt
+
+	return uc.jobRepo.CreateJob(ctx, request)
+}
+
+func (uc *jobUsecase) GetJob(ctx context.Context, tenantCode, serial, userSerial, userRole string) (resp entity.Job, err error) {
+	return uc.ownedJob(ctx, tenantCode, serial, userSerial, userRole)
+}
+
+// GetJobs lists the jobs of the user, an admin lists the jobs of everyone
+func (uc *jobUsecase) GetJobs(ctx context.Context, request entity.JobQuery, userSerial, userRole string) (resp entity.JobResponse, err error) {
+	if !isAdmin(uc.cfg, userRole) {
+		if userSerial == "" {
+			return resp, entity.ErrorLoginRequired
+		}
+
+		request.CreatedBy = userSerial
+	}
+
+	limit, _, _, _ := helper.SetupListParameter(int32(request.Page), int32(request.PageSize))
+
+	items, total, err := uc.jobRepo.GetJobs(ctx, request)
+	if err != nil {
+		return resp, err
+	}
+
+	resp = entity.JobResponse{
+		Page:      max(request.Page, 1),
+		PageSize:  int(limit),
+		TotalData: int(total),
+		TotalPage: int(helper.GenerateTotalPage(total, int64(limit))),
+		Items:     items,
+	}
+
+	return resp, nil
+}
+
+// CancelJob cancels a queued job, a running job is stopped by its worker at its next progress report
+func (uc *jobUsecase) CancelJob(ctx context.Context, tenantCode, serial, userSerial, userRole string) (resp entity.Job, err error) {
+	if _, err := uc.ownedJob(ctx, tenantCode, serial, userSerial, userRole); err != nil {
+		return resp, err
+	}
+
+	return uc.jobRepo.CancelJob(ctx, tenantCode, serial)
+}
+
+// ownedJob returns a job the user submitted, an admin gets any job
+func (uc *jobUsecase) ownedJob(ctx context.Context, tenantCode, serial, userSerial, userRole string) (resp entity.Job, err error) {
+	if serial == "" {
+		return resp, entity.ErrorSerialEmpty
+	}
+
+	if userSerial == "" && !isAdmin(uc.cfg, userRole) {
+		return resp, entity.ErrorLoginRequired
+	}
+
+	resp, err = uc.jobRepo.GetJob(ctx, tenantCode, serial)
+	if err != nil {
+		return resp, err
+	}
+
+	if resp.CreatedBy != userSerial && !isAdmin(uc.cfg, userRole) {
+		return entity.Job{}, entity.ErrorJobForbidden
+	}
+
+	return resp, nil
+}
+
+// ProcessNext claims a due job and runs it, it reports whether there was one
+func (uc *jobUsecase) ProcessNext(ctx context.Context) (processed bool, err error) {
+	job, claimed, err := uc.jobRepo.ClaimJob(ctx, uc.lease())
+	if err != nil || !claimed {
+		return false, err
+	}
+
+	// the worker that asked for the cancel went away before it could stop the job
+	if job.CancelRequested {
+		uc.finishJob(ctx, job, nil, entity.ErrorJobCancelled)
+		return true, nil
+	}
+
+	job, result, err := uc.runJob(ctx, job)
+	uc.finishJob(ctx, job, result, err)
+
+	return true, nil
+}
+
+// RunWorkers runs the configured number of workers until ctx is done, an idle worker polls for jobs
+func (uc *jobUsecase) RunWorkers(ctx context.Context) {
+	interval := time.Duration(uc.cfg.JobPollInterval) * time.Second
+	if interval <= 0 {
+		interval = time.Second
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < max(uc.cfg.JobWorkerConcurrency, 1); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for {
+				processed, err := uc.ProcessNext(ctx)
+				if err != nil {
+					log.Println("failed to process jobs:", err)
+				}
+
+				if processed && err == nil {
+					continue
+				}
+
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(interval):
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+}
+
+// runJob runs the handler of a job and returns the job with its last progress. A heartbeat keeps the lease of the job
+// while it runs and cancels the handler once the job is cancelled, a panicking handler fails its attempt instead of
+// the worker.
+func (uc *jobUsecase) runJob(ctx context.Context, job entity.Job) (resp entity.Job, result any, err error) {
+	handler, ok := uc.handler(job.JobType)
+	if !ok {
+		return job, nil, fmt.Errorf("%w: %v", entity.ErrorJobTypeUnknown, job.JobType)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	reporter := &jobReporter{uc: uc, job: job, cancel: cancel}
+
+	done := make(chan struct{})
+	defer close(done)
+	go reporter.heartbeat(ctx, done)
+
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("panic: %v", recovered)
+		}
+
+		if err != nil && reporter.isCancelled() {
+			err = entity.ErrorJobCancelled
+		}
+
+		resp = reporter.current()
+	}()
+
+	result, err = handler(ctx, job, reporter)
+	return resp, result, err
+}
+
+// finishJob stores the outcome of an attempt, a failed attempt is retried with exponential backoff while the job has
+// attempts left
+func (uc *jobUsecase) finishJob(ctx context.Context, job entity.Job, result any, err error) {
+	now := time.Now()
+	job.RunAt = now
+
+	switch {
+	case err == nil:
+		job.Status = entity.JobStatusSucceeded
+		job.Progress = 100
+		job.Error = ""
+		job.FinishedAt = &now
+
+		if result != nil {
+			job.Result, err = json.Marshal(result)
+			if err != nil {
+				job.Status = entity.JobStatusFailed
+				job.Error = fmt.Sprintf("failed to encode the result: %v", err)
+			}
+		}
+	case errors.Is(err, entity.ErrorJobCancelled):
+		job.Status = entity.JobStatusCancelled
+		job.Error = err.Error()
+		job.FinishedAt = &now
+	case errors.Is(err, entity.ErrorJobPayloadInvalid), errors.Is(err, entity.ErrorJobTypeUnknown), job.Attempts >= job.MaxAttempts:
+		job.Status = entity.JobStatusFailed
+		job.Error = err.Error()
+		job.FinishedAt = &now
+		log.Printf("job %v %v of %v failed after %v attempts: %v", job.JobType, job.Serial, job.TenantCode, job.Attempts, err)
+	default:
+		job.Status = entity.JobStatusQueued
+		job.Error = err.Error()
+		job.RunAt = now.Add(time.Duration(uc.cfg.JobRetryBaseDelay) * time.Second << min(job.Attempts-1, 10))
+	}
+
+	// the outcome is stored even when the worker is shutting down
+	if err := uc.jobRepo.FinishJob(context.WithoutCancel(ctx), job); err != nil {
+		log.Printf("failed to update job %v: %v", job.Serial, err)
+	}
+}
+
+func (uc *jobUsecase) handler(jobType string) (JobHandler, bool) {
+	uc.mu.RLock()
+	defer uc.mu.RUnlock()
+
+	handler, ok := uc.handlers[jobType]
+	return handler, ok
+}
+
+func (uc *jobUsecase) lease() time.Duration {
+	lease := time.Duration(uc.cfg.JobLease) * time.Second
+	if lease <= 0 {
+		lease = 5 * time.Minute
+	}
+
+	return lease
+}
+
+// jobReporter is the JobReporter of a running attempt of a job
+type jobReporter struct {
+	uc     *jobUsecase
+	cancel context.CancelFunc
+
+	mu        sync.Mutex
+	job       entity.Job
+	cancelled bool
+}
+
+func (r *jobReporter) Report(ctx context.Context, progress int, message string) (err error) {
+	r.mu.Lock()
+	r.job.Progress = min(max(progress, 0), 100)
+	r.job.ProgressMessage = message
+	r.mu.Unlock()
+
+	return r.save(ctx)
+}
+
+// heartbeat saves the progress of the job on a third of its lease until done is closed
+func (r *jobReporter) heartbeat(ctx context.Context, done <-chan struct{}) {
+	ticker := time.NewTicker(r.uc.lease() / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := r.save(ctx); err != nil && !errors.Is(err, entity.ErrorJobCancelled) {
+				log.Printf("failed to extend the lease of job %v: %v", r.job.Serial, err)
+			}
+		}
+	}
+}
+
+func (r *jobReporter) save(ctx context.Context) (err error) {
+	job := r.current()
+
+	cancelRequested, err := r.uc.jobRepo.UpdateJobProgress(ctx, job, r.uc.lease())
+	if err != nil {
+		return err
+	}
+
+	if cancelRequested {
+		r.mu.Lock()
+		r.cancelled = true
+		r.mu.Unlock()
+
+		r.cancel()
+		return entity.ErrorJobCancelled
+	}
+
+	return nil
+}
+
+func (r *jobReporter) current() entity.Job {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.job
+}
+
+func (r *jobReporter) isCancelled() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.cancelled
+}
@@ -0,0 +1,212 @@
+package module
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cerkas/cerkas-backend/config"
+	"github.com/cerkas/cerkas-backend/core/entity"
+)
+
+func newTestJobUsecase(jobRepo *fakeJobRepository) JobUsecase {
+	uc := NewJobUsecase(config.Config{AdminRole: "admin"}, jobRepo)
+	uc.Register("export", func(ctx context.Context, job entity.Job, reporter JobReporter) (result any, err error) {
+		return nil, nil
+	})
+
+	return uc
+}
+
+func TestSubmitJobNeedsAUser(t *testing.T) {
+	uc := newTestJobUsecase(&fakeJobRepository{})
+
+	if _, err := uc.SubmitJob(context.Background(), entity.Job{TenantCode: "acme", JobType: "export"}); !errors.Is(err, entity.ErrorLoginRequired) {
+		t.Fatalf("an anonymous request submitted a job, err %v", err)
+	}
+}
+
+func TestJobsAreKeptToTheirOwner(t *testing.T) {
+	jobRepo := &fakeJobRepository{}
+	uc := newTestJobUsecase(jobRepo)
+
+	submitted := map[string]entity.Job{}
+	for _, userSerial := range []string{"user-1", "user-2"} {
+		job, err := uc.SubmitJob(context.Background(), entity.Job{TenantCode: "acme", JobType: "export", CreatedBy: userSerial})
+		if err != nil {
+			t.Fatalf("SubmitJob: %v", err)
+		}
+		submitted[userSerial] = job
+	}
+
+	jobs, err := uc.GetJobs(context.Background(), entity.JobQuery{TenantCode: "acme"}, "user-1", "sales")
+	if err != nil {
+		t.Fatalf("GetJobs: %v", err)
+	}
+	if len(jobs.Items) != 1 || jobs.Items[0].CreatedBy != "user-1" {
+		t.Errorf("user-1 lists %+v, want only its own job", jobs.Items)
+	}
+
+	if _, err := uc.GetJobs(context.Background(), entity.JobQuery{TenantCode: "acme"}, "", ""); !errors.Is(err, entity.ErrorLoginRequired) {
+		t.Errorf("an anonymous request listed jobs, err %v", err)
+	}
+
+	if jobs, err := uc.GetJobs(context.Background(), entity.JobQuery{TenantCode: "acme"}, "user-3", "admin"); err != nil || len(jobs.Items) != 2 {
+		t.Errorf("admin lists %v jobs, want 2, err %v", len(jobs.Items), err)
+	}
+
+	other := submitted["user-2"].Serial
+	if _, err := uc.GetJob(context.Background(), "acme", other, "user-1", "sales"); !errors.Is(err, entity.ErrorJobForbidden) {
+		t.Errorf("user-1 read the job of user-2, err %v", err)
+	}
+
+	if _, err := uc.CancelJob(context.Background(), "acme", other, "user-1", "sales"); !errors.Is(err, entity.ErrorJobForbidden) {
+		t.Errorf("user-1 cancelled the job of user-2, err %v", err)
+	}
+
+	if _, err := uc.GetJob(context.Background(), "acme", other, "", ""); !errors.Is(err, entity.ErrorLoginRequired) {
+		t.Errorf("an anonymous request read a job, err %v", err)
+	}
+
+	if job, err := uc.CancelJob(context.Background(), "acme", other, "user-2", "sales"); err != nil || job.Status != entity.JobStatusCancelled {
+		t.Errorf("user-2 could not cancel its job: %+v, %v", job, err)
+	}
+
+	if _, err := uc.GetJob(context.Background(), "acme", other, "user-3", "admin"); err != nil {
+		t.Errorf("admin could not read the job of user-2: %v", err)
+	}
+}
+
+func TestFailedJobsAreRetriedWithBackoff(t *testing.T) {
+	jobRepo := &fakeJobRepository{}
+	uc := NewJobUsecase(config.Config{JobMaxAttempts: 3, JobRetryBaseDelay: 10}, jobRepo)
+
+	errFlaky := errors.New("upstream is down")
+	uc.Register("sync", func(ctx context.Context, job entity.Job, reporter JobReporter) (result any, err error) {
+		return nil, errFlaky
+	})
+
+	if _, err := uc.SubmitJob(context.Background(), entity.Job{TenantCode: "acme", JobType: "sync", CreatedBy: "user-1"}); err != nil {
+		t.Fatalf("SubmitJob: %v", err)
+	}
+
+	for attempt, wantDelay := range []time.Duration{10 * time.Second, 20 * time.Second} {
+		before := time.Now()
+		if processed, err := uc.ProcessNext(context.Background()); !processed || err != nil {
+			t.Fatalf("attempt %v: ProcessNext = %v, %v", attempt+1, processed, err)
+		}
+
+		job := jobRepo.jobs[0]
+		if job.Status != entity.JobStatusQueued || job.Error != errFlaky.Error() {
+			t.Errorf("attempt %v: job is %v with %q, want it queued again", attempt+1, job.Status, job.Error)
+		}
+
+		if delay := job.RunAt.Sub(before); delay < wantDelay || delay > wantDelay+time.Second {
+			t.Errorf("attempt %v: retried after %v, want %v", attempt+1, delay, wantDelay)
+		}
+
+		if processed, _ := uc.ProcessNext(context.Background()); processed {
+			t.Errorf("attempt %v: the job ran again before its backoff", attempt+1)
+		}
+
+		jobRepo.jobs[0].RunAt = time.Now()
+	}
+
+	if _, err := uc.ProcessNext(context.Background()); err != nil {
+		t.Fatalf("last attempt: ProcessNext = %v", err)
+	}
+
+	if job := jobRepo.jobs[0]; job.Status != entity.JobStatusFailed || job.Attempts != 3 || job.FinishedAt == nil {
+		t.Errorf("job after its last attempt = %+v, want it failed after 3 attempts", job)
+	}
+}
+
+func TestJobsFinish(t *testing.T) {
+	tests := []struct {
+		name       string
+		handler    JobHandler
+		payload    string
+		cancel     bool
+		wantStatus string
+		wantResult string
+	}{
+		{
+			name: "succeeded",
+			handler: func(ctx context.Context, job entity.Job, reporter JobReporter) (any, error) {
+				return map[string]int{"rows": 2}, reporter.Report(ctx, 50, "half way")
+			},
+			wantStatus: entity.JobStatusSucceeded,
+			wantResult: `{"rows":2}`,
+		},
+		{
+			name: "invalid payload is not retried",
+			handler: TypedJobHandler(func(ctx context.Context, job entity.Job, payload entity.RefreshRollupsPayload, reporter JobReporter) (any, error) {
+				return nil, nil
+			}),
+			payload:    `[]`,
+			wantStatus: entity.JobStatusFailed,
+		},
+		{
+			name: "panic is retried",
+			handler: func(ctx context.Context, job entity.Job, reporter JobReporter) (any, error) {
+				panic("nil map")
+			},
+			wantStatus: entity.JobStatusQueued,
+		},
+		{
+			name: "cancel requested before the attempt",
+			handler: func(ctx context.Context, job entity.Job, reporter JobReporter) (any, error) {
+				return "ran", nil
+			},
+			cancel:     true,
+			wantStatus: entity.JobStatusCancelled,
+		},
+	}
+
+	for _, test := range tests {
+		jobRepo := &fakeJobRepository{}
+		uc := NewJobUsecase(config.Config{JobMaxAttempts: 3}, jobRepo)
+		uc.Register("work", test.handler)
+
+		job, err := uc.SubmitJob(context.Background(), entity.Job{TenantCode: "acme", JobType: "work", CreatedBy: "user-1", Payload: []byte(test.payload)})
+		if err != nil {
+			t.Fatalf("%v: SubmitJob = %v", test.name, err)
+		}
+		jobRepo.jobs[0].CancelRequested = test.cancel
+
+		if _, err := uc.ProcessNext(context.Background()); err != nil {
+			t.Fatalf("%v: ProcessNext = %v", test.name, err)
+		}
+
+		job = jobRepo.jobs[0]
+		if job.Status != test.wantStatus || string(job.Result) != test.wantResult {
+			t.Errorf("%v: job is %v with result %s and error %q, want %v with %v", test.name, job.Status, job.Result, job.Error, test.wantStatus, test.wantResult)
+		}
+	}
+}
+
+func TestJobCancelledWhileRunning(t *testing.T) {
+	jobRepo := &fakeJobRepository{}
+	uc := NewJobUsecase(config.Config{JobMaxAttempts: 3}, jobRepo)
+	uc.Register("work", func(ctx context.Context, job entity.Job, reporter JobReporter) (any, error) {
+		jobRepo.jobs[0].CancelRequested = true
+		if err := reporter.Report(ctx, 10, "started"); err != nil {
+			return nil, err
+		}
+
+		return nil, ctx.Err()
+	})
+
+	if _, err := uc.SubmitJob(context.Background(), entity.Job{TenantCode: "acme", JobType: "work", CreatedBy: "user-1"}); err != nil {
+		t.Fatalf("SubmitJob: %v", err)
+	}
+
+	if _, err := uc.ProcessNext(context.Background()); err != nil {
+		t.Fatalf("ProcessNext: %v", err)
+	}
+
+	if job := jobRepo.jobs[0]; job.Status != entity.JobStatusCancelled || job.Progress != 10 || job.Attempts != 1 {
+		t.Errorf("job = %+v, want it cancelled at 10%%", job)
+	}
+}
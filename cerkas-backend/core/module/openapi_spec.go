@@ -0,0 +1,541 @@
+package module
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/cerkas/cerkas-backend/core/entity"
+)
+
+// openAPIFilterOperators are the operators of a filter item
+var openAPIFilterOperators = []entity.FilterOperator{
+	entity.FilterOperatorEqual,
+	entity.FilterOperatorNotEqual,
+	entity.FilterOperatorContains,
+	entity.FilterOperatorNotContains,
+	entity.FilterOperatorGreaterThan,
+	entity.FilterOperatorGreaterThanEqual,
+	entity.FilterOperatorLessThan,
+	entity.FilterOperatorLessThanEqual,
+	entity.FilterOperatorIn,
+}
+
+// openAPIField is a column of an object with the metadata of its object field, if it has one
+type openAPIField struct {
+	code        string
+	dataType    string
+	objectField entity.ObjectFields
+	rules       map[string]any
+	required    bool
+	readonly    bool
+}
+
+// openAPIBuilder collects the paths and the schemas of the objects of a tenant into an OpenAPI 3.0 document
+type openAPIBuilder struct {
+	request entity.OpenAPIRequest
+	paths   map[string]any
+	schemas map[string]any
+	tags    []map[string]any
+}
+
+func newOpenAPIBuilder(request entity.OpenAPIRequest) *openAPIBuilder {
+	filterOperators := []any{}
+	for _, operator := range openAPIFilterOperators {
+		filterOperators = append(filterOperators, string(operator))
+	}
+
+	return &openAPIBuilder{
+		request: request,
+		paths:   map[string]any{},
+		tags:    []map[string]any{},
+		schemas: map[string]any{
+			"ErrorResponse": map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"status": map[string]any{"type": "string"},
+					"code":   map[string]any{"type": "integer"},
+				},
+			},
+			"ChangeRequestResponse": openAPIEnvelope(map[string]any{
+				"type":        "object",
+				"description": "The change request holding the mutation until it is approved",
+			}),
+			"FilterOperator": map[string]any{"type": "string", "enum": filterOperators},
+			"Include": map[string]any{
+				"type":        "object",
+				"description": "A related list embedded in a detail record under its name",
+				"required":    []any{"object_code"},
+				"properties": map[string]any{
+					"name":           map[string]any{"type": "string"},
+					"object_code":    map[string]any{"type": "string"},
+					"relation_field": map[string]any{"type": "string"},
+					"fields":         map[string]any{"type": "object", "additionalProperties": map[string]any{"type": "object"}},
+					"filters":        map[string]any{"type": "array", "items": map[string]any{"type": "object"}},
+					"orders":         map[string]any{"type": "array", "items": map[string]any{"type": "object"}},
+					"limit":          map[string]any{"type": "integer", "minimum": 1},
+				},
+			},
+			"FileAttachment": map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"serial":      map[string]any{"type": "string", "format": "uuid"},
+					"file_name":   map[string]any{"type": "string"},
+					"mime_type":   map[string]any{"type": "string"},
+					"size":        map[string]any{"type": "integer", "format": "int64"},
+					"storage_key": map[string]any{"type": "string"},
+					"uploaded_by": map[string]any{"type": "string"},
+					"uploaded_at": map[string]any{"type": "string", "format": "date-time"},
+				},
+			},
+			"FileDisplayValue": map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"file_name":  map[string]any{"type": "string"},
+					"mime_type":  map[string]any{"type": "string"},
+					"size":       map[string]any{"type": "integer", "format": "int64"},
+					"url":        map[string]any{"type": "string"},
+					"thumbnails": map[string]any{"type": "object", "additionalProperties": map[string]any{"type": "string"}},
+				},
+			},
+		},
+	}
+}
+
+// document returns the OpenAPI document, the metadata version is its version
+func (b *openAPIBuilder) document(version string) map[string]any {
+	return map[string]any{
+		"openapi": "3.0.3",
+		"info": map[string]any{
+			"title":   fmt.Sprintf("%v %v data API", b.request.TenantCode, b.request.ProductCode),
+			"version": version,
+		},
+		"tags":     b.tags,
+		"paths":    b.paths,
+		"security": []any{map[string]any{"bearerAuth": []any{}}},
+		"components": map[string]any{
+			"schemas": b.schemas,
+			"securitySchemes": map[string]any{
+				"bearerAuth": map[string]any{"type": "http", "scheme": "bearer"},
+			},
+			"parameters": map[string]any{
+				"AsOf": map[string]any{
+					"name":        "as_of",
+					"in":          "query",
+					"description": "Reads the records as they were at this time",
+					"schema":      map[string]any{"type": "string", "format": "date-time"},
+				},
+				"ViewContentCode": map[string]any{"name": "view_content_code", "in": "path", "required": true, "schema": map[string]any{"type": "string"}},
+				"Serial":          map[string]any{"name": "serial", "in": "path", "required": true, "schema": map[string]any{"type": "string"}},
+			},
+		},
+	}
+}
+
+// addObject adds the data, detail, create, update and delete operations of an object with their schemas
+func (b *openAPIBuilder) addObject(object entity.Objects, columns []map[string]any, objectFields map[string]any) {
+	name := openAPISchemaName(object.Code)
+	fields := openAPIFields(columns, objectFields)
+
+	b.tags = append(b.tags, map[string]any{"name": object.Code, "description": openAPIFirst(object.Description, object.DisplayName)})
+
+	fieldCodes := []any{}
+	for _, field := range fields {
+		fieldCodes = append(fieldCodes, field.code)
+	}
+
+	b.schemas[name+"FieldCode"] = map[string]any{"type": "string", "enum": fieldCodes}
+	b.schemas[name+"Query"] = b.querySchema(object, name)
+	b.schemas[name+"Record"] = b.recordSchema(fields)
+	b.schemas[name+"List"] = map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"page":       map[string]any{"type": "integer"},
+			"page_size":  map[string]any{"type": "integer"},
+			"total_data": map[string]any{"type": "integer"},
+			"total_page": map[string]any{"type": "integer"},
+			"items":      map[string]any{"type": "array", "items": openAPIRef(name + "Record")},
+		},
+	}
+	b.schemas[name+"ListResponse"] = openAPIEnvelope(openAPIRef(name + "List"))
+	b.schemas[name+"RecordResponse"] = openAPIEnvelope(openAPIRef(name + "Record"))
+	b.schemas[name+"MutationResponse"] = openAPIEnvelope(map[string]any{
+		"type":       "object",
+		"properties": map[string]any{"items": map[string]any{"type": "array", "items": openAPIRef(name + "Record")}},
+	})
+
+	items, required := b.mutationItems(name, fields)
+	b.schemas[name+"CreateRequest"] = b.mutationSchema(object, items, required, true)
+	b.schemas[name+"UpdateRequest"] = b.mutationSchema(object, items, nil, false)
+
+	viewPath := fmt.Sprintf("/t/%v/p/%v/o/%v/view/{view_content_code}/data", b.request.TenantCode, b.request.ProductCode, object.Code)
+	dataPath := fmt.Sprintf("/t/%v/p/%v/o/%v/data", b.request.TenantCode, b.request.ProductCode, object.Code)
+
+	b.paths[viewPath] = map[string]any{
+		"post": b.operation(object, "list"+name, "Lists the records of a view", name+"Query", name+"ListResponse", "ViewContentCode", "AsOf"),
+	}
+	b.paths[viewPath+"/detail/{serial}"] = map[string]any{
+		"post": b.operation(object, "get"+name, "Reads a record by serial", name+"Query", name+"RecordResponse", "ViewContentCode", "Serial", "AsOf"),
+	}
+	b.paths[dataPath] = map[string]any{
+		"put": b.operation(object, "create"+name, "Creates a record", name+"CreateRequest", name+"MutationResponse"),
+	}
+	b.paths[dataPath+"/{serial}"] = map[string]any{
+		"patch":  b.operation(object, "update"+name, "Updates the given fields of a record", name+"UpdateRequest", name+"MutationResponse", "Serial"),
+		"delete": b.operation(object, "delete"+name, "Deletes a record", "", "", "Serial"),
+	}
+}
+
+// operation describes an operation of an object, a mutation can be held for approval
+func (b *openAPIBuilder) operation(object entity.Objects, operationID, summary, requestSchema, responseSchema string, parameters ...string) map[string]any {
+	parameterRefs := []any{}
+	for _, parameter := range parameters {
+		parameterRefs = append(parameterRefs, map[string]any{"$ref": "#/components/parameters/" + parameter})
+	}
+
+	success := map[string]any{"description": "Success"}
+	if responseSchema != "" {
+		success["content"] = openAPIJSONContent(openAPIRef(responseSchema))
+	}
+
+	responses := map[string]any{
+		"200":     success,
+		"default": map[string]any{"description": "Error", "content": openAPIJSONContent(openAPIRef("ErrorResponse"))},
+	}
+
+	if !strings.HasPrefix(operationID, "list") && !strings.HasPrefix(operationID, "get") {
+		responses["202"] = map[string]any{
+			"description": "Held for approval",
+			"content":     openAPIJSONContent(openAPIRef("ChangeRequestResponse")),
+		}
+	}
+
+	operation := map[string]any{
+		"operationId": operationID,
+		"summary":     summary,
+		"tags":        []any{object.Code},
+		"parameters":  parameterRefs,
+		"responses":   responses,
+	}
+
+	if requestSchema != "" {
+		operation["requestBody"] = map[string]any{"required": true, "content": openAPIJSONContent(openAPIRef(requestSchema))}
+	}
+
+	return operation
+}
+
+// querySchema is the body of the data and detail requests, the field codes of fields, filters and orders are the
+// ones of the object
+func (b *openAPIBuilder) querySchema(object entity.Objects, name string) map[string]any {
+	fieldCode := openAPIRef(name + "FieldCode")
+
+	return map[string]any{
+		"type":     "object",
+		"required": []any{"tenant_code", "product_code", "object_code"},
+		"properties": map[string]any{
+			"tenant_code":       openAPIConstant(b.request.TenantCode),
+			"product_code":      openAPIConstant(b.request.ProductCode),
+			"object_code":       openAPIConstant(object.Code),
+			"view_content_code": map[string]any{"type": "string"},
+			"fields": map[string]any{
+				"type": "object",
+				"additionalProperties": map[string]any{
+					"type": "object",
+					"properties": map[string]any{
+						"field_code": fieldCode,
+						"field_name": map[string]any{"type": "string"},
+					},
+				},
+			},
+			"filters": map[string]any{
+				"type": "array",
+				"items": map[string]any{
+					"type": "object",
+					"properties": map[string]any{
+						"operator": map[string]any{"type": "string", "enum": []any{string(entity.FilterOperatorAnd), string(entity.FilterOperatorOr)}},
+						"filter_item": map[string]any{
+							"type": "object",
+							"additionalProperties": map[string]any{
+								"type": "object",
+								"properties": map[string]any{
+									"field_name": fieldCode,
+									"operator":   openAPIRef("FilterOperator"),
+									"value":      map[string]any{},
+								},
+							},
+						},
+					},
+				},
+			},
+			"orders": map[string]any{
+				"type": "array",
+				"items": map[string]any{
+					"type": "object",
+					"properties": map[string]any{
+						"field_name": fieldCode,
+						"direction":  map[string]any{"type": "string", "enum": []any{"asc", "desc"}},
+					},
+				},
+			},
+			"page":      map[string]any{"type": "integer", "minimum": 1},
+			"page_size": map[string]any{"type": "integer", "minimum": 1},
+			"includes":  map[string]any{"type": "array", "items": openAPIRef("Include")},
+		},
+	}
+}
+
+// recordSchema is a record as returned by the data and detail requests, each field with its typed value
+func (b *openAPIBuilder) recordSchema(fields []openAPIField) map[string]any {
+	properties := map[string]any{}
+	for _, field := range fields {
+		displayValue := map[string]any{}
+		if field.objectField.DataType.Code == entity.DataTypeFile {
+			displayValue = openAPIRef("FileDisplayValue")
+		}
+
+		properties[field.code] = map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"complete_field_code": map[string]any{"type": "string"},
+				"field_code":          map[string]any{"type": "string"},
+				"field_name":          map[string]any{"type": "string"},
+				"data_type":           map[string]any{"type": "string"},
+				"value":               openAPIValueSchema(field),
+				"display_value":       displayValue,
+			},
+		}
+	}
+
+	return map[string]any{"type": "object", "properties": properties}
+}
+
+// mutationItems adds a schema for the item of each writable field, an item is told from the others by its field code
+func (b *openAPIBuilder) mutationItems(name string, fields []openAPIField) (items map[string]any, required []any) {
+	oneOf := []any{}
+	mapping := map[string]any{}
+	for _, field := range fields {
+		if field.readonly {
+			continue
+		}
+
+		itemName := name + "Item" + openAPISchemaName(field.code)
+		b.schemas[itemName] = map[string]any{
+			"type":     "object",
+			"required": []any{"field_code", "value"},
+			"properties": map[string]any{
+				"field_code": openAPIConstant(field.code),
+				"value":      openAPIValueSchema(field),
+			},
+		}
+
+		oneOf = append(oneOf, openAPIRef(itemName))
+		mapping[field.code] = "#/components/schemas/" + itemName
+
+		if field.required {
+			required = append(required, field.code)
+		}
+	}
+
+	if len(oneOf) == 0 {
+		return map[string]any{"type": "object"}, required
+	}
+
+	return map[string]any{
+		"oneOf":         oneOf,
+		"discriminator": map[string]any{"propertyName": "field_code", "mapping": mapping},
+	}, required
+}
+
+// mutationSchema is the body of a create or update request. An array can not require an item in OpenAPI 3.0, the
+// required fields of a create are listed in x-required-fields.
+func (b *openAPIBuilder) mutationSchema(object entity.Objects, items map[string]any, required []any, create bool) map[string]any {
+	schema := map[string]any{
+		"type":     "object",
+		"required": []any{"items"},
+		"properties": map[string]any{
+			"serial": map[string]any{"type": "string"},
+			"items":  map[string]any{"type": "array", "items": items},
+		},
+	}
+
+	// a create reads the object from its body, an update and a delete from their path
+	if create {
+		properties := schema["properties"].(map[string]any)
+		properties["tenant_code"] = openAPIConstant(b.request.TenantCode)
+		properties["product_code"] = openAPIConstant(b.request.ProductCode)
+		properties["object_code"] = openAPIConstant(object.Code)
+
+		schema["required"] = []any{"tenant_code", "object_code", "items"}
+		if len(required) > 0 {
+			schema["x-required-fields"] = required
+			schema["description"] = fmt.Sprintf("The items must set the fields %v", strings.Join(openAPIStrings(required), ", "))
+		}
+	}
+
+	return schema
+}
+
+// openAPIFields lists the columns of an object by code with the metadata of their object fields. A file field is
+// written by uploading to its files endpoint, not by a mutation.
+func openAPIFields(columns []map[string]any, objectFields map[string]any) (fields []openAPIField) {
+	for _, column := range columns {
+		code, _ := column[entity.FieldColumnCode].(string)
+		if code == "" {
+			continue
+		}
+
+		dataType, _ := column[entity.FieldDataType].(string)
+		objectField, _ := objectFields[code].(entity.ObjectFields)
+		rules := fieldRules(objectField)
+		required, _ := rules[FormFieldRequired].(bool)
+
+		fields = append(fields, openAPIField{
+			code:        code,
+			dataType:    dataType,
+			objectField: objectField,
+			rules:       rules,
+			required:    required,
+			readonly:    isReadonlyField(code, objectField, rules) || dataType == entity.FieldKindFormula || dataType == entity.FieldKindRollup || objectField.DataType.Code == entity.DataTypeFile,
+		})
+	}
+
+	sort.Slice(fields, func(i, j int) bool { return fields[i].code < fields[j].code })
+
+	return fields
+}
+
+// openAPIValueSchema is the schema of the value of a field, typed by its column and narrowed by its validation rules
+func openAPIValueSchema(field openAPIField) map[string]any {
+	if field.objectField.DataType.Code == entity.DataTypeFile {
+		return map[string]any{
+			"allOf":       []any{openAPIRef("FileAttachment")},
+			"description": "Uploaded to /t/{tenant_code}/p/{product_code}/o/{object_code}/data/{serial}/files/{field_code}",
+			"nullable":    true,
+			"readOnly":    true,
+		}
+	}
+
+	schema := openAPIColumnSchema(field.dataType)
+
+	options, ok := field.rules["options"].([]any)
+	if !ok {
+		options, _ = field.objectField.DataType.FieldOptions["options"].([]any)
+	}
+
+	enum := []any{}
+	for _, column := range kanbanOptionColumns(options) {
+		enum = append(enum, column.Value)
+	}
+	if len(enum) > 0 {
+		schema["enum"] = enum
+	}
+
+	for rule, keyword := range map[string]string{"min_length": "minLength", "max_length": "maxLength", "min": "minimum", "max": "maximum", "pattern": "pattern"} {
+		if value, ok := field.rules[rule]; ok {
+			schema[keyword] = value
+		}
+	}
+
+	if title := field.objectField.DisplayName; title != "" {
+		schema["title"] = title
+	}
+	if description := field.objectField.Description; description != "" {
+		schema["description"] = description
+	}
+	if len(field.rules) > 0 {
+		schema["x-validation-rules"] = field.rules
+	}
+
+	schema["nullable"] = !field.required
+	if field.readonly {
+		schema["readOnly"] = true
+	}
+
+	return schema
+}
+
+// openAPIColumnSchema types a value by the data type of its column
+func openAPIColumnSchema(dataType string) map[string]any {
+	switch strings.ToLower(dataType) {
+	case "smallint", "integer", "int", "int2", "int4":
+		return map[string]any{"type": "integer", "format": "int32"}
+	case "bigint", "int8":
+		return map[string]any{"type": "integer", "format": "int64"}
+	case "numeric", "decimal", "money":
+		return map[string]any{"type": "number"}
+	case "real", "float4":
+		return map[string]any{"type": "number", "format": "float"}
+	case "double precision", "double", "float", "float8":
+		return map[string]any{"type": "number", "format": "double"}
+	case "boolean", "bool":
+		return map[string]any{"type": "boolean"}
+	case "date":
+		return map[string]any{"type": "string", "format": "date"}
+	case "timestamp with time zone", "timestamp without time zone", "timestamp", "timestamptz", "datetime":
+		return map[string]any{"type": "string", "format": "date-time"}
+	case "uuid":
+		return map[string]any{"type": "string", "format": "uuid"}
+	case "json", "jsonb":
+		return map[string]any{"type": "object"}
+	case "array":
+		return map[string]any{"type": "array", "items": map[string]any{}}
+	case entity.FieldKindFormula, entity.FieldKindRollup:
+		// a computed field takes the type of its expression
+		return map[string]any{}
+	default:
+		return map[string]any{"type": "string"}
+	}
+}
+
+// openAPISchemaName turns a code like sales_order into a schema name like SalesOrder
+func openAPISchemaName(code string) string {
+	var name strings.Builder
+	for _, part := range strings.FieldsFunc(code, func(r rune) bool { return r == '_' || r == '-' || r == '.' || r == ' ' }) {
+		name.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+
+	return name.String()
+}
+
+// openAPIEnvelope wraps the schema of the data of a response in the status and code every response has
+func openAPIEnvelope(data map[string]any) map[string]any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"status": map[string]any{"type": "string"},
+			"code":   map[string]any{"type": "integer"},
+			"data":   data,
+		},
+	}
+}
+
+func openAPIRef(name string) map[string]any {
+	return map[string]any{"$ref": "#/components/schemas/" + name}
+}
+
+func openAPIConstant(value string) map[string]any {
+	return map[string]any{"type": "string", "enum": []any{value}}
+}
+
+func openAPIJSONContent(schema map[string]any) map[string]any {
+	return map[string]any{"application/json": map[string]any{"schema": schema}}
+}
+
+func openAPIFirst(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+
+	return ""
+}
+
+func openAPIStrings(values []any) (resp []string) {
+	for _, value := range values {
+		resp = append(resp, fmt.Sprintf("%v", value))
+	}
+
+	return resp
+}
@@ -0,0 +1,234 @@
+package module
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/cerkas/cerkas-backend/config"
+	"github.com/cerkas/cerkas-backend/core/entity"
+)
+
+func newTestOpenAPIUsecase() (OpenAPIUsecase, *fakeCatalogRepository) {
+	catalogRepo := &fakeCatalogRepository{
+		objects: []entity.Objects{{Serial: "so", Code: "sales_order", DisplayName: "Sales order"}},
+		version: "v1",
+		columns: map[string][]string{
+			"sales_order": {"serial", "code", "status", "total", "contract", "customer__name"},
+			"customer":    {"serial", "name"},
+		},
+		columnTypes: map[string]string{
+			"sales_order.serial": "uuid",
+			"sales_order.code":   "character varying",
+			"sales_order.status": "text",
+			"sales_order.total":  entity.FieldKindFormula,
+		},
+		objectFields: map[string]map[string]any{
+			"sales_order": {
+				"code":     entity.ObjectFields{FieldCode: "code", DisplayName: "Code", ValidationRules: map[string]any{"required": true, "max_length": 20.0}},
+				"status":   entity.ObjectFields{FieldCode: "status", DataType: entity.DataType{FieldOptions: map[string]any{"options": []any{map[string]any{"value": "draft"}, map[string]any{"value": "sent"}}}}},
+				"contract": entity.ObjectFields{FieldCode: "contract", DataType: entity.DataType{Code: entity.DataTypeFile}},
+			},
+		},
+	}
+
+	return NewOpenAPIUsecase(config.Config{OpenAPICacheTTL: 300}, catalogRepo, &fakeCatalogUsecase{catalogRepo: catalogRepo}), catalogRepo
+}
+
+func getOpenAPIDocument(t *testing.T, uc OpenAPIUsecase) (entity.OpenAPIDocument, map[string]any) {
+	t.Helper()
+
+	document, err := uc.GetDocument(context.Background(), entity.OpenAPIRequest{TenantCode: "acme", ProductCode: "crm"})
+	if err != nil {
+		t.Fatalf("GetDocument = %v", err)
+	}
+
+	spec := map[string]any{}
+	if err := json.Unmarshal(document.Content, &spec); err != nil {
+		t.Fatalf("json.Unmarshal: %v", err)
+	}
+
+	return document, spec
+}
+
+// specValue follows the keys of a path through the nested objects of a document
+func specValue(value any, path ...string) any {
+	for _, key := range path {
+		object, _ := value.(map[string]any)
+		value = object[key]
+	}
+
+	return value
+}
+
+// collectRefs lists the $ref values of a document
+func collectRefs(value any, refs map[string]bool) {
+	switch value := value.(type) {
+	case map[string]any:
+		for key, child := range value {
+			if ref, ok := child.(string); ok && key == "$ref" {
+				refs[ref] = true
+			}
+			collectRefs(child, refs)
+		}
+	case []any:
+		for _, child := range value {
+			collectRefs(child, refs)
+		}
+	}
+}
+
+func TestOpenAPIDocumentDescribesTheObjects(t *testing.T) {
+	uc, _ := newTestOpenAPIUsecase()
+	_, spec := getOpenAPIDocument(t, uc)
+
+	if spec["openapi"] != "3.0.3" || specValue(spec, "info", "version") != "v1" {
+		t.Errorf("openapi %v, version %v, want 3.0.3 at the metadata version", spec["openapi"], specValue(spec, "info", "version"))
+	}
+
+	for path, method := range map[string]string{
+		"/t/acme/p/crm/o/sales_order/view/{view_content_code}/data":                 "post",
+		"/t/acme/p/crm/o/sales_order/view/{view_content_code}/data/detail/{serial}": "post",
+		"/t/acme/p/crm/o/sales_order/data":                                          "put",
+		"/t/acme/p/crm/o/sales_order/data/{serial}":                                 "patch",
+	} {
+		if specValue(spec, "paths", path, method) == nil {
+			t.Errorf("%v %v is not described", method, path)
+		}
+	}
+
+	if specValue(spec, "paths", "/t/acme/p/crm/o/sales_order/data/{serial}", "delete", "responses", "202") == nil {
+		t.Errorf("delete can not be held for approval")
+	}
+
+	if specValue(spec, "paths", "/t/acme/p/crm/o/sales_order/view/{view_content_code}/data", "post", "responses", "202") != nil {
+		t.Errorf("list can be held for approval")
+	}
+
+	// every schema and parameter an operation refers to is in the document
+	refs := map[string]bool{}
+	collectRefs(spec, refs)
+	for ref := range refs {
+		if specValue(spec, strings.Split(strings.TrimPrefix(ref, "#/"), "/")...) == nil {
+			t.Errorf("%v is not in the document", ref)
+		}
+	}
+}
+
+func TestOpenAPIDocumentTypesTheFields(t *testing.T) {
+	uc, _ := newTestOpenAPIUsecase()
+	_, spec := getOpenAPIDocument(t, uc)
+	schemas := specValue(spec, "components", "schemas")
+
+	value := func(fieldCode string) map[string]any {
+		value, _ := specValue(schemas, "SalesOrderRecord", "properties", fieldCode, "properties", "value").(map[string]any)
+		return value
+	}
+
+	if code := value("code"); code["type"] != "string" || code["maxLength"] != 20.0 || code["nullable"] != false || code["title"] != "Code" {
+		t.Errorf("code = %v, want a required string of 20 characters at most", code)
+	}
+
+	if serial := value("serial"); serial["format"] != "uuid" || serial["nullable"] != true {
+		t.Errorf("serial = %v, want a nullable uuid", serial)
+	}
+
+	if status := value("status"); fmt.Sprint(status["enum"]) != "[draft sent]" {
+		t.Errorf("status enum = %v, want the options of the field", status["enum"])
+	}
+
+	if total := value("total"); total["type"] != nil || total["readOnly"] != true {
+		t.Errorf("total = %v, want an untyped readonly formula", total)
+	}
+
+	if contract := value("contract"); contract["readOnly"] != true || specValue(schemas, "SalesOrderRecord", "properties", "contract", "properties", "display_value", "$ref") != "#/components/schemas/FileDisplayValue" {
+		t.Errorf("contract = %v, want a readonly file", contract)
+	}
+
+	// only the fields a mutation can write have an item
+	mapping, _ := specValue(schemas, "SalesOrderCreateRequest", "properties", "items", "items", "discriminator", "mapping").(map[string]any)
+	got := []string{}
+	for fieldCode := range mapping {
+		got = append(got, fieldCode)
+	}
+	sort.Strings(got)
+	if want := []string{"code", "serial", "status"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("mutation items = %v, want %v", got, want)
+	}
+
+	if required := specValue(schemas, "SalesOrderCreateRequest", "x-required-fields"); fmt.Sprint(required) != "[code]" {
+		t.Errorf("x-required-fields = %v, want [code]", required)
+	}
+
+	if required := specValue(schemas, "SalesOrderUpdateRequest", "x-required-fields"); required != nil {
+		t.Errorf("update requires %v, want no fields", required)
+	}
+
+	if objectCode := specValue(schemas, "SalesOrderQuery", "properties", "object_code", "enum"); fmt.Sprint(objectCode) != "[sales_order]" {
+		t.Errorf("query object_code = %v, want [sales_order]", objectCode)
+	}
+}
+
+func TestOpenAPIDocumentIsGeneratedAgainWhenTheMetadataChanges(t *testing.T) {
+	uc, catalogRepo := newTestOpenAPIUsecase()
+	first, _ := getOpenAPIDocument(t, uc)
+
+	catalogRepo.objects = append(catalogRepo.objects, entity.Objects{Serial: "cu", Code: "customer"})
+
+	cached, spec := getOpenAPIDocument(t, uc)
+	if cached.ETag != first.ETag || specValue(spec, "components", "schemas", "CustomerRecord") != nil {
+		t.Errorf("document changed while the metadata version did not")
+	}
+
+	catalogRepo.version = "v2"
+
+	regenerated, spec := getOpenAPIDocument(t, uc)
+	if regenerated.ETag == first.ETag || specValue(spec, "components", "schemas", "CustomerRecord") == nil {
+		t.Errorf("document was not generated again for the new metadata version")
+	}
+}
+
+func TestOpenAPIColumnSchema(t *testing.T) {
+	tests := []struct {
+		dataType   string
+		wantType   any
+		wantFormat any
+	}{
+		{"integer", "integer", "int32"},
+		{"BIGINT", "integer", "int64"},
+		{"numeric", "number", nil},
+		{"double precision", "number", "double"},
+		{"boolean", "boolean", nil},
+		{"date", "string", "date"},
+		{"timestamp with time zone", "string", "date-time"},
+		{"uuid", "string", "uuid"},
+		{"jsonb", "object", nil},
+		{"character varying", "string", nil},
+		{entity.FieldKindRollup, nil, nil},
+	}
+
+	for _, test := range tests {
+		if schema := openAPIColumnSchema(test.dataType); schema["type"] != test.wantType || schema["format"] != test.wantFormat {
+			t.Errorf("%v: openAPIColumnSchema = %v, want %v %v", test.dataType, schema, test.wantType, test.wantFormat)
+		}
+	}
+}
+
+func TestOpenAPISchemaName(t *testing.T) {
+	tests := map[string]string{
+		"sales_order": "SalesOrder",
+		"invoice":     "Invoice",
+		"order-line":  "OrderLine",
+		"a__b":        "AB",
+	}
+
+	for code, want := range tests {
+		if got := openAPISchemaName(code); got != want {
+			t.Errorf("openAPISchemaName(%v) = %v, want %v", code, got, want)
+		}
+	}
+}
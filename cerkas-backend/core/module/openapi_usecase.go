@@ -0,0 +1,118 @@
+package module
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/cerkas/cerkas-backend/config"
+	"github.com/cerkas/cerkas-backend/core/entity"
+	"github.com/cerkas/cerkas-backend/core/repository"
+)
+
+type OpenAPIUsecase interface {
+	GetDocument(ctx context.Context, request entity.OpenAPIRequest) (resp entity.OpenAPIDocument, err error)
+}
+
+type openAPIUsecase struct {
+	cfg         config.Config
+	catalogRepo repository.CatalogRepository
+	catalogUc   CatalogUsecase
+
+	mu    sync.Mutex
+	cache map[entity.OpenAPIRequest]openAPICacheEntry
+}
+
+// openAPICacheEntry is a generated document with the metadata version it was generated from
+type openAPICacheEntry struct {
+	version     string
+	generatedAt time.Time
+	document    entity.OpenAPIDocument
+}
+
+func NewOpenAPIUsecase(cfg config.Config, catalogRepo repository.CatalogRepository, catalogUc CatalogUsecase) OpenAPIUsecase {
+	return &openAPIUsecase{
+		cfg:         cfg,
+		catalogRepo: catalogRepo,
+		catalogUc:   catalogUc,
+		cache:       map[entity.OpenAPIRequest]openAPICacheEntry{},
+	}
+}
+
+// GetDocument returns the OpenAPI document of the objects of a tenant. A document is generated again when the
+// metadata of the tenant changes, and after OpenAPICacheTTL seconds since the columns of a data source can change
+// without the metadata.
+func (uc *openAPIUsecase) GetDocument(ctx context.Context, request entity.OpenAPIRequest) (resp entity.OpenAPIDocument, err error) {
+	version, err := uc.catalogRepo.GetMetadataVersion(ctx, request.TenantCode)
+	if err != nil {
+		return resp, err
+	}
+
+	uc.mu.Lock()
+	cached, ok := uc.cache[request]
+	uc.mu.Unlock()
+
+	if ok && cached.version == version && time.Since(cached.generatedAt) < time.Duration(uc.cfg.OpenAPICacheTTL)*time.Second {
+		return cached.document, nil
+	}
+
+	spec, err := uc.generate(ctx, request, version)
+	if err != nil {
+		return resp, err
+	}
+
+	content, err := json.Marshal(spec)
+	if err != nil {
+		return resp, err
+	}
+
+	hash := sha256.Sum256(content)
+	resp = entity.OpenAPIDocument{
+		ETag:    `"` + hex.EncodeToString(hash[:16]) + `"`,
+		Content: content,
+	}
+
+	uc.mu.Lock()
+	uc.cache[request] = openAPICacheEntry{version: version, generatedAt: time.Now(), document: resp}
+	uc.mu.Unlock()
+
+	return resp, nil
+}
+
+// generate builds the document from the objects of the tenant, their introspected columns and their object fields
+func (uc *openAPIUsecase) generate(ctx context.Context, request entity.OpenAPIRequest, version string) (map[string]any, error) {
+	objects, err := uc.catalogRepo.GetObjectsByTenant(ctx, request.TenantCode)
+	if err != nil {
+		return nil, err
+	}
+
+	builder := newOpenAPIBuilder(request)
+	for _, object := range objects {
+		query := entity.CatalogQuery{
+			TenantCode:   request.TenantCode,
+			ProductCode:  request.ProductCode,
+			ObjectCode:   object.Code,
+			ObjectSerial: object.Serial,
+		}
+
+		// an object whose table can not be introspected is left out rather than failing the whole document
+		columns, _, _, _, err := uc.catalogRepo.GetColumnList(ctx, query)
+		if err != nil {
+			log.Printf("failed to list the columns of object %v for the openapi document: %v", object.Code, err)
+			continue
+		}
+
+		objectFields, err := uc.catalogUc.GetObjectFieldsByObjectCode(ctx, query)
+		if err != nil {
+			return nil, err
+		}
+
+		builder.addObject(object, columns, objectFields)
+	}
+
+	return builder.document(version), nil
+}
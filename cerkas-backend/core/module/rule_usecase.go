@@ -0,0 +1,114 @@
+package module
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/cerkas/cerkas-backend/config"
+	"github.com/cerkas/cerkas-backend/core/entity"
+	"github.com/cerkas/cerkas-backend/core/repository"
+	"github.com/cerkas/cerkas-backend/pkg/helper"
+)
+
+type RuleUsecase interface {
+	CreateRule(ctx context.Context, request entity.ObjectRule, userRole string) (resp entity.ObjectRule, err error)
+	GetRules(ctx context.Context, tenantCode, objectCode string) (resp []entity.ObjectRule, err error)
+	DeleteRule(ctx context.Context, tenantCode, serial, userRole string) (err error)
+}
+
+type ruleUsecase struct {
+	cfg      config.Config
+	ruleRepo repository.RuleRepository
+}
+
+func NewRuleUsecase(cfg config.Config, ruleRepo repository.RuleRepository) RuleUsecase {
+	return &ruleUsecase{
+		cfg:      cfg,
+		ruleRepo: ruleRepo,
+	}
+}
+
+// CreateRule stores a rule of an object, only an admin can change what runs inside the mutations of others
+func (uc *ruleUsecase) CreateRule(ctx context.Context, request entity.ObjectRule, userRole string) (resp entity.ObjectRule, err error) {
+	if !isAdmin(uc.cfg, userRole) {
+		return resp, entity.ErrorAdminRequired
+	}
+
+	if !helper.Contains(entity.RuleTimings, request.Timing) {
+		return resp, entity.ErrorRuleTimingInvalid
+	}
+
+	if len(request.EventTypes) == 0 {
+		request.EventTypes = entity.WebhookEventTypes
+	}
+
+	for _, eventType := range request.EventTypes {
+		if !helper.Contains(entity.WebhookEventTypes, eventType) {
+			return resp, entity.ErrorRuleEventInvalid
+		}
+	}
+
+	if len(request.Actions) == 0 {
+		return resp, fmt.Errorf("%w: a rule needs at least one action", entity.ErrorRuleActionInvalid)
+	}
+
+	for _, action := range request.Actions {
+		if err := validateRuleAction(action); err != nil {
+			return resp, err
+		}
+	}
+
+	request.Serial, err = helper.GenerateUUUID()
+	if err != nil {
+		return resp, err
+	}
+
+	if request.Name == "" {
+		request.Name = request.Serial
+	}
+
+	request.IsActive = true
+	request.CreatedAt = time.Now()
+
+	return uc.ruleRepo.CreateRule(ctx, request)
+}
+
+func (uc *ruleUsecase) GetRules(ctx context.Context, tenantCode, objectCode string) (resp []entity.ObjectRule, err error) {
+	return uc.ruleRepo.GetRules(ctx, tenantCode, objectCode)
+}
+
+func (uc *ruleUsecase) DeleteRule(ctx context.Context, tenantCode, serial, userRole string) (err error) {
+	if !isAdmin(uc.cfg, userRole) {
+		return entity.ErrorAdminRequired
+	}
+
+	if serial == "" {
+		return entity.ErrorSerialEmpty
+	}
+
+	return uc.ruleRepo.DeleteRule(ctx, tenantCode, serial)
+}
+
+// validateRuleAction checks an action has what its type needs
+func validateRuleAction(action entity.RuleAction) error {
+	switch action.Type {
+	case entity.RuleActionSetField:
+		if action.FieldCode == "" {
+			return fmt.Errorf("%w: set_field needs a field_code", entity.ErrorRuleActionInvalid)
+		}
+	case entity.RuleActionReject:
+	case entity.RuleActionCreateRecord:
+		if action.ObjectCode == "" || len(action.Items) == 0 {
+			return fmt.Errorf("%w: create_record needs an object_code and items", entity.ErrorRuleActionInvalid)
+		}
+	case entity.RuleActionEmitEvent:
+		if action.EventName == "" {
+			return fmt.Errorf("%w: emit_event needs an event_name", entity.ErrorRuleActionInvalid)
+		}
+	default:
+		return fmt.Errorf("%w: unknown type %q", entity.ErrorRuleActionInvalid, action.Type)
+	}
+
+	return nil
+}
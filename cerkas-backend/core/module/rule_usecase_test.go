@@ -0,0 +1,184 @@
+package module
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cerkas/cerkas-backend/config"
+	"github.com/cerkas/cerkas-backend/core/entity"
+)
+
+func TestRulesAreKeptToAdmins(t *testing.T) {
+	ruleRepo := &fakeRuleRepository{}
+	uc := NewRuleUsecase(config.Config{AdminRole: "admin"}, ruleRepo)
+	rule := entity.ObjectRule{
+		TenantCode: "acme",
+		ObjectCode: "invoice",
+		Timing:     entity.RuleTimingBefore,
+		Actions:    []entity.RuleAction{{Type: entity.RuleActionReject}},
+	}
+
+	for _, userRole := range []string{"", "sales"} {
+		if _, err := uc.CreateRule(context.Background(), rule, userRole); !errors.Is(err, entity.ErrorAdminRequired) {
+			t.Errorf("role %q created a rule, err %v", userRole, err)
+		}
+	}
+
+	created, err := uc.CreateRule(context.Background(), rule, "admin")
+	if err != nil {
+		t.Fatalf("admin could not create a rule: %v", err)
+	}
+
+	for _, userRole := range []string{"", "sales"} {
+		if err := uc.DeleteRule(context.Background(), "acme", created.Serial, userRole); !errors.Is(err, entity.ErrorAdminRequired) {
+			t.Errorf("role %q deleted a rule, err %v", userRole, err)
+		}
+	}
+
+	if err := uc.DeleteRule(context.Background(), "acme", created.Serial, "admin"); err != nil {
+		t.Fatalf("admin could not delete the rule: %v", err)
+	}
+
+	if len(ruleRepo.rules) != 0 {
+		t.Fatalf("rule is still stored after it was deleted")
+	}
+}
+
+func TestActiveRulesRunInOrder(t *testing.T) {
+	createdAt := time.Now()
+	rule := func(serial string, sequence int, created time.Time) entity.ObjectRule {
+		return entity.ObjectRule{
+			Serial:     serial,
+			ObjectCode: "invoice",
+			Timing:     entity.RuleTimingBefore,
+			EventTypes: []string{entity.AuditActionUpdate},
+			Sequence:   sequence,
+			IsActive:   true,
+			CreatedAt:  created,
+		}
+	}
+
+	ruleRepo := &fakeRuleRepository{rules: []entity.ObjectRule{
+		rule("d", 2, createdAt),
+		rule("c", 1, createdAt.Add(time.Minute)),
+		rule("b", 1, createdAt),
+		rule("a", 1, createdAt),
+	}}
+	uc := &catalogUsecase{ruleRepo: ruleRepo}
+
+	rules, err := uc.activeRules(context.Background(), entity.DataMutationRequest{ObjectCode: "invoice"}, entity.RuleTimingBefore, entity.AuditActionUpdate)
+	if err != nil {
+		t.Fatalf("activeRules: %v", err)
+	}
+
+	got := ""
+	for _, rule := range rules {
+		got += rule.Serial
+	}
+
+	if got != "abcd" {
+		t.Errorf("rules run as %q, want abcd", got)
+	}
+}
+
+func TestActiveRulesStopARecursion(t *testing.T) {
+	rule := func(serial string) entity.ObjectRule {
+		return entity.ObjectRule{
+			Serial:     serial,
+			ObjectCode: "invoice",
+			Timing:     entity.RuleTimingAfter,
+			EventTypes: []string{entity.AuditActionUpdate},
+			IsActive:   true,
+		}
+	}
+
+	uc := &catalogUsecase{ruleRepo: &fakeRuleRepository{rules: []entity.ObjectRule{rule("a"), rule("b")}}}
+	request := entity.DataMutationRequest{ObjectCode: "invoice"}
+
+	chainOf := func(serials ...string) context.Context {
+		ctx := context.Background()
+		for _, serial := range serials {
+			ctx = withRuleChain(ctx, serial)
+		}
+		return ctx
+	}
+
+	tests := []struct {
+		name  string
+		ctx   context.Context
+		rules string
+		want  error
+	}{
+		{"no chain", chainOf(), "ab", nil},
+		{"caused by a", chainOf("a"), "b", nil},
+		{"caused by both", chainOf("a", "b"), "", nil},
+		{"too deep", chainOf("x", "y", "a", "z", "w"), "", entity.ErrorRuleRecursion},
+		{"deep but nothing left to run", chainOf("x", "y", "z", "a", "b"), "", nil},
+	}
+
+	for _, test := range tests {
+		rules, err := uc.activeRules(test.ctx, request, entity.RuleTimingAfter, entity.AuditActionUpdate)
+		if !errors.Is(err, test.want) {
+			t.Errorf("%v: activeRules = %v, want %v", test.name, err, test.want)
+		}
+
+		got := ""
+		for _, rule := range rules {
+			got += rule.Serial
+		}
+
+		if got != test.rules {
+			t.Errorf("%v: rules %q, want %q", test.name, got, test.rules)
+		}
+	}
+
+	if chain := ruleChain(chainOf("a", "b")); len(chain) != 2 || chain[0] != "a" || chain[1] != "b" {
+		t.Errorf("ruleChain = %v, want [a b]", chain)
+	}
+}
+
+func TestBeforeRulesSetFieldsAndReject(t *testing.T) {
+	ruleRepo := &fakeRuleRepository{rules: []entity.ObjectRule{
+		{
+			Serial:     "copy-name",
+			ObjectCode: "invoice",
+			Timing:     entity.RuleTimingBefore,
+			EventTypes: []string{entity.AuditActionUpdate},
+			IsActive:   true,
+			Actions:    []entity.RuleAction{{Type: entity.RuleActionSetField, FieldCode: "title", Value: "$name"}},
+		},
+		{
+			Serial:     "no-void",
+			Name:       "void invoices are locked",
+			ObjectCode: "invoice",
+			Timing:     entity.RuleTimingBefore,
+			EventTypes: []string{entity.AuditActionUpdate},
+			IsActive:   true,
+			Sequence:   1,
+			Condition: []entity.FilterGroup{{Filters: map[string]entity.FilterItem{
+				"old.status": {Operator: entity.FilterOperatorEqual, Value: "void"},
+			}}},
+			Actions: []entity.RuleAction{{Type: entity.RuleActionReject}},
+		},
+	}}
+	uc := &catalogUsecase{ruleRepo: ruleRepo}
+
+	request := entity.DataMutationRequest{ObjectCode: "invoice", Items: []entity.DataItem{{FieldCode: "name", Value: "March"}}}
+
+	updated, err := uc.runBeforeRules(context.Background(), entity.AuditActionUpdate, request, map[string]entity.DataItem{"status": {Value: "open"}})
+	if err != nil {
+		t.Fatalf("runBeforeRules = %v", err)
+	}
+
+	if len(updated.Items) != 2 || updated.Items[1].FieldCode != "title" || updated.Items[1].Value != "March" {
+		t.Errorf("items = %+v, want title set from name", updated.Items)
+	}
+
+	_, err = uc.runBeforeRules(context.Background(), entity.AuditActionUpdate, request, map[string]entity.DataItem{"status": {Value: "void"}})
+	if !errors.Is(err, entity.ErrorRuleRejected) || !strings.Contains(err.Error(), "void invoices are locked") {
+		t.Errorf("void invoice: runBeforeRules = %v, want %v named after the rule", err, entity.ErrorRuleRejected)
+	}
+}
@@ -0,0 +1,415 @@
+package module
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/cerkas/cerkas-backend/config"
+	"github.com/cerkas/cerkas-backend/core/entity"
+	"github.com/cerkas/cerkas-backend/core/repository"
+	"github.com/cerkas/cerkas-backend/pkg/helper"
+)
+
+// scheduledJobHandler runs a job of one type, the result summarizes what it did for the run history
+type scheduledJobHandler func(ctx context.Context, job entity.ScheduledJob) (result string, err error)
+
+var snapshotTablePattern = regexp.MustCompile(`^[a-z_][a-z0-9_]*$`)
+
+type ScheduleUsecase interface {
+	CreateJob(ctx context.Context, request entity.ScheduledJob, userRole string) (resp entity.ScheduledJob, err error)
+	GetJobs(ctx context.Context, tenantCode, userRole string) (resp []entity.ScheduledJob, err error)
+	DeleteJob(ctx context.Context, tenantCode, serial, userRole string) (err error)
+	GetRuns(ctx context.Context, request entity.ScheduledJobRunQuery, userRole string) (resp entity.ScheduledJobRunResponse, err error)
+	RunDueJobs(ctx context.Context) (started int, err error)
+	RunScheduler(ctx context.Context)
+}
+
+type scheduleUsecase struct {
+	cfg          config.Config
+	scheduleRepo repository.ScheduleRepository
+	catalogRepo  repository.CatalogRepository
+	catalogUc    CatalogUsecase
+	locker       repository.Locker
+	transactor   repository.Transactor
+	eventBus     EventBus
+	handlers     map[string]scheduledJobHandler
+}
+
+func NewScheduleUsecase(cfg config.Config, scheduleRepo repository.ScheduleRepository, catalogRepo repository.CatalogRepository, catalogUc CatalogUsecase, locker repository.Locker, transactor repository.Transactor, eventBus EventBus) ScheduleUsecase {
+	uc := &scheduleUsecase{
+		cfg:          cfg,
+		scheduleRepo: scheduleRepo,
+		catalogRepo:  catalogRepo,
+		catalogUc:    catalogUc,
+		locker:       locker,
+		transactor:   transactor,
+		eventBus:     eventBus,
+	}
+
+	uc.handlers = map[string]scheduledJobHandler{
+		entity.ScheduledJobTypeExportView:     uc.exportView,
+		entity.ScheduledJobTypePurgeDeleted:   uc.purgeDeleted,
+		entity.ScheduledJobTypeRefreshRollups: uc.refreshRollups,
+		entity.ScheduledJobTypeQuerySnapshot:  uc.snapshotQuery,
+	}
+
+	return uc
+}
+
+// CreateJob is kept to admins, a scheduled job runs with the rights of the backend and query_snapshot runs sql
+func (uc *scheduleUsecase) CreateJob(ctx context.Context, request entity.ScheduledJob, userRole string) (resp entity.ScheduledJob, err error) {
+	if !isAdmin(uc.cfg, userRole) {
+		return resp, entity.ErrorAdminRequired
+	}
+
+	if !helper.Contains(entity.ScheduledJobTypes, request.JobType) {
+		return resp, entity.ErrorScheduledJobTypeInvalid
+	}
+
+	if err = validateScheduledJobConfig(request.JobType, request.Config); err != nil {
+		return resp, err
+	}
+
+	request.NextRunAt, err = nextScheduledRun(request, time.Now())
+	if err != nil {
+		return resp, err
+	}
+
+	request.Serial, err = helper.GenerateUUUID()
+	if err != nil {
+		return resp, err
+	}
+
+	if request.Name == "" {
+		request.Name = request.Serial
+	}
+
+	request.IsActive = true
+	request.CreatedAt = time.Now()
+
+	return uc.scheduleRepo.CreateJob(ctx, request)
+}
+
+func (uc *scheduleUsecase) GetJobs(ctx context.Context, tenantCode, userRole string) (resp []entity.ScheduledJob, err error) {
+	if !isAdmin(uc.cfg, userRole) {
+		return resp, entity.ErrorAdminRequired
+	}
+
+	return uc.scheduleRepo.GetJobs(ctx, tenantCode)
+}
+
+func (uc *scheduleUsecase) DeleteJob(ctx context.Context, tenantCode, serial, userRole string) (err error) {
+	if !isAdmin(uc.cfg, userRole) {
+		return entity.ErrorAdminRequired
+	}
+
+	if serial == "" {
+		return entity.ErrorSerialEmpty
+	}
+
+	return uc.scheduleRepo.DeleteJob(ctx, tenantCode, serial)
+}
+
+func (uc *scheduleUsecase) GetRuns(ctx context.Context, request entity.ScheduledJobRunQuery, userRole string) (resp entity.ScheduledJobRunResponse, err error) {
+	if !isAdmin(uc.cfg, userRole) {
+		return resp, entity.ErrorAdminRequired
+	}
+
+	limit, _, _, _ := helper.SetupListParameter(int32(request.Page), int32(request.PageSize))
+
+	items, total, err := uc.scheduleRepo.GetRuns(ctx, request)
+	if err != nil {
+		return resp, err
+	}
+
+	resp = entity.ScheduledJobRunResponse{
+		Page:      max(request.Page, 1),
+		PageSize:  int(limit),
+		TotalData: int(total),
+		TotalPage: int(helper.GenerateTotalPage(total, int64(limit))),
+		Items:     items,
+	}
+
+	return resp, nil
+}
+
+// RunDueJobs starts the due jobs of every tenant. A job runs on the replica holding its advisory lock, and only when
+// that replica is the one moving the job to its next run, so every run happens once across the replicas. A job still
+// running when it is due again is skipped until it is done.
+func (uc *scheduleUsecase) RunDueJobs(ctx context.Context) (started int, err error) {
+	now := time.Now()
+
+	jobs, err := uc.scheduleRepo.GetDueJobs(ctx, now, uc.cfg.SchedulerBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, job := range jobs {
+		nextRunAt, err := nextScheduledRun(job, now)
+		if err != nil {
+			log.Printf("scheduled job %v of %v: %v", job.Serial, job.TenantCode, err)
+			continue
+		}
+
+		release, acquired, err := uc.locker.TryLock(ctx, "scheduled_job:"+job.Serial)
+		if err != nil {
+			log.Printf("failed to lock scheduled job %v: %v", job.Serial, err)
+			continue
+		}
+
+		if !acquired {
+			continue
+		}
+
+		advanced, err := uc.scheduleRepo.AdvanceJob(ctx, job, nextRunAt)
+		if err != nil || !advanced {
+			if err != nil {
+				log.Printf("failed to advance scheduled job %v: %v", job.Serial, err)
+			}
+
+			release()
+			continue
+		}
+
+		started++
+		go func(job entity.ScheduledJob) {
+			defer release()
+
+			uc.runJob(ctx, job)
+		}(job)
+	}
+
+	return started, nil
+}
+
+// RunScheduler starts the due jobs until ctx is done
+func (uc *scheduleUsecase) RunScheduler(ctx context.Context) {
+	interval := time.Duration(uc.cfg.SchedulerInterval) * time.Second
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := uc.RunDueJobs(ctx); err != nil {
+				log.Println("failed to run scheduled jobs:", err)
+			}
+		}
+	}
+}
+
+// runJob runs a job and records the run, a panicking job fails its run instead of the scheduler
+func (uc *scheduleUsecase) runJob(ctx context.Context, job entity.ScheduledJob) {
+	run := entity.ScheduledJobRun{
+		JobSerial:  job.Serial,
+		TenantCode: job.TenantCode,
+		JobType:    job.JobType,
+		Status:     entity.ScheduledJobRunStatusRunning,
+		StartedAt:  time.Now(),
+	}
+
+	var err error
+	run.Serial, err = helper.GenerateUUUID()
+	if err != nil {
+		log.Printf("scheduled job %v: %v", job.Serial, err)
+		return
+	}
+
+	run, err = uc.scheduleRepo.CreateRun(ctx, run)
+	if err != nil {
+		log.Printf("failed to record the run of scheduled job %v: %v", job.Serial, err)
+		return
+	}
+
+	run.Result, err = uc.handleJob(ctx, job)
+
+	finishedAt := time.Now()
+	run.FinishedAt = &finishedAt
+	run.Status = entity.ScheduledJobRunStatusSucceeded
+
+	if err != nil {
+		run.Status = entity.ScheduledJobRunStatusFailed
+		run.Error = err.Error()
+		log.Printf("scheduled job %v %v of %v failed: %v", job.JobType, job.Serial, job.TenantCode, err)
+	}
+
+	if err := uc.scheduleRepo.UpdateRun(ctx, run); err != nil {
+		log.Printf("failed to update the run %v of scheduled job %v: %v", run.Serial, job.Serial, err)
+	}
+}
+
+func (uc *scheduleUsecase) handleJob(ctx context.Context, job entity.ScheduledJob) (result string, err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("panic: %v", recovered)
+		}
+	}()
+
+	handler, ok := uc.handlers[job.JobType]
+	if !ok {
+		return "", entity.ErrorScheduledJobTypeInvalid
+	}
+
+	return handler(ctx, job)
+}
+
+// purgeDeleted removes the records soft deleted more than the configured days ago. A purged record holding files
+// publishes a purge event, so the file subscriber removes what is left of them in the storage.
+func (uc *scheduleUsecase) purgeDeleted(ctx context.Context, job entity.ScheduledJob) (result string, err error) {
+	before := time.Now().AddDate(0, 0, -job.Config.Days)
+
+	fileFields, err := uc.catalogRepo.GetFileFields(ctx, job.TenantCode, job.Config.ObjectCode)
+	if err != nil {
+		return "", err
+	}
+
+	fieldCodes := []string{}
+	for _, field := range fileFields {
+		fieldCodes = append(fieldCodes, field.FieldCode)
+	}
+
+	var purged []map[string]any
+	err = uc.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		purged, err = uc.catalogRepo.PurgeDeletedData(ctx, job.TenantCode, job.Config.ObjectCode, before, fieldCodes)
+		if err != nil {
+			return err
+		}
+
+		events := []entity.RecordEvent{}
+		for _, record := range purged {
+			if event, ok := purgeEvent(job, record, fieldCodes); ok {
+				events = append(events, event)
+			}
+		}
+
+		if len(events) == 0 {
+			return nil
+		}
+
+		return uc.eventBus.Publish(ctx, events...)
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("purged %v records of %v deleted before %v", len(purged), job.Config.ObjectCode, before.Format(time.RFC3339)), nil
+}
+
+// purgeEvent returns the purge event of a record, a record without files needs none
+func purgeEvent(job entity.ScheduledJob, record map[string]any, fieldCodes []string) (event entity.RecordEvent, ok bool) {
+	oldData := map[string]entity.DataItem{}
+	for _, fieldCode := range fieldCodes {
+		if record[fieldCode] != nil {
+			oldData[fieldCode] = entity.DataItem{FieldCode: fieldCode, Value: record[fieldCode]}
+		}
+	}
+
+	if len(oldData) == 0 {
+		return event, false
+	}
+
+	return entity.RecordEvent{
+		Type:         entity.RecordPurged,
+		TenantCode:   job.TenantCode,
+		ProductCode:  job.Config.ProductCode,
+		ObjectCode:   job.Config.ObjectCode,
+		RecordSerial: fmt.Sprintf("%v", record["serial"]),
+		OldData:      oldData,
+		OccurredAt:   time.Now(),
+	}, true
+}
+
+func (uc *scheduleUsecase) refreshRollups(ctx context.Context, job entity.ScheduledJob) (result string, err error) {
+	fieldCodes, err := uc.catalogRepo.RefreshObjectRollups(ctx, job.TenantCode, job.Config.ObjectCode)
+	if err != nil {
+		return "", err
+	}
+
+	if len(fieldCodes) == 0 {
+		return fmt.Sprintf("%v has no materialized rollup fields", job.Config.ObjectCode), nil
+	}
+
+	return fmt.Sprintf("refreshed %v of %v", strings.Join(fieldCodes, ", "), job.Config.ObjectCode), nil
+}
+
+func (uc *scheduleUsecase) snapshotQuery(ctx context.Context, job entity.ScheduledJob) (result string, err error) {
+	rows, err := uc.catalogRepo.SnapshotQuery(ctx, job.TenantCode, job.Config.ObjectCode, job.Config.RawQuery, job.Config.TargetTable)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("stored %v rows in %v", rows, job.Config.TargetTable), nil
+}
+
+// nextScheduledRun returns the first run of a job after a time, read in the timezone of the job
+func nextScheduledRun(job entity.ScheduledJob, after time.Time) (time.Time, error) {
+	schedule, err := helper.ParseCron(job.CronExpression)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: %v", entity.ErrorScheduledJobCronInvalid, err)
+	}
+
+	location := time.UTC
+	if job.Timezone != "" {
+		location, err = time.LoadLocation(job.Timezone)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("%w: %v", entity.ErrorScheduledJobCronInvalid, err)
+		}
+	}
+
+	next := schedule.Next(after.In(location))
+	if next.IsZero() {
+		return next, fmt.Errorf("%w: %v never runs", entity.ErrorScheduledJobCronInvalid, job.CronExpression)
+	}
+
+	return next, nil
+}
+
+// validateScheduledJobConfig checks a job type has the settings it reads
+func validateScheduledJobConfig(jobType string, jobConfig entity.ScheduledJobConfig) error {
+	switch jobType {
+	case entity.ScheduledJobTypeExportView:
+		if jobConfig.ObjectCode == "" || jobConfig.ViewContentCode == "" {
+			return fmt.Errorf("%w: export_view needs object_code and view_content_code", entity.ErrorScheduledJobConfigInvalid)
+		}
+
+		if !isExportPath(jobConfig.Path) {
+			return fmt.Errorf("%w: export_view needs a relative path inside the export directory", entity.ErrorScheduledJobConfigInvalid)
+		}
+	case entity.ScheduledJobTypePurgeDeleted:
+		if jobConfig.ObjectCode == "" || jobConfig.Days <= 0 {
+			return fmt.Errorf("%w: purge_deleted needs object_code and days", entity.ErrorScheduledJobConfigInvalid)
+		}
+	case entity.ScheduledJobTypeRefreshRollups:
+		if jobConfig.ObjectCode == "" {
+			return fmt.Errorf("%w: refresh_rollups needs object_code", entity.ErrorScheduledJobConfigInvalid)
+		}
+	case entity.ScheduledJobTypeQuerySnapshot:
+		if !isSelectQuery(jobConfig.RawQuery) {
+			return fmt.Errorf("%w: query_snapshot needs a raw_query of a single select statement", entity.ErrorScheduledJobConfigInvalid)
+		}
+
+		if !snapshotTablePattern.MatchString(jobConfig.TargetTable) {
+			return fmt.Errorf("%w: query_snapshot needs a target_table of lowercase letters, digits and underscores", entity.ErrorScheduledJobConfigInvalid)
+		}
+	}
+
+	return nil
+}
+
+// isSelectQuery reports whether a query is a single select statement, the snapshot wraps it in a subquery
+func isSelectQuery(query string) bool {
+	query = strings.TrimSuffix(strings.TrimSpace(query), ";")
+	fields := strings.Fields(query)
+
+	return len(fields) > 0 && strings.EqualFold(fields[0], "select") && !strings.Contains(query, ";")
+}
@@ -0,0 +1,206 @@
+package module
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cerkas/cerkas-backend/config"
+	"github.com/cerkas/cerkas-backend/core/entity"
+)
+
+func snapshotJob(rawQuery string) entity.ScheduledJob {
+	return entity.ScheduledJob{
+		TenantCode:     "acme",
+		JobType:        entity.ScheduledJobTypeQuerySnapshot,
+		CronExpression: "0 1 * * *",
+		Config: entity.ScheduledJobConfig{
+			ObjectCode:  "invoice",
+			RawQuery:    rawQuery,
+			TargetTable: "invoice_daily",
+		},
+	}
+}
+
+func TestScheduledJobsAreKeptToAdmins(t *testing.T) {
+	scheduleRepo := &fakeScheduleRepository{}
+	uc := NewScheduleUsecase(config.Config{AdminRole: "admin"}, scheduleRepo, nil, nil, nil, nil, nil)
+
+	for _, userRole := range []string{"", "sales"} {
+		if _, err := uc.CreateJob(context.Background(), snapshotJob("SELECT count(*) FROM invoice"), userRole); !errors.Is(err, entity.ErrorAdminRequired) {
+			t.Errorf("role %q scheduled a job, err %v", userRole, err)
+		}
+
+		if _, err := uc.GetJobs(context.Background(), "acme", userRole); !errors.Is(err, entity.ErrorAdminRequired) {
+			t.Errorf("role %q listed the jobs, err %v", userRole, err)
+		}
+
+		if err := uc.DeleteJob(context.Background(), "acme", "job-1", userRole); !errors.Is(err, entity.ErrorAdminRequired) {
+			t.Errorf("role %q deleted a job, err %v", userRole, err)
+		}
+
+		if _, err := uc.GetRuns(context.Background(), entity.ScheduledJobRunQuery{TenantCode: "acme"}, userRole); !errors.Is(err, entity.ErrorAdminRequired) {
+			t.Errorf("role %q listed the runs, err %v", userRole, err)
+		}
+	}
+
+	job, err := uc.CreateJob(context.Background(), snapshotJob("SELECT count(*) FROM invoice"), "admin")
+	if err != nil {
+		t.Fatalf("admin could not schedule a job: %v", err)
+	}
+
+	if job.Serial == "" || job.NextRunAt.IsZero() || len(scheduleRepo.jobs) != 1 {
+		t.Errorf("job is stored as %+v", job)
+	}
+}
+
+func TestQuerySnapshotTakesASingleSelect(t *testing.T) {
+	tests := []struct {
+		rawQuery string
+		wantErr  error
+	}{
+		{"SELECT status, count(*) FROM invoice GROUP BY status", nil},
+		{"  select 1;  ", nil},
+		{"", entity.ErrorScheduledJobConfigInvalid},
+		{"DELETE FROM invoice", entity.ErrorScheduledJobConfigInvalid},
+		{"SELECT 1; DROP TABLE invoice", entity.ErrorScheduledJobConfigInvalid},
+	}
+
+	uc := NewScheduleUsecase(config.Config{AdminRole: "admin"}, &fakeScheduleRepository{}, nil, nil, nil, nil, nil)
+	for _, test := range tests {
+		if _, err := uc.CreateJob(context.Background(), snapshotJob(test.rawQuery), "admin"); !errors.Is(err, test.wantErr) {
+			t.Errorf("raw_query %q returned %v, want %v", test.rawQuery, err, test.wantErr)
+		}
+	}
+}
+
+func TestPurgeDeletedRemovesTheFilesOfPurgedRecords(t *testing.T) {
+	contract := entity.FileAttachment{Serial: "f1", StorageKey: "acme/invoice/i1/contract/f1"}
+	catalogRepo := &fakeCatalogRepository{
+		fileFields: map[string][]entity.FileField{"invoice": {{FieldCode: "contract"}}},
+		records: map[string][]map[string]any{"invoice": {
+			{"serial": "i1", "contract": contract, "deleted_at": time.Now().AddDate(0, 0, -40)},
+			{"serial": "i2", "contract": nil, "deleted_at": time.Now().AddDate(0, 0, -40)},
+			{"serial": "i3", "contract": nil, "deleted_at": time.Now()},
+			{"serial": "i4", "contract": nil},
+		}},
+	}
+	eventBus := &fakeEventBus{}
+	cfg := config.Config{ThumbnailSizes: []int{128}}
+	uc := &scheduleUsecase{cfg: cfg, catalogRepo: catalogRepo, transactor: fakeTransactor{}, eventBus: eventBus}
+
+	job := entity.ScheduledJob{
+		TenantCode: "acme",
+		JobType:    entity.ScheduledJobTypePurgeDeleted,
+		Config:     entity.ScheduledJobConfig{ObjectCode: "invoice", Days: 30},
+	}
+	result, err := uc.purgeDeleted(context.Background(), job)
+	if err != nil {
+		t.Fatalf("purgeDeleted: %v", err)
+	}
+
+	if !strings.HasPrefix(result, "purged 2 records") || len(catalogRepo.records["invoice"]) != 2 {
+		t.Errorf("result is %q with %v records left, want 2 records purged", result, len(catalogRepo.records["invoice"]))
+	}
+
+	// only the purged record holding a file needs an event
+	if len(eventBus.events) != 1 || eventBus.events[0].Type != entity.RecordPurged || eventBus.events[0].RecordSerial != "i1" {
+		t.Fatalf("published %+v, want one purge event of i1", eventBus.events)
+	}
+
+	storage := &fakeFileStorage{}
+	fileUc := NewFileUsecase(cfg, catalogRepo, nil, storage)
+	if err := fileUc.HandleRecordEvent(context.Background(), eventBus.events[0]); err != nil {
+		t.Fatalf("HandleRecordEvent: %v", err)
+	}
+
+	want := []string{contract.StorageKey, thumbnailKey(contract.StorageKey, 128)}
+	if strings.Join(storage.deleted, ",") != strings.Join(want, ",") {
+		t.Errorf("deleted %v, want %v", storage.deleted, want)
+	}
+}
+
+func TestRunDueJobsRunsEveryDueJobOnce(t *testing.T) {
+	due := time.Now().Add(-time.Minute)
+	scheduleRepo := &fakeScheduleRepository{jobs: []entity.ScheduledJob{
+		{Serial: "rollups", TenantCode: "acme", JobType: entity.ScheduledJobTypeRefreshRollups, CronExpression: "*/5 * * * *", Config: entity.ScheduledJobConfig{ObjectCode: "order"}, IsActive: true, NextRunAt: due},
+		{Serial: "unknown", TenantCode: "acme", JobType: "reindex", CronExpression: "0 * * * *", IsActive: true, NextRunAt: due},
+		{Serial: "broken-cron", TenantCode: "acme", JobType: entity.ScheduledJobTypeRefreshRollups, CronExpression: "not a cron", IsActive: true, NextRunAt: due},
+		{Serial: "later", TenantCode: "acme", JobType: entity.ScheduledJobTypeRefreshRollups, CronExpression: "0 * * * *", IsActive: true, NextRunAt: time.Now().Add(time.Hour)},
+		{Serial: "locked", TenantCode: "acme", JobType: entity.ScheduledJobTypeRefreshRollups, CronExpression: "0 * * * *", IsActive: true, NextRunAt: due},
+	}}
+	catalogRepo := &fakeCatalogRepository{rollups: map[string][]entity.RollupField{"order_line": {orderTotalRollup(entity.RollupFunctionSum)}}}
+	locker := &fakeLocker{held: map[string]bool{"scheduled_job:locked": true}}
+	uc := NewScheduleUsecase(config.Config{}, scheduleRepo, catalogRepo, nil, locker, nil, nil)
+
+	started, err := uc.RunDueJobs(context.Background())
+	if err != nil {
+		t.Fatalf("RunDueJobs = %v", err)
+	}
+	locker.wait()
+
+	if started != 2 {
+		t.Errorf("started %v jobs, want 2", started)
+	}
+
+	runs := map[string]entity.ScheduledJobRun{}
+	for _, run := range scheduleRepo.runs {
+		runs[run.JobSerial] = run
+	}
+
+	if run := runs["rollups"]; run.Status != entity.ScheduledJobRunStatusSucceeded || run.Result != "refreshed total of order" || run.FinishedAt == nil {
+		t.Errorf("rollups run = %+v, want a finished run refreshing total", run)
+	}
+
+	if run := runs["unknown"]; run.Status != entity.ScheduledJobRunStatusFailed || run.Error != entity.ErrorScheduledJobTypeInvalid.Error() {
+		t.Errorf("unknown run = %+v, want a failed run", run)
+	}
+
+	for _, serial := range []string{"broken-cron", "later", "locked"} {
+		if _, ok := runs[serial]; ok {
+			t.Errorf("%v ran, want it left alone", serial)
+		}
+	}
+
+	for _, job := range scheduleRepo.jobs {
+		moved := job.NextRunAt.After(time.Now())
+		if wantMoved := job.Serial == "rollups" || job.Serial == "unknown" || job.Serial == "later"; moved != wantMoved {
+			t.Errorf("%v: next run %v, moved %v, want %v", job.Serial, job.NextRunAt, moved, wantMoved)
+		}
+	}
+
+	started, err = uc.RunDueJobs(context.Background())
+	locker.wait()
+	if err != nil || started != 0 {
+		t.Errorf("second RunDueJobs started %v jobs, err %v, want none", started, err)
+	}
+}
+
+func TestNextScheduledRunReadsTheTimezoneOfTheJob(t *testing.T) {
+	after := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		timezone string
+		want     time.Time
+		wantErr  error
+	}{
+		{"utc", "", time.Date(2026, 1, 1, 1, 0, 0, 0, time.UTC), nil},
+		{"jakarta", "Asia/Jakarta", time.Date(2026, 1, 1, 18, 0, 0, 0, time.UTC), nil},
+		{"unknown timezone", "Mars/Olympus", time.Time{}, entity.ErrorScheduledJobCronInvalid},
+	}
+
+	for _, test := range tests {
+		next, err := nextScheduledRun(entity.ScheduledJob{CronExpression: "0 1 * * *", Timezone: test.timezone}, after)
+		if !errors.Is(err, test.wantErr) {
+			t.Errorf("%v: nextScheduledRun = %v, want %v", test.name, err, test.wantErr)
+			continue
+		}
+
+		if !next.Equal(test.want) {
+			t.Errorf("%v: next run %v, want %v", test.name, next, test.want)
+		}
+	}
+}
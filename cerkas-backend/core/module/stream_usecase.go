@@ -0,0 +1,322 @@
+package module
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"sync"
+
+	"github.com/cerkas/cerkas-backend/config"
+	"github.com/cerkas/cerkas-backend/core/entity"
+	"github.com/cerkas/cerkas-backend/core/repository"
+)
+
+// streamBufferSize is the number of changes a view stream may lag behind before it is dropped
+const streamBufferSize = 64
+
+type StreamUsecase interface {
+	Subscribe(ctx context.Context, request entity.GetViewContentByKeysRequest) (changes <-chan entity.RecordChange, err error)
+	HandleRecordEvent(ctx context.Context, event entity.RecordEvent) (err error)
+	RunChangeFeed(ctx context.Context)
+}
+
+// viewStream is a view with its open streams, changes are resolved once per view and fanned out to every stream
+type viewStream struct {
+	request entity.GetViewContentByKeysRequest
+	filters []entity.FilterGroup
+	streams map[chan entity.RecordChange]struct{}
+}
+
+type streamUsecase struct {
+	cfg            config.Config
+	outboxRepo     repository.OutboxRepository
+	changeFeedRepo repository.ChangeFeedRepository
+	catalogUc      CatalogUsecase
+
+	mu    sync.Mutex
+	views map[entity.GetViewContentByKeysRequest]*viewStream
+}
+
+func NewStreamUsecase(cfg config.Config, outboxRepo repository.OutboxRepository, changeFeedRepo repository.ChangeFeedRepository, catalogUc CatalogUsecase) StreamUsecase {
+	return &streamUsecase{
+		cfg:            cfg,
+		outboxRepo:     outboxRepo,
+		changeFeedRepo: changeFeedRepo,
+		catalogUc:      catalogUc,
+		views:          map[entity.GetViewContentByKeysRequest]*viewStream{},
+	}
+}
+
+// Subscribe opens a stream of the changes of a view, the stream is closed when ctx is done, when the reader falls
+// too far behind or when the subscriber can no longer read the view. Streams are kept per subscriber, every change is
+// read back with the identity of the subscriber.
+func (uc *streamUsecase) Subscribe(ctx context.Context, request entity.GetViewContentByKeysRequest) (changes <-chan entity.RecordChange, err error) {
+	request.LayoutType = "record"
+
+	viewContent, err := uc.readView(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+
+	stream := make(chan entity.RecordChange, streamBufferSize)
+
+	uc.mu.Lock()
+	view, ok := uc.views[request]
+	if !ok {
+		view = &viewStream{
+			request: request,
+			streams: map[chan entity.RecordChange]struct{}{},
+		}
+		uc.views[request] = view
+	}
+
+	// the latest stored filters win, a view edited while streamed is picked up by the next subscriber
+	view.filters = viewSchemaFilters(viewContent.ViewContent.ViewSchema.Query)
+	view.streams[stream] = struct{}{}
+	uc.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		uc.unsubscribe(request, stream)
+	}()
+
+	return stream, nil
+}
+
+// HandleRecordEvent broadcasts the event to the change feed of every replica
+func (uc *streamUsecase) HandleRecordEvent(ctx context.Context, event entity.RecordEvent) (err error) {
+	return uc.changeFeedRepo.Notify(ctx, event.ID)
+}
+
+// RunChangeFeed listens to the change feed and pushes the changes to the open view streams until ctx is done
+func (uc *streamUsecase) RunChangeFeed(ctx context.Context) {
+	err := uc.changeFeedRepo.Listen(ctx, func(eventID int64) {
+		if !uc.hasStreams() {
+			return
+		}
+
+		event, err := uc.outboxRepo.GetEvent(ctx, eventID)
+		if err != nil {
+			log.Printf("failed to read event %v of the change feed: %v", eventID, err)
+			return
+		}
+
+		uc.publish(ctx, event)
+	})
+	if err != nil {
+		log.Println("change feed stopped:", err)
+	}
+}
+
+// publish resolves the change of every view of the event object and sends it to its streams
+func (uc *streamUsecase) publish(ctx context.Context, event entity.RecordEvent) {
+	if !event.IsRecordChange() {
+		return
+	}
+
+	uc.mu.Lock()
+	views := []viewStream{}
+	for _, view := range uc.views {
+		if view.request.TenantCode == event.TenantCode && view.request.ObjectCode == event.ObjectCode {
+			views = append(views, viewStream{request: view.request, filters: view.filters})
+		}
+	}
+	uc.mu.Unlock()
+
+	for _, view := range views {
+		change, ok, err := uc.resolveChange(ctx, view, event)
+		if errors.Is(err, entity.ErrorNotFound) {
+			// the view was deleted or is no longer shared with the subscriber
+			uc.closeView(view.request)
+			continue
+		}
+
+		if err != nil {
+			log.Printf("failed to resolve %v of %v.%v %v for view %v: %v", event.Type, event.TenantCode, event.ObjectCode, event.RecordSerial, view.request.ViewContentCode, err)
+			continue
+		}
+
+		if ok {
+			uc.send(view.request, change)
+		}
+	}
+}
+
+// resolveChange returns what an event means for a view. A record is read back through GetObjectData with the
+// view schema, so the pushed item has the same fields and shape as the list. An update that moves a record in or
+// out of the view filters is pushed as a create or a delete.
+func (uc *streamUsecase) resolveChange(ctx context.Context, view viewStream, event entity.RecordEvent) (change entity.RecordChange, ok bool, err error) {
+	change = entity.RecordChange{
+		RecordSerial: event.RecordSerial,
+		OccurredAt:   event.OccurredAt,
+	}
+
+	// the subscriber must still be able to read the view, a view that is not found is read without its filters
+	if _, err = uc.readView(ctx, view.request); err != nil {
+		return change, false, err
+	}
+
+	wasInView := event.Type != entity.RecordCreated && matchFilters(localFilters(view.filters), dataItemValues(event.OldData))
+
+	if event.Type != entity.RecordDeleted {
+		item, err := uc.getViewItem(ctx, view.request, event.RecordSerial)
+		if err != nil {
+			return change, false, err
+		}
+
+		if item != nil {
+			change.Event = entity.StreamEventUpdate
+			if !wasInView {
+				change.Event = entity.StreamEventCreate
+			}
+
+			change.Item = item
+			return change, true, nil
+		}
+	}
+
+	if !wasInView {
+		return change, false, nil
+	}
+
+	change.Event = entity.StreamEventDelete
+	change.Item = map[string]entity.DataItem{
+		"serial": {FieldCode: "serial", FieldName: "Serial", DataType: "Uuid", Value: event.RecordSerial},
+	}
+
+	return change, true, nil
+}
+
+// readView returns the view of request as resolved for its user, a view the user can not read is not found
+func (uc *streamUsecase) readView(ctx context.Context, request entity.GetViewContentByKeysRequest) (resp entity.ViewContentResponse, err error) {
+	resp, err = uc.catalogUc.GetContentLayoutByKeys(ctx, request, entity.CatalogQuery{
+		UserSerial: request.UserSerial,
+		UserRole:   request.UserRole,
+	})
+	if err != nil {
+		return resp, err
+	}
+
+	if resp.ViewContent.Serial == "" {
+		return resp, entity.ErrorNotFound
+	}
+
+	return resp, nil
+}
+
+// getViewItem returns the record as listed by the view for the subscriber, or nil when the view filters exclude it
+func (uc *streamUsecase) getViewItem(ctx context.Context, request entity.GetViewContentByKeysRequest, recordSerial string) (item map[string]entity.DataItem, err error) {
+	resp, err := uc.catalogUc.GetObjectData(ctx, entity.CatalogQuery{
+		TenantCode:      request.TenantCode,
+		ProductCode:     request.ProductCode,
+		ObjectCode:      request.ObjectCode,
+		ViewContentCode: request.ViewContentCode,
+		StructureType:   entity.StructureTypeList,
+		Filters: []entity.FilterGroup{
+			{
+				Operator: entity.FilterOperator(entity.FilterOperatorAnd),
+				Filters: map[string]entity.FilterItem{
+					"serial": {FieldName: "serial", Operator: entity.FilterOperatorEqual, Value: recordSerial},
+				},
+			},
+		},
+		Page:       1,
+		PageSize:   1,
+		UserSerial: request.UserSerial,
+		UserRole:   request.UserRole,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(resp.Items) == 0 {
+		return nil, nil
+	}
+
+	return resp.Items[0], nil
+}
+
+func (uc *streamUsecase) send(request entity.GetViewContentByKeysRequest, change entity.RecordChange) {
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+
+	view, ok := uc.views[request]
+	if !ok {
+		return
+	}
+
+	for stream := range view.streams {
+		select {
+		case stream <- change:
+		default:
+			// the reader is too slow, closing the stream makes the client reconnect and reload the view
+			delete(view.streams, stream)
+			close(stream)
+		}
+	}
+}
+
+// closeView closes every stream of a view, the clients reconnect and are checked again on subscribe
+func (uc *streamUsecase) closeView(request entity.GetViewContentByKeysRequest) {
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+
+	view, ok := uc.views[request]
+	if !ok {
+		return
+	}
+
+	for stream := range view.streams {
+		close(stream)
+	}
+
+	delete(uc.views, request)
+}
+
+func (uc *streamUsecase) unsubscribe(request entity.GetViewContentByKeysRequest, stream chan entity.RecordChange) {
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+
+	view, ok := uc.views[request]
+	if !ok {
+		return
+	}
+
+	if _, ok := view.streams[stream]; ok {
+		delete(view.streams, stream)
+		close(stream)
+	}
+
+	if len(view.streams) == 0 {
+		delete(uc.views, request)
+	}
+}
+
+func (uc *streamUsecase) hasStreams() bool {
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+
+	return len(uc.views) > 0
+}
+
+// localFilters drops the filters on related fields, they can not be evaluated against the values of a record
+func localFilters(filters []entity.FilterGroup) []entity.FilterGroup {
+	result := make([]entity.FilterGroup, 0, len(filters))
+	for _, filterGroup := range filters {
+		localGroup := entity.FilterGroup{
+			Operator: filterGroup.Operator,
+			Filters:  map[string]entity.FilterItem{},
+		}
+
+		for fieldName, filter := range filterGroup.Filters {
+			if !strings.Contains(fieldName, "__") {
+				localGroup.Filters[fieldName] = filter
+			}
+		}
+
+		result = append(result, localGroup)
+	}
+
+	return result
+}
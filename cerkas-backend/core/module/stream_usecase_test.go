@@ -0,0 +1,145 @@
+package module
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/cerkas/cerkas-backend/config"
+	"github.com/cerkas/cerkas-backend/core/entity"
+)
+
+// newTestStream returns a stream usecase over a view of the open invoices
+func newTestStream(records ...map[string]any) (*streamUsecase, *fakeCatalogUsecase) {
+	catalogUc := &fakeCatalogUsecase{
+		catalogRepo: &fakeCatalogRepository{records: map[string][]map[string]any{"invoice": records}},
+		view: entity.ViewContentResponse{ViewContent: entity.ViewContent{
+			Serial: "view-1",
+			ViewSchema: entity.ViewSchema{Query: map[string]any{"filters": []any{
+				map[string]any{"operator": "AND", "filter_item": map[string]any{
+					"status": map[string]any{"field_code": "status", "operator": "equal", "value": "open"},
+				}},
+			}}},
+		}},
+	}
+
+	uc := NewStreamUsecase(config.Config{}, nil, nil, catalogUc).(*streamUsecase)
+	return uc, catalogUc
+}
+
+func streamEvent(eventType entity.RecordEventType, oldStatus string) entity.RecordEvent {
+	event := entity.RecordEvent{Type: eventType, TenantCode: "acme", ObjectCode: "invoice", RecordSerial: "i1"}
+	if oldStatus != "" {
+		event.OldData = map[string]entity.DataItem{"status": {FieldCode: "status", Value: oldStatus}}
+	}
+
+	return event
+}
+
+func TestStreamPushesWhatAChangeMeansForTheView(t *testing.T) {
+	tests := []struct {
+		name      string
+		event     entity.RecordEvent
+		newStatus string
+		want      string
+	}{
+		{"created in the view", streamEvent(entity.RecordCreated, ""), "open", entity.StreamEventCreate},
+		{"updated in the view", streamEvent(entity.RecordUpdated, "open"), "open", entity.StreamEventUpdate},
+		{"updated into the view", streamEvent(entity.RecordUpdated, "paid"), "open", entity.StreamEventCreate},
+		{"updated out of the view", streamEvent(entity.RecordUpdated, "open"), "paid", entity.StreamEventDelete},
+		{"deleted from the view", streamEvent(entity.RecordDeleted, "open"), "", entity.StreamEventDelete},
+		{"created out of the view", streamEvent(entity.RecordCreated, ""), "paid", ""},
+		{"deleted out of the view", streamEvent(entity.RecordDeleted, "paid"), "", ""},
+	}
+
+	for _, test := range tests {
+		records := []map[string]any{}
+		if test.newStatus != "" {
+			records = append(records, map[string]any{"serial": "i1", "status": test.newStatus})
+		}
+
+		uc, _ := newTestStream(records...)
+		request := entity.GetViewContentByKeysRequest{TenantCode: "acme", ObjectCode: "invoice", ViewContentCode: "open-invoices"}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		changes, err := uc.Subscribe(ctx, request)
+		if err != nil {
+			t.Fatalf("%v: Subscribe: %v", test.name, err)
+		}
+
+		uc.publish(context.Background(), test.event)
+
+		got := ""
+		select {
+		case change := <-changes:
+			got = change.Event
+			if change.RecordSerial != "i1" {
+				t.Errorf("%v: change of %v, want i1", test.name, change.RecordSerial)
+			}
+		default:
+		}
+
+		if got != test.want {
+			t.Errorf("%v: pushed %q, want %q", test.name, got, test.want)
+		}
+
+		cancel()
+	}
+}
+
+func TestStreamSkipsEventsThatAreNotRecordChanges(t *testing.T) {
+	uc, _ := newTestStream(map[string]any{"serial": "i1", "status": "open"})
+	changes, err := uc.Subscribe(context.Background(), entity.GetViewContentByKeysRequest{TenantCode: "acme", ObjectCode: "invoice"})
+	if err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+
+	uc.publish(context.Background(), streamEvent(entity.RecordPurged, "open"))
+
+	select {
+	case change := <-changes:
+		t.Fatalf("a purge was pushed as %+v", change)
+	default:
+	}
+}
+
+func TestStreamDropsASlowReader(t *testing.T) {
+	uc, _ := newTestStream(map[string]any{"serial": "i1", "status": "open"})
+	changes, err := uc.Subscribe(context.Background(), entity.GetViewContentByKeysRequest{TenantCode: "acme", ObjectCode: "invoice"})
+	if err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+
+	for i := 0; i <= streamBufferSize; i++ {
+		uc.publish(context.Background(), streamEvent(entity.RecordUpdated, "open"))
+	}
+
+	received := 0
+	for range changes {
+		received++
+	}
+
+	if received != streamBufferSize {
+		t.Errorf("slow reader received %v changes before its stream was closed, want %v", received, streamBufferSize)
+	}
+}
+
+func TestStreamClosesWhenTheViewIsGone(t *testing.T) {
+	uc, catalogUc := newTestStream(map[string]any{"serial": "i1", "status": "open"})
+	request := entity.GetViewContentByKeysRequest{TenantCode: "acme", ObjectCode: "invoice"}
+	changes, err := uc.Subscribe(context.Background(), request)
+	if err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+
+	catalogUc.view = entity.ViewContentResponse{}
+	uc.publish(context.Background(), streamEvent(entity.RecordUpdated, "open"))
+
+	if _, ok := <-changes; ok {
+		t.Fatalf("stream of a deleted view is still open")
+	}
+
+	if _, err := uc.Subscribe(context.Background(), request); !errors.Is(err, entity.ErrorNotFound) {
+		t.Errorf("subscribed to a deleted view, err %v", err)
+	}
+}
@@ -0,0 +1,183 @@
+package module
+
+import (
+	"context"
+	"errors"
+
+	"github.com/cerkas/cerkas-backend/config"
+	"github.com/cerkas/cerkas-backend/core/entity"
+	"github.com/cerkas/cerkas-backend/core/repository"
+	"github.com/cerkas/cerkas-backend/pkg/helper"
+)
+
+var (
+	// fields maintained by the system, a restore never writes them back
+	versionSystemFields = []string{"id", "serial", "created_by", "created_at", "updated_by", "updated_at", "deleted_by", "deleted_at"}
+)
+
+type VersionUsecase interface {
+	GetRecordVersions(ctx context.Context, request entity.CatalogQuery) (resp []entity.RecordVersion, err error)
+	RestoreRecordVersion(ctx context.Context, request entity.RestoreVersionRequest) (resp map[string]entity.DataItem, err error)
+	HandleRecordEvent(ctx context.Context, event entity.RecordEvent) (err error)
+}
+
+type versionUsecase struct {
+	cfg         config.Config
+	catalogRepo repository.CatalogRepository
+	versionRepo repository.VersionRepository
+	transactor  repository.Transactor
+	catalogUc   CatalogUsecase
+}
+
+func NewVersionUsecase(cfg config.Config, catalogRepo repository.CatalogRepository, versionRepo repository.VersionRepository, transactor repository.Transactor, catalogUc CatalogUsecase) VersionUsecase {
+	return &versionUsecase{
+		cfg:         cfg,
+		catalogRepo: catalogRepo,
+		versionRepo: versionRepo,
+		transactor:  transactor,
+		catalogUc:   catalogUc,
+	}
+}
+
+func (uc *versionUsecase) GetRecordVersions(ctx context.Context, request entity.CatalogQuery) (resp []entity.RecordVersion, err error) {
+	if request.Serial == "" {
+		return resp, entity.ErrorSerialEmpty
+	}
+
+	return uc.versionRepo.GetRecordVersions(ctx, request.TenantCode, request.ObjectCode, request.Serial)
+}
+
+// RestoreRecordVersion re-applies the values of a version through the normal update path.
+// A soft deleted record is brought back in the same transaction as the values, so a restore held back for
+// approval or failing on the update leaves the record deleted.
+func (uc *versionUsecase) RestoreRecordVersion(ctx context.Context, request entity.RestoreVersionRequest) (resp map[string]entity.DataItem, err error) {
+	if request.RecordSerial == "" {
+		return resp, entity.ErrorSerialEmpty
+	}
+
+	version, err := uc.versionRepo.GetRecordVersion(ctx, request.TenantCode, request.ObjectCode, request.RecordSerial, request.Version)
+	if err != nil {
+		return resp, err
+	}
+
+	if version.IsDeleted {
+		return resp, errors.New("a delete version has no values to restore, choose the version before it")
+	}
+
+	mutation := entity.DataMutationRequest{
+		Serial:      request.RecordSerial,
+		ObjectCode:  request.ObjectCode,
+		TenantCode:  request.TenantCode,
+		ProductCode: request.ProductCode,
+		UserSerial:  request.UserSerial,
+		UserRole:    request.UserRole,
+	}
+
+	current, err := uc.catalogRepo.GetObjectDetail(ctx, entity.CatalogQuery{
+		Serial:     request.RecordSerial,
+		ObjectCode: request.ObjectCode,
+		TenantCode: request.TenantCode,
+	})
+	if err != nil {
+		return resp, err
+	}
+
+	computedFields, err := uc.computedFields(ctx, request.TenantCode, request.ObjectCode)
+	if err != nil {
+		return resp, err
+	}
+
+	for fieldCode, item := range version.Data {
+		if helper.Contains(versionSystemFields, fieldCode) || helper.Contains(computedFields, fieldCode) {
+			continue
+		}
+
+		mutation.Items = append(mutation.Items, entity.DataItem{
+			FieldCode: fieldCode,
+			DataType:  item.DataType,
+			Value:     item.Value,
+		})
+	}
+
+	if len(current) > 0 {
+		if _, err = uc.catalogUc.UpdateObjectData(ctx, mutation); err != nil {
+			return resp, err
+		}
+	} else {
+		err = uc.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+			if err := uc.catalogRepo.RestoreObjectData(ctx, mutation); err != nil {
+				return err
+			}
+
+			// the change request would apply to a record that is still deleted, roll back the restore with it
+			var pending *ApprovalPendingError
+			_, err := uc.catalogUc.UpdateObjectData(ctx, mutation)
+			if errors.As(err, &pending) {
+				return entity.ErrorRestoreNeedsApproval
+			}
+
+			return err
+		})
+		if err != nil {
+			return resp, err
+		}
+	}
+
+	return uc.catalogUc.GetObjectDetail(ctx, entity.CatalogQuery{
+		ObjectCode:  request.ObjectCode,
+		TenantCode:  request.TenantCode,
+		ProductCode: request.ProductCode,
+	}, request.RecordSerial)
+}
+
+// computedFields returns the formula and rollup fields of an object, a restore leaves them to be computed again
+func (uc *versionUsecase) computedFields(ctx context.Context, tenantCode, objectCode string) (resp []string, err error) {
+	object, err := uc.catalogRepo.GetObjectByCode(ctx, objectCode, tenantCode)
+	if err != nil {
+		return resp, err
+	}
+
+	objectFields, err := uc.catalogRepo.GetObjectFieldsByObjectCode(ctx, entity.CatalogQuery{
+		TenantCode:   tenantCode,
+		ObjectCode:   objectCode,
+		ObjectSerial: object.Serial,
+	})
+	if err != nil {
+		return resp, err
+	}
+
+	for fieldCode, field := range objectFields {
+		if data, ok := field.(entity.ObjectFields); ok && (data.Kind == entity.FieldKindFormula || data.Kind == entity.FieldKindRollup) {
+			resp = append(resp, fieldCode)
+		}
+	}
+
+	return resp, nil
+}
+
+// HandleRecordEvent stores the snapshot of a record after a mutation, the version serial is the event serial
+// so a redelivered event adds no version
+func (uc *versionUsecase) HandleRecordEvent(ctx context.Context, event entity.RecordEvent) (err error) {
+	// events emitted by rules do not change the record
+	if !event.IsRecordChange() {
+		return nil
+	}
+
+	snapshot := event.Data
+	if snapshot == nil {
+		snapshot = map[string]entity.DataItem{}
+	}
+
+	return uc.versionRepo.CreateRecordVersion(ctx, entity.RecordVersion{
+		Serial:       event.Serial,
+		TenantCode:   event.TenantCode,
+		ProductCode:  event.ProductCode,
+		ObjectCode:   event.ObjectCode,
+		RecordSerial: event.RecordSerial,
+		Action:       event.Action(),
+		ActorSerial:  event.ActorSerial,
+		IsDeleted:    event.Type == entity.RecordDeleted,
+		Data:         snapshot,
+		CreatedAt:    event.OccurredAt,
+	})
+}
@@ -0,0 +1,60 @@
+package module
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cerkas/cerkas-backend/config"
+	"github.com/cerkas/cerkas-backend/core/entity"
+)
+
+func TestRestoreRecordVersionBringsBackADeletedRecord(t *testing.T) {
+	catalogRepo := &fakeCatalogRepository{
+		records: map[string][]map[string]any{"invoice": {
+			{"serial": "i1", "name": "INV-001 changed", "total": 99.0, "deleted_at": time.Now()},
+		}},
+		objectFields: map[string]map[string]any{"invoice": {
+			"name":  entity.ObjectFields{Kind: "field"},
+			"total": entity.ObjectFields{Kind: entity.FieldKindFormula},
+			"lines": entity.ObjectFields{Kind: entity.FieldKindRollup},
+		}},
+	}
+	versionRepo := &fakeVersionRepository{versions: []entity.RecordVersion{{
+		ObjectCode:   "invoice",
+		RecordSerial: "i1",
+		Version:      1,
+		Data: map[string]entity.DataItem{
+			"serial":     {FieldCode: "serial", Value: "i1"},
+			"name":       {FieldCode: "name", Value: "INV-001"},
+			"total":      {FieldCode: "total", Value: 10.0},
+			"lines":      {FieldCode: "lines", Value: 2.0},
+			"updated_at": {FieldCode: "updated_at", Value: "2024-01-15T10:00:00Z"},
+		},
+	}}}
+	catalogUc := &fakeCatalogUsecase{catalogRepo: catalogRepo}
+	uc := NewVersionUsecase(config.Config{}, catalogRepo, versionRepo, fakeTransactor{}, catalogUc)
+
+	restored, err := uc.RestoreRecordVersion(context.Background(), entity.RestoreVersionRequest{TenantCode: "acme", ObjectCode: "invoice", RecordSerial: "i1", Version: 1})
+	if err != nil {
+		t.Fatalf("RestoreRecordVersion: %v", err)
+	}
+
+	if restored["name"].Value != "INV-001" {
+		t.Errorf("restored record is %+v, want the name of version 1", restored)
+	}
+
+	// computed and system fields are not written, the record computes them again
+	if len(catalogUc.mutations) != 1 || len(catalogUc.mutations[0].Items) != 1 || catalogUc.mutations[0].Items[0].FieldCode != "name" {
+		t.Errorf("restore wrote %+v, want only the name", catalogUc.mutations)
+	}
+}
+
+func TestRestoreRecordVersionRejectsADeleteVersion(t *testing.T) {
+	versionRepo := &fakeVersionRepository{versions: []entity.RecordVersion{{ObjectCode: "invoice", RecordSerial: "i1", Version: 2, IsDeleted: true}}}
+	uc := NewVersionUsecase(config.Config{}, &fakeCatalogRepository{}, versionRepo, fakeTransactor{}, nil)
+
+	if _, err := uc.RestoreRecordVersion(context.Background(), entity.RestoreVersionRequest{ObjectCode: "invoice", RecordSerial: "i1", Version: 2}); err == nil {
+		t.Fatalf("a delete version was restored")
+	}
+}
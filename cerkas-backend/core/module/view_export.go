@@ -0,0 +1,160 @@
+package module
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/cerkas/cerkas-backend/core/entity"
+)
+
+const exportPageSize = 500
+
+// exportView writes the records of the view of a scheduled job to a csv file under the export directory of the tenant
+func (uc *scheduleUsecase) exportView(ctx context.Context, job entity.ScheduledJob) (result string, err error) {
+	path, err := exportFilePath(uc.cfg.ScheduleExportDir, job.TenantCode, job.Config.Path)
+	if err != nil {
+		return "", err
+	}
+
+	exported, err := exportViewFile(ctx, uc.catalogUc, path, job.TenantCode, job.CreatedBy, entity.ExportViewPayload{
+		ProductCode:     job.Config.ProductCode,
+		ObjectCode:      job.Config.ObjectCode,
+		ViewContentCode: job.Config.ViewContentCode,
+		Filters:         job.Config.Filters,
+	}, nil)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("exported %v records of %v to %v", exported, job.Config.ObjectCode, path), nil
+}
+
+// exportFilePath resolves the path of an export inside the export directory of a tenant, with the timestamp
+// placeholder replaced by the current time
+func exportFilePath(exportDir, tenantCode, path string) (string, error) {
+	path = strings.ReplaceAll(path, entity.ScheduleTimestampPlaceholder, time.Now().UTC().Format("20060102T150405Z"))
+	if !isExportPath(path) {
+		return "", fmt.Errorf("%w: export path %v leaves the export directory", entity.ErrorScheduledJobConfigInvalid, path)
+	}
+
+	return filepath.Join(exportDir, tenantCode, path), nil
+}
+
+// exportViewFile writes the records of a view to a csv file, progress is called after every page when it is set and
+// stops the export when it fails. The file is written next to its destination first, so a reader never sees half an
+// export.
+func exportViewFile(ctx context.Context, catalogUc CatalogUsecase, path, tenantCode, userSerial string, request entity.ExportViewPayload, progress func(exported, total int) error) (exported int, err error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return 0, err
+	}
+
+	file, err := os.CreateTemp(filepath.Dir(path), ".export-*")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+
+	var fieldCodes []string
+	for page := 1; ; page++ {
+		resp, err := catalogUc.GetObjectData(ctx, entity.CatalogQuery{
+			TenantCode:      tenantCode,
+			ProductCode:     request.ProductCode,
+			ObjectCode:      request.ObjectCode,
+			ViewContentCode: request.ViewContentCode,
+			Filters:         request.Filters,
+			StructureType:   entity.StructureTypeList,
+			Page:            page,
+			PageSize:        exportPageSize,
+			UserSerial:      userSerial,
+		})
+		if err != nil {
+			return 0, err
+		}
+
+		for _, item := range resp.Items {
+			// the columns are the fields of the first record, with their names as header
+			if fieldCodes == nil {
+				fieldCodes = sortedKeys(item)
+
+				header := []string{}
+				for _, fieldCode := range fieldCodes {
+					header = append(header, exportHeader(item[fieldCode]))
+				}
+
+				if err := writer.Write(header); err != nil {
+					return 0, err
+				}
+			}
+
+			row := []string{}
+			for _, fieldCode := range fieldCodes {
+				row = append(row, exportValue(item[fieldCode]))
+			}
+
+			if err := writer.Write(row); err != nil {
+				return 0, err
+			}
+
+			exported++
+		}
+
+		if progress != nil {
+			if err := progress(exported, resp.TotalData); err != nil {
+				return 0, err
+			}
+		}
+
+		if page >= resp.TotalPage || len(resp.Items) == 0 {
+			break
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return 0, err
+	}
+
+	if err := file.Close(); err != nil {
+		return 0, err
+	}
+
+	if err := os.Rename(file.Name(), path); err != nil {
+		return 0, err
+	}
+
+	return exported, nil
+}
+
+// isExportPath reports whether a path names a file inside the export directory
+func isExportPath(path string) bool {
+	return path != "" && filepath.IsLocal(path) && !strings.HasSuffix(path, string(filepath.Separator))
+}
+
+func exportHeader(item entity.DataItem) string {
+	if item.FieldName != "" {
+		return item.FieldName
+	}
+
+	return item.FieldCode
+}
+
+func exportValue(item entity.DataItem) string {
+	value := item.DisplayValue
+	if value == nil {
+		value = item.Value
+	}
+
+	if value == nil {
+		return ""
+	}
+
+	return fmt.Sprintf("%v", value)
+}
@@ -0,0 +1,173 @@
+package module
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cerkas/cerkas-backend/core/entity"
+	"github.com/cerkas/cerkas-backend/pkg/helper"
+)
+
+// keys of the input metadata a form field gets on top of the table field keys
+const (
+	FormFieldRequired        = "required"
+	FormFieldReadonly        = "readonly"
+	FormFieldValidationRules = "validation_rules"
+	FormFieldDefaultValue    = "default_value"
+	FormFieldDisplayType     = "display_type"
+	FormFieldOptions         = "field_options"
+	FormFieldLookup          = "lookup"
+	FormFieldValue           = "value"
+)
+
+// fieldInputs returns the object fields of an object by field code, once per layout
+func (l *layoutContext) fieldInputs(objectCode string) (map[string]entity.ObjectFields, error) {
+	if inputs, ok := l.inputCache[objectCode]; ok {
+		return inputs, nil
+	}
+
+	if l.loadFieldInputs == nil {
+		return nil, nil
+	}
+
+	inputs, err := l.loadFieldInputs(l.ctx, objectCode)
+	if err != nil {
+		return nil, err
+	}
+
+	if l.inputCache == nil {
+		l.inputCache = map[string]map[string]entity.ObjectFields{}
+	}
+	l.inputCache[objectCode] = inputs
+
+	return inputs, nil
+}
+
+// formFieldInput adds the input metadata of an object field to a form field. The validation rules of the object field
+// override the ones of its data type, "required", "readonly" and "default" are read from the merged rules.
+func (l *layoutContext) formFieldInput(objectCode string, field map[string]any) (map[string]any, error) {
+	inputs, err := l.fieldInputs(objectCode)
+	if err != nil {
+		return nil, err
+	}
+
+	fieldCode, _ := field[entity.FieldColumnCode].(string)
+	objectField := inputs[fieldCode]
+
+	rules := fieldRules(objectField)
+
+	input := map[string]any{}
+	for key, value := range field {
+		input[key] = value
+	}
+
+	required, _ := rules[FormFieldRequired].(bool)
+	readonly := isReadonlyField(fieldCode, objectField, rules)
+
+	var defaultValue any = objectField.DefaultValue
+	if objectField.DefaultValue == "" {
+		defaultValue = rules["default"]
+	}
+
+	input[FormFieldRequired] = required
+	input[FormFieldReadonly] = readonly
+	input[FormFieldValidationRules] = rules
+	input[FormFieldDefaultValue] = defaultValue
+	input[FormFieldDisplayType] = objectField.DataType.DisplayType
+	input[FormFieldOptions] = objectField.DataType.FieldOptions
+
+	lookup, err := l.lookupHint(objectCode, field)
+	if err != nil {
+		return nil, err
+	}
+
+	if lookup != nil {
+		input[FormFieldLookup] = lookup
+	}
+
+	if l.record != nil && objectCode == l.request.ObjectCode {
+		input[FormFieldValue] = l.record[fieldCode].Value
+	}
+
+	return input, nil
+}
+
+// fieldRules merges the validation rules of an object field over the ones of its data type
+func fieldRules(objectField entity.ObjectFields) map[string]any {
+	rules := map[string]any{}
+	for key, value := range objectField.DataType.ValidationRules {
+		rules[key] = value
+	}
+	for key, value := range objectField.ValidationRules {
+		rules[key] = value
+	}
+
+	return rules
+}
+
+// isReadonlyField tells whether a field can not be written. Chained relation fields are shown from another object
+// and formula and rollup fields are computed.
+func isReadonlyField(fieldCode string, objectField entity.ObjectFields, rules map[string]any) bool {
+	readonly, _ := rules[FormFieldReadonly].(bool)
+
+	return readonly || objectField.IsSystem || objectField.Kind == entity.FieldKindFormula || objectField.Kind == entity.FieldKindRollup || strings.Contains(fieldCode, "__")
+}
+
+// lookupHint tells a form how to pick the record a reference field points to, fields without a reference get none
+func (l *layoutContext) lookupHint(objectCode string, field map[string]any) (map[string]any, error) {
+	targetObject, _ := field[entity.FieldForeignTableName].(string)
+	if targetObject == "" {
+		return nil, nil
+	}
+
+	valueField, _ := field[entity.FieldForeignColumnName].(string)
+	if valueField == "" {
+		valueField = "serial"
+	}
+
+	displayField := valueField
+	inputs, err := l.fieldInputs(targetObject)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, fieldCode := range sortedKeys(inputs) {
+		if inputs[fieldCode].IsDisplayName {
+			displayField = fieldCode
+			break
+		}
+	}
+
+	return map[string]any{
+		entity.OBJECT_CODE: targetObject,
+		"value_field":      valueField,
+		"display_field":    displayField,
+		"endpoint":         fmt.Sprintf("/t/%v/p/%v/o/%v/fields/%v/lookup", l.request.TenantCode, l.request.ProductCode, objectCode, field[entity.FieldColumnCode]),
+	}, nil
+}
+
+// formLayoutFields are the editable fields of the component object with their input metadata
+func formLayoutFields(layout *layoutContext, props map[string]any) ([]map[string]any, error) {
+	fields, err := objectLayoutFields(layout, props)
+	if err != nil {
+		return nil, err
+	}
+
+	objectCode := layout.componentObjectCode(props)
+
+	editableFields := []map[string]any{}
+	for _, field := range fields {
+		if fieldCode, _ := field[entity.FieldColumnCode].(string); helper.Contains(versionSystemFields, fieldCode) {
+			continue
+		}
+
+		input, err := layout.formFieldInput(objectCode, field)
+		if err != nil {
+			return nil, err
+		}
+
+		editableFields = append(editableFields, input)
+	}
+
+	return editableFields, nil
+}
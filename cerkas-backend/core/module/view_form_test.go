@@ -0,0 +1,140 @@
+package module
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/cerkas/cerkas-backend/core/entity"
+)
+
+func newTestFormLayout(record map[string]entity.DataItem) *layoutContext {
+	return &layoutContext{
+		ctx:     context.Background(),
+		request: entity.GetViewContentByKeysRequest{TenantCode: "acme", ProductCode: "crm", ObjectCode: "task", ViewContentCode: "all"},
+		loadFields: func(ctx context.Context, objectCode string) ([]map[string]any, error) {
+			return []map[string]any{
+				{entity.FieldColumnCode: "serial"},
+				{entity.FieldColumnCode: "name"},
+				{entity.FieldColumnCode: "total"},
+				{entity.FieldColumnCode: "owner", entity.FieldForeignTableName: "user"},
+			}, nil
+		},
+		loadFieldInputs: func(ctx context.Context, objectCode string) (map[string]entity.ObjectFields, error) {
+			if objectCode == "user" {
+				return map[string]entity.ObjectFields{
+					"email":     {FieldCode: "email"},
+					"full_name": {FieldCode: "full_name", IsDisplayName: true},
+				}, nil
+			}
+
+			return map[string]entity.ObjectFields{
+				"name": {
+					FieldCode:       "name",
+					DataType:        entity.DataType{DisplayType: "text", ValidationRules: map[string]any{"required": false, "max_length": 10.0}},
+					ValidationRules: map[string]any{"required": true},
+					DefaultValue:    "untitled",
+				},
+				"total": {FieldCode: "total", Kind: entity.FieldKindFormula, DataType: entity.DataType{ValidationRules: map[string]any{"default": 0.0}}},
+				"owner": {FieldCode: "owner"},
+			}, nil
+		},
+		record: record,
+	}
+}
+
+func resolveForm(t *testing.T, layout *layoutContext) map[string]map[string]any {
+	t.Helper()
+
+	resolved, err := NewLayoutRegistry().Resolve(layout, layoutConfig(t, `{"type":"form"}`))
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+
+	fields := map[string]map[string]any{}
+	for _, field := range resolved[entity.PROPS].(map[string]any)[entity.FIELDS].([]map[string]any) {
+		fields[field[entity.FieldColumnCode].(string)] = field
+	}
+
+	return fields
+}
+
+func TestFormFieldsCarryTheirInputMetadata(t *testing.T) {
+	fields := resolveForm(t, newTestFormLayout(map[string]entity.DataItem{"name": {Value: "Call back"}}))
+
+	if _, ok := fields["serial"]; ok {
+		t.Errorf("system field serial is in the form")
+	}
+
+	name := fields["name"]
+	if name[FormFieldRequired] != true || name[FormFieldReadonly] != false || name[FormFieldDefaultValue] != "untitled" || name[FormFieldDisplayType] != "text" {
+		t.Errorf("name = %v, want a required text input defaulting to untitled", name)
+	}
+
+	if rules := name[FormFieldValidationRules].(map[string]any); rules["max_length"] != 10.0 || rules["required"] != true {
+		t.Errorf("name rules = %v, want the field rules over the data type rules", rules)
+	}
+
+	if name[FormFieldValue] != "Call back" {
+		t.Errorf("name value = %v, want the value of the record", name[FormFieldValue])
+	}
+
+	total := fields["total"]
+	if total[FormFieldReadonly] != true || total[FormFieldDefaultValue] != 0.0 {
+		t.Errorf("total = %v, want a readonly formula defaulting to the data type default", total)
+	}
+
+	lookup, _ := fields["owner"][FormFieldLookup].(map[string]any)
+	if lookup[entity.OBJECT_CODE] != "user" || lookup["value_field"] != "serial" || lookup["display_field"] != "full_name" || lookup["endpoint"] != "/t/acme/p/crm/o/task/fields/owner/lookup" {
+		t.Errorf("owner lookup = %v, want a lookup of user shown by full_name", lookup)
+	}
+
+	if _, ok := fields["name"][FormFieldLookup]; ok {
+		t.Errorf("name has a lookup, want none for a field without a reference")
+	}
+}
+
+func TestFormWithoutARecordHasNoValues(t *testing.T) {
+	fields := resolveForm(t, newTestFormLayout(nil))
+
+	if _, ok := fields["name"][FormFieldValue]; ok {
+		t.Errorf("name has a value, want none without a record")
+	}
+}
+
+func TestFormFieldInputsFail(t *testing.T) {
+	layout := newTestFormLayout(nil)
+	errBroken := errors.New("broken")
+	layout.loadFieldInputs = func(ctx context.Context, objectCode string) (map[string]entity.ObjectFields, error) {
+		return nil, errBroken
+	}
+
+	_, err := NewLayoutRegistry().Resolve(layout, layoutConfig(t, `{"type":"form"}`))
+
+	var layoutError *LayoutError
+	if !errors.As(err, &layoutError) || layoutError.Path != "$.props.fields" || layoutError.Message != errBroken.Error() {
+		t.Errorf("Resolve = %v, want an error at the fields of the form", err)
+	}
+}
+
+func TestIsReadonlyField(t *testing.T) {
+	tests := []struct {
+		name        string
+		fieldCode   string
+		objectField entity.ObjectFields
+		rules       map[string]any
+		want        bool
+	}{
+		{"plain field", "name", entity.ObjectFields{}, map[string]any{}, false},
+		{"readonly rule", "name", entity.ObjectFields{}, map[string]any{"readonly": true}, true},
+		{"system field", "code", entity.ObjectFields{IsSystem: true}, map[string]any{}, true},
+		{"rollup field", "count", entity.ObjectFields{Kind: entity.FieldKindRollup}, map[string]any{}, true},
+		{"chained field", "owner__name", entity.ObjectFields{}, map[string]any{}, true},
+	}
+
+	for _, test := range tests {
+		if got := isReadonlyField(test.fieldCode, test.objectField, test.rules); got != test.want {
+			t.Errorf("%v: isReadonlyField = %v, want %v", test.name, got, test.want)
+		}
+	}
+}
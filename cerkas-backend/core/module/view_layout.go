@@ -0,0 +1,518 @@
+package module
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/cerkas/cerkas-backend/core/entity"
+)
+
+/*
+	example of view layout json:
+	{
+			"children": [
+					{
+							"class_name": "",
+							"props": {"fields": ["name", "email"]},
+							"type": "table"
+					}
+			],
+			"class_name": "",
+			"props": {},
+			"type": "webView"
+	}
+
+	every node has a type registered in the layout registry, containers hold children and are resolved recursively.
+	Leaf components get their fields, object keys and data_source injected into their props.
+*/
+
+// LayoutComponent declares a component type of the view layout
+type LayoutComponent struct {
+	Type string
+
+	// IsContainer components hold children
+	IsContainer bool
+
+	// Props are the known props with their json kind, RequiredProps must be set. Both are validated when the layout is loaded.
+	Props         map[string]string
+	RequiredProps []string
+
+	// Fields resolves the fields of the component, a component without Fields gets none
+	Fields func(layout *layoutContext, props map[string]any) (fields []map[string]any, err error)
+
+	// Data resolves how the component loads its data, it is injected as props.data_source
+	Data func(layout *layoutContext, props map[string]any) (dataSource map[string]any, err error)
+}
+
+// LayoutError is an invalid layout node, Path is the json path of the node
+type LayoutError struct {
+	Path    string
+	Message string
+}
+
+func (e *LayoutError) Error() string {
+	return fmt.Sprintf("invalid view layout at %v: %v", e.Path, e.Message)
+}
+
+// layoutContext is the view a layout is resolved for
+type layoutContext struct {
+	ctx     context.Context
+	request entity.GetViewContentByKeysRequest
+
+	// viewFields are the fields of the view object, limited to the view schema display fields when it has some
+	viewFields []map[string]any
+
+	// loadFields returns every field of an object
+	loadFields func(ctx context.Context, objectCode string) ([]map[string]any, error)
+
+	fieldCache map[string][]map[string]any
+
+	// loadFieldInputs returns the object fields of an object by field code, they carry the input settings of a form
+	loadFieldInputs func(ctx context.Context, objectCode string) (map[string]entity.ObjectFields, error)
+
+	inputCache map[string]map[string]entity.ObjectFields
+
+	// record holds the current values of the view object record a form edits, nil when no record is requested
+	record map[string]entity.DataItem
+}
+
+// objectFields returns every field of an object, once per layout
+func (l *layoutContext) objectFields(objectCode string) ([]map[string]any, error) {
+	if fields, ok := l.fieldCache[objectCode]; ok {
+		return fields, nil
+	}
+
+	if l.loadFields == nil {
+		return l.viewFields, nil
+	}
+
+	fields, err := l.loadFields(l.ctx, objectCode)
+	if err != nil {
+		return nil, err
+	}
+
+	if l.fieldCache == nil {
+		l.fieldCache = map[string][]map[string]any{}
+	}
+	l.fieldCache[objectCode] = fields
+
+	return fields, nil
+}
+
+// componentObjectCode returns the object of a component, the view object unless props.object_code says otherwise
+func (l *layoutContext) componentObjectCode(props map[string]any) string {
+	if objectCode, ok := props[entity.OBJECT_CODE].(string); ok && objectCode != "" {
+		return objectCode
+	}
+
+	return l.request.ObjectCode
+}
+
+type LayoutRegistry struct {
+	mu         sync.RWMutex
+	components map[string]LayoutComponent
+}
+
+// NewLayoutRegistry returns a registry with the built in components
+func NewLayoutRegistry() *LayoutRegistry {
+	registry := &LayoutRegistry{
+		components: map[string]LayoutComponent{},
+	}
+
+	for _, component := range defaultLayoutComponents() {
+		registry.Register(component)
+	}
+
+	return registry
+}
+
+// Register adds a component type, or replaces the registered one
+func (r *LayoutRegistry) Register(component LayoutComponent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.components[component.Type] = component
+}
+
+func (r *LayoutRegistry) component(componentType string) (LayoutComponent, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	component, ok := r.components[componentType]
+	return component, ok
+}
+
+// Validate checks a layout config against the schema of its components
+func (r *LayoutRegistry) Validate(layoutConfig map[string]any) error {
+	if layoutConfig == nil {
+		return nil
+	}
+
+	return r.validateNode(layoutConfig, "$")
+}
+
+func (r *LayoutRegistry) validateNode(node map[string]any, path string) error {
+	componentType, ok := node[entity.TYPE].(string)
+	if !ok || componentType == "" {
+		return &LayoutError{Path: path + ".type", Message: "type is required and must be a string"}
+	}
+
+	component, ok := r.component(componentType)
+	if !ok {
+		return &LayoutError{Path: path + ".type", Message: fmt.Sprintf("unknown component type %q", componentType)}
+	}
+
+	if className, exists := node[entity.CLASS_NAME]; exists && className != nil {
+		if _, ok := className.(string); !ok {
+			return &LayoutError{Path: path + ".class_name", Message: "class_name must be a string"}
+		}
+	}
+
+	props := map[string]any{}
+	if rawProps, exists := node[entity.PROPS]; exists && rawProps != nil {
+		if props, ok = rawProps.(map[string]any); !ok {
+			return &LayoutError{Path: path + ".props", Message: "props must be an object"}
+		}
+	}
+
+	for _, propName := range component.RequiredProps {
+		if value, exists := props[propName]; !exists || value == nil || value == "" {
+			return &LayoutError{Path: fmt.Sprintf("%v.props.%v", path, propName), Message: fmt.Sprintf("%v requires %v", componentType, propName)}
+		}
+	}
+
+	for propName, kind := range component.Props {
+		value, exists := props[propName]
+		if !exists || value == nil {
+			continue
+		}
+
+		if !isPropKind(value, kind) {
+			return &LayoutError{Path: fmt.Sprintf("%v.props.%v", path, propName), Message: fmt.Sprintf("%v must be a %v", propName, kind)}
+		}
+	}
+
+	rawChildren, exists := node[entity.CHILDREN]
+	if !exists || rawChildren == nil {
+		return nil
+	}
+
+	children, ok := rawChildren.([]any)
+	if !ok {
+		return &LayoutError{Path: path + ".children", Message: "children must be an array"}
+	}
+
+	if len(children) > 0 && !component.IsContainer {
+		return &LayoutError{Path: path + ".children", Message: fmt.Sprintf("%v can not have children", componentType)}
+	}
+
+	for i, rawChild := range children {
+		childPath := fmt.Sprintf("%v.children[%d]", path, i)
+
+		child, ok := rawChild.(map[string]any)
+		if !ok {
+			return &LayoutError{Path: childPath, Message: "child must be an object"}
+		}
+
+		if err := r.validateNode(child, childPath); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Resolve injects the fields, object keys and data source of every component into a layout config checked by Validate
+func (r *LayoutRegistry) Resolve(layout *layoutContext, layoutConfig map[string]any) (map[string]any, error) {
+	if layoutConfig == nil {
+		return nil, nil
+	}
+
+	if err := r.resolveNode(layout, layoutConfig, "$"); err != nil {
+		return nil, err
+	}
+
+	return layoutConfig, nil
+}
+
+func (r *LayoutRegistry) resolveNode(layout *layoutContext, node map[string]any, path string) error {
+	componentType := node[entity.TYPE].(string)
+	component, _ := r.component(componentType)
+
+	if component.IsContainer {
+		children, _ := node[entity.CHILDREN].([]any)
+		for i, child := range children {
+			if err := r.resolveNode(layout, child.(map[string]any), fmt.Sprintf("%v.children[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	props, _ := node[entity.PROPS].(map[string]any)
+	if props == nil {
+		props = map[string]any{}
+	}
+
+	objectCode := layout.componentObjectCode(props)
+
+	if className, _ := node[entity.CLASS_NAME].(string); className == "" {
+		node[entity.CLASS_NAME] = fmt.Sprintf("%s__%s", componentType, objectCode)
+	}
+
+	if component.Fields != nil {
+		fields, err := component.Fields(layout, props)
+		if err != nil {
+			return &LayoutError{Path: path + ".props.fields", Message: err.Error()}
+		}
+
+		props[entity.FIELDS] = fields
+	}
+
+	if component.Data != nil {
+		dataSource, err := component.Data(layout, props)
+		if err != nil {
+			return &LayoutError{Path: path + ".props.data_source", Message: err.Error()}
+		}
+
+		props[entity.DATA_SOURCE] = dataSource
+	}
+
+	props[entity.OBJECT_CODE] = objectCode
+	props[entity.TENANT_CODE] = layout.request.TenantCode
+	node[entity.PROPS] = props
+
+	return nil
+}
+
+func defaultLayoutComponents() []LayoutComponent {
+	listProps := map[string]string{
+		entity.OBJECT_CODE: entity.PropString,
+		entity.FIELDS:      entity.PropArray,
+		"page_size":        entity.PropNumber,
+	}
+
+	return []LayoutComponent{
+		{Type: entity.LayoutWebView, IsContainer: true},
+		{Type: entity.LayoutMobileView, IsContainer: true},
+		{Type: entity.LayoutSection, IsContainer: true, Props: map[string]string{"title": entity.PropString}},
+		{Type: entity.LayoutTabs, IsContainer: true, Props: map[string]string{"tabs": entity.PropArray, "active_tab": entity.PropNumber}},
+		{
+			Type:   entity.LayoutTable,
+			Props:  listProps,
+			Fields: viewLayoutFields,
+			Data:   layoutDataSource("list"),
+		},
+		{
+			Type:   entity.LayoutDetail,
+			Props:  map[string]string{entity.OBJECT_CODE: entity.PropString, entity.FIELDS: entity.PropArray},
+			Fields: objectLayoutFields,
+			Data:   layoutDataSource("detail"),
+		},
+		{
+			Type:   entity.LayoutForm,
+			Props:  map[string]string{entity.OBJECT_CODE: entity.PropString, entity.FIELDS: entity.PropArray},
+			Fields: formLayoutFields,
+			Data:   layoutDataSource("form"),
+		},
+		{
+			Type:          entity.LayoutKanban,
+			Props:         mergeProps(listProps, map[string]string{"group_by": entity.PropString}),
+			RequiredProps: []string{"group_by"},
+			Fields:        viewLayoutFields,
+			Data:          layoutDataSource("kanban", "group_by"),
+		},
+		{
+			Type:          entity.LayoutCalendar,
+			Props:         mergeProps(listProps, map[string]string{"start_field": entity.PropString, "end_field": entity.PropString}),
+			RequiredProps: []string{"start_field"},
+			Fields:        viewLayoutFields,
+			Data:          layoutDataSource("calendar", "start_field", "end_field"),
+		},
+		{
+			Type: entity.LayoutChart,
+			Props: map[string]string{
+				entity.OBJECT_CODE: entity.PropString,
+				"chart_type":       entity.PropString,
+				"x_field":          entity.PropString,
+				"y_field":          entity.PropString,
+				"aggregate":        entity.PropString,
+			},
+			RequiredProps: []string{"chart_type", "x_field"},
+			Fields:        chartLayoutFields,
+			Data:          layoutDataSource("aggregate", "x_field", "y_field", "aggregate"),
+		},
+		{
+			Type: entity.LayoutRelatedList,
+			Props: mergeProps(listProps, map[string]string{
+				"relation_field": entity.PropString,
+				"title":          entity.PropString,
+			}),
+			RequiredProps: []string{entity.OBJECT_CODE, "relation_field"},
+			Fields:        objectLayoutFields,
+			Data:          layoutDataSource("include", "relation_field"),
+		},
+	}
+}
+
+// viewLayoutFields are the fields of a list of the view object, the view schema decides them unless props.fields does.
+// Lists of other objects get every field of that object.
+func viewLayoutFields(layout *layoutContext, props map[string]any) ([]map[string]any, error) {
+	objectCode := layout.componentObjectCode(props)
+	if objectCode != layout.request.ObjectCode {
+		return objectLayoutFields(layout, props)
+	}
+
+	fields := layout.viewFields
+	if _, ok := props[entity.FIELDS]; ok {
+		allFields, err := layout.objectFields(objectCode)
+		if err != nil {
+			return nil, err
+		}
+
+		fields = allFields
+	}
+
+	return selectLayoutFields(fields, props)
+}
+
+// objectLayoutFields are every field of the component object, or the ones listed in props.fields
+func objectLayoutFields(layout *layoutContext, props map[string]any) ([]map[string]any, error) {
+	fields, err := layout.objectFields(layout.componentObjectCode(props))
+	if err != nil {
+		return nil, err
+	}
+
+	return selectLayoutFields(fields, props)
+}
+
+// chartLayoutFields are the dimension and measure fields of a chart
+func chartLayoutFields(layout *layoutContext, props map[string]any) ([]map[string]any, error) {
+	chartProps := map[string]any{}
+	fieldCodes := []any{}
+	for _, propName := range []string{"x_field", "y_field"} {
+		if fieldCode, ok := props[propName].(string); ok && fieldCode != "" {
+			fieldCodes = append(fieldCodes, fieldCode)
+		}
+	}
+
+	chartProps[entity.OBJECT_CODE] = props[entity.OBJECT_CODE]
+	chartProps[entity.FIELDS] = fieldCodes
+
+	return objectLayoutFields(layout, chartProps)
+}
+
+// selectLayoutFields keeps the fields listed in props.fields in their order, every field is kept when none is listed
+func selectLayoutFields(fields []map[string]any, props map[string]any) ([]map[string]any, error) {
+	rawFieldCodes, ok := props[entity.FIELDS].([]any)
+	if !ok {
+		return fields, nil
+	}
+
+	fieldMap := make(map[string]map[string]any, len(fields))
+	for _, field := range fields {
+		if fieldCode, ok := field[entity.FieldColumnCode].(string); ok {
+			fieldMap[fieldCode] = field
+		}
+	}
+
+	selectedFields := []map[string]any{}
+	for i, rawFieldCode := range rawFieldCodes {
+		fieldCode, ok := rawFieldCode.(string)
+		if !ok {
+			// a previously resolved field list, keep it as is
+			if field, ok := rawFieldCode.(map[string]any); ok {
+				selectedFields = append(selectedFields, field)
+				continue
+			}
+
+			return nil, fmt.Errorf("fields[%d] must be a field code", i)
+		}
+
+		field, ok := fieldMap[fieldCode]
+		if !ok {
+			return nil, fmt.Errorf("unknown field %v, expected one of %v", fieldCode, strings.Join(sortedKeys(fieldMap), ", "))
+		}
+
+		selectedFields = append(selectedFields, field)
+	}
+
+	return selectedFields, nil
+}
+
+// layoutDataSource returns a resolver of the data source of a component with the given mode, carrying the listed props
+func layoutDataSource(mode string, propNames ...string) func(layout *layoutContext, props map[string]any) (map[string]any, error) {
+	return func(layout *layoutContext, props map[string]any) (map[string]any, error) {
+		dataSource := map[string]any{
+			"mode":              mode,
+			entity.TENANT_CODE:  layout.request.TenantCode,
+			entity.PRODUCT_CODE: layout.request.ProductCode,
+			entity.OBJECT_CODE:  layout.componentObjectCode(props),
+			"view_content_code": layout.request.ViewContentCode,
+			"is_view_object":    layout.componentObjectCode(props) == layout.request.ObjectCode,
+		}
+
+		for _, propName := range propNames {
+			if value, ok := props[propName]; ok {
+				dataSource[propName] = value
+			}
+		}
+
+		if pageSize, ok := props["page_size"]; ok {
+			dataSource["page_size"] = pageSize
+		}
+
+		return dataSource, nil
+	}
+}
+
+func isPropKind(value any, kind string) bool {
+	switch kind {
+	case entity.PropString:
+		_, ok := value.(string)
+		return ok
+	case entity.PropNumber:
+		switch value.(type) {
+		case float64, float32, int, int32, int64:
+			return true
+		}
+		return false
+	case entity.PropBoolean:
+		_, ok := value.(bool)
+		return ok
+	case entity.PropArray:
+		_, ok := value.([]any)
+		return ok
+	case entity.PropObject:
+		_, ok := value.(map[string]any)
+		return ok
+	}
+
+	return true
+}
+
+func mergeProps(props ...map[string]string) map[string]string {
+	result := map[string]string{}
+	for _, prop := range props {
+		for key, value := range prop {
+			result[key] = value
+		}
+	}
+
+	return result
+}
+
+func sortedKeys[T any](values map[string]T) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,166 @@
+package module
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/cerkas/cerkas-backend/core/entity"
+)
+
+func layoutConfig(t *testing.T, raw string) map[string]any {
+	t.Helper()
+
+	config := map[string]any{}
+	if err := json.Unmarshal([]byte(raw), &config); err != nil {
+		t.Fatalf("layout %v: %v", raw, err)
+	}
+
+	return config
+}
+
+func layoutFieldCodes(fields any) []string {
+	codes := []string{}
+	list, _ := fields.([]map[string]any)
+	for _, field := range list {
+		codes = append(codes, field[entity.FieldColumnCode].(string))
+	}
+
+	return codes
+}
+
+func TestValidateLayout(t *testing.T) {
+	tests := []struct {
+		name     string
+		layout   string
+		wantPath string
+	}{
+		{"valid", `{"type":"webView","children":[{"type":"section","children":[{"type":"table","props":{"fields":["name"]}}]}]}`, ""},
+		{"missing type", `{"children":[]}`, "$.type"},
+		{"unknown type", `{"type":"webView","children":[{"type":"carousel"}]}`, "$.children[0].type"},
+		{"props not an object", `{"type":"table","props":[]}`, "$.props"},
+		{"missing required prop", `{"type":"kanban","props":{}}`, "$.props.group_by"},
+		{"prop of the wrong kind", `{"type":"table","props":{"page_size":"ten"}}`, "$.props.page_size"},
+		{"children of a leaf", `{"type":"table","children":[{"type":"table"}]}`, "$.children"},
+		{"child not an object", `{"type":"webView","children":["table"]}`, "$.children[0]"},
+		{"class name not a string", `{"type":"webView","class_name":1}`, "$.class_name"},
+	}
+
+	registry := NewLayoutRegistry()
+	for _, test := range tests {
+		err := registry.Validate(layoutConfig(t, test.layout))
+		if test.wantPath == "" {
+			if err != nil {
+				t.Errorf("%v: Validate = %v", test.name, err)
+			}
+			continue
+		}
+
+		var layoutError *LayoutError
+		if !errors.As(err, &layoutError) || layoutError.Path != test.wantPath {
+			t.Errorf("%v: Validate = %v, want an error at %v", test.name, err, test.wantPath)
+		}
+	}
+}
+
+func TestRegisteredComponentsAreValidated(t *testing.T) {
+	registry := NewLayoutRegistry()
+	registry.Register(LayoutComponent{Type: "map", Props: map[string]string{"zoom": entity.PropNumber}, RequiredProps: []string{"zoom"}})
+
+	if err := registry.Validate(layoutConfig(t, `{"type":"map","props":{"zoom":3}}`)); err != nil {
+		t.Errorf("registered component is rejected: %v", err)
+	}
+
+	if err := registry.Validate(layoutConfig(t, `{"type":"map","props":{}}`)); err == nil {
+		t.Errorf("registered component is accepted without its required prop")
+	}
+}
+
+func TestResolveLayout(t *testing.T) {
+	field := func(code string) map[string]any { return map[string]any{entity.FieldColumnCode: code} }
+	loads := map[string]int{}
+	layout := &layoutContext{
+		ctx:        context.Background(),
+		request:    entity.GetViewContentByKeysRequest{TenantCode: "acme", ProductCode: "crm", ObjectCode: "invoice", ViewContentCode: "all"},
+		viewFields: []map[string]any{field("name"), field("total")},
+		loadFields: func(ctx context.Context, objectCode string) ([]map[string]any, error) {
+			loads[objectCode]++
+			if objectCode == "invoice" {
+				return []map[string]any{field("name"), field("total"), field("status")}, nil
+			}
+			return []map[string]any{field("product"), field("quantity")}, nil
+		},
+	}
+
+	config := layoutConfig(t, `{"type":"webView","children":[
+		{"type":"table"},
+		{"type":"kanban","props":{"group_by":"status","fields":["status","name"]}},
+		{"type":"related-list","props":{"object_code":"invoice_line","relation_field":"invoice","fields":["product"]}},
+		{"type":"detail","props":{"object_code":"invoice_line"}}
+	]}`)
+
+	registry := NewLayoutRegistry()
+	if err := registry.Validate(config); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+
+	resolved, err := registry.Resolve(layout, config)
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+
+	children := resolved[entity.CHILDREN].([]any)
+	props := func(i int) map[string]any { return children[i].(map[string]any)[entity.PROPS].(map[string]any) }
+
+	tests := []struct {
+		name   string
+		props  map[string]any
+		fields string
+		mode   string
+	}{
+		{"table gets the view fields", props(0), "[name total]", "list"},
+		{"kanban keeps the listed fields in order", props(1), "[status name]", "kanban"},
+		{"related list gets fields of its object", props(2), "[product]", "include"},
+		{"detail of another object gets all its fields", props(3), "[product quantity]", "detail"},
+	}
+
+	for _, test := range tests {
+		if got := fmt.Sprint(layoutFieldCodes(test.props[entity.FIELDS])); got != test.fields {
+			t.Errorf("%v: fields %v, want %v", test.name, got, test.fields)
+		}
+
+		dataSource := test.props[entity.DATA_SOURCE].(map[string]any)
+		if dataSource["mode"] != test.mode || dataSource[entity.TENANT_CODE] != "acme" || dataSource["view_content_code"] != "all" {
+			t.Errorf("%v: data source %v, want mode %v", test.name, dataSource, test.mode)
+		}
+	}
+
+	if props(1)[entity.DATA_SOURCE].(map[string]any)["group_by"] != "status" {
+		t.Errorf("kanban data source does not carry group_by")
+	}
+
+	if className := children[2].(map[string]any)[entity.CLASS_NAME]; className != "related-list__invoice_line" {
+		t.Errorf("related list class name is %v", className)
+	}
+
+	if loads["invoice_line"] != 1 {
+		t.Errorf("fields of invoice_line were loaded %v times, want once", loads["invoice_line"])
+	}
+}
+
+func TestResolveLayoutRejectsUnknownFields(t *testing.T) {
+	layout := &layoutContext{
+		ctx:        context.Background(),
+		request:    entity.GetViewContentByKeysRequest{ObjectCode: "invoice"},
+		viewFields: []map[string]any{{entity.FieldColumnCode: "name"}},
+	}
+
+	_, err := NewLayoutRegistry().Resolve(layout, layoutConfig(t, `{"type":"webView","children":[{"type":"table","props":{"fields":["secret"]}}]}`))
+
+	var layoutError *LayoutError
+	if !errors.As(err, &layoutError) || layoutError.Path != "$.children[0].props.fields" {
+		t.Fatalf("Resolve = %v, want an error at the fields of the table", err)
+	}
+}
@@ -0,0 +1,679 @@
+package module
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/cerkas/cerkas-backend/core/entity"
+	"github.com/cerkas/cerkas-backend/pkg/helper"
+)
+
+// CreateViewContent adds a view to an object. The first view of a layout type becomes its default. Only an admin
+// adds a shared view or a view owned by someone else.
+func (uc *viewUsecase) CreateViewContent(ctx context.Context, record entity.ViewContentRecord, userSerial, userRole string) (resp entity.ViewContentRecord, err error) {
+	if (record.OwnerSerial == "" || record.OwnerSerial != userSerial) && !isAdmin(uc.cfg, userRole) {
+		return resp, entity.ErrorViewForbidden
+	}
+
+	err = uc.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		resp, err = uc.createViewContent(ctx, record, userSerial)
+		return err
+	})
+
+	return resp, err
+}
+
+// UpdateViewContent replaces the editable fields of a view, the keys and the user of request find the view
+func (uc *viewUsecase) UpdateViewContent(ctx context.Context, request entity.GetViewContentByKeysRequest, record entity.ViewContentRecord) (resp entity.ViewContentRecord, err error) {
+	userSerial := request.UserSerial
+
+	err = uc.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		current, err := uc.getWritableViewContent(ctx, request)
+		if err != nil {
+			return err
+		}
+
+		// the keys and the owner of a view can not move, only its code can be renamed
+		record.Serial = current.Serial
+		record.OwnerSerial = current.OwnerSerial
+		record.TenantCode = current.TenantCode
+		record.ProductCode = current.ProductCode
+		record.ObjectCode = current.ObjectCode
+		record.LayoutType = current.LayoutType
+
+		record.Code = strings.TrimSpace(record.Code)
+		if record.Code == "" {
+			record.Code = current.Code
+		}
+
+		if record.Code != current.Code {
+			if err := uc.checkViewCode(ctx, record); err != nil {
+				return err
+			}
+		}
+
+		if current.IsDefault && !record.IsDefault {
+			return entity.ErrorViewDefaultRequired
+		}
+
+		if err := uc.checkViewReferences(ctx, record); err != nil {
+			return err
+		}
+
+		resp, err = uc.viewRepo.UpdateViewContent(ctx, record, userSerial)
+		if err != nil {
+			return err
+		}
+
+		if record.IsDefault && !current.IsDefault {
+			return uc.viewRepo.SetDefaultViewContent(ctx, resp, userSerial)
+		}
+
+		return nil
+	})
+
+	return resp, err
+}
+
+// DeleteViewContent removes a view, the oldest remaining view of the layout type takes over a deleted default
+func (uc *viewUsecase) DeleteViewContent(ctx context.Context, request entity.GetViewContentByKeysRequest) (err error) {
+	userSerial := request.UserSerial
+
+	return uc.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		current, err := uc.getWritableViewContent(ctx, request)
+		if err != nil {
+			return err
+		}
+
+		if err := uc.viewRepo.DeleteViewContent(ctx, current.Serial, userSerial); err != nil {
+			return err
+		}
+
+		if !current.IsDefault {
+			return nil
+		}
+
+		remaining, err := uc.viewRepo.GetViewContents(ctx, current.TenantCode, current.ProductCode, current.ObjectCode, current.LayoutType, current.OwnerSerial)
+		if err != nil || len(remaining) == 0 {
+			return err
+		}
+
+		return uc.viewRepo.SetDefaultViewContent(ctx, remaining[0], userSerial)
+	})
+}
+
+// CloneViewContent copies a view together with its schema, so the copy can be edited on its own. The layout is shared.
+// The copy a user other than an admin makes of a shared view is a personal view.
+func (uc *viewUsecase) CloneViewContent(ctx context.Context, request entity.GetViewContentByKeysRequest, clone entity.CloneViewRequest) (resp entity.ViewContentRecord, err error) {
+	err = uc.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		current, err := uc.getOwnViewContent(ctx, request, clone.UserSerial)
+		if err != nil {
+			return err
+		}
+
+		record := current
+		if !isAdmin(uc.cfg, clone.UserRole) {
+			if clone.UserSerial == "" {
+				return entity.ErrorViewUserEmpty
+			}
+
+			record.OwnerSerial = clone.UserSerial
+		}
+
+		record.Serial = ""
+		record.Code = clone.Code
+		record.Name = cloneName(clone.Name, current.Name)
+		record.IsDefault = false
+
+		if current.ViewSchemaSerial != "" {
+			schema, err := uc.viewRepo.GetViewSchema(ctx, current.ViewSchemaSerial)
+			if err != nil {
+				return err
+			}
+
+			schema, err = uc.cloneViewSchema(ctx, schema, clone)
+			if err != nil {
+				return err
+			}
+
+			record.ViewSchemaSerial = schema.Serial
+		}
+
+		resp, err = uc.createViewContent(ctx, record, clone.UserSerial)
+		return err
+	})
+
+	return resp, err
+}
+
+// CreateViewSchema adds a schema to an object, its query and display fields must refer to fields of the object
+func (uc *viewUsecase) CreateViewSchema(ctx context.Context, tenantCode, objectCode string, schema entity.ViewSchema, userSerial, userRole string) (resp entity.ViewSchema, err error) {
+	if !isAdmin(uc.cfg, userRole) {
+		return resp, entity.ErrorViewForbidden
+	}
+
+	return uc.createViewSchema(ctx, tenantCode, objectCode, schema, userSerial)
+}
+
+func (uc *viewUsecase) createViewSchema(ctx context.Context, tenantCode, objectCode string, schema entity.ViewSchema, userSerial string) (resp entity.ViewSchema, err error) {
+	object, err := uc.catalogRepo.GetObjectByCode(ctx, objectCode, tenantCode)
+	if err != nil {
+		return resp, entity.ErrorNotFound
+	}
+
+	if err := uc.validateViewSchema(ctx, tenantCode, objectCode, schema); err != nil {
+		return resp, err
+	}
+
+	schema.Serial, err = helper.GenerateUUUID()
+	if err != nil {
+		return resp, err
+	}
+
+	schema.ObjectSerial = object.Serial
+
+	return uc.viewRepo.CreateViewSchema(ctx, schema, userSerial)
+}
+
+func (uc *viewUsecase) UpdateViewSchema(ctx context.Context, tenantCode, objectCode string, schema entity.ViewSchema, userSerial, userRole string) (resp entity.ViewSchema, err error) {
+	if !isAdmin(uc.cfg, userRole) {
+		return resp, entity.ErrorViewForbidden
+	}
+
+	if _, err := uc.getObjectViewSchema(ctx, tenantCode, objectCode, schema.Serial); err != nil {
+		return resp, err
+	}
+
+	if err := uc.validateViewSchema(ctx, tenantCode, objectCode, schema); err != nil {
+		return resp, err
+	}
+
+	return uc.viewRepo.UpdateViewSchema(ctx, schema, userSerial)
+}
+
+// DeleteViewSchema removes a schema no view uses anymore
+func (uc *viewUsecase) DeleteViewSchema(ctx context.Context, tenantCode, objectCode, serial, userSerial, userRole string) (err error) {
+	if !isAdmin(uc.cfg, userRole) {
+		return entity.ErrorViewForbidden
+	}
+
+	if _, err := uc.getObjectViewSchema(ctx, tenantCode, objectCode, serial); err != nil {
+		return err
+	}
+
+	used, err := uc.viewRepo.IsViewSchemaUsed(ctx, serial)
+	if err != nil {
+		return err
+	}
+
+	if used {
+		return entity.ErrorViewInUse
+	}
+
+	return uc.viewRepo.DeleteViewSchema(ctx, serial, userSerial)
+}
+
+func (uc *viewUsecase) CloneViewSchema(ctx context.Context, tenantCode, objectCode, serial string, clone entity.CloneViewRequest) (resp entity.ViewSchema, err error) {
+	if !isAdmin(uc.cfg, clone.UserRole) {
+		return resp, entity.ErrorViewForbidden
+	}
+
+	schema, err := uc.getObjectViewSchema(ctx, tenantCode, objectCode, serial)
+	if err != nil {
+		return resp, err
+	}
+
+	return uc.cloneViewSchema(ctx, schema, clone)
+}
+
+// CreateViewLayout adds a layout, its config must pass the layout component registry
+func (uc *viewUsecase) CreateViewLayout(ctx context.Context, layout entity.ViewLayout, userSerial, userRole string) (resp entity.ViewLayout, err error) {
+	if !isAdmin(uc.cfg, userRole) {
+		return resp, entity.ErrorViewForbidden
+	}
+
+	if strings.TrimSpace(layout.Code) == "" {
+		return resp, entity.ErrorViewCodeEmpty
+	}
+
+	if err := uc.layouts.Validate(layout.LayoutConfig); err != nil {
+		return resp, err
+	}
+
+	layout.Serial, err = helper.GenerateUUUID()
+	if err != nil {
+		return resp, err
+	}
+
+	return uc.viewRepo.CreateViewLayout(ctx, layout, userSerial)
+}
+
+func (uc *viewUsecase) UpdateViewLayout(ctx context.Context, layout entity.ViewLayout, userSerial, userRole string) (resp entity.ViewLayout, err error) {
+	if !isAdmin(uc.cfg, userRole) {
+		return resp, entity.ErrorViewForbidden
+	}
+
+	current, err := uc.viewRepo.GetViewLayout(ctx, layout.Serial)
+	if err != nil {
+		return resp, err
+	}
+
+	if strings.TrimSpace(layout.Code) == "" {
+		layout.Code = current.Code
+	}
+
+	if err := uc.layouts.Validate(layout.LayoutConfig); err != nil {
+		return resp, err
+	}
+
+	return uc.viewRepo.UpdateViewLayout(ctx, layout, userSerial)
+}
+
+// DeleteViewLayout removes a layout no view uses anymore
+func (uc *viewUsecase) DeleteViewLayout(ctx context.Context, serial, userSerial, userRole string) (err error) {
+	if !isAdmin(uc.cfg, userRole) {
+		return entity.ErrorViewForbidden
+	}
+
+	used, err := uc.viewRepo.IsViewLayoutUsed(ctx, serial)
+	if err != nil {
+		return err
+	}
+
+	if used {
+		return entity.ErrorViewInUse
+	}
+
+	return uc.viewRepo.DeleteViewLayout(ctx, serial, userSerial)
+}
+
+func (uc *viewUsecase) CloneViewLayout(ctx context.Context, serial string, clone entity.CloneViewRequest) (resp entity.ViewLayout, err error) {
+	layout, err := uc.viewRepo.GetViewLayout(ctx, serial)
+	if err != nil {
+		return resp, err
+	}
+
+	layout.Code = clone.Code
+
+	return uc.CreateViewLayout(ctx, layout, clone.UserSerial, clone.UserRole)
+}
+
+// createViewContent validates and writes a new view, ctx carries the transaction
+func (uc *viewUsecase) createViewContent(ctx context.Context, record entity.ViewContentRecord, userSerial string) (resp entity.ViewContentRecord, err error) {
+	record.Code = strings.TrimSpace(record.Code)
+	if record.Code == "" {
+		return resp, entity.ErrorViewCodeEmpty
+	}
+
+	if record.LayoutType == "" {
+		return resp, entity.ErrorViewLayoutTypeEmpty
+	}
+
+	if err := uc.checkViewCode(ctx, record); err != nil {
+		return resp, err
+	}
+
+	if err := uc.checkViewReferences(ctx, record); err != nil {
+		return resp, err
+	}
+
+	siblings, err := uc.viewRepo.GetViewContents(ctx, record.TenantCode, record.ProductCode, record.ObjectCode, record.LayoutType, record.OwnerSerial)
+	if err != nil {
+		return resp, err
+	}
+
+	hasDefault := false
+	for _, sibling := range siblings {
+		hasDefault = hasDefault || sibling.IsDefault
+	}
+
+	// personal views fall back to the shared default, they only become a default when asked to
+	if !hasDefault && record.OwnerSerial == "" {
+		record.IsDefault = true
+	}
+
+	record.Serial, err = helper.GenerateUUUID()
+	if err != nil {
+		return resp, err
+	}
+
+	resp, err = uc.viewRepo.CreateViewContent(ctx, record, userSerial)
+	if err != nil {
+		return resp, err
+	}
+
+	if record.IsDefault && hasDefault {
+		return resp, uc.viewRepo.SetDefaultViewContent(ctx, resp, userSerial)
+	}
+
+	return resp, nil
+}
+
+// getOwnViewContent returns a view the user may change, the personal views of other users are not found
+func (uc *viewUsecase) getOwnViewContent(ctx context.Context, request entity.GetViewContentByKeysRequest, userSerial string) (resp entity.ViewContentRecord, err error) {
+	resp, err = uc.viewRepo.GetViewContent(ctx, request.TenantCode, request.ProductCode, request.ObjectCode, request.ViewContentCode, request.LayoutType)
+	if err != nil {
+		return resp, err
+	}
+
+	if resp.OwnerSerial != "" && resp.OwnerSerial != userSerial {
+		return entity.ViewContentRecord{}, entity.ErrorNotFound
+	}
+
+	return resp, nil
+}
+
+// getWritableViewContent returns a view the user of request may change, the shared views are changed by an admin only
+func (uc *viewUsecase) getWritableViewContent(ctx context.Context, request entity.GetViewContentByKeysRequest) (resp entity.ViewContentRecord, err error) {
+	resp, err = uc.getOwnViewContent(ctx, request, request.UserSerial)
+	if err != nil {
+		return resp, err
+	}
+
+	if resp.OwnerSerial == "" && !isAdmin(uc.cfg, request.UserRole) {
+		return entity.ViewContentRecord{}, entity.ErrorViewForbidden
+	}
+
+	return resp, nil
+}
+
+// checkViewCode fails when another view of the same layout type already has the code of record
+func (uc *viewUsecase) checkViewCode(ctx context.Context, record entity.ViewContentRecord) (err error) {
+	_, err = uc.viewRepo.GetViewContent(ctx, record.TenantCode, record.ProductCode, record.ObjectCode, record.Code, record.LayoutType)
+	if err == nil {
+		return entity.ErrorViewCodeExists
+	}
+
+	if errors.Is(err, entity.ErrorNotFound) {
+		return nil
+	}
+
+	return err
+}
+
+// checkViewReferences fails when the schema or the layout of a view does not exist
+func (uc *viewUsecase) checkViewReferences(ctx context.Context, record entity.ViewContentRecord) (err error) {
+	if record.ViewSchemaSerial != "" {
+		if _, err := uc.getObjectViewSchema(ctx, record.TenantCode, record.ObjectCode, record.ViewSchemaSerial); err != nil {
+			return err
+		}
+	}
+
+	if record.ViewLayoutSerial != "" {
+		if _, err := uc.viewRepo.GetViewLayout(ctx, record.ViewLayoutSerial); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// getObjectViewSchema returns a schema of an object, a schema of another object is not found
+func (uc *viewUsecase) getObjectViewSchema(ctx context.Context, tenantCode, objectCode, serial string) (resp entity.ViewSchema, err error) {
+	object, err := uc.catalogRepo.GetObjectByCode(ctx, objectCode, tenantCode)
+	if err != nil {
+		return resp, entity.ErrorNotFound
+	}
+
+	resp, err = uc.viewRepo.GetViewSchema(ctx, serial)
+	if err != nil {
+		return resp, err
+	}
+
+	if resp.ObjectSerial != object.Serial {
+		return entity.ViewSchema{}, entity.ErrorNotFound
+	}
+
+	return resp, nil
+}
+
+func (uc *viewUsecase) cloneViewSchema(ctx context.Context, schema entity.ViewSchema, clone entity.CloneViewRequest) (resp entity.ViewSchema, err error) {
+	schema.Serial, err = helper.GenerateUUUID()
+	if err != nil {
+		return resp, err
+	}
+
+	if clone.Code != "" {
+		schema.Code = clone.Code
+	}
+
+	schema.Name = cloneName(clone.Name, schema.Name)
+	schema.IsFavorite = false
+
+	return uc.viewRepo.CreateViewSchema(ctx, schema, clone.UserSerial)
+}
+
+// validateViewSchema checks every field named by the query and the display fields of a schema against the columns
+// of the object. A related field path is valid when its first field is a reference.
+func (uc *viewUsecase) validateViewSchema(ctx context.Context, tenantCode, objectCode string, schema entity.ViewSchema) (err error) {
+	columnList, _, _, _, err := uc.catalogRepo.GetColumnList(ctx, entity.CatalogQuery{
+		TenantCode: tenantCode,
+		ObjectCode: objectCode,
+	})
+	if err != nil {
+		return err
+	}
+
+	columns := map[string]map[string]any{}
+	for _, column := range columnList {
+		if fieldCode, ok := column[entity.FieldColumnCode].(string); ok {
+			columns[fieldCode] = column
+		}
+	}
+
+	checkField := func(fieldName string) error {
+		path := strings.Split(fieldName, "__")
+
+		column, ok := columns[path[0]]
+		if !ok || (len(path) > 1 && column[entity.FieldForeignTableName] == nil) {
+			return fmt.Errorf("%w: %v", entity.ErrorViewFieldInvalid, fieldName)
+		}
+
+		return nil
+	}
+
+	fieldNames := []string{}
+	for _, filterGroup := range viewSchemaFilters(schema.Query) {
+		for key, filter := range filterGroup.Filters {
+			fieldNames = append(fieldNames, key)
+			if filter.FieldName != "" && filter.FieldName != key {
+				fieldNames = append(fieldNames, filter.FieldName)
+			}
+		}
+	}
+
+	if orders, ok := schema.Query["orders"].([]any); ok {
+		for _, order := range orders {
+			if orderMap, ok := order.(map[string]any); ok {
+				if fieldName, ok := orderMap["field_name"].(string); ok {
+					fieldNames = append(fieldNames, fieldName)
+				}
+			}
+		}
+	}
+
+	for _, key := range []string{"group_by", "start_field", "end_field"} {
+		if fieldName, ok := schema.Query[key].(string); ok && fieldName != "" {
+			fieldNames = append(fieldNames, fieldName)
+		}
+	}
+
+	for key := range schema.DisplayField {
+		fieldNames = append(fieldNames, key)
+	}
+
+	for _, fieldName := range fieldNames {
+		if err := checkField(fieldName); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// cloneName names a copy after its original unless a name is given
+func cloneName(name, original string) string {
+	if strings.TrimSpace(name) != "" {
+		return name
+	}
+
+	return original + " (copy)"
+}
+
+// SavePersonalView saves filters, orders and columns as a view owned by the user of request. The view starts from the
+// layout of the view named by save.ViewContentCode, or of the default view.
+func (uc *viewUsecase) SavePersonalView(ctx context.Context, request entity.GetViewContentByKeysRequest, save entity.SavePersonalViewRequest) (resp entity.ViewContentRecord, err error) {
+	if request.UserSerial == "" {
+		return resp, entity.ErrorViewUserEmpty
+	}
+
+	if save.LayoutType == "" {
+		save.LayoutType = "record"
+	}
+
+	baseView, err := uc.viewRepo.GetViewContentByKeys(ctx, entity.GetViewContentByKeysRequest{
+		TenantCode:      request.TenantCode,
+		ProductCode:     request.ProductCode,
+		ObjectCode:      request.ObjectCode,
+		ViewContentCode: save.ViewContentCode,
+		LayoutType:      save.LayoutType,
+		UserSerial:      request.UserSerial,
+		UserRole:        request.UserRole,
+	})
+	if err != nil {
+		return resp, err
+	}
+
+	schema := entity.ViewSchema{
+		Code:          save.Code,
+		Name:          save.Name,
+		Query:         personalViewQuery(save),
+		DisplayField:  map[string]any{},
+		StructureType: entity.StructureTypeList,
+	}
+
+	for key, field := range save.Fields {
+		schema.DisplayField[key] = map[string]any{
+			"field_code": field.FieldCode,
+			"field_name": field.FieldName,
+		}
+	}
+
+	err = uc.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		schema, err = uc.createViewSchema(ctx, request.TenantCode, request.ObjectCode, schema, request.UserSerial)
+		if err != nil {
+			return err
+		}
+
+		resp, err = uc.createViewContent(ctx, entity.ViewContentRecord{
+			Code:             save.Code,
+			Name:             save.Name,
+			TenantCode:       request.TenantCode,
+			ProductCode:      request.ProductCode,
+			ObjectCode:       request.ObjectCode,
+			OwnerSerial:      request.UserSerial,
+			SharedRole:       save.SharedRole,
+			ViewSchemaSerial: schema.Serial,
+			ViewLayoutSerial: stringItemValue(baseView[entity.VIEW_LAYOUT_SERIAL]),
+			LayoutType:       save.LayoutType,
+			IsDefault:        save.IsDefault,
+			IsShownInList:    true,
+		}, request.UserSerial)
+
+		return err
+	})
+
+	return resp, err
+}
+
+// GetAvailableViews lists the views of an object the user of request can open, with the favorites of the user marked
+func (uc *viewUsecase) GetAvailableViews(ctx context.Context, request entity.GetViewContentByKeysRequest) (resp []entity.ViewContentRecord, err error) {
+	resp, err = uc.viewRepo.GetAvailableViewContents(ctx, request.TenantCode, request.ProductCode, request.ObjectCode, request.UserSerial, request.UserRole)
+	if err != nil || request.UserSerial == "" {
+		return resp, err
+	}
+
+	favorites, err := uc.viewRepo.GetFavoriteViewSerials(ctx, request.UserSerial)
+	if err != nil {
+		return resp, err
+	}
+
+	for i := range resp {
+		resp[i].IsFavorite = helper.Contains(favorites, resp[i].Serial)
+	}
+
+	return resp, nil
+}
+
+// SetViewFavorite marks or unmarks a view the user of request can open as a favorite of the user
+func (uc *viewUsecase) SetViewFavorite(ctx context.Context, request entity.GetViewContentByKeysRequest, isFavorite bool) (err error) {
+	if request.UserSerial == "" {
+		return entity.ErrorViewUserEmpty
+	}
+
+	view, err := uc.viewRepo.GetViewContent(ctx, request.TenantCode, request.ProductCode, request.ObjectCode, request.ViewContentCode, request.LayoutType)
+	if err != nil {
+		return err
+	}
+
+	if view.OwnerSerial != "" && view.OwnerSerial != request.UserSerial && (view.SharedRole == "" || view.SharedRole != request.UserRole) {
+		return entity.ErrorNotFound
+	}
+
+	if isFavorite {
+		return uc.viewRepo.AddViewFavorite(ctx, request.UserSerial, view.Serial)
+	}
+
+	return uc.viewRepo.RemoveViewFavorite(ctx, request.UserSerial, view.Serial)
+}
+
+// personalViewQuery stores filters and orders the way view schema queries are read back by viewSchemaFilters and viewSchemaOrders
+func personalViewQuery(save entity.SavePersonalViewRequest) map[string]any {
+	filters := []any{}
+	for _, filterGroup := range save.Filters {
+		filterItems := map[string]any{}
+		for key, filter := range filterGroup.Filters {
+			fieldName := filter.FieldName
+			if fieldName == "" {
+				fieldName = key
+			}
+
+			filterItems[key] = map[string]any{
+				"field_code": fieldName,
+				"operator":   string(filter.Operator),
+				"value":      filter.Value,
+			}
+		}
+
+		filters = append(filters, map[string]any{
+			"operator":    string(filterGroup.Operator),
+			"filter_item": filterItems,
+		})
+	}
+
+	orders := []any{}
+	for _, order := range save.Orders {
+		orders = append(orders, map[string]any{
+			"field_name": order.FieldName,
+			"direction":  order.Direction,
+		})
+	}
+
+	return map[string]any{
+		"filters": filters,
+		"orders":  orders,
+	}
+}
+
+func stringItemValue(item entity.DataItem) string {
+	switch v := item.Value.(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,252 @@
+package module
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/cerkas/cerkas-backend/config"
+	"github.com/cerkas/cerkas-backend/core/entity"
+)
+
+func newTestViewUsecase(viewRepo *fakeViewRepository) ViewUsecase {
+	catalogRepo := &fakeCatalogRepository{
+		columns:      map[string][]string{"task": {"name", "status", "owner"}},
+		references:   map[string]entity.ForeignKeyInfo{"task.owner": {ForeignTable: "user"}},
+		objectFields: map[string]map[string]any{"task": {}},
+	}
+
+	return NewViewUsecase(config.Config{AdminRole: "admin"}, catalogRepo, viewRepo, nil, fakeTransactor{})
+}
+
+func taskView(code string) entity.ViewContentRecord {
+	return entity.ViewContentRecord{
+		Code:        code,
+		Name:        code,
+		TenantCode:  "acme",
+		ProductCode: "crm",
+		ObjectCode:  "task",
+		LayoutType:  "record",
+	}
+}
+
+func taskViewKeys(code, userSerial, userRole string) entity.GetViewContentByKeysRequest {
+	return entity.GetViewContentByKeysRequest{
+		TenantCode:      "acme",
+		ProductCode:     "crm",
+		ObjectCode:      "task",
+		ViewContentCode: code,
+		LayoutType:      "record",
+		UserSerial:      userSerial,
+		UserRole:        userRole,
+	}
+}
+
+func TestCreateViewContentMakesTheFirstViewTheDefault(t *testing.T) {
+	viewRepo := &fakeViewRepository{}
+	uc := newTestViewUsecase(viewRepo)
+	ctx := context.Background()
+
+	first, err := uc.CreateViewContent(ctx, taskView("all"), "admin-1", "admin")
+	if err != nil {
+		t.Fatalf("CreateViewContent = %v", err)
+	}
+
+	second, err := uc.CreateViewContent(ctx, taskView("open"), "admin-1", "admin")
+	if err != nil {
+		t.Fatalf("CreateViewContent = %v", err)
+	}
+
+	if !first.IsDefault || second.IsDefault {
+		t.Errorf("defaults = %v, %v, want true, false", first.IsDefault, second.IsDefault)
+	}
+
+	tests := []struct {
+		name     string
+		record   entity.ViewContentRecord
+		userRole string
+		want     error
+	}{
+		{"code taken", taskView("open"), "admin", entity.ErrorViewCodeExists},
+		{"code empty", taskView(" "), "admin", entity.ErrorViewCodeEmpty},
+		{"shared view by a user", taskView("mine"), "user", entity.ErrorViewForbidden},
+	}
+
+	for _, test := range tests {
+		if _, err := uc.CreateViewContent(ctx, test.record, "user-1", test.userRole); !errors.Is(err, test.want) {
+			t.Errorf("%v: CreateViewContent = %v, want %v", test.name, err, test.want)
+		}
+	}
+
+	personal := taskView("mine")
+	personal.OwnerSerial = "user-1"
+	created, err := uc.CreateViewContent(ctx, personal, "user-1", "user")
+	if err != nil {
+		t.Fatalf("personal view: CreateViewContent = %v", err)
+	}
+
+	if created.IsDefault {
+		t.Errorf("personal view: IsDefault = true, want false")
+	}
+}
+
+func TestUpdateViewContentKeepsADefault(t *testing.T) {
+	viewRepo := &fakeViewRepository{}
+	uc := newTestViewUsecase(viewRepo)
+	ctx := context.Background()
+
+	for _, code := range []string{"all", "open"} {
+		if _, err := uc.CreateViewContent(ctx, taskView(code), "admin-1", "admin"); err != nil {
+			t.Fatalf("CreateViewContent %v = %v", code, err)
+		}
+	}
+
+	if _, err := uc.UpdateViewContent(ctx, taskViewKeys("all", "admin-1", "admin"), taskView("all")); !errors.Is(err, entity.ErrorViewDefaultRequired) {
+		t.Errorf("unset default: UpdateViewContent = %v, want %v", err, entity.ErrorViewDefaultRequired)
+	}
+
+	if _, err := uc.UpdateViewContent(ctx, taskViewKeys("open", "user-1", "user"), taskView("open")); !errors.Is(err, entity.ErrorViewForbidden) {
+		t.Errorf("shared view by a user: UpdateViewContent = %v, want %v", err, entity.ErrorViewForbidden)
+	}
+
+	if _, err := uc.UpdateViewContent(ctx, taskViewKeys("open", "admin-1", "admin"), taskView("all")); !errors.Is(err, entity.ErrorViewCodeExists) {
+		t.Errorf("rename to a taken code: UpdateViewContent = %v, want %v", err, entity.ErrorViewCodeExists)
+	}
+
+	record := taskView("open")
+	record.IsDefault = true
+	if _, err := uc.UpdateViewContent(ctx, taskViewKeys("open", "admin-1", "admin"), record); err != nil {
+		t.Fatalf("move default: UpdateViewContent = %v", err)
+	}
+
+	for _, content := range viewRepo.contents {
+		if content.IsDefault != (content.Code == "open") {
+			t.Errorf("%v: IsDefault = %v after moving the default", content.Code, content.IsDefault)
+		}
+	}
+}
+
+func TestDeleteDefaultViewHandsTheDefaultOver(t *testing.T) {
+	viewRepo := &fakeViewRepository{}
+	uc := newTestViewUsecase(viewRepo)
+	ctx := context.Background()
+
+	for _, code := range []string{"all", "open", "closed"} {
+		if _, err := uc.CreateViewContent(ctx, taskView(code), "admin-1", "admin"); err != nil {
+			t.Fatalf("CreateViewContent %v = %v", code, err)
+		}
+	}
+
+	if err := uc.DeleteViewContent(ctx, taskViewKeys("all", "user-1", "user")); !errors.Is(err, entity.ErrorViewForbidden) {
+		t.Errorf("shared view by a user: DeleteViewContent = %v, want %v", err, entity.ErrorViewForbidden)
+	}
+
+	if err := uc.DeleteViewContent(ctx, taskViewKeys("all", "admin-1", "admin")); err != nil {
+		t.Fatalf("DeleteViewContent = %v", err)
+	}
+
+	if len(viewRepo.contents) != 2 || !viewRepo.contents[0].IsDefault || viewRepo.contents[0].Code != "open" {
+		t.Errorf("views after delete = %+v, want open as the default", viewRepo.contents)
+	}
+}
+
+func TestCloneViewContentCopiesTheSchema(t *testing.T) {
+	viewRepo := &fakeViewRepository{
+		schemas: []entity.ViewSchema{{Serial: "schema-1", Code: "all", Name: "All", ObjectSerial: "task"}},
+	}
+	uc := newTestViewUsecase(viewRepo)
+	ctx := context.Background()
+
+	shared := taskView("all")
+	shared.ViewSchemaSerial = "schema-1"
+	if _, err := uc.CreateViewContent(ctx, shared, "admin-1", "admin"); err != nil {
+		t.Fatalf("CreateViewContent = %v", err)
+	}
+
+	clone, err := uc.CloneViewContent(ctx, taskViewKeys("all", "user-1", "user"), entity.CloneViewRequest{Code: "my-all", UserSerial: "user-1", UserRole: "user"})
+	if err != nil {
+		t.Fatalf("CloneViewContent = %v", err)
+	}
+
+	if clone.OwnerSerial != "user-1" || clone.IsDefault || clone.Name != "all (copy)" {
+		t.Errorf("clone = %+v, want a personal view named all (copy)", clone)
+	}
+
+	if clone.ViewSchemaSerial == "" || clone.ViewSchemaSerial == "schema-1" || len(viewRepo.schemas) != 2 {
+		t.Errorf("clone schema = %v, want a copy of schema-1", clone.ViewSchemaSerial)
+	}
+
+	if _, err := uc.CloneViewContent(ctx, taskViewKeys("my-all", "user-2", "user"), entity.CloneViewRequest{Code: "copy", UserSerial: "user-2", UserRole: "user"}); !errors.Is(err, entity.ErrorNotFound) {
+		t.Errorf("personal view of another user: CloneViewContent = %v, want %v", err, entity.ErrorNotFound)
+	}
+}
+
+func TestCreateViewSchemaChecksTheFields(t *testing.T) {
+	uc := newTestViewUsecase(&fakeViewRepository{})
+	ctx := context.Background()
+
+	tests := []struct {
+		name   string
+		schema entity.ViewSchema
+		want   error
+	}{
+		{"known fields", entity.ViewSchema{Code: "a", DisplayField: map[string]any{"name": nil}, Query: map[string]any{"group_by": "status"}}, nil},
+		{"related field of a reference", entity.ViewSchema{Code: "b", DisplayField: map[string]any{"owner__name": nil}}, nil},
+		{"unknown display field", entity.ViewSchema{Code: "c", DisplayField: map[string]any{"title": nil}}, entity.ErrorViewFieldInvalid},
+		{"related field of a plain field", entity.ViewSchema{Code: "d", DisplayField: map[string]any{"status__name": nil}}, entity.ErrorViewFieldInvalid},
+		{"unknown order field", entity.ViewSchema{Code: "e", Query: map[string]any{"orders": []any{map[string]any{"field_name": "due"}}}}, entity.ErrorViewFieldInvalid},
+	}
+
+	for _, test := range tests {
+		_, err := uc.CreateViewSchema(ctx, "acme", "task", test.schema, "admin-1", "admin")
+		if !errors.Is(err, test.want) {
+			t.Errorf("%v: CreateViewSchema = %v, want %v", test.name, err, test.want)
+		}
+	}
+
+	if _, err := uc.CreateViewSchema(ctx, "acme", "task", entity.ViewSchema{Code: "f"}, "user-1", "user"); !errors.Is(err, entity.ErrorViewForbidden) {
+		t.Errorf("by a user: CreateViewSchema = %v, want %v", err, entity.ErrorViewForbidden)
+	}
+}
+
+func TestViewSchemasAndLayoutsInUseAreKept(t *testing.T) {
+	viewRepo := &fakeViewRepository{
+		schemas: []entity.ViewSchema{{Serial: "schema-1", ObjectSerial: "task"}, {Serial: "schema-2", ObjectSerial: "task"}},
+		layouts: []entity.ViewLayout{{Serial: "layout-1", Code: "list"}, {Serial: "layout-2", Code: "board"}},
+	}
+	uc := newTestViewUsecase(viewRepo)
+	ctx := context.Background()
+
+	view := taskView("all")
+	view.ViewSchemaSerial = "schema-1"
+	view.ViewLayoutSerial = "layout-1"
+	if _, err := uc.CreateViewContent(ctx, view, "admin-1", "admin"); err != nil {
+		t.Fatalf("CreateViewContent = %v", err)
+	}
+
+	if err := uc.DeleteViewSchema(ctx, "acme", "task", "schema-1", "admin-1", "admin"); !errors.Is(err, entity.ErrorViewInUse) {
+		t.Errorf("used schema: DeleteViewSchema = %v, want %v", err, entity.ErrorViewInUse)
+	}
+
+	if err := uc.DeleteViewLayout(ctx, "layout-1", "admin-1", "admin"); !errors.Is(err, entity.ErrorViewInUse) {
+		t.Errorf("used layout: DeleteViewLayout = %v, want %v", err, entity.ErrorViewInUse)
+	}
+
+	if err := uc.DeleteViewSchema(ctx, "acme", "task", "schema-2", "admin-1", "admin"); err != nil {
+		t.Errorf("unused schema: DeleteViewSchema = %v", err)
+	}
+
+	if err := uc.DeleteViewLayout(ctx, "layout-2", "user-1", "user"); !errors.Is(err, entity.ErrorViewForbidden) {
+		t.Errorf("by a user: DeleteViewLayout = %v, want %v", err, entity.ErrorViewForbidden)
+	}
+
+	if err := uc.DeleteViewLayout(ctx, "layout-2", "admin-1", "admin"); err != nil {
+		t.Errorf("unused layout: DeleteViewLayout = %v", err)
+	}
+
+	missing := taskView("missing")
+	missing.ViewLayoutSerial = "layout-2"
+	if _, err := uc.CreateViewContent(ctx, missing, "admin-1", "admin"); !errors.Is(err, entity.ErrorNotFound) {
+		t.Errorf("deleted layout: CreateViewContent = %v, want %v", err, entity.ErrorNotFound)
+	}
+}
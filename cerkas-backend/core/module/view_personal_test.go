@@ -0,0 +1,137 @@
+package module
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/cerkas/cerkas-backend/core/entity"
+)
+
+func TestSavePersonalViewKeepsTheLayoutOfTheDefault(t *testing.T) {
+	viewRepo := &fakeViewRepository{
+		layouts: []entity.ViewLayout{{Serial: "layout-1", Code: "list"}},
+	}
+	uc := newTestViewUsecase(viewRepo)
+	ctx := context.Background()
+
+	shared := taskView("all")
+	shared.ViewLayoutSerial = "layout-1"
+	if _, err := uc.CreateViewContent(ctx, shared, "admin-1", "admin"); err != nil {
+		t.Fatalf("CreateViewContent = %v", err)
+	}
+
+	save := entity.SavePersonalViewRequest{
+		Code: "my-open",
+		Name: "My open tasks",
+		Filters: []entity.FilterGroup{{
+			Filters: map[string]entity.FilterItem{"status": {Operator: entity.FilterOperatorEqual, Value: "open"}},
+		}},
+		Orders: []entity.Order{{FieldName: "name", Direction: "asc"}},
+		Fields: map[string]entity.Field{"name": {FieldCode: "name", FieldName: "name"}},
+	}
+
+	view, err := uc.SavePersonalView(ctx, taskViewKeys("", "user-1", "user"), save)
+	if err != nil {
+		t.Fatalf("SavePersonalView = %v", err)
+	}
+
+	if view.OwnerSerial != "user-1" || view.ViewLayoutSerial != "layout-1" || view.LayoutType != "record" || view.IsDefault {
+		t.Errorf("view = %+v, want a personal record view on layout-1", view)
+	}
+
+	schema, err := viewRepo.GetViewSchema(ctx, view.ViewSchemaSerial)
+	if err != nil {
+		t.Fatalf("GetViewSchema = %v", err)
+	}
+
+	filters := viewSchemaFilters(schema.Query)
+	if len(filters) != 1 || filters[0].Filters["status"].Value != "open" || filters[0].Filters["status"].FieldName != "status" {
+		t.Errorf("filters = %+v, want status equal open", filters)
+	}
+
+	orders := viewSchemaOrders(schema.Query)
+	if len(orders) != 1 || orders[0].FieldName != "name" {
+		t.Errorf("orders = %+v, want name", orders)
+	}
+
+	tests := []struct {
+		name    string
+		request entity.GetViewContentByKeysRequest
+		save    entity.SavePersonalViewRequest
+		want    error
+	}{
+		{"no user", taskViewKeys("", "", "user"), entity.SavePersonalViewRequest{Code: "other"}, entity.ErrorViewUserEmpty},
+		{"code taken", taskViewKeys("", "user-1", "user"), entity.SavePersonalViewRequest{Code: "my-open"}, entity.ErrorViewCodeExists},
+		{"unknown field", taskViewKeys("", "user-1", "user"), entity.SavePersonalViewRequest{Code: "other", Fields: map[string]entity.Field{"title": {}}}, entity.ErrorViewFieldInvalid},
+		{"unknown base view", taskViewKeys("", "user-1", "user"), entity.SavePersonalViewRequest{Code: "other", ViewContentCode: "missing"}, entity.ErrorNotFound},
+	}
+
+	for _, test := range tests {
+		if _, err := uc.SavePersonalView(ctx, test.request, test.save); !errors.Is(err, test.want) {
+			t.Errorf("%v: SavePersonalView = %v, want %v", test.name, err, test.want)
+		}
+	}
+}
+
+func TestAvailableViewsMarkTheFavoritesOfTheUser(t *testing.T) {
+	viewRepo := &fakeViewRepository{}
+	uc := newTestViewUsecase(viewRepo)
+	ctx := context.Background()
+
+	if _, err := uc.CreateViewContent(ctx, taskView("all"), "admin-1", "admin"); err != nil {
+		t.Fatalf("CreateViewContent = %v", err)
+	}
+
+	for _, view := range []struct{ code, owner, sharedRole string }{
+		{"mine", "user-1", ""},
+		{"private", "user-2", ""},
+		{"team", "user-2", "user"},
+	} {
+		record := taskView(view.code)
+		record.OwnerSerial = view.owner
+		record.SharedRole = view.sharedRole
+		if _, err := uc.CreateViewContent(ctx, record, view.owner, "user"); err != nil {
+			t.Fatalf("CreateViewContent %v = %v", view.code, err)
+		}
+	}
+
+	for _, code := range []string{"all", "team"} {
+		if err := uc.SetViewFavorite(ctx, taskViewKeys(code, "user-1", "user"), true); err != nil {
+			t.Fatalf("SetViewFavorite %v = %v", code, err)
+		}
+	}
+
+	if err := uc.SetViewFavorite(ctx, taskViewKeys("private", "user-1", "user"), true); !errors.Is(err, entity.ErrorNotFound) {
+		t.Errorf("private view of another user: SetViewFavorite = %v, want %v", err, entity.ErrorNotFound)
+	}
+
+	if err := uc.SetViewFavorite(ctx, taskViewKeys("all", "", "user"), true); !errors.Is(err, entity.ErrorViewUserEmpty) {
+		t.Errorf("no user: SetViewFavorite = %v, want %v", err, entity.ErrorViewUserEmpty)
+	}
+
+	if err := uc.SetViewFavorite(ctx, taskViewKeys("all", "user-1", "user"), false); err != nil {
+		t.Fatalf("unmark: SetViewFavorite = %v", err)
+	}
+
+	views, err := uc.GetAvailableViews(ctx, taskViewKeys("", "user-1", "user"))
+	if err != nil {
+		t.Fatalf("GetAvailableViews = %v", err)
+	}
+
+	got := map[string]bool{}
+	for _, view := range views {
+		got[view.Code] = view.IsFavorite
+	}
+
+	want := map[string]bool{"all": false, "mine": false, "team": true}
+	if len(got) != len(want) {
+		t.Errorf("views = %v, want %v", got, want)
+	}
+
+	for code, isFavorite := range want {
+		if favorite, ok := got[code]; !ok || favorite != isFavorite {
+			t.Errorf("%v: IsFavorite = %v, want %v", code, favorite, isFavorite)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package module
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/cerkas/cerkas-backend/core/entity"
+	"github.com/cerkas/cerkas-backend/core/repository"
+)
+
+/*
+	a view is resolved along its layers: user -> tenant+product -> tenant -> product -> global.
+	The display fields and the layout config are merged from the global layer up, so a more specific layer overrides
+	the settings it sets and inherits the rest:
+	- objects are merged key by key
+	- children of a layout are merged by position while their types match, otherwise they are replaced
+	- any other value replaces the inherited one, a null removes it
+*/
+
+// resolveViewChain merges the display fields and the layout configs of the layers of a view
+func resolveViewChain(ctx context.Context, viewRepo repository.ViewRepository, request entity.GetViewContentByKeysRequest) (resp entity.ViewResolution, err error) {
+	resp.Layers, err = viewRepo.GetViewContentChain(ctx, request)
+	if err != nil {
+		return resp, err
+	}
+
+	resp.DisplayField = map[string]any{}
+	resp.LayoutConfig = map[string]any{}
+	resp.Sources = map[string]string{}
+
+	for i := len(resp.Layers) - 1; i >= 0; i-- {
+		layer := resp.Layers[i]
+
+		if layer.ViewSchemaSerial != "" {
+			schema, err := viewRepo.GetViewSchema(ctx, layer.ViewSchemaSerial)
+			if err != nil && !errors.Is(err, entity.ErrorNotFound) {
+				return resp, err
+			}
+
+			mergeViewSetting(resp.DisplayField, schema.DisplayField, "display_field", layer.Layer, resp.Sources)
+		}
+
+		if layer.ViewLayoutSerial != "" {
+			layout, err := viewRepo.GetViewLayout(ctx, layer.ViewLayoutSerial)
+			if err != nil && !errors.Is(err, entity.ErrorNotFound) {
+				return resp, err
+			}
+
+			mergeViewSetting(resp.LayoutConfig, layout.LayoutConfig, "layout_config", layer.Layer, resp.Sources)
+		}
+	}
+
+	return resp, nil
+}
+
+// mergeViewSetting merges src of layer into dst, sources keeps the layer of every merged value by its path
+func mergeViewSetting(dst, src map[string]any, path, layer string, sources map[string]string) {
+	for key, value := range src {
+		keyPath := path + "." + key
+
+		if value == nil {
+			delete(dst, key)
+			clearViewSources(sources, keyPath)
+			continue
+		}
+
+		srcMap, srcIsMap := value.(map[string]any)
+		if dstMap, ok := dst[key].(map[string]any); ok && srcIsMap {
+			mergeViewSetting(dstMap, srcMap, keyPath, layer, sources)
+			continue
+		}
+
+		if key == entity.CHILDREN && mergeLayoutChildren(dst[key], value, keyPath, layer, sources) {
+			continue
+		}
+
+		clearViewSources(sources, keyPath)
+
+		if srcIsMap {
+			merged := map[string]any{}
+			mergeViewSetting(merged, srcMap, keyPath, layer, sources)
+			dst[key] = merged
+		} else {
+			dst[key] = value
+		}
+
+		sources[keyPath] = layer
+	}
+}
+
+// mergeLayoutChildren merges the children of a layout node by position, it fails when the children do not line up
+func mergeLayoutChildren(dst, src any, path, layer string, sources map[string]string) bool {
+	dstChildren, ok := dst.([]any)
+	if !ok {
+		return false
+	}
+
+	srcChildren, ok := src.([]any)
+	if !ok || len(srcChildren) != len(dstChildren) {
+		return false
+	}
+
+	for i := range srcChildren {
+		dstChild, ok := dstChildren[i].(map[string]any)
+		if !ok {
+			return false
+		}
+
+		srcChild, ok := srcChildren[i].(map[string]any)
+		if !ok || srcChild[entity.TYPE] != dstChild[entity.TYPE] {
+			return false
+		}
+	}
+
+	for i := range srcChildren {
+		mergeViewSetting(dstChildren[i].(map[string]any), srcChildren[i].(map[string]any), fmt.Sprintf("%v.%v", path, i), layer, sources)
+	}
+
+	return true
+}
+
+// clearViewSources forgets the layers of a path and of everything below it
+func clearViewSources(sources map[string]string, path string) {
+	for key := range sources {
+		if key == path || strings.HasPrefix(key, path+".") {
+			delete(sources, key)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package module
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/cerkas/cerkas-backend/core/entity"
+)
+
+func TestGetViewResolutionMergesTheLayers(t *testing.T) {
+	viewRepo := &fakeViewRepository{
+		schemas: []entity.ViewSchema{
+			{Serial: "schema-global", DisplayField: layoutConfig(t, `{"name":{"field_name":"Name"},"status":{"field_name":"Status"}}`)},
+			{Serial: "schema-user", DisplayField: layoutConfig(t, `{"name":{"field_name":"Title"},"status":null}`)},
+		},
+		layouts: []entity.ViewLayout{
+			{Serial: "layout-global", LayoutConfig: layoutConfig(t, `{"type":"webView","class_name":"page","children":[{"type":"table","props":{"page_size":10,"fields":["name"]}}]}`)},
+			{Serial: "layout-tenant", LayoutConfig: layoutConfig(t, `{"children":[{"type":"table","props":{"page_size":25}}]}`)},
+		},
+		chain: []entity.ViewContentRecord{
+			{Code: "all", Layer: entity.ViewLayerUser, ViewSchemaSerial: "schema-user"},
+			{Code: "all", Layer: entity.ViewLayerTenant, ViewLayoutSerial: "layout-tenant"},
+			{Code: "all", Layer: entity.ViewLayerGlobal, ViewSchemaSerial: "schema-global", ViewLayoutSerial: "layout-global"},
+		},
+	}
+	uc := newTestViewUsecase(viewRepo)
+
+	resolution, err := uc.GetViewResolution(context.Background(), taskViewKeys("all", "user-1", "user"))
+	if err != nil {
+		t.Fatalf("GetViewResolution = %v", err)
+	}
+
+	wantDisplayField := layoutConfig(t, `{"name":{"field_name":"Title"}}`)
+	if !reflect.DeepEqual(resolution.DisplayField, wantDisplayField) {
+		t.Errorf("DisplayField = %v, want %v", resolution.DisplayField, wantDisplayField)
+	}
+
+	wantLayout := layoutConfig(t, `{"type":"webView","class_name":"page","children":[{"type":"table","props":{"page_size":25,"fields":["name"]}}]}`)
+	if !reflect.DeepEqual(resolution.LayoutConfig, wantLayout) {
+		t.Errorf("LayoutConfig = %v, want %v", resolution.LayoutConfig, wantLayout)
+	}
+
+	// a value a layer adds as a whole is kept under its own path, the layers overriding parts of it add their paths
+	wantSources := map[string]string{
+		"display_field.name":                       entity.ViewLayerGlobal,
+		"display_field.name.field_name":            entity.ViewLayerUser,
+		"layout_config.type":                       entity.ViewLayerGlobal,
+		"layout_config.class_name":                 entity.ViewLayerGlobal,
+		"layout_config.children":                   entity.ViewLayerGlobal,
+		"layout_config.children.0.type":            entity.ViewLayerTenant,
+		"layout_config.children.0.props.page_size": entity.ViewLayerTenant,
+	}
+	if !reflect.DeepEqual(resolution.Sources, wantSources) {
+		t.Errorf("Sources = %v, want %v", resolution.Sources, wantSources)
+	}
+}
+
+func TestMergeViewSettingReplacesChildrenThatDoNotLineUp(t *testing.T) {
+	tests := []struct {
+		name string
+		dst  string
+		src  string
+		want string
+	}{
+		{"same types", `{"children":[{"type":"table","props":{"a":1}}]}`, `{"children":[{"type":"table","props":{"b":2}}]}`, `{"children":[{"type":"table","props":{"a":1,"b":2}}]}`},
+		{"other type", `{"children":[{"type":"table","props":{"a":1}}]}`, `{"children":[{"type":"kanban"}]}`, `{"children":[{"type":"kanban"}]}`},
+		{"other length", `{"children":[{"type":"table"}]}`, `{"children":[{"type":"table"},{"type":"form"}]}`, `{"children":[{"type":"table"},{"type":"form"}]}`},
+		{"null removes", `{"class_name":"page","type":"webView"}`, `{"class_name":null}`, `{"type":"webView"}`},
+	}
+
+	for _, test := range tests {
+		dst := layoutConfig(t, test.dst)
+		mergeViewSetting(dst, layoutConfig(t, test.src), "layout_config", entity.ViewLayerUser, map[string]string{})
+
+		if want := layoutConfig(t, test.want); !reflect.DeepEqual(dst, want) {
+			t.Errorf("%v: merged = %v, want %v", test.name, dst, want)
+		}
+	}
+}
+
+func TestGetViewResolutionOfAMissingView(t *testing.T) {
+	uc := newTestViewUsecase(&fakeViewRepository{})
+
+	if _, err := uc.GetViewResolution(context.Background(), taskViewKeys("missing", "user-1", "user")); !errors.Is(err, entity.ErrorNotFound) {
+		t.Errorf("GetViewResolution = %v, want %v", err, entity.ErrorNotFound)
+	}
+}
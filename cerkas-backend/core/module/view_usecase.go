@@ -3,7 +3,6 @@ package module
 import (
 	"context"
 	"encoding/json"
-	"fmt"
 	"reflect"
 	"strings"
 
@@ -17,6 +16,27 @@ import (
 
 type ViewUsecase interface {
 	GetContentLayoutByKeys(ctx context.Context, request entity.GetViewContentByKeysRequest, catalogQuery entity.CatalogQuery) (resp entity.ViewContentResponse, err error)
+	GetViewResolution(ctx context.Context, request entity.GetViewContentByKeysRequest) (resp entity.ViewResolution, err error)
+	RegisterLayoutComponent(component LayoutComponent)
+
+	CreateViewContent(ctx context.Context, record entity.ViewContentRecord, userSerial, userRole string) (resp entity.ViewContentRecord, err error)
+	UpdateViewContent(ctx context.Context, request entity.GetViewContentByKeysRequest, record entity.ViewContentRecord) (resp entity.ViewContentRecord, err error)
+	DeleteViewContent(ctx context.Context, request entity.GetViewContentByKeysRequest) (err error)
+	CloneViewContent(ctx context.Context, request entity.GetViewContentByKeysRequest, clone entity.CloneViewRequest) (resp entity.ViewContentRecord, err error)
+
+	CreateViewSchema(ctx context.Context, tenantCode, objectCode string, schema entity.ViewSchema, userSerial, userRole string) (resp entity.ViewSchema, err error)
+	UpdateViewSchema(ctx context.Context, tenantCode, objectCode string, schema entity.ViewSchema, userSerial, userRole string) (resp entity.ViewSchema, err error)
+	DeleteViewSchema(ctx context.Context, tenantCode, objectCode, serial, userSerial, userRole string) (err error)
+	CloneViewSchema(ctx context.Context, tenantCode, objectCode, serial string, clone entity.CloneViewRequest) (resp entity.ViewSchema, err error)
+
+	CreateViewLayout(ctx context.Context, layout entity.ViewLayout, userSerial, userRole string) (resp entity.ViewLayout, err error)
+	UpdateViewLayout(ctx context.Context, layout entity.ViewLayout, userSerial, userRole string) (resp entity.ViewLayout, err error)
+	DeleteViewLayout(ctx context.Context, serial, userSerial, userRole string) (err error)
+	CloneViewLayout(ctx context.Context, serial string, clone entity.CloneViewRequest) (resp entity.ViewLayout, err error)
+
+	SavePersonalView(ctx context.Context, request entity.GetViewContentByKeysRequest, save entity.SavePersonalViewRequest) (resp entity.ViewContentRecord, err error)
+	GetAvailableViews(ctx context.Context, request entity.GetViewContentByKeysRequest) (resp []entity.ViewContentRecord, err error)
+	SetViewFavorite(ctx context.Context, request entity.GetViewContentByKeysRequest, isFavorite bool) (err error)
 }
 
 type viewUsecase struct {
@@ -24,23 +44,38 @@ type viewUsecase struct {
 	catalogRepo repository.CatalogRepository
 	viewRepo    repository.ViewRepository
 	catalogUc   CatalogUsecase
+	transactor  repository.Transactor
+	layouts     *LayoutRegistry
 }
 
-func NewViewUsecase(cfg config.Config, catalogRepo repository.CatalogRepository, viewRepo repository.ViewRepository, catalogUc CatalogUsecase) ViewUsecase {
+func NewViewUsecase(cfg config.Config, catalogRepo repository.CatalogRepository, viewRepo repository.ViewRepository, catalogUc CatalogUsecase, transactor repository.Transactor) ViewUsecase {
 	return &viewUsecase{
 		cfg:         cfg,
 		catalogRepo: catalogRepo,
 		viewRepo:    viewRepo,
 		catalogUc:   catalogUc,
+		transactor:  transactor,
+		layouts:     NewLayoutRegistry(),
 	}
 }
 
+// RegisterLayoutComponent adds a component type to the view layouts, or replaces a built in one
+func (uc *viewUsecase) RegisterLayoutComponent(component LayoutComponent) {
+	uc.layouts.Register(component)
+}
+
 func (uc *viewUsecase) GetContentLayoutByKeys(ctx context.Context, request entity.GetViewContentByKeysRequest, catalogQuery entity.CatalogQuery) (resp entity.ViewContentResponse, err error) {
 	viewContentRecord, err := uc.viewRepo.GetViewContentByKeys(ctx, request)
 	if err != nil {
 		return resp, err
 	}
 
+	// the display fields and the layout config are merged along the layers of the view
+	resolution, err := resolveViewChain(ctx, uc.viewRepo, request)
+	if err != nil {
+		return resp, err
+	}
+
 	// Convert map to struct
 	if err = mapToStructSnakeCase(viewContentRecord, &resp.ViewContent); err != nil {
 		return resp, err
@@ -146,6 +181,7 @@ func (uc *viewUsecase) GetContentLayoutByKeys(ctx context.Context, request entit
 				return resp, err
 			}
 
+			viewSchemaSt.DisplayField = resolution.DisplayField
 			resp.ViewContent.ViewSchema = viewSchemaSt
 
 			if catalogQuery.Fields == nil {
@@ -196,6 +232,12 @@ func (uc *viewUsecase) GetContentLayoutByKeys(ctx context.Context, request entit
 				return resp, err
 			}
 
+			viewLayoutSt.LayoutConfig = resolution.LayoutConfig
+
+			if err = uc.layouts.Validate(viewLayoutSt.LayoutConfig); err != nil {
+				return resp, err
+			}
+
 			resp.ViewContent.ViewLayout = viewLayoutSt
 		}
 	}
@@ -212,17 +254,71 @@ func (uc *viewUsecase) GetContentLayoutByKeys(ctx context.Context, request entit
 		}
 	}
 
-	originalFields, _, _, _, err := uc.catalogRepo.GetColumnList(ctx, catalogQuery)
+	resp.Fields, err = uc.describeFields(ctx, catalogQuery)
+	if err != nil {
+		return resp, err
+	}
+
+	// the current values of the record a form edits
+	var record map[string]entity.DataItem
+	if request.RecordSerial != "" {
+		record, err = uc.catalogUc.GetObjectDetail(ctx, entity.CatalogQuery{
+			TenantCode:  request.TenantCode,
+			ProductCode: request.ProductCode,
+			ObjectCode:  request.ObjectCode,
+		}, request.RecordSerial)
+		if err != nil {
+			return resp, err
+		}
+
+		if record == nil {
+			return resp, entity.ErrorNotFound
+		}
+	}
+
+	// fetching layout
+	resp.Layout, err = uc.layouts.Resolve(&layoutContext{
+		ctx:             ctx,
+		request:         request,
+		viewFields:      resp.Fields,
+		loadFields:      uc.loadObjectFields(request),
+		loadFieldInputs: uc.loadFieldInputs(request),
+		record:          record,
+	}, resp.ViewContent.ViewLayout.LayoutConfig)
 	if err != nil {
 		return resp, err
 	}
 
+	return resp, nil
+}
+
+// GetViewResolution shows the layers a view is resolved from and the layer of each of its settings
+func (uc *viewUsecase) GetViewResolution(ctx context.Context, request entity.GetViewContentByKeysRequest) (resp entity.ViewResolution, err error) {
+	resp, err = resolveViewChain(ctx, uc.viewRepo, request)
+	if err != nil {
+		return resp, err
+	}
+
+	if len(resp.Layers) == 0 {
+		return resp, entity.ErrorNotFound
+	}
+
+	return resp, nil
+}
+
+// describeFields returns the columns of an object with the display names and data types of its object fields
+func (uc *viewUsecase) describeFields(ctx context.Context, catalogQuery entity.CatalogQuery) (fields []map[string]any, err error) {
+	originalFields, _, _, _, err := uc.catalogRepo.GetColumnList(ctx, catalogQuery)
+	if err != nil {
+		return fields, err
+	}
+
 	// handle custom object fields based on object field table
 	objectFields := map[string]any{}
 	if catalogQuery.ObjectSerial != "" {
 		objectFields, err = uc.catalogUc.GetObjectFieldsByObjectCode(ctx, catalogQuery)
 		if err != nil {
-			return resp, err
+			return fields, err
 		}
 	}
 
@@ -247,104 +343,56 @@ func (uc *viewUsecase) GetContentLayoutByKeys(ctx context.Context, request entit
 		originalFields[i] = originalField
 	}
 
-	resp.Fields = originalFields
-
-	// fetching layout
-	resp.Layout, err = handleViewLayout(resp.ViewContent.ViewLayout.LayoutConfig, resp.Fields, request)
-	if err != nil {
-		return resp, err
-	}
-
-	return resp, nil
+	return originalFields, nil
 }
 
-func handleViewLayout(viewLayoutConfig map[string]any, fields []map[string]any, request entity.GetViewContentByKeysRequest) (map[string]any, error) {
-	// TODO: inject view schema into view layout respectively
-
-	/*
-		example of view layout json:
-		{
-				"children": [
-						{
-								"class_name": "",
-								"props": {},
-								"type": "table"
-						}
-				],
-				"class_name": "",
-				"props": {},
-				"type": "webView"
+// loadObjectFields returns the field loader of the layout components, it describes every field of an object of the view tenant
+func (uc *viewUsecase) loadObjectFields(request entity.GetViewContentByKeysRequest) func(ctx context.Context, objectCode string) ([]map[string]any, error) {
+	return func(ctx context.Context, objectCode string) ([]map[string]any, error) {
+		catalogQuery := entity.CatalogQuery{
+			ObjectCode:  objectCode,
+			TenantCode:  request.TenantCode,
+			ProductCode: request.ProductCode,
 		}
 
-		because the layout is dynamic, we need to handle it in a generic way
-		it will always have children and type, and it can be recursive
-	*/
-
-	// check if viewLayoutConfig is nil
-	if viewLayoutConfig == nil {
-		return nil, nil
-	}
-
-	// check if viewLayoutConfig is a map
-	if reflect.TypeOf(viewLayoutConfig).Kind() != reflect.Map {
-		return nil, fmt.Errorf("viewLayoutConfig is not a map")
-	}
-
-	switch viewLayoutConfig["type"] {
-	case "webView", "mobileView":
-		// check if children is a slice
-		if reflect.TypeOf(viewLayoutConfig["children"]).Kind() != reflect.Slice {
-			return nil, fmt.Errorf("children is not a slice")
+		object, _ := uc.catalogRepo.GetObjectByCode(ctx, objectCode, request.TenantCode)
+		if object.Serial != "" {
+			catalogQuery.ObjectSerial = object.Serial
+			catalogQuery.TenantSerial = object.Tenant.Serial
 		}
 
-		// iterate children
-		for i := 0; i < reflect.ValueOf(viewLayoutConfig["children"]).Len(); i++ {
-			child := reflect.ValueOf(viewLayoutConfig["children"]).Index(i)
-
-			// do recursion
-			childConfig, _ := handleViewLayout(child.Interface().(map[string]any), fields, request)
-
-			if childConfig != nil {
-				// set child config
-				child.Set(reflect.ValueOf(childConfig))
-			}
-		}
-	case "table", "detail", "form":
-		className := ""
-		if _, ok := viewLayoutConfig[entity.CLASS_NAME]; ok {
-			className = viewLayoutConfig[entity.CLASS_NAME].(string)
-		}
+		return uc.describeFields(ctx, catalogQuery)
+	}
+}
 
-		if className == "" {
-			viewLayoutConfig[entity.CLASS_NAME] = fmt.Sprintf("%s__%s", viewLayoutConfig["type"], request.ObjectCode)
-		}
+// loadFieldInputs returns the object field loader of the form components, object fields carry the input settings of a field
+func (uc *viewUsecase) loadFieldInputs(request entity.GetViewContentByKeysRequest) func(ctx context.Context, objectCode string) (map[string]entity.ObjectFields, error) {
+	return func(ctx context.Context, objectCode string) (map[string]entity.ObjectFields, error) {
+		inputs := map[string]entity.ObjectFields{}
 
-		props := map[string]any{}
-		if _, ok := viewLayoutConfig[entity.PROPS]; ok {
-			props = viewLayoutConfig[entity.PROPS].(map[string]any)
+		object, _ := uc.catalogRepo.GetObjectByCode(ctx, objectCode, request.TenantCode)
+		if object.Serial == "" {
+			return inputs, nil
 		}
 
-		isInjectProps := false
-
-		// check if field object code exists, if yes, then check if object code value is the same with the object code in request
-		if objCode, exists := props[entity.OBJECT_CODE]; !exists || objCode.(string) == "" || objCode.(string) == request.ObjectCode {
-			isInjectProps = true
+		objectFields, err := uc.catalogUc.GetObjectFieldsByObjectCode(ctx, entity.CatalogQuery{
+			ObjectCode:   objectCode,
+			ObjectSerial: object.Serial,
+			TenantCode:   request.TenantCode,
+			ProductCode:  request.ProductCode,
+		})
+		if err != nil {
+			return nil, err
 		}
 
-		if isInjectProps {
-			// handle props injection from fields
-			if _, ok := viewLayoutConfig[entity.PROPS]; ok {
-				props := viewLayoutConfig[entity.PROPS].(map[string]any)
-				props[entity.FIELDS] = fields
-				props[entity.OBJECT_CODE] = request.ObjectCode
-				props[entity.TENANT_CODE] = request.TenantCode
-
-				viewLayoutConfig[entity.PROPS] = props
+		for fieldCode, item := range objectFields {
+			if objectField, ok := item.(entity.ObjectFields); ok {
+				inputs[fieldCode] = objectField
 			}
 		}
-	}
 
-	return viewLayoutConfig, nil
+		return inputs, nil
+	}
 }
 
 // Conversion function
@@ -0,0 +1,398 @@
+package module
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/cerkas/cerkas-backend/config"
+	"github.com/cerkas/cerkas-backend/core/entity"
+	"github.com/cerkas/cerkas-backend/core/repository"
+	"github.com/cerkas/cerkas-backend/pkg/helper"
+)
+
+type WebhookUsecase interface {
+	CreateSubscription(ctx context.Context, request entity.WebhookSubscription, userRole string) (resp entity.WebhookSubscription, err error)
+	GetSubscriptions(ctx context.Context, tenantCode, userRole string) (resp []entity.WebhookSubscription, err error)
+	DeleteSubscription(ctx context.Context, tenantCode, serial, userRole string) (err error)
+	GetDeliveries(ctx context.Context, request entity.WebhookDeliveryQuery, userRole string) (resp entity.WebhookDeliveryResponse, err error)
+	HandleRecordEvent(ctx context.Context, event entity.RecordEvent) (err error)
+	DeliverDue(ctx context.Context) (err error)
+	RunDeliveryWorker(ctx context.Context)
+}
+
+type webhookUsecase struct {
+	cfg         config.Config
+	webhookRepo repository.WebhookRepository
+	httpClient  *http.Client
+}
+
+// NewWebhookUsecase creates the webhook usecase, httpClient is optional and defaults to a client with the configured
+// timeout that only connects to public addresses
+func NewWebhookUsecase(cfg config.Config, webhookRepo repository.WebhookRepository, httpClient *http.Client) WebhookUsecase {
+	if httpClient == nil {
+		dialer := &net.Dialer{Timeout: time.Duration(cfg.WebhookTimeout) * time.Second}
+		if !cfg.WebhookAllowPrivateHosts {
+			// checked on connect as well, a host may resolve to another address than it did on subscription
+			dialer.Control = func(network, address string, _ syscall.RawConn) error {
+				host, _, err := net.SplitHostPort(address)
+				if ip := net.ParseIP(host); err != nil || ip == nil || isPrivateAddress(ip) {
+					return fmt.Errorf("%w: %v", entity.ErrorWebhookURLInvalid, address)
+				}
+
+				return nil
+			}
+		}
+
+		httpClient = &http.Client{
+			Timeout:   time.Duration(cfg.WebhookTimeout) * time.Second,
+			Transport: &http.Transport{DialContext: dialer.DialContext},
+		}
+	}
+
+	return &webhookUsecase{
+		cfg:         cfg,
+		webhookRepo: webhookRepo,
+		httpClient:  httpClient,
+	}
+}
+
+func (uc *webhookUsecase) CreateSubscription(ctx context.Context, request entity.WebhookSubscription, userRole string) (resp entity.WebhookSubscription, err error) {
+	if !isAdmin(uc.cfg, userRole) {
+		return resp, entity.ErrorAdminRequired
+	}
+
+	if request.URL == "" {
+		return resp, entity.ErrorWebhookURLEmpty
+	}
+
+	if err := uc.checkURL(ctx, request.URL); err != nil {
+		return resp, err
+	}
+
+	if len(request.EventTypes) == 0 {
+		request.EventTypes = entity.WebhookEventTypes
+	}
+
+	for _, eventType := range request.EventTypes {
+		if !helper.Contains(entity.WebhookEventTypes, eventType) && !helper.Contains(entity.ApprovalEventTypes, eventType) {
+			return resp, entity.ErrorWebhookEventTypeInvalid
+		}
+	}
+
+	request.Serial, err = helper.GenerateUUUID()
+	if err != nil {
+		return resp, err
+	}
+
+	if request.Secret == "" {
+		request.Secret = helper.GenerateSerial(32)
+	}
+
+	request.IsActive = true
+	request.CreatedAt = time.Now()
+
+	// the secret is only returned once, on creation
+	return uc.webhookRepo.CreateSubscription(ctx, request)
+}
+
+func (uc *webhookUsecase) GetSubscriptions(ctx context.Context, tenantCode, userRole string) (resp []entity.WebhookSubscription, err error) {
+	if !isAdmin(uc.cfg, userRole) {
+		return resp, entity.ErrorAdminRequired
+	}
+
+	resp, err = uc.webhookRepo.GetSubscriptions(ctx, tenantCode)
+	if err != nil {
+		return resp, err
+	}
+
+	for i := range resp {
+		resp[i].Secret = ""
+	}
+
+	return resp, nil
+}
+
+func (uc *webhookUsecase) DeleteSubscription(ctx context.Context, tenantCode, serial, userRole string) (err error) {
+	if !isAdmin(uc.cfg, userRole) {
+		return entity.ErrorAdminRequired
+	}
+
+	if serial == "" {
+		return entity.ErrorSerialEmpty
+	}
+
+	return uc.webhookRepo.DeleteSubscription(ctx, tenantCode, serial)
+}
+
+func (uc *webhookUsecase) GetDeliveries(ctx context.Context, request entity.WebhookDeliveryQuery, userRole string) (resp entity.WebhookDeliveryResponse, err error) {
+	if !isAdmin(uc.cfg, userRole) {
+		return resp, entity.ErrorAdminRequired
+	}
+
+	limit, _, _, _ := helper.SetupListParameter(int32(request.Page), int32(request.PageSize))
+
+	items, total, err := uc.webhookRepo.GetDeliveries(ctx, request)
+	if err != nil {
+		return resp, err
+	}
+
+	resp = entity.WebhookDeliveryResponse{
+		Page:      max(request.Page, 1),
+		PageSize:  int(limit),
+		TotalData: int(total),
+		TotalPage: int(helper.GenerateTotalPage(total, int64(limit))),
+		Items:     items,
+	}
+
+	return resp, nil
+}
+
+// checkURL accepts http and https urls of public hosts, loopback, link-local and private addresses are refused unless
+// WEBHOOK_ALLOW_PRIVATE_HOSTS is set so a subscription can not reach into the internal network
+func (uc *webhookUsecase) checkURL(ctx context.Context, rawURL string) error {
+	parsedURL, err := url.ParseRequestURI(rawURL)
+	if err != nil || (parsedURL.Scheme != "http" && parsedURL.Scheme != "https") || parsedURL.Hostname() == "" {
+		return fmt.Errorf("%w: %v", entity.ErrorWebhookURLInvalid, rawURL)
+	}
+
+	if uc.cfg.WebhookAllowPrivateHosts {
+		return nil
+	}
+
+	addresses, err := net.DefaultResolver.LookupIPAddr(ctx, parsedURL.Hostname())
+	if err != nil {
+		return fmt.Errorf("%w: %v", entity.ErrorWebhookURLInvalid, err)
+	}
+
+	for _, address := range addresses {
+		if isPrivateAddress(address.IP) {
+			return fmt.Errorf("%w: %v resolves to %v", entity.ErrorWebhookURLInvalid, parsedURL.Hostname(), address.IP)
+		}
+	}
+
+	return nil
+}
+
+// isPrivateAddress reports whether an address belongs to the host itself or to an internal network
+func isPrivateAddress(ip net.IP) bool {
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsUnspecified()
+}
+
+// HandleRecordEvent writes one pending delivery per matching subscription into the outbox. Filters are evaluated
+// against the record after the mutation, or its last values when it was deleted.
+func (uc *webhookUsecase) HandleRecordEvent(ctx context.Context, event entity.RecordEvent) (err error) {
+	action := event.Action()
+
+	subscriptions, err := uc.webhookRepo.GetActiveSubscriptions(ctx, event.TenantCode, event.ObjectCode, action)
+	if err != nil {
+		return err
+	}
+
+	if len(subscriptions) == 0 {
+		return nil
+	}
+
+	payload := entity.WebhookPayload{
+		Event:        action,
+		TenantCode:   event.TenantCode,
+		ProductCode:  event.ProductCode,
+		ObjectCode:   event.ObjectCode,
+		RecordSerial: event.RecordSerial,
+		ActorSerial:  event.ActorSerial,
+		Data:         event.Data,
+		OldData:      event.OldData,
+		OccurredAt:   event.OccurredAt,
+	}
+
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	values := dataItemValues(event.Data)
+	now := time.Now()
+
+	deliveries := []entity.WebhookDelivery{}
+	for _, subscription := range subscriptions {
+		if !matchFilters(subscription.Filters, values) {
+			continue
+		}
+
+		serial, err := helper.GenerateUUUID()
+		if err != nil {
+			return err
+		}
+
+		deliveries = append(deliveries, entity.WebhookDelivery{
+			Serial:             serial,
+			EventSerial:        event.Serial,
+			SubscriptionSerial: subscription.Serial,
+			TenantCode:         event.TenantCode,
+			EventType:          action,
+			Payload:            string(payloadBytes),
+			Status:             entity.WebhookDeliveryStatusPending,
+			NextAttemptAt:      now,
+			CreatedAt:          now,
+		})
+	}
+
+	return uc.webhookRepo.CreateDeliveries(ctx, deliveries)
+}
+
+// DeliverDue sends a batch of due deliveries from the outbox
+func (uc *webhookUsecase) DeliverDue(ctx context.Context) (err error) {
+	lease := uc.httpClient.Timeout + time.Minute
+
+	deliveries, err := uc.webhookRepo.ClaimDueDeliveries(ctx, uc.cfg.WebhookBatchSize, lease)
+	if err != nil {
+		return err
+	}
+
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	subscriptionSerials := []string{}
+	for _, delivery := range deliveries {
+		if !helper.Contains(subscriptionSerials, delivery.SubscriptionSerial) {
+			subscriptionSerials = append(subscriptionSerials, delivery.SubscriptionSerial)
+		}
+	}
+
+	subscriptions, err := uc.webhookRepo.GetSubscriptionsBySerials(ctx, subscriptionSerials)
+	if err != nil {
+		return err
+	}
+
+	subscriptionMap := map[string]entity.WebhookSubscription{}
+	for _, subscription := range subscriptions {
+		subscriptionMap[subscription.Serial] = subscription
+	}
+
+	for _, delivery := range deliveries {
+		subscription, ok := subscriptionMap[delivery.SubscriptionSerial]
+		if !ok || !subscription.IsActive {
+			delivery.Status = entity.WebhookDeliveryStatusDead
+			delivery.LastError = "subscription is deleted or inactive"
+		} else {
+			delivery = uc.deliver(ctx, subscription, delivery)
+		}
+
+		if err := uc.webhookRepo.UpdateDelivery(ctx, delivery); err != nil {
+			log.Printf("failed to update webhook delivery %v: %v", delivery.Serial, err)
+		}
+	}
+
+	return nil
+}
+
+// RunDeliveryWorker polls the outbox until ctx is done
+func (uc *webhookUsecase) RunDeliveryWorker(ctx context.Context) {
+	interval := time.Duration(uc.cfg.WebhookWorkerInterval) * time.Second
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := uc.DeliverDue(ctx); err != nil {
+				log.Println("failed to deliver webhooks:", err)
+			}
+		}
+	}
+}
+
+// deliver sends a signed delivery and returns it with the attempt recorded
+func (uc *webhookUsecase) deliver(ctx context.Context, subscription entity.WebhookSubscription, delivery entity.WebhookDelivery) entity.WebhookDelivery {
+	delivery.Attempts++
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	signature := helper.SignHMACSHA256(subscription.Secret, []byte(timestamp+"."+delivery.Payload))
+
+	statusCode, err := uc.send(ctx, subscription.URL, delivery, timestamp, signature)
+	delivery.ResponseStatus = statusCode
+
+	if err == nil {
+		now := time.Now()
+		delivery.Status = entity.WebhookDeliveryStatusDelivered
+		delivery.DeliveredAt = &now
+		delivery.LastError = ""
+
+		return delivery
+	}
+
+	delivery.LastError = err.Error()
+
+	if delivery.Attempts >= uc.cfg.WebhookMaxAttempts {
+		delivery.Status = entity.WebhookDeliveryStatusDead
+		return delivery
+	}
+
+	// exponential backoff: base, 2*base, 4*base, ...
+	delay := time.Duration(uc.cfg.WebhookRetryBaseDelay) * time.Second << (delivery.Attempts - 1)
+	delivery.Status = entity.WebhookDeliveryStatusRetrying
+	delivery.NextAttemptAt = time.Now().Add(delay)
+
+	return delivery
+}
+
+func (uc *webhookUsecase) send(ctx context.Context, targetURL string, delivery entity.WebhookDelivery, timestamp, signature string) (statusCode int, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, targetURL, bytes.NewBufferString(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(entity.WebhookSignatureHeader, "sha256="+signature)
+	req.Header.Set(entity.WebhookTimestampHeader, timestamp)
+	req.Header.Set(entity.WebhookEventHeader, delivery.EventType)
+	req.Header.Set(entity.WebhookDeliveryHeader, delivery.Serial)
+
+	res, err := uc.httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+
+	// drain the body so the connection can be reused
+	io.Copy(io.Discard, io.LimitReader(res.Body, 1<<16))
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return res.StatusCode, fmt.Errorf("receiver responded with status %v", res.StatusCode)
+	}
+
+	return res.StatusCode, nil
+}
+
+// normalizeDataItems converts the values of a record into json friendly values
+func normalizeDataItems(items map[string]entity.DataItem) map[string]entity.DataItem {
+	if len(items) == 0 {
+		return nil
+	}
+
+	resp := make(map[string]entity.DataItem, len(items))
+	for key, item := range items {
+		item.Value = normalizeValue(item.Value)
+		item.DisplayValue = normalizeValue(item.DisplayValue)
+		resp[key] = item
+	}
+
+	return resp
+}
@@ -0,0 +1,300 @@
+package module
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cerkas/cerkas-backend/config"
+	"github.com/cerkas/cerkas-backend/core/entity"
+)
+
+const webhookTestSecret = "whsec-test"
+
+// webhookReceiver records the requests it gets and answers them with status
+type webhookReceiver struct {
+	mu       sync.Mutex
+	status   int
+	requests []receivedWebhook
+}
+
+type receivedWebhook struct {
+	header http.Header
+	body   []byte
+}
+
+func (r *webhookReceiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.requests = append(r.requests, receivedWebhook{header: req.Header.Clone(), body: body})
+
+	w.WriteHeader(r.status)
+}
+
+func (r *webhookReceiver) setStatus(status int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.status = status
+}
+
+func (r *webhookReceiver) received() []receivedWebhook {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]receivedWebhook{}, r.requests...)
+}
+
+func newWebhookTest(t *testing.T, status int, isActive bool) (*webhookReceiver, *fakeWebhookRepository, WebhookUsecase) {
+	t.Helper()
+
+	receiver := &webhookReceiver{status: status}
+	server := httptest.NewServer(receiver)
+	t.Cleanup(server.Close)
+
+	webhookRepo := &fakeWebhookRepository{
+		subscriptions: []entity.WebhookSubscription{{
+			Serial:     "subscription-1",
+			TenantCode: "acme",
+			ObjectCode: "invoice",
+			EventTypes: []string{entity.AuditActionUpdate},
+			URL:        server.URL,
+			Secret:     webhookTestSecret,
+			IsActive:   isActive,
+		}},
+		deliveries: []entity.WebhookDelivery{{
+			Serial:             "delivery-1",
+			EventSerial:        "event-1",
+			SubscriptionSerial: "subscription-1",
+			TenantCode:         "acme",
+			EventType:          entity.AuditActionUpdate,
+			Payload:            `{"event":"update","record_serial":"invoice-1"}`,
+			Status:             entity.WebhookDeliveryStatusPending,
+			NextAttemptAt:      time.Now().Add(-time.Second),
+		}},
+	}
+
+	cfg := config.Config{
+		WebhookBatchSize:      10,
+		WebhookMaxAttempts:    3,
+		WebhookRetryBaseDelay: 30,
+	}
+
+	return receiver, webhookRepo, NewWebhookUsecase(cfg, webhookRepo, server.Client())
+}
+
+// makeDue moves the next attempt of a retrying delivery into the past, as if the backoff had elapsed
+func (r *fakeWebhookRepository) makeDue(serial string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.deliveries {
+		if r.deliveries[i].Serial == serial {
+			r.deliveries[i].NextAttemptAt = time.Now().Add(-time.Second)
+		}
+	}
+}
+
+func TestDeliverDueSignsTheBody(t *testing.T) {
+	receiver, webhookRepo, uc := newWebhookTest(t, http.StatusNoContent, true)
+
+	if err := uc.DeliverDue(context.Background()); err != nil {
+		t.Fatalf("DeliverDue: %v", err)
+	}
+
+	requests := receiver.received()
+	if len(requests) != 1 {
+		t.Fatalf("expected 1 request, got %v", len(requests))
+	}
+
+	request := requests[0]
+	mac := hmac.New(sha256.New, []byte(webhookTestSecret))
+	mac.Write([]byte(request.header.Get(entity.WebhookTimestampHeader) + "." + string(request.body)))
+	want := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+
+	if got := request.header.Get(entity.WebhookSignatureHeader); !hmac.Equal([]byte(got), []byte(want)) {
+		t.Errorf("signature %v does not match the body, want %v", got, want)
+	}
+
+	if got := request.header.Get(entity.WebhookEventHeader); got != entity.AuditActionUpdate {
+		t.Errorf("event header is %v, want %v", got, entity.AuditActionUpdate)
+	}
+
+	if got := request.header.Get(entity.WebhookDeliveryHeader); got != "delivery-1" {
+		t.Errorf("delivery header is %v, want delivery-1", got)
+	}
+
+	delivery := webhookRepo.delivery("delivery-1")
+	if delivery.Status != entity.WebhookDeliveryStatusDelivered || delivery.DeliveredAt == nil {
+		t.Errorf("delivery is %v, want delivered with a delivered_at", delivery.Status)
+	}
+
+	if delivery.Attempts != 1 || delivery.ResponseStatus != http.StatusNoContent {
+		t.Errorf("delivery has %v attempts and status %v, want 1 and %v", delivery.Attempts, delivery.ResponseStatus, http.StatusNoContent)
+	}
+}
+
+func TestDeliverDueRetriesWithBackoffUntilDead(t *testing.T) {
+	receiver, webhookRepo, uc := newWebhookTest(t, http.StatusInternalServerError, true)
+
+	for attempt := 1; attempt <= 2; attempt++ {
+		before := time.Now()
+		if err := uc.DeliverDue(context.Background()); err != nil {
+			t.Fatalf("DeliverDue: %v", err)
+		}
+
+		delivery := webhookRepo.delivery("delivery-1")
+		if delivery.Status != entity.WebhookDeliveryStatusRetrying || delivery.Attempts != attempt {
+			t.Fatalf("attempt %v: delivery is %v after %v attempts, want retrying", attempt, delivery.Status, delivery.Attempts)
+		}
+
+		if delivery.ResponseStatus != http.StatusInternalServerError || delivery.LastError == "" {
+			t.Errorf("attempt %v: the failed response is not recorded", attempt)
+		}
+
+		// 30s, then 60s
+		delay := 30 * time.Second << (attempt - 1)
+		if delivery.NextAttemptAt.Before(before.Add(delay)) || delivery.NextAttemptAt.After(time.Now().Add(delay)) {
+			t.Errorf("attempt %v: next attempt at %v, want %v from now", attempt, delivery.NextAttemptAt, delay)
+		}
+
+		// the delivery is not due before its backoff elapsed
+		if err := uc.DeliverDue(context.Background()); err != nil {
+			t.Fatalf("DeliverDue: %v", err)
+		}
+
+		if len(receiver.received()) != attempt {
+			t.Fatalf("a retrying delivery was sent before its next attempt")
+		}
+
+		webhookRepo.makeDue("delivery-1")
+	}
+
+	if err := uc.DeliverDue(context.Background()); err != nil {
+		t.Fatalf("DeliverDue: %v", err)
+	}
+
+	delivery := webhookRepo.delivery("delivery-1")
+	if delivery.Status != entity.WebhookDeliveryStatusDead || delivery.Attempts != 3 {
+		t.Fatalf("delivery is %v after %v attempts, want dead after 3", delivery.Status, delivery.Attempts)
+	}
+
+	if len(receiver.received()) != 3 {
+		t.Errorf("expected 3 requests, got %v", len(receiver.received()))
+	}
+}
+
+func TestDeliverDueRecoversAfterRetry(t *testing.T) {
+	receiver, webhookRepo, uc := newWebhookTest(t, http.StatusBadGateway, true)
+
+	if err := uc.DeliverDue(context.Background()); err != nil {
+		t.Fatalf("DeliverDue: %v", err)
+	}
+
+	receiver.setStatus(http.StatusOK)
+	webhookRepo.makeDue("delivery-1")
+
+	if err := uc.DeliverDue(context.Background()); err != nil {
+		t.Fatalf("DeliverDue: %v", err)
+	}
+
+	delivery := webhookRepo.delivery("delivery-1")
+	if delivery.Status != entity.WebhookDeliveryStatusDelivered || delivery.Attempts != 2 || delivery.LastError != "" {
+		t.Errorf("delivery is %v after %v attempts with error %q, want delivered after 2", delivery.Status, delivery.Attempts, delivery.LastError)
+	}
+}
+
+func TestDeliverDueDropsDisabledSubscriptions(t *testing.T) {
+	receiver, webhookRepo, uc := newWebhookTest(t, http.StatusOK, false)
+
+	if err := uc.DeliverDue(context.Background()); err != nil {
+		t.Fatalf("DeliverDue: %v", err)
+	}
+
+	if len(receiver.received()) != 0 {
+		t.Errorf("a disabled subscription received %v requests", len(receiver.received()))
+	}
+
+	delivery := webhookRepo.delivery("delivery-1")
+	if delivery.Status != entity.WebhookDeliveryStatusDead || delivery.Attempts != 0 {
+		t.Errorf("delivery is %v after %v attempts, want dead without an attempt", delivery.Status, delivery.Attempts)
+	}
+}
+
+func TestCreateSubscriptionIsKeptToAdmins(t *testing.T) {
+	uc := NewWebhookUsecase(config.Config{AdminRole: "admin"}, &fakeWebhookRepository{}, nil)
+
+	for _, userRole := range []string{"", "sales"} {
+		_, err := uc.CreateSubscription(context.Background(), entity.WebhookSubscription{TenantCode: "acme", URL: "https://example.com/hook"}, userRole)
+		if !errors.Is(err, entity.ErrorAdminRequired) {
+			t.Errorf("role %q subscribed, err %v", userRole, err)
+		}
+	}
+
+	if _, err := uc.GetSubscriptions(context.Background(), "acme", ""); !errors.Is(err, entity.ErrorAdminRequired) {
+		t.Errorf("an anonymous request listed the subscriptions, err %v", err)
+	}
+
+	if err := uc.DeleteSubscription(context.Background(), "acme", "subscription-1", "sales"); !errors.Is(err, entity.ErrorAdminRequired) {
+		t.Errorf("a sales role deleted a subscription, err %v", err)
+	}
+
+	if _, err := uc.GetDeliveries(context.Background(), entity.WebhookDeliveryQuery{TenantCode: "acme"}, ""); !errors.Is(err, entity.ErrorAdminRequired) {
+		t.Errorf("an anonymous request listed the deliveries, err %v", err)
+	}
+}
+
+func TestCreateSubscriptionRefusesInternalHosts(t *testing.T) {
+	tests := []struct {
+		url          string
+		allowPrivate bool
+		wantErr      error
+	}{
+		{"ftp://93.184.215.14/hook", false, entity.ErrorWebhookURLInvalid},
+		{"/hook", false, entity.ErrorWebhookURLInvalid},
+		{"http://127.0.0.1:8080/hook", false, entity.ErrorWebhookURLInvalid},
+		{"http://[::1]/hook", false, entity.ErrorWebhookURLInvalid},
+		{"http://10.0.0.5/hook", false, entity.ErrorWebhookURLInvalid},
+		{"http://192.168.1.10/hook", false, entity.ErrorWebhookURLInvalid},
+		{"http://169.254.169.254/latest/meta-data", false, entity.ErrorWebhookURLInvalid},
+		{"http://0.0.0.0/hook", false, entity.ErrorWebhookURLInvalid},
+		{"https://93.184.215.14/hook", false, nil},
+		{"http://127.0.0.1:8080/hook", true, nil},
+	}
+
+	for _, test := range tests {
+		cfg := config.Config{AdminRole: "admin", WebhookAllowPrivateHosts: test.allowPrivate}
+		uc := NewWebhookUsecase(cfg, &fakeWebhookRepository{}, nil)
+
+		_, err := uc.CreateSubscription(context.Background(), entity.WebhookSubscription{TenantCode: "acme", URL: test.url}, "admin")
+		if !errors.Is(err, test.wantErr) {
+			t.Errorf("subscribing %v (private allowed %v) returned %v, want %v", test.url, test.allowPrivate, err, test.wantErr)
+		}
+	}
+}
+
+func TestDeliverDueDoesNotConnectToInternalHosts(t *testing.T) {
+	receiver, webhookRepo, _ := newWebhookTest(t, http.StatusOK, true)
+
+	// the default client refuses the loopback address of the receiver, as it would a host rebound to it
+	uc := NewWebhookUsecase(config.Config{WebhookBatchSize: 10, WebhookMaxAttempts: 3, WebhookRetryBaseDelay: 30, WebhookTimeout: 5}, webhookRepo, nil)
+	if err := uc.DeliverDue(context.Background()); err != nil {
+		t.Fatalf("DeliverDue: %v", err)
+	}
+
+	if len(receiver.received()) != 0 {
+		t.Fatalf("the receiver on a loopback address got %v requests", len(receiver.received()))
+	}
+
+	if delivery := webhookRepo.delivery("delivery-1"); delivery.Status != entity.WebhookDeliveryStatusRetrying {
+		t.Errorf("delivery is %v, want retrying", delivery.Status)
+	}
+}
@@ -0,0 +1,19 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/cerkas/cerkas-backend/core/entity"
+)
+
+type ApprovalRepository interface {
+	CreatePolicy(ctx context.Context, policy entity.ApprovalPolicy) (resp entity.ApprovalPolicy, err error)
+	GetPolicies(ctx context.Context, tenantCode, objectCode string) (resp []entity.ApprovalPolicy, err error)
+	GetPolicy(ctx context.Context, serial string) (resp entity.ApprovalPolicy, err error)
+	GetActivePolicies(ctx context.Context, tenantCode, objectCode, eventType string) (resp []entity.ApprovalPolicy, err error)
+	DeletePolicy(ctx context.Context, tenantCode, serial string) (err error)
+	CreateChangeRequest(ctx context.Context, changeRequest entity.ChangeRequest) (resp entity.ChangeRequest, err error)
+	GetChangeRequests(ctx context.Context, request entity.ChangeRequestQuery) (resp []entity.ChangeRequest, err error)
+	GetChangeRequestForUpdate(ctx context.Context, tenantCode, serial string) (resp entity.ChangeRequest, err error)
+	UpdateChangeRequest(ctx context.Context, changeRequest entity.ChangeRequest) (err error)
+}
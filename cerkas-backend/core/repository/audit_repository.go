@@ -0,0 +1,12 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/cerkas/cerkas-backend/core/entity"
+)
+
+type AuditRepository interface {
+	CreateAuditLog(ctx context.Context, auditLog entity.AuditLog) (err error)
+	GetAuditLogsByRecord(ctx context.Context, tenantCode, objectCode, recordSerial string) (resp []entity.AuditLog, err error)
+}
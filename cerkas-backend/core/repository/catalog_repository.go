@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/cerkas/cerkas-backend/core/entity"
 )
@@ -9,11 +10,25 @@ import (
 type CatalogRepository interface {
 	GetColumnList(ctx context.Context, request entity.CatalogQuery) (columns []map[string]interface{}, columnStrings string, joinQueryMap map[string]string, joinQueryOrder []string, err error)
 	GetObjectData(ctx context.Context, request entity.CatalogQuery) (resp entity.CatalogResponse, err error)
+	GetGroupCounts(ctx context.Context, request entity.CatalogQuery, fieldName string) (resp []entity.GroupCount, err error)
 	GetObjectDetail(ctx context.Context, request entity.CatalogQuery) (resp map[string]entity.DataItem, err error)
 	GetDataByRawQuery(ctx context.Context, request entity.CatalogQuery) (resp entity.CatalogResponse, err error)
 	CreateObjectData(ctx context.Context, request entity.DataMutationRequest) (resp entity.CatalogResponse, err error)
 	UpdateObjectData(ctx context.Context, request entity.DataMutationRequest) (resp entity.CatalogResponse, err error)
 	DeleteObjectData(ctx context.Context, request entity.DataMutationRequest) (err error)
+	RestoreObjectData(ctx context.Context, request entity.DataMutationRequest) (err error)
+	GetReverseRelations(ctx context.Context, tenantCode, objectCode string) (resp []entity.ReverseRelation, err error)
+	GetReferenceTarget(ctx context.Context, tenantCode, objectCode, fieldCode string) (resp entity.ForeignKeyInfo, err error)
+	GetForeignKeyInfo(ctx context.Context, tableName, columnName, schemaName string) (resp entity.ForeignKeyInfo, err error)
+	GetMaterializedRollups(ctx context.Context, tenantCode, objectCode string) (resp []entity.RollupField, err error)
+	RefreshRollup(ctx context.Context, tenantCode string, rollup entity.RollupField, values []string) (err error)
+	RefreshObjectRollups(ctx context.Context, tenantCode, objectCode string) (resp []string, err error)
+	PurgeDeletedData(ctx context.Context, tenantCode, objectCode string, before time.Time, fieldCodes []string) (purged []map[string]any, err error)
+	SnapshotQuery(ctx context.Context, tenantCode, objectCode, rawQuery, targetTable string) (rows int64, err error)
+	GetFileFields(ctx context.Context, tenantCode, objectCode string) (resp []entity.FileField, err error)
+	GetTenantLocale(ctx context.Context, tenantCode string) (resp entity.TenantLocale, err error)
+	GetObjectsByTenant(ctx context.Context, tenantCode string) (resp []entity.Objects, err error)
+	GetMetadataVersion(ctx context.Context, tenantCode string) (version string, err error)
 	GetObjectFieldsByObjectCode(ctx context.Context, request entity.CatalogQuery) (resp map[string]any, err error)
 	GetObjectByCode(ctx context.Context, objectCode, tenantCode string) (resp entity.Objects, err error)
 	GetDataTypeBySerial(ctx context.Context, serial string) (resp entity.DataType, err error)
@@ -0,0 +1,11 @@
+package repository
+
+import (
+	"context"
+)
+
+// ChangeFeedRepository broadcasts record events to every replica of the backend
+type ChangeFeedRepository interface {
+	Notify(ctx context.Context, eventID int64) (err error)
+	Listen(ctx context.Context, handler func(eventID int64)) (err error)
+}
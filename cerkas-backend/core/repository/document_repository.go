@@ -0,0 +1,14 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/cerkas/cerkas-backend/core/entity"
+)
+
+type DocumentRepository interface {
+	CreateTemplate(ctx context.Context, template entity.DocumentTemplate) (resp entity.DocumentTemplate, err error)
+	GetTemplates(ctx context.Context, tenantCode, objectCode string) (resp []entity.DocumentTemplate, err error)
+	GetTemplateByCode(ctx context.Context, tenantCode, objectCode, code string) (resp entity.DocumentTemplate, err error)
+	DeleteTemplate(ctx context.Context, tenantCode, serial string) (err error)
+}
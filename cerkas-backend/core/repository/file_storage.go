@@ -0,0 +1,14 @@
+package repository
+
+import (
+	"context"
+	"io"
+)
+
+// FileStorage keeps the content of attachments by key. Keys are slash separated paths, Delete of a missing key is
+// not an error so a cleanup can be retried.
+type FileStorage interface {
+	Save(ctx context.Context, key string, content io.Reader) (size int64, err error)
+	Open(ctx context.Context, key string) (content io.ReadCloser, err error)
+	Delete(ctx context.Context, key string) (err error)
+}
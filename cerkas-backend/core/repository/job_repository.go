@@ -0,0 +1,18 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/cerkas/cerkas-backend/core/entity"
+)
+
+type JobRepository interface {
+	CreateJob(ctx context.Context, job entity.Job) (resp entity.Job, err error)
+	GetJob(ctx context.Context, tenantCode, serial string) (resp entity.Job, err error)
+	GetJobs(ctx context.Context, request entity.JobQuery) (resp []entity.Job, total int64, err error)
+	ClaimJob(ctx context.Context, lease time.Duration) (resp entity.Job, claimed bool, err error)
+	UpdateJobProgress(ctx context.Context, job entity.Job, lease time.Duration) (cancelRequested bool, err error)
+	FinishJob(ctx context.Context, job entity.Job) (err error)
+	CancelJob(ctx context.Context, tenantCode, serial string) (resp entity.Job, err error)
+}
@@ -0,0 +1,9 @@
+package repository
+
+import "context"
+
+// Locker takes locks shared by every replica of the backend. A lock is held until release is called or the
+// replica holding it goes away, TryLock does not wait for a lock held elsewhere.
+type Locker interface {
+	TryLock(ctx context.Context, key string) (release func(), acquired bool, err error)
+}
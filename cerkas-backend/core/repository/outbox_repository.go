@@ -0,0 +1,15 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/cerkas/cerkas-backend/core/entity"
+)
+
+type OutboxRepository interface {
+	CreateEvents(ctx context.Context, events []entity.RecordEvent) (err error)
+	ClaimEvents(ctx context.Context, limit int, lease time.Duration) (resp []entity.OutboxEvent, err error)
+	UpdateEvent(ctx context.Context, event entity.OutboxEvent) (err error)
+	GetEvent(ctx context.Context, id int64) (resp entity.RecordEvent, err error)
+}
@@ -0,0 +1,14 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/cerkas/cerkas-backend/core/entity"
+)
+
+type RuleRepository interface {
+	CreateRule(ctx context.Context, rule entity.ObjectRule) (resp entity.ObjectRule, err error)
+	GetRules(ctx context.Context, tenantCode, objectCode string) (resp []entity.ObjectRule, err error)
+	GetActiveRules(ctx context.Context, tenantCode, objectCode, timing, eventType string) (resp []entity.ObjectRule, err error)
+	DeleteRule(ctx context.Context, tenantCode, serial string) (err error)
+}
@@ -0,0 +1,19 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/cerkas/cerkas-backend/core/entity"
+)
+
+type ScheduleRepository interface {
+	CreateJob(ctx context.Context, job entity.ScheduledJob) (resp entity.ScheduledJob, err error)
+	GetJobs(ctx context.Context, tenantCode string) (resp []entity.ScheduledJob, err error)
+	DeleteJob(ctx context.Context, tenantCode, serial string) (err error)
+	GetDueJobs(ctx context.Context, now time.Time, limit int) (resp []entity.ScheduledJob, err error)
+	AdvanceJob(ctx context.Context, job entity.ScheduledJob, nextRunAt time.Time) (advanced bool, err error)
+	CreateRun(ctx context.Context, run entity.ScheduledJobRun) (resp entity.ScheduledJobRun, err error)
+	UpdateRun(ctx context.Context, run entity.ScheduledJobRun) (err error)
+	GetRuns(ctx context.Context, request entity.ScheduledJobRunQuery) (resp []entity.ScheduledJobRun, total int64, err error)
+}
@@ -0,0 +1,9 @@
+package repository
+
+import "context"
+
+// Transactor runs fn inside a database transaction. Repositories pick the transaction up from the context,
+// so every write made with the context passed to fn commits or rolls back together.
+type Transactor interface {
+	WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) (err error)
+}
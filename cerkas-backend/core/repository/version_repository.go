@@ -0,0 +1,16 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/cerkas/cerkas-backend/core/entity"
+)
+
+type VersionRepository interface {
+	CreateRecordVersion(ctx context.Context, version entity.RecordVersion) (err error)
+	GetRecordVersions(ctx context.Context, tenantCode, objectCode, recordSerial string) (resp []entity.RecordVersion, err error)
+	GetRecordVersion(ctx context.Context, tenantCode, objectCode, recordSerial string, version int) (resp entity.RecordVersion, err error)
+	GetRecordVersionAsOf(ctx context.Context, tenantCode, objectCode, recordSerial string, asOf time.Time) (resp entity.RecordVersion, err error)
+	GetObjectVersionsAsOf(ctx context.Context, tenantCode, objectCode string, asOf time.Time) (resp []entity.RecordVersion, err error)
+}
@@ -8,4 +8,28 @@ import (
 
 type ViewRepository interface {
 	GetViewContentByKeys(ctx context.Context, request entity.GetViewContentByKeysRequest) (resp map[string]entity.DataItem, err error)
+	GetViewContentChain(ctx context.Context, request entity.GetViewContentByKeysRequest) (resp []entity.ViewContentRecord, err error)
+
+	GetViewContents(ctx context.Context, tenantCode, productCode, objectCode, layoutType, ownerSerial string) (resp []entity.ViewContentRecord, err error)
+	GetAvailableViewContents(ctx context.Context, tenantCode, productCode, objectCode, userSerial, userRole string) (resp []entity.ViewContentRecord, err error)
+	GetViewContent(ctx context.Context, tenantCode, productCode, objectCode, code, layoutType string) (resp entity.ViewContentRecord, err error)
+	CreateViewContent(ctx context.Context, record entity.ViewContentRecord, userSerial string) (resp entity.ViewContentRecord, err error)
+	UpdateViewContent(ctx context.Context, record entity.ViewContentRecord, userSerial string) (resp entity.ViewContentRecord, err error)
+	DeleteViewContent(ctx context.Context, serial, userSerial string) (err error)
+	SetDefaultViewContent(ctx context.Context, record entity.ViewContentRecord, userSerial string) (err error)
+	IsViewSchemaUsed(ctx context.Context, serial string) (used bool, err error)
+	IsViewLayoutUsed(ctx context.Context, serial string) (used bool, err error)
+	GetFavoriteViewSerials(ctx context.Context, userSerial string) (resp []string, err error)
+	AddViewFavorite(ctx context.Context, userSerial, viewContentSerial string) (err error)
+	RemoveViewFavorite(ctx context.Context, userSerial, viewContentSerial string) (err error)
+
+	GetViewSchema(ctx context.Context, serial string) (resp entity.ViewSchema, err error)
+	CreateViewSchema(ctx context.Context, schema entity.ViewSchema, userSerial string) (resp entity.ViewSchema, err error)
+	UpdateViewSchema(ctx context.Context, schema entity.ViewSchema, userSerial string) (resp entity.ViewSchema, err error)
+	DeleteViewSchema(ctx context.Context, serial, userSerial string) (err error)
+
+	GetViewLayout(ctx context.Context, serial string) (resp entity.ViewLayout, err error)
+	CreateViewLayout(ctx context.Context, layout entity.ViewLayout, userSerial string) (resp entity.ViewLayout, err error)
+	UpdateViewLayout(ctx context.Context, layout entity.ViewLayout, userSerial string) (resp entity.ViewLayout, err error)
+	DeleteViewLayout(ctx context.Context, serial, userSerial string) (err error)
 }
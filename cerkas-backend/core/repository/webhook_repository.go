@@ -0,0 +1,20 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/cerkas/cerkas-backend/core/entity"
+)
+
+type WebhookRepository interface {
+	CreateSubscription(ctx context.Context, subscription entity.WebhookSubscription) (resp entity.WebhookSubscription, err error)
+	GetSubscriptions(ctx context.Context, tenantCode string) (resp []entity.WebhookSubscription, err error)
+	GetSubscriptionsBySerials(ctx context.Context, serials []string) (resp []entity.WebhookSubscription, err error)
+	GetActiveSubscriptions(ctx context.Context, tenantCode, objectCode, eventType string) (resp []entity.WebhookSubscription, err error)
+	DeleteSubscription(ctx context.Context, tenantCode, serial string) (err error)
+	CreateDeliveries(ctx context.Context, deliveries []entity.WebhookDelivery) (err error)
+	ClaimDueDeliveries(ctx context.Context, limit int, lease time.Duration) (resp []entity.WebhookDelivery, err error)
+	UpdateDelivery(ctx context.Context, delivery entity.WebhookDelivery) (err error)
+	GetDeliveries(ctx context.Context, request entity.WebhookDeliveryQuery) (resp []entity.WebhookDelivery, total int64, err error)
+}
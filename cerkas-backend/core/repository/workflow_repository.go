@@ -0,0 +1,13 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/cerkas/cerkas-backend/core/entity"
+)
+
+type WorkflowRepository interface {
+	GetStateMachines(ctx context.Context, tenantCode, objectCode string) (resp map[string]entity.StateMachine, err error)
+	CreateTransitionLogs(ctx context.Context, logs []entity.StateTransitionLog) (err error)
+	GetTransitionLogs(ctx context.Context, tenantCode, objectCode, recordSerial string) (resp []entity.StateTransitionLog, err error)
+}
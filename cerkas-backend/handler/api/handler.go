@@ -1,8 +1,13 @@
 package api
 
 import (
+	"errors"
+	"io"
 	"log"
+	"mime"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/cerkas/cerkas-backend/config"
 	"github.com/cerkas/cerkas-backend/core/entity"
@@ -16,20 +21,98 @@ type HTTPHandler interface {
 	GetObjectDetail(c *gin.Context)
 	GetDataByRawQuery(c *gin.Context)
 	GetContentLayoutByKeys(c *gin.Context)
+	GetViewResolution(c *gin.Context)
+	LookupFieldValues(c *gin.Context)
 	CreateObjectData(c *gin.Context)
+	UpdateObjectData(c *gin.Context)
+	DeleteObjectData(c *gin.Context)
+	GetRecordHistory(c *gin.Context)
+	GetRecordVersions(c *gin.Context)
+	RestoreRecordVersion(c *gin.Context)
+	GetRecordTransitions(c *gin.Context)
+	GetTransitionHistory(c *gin.Context)
+	CreateWebhookSubscription(c *gin.Context)
+	GetWebhookSubscriptions(c *gin.Context)
+	DeleteWebhookSubscription(c *gin.Context)
+	GetWebhookDeliveries(c *gin.Context)
+	StreamViewChanges(c *gin.Context)
+	CreateViewContent(c *gin.Context)
+	UpdateViewContent(c *gin.Context)
+	DeleteViewContent(c *gin.Context)
+	CloneViewContent(c *gin.Context)
+	CreateViewSchema(c *gin.Context)
+	UpdateViewSchema(c *gin.Context)
+	DeleteViewSchema(c *gin.Context)
+	CloneViewSchema(c *gin.Context)
+	CreateViewLayout(c *gin.Context)
+	UpdateViewLayout(c *gin.Context)
+	DeleteViewLayout(c *gin.Context)
+	CloneViewLayout(c *gin.Context)
+	SavePersonalView(c *gin.Context)
+	GetAvailableViews(c *gin.Context)
+	AddViewFavorite(c *gin.Context)
+	RemoveViewFavorite(c *gin.Context)
+	CreateObjectRule(c *gin.Context)
+	GetObjectRules(c *gin.Context)
+	DeleteObjectRule(c *gin.Context)
+	CreateApprovalPolicy(c *gin.Context)
+	GetApprovalPolicies(c *gin.Context)
+	DeleteApprovalPolicy(c *gin.Context)
+	GetChangeRequests(c *gin.Context)
+	ApproveChangeRequest(c *gin.Context)
+	RejectChangeRequest(c *gin.Context)
+	CreateScheduledJob(c *gin.Context)
+	GetScheduledJobs(c *gin.Context)
+	DeleteScheduledJob(c *gin.Context)
+	GetScheduledJobRuns(c *gin.Context)
+	SubmitJob(c *gin.Context)
+	GetJobs(c *gin.Context)
+	GetJob(c *gin.Context)
+	CancelJob(c *gin.Context)
+	UploadRecordFile(c *gin.Context)
+	DownloadRecordFile(c *gin.Context)
+	DeleteRecordFile(c *gin.Context)
+	GetRecordThumbnail(c *gin.Context)
+	CreateDocumentTemplate(c *gin.Context)
+	GetDocumentTemplates(c *gin.Context)
+	DeleteDocumentTemplate(c *gin.Context)
+	RenderRecordDocument(c *gin.Context)
+	GetOpenAPIDocument(c *gin.Context)
 }
 
 type httpHandler struct {
-	cfg       config.Config
-	catalogUc module.CatalogUsecase
-	viewUc    module.ViewUsecase
+	cfg        config.Config
+	catalogUc  module.CatalogUsecase
+	viewUc     module.ViewUsecase
+	auditUc    module.AuditUsecase
+	versionUc  module.VersionUsecase
+	webhookUc  module.WebhookUsecase
+	streamUc   module.StreamUsecase
+	ruleUc     module.RuleUsecase
+	approvalUc module.ApprovalUsecase
+	scheduleUc module.ScheduleUsecase
+	jobUc      module.JobUsecase
+	fileUc     module.FileUsecase
+	documentUc module.DocumentUsecase
+	openAPIUc  module.OpenAPIUsecase
 }
 
-func NewHTTPHandler(cfg config.Config, catalogUc module.CatalogUsecase, viewUc module.ViewUsecase) HTTPHandler {
+func NewHTTPHandler(cfg config.Config, catalogUc module.CatalogUsecase, viewUc module.ViewUsecase, auditUc module.AuditUsecase, versionUc module.VersionUsecase, webhookUc module.WebhookUsecase, streamUc module.StreamUsecase, ruleUc module.RuleUsecase, approvalUc module.ApprovalUsecase, scheduleUc module.ScheduleUsecase, jobUc module.JobUsecase, fileUc module.FileUsecase, documentUc module.DocumentUsecase, openAPIUc module.OpenAPIUsecase) HTTPHandler {
 	return &httpHandler{
-		cfg:       cfg,
-		catalogUc: catalogUc,
-		viewUc:    viewUc,
+		cfg:        cfg,
+		catalogUc:  catalogUc,
+		viewUc:     viewUc,
+		auditUc:    auditUc,
+		versionUc:  versionUc,
+		webhookUc:  webhookUc,
+		streamUc:   streamUc,
+		ruleUc:     ruleUc,
+		approvalUc: approvalUc,
+		scheduleUc: scheduleUc,
+		jobUc:      jobUc,
+		fileUc:     fileUc,
+		documentUc: documentUc,
+		openAPIUc:  openAPIUc,
 	}
 }
 
@@ -47,9 +130,20 @@ func (h *httpHandler) GetObjectData(c *gin.Context) {
 		return
 	}
 
+	if asOf := c.Query("as_of"); asOf != "" {
+		request.AsOf = asOf
+	}
+
+	request.UserSerial, request.UserRole = requestUser(c)
+
 	response, err := h.catalogUc.GetObjectData(c, request)
 	if err != nil {
 		statusCode = http.StatusInternalServerError
+		if errors.Is(err, entity.ErrorKanbanGroupByEmpty) || errors.Is(err, entity.ErrorKanbanGroupByInvalid) ||
+			errors.Is(err, entity.ErrorCalendarStartEmpty) || errors.Is(err, entity.ErrorCalendarWindowInvalid) ||
+			errors.Is(err, entity.ErrorFilterGroupOperatorInvalid) || errors.Is(err, entity.ErrorFilterOperatorInvalid) {
+			statusCode = http.StatusBadRequest
+		}
 		statusMessage = err.Error()
 
 		log.Println(statusMessage)
@@ -90,6 +184,10 @@ func (h *httpHandler) GetObjectDetail(c *gin.Context) {
 
 	request.Serial = serial
 
+	if asOf := c.Query("as_of"); asOf != "" {
+		request.AsOf = asOf
+	}
+
 	if tenantCode != "" {
 		request.TenantCode = tenantCode
 	}
@@ -105,6 +203,9 @@ func (h *httpHandler) GetObjectDetail(c *gin.Context) {
 	response, err := h.catalogUc.GetObjectDetail(c, request, serial)
 	if err != nil {
 		statusCode = http.StatusInternalServerError
+		if errors.Is(err, entity.ErrorIncludeNotFound) || errors.Is(err, entity.ErrorIncludeAmbiguous) {
+			statusCode = http.StatusBadRequest
+		}
 		statusMessage = err.Error()
 
 		log.Println(statusMessage)
@@ -153,6 +254,8 @@ func (h *httpHandler) GetContentLayoutByKeys(c *gin.Context) {
 	request.ObjectCode = c.Param("object_code")
 	request.ViewContentCode = c.Param("view_content_code")
 	request.LayoutType = c.Param("layout_type")
+	request.RecordSerial = c.Query("serial")
+	request.UserSerial, request.UserRole = requestUser(c)
 
 	catalogQuery := entity.CatalogQuery{
 		TenantCode:      request.TenantCode,
@@ -164,6 +267,9 @@ func (h *httpHandler) GetContentLayoutByKeys(c *gin.Context) {
 	response, err := h.viewUc.GetContentLayoutByKeys(c, request, catalogQuery)
 	if err != nil {
 		statusCode = http.StatusInternalServerError
+		if errors.Is(err, entity.ErrorNotFound) {
+			statusCode = http.StatusNotFound
+		}
 		statusMessage = err.Error()
 
 		log.Println(statusMessage)
@@ -174,10 +280,69 @@ func (h *httpHandler) GetContentLayoutByKeys(c *gin.Context) {
 	helper.ResponseOutput(c, int32(statusCode), statusMessage, response)
 }
 
+func (h *httpHandler) GetViewResolution(c *gin.Context) {
+	var statusCode int32 = entity.DefaultSucessCode
+	var statusMessage string = entity.DefaultSuccessMessage
+
+	response, err := h.viewUc.GetViewResolution(c, viewContentKeys(c))
+	if err != nil {
+		statusCode = viewErrorStatus(err)
+		statusMessage = err.Error()
+
+		log.Println(statusMessage)
+		helper.ResponseOutput(c, statusCode, statusMessage, nil)
+		return
+	}
+
+	helper.ResponseOutput(c, statusCode, statusMessage, response)
+}
+
+func (h *httpHandler) LookupFieldValues(c *gin.Context) {
+	var statusCode int32 = entity.DefaultSucessCode
+	var statusMessage string = entity.DefaultSuccessMessage
+
+	request := entity.LookupQuery{
+		TenantCode:  c.Param("tenant_code"),
+		ProductCode: c.Param("product_code"),
+		ObjectCode:  c.Param("object_code"),
+		FieldCode:   c.Param("field_code"),
+		Search:      c.Query("q"),
+		Filters:     c.QueryMap("filter"),
+	}
+
+	if limit := c.Query("limit"); limit != "" {
+		value, err := strconv.Atoi(limit)
+		if err != nil {
+			statusCode = http.StatusBadRequest
+			statusMessage = err.Error()
+
+			log.Println(statusMessage)
+			helper.ResponseOutput(c, statusCode, statusMessage, nil)
+			return
+		}
+
+		request.Limit = value
+	}
+
+	response, err := h.catalogUc.LookupFieldValues(c, request)
+	if err != nil {
+		statusCode = http.StatusInternalServerError
+		if errors.Is(err, entity.ErrorLookupFieldInvalid) || errors.Is(err, entity.ErrorLookupFilterInvalid) {
+			statusCode = http.StatusBadRequest
+		}
+		statusMessage = err.Error()
+
+		log.Println(statusMessage)
+		helper.ResponseOutput(c, statusCode, statusMessage, nil)
+		return
+	}
+
+	helper.ResponseOutput(c, statusCode, statusMessage, response)
+}
+
 func (h *httpHandler) CreateObjectData(c *gin.Context) {
 	var statusCode int32 = entity.DefaultSucessCode
 	var statusMessage string = entity.DefaultSuccessMessage
-	var defaultUserSerial string = "system"
 
 	request := entity.DataMutationRequest{}
 	if err := c.ShouldBindJSON(&request); err != nil {
@@ -189,9 +354,172 @@ func (h *httpHandler) CreateObjectData(c *gin.Context) {
 		return
 	}
 
-	request.UserSerial = defaultUserSerial
+	request.UserSerial, request.UserRole = requestUser(c)
 
 	response, err := h.catalogUc.CreateObjectData(c, request)
+	if err != nil {
+		if pending, ok := approvalPending(err); ok {
+			helper.ResponseOutput(c, http.StatusAccepted, pending.Error(), pending.ChangeRequest)
+			return
+		}
+
+		statusCode = mutationErrorStatus(err)
+		statusMessage = err.Error()
+
+		log.Println(statusMessage)
+		helper.ResponseOutput(c, int32(statusCode), statusMessage, nil)
+		return
+	}
+
+	helper.ResponseOutput(c, int32(statusCode), statusMessage, response)
+}
+
+func (h *httpHandler) UpdateObjectData(c *gin.Context) {
+	var statusCode int32 = entity.DefaultSucessCode
+	var statusMessage string = entity.DefaultSuccessMessage
+
+	request := entity.DataMutationRequest{}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		statusCode = http.StatusBadRequest
+		statusMessage = err.Error()
+
+		log.Println(statusMessage)
+		helper.ResponseOutput(c, statusCode, statusMessage, nil)
+		return
+	}
+
+	bindMutationParams(c, &request)
+	request.UserSerial, request.UserRole = requestUser(c)
+
+	response, err := h.catalogUc.UpdateObjectData(c, request)
+	if err != nil {
+		if pending, ok := approvalPending(err); ok {
+			helper.ResponseOutput(c, http.StatusAccepted, pending.Error(), pending.ChangeRequest)
+			return
+		}
+
+		statusCode = mutationErrorStatus(err)
+		statusMessage = err.Error()
+
+		log.Println(statusMessage)
+		helper.ResponseOutput(c, int32(statusCode), statusMessage, nil)
+		return
+	}
+
+	helper.ResponseOutput(c, int32(statusCode), statusMessage, response)
+}
+
+func (h *httpHandler) DeleteObjectData(c *gin.Context) {
+	var statusCode int32 = entity.DefaultSucessCode
+	var statusMessage string = entity.DefaultSuccessMessage
+
+	request := entity.DataMutationRequest{}
+	bindMutationParams(c, &request)
+	request.UserSerial, request.UserRole = requestUser(c)
+
+	if err := h.catalogUc.DeleteObjectData(c, request); err != nil {
+		if pending, ok := approvalPending(err); ok {
+			helper.ResponseOutput(c, http.StatusAccepted, pending.Error(), pending.ChangeRequest)
+			return
+		}
+
+		statusCode = mutationErrorStatus(err)
+		statusMessage = err.Error()
+
+		log.Println(statusMessage)
+		helper.ResponseOutput(c, int32(statusCode), statusMessage, nil)
+		return
+	}
+
+	helper.ResponseOutput(c, int32(statusCode), statusMessage, nil)
+}
+
+func (h *httpHandler) GetRecordHistory(c *gin.Context) {
+	var statusCode int32 = entity.DefaultSucessCode
+	var statusMessage string = entity.DefaultSuccessMessage
+
+	request := entity.CatalogQuery{
+		Serial:      c.Param("serial"),
+		TenantCode:  c.Param("tenant_code"),
+		ProductCode: c.Param("product_code"),
+		ObjectCode:  c.Param("object_code"),
+	}
+
+	response, err := h.auditUc.GetRecordHistory(c, request)
+	if err != nil {
+		statusCode = http.StatusInternalServerError
+		statusMessage = err.Error()
+
+		log.Println(statusMessage)
+		helper.ResponseOutput(c, int32(statusCode), statusMessage, nil)
+		return
+	}
+
+	helper.ResponseOutput(c, int32(statusCode), statusMessage, response)
+}
+
+// GetRecordTransitions lists the state transitions the user can run on a record
+func (h *httpHandler) GetRecordTransitions(c *gin.Context) {
+	var statusCode int32 = entity.DefaultSucessCode
+	var statusMessage string = entity.DefaultSuccessMessage
+
+	request := entity.CatalogQuery{
+		Serial:      c.Param("serial"),
+		TenantCode:  c.Param("tenant_code"),
+		ProductCode: c.Param("product_code"),
+		ObjectCode:  c.Param("object_code"),
+	}
+	request.UserSerial, request.UserRole = requestUser(c)
+
+	response, err := h.catalogUc.GetRecordTransitions(c, request)
+	if err != nil {
+		statusCode = mutationErrorStatus(err)
+		statusMessage = err.Error()
+
+		log.Println(statusMessage)
+		helper.ResponseOutput(c, int32(statusCode), statusMessage, nil)
+		return
+	}
+
+	helper.ResponseOutput(c, int32(statusCode), statusMessage, response)
+}
+
+func (h *httpHandler) GetTransitionHistory(c *gin.Context) {
+	var statusCode int32 = entity.DefaultSucessCode
+	var statusMessage string = entity.DefaultSuccessMessage
+
+	request := entity.CatalogQuery{
+		Serial:      c.Param("serial"),
+		TenantCode:  c.Param("tenant_code"),
+		ProductCode: c.Param("product_code"),
+		ObjectCode:  c.Param("object_code"),
+	}
+
+	response, err := h.catalogUc.GetTransitionHistory(c, request)
+	if err != nil {
+		statusCode = http.StatusInternalServerError
+		statusMessage = err.Error()
+
+		log.Println(statusMessage)
+		helper.ResponseOutput(c, int32(statusCode), statusMessage, nil)
+		return
+	}
+
+	helper.ResponseOutput(c, int32(statusCode), statusMessage, response)
+}
+
+func (h *httpHandler) GetRecordVersions(c *gin.Context) {
+	var statusCode int32 = entity.DefaultSucessCode
+	var statusMessage string = entity.DefaultSuccessMessage
+
+	request := entity.CatalogQuery{
+		Serial:      c.Param("serial"),
+		TenantCode:  c.Param("tenant_code"),
+		ProductCode: c.Param("product_code"),
+		ObjectCode:  c.Param("object_code"),
+	}
+
+	response, err := h.versionUc.GetRecordVersions(c, request)
 	if err != nil {
 		statusCode = http.StatusInternalServerError
 		statusMessage = err.Error()
@@ -203,3 +531,1441 @@ func (h *httpHandler) CreateObjectData(c *gin.Context) {
 
 	helper.ResponseOutput(c, int32(statusCode), statusMessage, response)
 }
+
+func (h *httpHandler) RestoreRecordVersion(c *gin.Context) {
+	var statusCode int32 = entity.DefaultSucessCode
+	var statusMessage string = entity.DefaultSuccessMessage
+
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil {
+		statusCode = http.StatusBadRequest
+		statusMessage = err.Error()
+
+		log.Println(statusMessage)
+		helper.ResponseOutput(c, statusCode, statusMessage, nil)
+		return
+	}
+
+	request := entity.RestoreVersionRequest{
+		TenantCode:   c.Param("tenant_code"),
+		ProductCode:  c.Param("product_code"),
+		ObjectCode:   c.Param("object_code"),
+		RecordSerial: c.Param("serial"),
+		Version:      version,
+	}
+	request.UserSerial, request.UserRole = requestUser(c)
+
+	response, err := h.versionUc.RestoreRecordVersion(c, request)
+	if err != nil {
+		if pending, ok := approvalPending(err); ok {
+			helper.ResponseOutput(c, http.StatusAccepted, pending.Error(), pending.ChangeRequest)
+			return
+		}
+
+		statusCode = versionErrorStatus(err)
+		statusMessage = err.Error()
+
+		log.Println(statusMessage)
+		helper.ResponseOutput(c, int32(statusCode), statusMessage, nil)
+		return
+	}
+
+	helper.ResponseOutput(c, int32(statusCode), statusMessage, response)
+}
+
+func (h *httpHandler) CreateWebhookSubscription(c *gin.Context) {
+	var statusCode int32 = entity.DefaultSucessCode
+	var statusMessage string = entity.DefaultSuccessMessage
+
+	request := entity.WebhookSubscription{}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		statusCode = http.StatusBadRequest
+		statusMessage = err.Error()
+
+		log.Println(statusMessage)
+		helper.ResponseOutput(c, statusCode, statusMessage, nil)
+		return
+	}
+
+	request.TenantCode = c.Param("tenant_code")
+
+	var userRole string
+	request.CreatedBy, userRole = requestUser(c)
+
+	response, err := h.webhookUc.CreateSubscription(c, request, userRole)
+	if err != nil {
+		statusCode = webhookErrorStatus(err)
+		statusMessage = err.Error()
+
+		log.Println(statusMessage)
+		helper.ResponseOutput(c, int32(statusCode), statusMessage, nil)
+		return
+	}
+
+	helper.ResponseOutput(c, int32(statusCode), statusMessage, response)
+}
+
+func (h *httpHandler) GetWebhookSubscriptions(c *gin.Context) {
+	var statusCode int32 = entity.DefaultSucessCode
+	var statusMessage string = entity.DefaultSuccessMessage
+
+	_, userRole := requestUser(c)
+
+	response, err := h.webhookUc.GetSubscriptions(c, c.Param("tenant_code"), userRole)
+	if err != nil {
+		statusCode = webhookErrorStatus(err)
+		statusMessage = err.Error()
+
+		log.Println(statusMessage)
+		helper.ResponseOutput(c, int32(statusCode), statusMessage, nil)
+		return
+	}
+
+	helper.ResponseOutput(c, int32(statusCode), statusMessage, response)
+}
+
+func (h *httpHandler) DeleteWebhookSubscription(c *gin.Context) {
+	var statusCode int32 = entity.DefaultSucessCode
+	var statusMessage string = entity.DefaultSuccessMessage
+
+	_, userRole := requestUser(c)
+
+	err := h.webhookUc.DeleteSubscription(c, c.Param("tenant_code"), c.Param("serial"), userRole)
+	if err != nil {
+		statusCode = webhookErrorStatus(err)
+		statusMessage = err.Error()
+
+		log.Println(statusMessage)
+		helper.ResponseOutput(c, int32(statusCode), statusMessage, nil)
+		return
+	}
+
+	helper.ResponseOutput(c, int32(statusCode), statusMessage, nil)
+}
+
+func (h *httpHandler) GetWebhookDeliveries(c *gin.Context) {
+	var statusCode int32 = entity.DefaultSucessCode
+	var statusMessage string = entity.DefaultSuccessMessage
+
+	page, _ := strconv.Atoi(c.Query("page"))
+	pageSize, _ := strconv.Atoi(c.Query("page_size"))
+
+	request := entity.WebhookDeliveryQuery{
+		TenantCode:         c.Param("tenant_code"),
+		SubscriptionSerial: c.Param("serial"),
+		Status:             c.Query("status"),
+		Page:               page,
+		PageSize:           pageSize,
+	}
+
+	_, userRole := requestUser(c)
+
+	response, err := h.webhookUc.GetDeliveries(c, request, userRole)
+	if err != nil {
+		statusCode = webhookErrorStatus(err)
+		statusMessage = err.Error()
+
+		log.Println(statusMessage)
+		helper.ResponseOutput(c, int32(statusCode), statusMessage, nil)
+		return
+	}
+
+	helper.ResponseOutput(c, int32(statusCode), statusMessage, response)
+}
+
+func (h *httpHandler) CreateObjectRule(c *gin.Context) {
+	var statusCode int32 = entity.DefaultSucessCode
+	var statusMessage string = entity.DefaultSuccessMessage
+
+	request := entity.ObjectRule{}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		statusCode = http.StatusBadRequest
+		statusMessage = err.Error()
+
+		log.Println(statusMessage)
+		helper.ResponseOutput(c, statusCode, statusMessage, nil)
+		return
+	}
+
+	request.TenantCode = c.Param("tenant_code")
+	request.ObjectCode = c.Param("object_code")
+	var userRole string
+	request.CreatedBy, userRole = requestUser(c)
+
+	response, err := h.ruleUc.CreateRule(c, request, userRole)
+	if err != nil {
+		statusCode = ruleErrorStatus(err)
+		statusMessage = err.Error()
+
+		log.Println(statusMessage)
+		helper.ResponseOutput(c, int32(statusCode), statusMessage, nil)
+		return
+	}
+
+	helper.ResponseOutput(c, int32(statusCode), statusMessage, response)
+}
+
+func (h *httpHandler) GetObjectRules(c *gin.Context) {
+	var statusCode int32 = entity.DefaultSucessCode
+	var statusMessage string = entity.DefaultSuccessMessage
+
+	response, err := h.ruleUc.GetRules(c, c.Param("tenant_code"), c.Param("object_code"))
+	if err != nil {
+		statusCode = http.StatusInternalServerError
+		statusMessage = err.Error()
+
+		log.Println(statusMessage)
+		helper.ResponseOutput(c, int32(statusCode), statusMessage, nil)
+		return
+	}
+
+	helper.ResponseOutput(c, int32(statusCode), statusMessage, response)
+}
+
+func (h *httpHandler) DeleteObjectRule(c *gin.Context) {
+	var statusCode int32 = entity.DefaultSucessCode
+	var statusMessage string = entity.DefaultSuccessMessage
+
+	_, userRole := requestUser(c)
+
+	err := h.ruleUc.DeleteRule(c, c.Param("tenant_code"), c.Param("serial"), userRole)
+	if err != nil {
+		statusCode = ruleErrorStatus(err)
+		statusMessage = err.Error()
+
+		log.Println(statusMessage)
+		helper.ResponseOutput(c, int32(statusCode), statusMessage, nil)
+		return
+	}
+
+	helper.ResponseOutput(c, int32(statusCode), statusMessage, nil)
+}
+
+func (h *httpHandler) CreateApprovalPolicy(c *gin.Context) {
+	var statusCode int32 = entity.DefaultSucessCode
+	var statusMessage string = entity.DefaultSuccessMessage
+
+	request := entity.ApprovalPolicy{}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		statusCode = http.StatusBadRequest
+		statusMessage = err.Error()
+
+		log.Println(statusMessage)
+		helper.ResponseOutput(c, statusCode, statusMessage, nil)
+		return
+	}
+
+	request.TenantCode = c.Param("tenant_code")
+	request.ObjectCode = c.Param("object_code")
+
+	var userRole string
+	request.CreatedBy, userRole = requestUser(c)
+
+	response, err := h.approvalUc.CreatePolicy(c, request, userRole)
+	if err != nil {
+		statusCode = approvalErrorStatus(err)
+		statusMessage = err.Error()
+
+		log.Println(statusMessage)
+		helper.ResponseOutput(c, int32(statusCode), statusMessage, nil)
+		return
+	}
+
+	helper.ResponseOutput(c, int32(statusCode), statusMessage, response)
+}
+
+func (h *httpHandler) GetApprovalPolicies(c *gin.Context) {
+	var statusCode int32 = entity.DefaultSucessCode
+	var statusMessage string = entity.DefaultSuccessMessage
+
+	response, err := h.approvalUc.GetPolicies(c, c.Param("tenant_code"), c.Param("object_code"))
+	if err != nil {
+		statusCode = http.StatusInternalServerError
+		statusMessage = err.Error()
+
+		log.Println(statusMessage)
+		helper.ResponseOutput(c, int32(statusCode), statusMessage, nil)
+		return
+	}
+
+	helper.ResponseOutput(c, int32(statusCode), statusMessage, response)
+}
+
+func (h *httpHandler) DeleteApprovalPolicy(c *gin.Context) {
+	var statusCode int32 = entity.DefaultSucessCode
+	var statusMessage string = entity.DefaultSuccessMessage
+
+	_, userRole := requestUser(c)
+
+	err := h.approvalUc.DeletePolicy(c, c.Param("tenant_code"), c.Param("serial"), userRole)
+	if err != nil {
+		statusCode = approvalErrorStatus(err)
+		statusMessage = err.Error()
+
+		log.Println(statusMessage)
+		helper.ResponseOutput(c, int32(statusCode), statusMessage, nil)
+		return
+	}
+
+	helper.ResponseOutput(c, int32(statusCode), statusMessage, nil)
+}
+
+// GetChangeRequests lists the change requests of a tenant, narrowed by the object_code and status query parameters
+func (h *httpHandler) GetChangeRequests(c *gin.Context) {
+	var statusCode int32 = entity.DefaultSucessCode
+	var statusMessage string = entity.DefaultSuccessMessage
+
+	request := entity.ChangeRequestQuery{
+		TenantCode: c.Param("tenant_code"),
+		ObjectCode: c.Query("object_code"),
+		Status:     c.Query("status"),
+	}
+
+	response, err := h.approvalUc.GetChangeRequests(c, request)
+	if err != nil {
+		statusCode = approvalErrorStatus(err)
+		statusMessage = err.Error()
+
+		log.Println(statusMessage)
+		helper.ResponseOutput(c, int32(statusCode), statusMessage, nil)
+		return
+	}
+
+	helper.ResponseOutput(c, int32(statusCode), statusMessage, response)
+}
+
+func (h *httpHandler) ApproveChangeRequest(c *gin.Context) {
+	h.decideChangeRequest(c, true)
+}
+
+func (h *httpHandler) RejectChangeRequest(c *gin.Context) {
+	h.decideChangeRequest(c, false)
+}
+
+func (h *httpHandler) decideChangeRequest(c *gin.Context, approve bool) {
+	var statusCode int32 = entity.DefaultSucessCode
+	var statusMessage string = entity.DefaultSuccessMessage
+
+	request := entity.ChangeRequestDecision{}
+	// the comment is optional, so is the body
+	if err := c.ShouldBindJSON(&request); err != nil && !errors.Is(err, io.EOF) {
+		statusCode = http.StatusBadRequest
+		statusMessage = err.Error()
+
+		log.Println(statusMessage)
+		helper.ResponseOutput(c, statusCode, statusMessage, nil)
+		return
+	}
+
+	request.TenantCode = c.Param("tenant_code")
+	request.Serial = c.Param("serial")
+	request.Approve = approve
+	request.UserSerial, request.UserRole = requestUser(c)
+
+	response, err := h.approvalUc.DecideChangeRequest(c, request)
+	if err != nil {
+		statusCode = approvalErrorStatus(err)
+		statusMessage = err.Error()
+
+		log.Println(statusMessage)
+		helper.ResponseOutput(c, int32(statusCode), statusMessage, nil)
+		return
+	}
+
+	helper.ResponseOutput(c, int32(statusCode), statusMessage, response)
+}
+
+func (h *httpHandler) CreateScheduledJob(c *gin.Context) {
+	var statusCode int32 = entity.DefaultSucessCode
+	var statusMessage string = entity.DefaultSuccessMessage
+
+	request := entity.ScheduledJob{}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		statusCode = http.StatusBadRequest
+		statusMessage = err.Error()
+
+		log.Println(statusMessage)
+		helper.ResponseOutput(c, statusCode, statusMessage, nil)
+		return
+	}
+
+	request.TenantCode = c.Param("tenant_code")
+
+	var userRole string
+	request.CreatedBy, userRole = requestUser(c)
+
+	response, err := h.scheduleUc.CreateJob(c, request, userRole)
+	if err != nil {
+		statusCode = scheduleErrorStatus(err)
+		statusMessage = err.Error()
+
+		log.Println(statusMessage)
+		helper.ResponseOutput(c, int32(statusCode), statusMessage, nil)
+		return
+	}
+
+	helper.ResponseOutput(c, int32(statusCode), statusMessage, response)
+}
+
+func (h *httpHandler) GetScheduledJobs(c *gin.Context) {
+	var statusCode int32 = entity.DefaultSucessCode
+	var statusMessage string = entity.DefaultSuccessMessage
+
+	_, userRole := requestUser(c)
+
+	response, err := h.scheduleUc.GetJobs(c, c.Param("tenant_code"), userRole)
+	if err != nil {
+		statusCode = scheduleErrorStatus(err)
+		statusMessage = err.Error()
+
+		log.Println(statusMessage)
+		helper.ResponseOutput(c, int32(statusCode), statusMessage, nil)
+		return
+	}
+
+	helper.ResponseOutput(c, int32(statusCode), statusMessage, response)
+}
+
+func (h *httpHandler) DeleteScheduledJob(c *gin.Context) {
+	var statusCode int32 = entity.DefaultSucessCode
+	var statusMessage string = entity.DefaultSuccessMessage
+
+	_, userRole := requestUser(c)
+
+	err := h.scheduleUc.DeleteJob(c, c.Param("tenant_code"), c.Param("serial"), userRole)
+	if err != nil {
+		statusCode = scheduleErrorStatus(err)
+		statusMessage = err.Error()
+
+		log.Println(statusMessage)
+		helper.ResponseOutput(c, int32(statusCode), statusMessage, nil)
+		return
+	}
+
+	helper.ResponseOutput(c, int32(statusCode), statusMessage, nil)
+}
+
+func (h *httpHandler) GetScheduledJobRuns(c *gin.Context) {
+	var statusCode int32 = entity.DefaultSucessCode
+	var statusMessage string = entity.DefaultSuccessMessage
+
+	page, _ := strconv.Atoi(c.Query("page"))
+	pageSize, _ := strconv.Atoi(c.Query("page_size"))
+
+	request := entity.ScheduledJobRunQuery{
+		TenantCode: c.Param("tenant_code"),
+		JobSerial:  c.Param("serial"),
+		Status:     c.Query("status"),
+		Page:       page,
+		PageSize:   pageSize,
+	}
+
+	_, userRole := requestUser(c)
+
+	response, err := h.scheduleUc.GetRuns(c, request, userRole)
+	if err != nil {
+		statusCode = scheduleErrorStatus(err)
+		statusMessage = err.Error()
+
+		log.Println(statusMessage)
+		helper.ResponseOutput(c, int32(statusCode), statusMessage, nil)
+		return
+	}
+
+	helper.ResponseOutput(c, int32(statusCode), statusMessage, response)
+}
+
+// SubmitJob queues a background job, the job is polled by its serial until it is finished
+func (h *httpHandler) SubmitJob(c *gin.Context) {
+	var statusCode int32 = http.StatusAccepted
+	var statusMessage string = entity.DefaultSuccessMessage
+
+	request := entity.Job{}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		statusCode = http.StatusBadRequest
+		statusMessage = err.Error()
+
+		log.Println(statusMessage)
+		helper.ResponseOutput(c, statusCode, statusMessage, nil)
+		return
+	}
+
+	request.TenantCode = c.Param("tenant_code")
+	request.CreatedBy, _ = requestUser(c)
+
+	response, err := h.jobUc.SubmitJob(c, request)
+	if err != nil {
+		statusCode = jobErrorStatus(err)
+		statusMessage = err.Error()
+
+		log.Println(statusMessage)
+		helper.ResponseOutput(c, int32(statusCode), statusMessage, nil)
+		return
+	}
+
+	helper.ResponseOutput(c, int32(statusCode), statusMessage, response)
+}
+
+func (h *httpHandler) GetJobs(c *gin.Context) {
+	var statusCode int32 = entity.DefaultSucessCode
+	var statusMessage string = entity.DefaultSuccessMessage
+
+	page, _ := strconv.Atoi(c.Query("page"))
+	pageSize, _ := strconv.Atoi(c.Query("page_size"))
+
+	request := entity.JobQuery{
+		TenantCode: c.Param("tenant_code"),
+		JobType:    c.Query("job_type"),
+		Status:     c.Query("status"),
+		Page:       page,
+		PageSize:   pageSize,
+	}
+
+	userSerial, userRole := requestUser(c)
+
+	response, err := h.jobUc.GetJobs(c, request, userSerial, userRole)
+	if err != nil {
+		statusCode = jobErrorStatus(err)
+		statusMessage = err.Error()
+
+		log.Println(statusMessage)
+		helper.ResponseOutput(c, int32(statusCode), statusMessage, nil)
+		return
+	}
+
+	helper.ResponseOutput(c, int32(statusCode), statusMessage, response)
+}
+
+func (h *httpHandler) GetJob(c *gin.Context) {
+	var statusCode int32 = entity.DefaultSucessCode
+	var statusMessage string = entity.DefaultSuccessMessage
+
+	userSerial, userRole := requestUser(c)
+
+	response, err := h.jobUc.GetJob(c, c.Param("tenant_code"), c.Param("serial"), userSerial, userRole)
+	if err != nil {
+		statusCode = jobErrorStatus(err)
+		statusMessage = err.Error()
+
+		log.Println(statusMessage)
+		helper.ResponseOutput(c, int32(statusCode), statusMessage, nil)
+		return
+	}
+
+	helper.ResponseOutput(c, int32(statusCode), statusMessage, response)
+}
+
+func (h *httpHandler) CancelJob(c *gin.Context) {
+	var statusCode int32 = entity.DefaultSucessCode
+	var statusMessage string = entity.DefaultSuccessMessage
+
+	userSerial, userRole := requestUser(c)
+
+	response, err := h.jobUc.CancelJob(c, c.Param("tenant_code"), c.Param("serial"), userSerial, userRole)
+	if err != nil {
+		statusCode = jobErrorStatus(err)
+		statusMessage = err.Error()
+
+		log.Println(statusMessage)
+		helper.ResponseOutput(c, int32(statusCode), statusMessage, nil)
+		return
+	}
+
+	helper.ResponseOutput(c, int32(statusCode), statusMessage, response)
+}
+
+// UploadRecordFile stores the multipart file of the form field "file" in a file field of a record
+func (h *httpHandler) UploadRecordFile(c *gin.Context) {
+	var statusCode int32 = entity.DefaultSucessCode
+	var statusMessage string = entity.DefaultSuccessMessage
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		statusCode = http.StatusBadRequest
+		statusMessage = err.Error()
+
+		log.Println(statusMessage)
+		helper.ResponseOutput(c, statusCode, statusMessage, nil)
+		return
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		statusCode = http.StatusBadRequest
+		statusMessage = err.Error()
+
+		log.Println(statusMessage)
+		helper.ResponseOutput(c, statusCode, statusMessage, nil)
+		return
+	}
+	defer file.Close()
+
+	response, err := h.fileUc.UploadFile(c, fileRequest(c), header.Filename, file)
+	if err != nil {
+		if pending, ok := approvalPending(err); ok {
+			helper.ResponseOutput(c, http.StatusAccepted, pending.Error(), pending.ChangeRequest)
+			return
+		}
+
+		statusCode = fileErrorStatus(err)
+		statusMessage = err.Error()
+
+		log.Println(statusMessage)
+		helper.ResponseOutput(c, int32(statusCode), statusMessage, nil)
+		return
+	}
+
+	helper.ResponseOutput(c, int32(statusCode), statusMessage, response)
+}
+
+// DownloadRecordFile streams the file of a file field of a record
+func (h *httpHandler) DownloadRecordFile(c *gin.Context) {
+	var statusCode int32 = entity.DefaultSucessCode
+	var statusMessage string = entity.DefaultSuccessMessage
+
+	attachment, content, err := h.fileUc.DownloadFile(c, fileRequest(c))
+	if err != nil {
+		statusCode = fileErrorStatus(err)
+		statusMessage = err.Error()
+
+		log.Println(statusMessage)
+		helper.ResponseOutput(c, int32(statusCode), statusMessage, nil)
+		return
+	}
+	defer content.Close()
+
+	c.DataFromReader(http.StatusOK, attachment.Size, attachment.MimeType, content, map[string]string{
+		"Content-Disposition":    mime.FormatMediaType("attachment", map[string]string{"filename": attachment.FileName}),
+		"X-Content-Type-Options": "nosniff",
+	})
+}
+
+// GetRecordThumbnail streams a thumbnail of the image in a file field of a record
+func (h *httpHandler) GetRecordThumbnail(c *gin.Context) {
+	var statusCode int32 = entity.DefaultSucessCode
+	var statusMessage string = entity.DefaultSuccessMessage
+
+	size, err := strconv.Atoi(c.Param("size"))
+	if err != nil {
+		statusCode = http.StatusBadRequest
+		statusMessage = err.Error()
+
+		log.Println(statusMessage)
+		helper.ResponseOutput(c, statusCode, statusMessage, nil)
+		return
+	}
+
+	mimeType, content, err := h.fileUc.GetThumbnail(c, fileRequest(c), size)
+	if err != nil {
+		statusCode = fileErrorStatus(err)
+		statusMessage = err.Error()
+
+		log.Println(statusMessage)
+		helper.ResponseOutput(c, int32(statusCode), statusMessage, nil)
+		return
+	}
+	defer content.Close()
+
+	// the thumbnail urls of list responses change with every upload, so a thumbnail can be cached
+	c.DataFromReader(http.StatusOK, -1, mimeType, content, map[string]string{
+		"Cache-Control":          "private, max-age=86400",
+		"X-Content-Type-Options": "nosniff",
+	})
+}
+
+func (h *httpHandler) DeleteRecordFile(c *gin.Context) {
+	var statusCode int32 = entity.DefaultSucessCode
+	var statusMessage string = entity.DefaultSuccessMessage
+
+	if err := h.fileUc.DeleteFile(c, fileRequest(c)); err != nil {
+		if pending, ok := approvalPending(err); ok {
+			helper.ResponseOutput(c, http.StatusAccepted, pending.Error(), pending.ChangeRequest)
+			return
+		}
+
+		statusCode = fileErrorStatus(err)
+		statusMessage = err.Error()
+
+		log.Println(statusMessage)
+		helper.ResponseOutput(c, int32(statusCode), statusMessage, nil)
+		return
+	}
+
+	helper.ResponseOutput(c, int32(statusCode), statusMessage, nil)
+}
+
+func (h *httpHandler) CreateDocumentTemplate(c *gin.Context) {
+	var statusCode int32 = entity.DefaultSucessCode
+	var statusMessage string = entity.DefaultSuccessMessage
+
+	request := entity.DocumentTemplate{}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		statusCode = http.StatusBadRequest
+		statusMessage = err.Error()
+
+		log.Println(statusMessage)
+		helper.ResponseOutput(c, statusCode, statusMessage, nil)
+		return
+	}
+
+	request.TenantCode = c.Param("tenant_code")
+	request.ObjectCode = c.Param("object_code")
+	var userRole string
+	request.CreatedBy, userRole = requestUser(c)
+
+	response, err := h.documentUc.CreateTemplate(c, request, userRole)
+	if err != nil {
+		statusCode = documentErrorStatus(err)
+		statusMessage = err.Error()
+
+		log.Println(statusMessage)
+		helper.ResponseOutput(c, int32(statusCode), statusMessage, nil)
+		return
+	}
+
+	helper.ResponseOutput(c, int32(statusCode), statusMessage, response)
+}
+
+func (h *httpHandler) GetDocumentTemplates(c *gin.Context) {
+	var statusCode int32 = entity.DefaultSucessCode
+	var statusMessage string = entity.DefaultSuccessMessage
+
+	response, err := h.documentUc.GetTemplates(c, c.Param("tenant_code"), c.Param("object_code"))
+	if err != nil {
+		statusCode = http.StatusInternalServerError
+		statusMessage = err.Error()
+
+		log.Println(statusMessage)
+		helper.ResponseOutput(c, int32(statusCode), statusMessage, nil)
+		return
+	}
+
+	helper.ResponseOutput(c, int32(statusCode), statusMessage, response)
+}
+
+func (h *httpHandler) DeleteDocumentTemplate(c *gin.Context) {
+	var statusCode int32 = entity.DefaultSucessCode
+	var statusMessage string = entity.DefaultSuccessMessage
+
+	_, userRole := requestUser(c)
+
+	err := h.documentUc.DeleteTemplate(c, c.Param("tenant_code"), c.Param("serial"), userRole)
+	if err != nil {
+		statusCode = documentErrorStatus(err)
+		statusMessage = err.Error()
+
+		log.Println(statusMessage)
+		helper.ResponseOutput(c, int32(statusCode), statusMessage, nil)
+		return
+	}
+
+	helper.ResponseOutput(c, int32(statusCode), statusMessage, nil)
+}
+
+// RenderRecordDocument renders a record with a document template, as html or as pdf by the format query parameter
+func (h *httpHandler) RenderRecordDocument(c *gin.Context) {
+	var statusCode int32 = entity.DefaultSucessCode
+	var statusMessage string = entity.DefaultSuccessMessage
+
+	request := entity.RenderDocumentRequest{
+		TenantCode:   c.Param("tenant_code"),
+		ProductCode:  c.Param("product_code"),
+		ObjectCode:   c.Param("object_code"),
+		RecordSerial: c.Param("serial"),
+		TemplateCode: c.Param("template_code"),
+		Format:       c.Query("format"),
+	}
+	request.UserSerial, request.UserRole = requestUser(c)
+
+	response, err := h.documentUc.RenderDocument(c, request)
+	if err != nil {
+		statusCode = documentErrorStatus(err)
+		statusMessage = err.Error()
+
+		log.Println(statusMessage)
+		helper.ResponseOutput(c, int32(statusCode), statusMessage, nil)
+		return
+	}
+
+	c.Header("Content-Disposition", mime.FormatMediaType("inline", map[string]string{"filename": response.FileName}))
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Data(http.StatusOK, response.ContentType, response.Content)
+}
+
+// GetOpenAPIDocument serves the OpenAPI document of the objects of a tenant, a client holding the current version
+// gets a 304 from its etag
+func (h *httpHandler) GetOpenAPIDocument(c *gin.Context) {
+	var statusCode int32 = entity.DefaultSucessCode
+	var statusMessage string = entity.DefaultSuccessMessage
+
+	response, err := h.openAPIUc.GetDocument(c, entity.OpenAPIRequest{
+		TenantCode:  c.Param("tenant_code"),
+		ProductCode: c.Param("product_code"),
+	})
+	if err != nil {
+		statusCode = http.StatusInternalServerError
+		statusMessage = err.Error()
+
+		log.Println(statusMessage)
+		helper.ResponseOutput(c, int32(statusCode), statusMessage, nil)
+		return
+	}
+
+	c.Header("ETag", response.ETag)
+	c.Header("Cache-Control", "no-cache")
+	if c.GetHeader("If-None-Match") == response.ETag {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	c.Data(http.StatusOK, "application/json", response.Content)
+}
+
+// StreamViewChanges pushes the changes of the records of a view as server-sent events
+func (h *httpHandler) StreamViewChanges(c *gin.Context) {
+	var statusCode int32 = entity.DefaultSucessCode
+	var statusMessage string = entity.DefaultSuccessMessage
+
+	request := entity.GetViewContentByKeysRequest{
+		TenantCode:      c.Param("tenant_code"),
+		ProductCode:     c.Param("product_code"),
+		ObjectCode:      c.Param("object_code"),
+		ViewContentCode: c.Param("view_content_code"),
+	}
+	request.UserSerial, request.UserRole = requestUser(c)
+
+	changes, err := h.streamUc.Subscribe(c.Request.Context(), request)
+	if err != nil {
+		statusCode = http.StatusInternalServerError
+		if errors.Is(err, entity.ErrorNotFound) {
+			statusCode = http.StatusNotFound
+		}
+		statusMessage = err.Error()
+
+		log.Println(statusMessage)
+		helper.ResponseOutput(c, int32(statusCode), statusMessage, nil)
+		return
+	}
+
+	heartbeat := time.NewTicker(entity.StreamHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case change, ok := <-changes:
+			if !ok {
+				return false
+			}
+
+			c.SSEvent(change.Event, change)
+			return true
+		case <-heartbeat.C:
+			c.SSEvent(entity.StreamEventPing, time.Now().Unix())
+			return true
+		}
+	})
+}
+
+func (h *httpHandler) CreateViewContent(c *gin.Context) {
+	var statusCode int32 = entity.DefaultSucessCode
+	var statusMessage string = entity.DefaultSuccessMessage
+	userSerial, userRole := requestUser(c)
+
+	request := entity.ViewContentRecord{}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		statusCode = http.StatusBadRequest
+		statusMessage = err.Error()
+
+		log.Println(statusMessage)
+		helper.ResponseOutput(c, statusCode, statusMessage, nil)
+		return
+	}
+
+	request.TenantCode = c.Param("tenant_code")
+	request.ProductCode = c.Param("product_code")
+	request.ObjectCode = c.Param("object_code")
+
+	response, err := h.viewUc.CreateViewContent(c, request, userSerial, userRole)
+	if err != nil {
+		statusCode = viewErrorStatus(err)
+		statusMessage = err.Error()
+
+		log.Println(statusMessage)
+		helper.ResponseOutput(c, statusCode, statusMessage, nil)
+		return
+	}
+
+	helper.ResponseOutput(c, statusCode, statusMessage, response)
+}
+
+func (h *httpHandler) UpdateViewContent(c *gin.Context) {
+	var statusCode int32 = entity.DefaultSucessCode
+	var statusMessage string = entity.DefaultSuccessMessage
+
+	request := entity.ViewContentRecord{}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		statusCode = http.StatusBadRequest
+		statusMessage = err.Error()
+
+		log.Println(statusMessage)
+		helper.ResponseOutput(c, statusCode, statusMessage, nil)
+		return
+	}
+
+	response, err := h.viewUc.UpdateViewContent(c, viewContentKeys(c), request)
+	if err != nil {
+		statusCode = viewErrorStatus(err)
+		statusMessage = err.Error()
+
+		log.Println(statusMessage)
+		helper.ResponseOutput(c, statusCode, statusMessage, nil)
+		return
+	}
+
+	helper.ResponseOutput(c, statusCode, statusMessage, response)
+}
+
+func (h *httpHandler) DeleteViewContent(c *gin.Context) {
+	var statusCode int32 = entity.DefaultSucessCode
+	var statusMessage string = entity.DefaultSuccessMessage
+
+	if err := h.viewUc.DeleteViewContent(c, viewContentKeys(c)); err != nil {
+		statusCode = viewErrorStatus(err)
+		statusMessage = err.Error()
+
+		log.Println(statusMessage)
+		helper.ResponseOutput(c, statusCode, statusMessage, nil)
+		return
+	}
+
+	helper.ResponseOutput(c, statusCode, statusMessage, nil)
+}
+
+func (h *httpHandler) CloneViewContent(c *gin.Context) {
+	var statusCode int32 = entity.DefaultSucessCode
+	var statusMessage string = entity.DefaultSuccessMessage
+
+	request := entity.CloneViewRequest{}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		statusCode = http.StatusBadRequest
+		statusMessage = err.Error()
+
+		log.Println(statusMessage)
+		helper.ResponseOutput(c, statusCode, statusMessage, nil)
+		return
+	}
+
+	request.UserSerial, request.UserRole = requestUser(c)
+
+	response, err := h.viewUc.CloneViewContent(c, viewContentKeys(c), request)
+	if err != nil {
+		statusCode = viewErrorStatus(err)
+		statusMessage = err.Error()
+
+		log.Println(statusMessage)
+		helper.ResponseOutput(c, statusCode, statusMessage, nil)
+		return
+	}
+
+	helper.ResponseOutput(c, statusCode, statusMessage, response)
+}
+
+func (h *httpHandler) CreateViewSchema(c *gin.Context) {
+	var statusCode int32 = entity.DefaultSucessCode
+	var statusMessage string = entity.DefaultSuccessMessage
+	userSerial, userRole := requestUser(c)
+
+	request := entity.ViewSchema{}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		statusCode = http.StatusBadRequest
+		statusMessage = err.Error()
+
+		log.Println(statusMessage)
+		helper.ResponseOutput(c, statusCode, statusMessage, nil)
+		return
+	}
+
+	response, err := h.viewUc.CreateViewSchema(c, c.Param("tenant_code"), c.Param("object_code"), request, userSerial, userRole)
+	if err != nil {
+		statusCode = viewErrorStatus(err)
+		statusMessage = err.Error()
+
+		log.Println(statusMessage)
+		helper.ResponseOutput(c, statusCode, statusMessage, nil)
+		return
+	}
+
+	helper.ResponseOutput(c, statusCode, statusMessage, response)
+}
+
+func (h *httpHandler) UpdateViewSchema(c *gin.Context) {
+	var statusCode int32 = entity.DefaultSucessCode
+	var statusMessage string = entity.DefaultSuccessMessage
+	userSerial, userRole := requestUser(c)
+
+	request := entity.ViewSchema{}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		statusCode = http.StatusBadRequest
+		statusMessage = err.Error()
+
+		log.Println(statusMessage)
+		helper.ResponseOutput(c, statusCode, statusMessage, nil)
+		return
+	}
+
+	request.Serial = c.Param("serial")
+
+	response, err := h.viewUc.UpdateViewSchema(c, c.Param("tenant_code"), c.Param("object_code"), request, userSerial, userRole)
+	if err != nil {
+		statusCode = viewErrorStatus(err)
+		statusMessage = err.Error()
+
+		log.Println(statusMessage)
+		helper.ResponseOutput(c, statusCode, statusMessage, nil)
+		return
+	}
+
+	helper.ResponseOutput(c, statusCode, statusMessage, response)
+}
+
+func (h *httpHandler) DeleteViewSchema(c *gin.Context) {
+	var statusCode int32 = entity.DefaultSucessCode
+	var statusMessage string = entity.DefaultSuccessMessage
+	userSerial, userRole := requestUser(c)
+
+	if err := h.viewUc.DeleteViewSchema(c, c.Param("tenant_code"), c.Param("object_code"), c.Param("serial"), userSerial, userRole); err != nil {
+		statusCode = viewErrorStatus(err)
+		statusMessage = err.Error()
+
+		log.Println(statusMessage)
+		helper.ResponseOutput(c, statusCode, statusMessage, nil)
+		return
+	}
+
+	helper.ResponseOutput(c, statusCode, statusMessage, nil)
+}
+
+func (h *httpHandler) CloneViewSchema(c *gin.Context) {
+	var statusCode int32 = entity.DefaultSucessCode
+	var statusMessage string = entity.DefaultSuccessMessage
+
+	request := entity.CloneViewRequest{}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		statusCode = http.StatusBadRequest
+		statusMessage = err.Error()
+
+		log.Println(statusMessage)
+		helper.ResponseOutput(c, statusCode, statusMessage, nil)
+		return
+	}
+
+	request.UserSerial, request.UserRole = requestUser(c)
+
+	response, err := h.viewUc.CloneViewSchema(c, c.Param("tenant_code"), c.Param("object_code"), c.Param("serial"), request)
+	if err != nil {
+		statusCode = viewErrorStatus(err)
+		statusMessage = err.Error()
+
+		log.Println(statusMessage)
+		helper.ResponseOutput(c, statusCode, statusMessage, nil)
+		return
+	}
+
+	helper.ResponseOutput(c, statusCode, statusMessage, response)
+}
+
+func (h *httpHandler) CreateViewLayout(c *gin.Context) {
+	var statusCode int32 = entity.DefaultSucessCode
+	var statusMessage string = entity.DefaultSuccessMessage
+	userSerial, userRole := requestUser(c)
+
+	request := entity.ViewLayout{}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		statusCode = http.StatusBadRequest
+		statusMessage = err.Error()
+
+		log.Println(statusMessage)
+		helper.ResponseOutput(c, statusCode, statusMessage, nil)
+		return
+	}
+
+	response, err := h.viewUc.CreateViewLayout(c, request, userSerial, userRole)
+	if err != nil {
+		statusCode = viewErrorStatus(err)
+		statusMessage = err.Error()
+
+		log.Println(statusMessage)
+		helper.ResponseOutput(c, statusCode, statusMessage, nil)
+		return
+	}
+
+	helper.ResponseOutput(c, statusCode, statusMessage, response)
+}
+
+func (h *httpHandler) UpdateViewLayout(c *gin.Context) {
+	var statusCode int32 = entity.DefaultSucessCode
+	var statusMessage string = entity.DefaultSuccessMessage
+	userSerial, userRole := requestUser(c)
+
+	request := entity.ViewLayout{}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		statusCode = http.StatusBadRequest
+		statusMessage = err.Error()
+
+		log.Println(statusMessage)
+		helper.ResponseOutput(c, statusCode, statusMessage, nil)
+		return
+	}
+
+	request.Serial = c.Param("serial")
+
+	response, err := h.viewUc.UpdateViewLayout(c, request, userSerial, userRole)
+	if err != nil {
+		statusCode = viewErrorStatus(err)
+		statusMessage = err.Error()
+
+		log.Println(statusMessage)
+		helper.ResponseOutput(c, statusCode, statusMessage, nil)
+		return
+	}
+
+	helper.ResponseOutput(c, statusCode, statusMessage, response)
+}
+
+func (h *httpHandler) DeleteViewLayout(c *gin.Context) {
+	var statusCode int32 = entity.DefaultSucessCode
+	var statusMessage string = entity.DefaultSuccessMessage
+	userSerial, userRole := requestUser(c)
+
+	if err := h.viewUc.DeleteViewLayout(c, c.Param("serial"), userSerial, userRole); err != nil {
+		statusCode = viewErrorStatus(err)
+		statusMessage = err.Error()
+
+		log.Println(statusMessage)
+		helper.ResponseOutput(c, statusCode, statusMessage, nil)
+		return
+	}
+
+	helper.ResponseOutput(c, statusCode, statusMessage, nil)
+}
+
+func (h *httpHandler) CloneViewLayout(c *gin.Context) {
+	var statusCode int32 = entity.DefaultSucessCode
+	var statusMessage string = entity.DefaultSuccessMessage
+
+	request := entity.CloneViewRequest{}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		statusCode = http.StatusBadRequest
+		statusMessage = err.Error()
+
+		log.Println(statusMessage)
+		helper.ResponseOutput(c, statusCode, statusMessage, nil)
+		return
+	}
+
+	request.UserSerial, request.UserRole = requestUser(c)
+
+	response, err := h.viewUc.CloneViewLayout(c, c.Param("serial"), request)
+	if err != nil {
+		statusCode = viewErrorStatus(err)
+		statusMessage = err.Error()
+
+		log.Println(statusMessage)
+		helper.ResponseOutput(c, statusCode, statusMessage, nil)
+		return
+	}
+
+	helper.ResponseOutput(c, statusCode, statusMessage, response)
+}
+
+func (h *httpHandler) SavePersonalView(c *gin.Context) {
+	var statusCode int32 = entity.DefaultSucessCode
+	var statusMessage string = entity.DefaultSuccessMessage
+
+	request := entity.SavePersonalViewRequest{}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		statusCode = http.StatusBadRequest
+		statusMessage = err.Error()
+
+		log.Println(statusMessage)
+		helper.ResponseOutput(c, statusCode, statusMessage, nil)
+		return
+	}
+
+	response, err := h.viewUc.SavePersonalView(c, viewContentKeys(c), request)
+	if err != nil {
+		statusCode = viewErrorStatus(err)
+		statusMessage = err.Error()
+
+		log.Println(statusMessage)
+		helper.ResponseOutput(c, statusCode, statusMessage, nil)
+		return
+	}
+
+	helper.ResponseOutput(c, statusCode, statusMessage, response)
+}
+
+func (h *httpHandler) GetAvailableViews(c *gin.Context) {
+	var statusCode int32 = entity.DefaultSucessCode
+	var statusMessage string = entity.DefaultSuccessMessage
+
+	response, err := h.viewUc.GetAvailableViews(c, viewContentKeys(c))
+	if err != nil {
+		statusCode = viewErrorStatus(err)
+		statusMessage = err.Error()
+
+		log.Println(statusMessage)
+		helper.ResponseOutput(c, statusCode, statusMessage, nil)
+		return
+	}
+
+	helper.ResponseOutput(c, statusCode, statusMessage, response)
+}
+
+func (h *httpHandler) AddViewFavorite(c *gin.Context) {
+	h.setViewFavorite(c, true)
+}
+
+func (h *httpHandler) RemoveViewFavorite(c *gin.Context) {
+	h.setViewFavorite(c, false)
+}
+
+func (h *httpHandler) setViewFavorite(c *gin.Context, isFavorite bool) {
+	var statusCode int32 = entity.DefaultSucessCode
+	var statusMessage string = entity.DefaultSuccessMessage
+
+	if err := h.viewUc.SetViewFavorite(c, viewContentKeys(c), isFavorite); err != nil {
+		statusCode = viewErrorStatus(err)
+		statusMessage = err.Error()
+
+		log.Println(statusMessage)
+		helper.ResponseOutput(c, statusCode, statusMessage, nil)
+		return
+	}
+
+	helper.ResponseOutput(c, statusCode, statusMessage, nil)
+}
+
+// bindMutationParams overrides the mutation target with the path parameters
+func bindMutationParams(c *gin.Context, request *entity.DataMutationRequest) {
+	if serial := c.Param("serial"); serial != "" {
+		request.Serial = serial
+	}
+
+	if tenantCode := c.Param("tenant_code"); tenantCode != "" {
+		request.TenantCode = tenantCode
+	}
+
+	if productCode := c.Param("product_code"); productCode != "" {
+		request.ProductCode = productCode
+	}
+
+	if objectCode := c.Param("object_code"); objectCode != "" {
+		request.ObjectCode = objectCode
+	}
+}
+
+// fileRequest reads the file field of a record from the path parameters and the user from the headers
+func fileRequest(c *gin.Context) entity.FileRequest {
+	request := entity.FileRequest{
+		TenantCode:   c.Param("tenant_code"),
+		ProductCode:  c.Param("product_code"),
+		ObjectCode:   c.Param("object_code"),
+		RecordSerial: c.Param("serial"),
+		FieldCode:    c.Param("field_code"),
+	}
+	request.UserSerial, request.UserRole = requestUser(c)
+
+	return request
+}
+
+// viewContentKeys reads the keys of a view content from the path parameters and the user from the bearer token
+func viewContentKeys(c *gin.Context) entity.GetViewContentByKeysRequest {
+	request := entity.GetViewContentByKeysRequest{
+		TenantCode:      c.Param("tenant_code"),
+		ProductCode:     c.Param("product_code"),
+		ObjectCode:      c.Param("object_code"),
+		ViewContentCode: c.Param("view_content_code"),
+		LayoutType:      c.Param("layout_type"),
+	}
+	request.UserSerial, request.UserRole = requestUser(c)
+
+	return request
+}
+
+// requestUser returns the user and role of the bearer token checked by the auth middleware, both are empty for a
+// request without a token
+func requestUser(c *gin.Context) (userSerial, userRole string) {
+	value, _ := c.Get(entity.ContextKeyUser)
+	claims, _ := value.(entity.UserClaims)
+
+	return claims.UserSerial, claims.UserRole
+}
+
+// mutationErrorStatus maps the errors of a record mutation to http status codes, a mutation rejected by a rule or
+// the state machine is the fault of its values
+func mutationErrorStatus(err error) int32 {
+	switch {
+	case errors.Is(err, entity.ErrorRuleRejected), errors.Is(err, entity.ErrorTransitionInvalid), errors.Is(err, entity.ErrorTransitionFieldRequired):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, entity.ErrorTransitionForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, entity.ErrorNotFound):
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// approvalPending reports whether a mutation was held back for approval instead of applied
+func approvalPending(err error) (*module.ApprovalPendingError, bool) {
+	var pending *module.ApprovalPendingError
+	return pending, errors.As(err, &pending)
+}
+
+// approvalErrorStatus maps the errors of the approval endpoints to http status codes, an approved change failing to
+// apply is mapped like the mutation
+func approvalErrorStatus(err error) int32 {
+	switch {
+	case errors.Is(err, entity.ErrorNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, entity.ErrorApprovalForbidden), errors.Is(err, entity.ErrorAdminRequired):
+		return http.StatusForbidden
+	case errors.Is(err, entity.ErrorChangeRequestDecided):
+		return http.StatusConflict
+	case errors.Is(err, entity.ErrorSerialEmpty), errors.Is(err, entity.ErrorApprovalEventInvalid), errors.Is(err, entity.ErrorChangeRequestStatusInvalid):
+		return http.StatusBadRequest
+	default:
+		return mutationErrorStatus(err)
+	}
+}
+
+// scheduleErrorStatus maps the errors of the scheduled job endpoints to http status codes
+func scheduleErrorStatus(err error) int32 {
+	switch {
+	case errors.Is(err, entity.ErrorNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, entity.ErrorAdminRequired):
+		return http.StatusForbidden
+	case errors.Is(err, entity.ErrorSerialEmpty), errors.Is(err, entity.ErrorScheduledJobTypeInvalid), errors.Is(err, entity.ErrorScheduledJobCronInvalid), errors.Is(err, entity.ErrorScheduledJobConfigInvalid):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// jobErrorStatus maps the errors of the job queue endpoints to http status codes
+func jobErrorStatus(err error) int32 {
+	switch {
+	case errors.Is(err, entity.ErrorNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, entity.ErrorJobFinished):
+		return http.StatusConflict
+	case errors.Is(err, entity.ErrorLoginRequired):
+		return http.StatusUnauthorized
+	case errors.Is(err, entity.ErrorJobForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, entity.ErrorSerialEmpty), errors.Is(err, entity.ErrorJobTypeUnknown), errors.Is(err, entity.ErrorJobPayloadInvalid):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// fileErrorStatus maps the errors of the file endpoints to http status codes, the update setting the file is mapped
+// like any mutation
+func fileErrorStatus(err error) int32 {
+	switch {
+	case errors.Is(err, entity.ErrorFileNotFound), errors.Is(err, entity.ErrorThumbnailUnavailable):
+		return http.StatusNotFound
+	case errors.Is(err, entity.ErrorFileTooLarge):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, entity.ErrorFileTypeInvalid):
+		return http.StatusUnsupportedMediaType
+	case errors.Is(err, entity.ErrorSerialEmpty), errors.Is(err, entity.ErrorFileFieldInvalid), errors.Is(err, entity.ErrorFileEmpty), errors.Is(err, entity.ErrorThumbnailSizeInvalid):
+		return http.StatusBadRequest
+	default:
+		return mutationErrorStatus(err)
+	}
+}
+
+// documentErrorStatus maps the errors of the document template endpoints to http status codes
+func documentErrorStatus(err error) int32 {
+	switch {
+	case errors.Is(err, entity.ErrorNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, entity.ErrorDocumentTemplateExists):
+		return http.StatusConflict
+	case errors.Is(err, entity.ErrorAdminRequired):
+		return http.StatusForbidden
+	case errors.Is(err, entity.ErrorDocumentRenderFailed):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, entity.ErrorSerialEmpty), errors.Is(err, entity.ErrorDocumentTemplateInvalid), errors.Is(err, entity.ErrorDocumentPageSizeInvalid), errors.Is(err, entity.ErrorDocumentFormatInvalid):
+		return http.StatusBadRequest
+	default:
+		return mutationErrorStatus(err)
+	}
+}
+
+// versionErrorStatus maps the errors of a version restore to http status codes, the update it applies is mapped like
+// any mutation
+func versionErrorStatus(err error) int32 {
+	switch {
+	case errors.Is(err, entity.ErrorRestoreNeedsApproval):
+		return http.StatusConflict
+	default:
+		return mutationErrorStatus(err)
+	}
+}
+
+// webhookErrorStatus maps the errors of the webhook endpoints to http status codes
+func webhookErrorStatus(err error) int32 {
+	switch {
+	case errors.Is(err, entity.ErrorNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, entity.ErrorAdminRequired):
+		return http.StatusForbidden
+	case errors.Is(err, entity.ErrorSerialEmpty), errors.Is(err, entity.ErrorWebhookURLEmpty), errors.Is(err, entity.ErrorWebhookURLInvalid), errors.Is(err, entity.ErrorWebhookEventTypeInvalid):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// ruleErrorStatus maps the errors of the rule endpoints to http status codes
+func ruleErrorStatus(err error) int32 {
+	switch {
+	case errors.Is(err, entity.ErrorNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, entity.ErrorAdminRequired):
+		return http.StatusForbidden
+	case errors.Is(err, entity.ErrorSerialEmpty), errors.Is(err, entity.ErrorRuleTimingInvalid), errors.Is(err, entity.ErrorRuleEventInvalid), errors.Is(err, entity.ErrorRuleActionInvalid):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// viewErrorStatus maps the errors of the view management endpoints to a status code
+func viewErrorStatus(err error) int32 {
+	var layoutError *module.LayoutError
+
+	switch {
+	case errors.Is(err, entity.ErrorNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, entity.ErrorViewForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, entity.ErrorViewCodeExists), errors.Is(err, entity.ErrorViewInUse), errors.Is(err, entity.ErrorViewDefaultRequired):
+		return http.StatusConflict
+	case errors.Is(err, entity.ErrorViewCodeEmpty), errors.Is(err, entity.ErrorViewLayoutTypeEmpty), errors.Is(err, entity.ErrorViewFieldInvalid), errors.Is(err, entity.ErrorViewUserEmpty), errors.As(err, &layoutError):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
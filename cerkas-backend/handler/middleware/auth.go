@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/cerkas/cerkas-backend/config"
+	"github.com/cerkas/cerkas-backend/core/entity"
+	"github.com/cerkas/cerkas-backend/pkg/helper"
+	"github.com/gin-gonic/gin"
+)
+
+// AuthMiddleware reads the user of a request from its bearer token, signed with AUTH_TOKEN_SECRET by the identity
+// provider. A request without a token runs without a user and role, a request with a bad token is rejected.
+func AuthMiddleware(cfg config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		authorization := c.GetHeader("Authorization")
+		if authorization == "" {
+			c.Next()
+			return
+		}
+
+		token, ok := strings.CutPrefix(authorization, "Bearer ")
+		claims := entity.UserClaims{}
+		if !ok || helper.VerifyToken(cfg.AuthTokenSecret, strings.TrimSpace(token), &claims) != nil || claims.UserSerial == "" || claims.ExpiresAt < time.Now().Unix() {
+			helper.ResponseOutput(c, http.StatusUnauthorized, entity.ErrorUnauthorized.Error(), nil)
+			c.Abort()
+			return
+		}
+
+		c.Set(entity.ContextKeyUser, claims)
+		c.Next()
+	}
+}
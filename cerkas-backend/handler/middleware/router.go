@@ -2,14 +2,30 @@
 package middleware
 
 import (
+	"context"
+	"fmt"
 	"strings"
 
 	"github.com/cerkas/cerkas-backend/config"
+	"github.com/cerkas/cerkas-backend/core/entity"
 	"github.com/cerkas/cerkas-backend/core/module"
 	"github.com/cerkas/cerkas-backend/handler/api"
 	"github.com/cerkas/cerkas-backend/pkg/conn"
+	approvalrepository "github.com/cerkas/cerkas-backend/repository/approval_repository"
+	auditrepository "github.com/cerkas/cerkas-backend/repository/audit_repository"
 	catalogrepository "github.com/cerkas/cerkas-backend/repository/catalog_repository"
+	changefeedrepository "github.com/cerkas/cerkas-backend/repository/change_feed_repository"
+	documentrepository "github.com/cerkas/cerkas-backend/repository/document_repository"
+	filestorage "github.com/cerkas/cerkas-backend/repository/file_storage"
+	jobrepository "github.com/cerkas/cerkas-backend/repository/job_repository"
+	outboxrepository "github.com/cerkas/cerkas-backend/repository/outbox_repository"
+	rulerepository "github.com/cerkas/cerkas-backend/repository/rule_repository"
+	schedulerepository "github.com/cerkas/cerkas-backend/repository/schedule_repository"
+	"github.com/cerkas/cerkas-backend/repository/util"
+	versionrepository "github.com/cerkas/cerkas-backend/repository/version_repository"
 	viewrepository "github.com/cerkas/cerkas-backend/repository/view_repository"
+	webhookrepository "github.com/cerkas/cerkas-backend/repository/webhook_repository"
+	workflowrepository "github.com/cerkas/cerkas-backend/repository/workflow_repository"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -22,25 +38,130 @@ func InitRouter(cfg config.Config, db *gorm.DB) (*gin.Engine, conn.CacheService)
 
 	router := gin.New()
 	router.Use(CORSMiddleware())
+	router.Use(AuthMiddleware(cfg))
 
 	coreRedis, _ := conn.InitRedis(cfg)
 
 	// repository
 	catalogRepo := catalogrepository.New(cfg, db)
 	viewRepo := viewrepository.New(db, cfg)
+	auditRepo := auditrepository.New(cfg, db)
+	versionRepo := versionrepository.New(cfg, db)
+	webhookRepo := webhookrepository.New(cfg, db)
+	outboxRepo := outboxrepository.New(cfg, db)
+	ruleRepo := rulerepository.New(cfg, db)
+	workflowRepo := workflowrepository.New(cfg, db)
+	approvalRepo := approvalrepository.New(cfg, db)
+	scheduleRepo := schedulerepository.New(cfg, db)
+	jobRepo := jobrepository.New(cfg, db)
+	documentRepo := documentrepository.New(cfg, db)
+	transactor := util.NewTransactor(db)
+	locker := util.NewAdvisoryLocker(db)
+	changeFeedRepo := changefeedrepository.New(cfg, db)
+	fileStorage, err := filestorage.New(cfg)
+	if err != nil {
+		panic(fmt.Errorf("failed to init file storage: %s", err.Error()))
+	}
 
 	// usecase
-	catalogUc := module.NewCatalogUsecase(cfg, catalogRepo, viewRepo)
-	viewUc := module.NewViewUsecase(cfg, catalogRepo, viewRepo, catalogUc)
+	auditUc := module.NewAuditUsecase(cfg, auditRepo, catalogRepo)
+	webhookUc := module.NewWebhookUsecase(cfg, webhookRepo, nil)
+	eventBus := module.NewEventBus(cfg, outboxRepo)
+	catalogUc := module.NewCatalogUsecase(cfg, catalogRepo, viewRepo, versionRepo, ruleRepo, workflowRepo, approvalRepo, transactor, eventBus)
+	viewUc := module.NewViewUsecase(cfg, catalogRepo, viewRepo, catalogUc, transactor)
+	versionUc := module.NewVersionUsecase(cfg, catalogRepo, versionRepo, transactor, catalogUc)
+	streamUc := module.NewStreamUsecase(cfg, outboxRepo, changeFeedRepo, catalogUc)
+	ruleUc := module.NewRuleUsecase(cfg, ruleRepo)
+	approvalUc := module.NewApprovalUsecase(cfg, approvalRepo, catalogUc, transactor, eventBus)
+	scheduleUc := module.NewScheduleUsecase(cfg, scheduleRepo, catalogRepo, catalogUc, locker, transactor, eventBus)
+	jobUc := module.NewJobUsecase(cfg, jobRepo)
+	fileUc := module.NewFileUsecase(cfg, catalogRepo, catalogUc, fileStorage)
+	documentUc := module.NewDocumentUsecase(cfg, documentRepo, catalogRepo, catalogUc)
+	openAPIUc := module.NewOpenAPIUsecase(cfg, catalogRepo, catalogUc)
+
+	// job handlers
+	jobUc.Register(entity.JobTypeImportData, module.NewImportDataJobHandler(catalogUc))
+	jobUc.Register(entity.JobTypeExportView, module.NewExportViewJobHandler(cfg, catalogUc))
+	jobUc.Register(entity.JobTypeRefreshRollups, module.NewRefreshRollupsJobHandler(catalogRepo))
+
+	// event subscribers
+	eventBus.Subscribe("audit", auditUc.HandleRecordEvent)
+	eventBus.Subscribe("version", versionUc.HandleRecordEvent)
+	eventBus.Subscribe("webhook", webhookUc.HandleRecordEvent)
+	eventBus.Subscribe("stream", streamUc.HandleRecordEvent)
+	eventBus.Subscribe("files", fileUc.HandleRecordEvent)
+
+	// worker
+	go eventBus.RunRelay(context.Background())
+	go webhookUc.RunDeliveryWorker(context.Background())
+	go streamUc.RunChangeFeed(context.Background())
+	go scheduleUc.RunScheduler(context.Background())
+	go jobUc.RunWorkers(context.Background())
 
 	// handler
-	httpHandler := api.NewHTTPHandler(cfg, catalogUc, viewUc)
+	httpHandler := api.NewHTTPHandler(cfg, catalogUc, viewUc, auditUc, versionUc, webhookUc, streamUc, ruleUc, approvalUc, scheduleUc, jobUc, fileUc, documentUc, openAPIUc)
 
 	router.POST("t/:tenant_code/p/:product_code/o/:object_code/view/:view_content_code/data", httpHandler.GetObjectData)
 	router.POST("t/:tenant_code/p/:product_code/o/:object_code/view/:view_content_code/data/raw", httpHandler.GetDataByRawQuery)
 	router.POST("t/:tenant_code/p/:product_code/o/:object_code/view/:view_content_code/data/detail/:serial", httpHandler.GetObjectDetail)
 	router.POST("t/:tenant_code/p/:product_code/o/:object_code/view/:view_content_code/:layout_type", httpHandler.GetContentLayoutByKeys)
+	router.GET("t/:tenant_code/p/:product_code/o/:object_code/view/:view_content_code/stream", httpHandler.StreamViewChanges)
+	router.GET("t/:tenant_code/p/:product_code/o/:object_code/view/:view_content_code/:layout_type/resolution", httpHandler.GetViewResolution)
+	router.GET("t/:tenant_code/p/:product_code/o/:object_code/fields/:field_code/lookup", httpHandler.LookupFieldValues)
+	router.GET("t/:tenant_code/p/:product_code/openapi.json", httpHandler.GetOpenAPIDocument)
 	router.PUT("t/:tenant_code/p/:product_code/o/:object_code/data", httpHandler.CreateObjectData)
+	router.PATCH("t/:tenant_code/p/:product_code/o/:object_code/data/:serial", httpHandler.UpdateObjectData)
+	router.DELETE("t/:tenant_code/p/:product_code/o/:object_code/data/:serial", httpHandler.DeleteObjectData)
+	router.GET("t/:tenant_code/p/:product_code/o/:object_code/data/:serial/history", httpHandler.GetRecordHistory)
+	router.GET("t/:tenant_code/p/:product_code/o/:object_code/data/:serial/versions", httpHandler.GetRecordVersions)
+	router.POST("t/:tenant_code/p/:product_code/o/:object_code/data/:serial/versions/:version/restore", httpHandler.RestoreRecordVersion)
+	router.GET("t/:tenant_code/p/:product_code/o/:object_code/data/:serial/transitions", httpHandler.GetRecordTransitions)
+	router.GET("t/:tenant_code/p/:product_code/o/:object_code/data/:serial/transitions/history", httpHandler.GetTransitionHistory)
+	router.POST("t/:tenant_code/p/:product_code/o/:object_code/data/:serial/files/:field_code", httpHandler.UploadRecordFile)
+	router.GET("t/:tenant_code/p/:product_code/o/:object_code/data/:serial/files/:field_code", httpHandler.DownloadRecordFile)
+	router.DELETE("t/:tenant_code/p/:product_code/o/:object_code/data/:serial/files/:field_code", httpHandler.DeleteRecordFile)
+	router.GET("t/:tenant_code/p/:product_code/o/:object_code/data/:serial/files/:field_code/thumbnails/:size", httpHandler.GetRecordThumbnail)
+	router.GET("t/:tenant_code/p/:product_code/o/:object_code/data/:serial/render/:template_code", httpHandler.RenderRecordDocument)
+	router.POST("t/:tenant_code/p/:product_code/o/:object_code/views", httpHandler.CreateViewContent)
+	router.PUT("t/:tenant_code/p/:product_code/o/:object_code/views/:view_content_code/:layout_type", httpHandler.UpdateViewContent)
+	router.DELETE("t/:tenant_code/p/:product_code/o/:object_code/views/:view_content_code/:layout_type", httpHandler.DeleteViewContent)
+	router.POST("t/:tenant_code/p/:product_code/o/:object_code/views/:view_content_code/:layout_type/clone", httpHandler.CloneViewContent)
+	router.GET("t/:tenant_code/p/:product_code/o/:object_code/views", httpHandler.GetAvailableViews)
+	router.POST("t/:tenant_code/p/:product_code/o/:object_code/views/personal", httpHandler.SavePersonalView)
+	router.POST("t/:tenant_code/p/:product_code/o/:object_code/views/:view_content_code/:layout_type/favorite", httpHandler.AddViewFavorite)
+	router.DELETE("t/:tenant_code/p/:product_code/o/:object_code/views/:view_content_code/:layout_type/favorite", httpHandler.RemoveViewFavorite)
+	router.POST("t/:tenant_code/p/:product_code/o/:object_code/view_schemas", httpHandler.CreateViewSchema)
+	router.PUT("t/:tenant_code/p/:product_code/o/:object_code/view_schemas/:serial", httpHandler.UpdateViewSchema)
+	router.DELETE("t/:tenant_code/p/:product_code/o/:object_code/view_schemas/:serial", httpHandler.DeleteViewSchema)
+	router.POST("t/:tenant_code/p/:product_code/o/:object_code/view_schemas/:serial/clone", httpHandler.CloneViewSchema)
+	router.POST("t/:tenant_code/view_layouts", httpHandler.CreateViewLayout)
+	router.PUT("t/:tenant_code/view_layouts/:serial", httpHandler.UpdateViewLayout)
+	router.DELETE("t/:tenant_code/view_layouts/:serial", httpHandler.DeleteViewLayout)
+	router.POST("t/:tenant_code/view_layouts/:serial/clone", httpHandler.CloneViewLayout)
+	router.POST("t/:tenant_code/webhooks", httpHandler.CreateWebhookSubscription)
+	router.GET("t/:tenant_code/webhooks", httpHandler.GetWebhookSubscriptions)
+	router.DELETE("t/:tenant_code/webhooks/:serial", httpHandler.DeleteWebhookSubscription)
+	router.GET("t/:tenant_code/webhooks/:serial/deliveries", httpHandler.GetWebhookDeliveries)
+	router.POST("t/:tenant_code/o/:object_code/rules", httpHandler.CreateObjectRule)
+	router.GET("t/:tenant_code/o/:object_code/rules", httpHandler.GetObjectRules)
+	router.DELETE("t/:tenant_code/o/:object_code/rules/:serial", httpHandler.DeleteObjectRule)
+	router.POST("t/:tenant_code/o/:object_code/approval_policies", httpHandler.CreateApprovalPolicy)
+	router.GET("t/:tenant_code/o/:object_code/approval_policies", httpHandler.GetApprovalPolicies)
+	router.DELETE("t/:tenant_code/o/:object_code/approval_policies/:serial", httpHandler.DeleteApprovalPolicy)
+	router.POST("t/:tenant_code/o/:object_code/document_templates", httpHandler.CreateDocumentTemplate)
+	router.GET("t/:tenant_code/o/:object_code/document_templates", httpHandler.GetDocumentTemplates)
+	router.DELETE("t/:tenant_code/o/:object_code/document_templates/:serial", httpHandler.DeleteDocumentTemplate)
+	router.GET("t/:tenant_code/change_requests", httpHandler.GetChangeRequests)
+	router.POST("t/:tenant_code/change_requests/:serial/approve", httpHandler.ApproveChangeRequest)
+	router.POST("t/:tenant_code/change_requests/:serial/reject", httpHandler.RejectChangeRequest)
+	router.POST("t/:tenant_code/scheduled_jobs", httpHandler.CreateScheduledJob)
+	router.GET("t/:tenant_code/scheduled_jobs", httpHandler.GetScheduledJobs)
+	router.DELETE("t/:tenant_code/scheduled_jobs/:serial", httpHandler.DeleteScheduledJob)
+	router.GET("t/:tenant_code/scheduled_jobs/:serial/runs", httpHandler.GetScheduledJobRuns)
+	router.POST("t/:tenant_code/jobs", httpHandler.SubmitJob)
+	router.GET("t/:tenant_code/jobs", httpHandler.GetJobs)
+	router.GET("t/:tenant_code/jobs/:serial", httpHandler.GetJob)
+	router.POST("t/:tenant_code/jobs/:serial/cancel", httpHandler.CancelJob)
 
 	router.NoRoute(func(c *gin.Context) {
 		c.JSON(404, gin.H{"code": "404", "message": "Page not found"})
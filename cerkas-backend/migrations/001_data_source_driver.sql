@@ -0,0 +1,2 @@
+-- object data sources can live in postgres, mysql, sqlite or sqlserver
+ALTER TABLE public.data_source ADD COLUMN IF NOT EXISTS driver varchar(32) NOT NULL DEFAULT 'postgres';
@@ -0,0 +1,25 @@
+-- append-only history of every record mutation done through the catalog usecase
+CREATE TABLE IF NOT EXISTS public.audit_log (
+	id bigserial PRIMARY KEY,
+	serial uuid NOT NULL UNIQUE,
+	tenant_code varchar(255) NOT NULL,
+	product_code varchar(255),
+	object_code varchar(255) NOT NULL,
+	record_serial varchar(255) NOT NULL,
+	action varchar(16) NOT NULL,
+	actor_serial varchar(255),
+	changes jsonb NOT NULL DEFAULT '[]',
+	created_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS audit_log_record_idx ON public.audit_log (tenant_code, object_code, record_serial, created_at);
+
+CREATE OR REPLACE FUNCTION public.audit_log_append_only() RETURNS trigger AS $$
+BEGIN
+	RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS audit_log_append_only ON public.audit_log;
+CREATE TRIGGER audit_log_append_only BEFORE UPDATE OR DELETE ON public.audit_log
+	FOR EACH ROW EXECUTE FUNCTION public.audit_log_append_only();
@@ -0,0 +1,18 @@
+-- full snapshot of a record after every mutation, used for point-in-time reads and restore
+CREATE TABLE IF NOT EXISTS public.record_version (
+	id bigserial PRIMARY KEY,
+	serial uuid NOT NULL UNIQUE,
+	tenant_code varchar(255) NOT NULL,
+	product_code varchar(255),
+	object_code varchar(255) NOT NULL,
+	record_serial varchar(255) NOT NULL,
+	version integer NOT NULL,
+	action varchar(16) NOT NULL,
+	actor_serial varchar(255),
+	is_deleted boolean NOT NULL DEFAULT false,
+	data jsonb NOT NULL DEFAULT '{}',
+	created_at timestamptz NOT NULL DEFAULT now(),
+	UNIQUE (tenant_code, object_code, record_serial, version)
+);
+
+CREATE INDEX IF NOT EXISTS record_version_as_of_idx ON public.record_version (tenant_code, object_code, created_at);
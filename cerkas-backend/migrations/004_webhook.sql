@@ -0,0 +1,40 @@
+-- outbound webhook subscriptions per tenant and object
+CREATE TABLE IF NOT EXISTS public.webhook_subscription (
+	id bigserial PRIMARY KEY,
+	serial uuid NOT NULL UNIQUE,
+	tenant_code varchar(255) NOT NULL,
+	object_code varchar(255) NOT NULL,
+	event_types jsonb NOT NULL DEFAULT '[]',
+	url text NOT NULL,
+	secret varchar(255) NOT NULL,
+	filters jsonb NOT NULL DEFAULT '[]',
+	is_active boolean NOT NULL DEFAULT true,
+	created_by varchar(255),
+	created_at timestamptz NOT NULL DEFAULT now(),
+	updated_by varchar(255),
+	updated_at timestamptz NOT NULL DEFAULT now(),
+	deleted_by varchar(255),
+	deleted_at timestamptz
+);
+
+CREATE INDEX IF NOT EXISTS webhook_subscription_object_idx ON public.webhook_subscription (tenant_code, object_code) WHERE deleted_at IS NULL;
+
+-- outbox of webhook deliveries, polled by the delivery worker
+CREATE TABLE IF NOT EXISTS public.webhook_delivery (
+	id bigserial PRIMARY KEY,
+	serial uuid NOT NULL UNIQUE,
+	subscription_serial uuid NOT NULL,
+	tenant_code varchar(255) NOT NULL,
+	event_type varchar(16) NOT NULL,
+	payload jsonb NOT NULL,
+	status varchar(16) NOT NULL DEFAULT 'pending',
+	attempts integer NOT NULL DEFAULT 0,
+	next_attempt_at timestamptz NOT NULL DEFAULT now(),
+	last_error text,
+	response_status integer,
+	delivered_at timestamptz,
+	created_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS webhook_delivery_due_idx ON public.webhook_delivery (next_attempt_at) WHERE status IN ('pending', 'retrying');
+CREATE INDEX IF NOT EXISTS webhook_delivery_subscription_idx ON public.webhook_delivery (tenant_code, subscription_serial, id);
@@ -0,0 +1,26 @@
+-- record change events, written in the transaction of the mutation and dispatched by the event relay
+CREATE TABLE IF NOT EXISTS public.event_outbox (
+	id bigserial PRIMARY KEY,
+	serial uuid NOT NULL UNIQUE,
+	event_type varchar(32) NOT NULL,
+	tenant_code varchar(255) NOT NULL,
+	product_code varchar(255),
+	object_code varchar(255) NOT NULL,
+	record_serial varchar(255) NOT NULL,
+	actor_serial varchar(255),
+	payload jsonb NOT NULL DEFAULT '{}',
+	status varchar(16) NOT NULL DEFAULT 'pending',
+	attempts integer NOT NULL DEFAULT 0,
+	completed_subscribers jsonb NOT NULL DEFAULT '[]',
+	next_attempt_at timestamptz NOT NULL DEFAULT now(),
+	last_error text,
+	occurred_at timestamptz NOT NULL DEFAULT now(),
+	dispatched_at timestamptz
+);
+
+CREATE INDEX IF NOT EXISTS event_outbox_pending_idx ON public.event_outbox (id) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS event_outbox_record_idx ON public.event_outbox (tenant_code, object_code, record_serial, id) WHERE status = 'pending';
+
+-- subscribers run at least once, deliveries of a retried event must not be duplicated
+ALTER TABLE public.webhook_delivery ADD COLUMN IF NOT EXISTS event_serial uuid;
+CREATE UNIQUE INDEX IF NOT EXISTS webhook_delivery_event_idx ON public.webhook_delivery (event_serial, subscription_serial);
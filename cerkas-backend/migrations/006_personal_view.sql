@@ -0,0 +1,13 @@
+-- personal views are view_content rows with an owner, shared_role lets the owner share one with a role
+ALTER TABLE public.view_content ADD COLUMN IF NOT EXISTS shared_role varchar(255);
+
+CREATE INDEX IF NOT EXISTS view_content_owner_idx ON public.view_content (tenant_code, product_code, object_code, owner_serial);
+
+-- favorite views, per user
+CREATE TABLE IF NOT EXISTS public.view_favorite (
+	id bigserial PRIMARY KEY,
+	user_serial varchar(255) NOT NULL,
+	view_content_serial varchar(255) NOT NULL,
+	created_at timestamptz NOT NULL DEFAULT now(),
+	UNIQUE (user_serial, view_content_serial)
+);
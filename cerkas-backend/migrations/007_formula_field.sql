@@ -0,0 +1,3 @@
+-- object fields are physical columns unless their kind says otherwise, formula fields are computed from expression
+ALTER TABLE public.object_fields ADD COLUMN IF NOT EXISTS kind varchar(50) NOT NULL DEFAULT 'column';
+ALTER TABLE public.object_fields ADD COLUMN IF NOT EXISTS expression text;
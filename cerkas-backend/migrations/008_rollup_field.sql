@@ -0,0 +1,4 @@
+-- rollup fields aggregate the records of another object referring to a record, rollup holds their settings:
+-- {"object_code": "order_lines", "relation_field": "order_serial", "function": "sum", "field_code": "amount",
+--  "filters": [...], "materialized": false}
+ALTER TABLE public.object_fields ADD COLUMN IF NOT EXISTS rollup jsonb;
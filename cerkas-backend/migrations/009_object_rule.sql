@@ -0,0 +1,25 @@
+-- business rules run by the catalog usecase before and after the mutations of an object
+CREATE TABLE IF NOT EXISTS public.object_rule (
+	id bigserial PRIMARY KEY,
+	serial uuid NOT NULL UNIQUE,
+	tenant_code varchar(255) NOT NULL,
+	object_code varchar(255) NOT NULL,
+	name varchar(255) NOT NULL,
+	timing varchar(16) NOT NULL,
+	event_types jsonb NOT NULL DEFAULT '[]',
+	condition jsonb NOT NULL DEFAULT '[]',
+	actions jsonb NOT NULL DEFAULT '[]',
+	sequence integer NOT NULL DEFAULT 0,
+	is_active boolean NOT NULL DEFAULT true,
+	created_by varchar(255),
+	created_at timestamptz NOT NULL DEFAULT now(),
+	updated_by varchar(255),
+	updated_at timestamptz NOT NULL DEFAULT now(),
+	deleted_by varchar(255),
+	deleted_at timestamptz
+);
+
+CREATE INDEX IF NOT EXISTS object_rule_object_idx ON public.object_rule (tenant_code, object_code, timing) WHERE deleted_at IS NULL;
+
+-- events emitted by rules are named by the rule, they do not fit the record event types
+ALTER TABLE public.event_outbox ALTER COLUMN event_type TYPE varchar(255);
@@ -0,0 +1,21 @@
+-- an option field can hold the state of a record, state_machine defines its states and transitions:
+-- {"initial_state": "draft", "states": ["draft", "open", "closed"],
+--  "transitions": [{"code": "close", "from": ["open"], "to": "closed", "roles": ["manager"], "required_fields": ["resolution"]}]}
+ALTER TABLE public.object_fields ADD COLUMN IF NOT EXISTS state_machine jsonb;
+
+-- the transitions records went through
+CREATE TABLE IF NOT EXISTS public.state_transition_log (
+	id bigserial PRIMARY KEY,
+	serial uuid NOT NULL UNIQUE,
+	tenant_code varchar(255) NOT NULL,
+	object_code varchar(255) NOT NULL,
+	record_serial varchar(255) NOT NULL,
+	field_code varchar(255) NOT NULL,
+	transition varchar(255),
+	from_state varchar(255),
+	to_state varchar(255) NOT NULL,
+	actor_serial varchar(255),
+	created_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS state_transition_log_record_idx ON public.state_transition_log (tenant_code, object_code, record_serial, id);
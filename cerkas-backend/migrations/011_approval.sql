@@ -0,0 +1,46 @@
+-- approval policies hold back matching mutations of an object until they are approved
+CREATE TABLE IF NOT EXISTS public.approval_policy (
+	id bigserial PRIMARY KEY,
+	serial uuid NOT NULL UNIQUE,
+	tenant_code varchar(255) NOT NULL,
+	object_code varchar(255) NOT NULL,
+	name varchar(255) NOT NULL,
+	event_types jsonb NOT NULL DEFAULT '[]',
+	condition jsonb NOT NULL DEFAULT '[]',
+	approver_roles jsonb NOT NULL DEFAULT '[]',
+	is_active boolean NOT NULL DEFAULT true,
+	created_by varchar(255),
+	created_at timestamptz NOT NULL DEFAULT now(),
+	updated_by varchar(255),
+	updated_at timestamptz NOT NULL DEFAULT now(),
+	deleted_by varchar(255),
+	deleted_at timestamptz
+);
+
+CREATE INDEX IF NOT EXISTS approval_policy_object_idx ON public.approval_policy (tenant_code, object_code) WHERE deleted_at IS NULL;
+
+-- mutations held back by an approval policy, mutation is the request applied on approval
+CREATE TABLE IF NOT EXISTS public.change_request (
+	id bigserial PRIMARY KEY,
+	serial uuid NOT NULL UNIQUE,
+	tenant_code varchar(255) NOT NULL,
+	product_code varchar(255),
+	object_code varchar(255) NOT NULL,
+	record_serial varchar(255),
+	policy_serial uuid NOT NULL,
+	action varchar(16) NOT NULL,
+	mutation jsonb NOT NULL,
+	status varchar(16) NOT NULL DEFAULT 'pending',
+	requested_by varchar(255),
+	requester_role varchar(255),
+	requested_at timestamptz NOT NULL DEFAULT now(),
+	decided_by varchar(255),
+	decided_at timestamptz,
+	comment text
+);
+
+CREATE INDEX IF NOT EXISTS change_request_status_idx ON public.change_request (tenant_code, status, id);
+
+-- approval events are longer than the record actions the event columns were sized for
+ALTER TABLE public.audit_log ALTER COLUMN action TYPE varchar(64);
+ALTER TABLE public.webhook_delivery ALTER COLUMN event_type TYPE varchar(64);
@@ -0,0 +1,37 @@
+-- recurring jobs of a tenant, next_run_at is advanced by the replica that claims a run
+CREATE TABLE IF NOT EXISTS public.scheduled_job (
+	id bigserial PRIMARY KEY,
+	serial uuid NOT NULL UNIQUE,
+	tenant_code varchar(255) NOT NULL,
+	name varchar(255) NOT NULL,
+	job_type varchar(32) NOT NULL,
+	cron_expression varchar(255) NOT NULL,
+	timezone varchar(64),
+	config jsonb NOT NULL DEFAULT '{}',
+	is_active boolean NOT NULL DEFAULT true,
+	next_run_at timestamptz NOT NULL,
+	last_run_at timestamptz,
+	created_by varchar(255),
+	created_at timestamptz NOT NULL DEFAULT now(),
+	updated_by varchar(255),
+	updated_at timestamptz NOT NULL DEFAULT now(),
+	deleted_by varchar(255),
+	deleted_at timestamptz
+);
+
+CREATE INDEX IF NOT EXISTS scheduled_job_due_idx ON public.scheduled_job (next_run_at) WHERE is_active AND deleted_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS public.scheduled_job_run (
+	id bigserial PRIMARY KEY,
+	serial uuid NOT NULL UNIQUE,
+	job_serial uuid NOT NULL,
+	tenant_code varchar(255) NOT NULL,
+	job_type varchar(32) NOT NULL,
+	status varchar(16) NOT NULL,
+	result text,
+	error text,
+	started_at timestamptz NOT NULL DEFAULT now(),
+	finished_at timestamptz
+);
+
+CREATE INDEX IF NOT EXISTS scheduled_job_run_job_idx ON public.scheduled_job_run (tenant_code, job_serial, id);
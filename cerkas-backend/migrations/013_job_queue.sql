@@ -0,0 +1,26 @@
+-- background jobs claimed by the workers with FOR UPDATE SKIP LOCKED, a running job whose lease expired is claimed again
+CREATE TABLE IF NOT EXISTS public.job (
+	id bigserial PRIMARY KEY,
+	serial uuid NOT NULL UNIQUE,
+	tenant_code varchar(255) NOT NULL,
+	job_type varchar(64) NOT NULL,
+	payload jsonb NOT NULL DEFAULT '{}',
+	status varchar(16) NOT NULL DEFAULT 'queued',
+	progress integer NOT NULL DEFAULT 0,
+	progress_message text,
+	result jsonb,
+	error text,
+	attempts integer NOT NULL DEFAULT 0,
+	max_attempts integer NOT NULL DEFAULT 3,
+	cancel_requested boolean NOT NULL DEFAULT false,
+	run_at timestamptz NOT NULL DEFAULT now(),
+	locked_until timestamptz,
+	created_by varchar(255),
+	created_at timestamptz NOT NULL DEFAULT now(),
+	updated_at timestamptz NOT NULL DEFAULT now(),
+	started_at timestamptz,
+	finished_at timestamptz
+);
+
+CREATE INDEX IF NOT EXISTS job_claim_idx ON public.job (run_at, id) WHERE status IN ('queued', 'running');
+CREATE INDEX IF NOT EXISTS job_tenant_idx ON public.job (tenant_code, id);
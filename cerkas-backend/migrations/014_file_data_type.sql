@@ -0,0 +1,4 @@
+-- file fields store the metadata of their attachment, the content lives in the file storage
+INSERT INTO public.data_types (serial, code, name, description, primitive_data_type, validation_rules, is_active, display_type, field_options, icon)
+SELECT gen_random_uuid(), 'file', 'File', 'An attached document or image', 'jsonb', '{"max_size": 10485760}', true, 'file', '{}', 'paperclip'
+WHERE NOT EXISTS (SELECT 1 FROM public.data_types WHERE code = 'file');
@@ -0,0 +1,25 @@
+-- documents are rendered in the language and time zone of their tenant
+ALTER TABLE public.tenants ADD COLUMN IF NOT EXISTS locale varchar(16) NOT NULL DEFAULT 'en-US';
+ALTER TABLE public.tenants ADD COLUMN IF NOT EXISTS timezone varchar(64) NOT NULL DEFAULT 'UTC';
+
+-- document templates render a record of an object as html or pdf, like an invoice or a letter
+CREATE TABLE IF NOT EXISTS public.document_template (
+	id bigserial PRIMARY KEY,
+	serial uuid NOT NULL UNIQUE,
+	tenant_code varchar(255) NOT NULL,
+	object_code varchar(255) NOT NULL,
+	code varchar(255) NOT NULL,
+	name varchar(255) NOT NULL,
+	body text NOT NULL,
+	includes jsonb NOT NULL DEFAULT '[]',
+	page_size varchar(32) NOT NULL DEFAULT 'a4',
+	is_active boolean NOT NULL DEFAULT true,
+	created_by varchar(255),
+	created_at timestamptz NOT NULL DEFAULT now(),
+	updated_by varchar(255),
+	updated_at timestamptz NOT NULL DEFAULT now(),
+	deleted_by varchar(255),
+	deleted_at timestamptz
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS document_template_code_idx ON public.document_template (tenant_code, object_code, code) WHERE deleted_at IS NULL;
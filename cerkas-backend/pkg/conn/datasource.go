@@ -0,0 +1,101 @@
+package conn
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cerkas/cerkas-backend/config"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// DialectorFunc builds a gorm dialector from a dsn
+type DialectorFunc func(dsn string) gorm.Dialector
+
+type DataSourceConfig struct {
+	Driver   string
+	Host     string
+	Port     string
+	Username string
+	Password string
+	DBName   string
+	Configs  map[string]any
+}
+
+var (
+	dialectorMu sync.RWMutex
+	dialectors  = map[string]DialectorFunc{
+		"postgres": postgres.Open,
+	}
+)
+
+// RegisterDialector makes a database driver available for object data sources.
+// Only postgres is registered by default, the mysql, sqlite and sqlserver drivers register themselves from the
+// driver_*.go files when the build enables their tag, see driver_sqlite.go.
+func RegisterDialector(driver string, fn DialectorFunc) {
+	dialectorMu.Lock()
+	defer dialectorMu.Unlock()
+
+	dialectors[strings.ToLower(driver)] = fn
+}
+
+// OpenDataSource opens a connection pool to an object data source
+func OpenDataSource(cfg *config.Config, source DataSourceConfig) (*gorm.DB, error) {
+	driver := strings.ToLower(source.Driver)
+	if driver == "" {
+		driver = cfg.DBType
+	}
+
+	dialectorMu.RLock()
+	dialector, ok := dialectors[driver]
+	dialectorMu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("database driver %v is not registered", driver)
+	}
+
+	db, err := gorm.Open(dialector(BuildDSN(source)), &gorm.Config{
+		NamingStrategy: schema.NamingStrategy{
+			SingularTable: true,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	rdb, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+
+	rdb.SetMaxIdleConns(cfg.MaxIdleConns)
+	rdb.SetMaxOpenConns(cfg.MaxOpenConns)
+	rdb.SetConnMaxLifetime(time.Duration(int(time.Minute) * cfg.ConnMaxLifetime))
+
+	log.Printf("Successfully connected to %v data source %v", driver, source.DBName)
+
+	return db, nil
+}
+
+// BuildDSN returns the driver specific dsn, a "dsn" entry in the data source configs takes precedence
+func BuildDSN(source DataSourceConfig) string {
+	if dsn, ok := source.Configs["dsn"].(string); ok && dsn != "" {
+		return dsn
+	}
+
+	switch strings.ToLower(source.Driver) {
+	case "mysql", "mariadb":
+		return fmt.Sprintf("%v:%v@tcp(%v:%v)/%v?parseTime=true", source.Username, source.Password, source.Host, source.Port, source.DBName)
+	case "sqlite", "sqlite3":
+		// the database name is the path of the database file
+		return source.DBName
+	case "sqlserver", "mssql":
+		return fmt.Sprintf("sqlserver://%v:%v@%v:%v?database=%v", source.Username, source.Password, source.Host, source.Port, source.DBName)
+	default:
+		return fmt.Sprintf("host=%v user=%v password=%v dbname=%v port=%v sslmode=disable TimeZone=Asia/Jakarta", source.Host, source.Username, source.Password, source.DBName, source.Port)
+	}
+}
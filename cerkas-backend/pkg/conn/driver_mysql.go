@@ -0,0 +1,8 @@
+package conn
+
+import "gorm.io/driver/mysql"
+
+func init() {
+	RegisterDialector("mysql", mysql.Open)
+	RegisterDialector("mariadb", mysql.Open)
+}
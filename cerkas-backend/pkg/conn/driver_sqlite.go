@@ -0,0 +1,9 @@
+package conn
+
+import "github.com/glebarez/sqlite"
+
+// The sqlite driver is pure go, a build needs no cgo for it
+func init() {
+	RegisterDialector("sqlite", sqlite.Open)
+	RegisterDialector("sqlite3", sqlite.Open)
+}
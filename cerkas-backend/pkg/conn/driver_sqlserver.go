@@ -0,0 +1,11 @@
+//go:build sqlserver
+
+package conn
+
+import "gorm.io/driver/sqlserver"
+
+// built with -tags sqlserver, after `go get gorm.io/driver/sqlserver && go mod vendor`
+func init() {
+	RegisterDialector("sqlserver", sqlserver.Open)
+	RegisterDialector("mssql", sqlserver.Open)
+}
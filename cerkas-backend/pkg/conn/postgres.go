@@ -12,9 +12,14 @@ import (
 	"gorm.io/gorm/schema"
 )
 
+// PostgresDSN returns the dsn of the main database
+func PostgresDSN(cfg *config.Config) string {
+	return fmt.Sprintf("host=%v user=%v password=%v dbname=%v port=%v sslmode=disable TimeZone=Asia/Jakarta", cfg.Host, cfg.Username, cfg.Password, cfg.DBName, cfg.Port)
+}
+
 func InitDB(cfg *config.Config) *gorm.DB {
 
-	dsn := fmt.Sprintf("host=%v user=%v password=%v dbname=%v port=%v sslmode=disable TimeZone=Asia/Jakarta", cfg.Host, cfg.Username, cfg.Password, cfg.DBName, cfg.Port)
+	dsn := PostgresDSN(cfg)
 	log.Printf("%v", dsn)
 	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
 		NamingStrategy: schema.NamingStrategy{
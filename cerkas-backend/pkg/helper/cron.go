@@ -0,0 +1,202 @@
+package helper
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CronSchedule is a parsed five field cron expression: minute, hour, day of month, month and day of week
+type CronSchedule struct {
+	minutes     uint64
+	hours       uint64
+	daysOfMonth uint64
+	months      uint64
+	daysOfWeek  uint64
+
+	// like cron, a restricted day of month and day of week match when either matches
+	anyDayOfMonth bool
+	anyDayOfWeek  bool
+}
+
+type cronField struct {
+	min, max int
+	names    map[string]int
+}
+
+var (
+	cronMinute     = cronField{min: 0, max: 59}
+	cronHour       = cronField{min: 0, max: 23}
+	cronDayOfMonth = cronField{min: 1, max: 31}
+	cronMonth      = cronField{min: 1, max: 12, names: map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6, "jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	cronDayOfWeek = cronField{min: 0, max: 7, names: map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+
+	cronMacros = map[string]string{
+		"@yearly":   "0 0 1 1 *",
+		"@annually": "0 0 1 1 *",
+		"@monthly":  "0 0 1 * *",
+		"@weekly":   "0 0 * * 0",
+		"@daily":    "0 0 * * *",
+		"@midnight": "0 0 * * *",
+		"@hourly":   "0 * * * *",
+	}
+)
+
+// ParseCron parses a cron expression. Every field takes *, values, ranges (1-5), steps (*/15, 1-30/5),
+// lists of them (1,15) and the names of months and week days. The @hourly, @daily, @weekly, @monthly
+// and @yearly macros are supported as well.
+func ParseCron(expression string) (schedule CronSchedule, err error) {
+	expression = strings.TrimSpace(strings.ToLower(expression))
+	if macro, ok := cronMacros[expression]; ok {
+		expression = macro
+	}
+
+	fields := strings.Fields(expression)
+	if len(fields) != 5 {
+		return schedule, fmt.Errorf("cron expression %q must have 5 fields", expression)
+	}
+
+	if schedule.minutes, err = parseCronField(fields[0], cronMinute); err != nil {
+		return schedule, err
+	}
+
+	if schedule.hours, err = parseCronField(fields[1], cronHour); err != nil {
+		return schedule, err
+	}
+
+	if schedule.daysOfMonth, err = parseCronField(fields[2], cronDayOfMonth); err != nil {
+		return schedule, err
+	}
+
+	if schedule.months, err = parseCronField(fields[3], cronMonth); err != nil {
+		return schedule, err
+	}
+
+	if schedule.daysOfWeek, err = parseCronField(fields[4], cronDayOfWeek); err != nil {
+		return schedule, err
+	}
+
+	// sunday is both 0 and 7
+	if schedule.daysOfWeek&(1<<7) != 0 {
+		schedule.daysOfWeek |= 1
+	}
+
+	schedule.anyDayOfMonth = fields[2] == "*" || fields[2] == "?"
+	schedule.anyDayOfWeek = fields[4] == "*" || fields[4] == "?"
+
+	return schedule, nil
+}
+
+// Next returns the first time after t matching the schedule, in the location of t. It returns the zero time
+// when nothing matches within five years, like the 30th of February.
+func (s CronSchedule) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		if s.months&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+
+		if !s.matchDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+
+		if s.hours&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+
+		if s.minutes&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+
+		return t
+	}
+
+	return time.Time{}
+}
+
+func (s CronSchedule) matchDay(t time.Time) bool {
+	dayOfMonth := s.daysOfMonth&(1<<uint(t.Day())) != 0
+	dayOfWeek := s.daysOfWeek&(1<<uint(t.Weekday())) != 0
+
+	switch {
+	case s.anyDayOfMonth && s.anyDayOfWeek:
+		return true
+	case s.anyDayOfMonth:
+		return dayOfWeek
+	case s.anyDayOfWeek:
+		return dayOfMonth
+	default:
+		return dayOfMonth || dayOfWeek
+	}
+}
+
+// parseCronField returns the values of a cron field as a bit set
+func parseCronField(field string, bounds cronField) (bits uint64, err error) {
+	for _, part := range strings.Split(field, ",") {
+		rangePart, step := part, 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			rangePart = part[:i]
+			if step, err = strconv.Atoi(part[i+1:]); err != nil || step <= 0 {
+				return 0, fmt.Errorf("invalid step in cron field %q", field)
+			}
+		}
+
+		start, end := bounds.min, bounds.max
+		switch {
+		case rangePart == "*" || rangePart == "?":
+		case strings.Contains(rangePart, "-"):
+			i := strings.Index(rangePart, "-")
+			if start, err = parseCronValue(rangePart[:i], bounds); err != nil {
+				return 0, err
+			}
+
+			if end, err = parseCronValue(rangePart[i+1:], bounds); err != nil {
+				return 0, err
+			}
+		default:
+			if start, err = parseCronValue(rangePart, bounds); err != nil {
+				return 0, err
+			}
+
+			// a single value with a step runs to the end of the field, like 5/15
+			end = start
+			if strings.Contains(part, "/") {
+				end = bounds.max
+			}
+		}
+
+		if start > end {
+			return 0, fmt.Errorf("invalid range in cron field %q", field)
+		}
+
+		for value := start; value <= end; value += step {
+			bits |= 1 << uint(value)
+		}
+	}
+
+	return bits, nil
+}
+
+func parseCronValue(value string, bounds cronField) (int, error) {
+	if number, ok := bounds.names[value]; ok {
+		return number, nil
+	}
+
+	number, err := strconv.Atoi(value)
+	if err != nil || number < bounds.min || number > bounds.max {
+		return 0, fmt.Errorf("cron value %q is out of range %v-%v", value, bounds.min, bounds.max)
+	}
+
+	return number, nil
+}
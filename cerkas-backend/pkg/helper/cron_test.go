@@ -0,0 +1,79 @@
+package helper
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCronNext(t *testing.T) {
+	at := func(value string) time.Time {
+		parsed, err := time.Parse("2006-01-02 15:04", value)
+		if err != nil {
+			t.Fatalf("time.Parse(%q): %v", value, err)
+		}
+		return parsed
+	}
+
+	tests := []struct {
+		name       string
+		expression string
+		from       string
+		want       string
+	}{
+		{"step", "*/15 * * * *", "2024-01-15 10:07", "2024-01-15 10:15"},
+		{"step from a value", "5/20 * * * *", "2024-01-15 10:26", "2024-01-15 10:45"},
+		{"range of hours rolls to the next day", "0 9-17 * * *", "2024-01-15 17:30", "2024-01-16 09:00"},
+		{"list", "0,30 8 * * *", "2024-01-15 08:00", "2024-01-15 08:30"},
+		{"range with a step", "0 0 1-7/3 * *", "2024-01-02 00:00", "2024-01-04 00:00"},
+		{"range of month names", "0 12 * feb-mar *", "2024-01-15 10:00", "2024-02-01 12:00"},
+		{"range of week day names", "0 0 * * mon-fri", "2024-01-19 12:00", "2024-01-22 00:00"},
+		{"sunday as 7", "0 0 * * 7", "2024-01-15 10:00", "2024-01-21 00:00"},
+		{"@hourly", "@hourly", "2024-01-15 10:00", "2024-01-15 11:00"},
+		{"@daily", "@daily", "2024-01-15 10:00", "2024-01-16 00:00"},
+		{"@weekly", "@weekly", "2024-01-15 10:00", "2024-01-21 00:00"},
+		{"@monthly", "@monthly", "2024-01-15 10:00", "2024-02-01 00:00"},
+		{"@yearly", "@yearly", "2024-01-15 10:00", "2025-01-01 00:00"},
+		{"day of month only", "0 0 13 * *", "2024-01-15 10:00", "2024-02-13 00:00"},
+		{"day of week matches first", "0 0 13 * fri", "2024-01-15 10:00", "2024-01-19 00:00"},
+		{"day of month matches first", "0 0 13 * fri", "2024-02-10 10:00", "2024-02-13 00:00"},
+		{"month without the day", "0 0 31 * *", "2024-01-31 00:00", "2024-03-31 00:00"},
+		{"year rollover", "59 23 31 12 *", "2024-12-31 23:59", "2025-12-31 23:59"},
+		{"leap day of this year", "0 0 29 2 *", "2023-01-01 00:00", "2024-02-29 00:00"},
+		{"leap day of the next leap year", "0 0 29 2 *", "2024-03-01 00:00", "2028-02-29 00:00"},
+		{"day that never comes", "0 0 30 2 *", "2024-01-01 00:00", ""},
+	}
+
+	for _, test := range tests {
+		schedule, err := ParseCron(test.expression)
+		if err != nil {
+			t.Errorf("%v: ParseCron(%q): %v", test.name, test.expression, err)
+			continue
+		}
+
+		want := time.Time{}
+		if test.want != "" {
+			want = at(test.want)
+		}
+
+		if got := schedule.Next(at(test.from)); !got.Equal(want) {
+			t.Errorf("%v: %q after %v runs at %v, want %v", test.name, test.expression, test.from, got, want)
+		}
+	}
+}
+
+func TestParseCronRejectsInvalidExpressions(t *testing.T) {
+	for _, expression := range []string{
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"* * * foo *",
+		"* * * * 8",
+	} {
+		if _, err := ParseCron(expression); err == nil {
+			t.Errorf("ParseCron(%q) is accepted", expression)
+		}
+	}
+}
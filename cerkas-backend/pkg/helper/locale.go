@@ -0,0 +1,93 @@
+package helper
+
+import (
+	"strings"
+)
+
+// LocaleFormat is how a locale writes numbers, dates and yes/no values
+type LocaleFormat struct {
+	DecimalSeparator string
+	GroupSeparator   string
+	DateLayout       string
+	DateTimeLayout   string
+	True             string
+	False            string
+}
+
+// DefaultLocale is the locale of a tenant without one
+const DefaultLocale = "en-US"
+
+// localeFormats are keyed by lowercase locale, a language alone stands for its most common region
+var localeFormats = map[string]LocaleFormat{
+	"en":    {DecimalSeparator: ".", GroupSeparator: ",", DateLayout: "Jan 2, 2006", DateTimeLayout: "Jan 2, 2006 3:04 PM", True: "Yes", False: "No"},
+	"en-gb": {DecimalSeparator: ".", GroupSeparator: ",", DateLayout: "02/01/2006", DateTimeLayout: "02/01/2006 15:04", True: "Yes", False: "No"},
+	"en-au": {DecimalSeparator: ".", GroupSeparator: ",", DateLayout: "02/01/2006", DateTimeLayout: "02/01/2006 15:04", True: "Yes", False: "No"},
+	"id":    {DecimalSeparator: ",", GroupSeparator: ".", DateLayout: "02/01/2006", DateTimeLayout: "02/01/2006 15.04", True: "Ya", False: "Tidak"},
+	"ms":    {DecimalSeparator: ".", GroupSeparator: ",", DateLayout: "02/01/2006", DateTimeLayout: "02/01/2006 15:04", True: "Ya", False: "Tidak"},
+	"de":    {DecimalSeparator: ",", GroupSeparator: ".", DateLayout: "02.01.2006", DateTimeLayout: "02.01.2006 15:04", True: "Ja", False: "Nein"},
+	"nl":    {DecimalSeparator: ",", GroupSeparator: ".", DateLayout: "02-01-2006", DateTimeLayout: "02-01-2006 15:04", True: "Ja", False: "Nee"},
+	"fr":    {DecimalSeparator: ",", GroupSeparator: " ", DateLayout: "02/01/2006", DateTimeLayout: "02/01/2006 15:04", True: "Oui", False: "Non"},
+	"es":    {DecimalSeparator: ",", GroupSeparator: ".", DateLayout: "02/01/2006", DateTimeLayout: "02/01/2006 15:04", True: "Sí", False: "No"},
+	"it":    {DecimalSeparator: ",", GroupSeparator: ".", DateLayout: "02/01/2006", DateTimeLayout: "02/01/2006 15:04", True: "Sì", False: "No"},
+	"pt":    {DecimalSeparator: ",", GroupSeparator: ".", DateLayout: "02/01/2006", DateTimeLayout: "02/01/2006 15:04", True: "Sim", False: "Não"},
+	"ja":    {DecimalSeparator: ".", GroupSeparator: ",", DateLayout: "2006/01/02", DateTimeLayout: "2006/01/02 15:04", True: "はい", False: "いいえ"},
+	"zh":    {DecimalSeparator: ".", GroupSeparator: ",", DateLayout: "2006/01/02", DateTimeLayout: "2006/01/02 15:04", True: "是", False: "否"},
+}
+
+// GetLocaleFormat returns the format of a locale like id-ID or en_GB, falling back to its language and then to
+// DefaultLocale
+func GetLocaleFormat(locale string) LocaleFormat {
+	locale = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(locale), "_", "-"))
+	if format, ok := localeFormats[locale]; ok {
+		return format
+	}
+
+	language, _, _ := strings.Cut(locale, "-")
+	if format, ok := localeFormats[language]; ok {
+		return format
+	}
+
+	return localeFormats["en"]
+}
+
+// FormatNumber groups the thousands of a decimal number like -1234567.50 and writes its decimal separator, the digits
+// are kept as they are so a numeric column keeps its scale. A text that is not a decimal number is returned as is.
+func (f LocaleFormat) FormatNumber(number string) string {
+	sign, digits := "", number
+	if strings.HasPrefix(digits, "-") || strings.HasPrefix(digits, "+") {
+		sign, digits = digits[:1], digits[1:]
+	}
+
+	integer, fraction, hasFraction := strings.Cut(digits, ".")
+	if integer == "" || !isDigits(integer) || (hasFraction && !isDigits(fraction)) {
+		return number
+	}
+
+	var grouped strings.Builder
+	for i, digit := range integer {
+		if i > 0 && (len(integer)-i)%3 == 0 {
+			grouped.WriteString(f.GroupSeparator)
+		}
+		grouped.WriteRune(digit)
+	}
+
+	if sign == "+" {
+		sign = ""
+	}
+
+	if hasFraction && fraction != "" {
+		return sign + grouped.String() + f.DecimalSeparator + fraction
+	}
+
+	return sign + grouped.String()
+}
+
+func isDigits(text string) bool {
+	for _, char := range text {
+		if char < '0' || char > '9' {
+			return false
+		}
+	}
+
+	return text != ""
+}
@@ -0,0 +1,54 @@
+package helper
+
+import (
+	"image"
+	"image/color"
+)
+
+// Thumbnail scales an image down to fit a square of size pixels, keeping its aspect ratio. Every pixel of the
+// thumbnail is the average of the source pixels it covers, which keeps the detail of large downscales that nearest
+// neighbour sampling would alias. An image already fitting the square is returned as is.
+func Thumbnail(src image.Image, size int) image.Image {
+	bounds := src.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if size <= 0 || width == 0 || height == 0 || (width <= size && height <= size) {
+		return src
+	}
+
+	thumbWidth, thumbHeight := size, size
+	if width > height {
+		thumbHeight = max(height*size/width, 1)
+	} else {
+		thumbWidth = max(width*size/height, 1)
+	}
+
+	thumb := image.NewRGBA(image.Rect(0, 0, thumbWidth, thumbHeight))
+	for y := 0; y < thumbHeight; y++ {
+		y0 := bounds.Min.Y + y*height/thumbHeight
+		y1 := max(bounds.Min.Y+(y+1)*height/thumbHeight, y0+1)
+
+		for x := 0; x < thumbWidth; x++ {
+			x0 := bounds.Min.X + x*width/thumbWidth
+			x1 := max(bounds.Min.X+(x+1)*width/thumbWidth, x0+1)
+
+			// the channels are alpha premultiplied, so averaging them weighs every pixel by its opacity
+			var r, g, b, a, count uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					pr, pg, pb, pa := src.At(sx, sy).RGBA()
+					r, g, b, a = r+uint64(pr), g+uint64(pg), b+uint64(pb), a+uint64(pa)
+					count++
+				}
+			}
+
+			thumb.SetRGBA64(x, y, color.RGBA64{
+				R: uint16(r / count),
+				G: uint16(g / count),
+				B: uint16(b / count),
+				A: uint16(a / count),
+			})
+		}
+	}
+
+	return thumb
+}
@@ -0,0 +1,64 @@
+package helper
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestThumbnailKeepsTheAspectRatio(t *testing.T) {
+	tests := []struct {
+		name          string
+		width, height int
+		size          int
+		wantWidth     int
+		wantHeight    int
+	}{
+		{"landscape", 400, 200, 100, 100, 50},
+		{"portrait", 90, 300, 60, 18, 60},
+		{"square", 128, 128, 64, 64, 64},
+		{"thin line keeps a pixel", 1000, 2, 10, 10, 1},
+		{"already fits", 40, 30, 64, 40, 30},
+		{"no size", 40, 30, 0, 40, 30},
+	}
+
+	for _, test := range tests {
+		thumb := Thumbnail(image.NewRGBA(image.Rect(0, 0, test.width, test.height)), test.size)
+		if bounds := thumb.Bounds(); bounds.Dx() != test.wantWidth || bounds.Dy() != test.wantHeight {
+			t.Errorf("%v: thumbnail is %vx%v, want %vx%v", test.name, bounds.Dx(), bounds.Dy(), test.wantWidth, test.wantHeight)
+		}
+	}
+}
+
+func TestThumbnailAveragesThePixels(t *testing.T) {
+	// a checkerboard of black and white, offset from the origin, averages to grey
+	src := image.NewRGBA(image.Rect(10, 10, 14, 14))
+	for y := 10; y < 14; y++ {
+		for x := 10; x < 14; x++ {
+			if (x+y)%2 == 0 {
+				src.Set(x, y, color.White)
+			} else {
+				src.Set(x, y, color.Black)
+			}
+		}
+	}
+
+	thumb := Thumbnail(src, 2)
+	for y := 0; y < 2; y++ {
+		for x := 0; x < 2; x++ {
+			r, g, b, a := thumb.At(x, y).RGBA()
+			if r != 0x7f7f || g != 0x7f7f || b != 0x7f7f || a != 0xffff {
+				t.Errorf("pixel %v,%v is %v %v %v %v, want a grey", x, y, r, g, b, a)
+			}
+		}
+	}
+
+	// a transparent pixel does not darken its opaque neighbour
+	src = image.NewRGBA(image.Rect(0, 0, 2, 1))
+	src.Set(0, 0, color.White)
+	src.Set(1, 0, color.Transparent)
+
+	if r, _, _, a := Thumbnail(src, 1).At(0, 0).RGBA(); a != 0x7f7f || r != 0x7f7f {
+		t.Errorf("half transparent pixel is r %v a %v, want a half transparent white", r, a)
+	}
+}
@@ -0,0 +1,43 @@
+package helper
+
+import (
+	"crypto/hmac"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+var errTokenSignature = errors.New("token signature is invalid")
+
+// SignToken returns a token carrying claims, the base64url encoded json of the claims and its HMAC-SHA256 joined by a dot
+func SignToken(secret string, claims any) (string, error) {
+	data, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	payload := base64.RawURLEncoding.EncodeToString(data)
+
+	return payload + "." + SignHMACSHA256(secret, []byte(payload)), nil
+}
+
+// VerifyToken checks the signature of a token made by SignToken and reads its claims. A token can not be verified
+// without a secret.
+func VerifyToken(secret, token string, claims any) error {
+	payload, signature, ok := strings.Cut(token, ".")
+	if !ok || secret == "" {
+		return errTokenSignature
+	}
+
+	if !hmac.Equal([]byte(signature), []byte(SignHMACSHA256(secret, []byte(payload)))) {
+		return errTokenSignature
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, claims)
+}
@@ -0,0 +1,43 @@
+package helper
+
+import (
+	"strings"
+	"testing"
+)
+
+type testClaims struct {
+	Subject string `json:"sub"`
+	Role    string `json:"role"`
+}
+
+func TestVerifyToken(t *testing.T) {
+	token, err := SignToken("secret", testClaims{Subject: "user-1", Role: "manager"})
+	if err != nil {
+		t.Fatalf("SignToken: %v", err)
+	}
+
+	claims := testClaims{}
+	if err := VerifyToken("secret", token, &claims); err != nil || claims.Subject != "user-1" || claims.Role != "manager" {
+		t.Fatalf("VerifyToken = %+v, %v", claims, err)
+	}
+
+	forged, _ := SignToken("other", testClaims{Subject: "user-1", Role: "admin"})
+	payload, _, _ := strings.Cut(forged, ".")
+	_, signature, _ := strings.Cut(token, ".")
+
+	for name, bad := range map[string]string{
+		"wrong secret":     forged,
+		"swapped payload":  payload + "." + signature,
+		"missing dot":      strings.ReplaceAll(token, ".", ""),
+		"empty":            "",
+		"truncated digest": token[:len(token)-2],
+	} {
+		if err := VerifyToken("secret", bad, &testClaims{}); err == nil {
+			t.Errorf("%v: token is accepted", name)
+		}
+	}
+
+	if err := VerifyToken("", token, &testClaims{}); err == nil {
+		t.Errorf("a token is accepted without a secret")
+	}
+}
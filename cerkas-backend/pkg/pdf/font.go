@@ -0,0 +1,134 @@
+package pdf
+
+// Font is one of the standard Helvetica faces every pdf reader ships, so no font is embedded
+type Font int
+
+const (
+	FontRegular Font = iota
+	FontBold
+	FontItalic
+	FontBoldItalic
+)
+
+var fontNames = [...]string{
+	FontRegular:    "Helvetica",
+	FontBold:       "Helvetica-Bold",
+	FontItalic:     "Helvetica-Oblique",
+	FontBoldItalic: "Helvetica-BoldOblique",
+}
+
+// StyledFont returns the face of a bold and italic combination
+func StyledFont(bold, italic bool) Font {
+	switch {
+	case bold && italic:
+		return FontBoldItalic
+	case bold:
+		return FontBold
+	case italic:
+		return FontItalic
+	default:
+		return FontRegular
+	}
+}
+
+func (f Font) isBold() bool {
+	return f == FontBold || f == FontBoldItalic
+}
+
+// glyph widths of the printable ascii characters from 32 to 126 in thousandths of the font size, from the font
+// metrics of Helvetica and Helvetica-Bold. The oblique faces share the widths of their upright face.
+var (
+	helveticaWidths = [95]int{
+		278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+		1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+		667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+		333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+		556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+	}
+	helveticaBoldWidths = [95]int{
+		278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278,
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 333, 333, 584, 584, 584, 611,
+		975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778,
+		667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556,
+		333, 556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611,
+		611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, 389, 280, 389, 584,
+	}
+)
+
+// glyph widths of the common characters above ascii, any other one is as wide as a digit
+var extendedWidths = map[byte][2]int{
+	0x80: {556, 556},   // euro
+	0x85: {1000, 1000}, // ellipsis
+	0x91: {222, 278},   // quotes
+	0x92: {222, 278},
+	0x93: {333, 500},
+	0x94: {333, 500},
+	0x95: {350, 350},   // bullet
+	0x96: {556, 556},   // en dash
+	0x97: {1000, 1000}, // em dash
+	0x99: {1000, 1000}, // trademark
+	0xA0: {278, 278},   // no-break space
+	0xA9: {737, 737},   // copyright
+	0xAE: {737, 737},   // registered
+	0xB0: {400, 400},   // degree
+}
+
+// TextWidth returns the width of a text in points
+func TextWidth(font Font, size float64, text string) float64 {
+	width := 0
+	for _, char := range encode(text) {
+		width += glyphWidth(font, char)
+	}
+
+	return float64(width) * size / 1000
+}
+
+func glyphWidth(font Font, char byte) int {
+	switch {
+	case char >= 32 && char <= 126 && font.isBold():
+		return helveticaBoldWidths[char-32]
+	case char >= 32 && char <= 126:
+		return helveticaWidths[char-32]
+	}
+
+	if widths, ok := extendedWidths[char]; ok {
+		if font.isBold() {
+			return widths[1]
+		}
+
+		return widths[0]
+	}
+
+	return 556
+}
+
+// the characters of windows-1252 between 0x80 and 0x9f, the rest of its upper half is latin-1
+var winAnsiCharacters = map[rune]byte{
+	'€': 0x80, '‚': 0x82, 'ƒ': 0x83, '„': 0x84, '…': 0x85, '†': 0x86, '‡': 0x87, 'ˆ': 0x88,
+	'‰': 0x89, 'Š': 0x8A, '‹': 0x8B, 'Œ': 0x8C, 'Ž': 0x8E, '‘': 0x91, '’': 0x92, '“': 0x93,
+	'”': 0x94, '•': 0x95, '–': 0x96, '—': 0x97, '˜': 0x98, '™': 0x99, 'š': 0x9A, '›': 0x9B,
+	'œ': 0x9C, 'ž': 0x9E, 'Ÿ': 0x9F,
+}
+
+// encode converts a text to the WinAnsiEncoding of the standard fonts, characters it lacks become a question mark
+func encode(text string) []byte {
+	encoded := make([]byte, 0, len(text))
+	for _, char := range text {
+		switch {
+		case char == '\t':
+			encoded = append(encoded, ' ')
+		case char < 32:
+		case char < 127 || (char >= 0xA0 && char <= 0xFF):
+			encoded = append(encoded, byte(char))
+		default:
+			if code, ok := winAnsiCharacters[char]; ok {
+				encoded = append(encoded, code)
+			} else {
+				encoded = append(encoded, '?')
+			}
+		}
+	}
+
+	return encoded
+}
@@ -0,0 +1,626 @@
+package pdf
+
+import (
+	"io"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+const (
+	pageMargin   = 50
+	defaultSize  = 10
+	lineSpacing  = 1.3
+	listIndent   = 18
+	cellPadding  = 4
+	tableSpacing = 8
+)
+
+// headingSizes are the font sizes of h1 to h6
+var headingSizes = map[atom.Atom]float64{
+	atom.H1: 20, atom.H2: 16, atom.H3: 14, atom.H4: 12, atom.H5: 11, atom.H6: 10,
+}
+
+// blockMargins are the space after a block, blocks not listed have none
+var blockMargins = map[atom.Atom]float64{
+	atom.H1: 10, atom.H2: 8, atom.H3: 6, atom.H4: 6, atom.H5: 4, atom.H6: 4,
+	atom.P: 8, atom.Ul: 6, atom.Ol: 6, atom.Blockquote: 8, atom.Pre: 8,
+}
+
+var blockElements = map[atom.Atom]bool{
+	atom.P: true, atom.Div: true, atom.Section: true, atom.Article: true, atom.Header: true, atom.Footer: true,
+	atom.Main: true, atom.Aside: true, atom.Nav: true, atom.Address: true, atom.Blockquote: true, atom.Pre: true,
+	atom.H1: true, atom.H2: true, atom.H3: true, atom.H4: true, atom.H5: true, atom.H6: true,
+	atom.Ul: true, atom.Ol: true, atom.Li: true, atom.Dl: true, atom.Dt: true, atom.Dd: true,
+	atom.Table: true, atom.Tr: true, atom.Td: true, atom.Th: true, atom.Caption: true,
+}
+
+// skippedElements hold no printable text
+var skippedElements = map[atom.Atom]bool{
+	atom.Head: true, atom.Title: true, atom.Style: true, atom.Script: true, atom.Template: true, atom.Noscript: true,
+}
+
+// RenderHTML lays out an html document on pages of a size and writes it as pdf. It supports the text of documents
+// like invoices and letters: paragraphs, headings, lists, line breaks, rules, bold and italic text, text alignment,
+// font sizes and tables with borders and column spans. Images and the rest of css are ignored.
+func RenderHTML(w io.Writer, source string, size PageSize) error {
+	root, err := html.Parse(strings.NewReader(source))
+	if err != nil {
+		return err
+	}
+
+	r := &renderer{doc: New(size), y: pageMargin}
+	r.doc.AddPage()
+
+	st := style{size: defaultSize}
+	r.node(root, st)
+	r.flush(st)
+
+	return r.doc.Write(w)
+}
+
+type style struct {
+	bold     bool
+	italic   bool
+	size     float64
+	align    string
+	indent   float64
+	preserve bool
+}
+
+func (st style) font() Font {
+	return StyledFont(st.bold, st.italic)
+}
+
+// run is a piece of text in one font, a run of a single newline breaks the line
+type run struct {
+	text     string
+	font     Font
+	size     float64
+	preserve bool
+}
+
+type renderer struct {
+	doc  *Document
+	y    float64
+	runs []run
+
+	// a renderer collecting the text of a table cell keeps the runs, blocks only break their lines
+	collecting bool
+	listItems  []int
+}
+
+func (r *renderer) node(n *html.Node, st style) {
+	switch n.Type {
+	case html.TextNode:
+		r.runs = append(r.runs, run{text: n.Data, font: st.font(), size: st.size, preserve: st.preserve})
+		return
+	case html.DocumentNode:
+		r.children(n, st)
+		return
+	case html.ElementNode:
+	default:
+		return
+	}
+
+	if skippedElements[n.DataAtom] {
+		return
+	}
+
+	own := elementStyle(n, st)
+
+	switch n.DataAtom {
+	case atom.Br:
+		r.runs = append(r.runs, run{text: "\n", size: st.size})
+		return
+	case atom.Hr:
+		r.flush(st)
+		if !r.collecting {
+			r.space(4)
+			r.doc.Line(pageMargin+st.indent, r.y, r.doc.size.Width-pageMargin, r.y, 0.5)
+			r.y += 8
+		}
+		return
+	case atom.Table:
+		if !r.collecting {
+			r.flush(st)
+			r.table(n, own)
+			return
+		}
+	case atom.Ul, atom.Ol:
+		own.indent += listIndent
+		r.listItems = append(r.listItems, 0)
+		defer func() { r.listItems = r.listItems[:len(r.listItems)-1] }()
+	case atom.Li:
+		r.flush(st)
+		r.runs = append(r.runs, run{text: r.listMarker(n) + " ", font: own.font(), size: own.size})
+	case atom.Blockquote, atom.Dd:
+		own.indent += listIndent
+	}
+
+	if !blockElements[n.DataAtom] {
+		r.children(n, own)
+		return
+	}
+
+	if n.DataAtom != atom.Li {
+		r.flush(st)
+	}
+	r.children(n, own)
+	r.flush(own)
+
+	if margin := blockMargins[n.DataAtom]; margin > 0 && !r.collecting {
+		r.y += margin
+	}
+}
+
+func (r *renderer) children(n *html.Node, st style) {
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		r.node(child, st)
+	}
+}
+
+// listMarker returns the bullet of a list item, or its number in an ordered list
+func (r *renderer) listMarker(n *html.Node) string {
+	if len(r.listItems) == 0 {
+		return "•"
+	}
+
+	r.listItems[len(r.listItems)-1]++
+	if n.Parent != nil && n.Parent.DataAtom == atom.Ol {
+		return strconv.Itoa(r.listItems[len(r.listItems)-1]) + "."
+	}
+
+	return "•"
+}
+
+// flush lays out the pending runs as a paragraph
+func (r *renderer) flush(st style) {
+	if r.collecting {
+		if len(r.runs) > 0 && r.runs[len(r.runs)-1].text != "\n" {
+			r.runs = append(r.runs, run{text: "\n", size: st.size})
+		}
+		return
+	}
+
+	runs := r.runs
+	r.runs = nil
+
+	left := pageMargin + st.indent
+	width := r.doc.size.Width - pageMargin - left
+
+	lines := wrap(runs, width)
+	for _, line := range lines {
+		r.space(line.height)
+		line.draw(r.doc, left, r.y, width, st.align)
+		r.y += line.height
+	}
+}
+
+// space starts a new page when a height does not fit the current one
+func (r *renderer) space(height float64) {
+	if r.y+height > r.doc.size.Height-pageMargin && r.y > pageMargin {
+		r.doc.AddPage()
+		r.y = pageMargin
+	}
+}
+
+// cellText collects the runs of a table cell
+func (r *renderer) cellText(n *html.Node, st style) []run {
+	collector := &renderer{collecting: true}
+	collector.children(n, st)
+
+	return collector.runs
+}
+
+type tableCell struct {
+	runs  []run
+	span  int
+	align string
+	head  bool
+}
+
+// table lays out the rows of a table, the columns share the width of the page by the width of their text
+func (r *renderer) table(n *html.Node, st style) {
+	rows := [][]tableCell{}
+	columns := 0
+
+	var collectRows func(n *html.Node)
+	collectRows = func(n *html.Node) {
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			switch child.DataAtom {
+			case atom.Thead, atom.Tbody, atom.Tfoot:
+				collectRows(child)
+			case atom.Tr:
+				row := []tableCell{}
+				span := 0
+				for cell := child.FirstChild; cell != nil; cell = cell.NextSibling {
+					if cell.DataAtom != atom.Td && cell.DataAtom != atom.Th {
+						continue
+					}
+
+					cellStyle := elementStyle(cell, st)
+					cellStyle.indent = 0
+					if cell.DataAtom == atom.Th {
+						cellStyle.bold = true
+					}
+
+					colspan, _ := strconv.Atoi(attribute(cell, "colspan"))
+					colspan = max(colspan, 1)
+					span += colspan
+
+					row = append(row, tableCell{
+						runs:  r.cellText(cell, cellStyle),
+						span:  colspan,
+						align: cellStyle.align,
+						head:  cell.DataAtom == atom.Th,
+					})
+				}
+
+				columns = max(columns, span)
+				rows = append(rows, row)
+			}
+		}
+	}
+	collectRows(n)
+
+	if columns == 0 {
+		return
+	}
+
+	left := pageMargin + st.indent
+	widths := columnWidths(rows, columns, r.doc.size.Width-pageMargin-left)
+	bordered := (attribute(n, "border") != "" && attribute(n, "border") != "0") || strings.Contains(attribute(n, "style"), "border")
+
+	for _, row := range rows {
+		cellLines := make([][]line, len(row))
+		height := 0.0
+		column := 0
+		for i, cell := range row {
+			cellLines[i] = wrap(cell.runs, spanWidth(widths, column, cell.span)-2*cellPadding)
+			height = max(height, linesHeight(cellLines[i]))
+			column += cell.span
+		}
+		height += 2 * cellPadding
+
+		r.space(height)
+
+		x := left
+		column = 0
+		for i, cell := range row {
+			width := spanWidth(widths, column, cell.span)
+			if cell.head {
+				r.doc.Rect(x, r.y, width, height, 0.92)
+			}
+
+			if bordered {
+				r.doc.Line(x, r.y, x+width, r.y, 0.5)
+				r.doc.Line(x, r.y+height, x+width, r.y+height, 0.5)
+				r.doc.Line(x, r.y, x, r.y+height, 0.5)
+				r.doc.Line(x+width, r.y, x+width, r.y+height, 0.5)
+			}
+
+			y := r.y + cellPadding
+			for _, line := range cellLines[i] {
+				line.draw(r.doc, x+cellPadding, y, width-2*cellPadding, cell.align)
+				y += line.height
+			}
+
+			x += width
+			column += cell.span
+		}
+
+		r.y += height
+	}
+
+	r.y += tableSpacing
+}
+
+// columnWidths shares a width between the columns. Columns get the width of their text when it fits, otherwise
+// every column keeps its longest word and the rest is shared by the width of the text.
+func columnWidths(rows [][]tableCell, columns int, available float64) []float64 {
+	natural := make([]float64, columns)
+	minimum := make([]float64, columns)
+	for _, row := range rows {
+		column := 0
+		for _, cell := range row {
+			if cell.span == 1 && column < columns {
+				textWidth, wordWidth := runsWidth(cell.runs)
+				natural[column] = max(natural[column], textWidth+2*cellPadding)
+				minimum[column] = max(minimum[column], wordWidth+2*cellPadding)
+			}
+			column += cell.span
+		}
+	}
+
+	totalNatural, totalMinimum := 0.0, 0.0
+	for i := range natural {
+		natural[i] = max(natural[i], 2*cellPadding+1)
+		minimum[i] = max(minimum[i], 2*cellPadding+1)
+		totalNatural += natural[i]
+		totalMinimum += minimum[i]
+	}
+
+	widths := make([]float64, columns)
+	for i := range widths {
+		switch {
+		case totalNatural <= available:
+			widths[i] = natural[i] * available / totalNatural
+		case totalMinimum <= available:
+			widths[i] = minimum[i] + (natural[i]-minimum[i])*(available-totalMinimum)/(totalNatural-totalMinimum)
+		default:
+			widths[i] = minimum[i] * available / totalMinimum
+		}
+	}
+
+	return widths
+}
+
+func spanWidth(widths []float64, column, span int) float64 {
+	width := 0.0
+	for i := column; i < column+span && i < len(widths); i++ {
+		width += widths[i]
+	}
+
+	return width
+}
+
+// runsWidth returns the width of the longest line of runs and of their longest word
+func runsWidth(runs []run) (lineWidth, wordWidth float64) {
+	current := 0.0
+	for _, word := range words(runs) {
+		if word.text == "\n" {
+			current = 0
+			continue
+		}
+
+		width := TextWidth(word.font, word.size, word.text)
+		if word.spaced && current > 0 {
+			current += TextWidth(word.font, word.size, " ")
+		}
+
+		current += width
+		lineWidth = max(lineWidth, current)
+		wordWidth = max(wordWidth, width)
+	}
+
+	return lineWidth, wordWidth
+}
+
+// word is a word of a run, spaced tells whether white space came before it
+type word struct {
+	text   string
+	font   Font
+	size   float64
+	spaced bool
+}
+
+// words splits runs into words, collapsing white space like html does unless the run preserves it
+func words(runs []run) []word {
+	result := []word{}
+	spaced := false
+	for _, run := range runs {
+		if run.text == "\n" {
+			result = append(result, word{text: "\n", size: run.size})
+			spaced = false
+			continue
+		}
+
+		if run.preserve {
+			for i, text := range strings.Split(run.text, "\n") {
+				if i > 0 {
+					result = append(result, word{text: "\n", size: run.size})
+				}
+
+				if text != "" {
+					result = append(result, word{text: text, font: run.font, size: run.size, spaced: spaced})
+				}
+				spaced = false
+			}
+			continue
+		}
+
+		text := run.text
+		if strings.TrimSpace(text) == "" {
+			spaced = spaced || text != ""
+			continue
+		}
+
+		if text[0] == ' ' || text[0] == '\n' || text[0] == '\t' || text[0] == '\r' {
+			spaced = true
+		}
+
+		for _, field := range strings.Fields(text) {
+			result = append(result, word{text: field, font: run.font, size: run.size, spaced: spaced})
+			spaced = true
+		}
+
+		last := text[len(text)-1]
+		spaced = last == ' ' || last == '\n' || last == '\t' || last == '\r'
+	}
+
+	return result
+}
+
+// line is a laid out line of words with their offsets from the start of the line
+type line struct {
+	words   []word
+	offsets []float64
+	width   float64
+	height  float64
+	size    float64
+}
+
+func (l line) draw(doc *Document, x, y, width float64, align string) {
+	switch align {
+	case "right":
+		x += width - l.width
+	case "center":
+		x += (width - l.width) / 2
+	}
+
+	baseline := y + l.size
+	for i, word := range l.words {
+		doc.Text(x+l.offsets[i], baseline, word.font, word.size, word.text)
+	}
+}
+
+func linesHeight(lines []line) float64 {
+	height := 0.0
+	for _, line := range lines {
+		height += line.height
+	}
+
+	return height
+}
+
+// wrap breaks runs into lines no wider than a width, a word wider than the width is broken between its characters
+func wrap(runs []run, width float64) []line {
+	lines := []line{}
+	current := line{}
+
+	finish := func(size float64) {
+		if current.size == 0 {
+			current.size = size
+		}
+		current.height = current.size * lineSpacing
+		lines = append(lines, current)
+		current = line{}
+	}
+
+	for _, word := range words(runs) {
+		if word.text == "\n" {
+			finish(word.size)
+			continue
+		}
+
+		for _, piece := range breakWord(word, width) {
+			pieceWidth := TextWidth(piece.font, piece.size, piece.text)
+
+			space := 0.0
+			if piece.spaced && len(current.words) > 0 {
+				space = TextWidth(piece.font, piece.size, " ")
+			}
+
+			if len(current.words) > 0 && current.width+space+pieceWidth > width {
+				finish(piece.size)
+				space = 0
+			}
+
+			current.words = append(current.words, piece)
+			current.offsets = append(current.offsets, current.width+space)
+			current.width += space + pieceWidth
+			current.size = max(current.size, piece.size)
+		}
+	}
+
+	if len(current.words) > 0 {
+		finish(current.size)
+	}
+
+	// a trailing break ends the last line, it adds no empty line
+	if len(lines) > 0 && len(lines[len(lines)-1].words) == 0 && len(runs) > 0 && runs[len(runs)-1].text == "\n" {
+		lines = lines[:len(lines)-1]
+	}
+
+	return lines
+}
+
+func breakWord(w word, width float64) []word {
+	if width <= 0 || TextWidth(w.font, w.size, w.text) <= width {
+		return []word{w}
+	}
+
+	pieces := []word{}
+	piece := ""
+	for _, char := range w.text {
+		if piece != "" && TextWidth(w.font, w.size, piece+string(char)) > width {
+			pieces = append(pieces, word{text: piece, font: w.font, size: w.size, spaced: len(pieces) == 0 && w.spaced})
+			piece = ""
+		}
+		piece += string(char)
+	}
+
+	return append(pieces, word{text: piece, font: w.font, size: w.size, spaced: len(pieces) == 0 && w.spaced})
+}
+
+// elementStyle applies the style of an element and of its style and align attributes to the inherited style
+func elementStyle(n *html.Node, inherited style) style {
+	st := inherited
+
+	switch n.DataAtom {
+	case atom.B, atom.Strong, atom.Th, atom.Dt:
+		st.bold = true
+	case atom.I, atom.Em, atom.Cite, atom.Var:
+		st.italic = true
+	case atom.Small:
+		st.size = inherited.size * 0.85
+	case atom.Pre, atom.Textarea:
+		st.preserve = true
+	}
+
+	if size, ok := headingSizes[n.DataAtom]; ok {
+		st.size = size
+		st.bold = true
+	}
+
+	// alignment applies to the block it is set on and its descendants
+	if blockElements[n.DataAtom] {
+		if align := strings.ToLower(attribute(n, "align")); align != "" {
+			st.align = align
+		}
+	}
+
+	for _, declaration := range strings.Split(attribute(n, "style"), ";") {
+		property, value, ok := strings.Cut(declaration, ":")
+		if !ok {
+			continue
+		}
+
+		value = strings.ToLower(strings.TrimSpace(value))
+		switch strings.ToLower(strings.TrimSpace(property)) {
+		case "text-align":
+			st.align = value
+		case "font-weight":
+			weight, err := strconv.Atoi(value)
+			st.bold = value == "bold" || value == "bolder" || (err == nil && weight >= 600)
+		case "font-style":
+			st.italic = value == "italic" || value == "oblique"
+		case "font-size":
+			if size, ok := fontSize(value, inherited.size); ok {
+				st.size = size
+			}
+		}
+	}
+
+	return st
+}
+
+// fontSize reads a css font size in pt, px or em
+func fontSize(value string, inherited float64) (float64, bool) {
+	units := map[string]float64{"pt": 1, "px": 0.75, "em": inherited, "rem": defaultSize, "%": inherited / 100}
+	for _, unit := range []string{"rem", "pt", "px", "em", "%"} {
+		if number, ok := strings.CutSuffix(value, unit); ok {
+			size, err := strconv.ParseFloat(strings.TrimSpace(number), 64)
+			if err != nil || size <= 0 {
+				return 0, false
+			}
+
+			return size * units[unit], true
+		}
+	}
+
+	return 0, false
+}
+
+func attribute(n *html.Node, key string) string {
+	for _, attr := range n.Attr {
+		if attr.Key == key {
+			return attr.Val
+		}
+	}
+
+	return ""
+}
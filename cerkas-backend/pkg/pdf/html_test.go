@@ -0,0 +1,190 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func renderHTML(t *testing.T, source string) []string {
+	t.Helper()
+
+	var out bytes.Buffer
+	if err := RenderHTML(&out, source, PageA4); err != nil {
+		t.Fatalf("RenderHTML = %v", err)
+	}
+
+	return pageContents(t, out.Bytes())
+}
+
+func TestRenderHTMLDrawsTheText(t *testing.T) {
+	contents := renderHTML(t, `<html><head><title>Skipped</title><style>p { color: red }</style></head><body>
+		<h1>Invoice INV-1</h1>
+		<p>Billed to <b>Acme</b> and <i>its branches</i></p>
+		<ol><li>First</li><li>Second</li></ol>
+		<ul><li>Point</li></ul>
+		<hr>
+		<p style="text-align: right; font-size: 8pt">Total 10</p>
+	</body></html>`)
+
+	if len(contents) != 1 {
+		t.Fatalf("%v pages, want 1", len(contents))
+	}
+
+	for _, want := range []string{
+		"/F2 20 Tf 50 771.89 Td (Invoice) Tj",
+		"/F1 10 Tf 50 ",
+		"(Acme) Tj",
+		"/F3 10 Tf",
+		"(1.) Tj",
+		"(2.) Tj",
+		"(\x95) Tj",
+		" l S\n",
+		"/F1 8 Tf",
+	} {
+		if !strings.Contains(contents[0], want) {
+			t.Errorf("page does not draw %q:\n%v", want, contents[0])
+		}
+	}
+
+	if strings.Contains(contents[0], "Skipped") || strings.Contains(contents[0], "color") {
+		t.Errorf("head of the document is drawn:\n%v", contents[0])
+	}
+}
+
+func TestRenderHTMLBreaksPages(t *testing.T) {
+	contents := renderHTML(t, strings.Repeat("<p>A line of a long letter</p>", 100))
+
+	if len(contents) < 2 {
+		t.Fatalf("%v pages, want the text to go on several pages", len(contents))
+	}
+
+	if count := strings.Count(strings.Join(contents, ""), "(letter) Tj"); count != 100 {
+		t.Errorf("%v paragraphs drawn, want 100", count)
+	}
+}
+
+func TestRenderHTMLTables(t *testing.T) {
+	contents := renderHTML(t, `<table border="1">
+		<thead><tr><th>Item</th><th align="right">Amount</th></tr></thead>
+		<tbody><tr><td>Widget</td><td align="right">10</td></tr><tr><td colspan="2">Paid in full</td></tr></tbody>
+	</table>`)
+
+	page := contents[0]
+	for _, want := range []string{"/F2 10 Tf", "(Item) Tj", "(Amount) Tj", "(Widget) Tj", "(Paid) Tj", "0.92 g"} {
+		if !strings.Contains(page, want) {
+			t.Errorf("table does not draw %q:\n%v", want, page)
+		}
+	}
+
+	// every cell of the 3 rows has its 4 borders
+	if count := strings.Count(page, " l S\n"); count != 5*4 {
+		t.Errorf("%v border lines, want %v", count, 5*4)
+	}
+
+	if contents := renderHTML(t, `<table><tr><td>Widget</td></tr></table>`); strings.Contains(contents[0], " l S\n") {
+		t.Errorf("table without a border draws lines:\n%v", contents[0])
+	}
+}
+
+func TestWrap(t *testing.T) {
+	runs := []run{{text: "one two  three\nfour", font: FontRegular, size: 10}}
+	width := TextWidth(FontRegular, 10, "one two")
+
+	got := []string{}
+	for _, line := range wrap(runs, width) {
+		if line.width > width {
+			t.Errorf("line of width %v is wider than %v", line.width, width)
+		}
+
+		texts := []string{}
+		for _, word := range line.words {
+			texts = append(texts, word.text)
+		}
+		got = append(got, strings.Join(texts, " "))
+	}
+
+	if want := []string{"one two", "three", "four"}; fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("wrap = %q, want %q", got, want)
+	}
+
+	lines := wrap([]run{{text: "first", size: 10}, {text: "\n", size: 10}, {text: "second", size: 10}, {text: "\n", size: 10}}, 500)
+	if len(lines) != 2 {
+		t.Errorf("wrap with a trailing break = %v lines, want 2", len(lines))
+	}
+}
+
+func TestWrapBreaksLongWords(t *testing.T) {
+	runs := []run{{text: "INV-0000000000000000000001", font: FontRegular, size: 10}}
+	width := TextWidth(FontRegular, 10, "INV-0000")
+
+	lines := wrap(runs, width)
+	if len(lines) < 2 {
+		t.Fatalf("wrap = %v lines, want the word broken", len(lines))
+	}
+
+	joined := ""
+	for _, line := range lines {
+		if line.width > width {
+			t.Errorf("line of width %v is wider than %v", line.width, width)
+		}
+		joined += line.words[0].text
+	}
+
+	if joined != runs[0].text {
+		t.Errorf("broken word = %v, want %v", joined, runs[0].text)
+	}
+}
+
+func TestWordsKeepPreservedText(t *testing.T) {
+	got := []string{}
+	for _, word := range words([]run{{text: "  a  b\n", size: 10}, {text: "x  y\nz", size: 10, preserve: true}}) {
+		got = append(got, fmt.Sprintf("%q %v", word.text, word.spaced))
+	}
+
+	want := []string{`"a" true`, `"b" true`, `"x  y" true`, `"\n" false`, `"z" false`}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("words = %v, want %v", got, want)
+	}
+}
+
+func TestColumnWidths(t *testing.T) {
+	cell := func(text string) tableCell {
+		return tableCell{runs: []run{{text: text, font: FontRegular, size: 10}}, span: 1}
+	}
+
+	short := [][]tableCell{{cell("Item"), cell("Amount")}}
+	widths := columnWidths(short, 2, 400)
+	if sum := widths[0] + widths[1]; sum < 399.99 || sum > 400.01 || widths[1] <= widths[0] {
+		t.Errorf("columnWidths = %v, want 400 shared by the width of the text", widths)
+	}
+
+	long := [][]tableCell{{cell(strings.Repeat("description ", 20)), cell("10")}}
+	widths = columnWidths(long, 2, 200)
+	if minimum := TextWidth(FontRegular, 10, "10") + 2*cellPadding; widths[1] < minimum {
+		t.Errorf("columnWidths = %v, want the amount column to keep %v", widths, minimum)
+	}
+}
+
+func TestFontSize(t *testing.T) {
+	tests := []struct {
+		value string
+		want  float64
+		ok    bool
+	}{
+		{"12pt", 12, true},
+		{"16px", 12, true},
+		{"2em", 20, true},
+		{"1.5rem", 15, true},
+		{"50%", 5, true},
+		{"large", 0, false},
+		{"-4pt", 0, false},
+	}
+
+	for _, test := range tests {
+		if got, ok := fontSize(test.value, 10); got != test.want || ok != test.ok {
+			t.Errorf("fontSize(%v) = %v, %v, want %v, %v", test.value, got, ok, test.want, test.ok)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+// Package pdf writes simple pdf documents of text and lines with the standard fonts, and lays out html on them.
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// PageSize is the size of a page in points
+type PageSize struct {
+	Width  float64
+	Height float64
+}
+
+var (
+	PageA4     = PageSize{Width: 595.28, Height: 841.89}
+	PageLetter = PageSize{Width: 612, Height: 792}
+)
+
+// PageSizes are the page sizes known by name
+var PageSizes = map[string]PageSize{
+	"a4":     PageA4,
+	"letter": PageLetter,
+}
+
+// Document is a pdf document drawn page by page. Coordinates are in points from the top left corner of the page, a
+// text is placed by its baseline.
+type Document struct {
+	size  PageSize
+	pages []*bytes.Buffer
+}
+
+func New(size PageSize) *Document {
+	return &Document{size: size}
+}
+
+// AddPage starts a new page, the drawing goes to the last page
+func (d *Document) AddPage() {
+	d.pages = append(d.pages, &bytes.Buffer{})
+}
+
+func (d *Document) Size() PageSize {
+	return d.size
+}
+
+func (d *Document) Text(x, y float64, font Font, size float64, text string) {
+	fmt.Fprintf(d.page(), "BT /F%d %s Tf %s %s Td (%s) Tj ET\n", font+1, number(size), number(x), number(d.size.Height-y), escape(encode(text)))
+}
+
+func (d *Document) Line(x1, y1, x2, y2, width float64) {
+	fmt.Fprintf(d.page(), "%s w %s %s m %s %s l S\n", number(width), number(x1), number(d.size.Height-y1), number(x2), number(d.size.Height-y2))
+}
+
+// Rect fills a rectangle in a gray level from 0 for black to 1 for white
+func (d *Document) Rect(x, y, width, height, gray float64) {
+	fmt.Fprintf(d.page(), "q %s g %s %s %s %s re f Q\n", number(gray), number(x), number(d.size.Height-y-height), number(width), number(height))
+}
+
+// Write writes the document, a document without pages gets an empty one
+func (d *Document) Write(w io.Writer) error {
+	if len(d.pages) == 0 {
+		d.AddPage()
+	}
+
+	out := &countingWriter{w: w}
+	offsets := []int64{}
+	object := func(body string, stream []byte) {
+		offsets = append(offsets, out.n)
+		fmt.Fprintf(out, "%d 0 obj\n%s\n", len(offsets), body)
+		if stream != nil {
+			fmt.Fprintf(out, "stream\n%s\nendstream\n", stream)
+		}
+		fmt.Fprint(out, "endobj\n")
+	}
+
+	fmt.Fprint(out, "%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	// the catalog, the page tree and the fonts come first, each page is followed by its content
+	kids := []string{}
+	for i := range d.pages {
+		kids = append(kids, fmt.Sprintf("%d 0 R", 7+2*i))
+	}
+
+	object("<< /Type /Catalog /Pages 2 0 R >>", nil)
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d /MediaBox [0 0 %s %s] >>", strings.Join(kids, " "), len(d.pages), number(d.size.Width), number(d.size.Height)), nil)
+	for _, name := range fontNames {
+		object(fmt.Sprintf("<< /Type /Font /Subtype /Type1 /BaseFont /%s /Encoding /WinAnsiEncoding >>", name), nil)
+	}
+
+	for i, page := range d.pages {
+		var content bytes.Buffer
+		compressor := zlib.NewWriter(&content)
+		if _, err := compressor.Write(page.Bytes()); err != nil {
+			return err
+		}
+		if err := compressor.Close(); err != nil {
+			return err
+		}
+
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /Resources << /Font << /F1 3 0 R /F2 4 0 R /F3 5 0 R /F4 6 0 R >> >> /Contents %d 0 R >>", 8+2*i), nil)
+		object(fmt.Sprintf("<< /Length %d /Filter /FlateDecode >>", content.Len()), content.Bytes())
+	}
+
+	xref := out.n
+	fmt.Fprintf(out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	return out.err
+}
+
+func (d *Document) page() *bytes.Buffer {
+	if len(d.pages) == 0 {
+		d.AddPage()
+	}
+
+	return d.pages[len(d.pages)-1]
+}
+
+// number formats a coordinate with two decimals at most, pdf has no exponent notation
+func number(value float64) string {
+	formatted := strings.TrimRight(fmt.Sprintf("%.2f", value), "0")
+	return strings.TrimSuffix(formatted, ".")
+}
+
+func escape(text []byte) []byte {
+	escaped := make([]byte, 0, len(text))
+	for _, char := range text {
+		if char == '\\' || char == '(' || char == ')' {
+			escaped = append(escaped, '\\')
+		}
+
+		escaped = append(escaped, char)
+	}
+
+	return escaped
+}
+
+// countingWriter keeps the offset of the output for the cross-reference table and the first error of the writes
+type countingWriter struct {
+	w   io.Writer
+	n   int64
+	err error
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	if cw.err != nil {
+		return 0, cw.err
+	}
+
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	cw.err = err
+
+	return n, err
+}
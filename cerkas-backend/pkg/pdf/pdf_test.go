@@ -0,0 +1,148 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+// pageContents returns the decompressed content streams of a written document, one per page
+func pageContents(t *testing.T, data []byte) []string {
+	t.Helper()
+
+	contents := []string{}
+	for {
+		_, rest, ok := bytes.Cut(data, []byte("stream\n"))
+		if !ok {
+			return contents
+		}
+
+		stream, after, ok := bytes.Cut(rest, []byte("\nendstream"))
+		if !ok {
+			t.Fatalf("stream without an end")
+		}
+
+		reader, err := zlib.NewReader(bytes.NewReader(stream))
+		if err != nil {
+			t.Fatalf("zlib.NewReader: %v", err)
+		}
+
+		content, err := io.ReadAll(reader)
+		if err != nil {
+			t.Fatalf("io.ReadAll: %v", err)
+		}
+
+		contents = append(contents, string(content))
+		data = after
+	}
+}
+
+func TestDocumentWrite(t *testing.T) {
+	doc := New(PageA4)
+	doc.Text(50, 60, FontBold, 12, "Total (net)")
+	doc.AddPage()
+	doc.Line(50, 100, 545.28, 100, 0.5)
+
+	var out bytes.Buffer
+	if err := doc.Write(&out); err != nil {
+		t.Fatalf("Write = %v", err)
+	}
+
+	data := out.String()
+	if !strings.HasPrefix(data, "%PDF-1.4\n") || !strings.HasSuffix(data, "%%EOF\n") {
+		t.Errorf("document does not start with the pdf header and end with its trailer")
+	}
+
+	if !strings.Contains(data, "/Count 2 /MediaBox [0 0 595.28 841.89]") {
+		t.Errorf("page tree does not hold 2 a4 pages")
+	}
+
+	contents := pageContents(t, out.Bytes())
+	if len(contents) != 2 {
+		t.Fatalf("%v page contents, want 2", len(contents))
+	}
+
+	if want := "BT /F2 12 Tf 50 781.89 Td (Total \\(net\\)) Tj ET\n"; contents[0] != want {
+		t.Errorf("first page = %q, want %q", contents[0], want)
+	}
+
+	if want := "0.5 w 50 741.89 m 545.28 741.89 l S\n"; contents[1] != want {
+		t.Errorf("second page = %q, want %q", contents[1], want)
+	}
+}
+
+func TestDocumentWithoutPagesGetsAnEmptyOne(t *testing.T) {
+	var out bytes.Buffer
+	if err := New(PageLetter).Write(&out); err != nil {
+		t.Fatalf("Write = %v", err)
+	}
+
+	if contents := pageContents(t, out.Bytes()); len(contents) != 1 || contents[0] != "" {
+		t.Errorf("page contents = %q, want one empty page", contents)
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func TestDocumentWriteFails(t *testing.T) {
+	if err := New(PageA4).Write(failingWriter{}); err == nil || err.Error() != "disk full" {
+		t.Errorf("Write = %v, want the error of the writer", err)
+	}
+}
+
+func TestEncode(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"Invoice", "Invoice"},
+		{"café", "caf\xe9"},
+		{"€ 10 – paid", "\x80 10 \x96 paid"},
+		{"a\tb\x01c", "a bc"},
+		{"请款单", "???"},
+	}
+
+	for _, test := range tests {
+		if got := string(encode(test.text)); got != test.want {
+			t.Errorf("%q: encode = %q, want %q", test.text, got, test.want)
+		}
+	}
+}
+
+func TestNumber(t *testing.T) {
+	tests := map[float64]string{
+		50:       "50",
+		841.89:   "841.89",
+		12.5:     "12.5",
+		0.004:    "0",
+		1e7:      "10000000",
+		-3.14159: "-3.14",
+	}
+
+	for value, want := range tests {
+		if got := number(value); got != want {
+			t.Errorf("number(%v) = %v, want %v", value, got, want)
+		}
+	}
+}
+
+func TestTextWidth(t *testing.T) {
+	if got := TextWidth(FontRegular, 10, "Hi"); got != 9.44 {
+		t.Errorf("Helvetica width = %v, want 9.44", got)
+	}
+
+	if regular, bold := TextWidth(FontRegular, 10, "Total"), TextWidth(FontBold, 10, "Total"); bold <= regular {
+		t.Errorf("bold width %v is not wider than regular %v", bold, regular)
+	}
+
+	if italic, regular := TextWidth(FontItalic, 10, "Total"), TextWidth(FontRegular, 10, "Total"); italic != regular {
+		t.Errorf("oblique width %v, want the upright width %v", italic, regular)
+	}
+}
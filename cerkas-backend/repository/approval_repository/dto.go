@@ -0,0 +1,181 @@
+package approvalrepository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/cerkas/cerkas-backend/core/entity"
+	"gorm.io/gorm"
+)
+
+type ApprovalPolicy struct {
+	ID            int64          `gorm:"column:id;primaryKey" json:"id"`
+	Serial        string         `gorm:"column:serial" json:"serial"`
+	TenantCode    string         `gorm:"column:tenant_code" json:"tenant_code"`
+	ObjectCode    string         `gorm:"column:object_code" json:"object_code"`
+	Name          string         `gorm:"column:name" json:"name"`
+	EventTypes    string         `gorm:"column:event_types" json:"event_types"`
+	Condition     string         `gorm:"column:condition" json:"condition"`
+	ApproverRoles string         `gorm:"column:approver_roles" json:"approver_roles"`
+	IsActive      bool           `gorm:"column:is_active" json:"is_active"`
+	CreatedBy     string         `gorm:"column:created_by" json:"created_by"`
+	CreatedAt     time.Time      `gorm:"column:created_at" json:"created_at"`
+	UpdatedBy     string         `gorm:"column:updated_by" json:"updated_by"`
+	UpdatedAt     time.Time      `gorm:"column:updated_at" json:"updated_at"`
+	DeletedBy     sql.NullString `gorm:"column:deleted_by" json:"deleted_by"`
+	DeletedAt     gorm.DeletedAt `gorm:"column:deleted_at" json:"deleted_at"`
+}
+
+func (ap *ApprovalPolicy) TableName() string {
+	return "approval_policy"
+}
+
+func (ap *ApprovalPolicy) ToEntity() entity.ApprovalPolicy {
+	// convert event types, condition and approver roles from string
+	eventTypes := []string{}
+	if err := json.Unmarshal([]byte(ap.EventTypes), &eventTypes); err != nil {
+		eventTypes = nil
+	}
+
+	condition := []entity.FilterGroup{}
+	if err := json.Unmarshal([]byte(ap.Condition), &condition); err != nil {
+		condition = nil
+	}
+
+	approverRoles := []string{}
+	if err := json.Unmarshal([]byte(ap.ApproverRoles), &approverRoles); err != nil {
+		approverRoles = nil
+	}
+
+	return entity.ApprovalPolicy{
+		ID:            ap.ID,
+		Serial:        ap.Serial,
+		TenantCode:    ap.TenantCode,
+		ObjectCode:    ap.ObjectCode,
+		Name:          ap.Name,
+		EventTypes:    eventTypes,
+		Condition:     condition,
+		ApproverRoles: approverRoles,
+		IsActive:      ap.IsActive,
+		CreatedBy:     ap.CreatedBy,
+		CreatedAt:     ap.CreatedAt,
+	}
+}
+
+func NewApprovalPolicy(policy entity.ApprovalPolicy) (ApprovalPolicy, error) {
+	eventTypes, err := json.Marshal(policy.EventTypes)
+	if err != nil {
+		return ApprovalPolicy{}, err
+	}
+
+	condition, err := json.Marshal(policy.Condition)
+	if err != nil {
+		return ApprovalPolicy{}, err
+	}
+
+	approverRoles, err := json.Marshal(policy.ApproverRoles)
+	if err != nil {
+		return ApprovalPolicy{}, err
+	}
+
+	return ApprovalPolicy{
+		Serial:        policy.Serial,
+		TenantCode:    policy.TenantCode,
+		ObjectCode:    policy.ObjectCode,
+		Name:          policy.Name,
+		EventTypes:    string(eventTypes),
+		Condition:     string(condition),
+		ApproverRoles: string(approverRoles),
+		IsActive:      policy.IsActive,
+		CreatedBy:     policy.CreatedBy,
+		CreatedAt:     policy.CreatedAt,
+		UpdatedBy:     policy.CreatedBy,
+		UpdatedAt:     policy.CreatedAt,
+	}, nil
+}
+
+type ChangeRequest struct {
+	ID            int64          `gorm:"column:id;primaryKey" json:"id"`
+	Serial        string         `gorm:"column:serial" json:"serial"`
+	TenantCode    string         `gorm:"column:tenant_code" json:"tenant_code"`
+	ProductCode   string         `gorm:"column:product_code" json:"product_code"`
+	ObjectCode    string         `gorm:"column:object_code" json:"object_code"`
+	RecordSerial  string         `gorm:"column:record_serial" json:"record_serial"`
+	PolicySerial  string         `gorm:"column:policy_serial" json:"policy_serial"`
+	Action        string         `gorm:"column:action" json:"action"`
+	Mutation      string         `gorm:"column:mutation" json:"mutation"`
+	Status        string         `gorm:"column:status" json:"status"`
+	RequestedBy   string         `gorm:"column:requested_by" json:"requested_by"`
+	RequesterRole string         `gorm:"column:requester_role" json:"requester_role"`
+	RequestedAt   time.Time      `gorm:"column:requested_at" json:"requested_at"`
+	DecidedBy     sql.NullString `gorm:"column:decided_by" json:"decided_by"`
+	DecidedAt     sql.NullTime   `gorm:"column:decided_at" json:"decided_at"`
+	Comment       sql.NullString `gorm:"column:comment" json:"comment"`
+}
+
+func (cr *ChangeRequest) TableName() string {
+	return "change_request"
+}
+
+func (cr *ChangeRequest) ToEntity() entity.ChangeRequest {
+	mutation := entity.DataMutationRequest{}
+	if err := json.Unmarshal([]byte(cr.Mutation), &mutation); err != nil {
+		mutation = entity.DataMutationRequest{}
+	}
+
+	var decidedAt *time.Time
+	if cr.DecidedAt.Valid {
+		decidedAt = &cr.DecidedAt.Time
+	}
+
+	return entity.ChangeRequest{
+		ID:            cr.ID,
+		Serial:        cr.Serial,
+		TenantCode:    cr.TenantCode,
+		ProductCode:   cr.ProductCode,
+		ObjectCode:    cr.ObjectCode,
+		RecordSerial:  cr.RecordSerial,
+		PolicySerial:  cr.PolicySerial,
+		Action:        cr.Action,
+		Mutation:      mutation,
+		Status:        cr.Status,
+		RequestedBy:   cr.RequestedBy,
+		RequesterRole: cr.RequesterRole,
+		RequestedAt:   cr.RequestedAt,
+		DecidedBy:     cr.DecidedBy.String,
+		DecidedAt:     decidedAt,
+		Comment:       cr.Comment.String,
+	}
+}
+
+func NewChangeRequest(changeRequest entity.ChangeRequest) (ChangeRequest, error) {
+	mutation, err := json.Marshal(changeRequest.Mutation)
+	if err != nil {
+		return ChangeRequest{}, err
+	}
+
+	decidedAt := sql.NullTime{}
+	if changeRequest.DecidedAt != nil {
+		decidedAt = sql.NullTime{Time: *changeRequest.DecidedAt, Valid: true}
+	}
+
+	return ChangeRequest{
+		ID:            changeRequest.ID,
+		Serial:        changeRequest.Serial,
+		TenantCode:    changeRequest.TenantCode,
+		ProductCode:   changeRequest.ProductCode,
+		ObjectCode:    changeRequest.ObjectCode,
+		RecordSerial:  changeRequest.RecordSerial,
+		PolicySerial:  changeRequest.PolicySerial,
+		Action:        changeRequest.Action,
+		Mutation:      string(mutation),
+		Status:        changeRequest.Status,
+		RequestedBy:   changeRequest.RequestedBy,
+		RequesterRole: changeRequest.RequesterRole,
+		RequestedAt:   changeRequest.RequestedAt,
+		DecidedBy:     sql.NullString{String: changeRequest.DecidedBy, Valid: changeRequest.DecidedBy != ""},
+		DecidedAt:     decidedAt,
+		Comment:       sql.NullString{String: changeRequest.Comment, Valid: changeRequest.Comment != ""},
+	}, nil
+}
@@ -0,0 +1,215 @@
+package approvalrepository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/cerkas/cerkas-backend/config"
+	"github.com/cerkas/cerkas-backend/core/entity"
+	repository_intf "github.com/cerkas/cerkas-backend/core/repository"
+	"github.com/cerkas/cerkas-backend/repository/util"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type repository struct {
+	cfg config.Config
+	db  *gorm.DB
+}
+
+func New(cfg config.Config, db *gorm.DB) repository_intf.ApprovalRepository {
+	return &repository{
+		cfg: cfg,
+		db:  db,
+	}
+}
+
+func (r *repository) CreatePolicy(ctx context.Context, policy entity.ApprovalPolicy) (resp entity.ApprovalPolicy, err error) {
+	record, err := NewApprovalPolicy(policy)
+	if err != nil {
+		return resp, err
+	}
+
+	db := r.db.Model(&ApprovalPolicy{})
+
+	if r.cfg.IsDebugMode {
+		db = db.Debug()
+	}
+
+	if err := db.Create(&record).Error; err != nil {
+		return resp, err
+	}
+
+	return record.ToEntity(), nil
+}
+
+func (r *repository) GetPolicies(ctx context.Context, tenantCode, objectCode string) (resp []entity.ApprovalPolicy, err error) {
+	db := r.db.Model(&ApprovalPolicy{})
+
+	if r.cfg.IsDebugMode {
+		db = db.Debug()
+	}
+
+	results := []ApprovalPolicy{}
+	if err := db.Where("tenant_code = ? AND object_code = ?", tenantCode, objectCode).Order("id ASC").Find(&results).Error; err != nil {
+		return resp, err
+	}
+
+	for _, result := range results {
+		resp = append(resp, result.ToEntity())
+	}
+
+	return resp, nil
+}
+
+// GetPolicy returns a policy by serial, deleted policies included so pending change requests can still be decided
+func (r *repository) GetPolicy(ctx context.Context, serial string) (resp entity.ApprovalPolicy, err error) {
+	db := r.db.Model(&ApprovalPolicy{})
+
+	if r.cfg.IsDebugMode {
+		db = db.Debug()
+	}
+
+	result := ApprovalPolicy{}
+	if err := db.Unscoped().Where("serial = ?", serial).First(&result).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return resp, entity.ErrorNotFound
+		}
+
+		return resp, err
+	}
+
+	return result.ToEntity(), nil
+}
+
+func (r *repository) GetActivePolicies(ctx context.Context, tenantCode, objectCode, eventType string) (resp []entity.ApprovalPolicy, err error) {
+	db := util.ContextDB(ctx, r.db).Model(&ApprovalPolicy{})
+
+	if r.cfg.IsDebugMode {
+		db = db.Debug()
+	}
+
+	results := []ApprovalPolicy{}
+	if err := db.Where("tenant_code = ? AND object_code = ? AND is_active = ?", tenantCode, objectCode, true).
+		Where("event_types::jsonb @> ?::jsonb", fmt.Sprintf("[%q]", eventType)).
+		Order("id ASC").
+		Find(&results).Error; err != nil {
+		return resp, err
+	}
+
+	for _, result := range results {
+		resp = append(resp, result.ToEntity())
+	}
+
+	return resp, nil
+}
+
+func (r *repository) DeletePolicy(ctx context.Context, tenantCode, serial string) (err error) {
+	db := r.db.Model(&ApprovalPolicy{})
+
+	if r.cfg.IsDebugMode {
+		db = db.Debug()
+	}
+
+	result := db.Where("tenant_code = ? AND serial = ?", tenantCode, serial).Delete(&ApprovalPolicy{})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return entity.ErrorNotFound
+	}
+
+	return nil
+}
+
+func (r *repository) CreateChangeRequest(ctx context.Context, changeRequest entity.ChangeRequest) (resp entity.ChangeRequest, err error) {
+	record, err := NewChangeRequest(changeRequest)
+	if err != nil {
+		return resp, err
+	}
+
+	db := util.ContextDB(ctx, r.db).Model(&ChangeRequest{})
+
+	if r.cfg.IsDebugMode {
+		db = db.Debug()
+	}
+
+	if err := db.Create(&record).Error; err != nil {
+		return resp, err
+	}
+
+	return record.ToEntity(), nil
+}
+
+func (r *repository) GetChangeRequests(ctx context.Context, request entity.ChangeRequestQuery) (resp []entity.ChangeRequest, err error) {
+	db := r.db.Model(&ChangeRequest{})
+
+	if r.cfg.IsDebugMode {
+		db = db.Debug()
+	}
+
+	db = db.Where("tenant_code = ?", request.TenantCode)
+
+	if request.ObjectCode != "" {
+		db = db.Where("object_code = ?", request.ObjectCode)
+	}
+
+	if request.Status != "" {
+		db = db.Where("status = ?", request.Status)
+	}
+
+	results := []ChangeRequest{}
+	if err := db.Order("id DESC").Find(&results).Error; err != nil {
+		return resp, err
+	}
+
+	for _, result := range results {
+		resp = append(resp, result.ToEntity())
+	}
+
+	return resp, nil
+}
+
+// GetChangeRequestForUpdate reads a change request and locks it until the transaction of ctx ends, so it is
+// decided only once
+func (r *repository) GetChangeRequestForUpdate(ctx context.Context, tenantCode, serial string) (resp entity.ChangeRequest, err error) {
+	db := util.ContextDB(ctx, r.db).Model(&ChangeRequest{})
+
+	if r.cfg.IsDebugMode {
+		db = db.Debug()
+	}
+
+	result := ChangeRequest{}
+	if err := db.Clauses(clause.Locking{Strength: "UPDATE"}).Where("tenant_code = ? AND serial = ?", tenantCode, serial).First(&result).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return resp, entity.ErrorNotFound
+		}
+
+		return resp, err
+	}
+
+	return result.ToEntity(), nil
+}
+
+// UpdateChangeRequest stores the decision on a change request
+func (r *repository) UpdateChangeRequest(ctx context.Context, changeRequest entity.ChangeRequest) (err error) {
+	record, err := NewChangeRequest(changeRequest)
+	if err != nil {
+		return err
+	}
+
+	db := util.ContextDB(ctx, r.db).Model(&ChangeRequest{})
+
+	if r.cfg.IsDebugMode {
+		db = db.Debug()
+	}
+
+	return db.Where("serial = ?", record.Serial).Updates(map[string]any{
+		"status":     record.Status,
+		"decided_by": record.DecidedBy,
+		"decided_at": record.DecidedAt,
+		"comment":    record.Comment,
+	}).Error
+}
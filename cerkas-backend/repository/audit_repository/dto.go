@@ -0,0 +1,65 @@
+package auditrepository
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/cerkas/cerkas-backend/core/entity"
+)
+
+type AuditLog struct {
+	ID           int64     `gorm:"column:id;primaryKey" json:"id"`
+	Serial       string    `gorm:"column:serial" json:"serial"`
+	TenantCode   string    `gorm:"column:tenant_code" json:"tenant_code"`
+	ProductCode  string    `gorm:"column:product_code" json:"product_code"`
+	ObjectCode   string    `gorm:"column:object_code" json:"object_code"`
+	RecordSerial string    `gorm:"column:record_serial" json:"record_serial"`
+	Action       string    `gorm:"column:action" json:"action"`
+	ActorSerial  string    `gorm:"column:actor_serial" json:"actor_serial"`
+	Changes      string    `gorm:"column:changes" json:"changes"`
+	CreatedAt    time.Time `gorm:"column:created_at" json:"created_at"`
+}
+
+func (al *AuditLog) TableName() string {
+	return "audit_log"
+}
+
+func (al *AuditLog) ToEntity() entity.AuditLog {
+	// convert changes from string to slice
+	changes := []entity.AuditChange{}
+	if err := json.Unmarshal([]byte(al.Changes), &changes); err != nil {
+		changes = nil
+	}
+
+	return entity.AuditLog{
+		ID:           al.ID,
+		Serial:       al.Serial,
+		TenantCode:   al.TenantCode,
+		ProductCode:  al.ProductCode,
+		ObjectCode:   al.ObjectCode,
+		RecordSerial: al.RecordSerial,
+		Action:       al.Action,
+		ActorSerial:  al.ActorSerial,
+		Changes:      changes,
+		CreatedAt:    al.CreatedAt,
+	}
+}
+
+func NewAuditLog(auditLog entity.AuditLog) (AuditLog, error) {
+	changes, err := json.Marshal(auditLog.Changes)
+	if err != nil {
+		return AuditLog{}, err
+	}
+
+	return AuditLog{
+		Serial:       auditLog.Serial,
+		TenantCode:   auditLog.TenantCode,
+		ProductCode:  auditLog.ProductCode,
+		ObjectCode:   auditLog.ObjectCode,
+		RecordSerial: auditLog.RecordSerial,
+		Action:       auditLog.Action,
+		ActorSerial:  auditLog.ActorSerial,
+		Changes:      string(changes),
+		CreatedAt:    auditLog.CreatedAt,
+	}, nil
+}
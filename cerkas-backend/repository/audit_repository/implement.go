@@ -0,0 +1,58 @@
+package auditrepository
+
+import (
+	"context"
+
+	"github.com/cerkas/cerkas-backend/config"
+	"github.com/cerkas/cerkas-backend/core/entity"
+	repository_intf "github.com/cerkas/cerkas-backend/core/repository"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type repository struct {
+	cfg config.Config
+	db  *gorm.DB
+}
+
+func New(cfg config.Config, db *gorm.DB) repository_intf.AuditRepository {
+	return &repository{
+		cfg: cfg,
+		db:  db,
+	}
+}
+
+// CreateAuditLog appends an entry to the audit log, entries are never updated or deleted
+func (r *repository) CreateAuditLog(ctx context.Context, auditLog entity.AuditLog) (err error) {
+	record, err := NewAuditLog(auditLog)
+	if err != nil {
+		return err
+	}
+
+	db := r.db.Model(&AuditLog{})
+
+	if r.cfg.IsDebugMode {
+		db = db.Debug()
+	}
+
+	return db.Clauses(clause.OnConflict{DoNothing: true}).Create(&record).Error
+}
+
+func (r *repository) GetAuditLogsByRecord(ctx context.Context, tenantCode, objectCode, recordSerial string) (resp []entity.AuditLog, err error) {
+	db := r.db.Model(&AuditLog{})
+
+	if r.cfg.IsDebugMode {
+		db = db.Debug()
+	}
+
+	results := []AuditLog{}
+	if err := db.Where("tenant_code = ? AND object_code = ? AND record_serial = ?", tenantCode, objectCode, recordSerial).Order("created_at ASC, id ASC").Find(&results).Error; err != nil {
+		return resp, err
+	}
+
+	for _, result := range results {
+		resp = append(resp, result.ToEntity())
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,103 @@
+package catalogrepository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cerkas/cerkas-backend/pkg/conn"
+	"github.com/cerkas/cerkas-backend/pkg/helper"
+	"github.com/cerkas/cerkas-backend/repository/util"
+	"gorm.io/gorm"
+)
+
+// dataSource is the connection and dialect used to query the data of an object
+type dataSource struct {
+	db      *gorm.DB
+	dialect Dialect
+}
+
+func (r *repository) defaultDataSource() dataSource {
+	return dataSource{
+		db:      r.db,
+		dialect: NewDialect(r.cfg.DBType),
+	}
+}
+
+// resolveDataSource returns the data source of an object. Objects without data source, or whose data source
+// has no host and no dsn, live in the main database and join the transaction carried by ctx.
+func (r *repository) resolveDataSource(ctx context.Context, tenantCode, objectCode string) (dataSource, error) {
+	source, err := r.lookupDataSource(ctx, tenantCode, objectCode)
+	if err != nil {
+		return source, err
+	}
+
+	if source.db == r.db {
+		source.db = util.ContextDB(ctx, r.db)
+	}
+
+	return source, nil
+}
+
+func (r *repository) lookupDataSource(ctx context.Context, tenantCode, objectCode string) (dataSource, error) {
+	// use Find instead of First, most metadata tables have no object record and that is not an error
+	object := Objects{}
+	db := r.db.Model(&Objects{})
+	db.Joins("JOIN tenants ON tenants.serial = objects.tenant_serial")
+	db.Where("objects.code = ?", objectCode)
+	db.Where("tenants.code = ?", tenantCode)
+
+	if err := db.Limit(1).Find(&object).Error; err != nil {
+		return dataSource{}, err
+	}
+
+	if object.DataSourceSerial == "" {
+		return r.defaultDataSource(), nil
+	}
+
+	if cached, ok := r.dataSources.Load(object.DataSourceSerial); ok {
+		return cached.(dataSource), nil
+	}
+
+	result := DataSource{}
+	if err := r.db.Model(&DataSource{}).Where("serial = ?", object.DataSourceSerial).Limit(1).Find(&result).Error; err != nil {
+		return dataSource{}, err
+	}
+
+	// never fall back to the main database, the data of the object would be read from and written to the wrong place
+	if result.Serial == "" {
+		return dataSource{}, fmt.Errorf("data source %v of object %v is not found", object.DataSourceSerial, objectCode)
+	}
+
+	source := result.ToEntity()
+	if _, ok := source.Configs["dsn"]; !ok && source.Host == "" && source.Driver != DialectSQLite {
+		return dataSource{db: r.db, dialect: NewDialect(source.Driver)}, nil
+	}
+
+	// passwords are stored encrypted with the internal secret key, older records are still plain text
+	password := source.Password
+	if decrypted, err := helper.DecryptPassword(source.Password, r.cfg.InternalSecretKey); err == nil {
+		password = decrypted
+	}
+
+	db, err := conn.OpenDataSource(&r.cfg, conn.DataSourceConfig{
+		Driver:   source.Driver,
+		Host:     source.Host,
+		Port:     source.Port,
+		Username: source.Username,
+		Password: password,
+		DBName:   source.DBName,
+		Configs:  source.Configs,
+	})
+	if err != nil {
+		return dataSource{}, err
+	}
+
+	resolved := dataSource{db: db, dialect: NewDialect(source.Driver)}
+	if cached, loaded := r.dataSources.LoadOrStore(object.DataSourceSerial, resolved); loaded {
+		// another request opened the same data source first
+		conn.DbClose(db)
+		return cached.(dataSource), nil
+	}
+
+	return resolved, nil
+}
@@ -0,0 +1,583 @@
+package catalogrepository
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/cerkas/cerkas-backend/core/entity"
+)
+
+const (
+	DialectPostgres  = "postgres"
+	DialectMySQL     = "mysql"
+	DialectSQLite    = "sqlite"
+	DialectSQLServer = "sqlserver"
+)
+
+var (
+	plainIdentifierRegex = regexp.MustCompile(`^[a-z_][a-z0-9_]*$`)
+	mysqlLiteralReplacer = strings.NewReplacer(`\`, `\\`, `'`, `''`)
+)
+
+// Dialect hides the SQL differences between the databases an object data source can live in.
+// Every query built against object data (not the metadata tables) goes through it.
+type Dialect interface {
+	Name() string
+
+	// QuoteIdentifier quotes a single identifier (schema, table, column or alias)
+	QuoteIdentifier(identifier string) string
+
+	// TableName returns the qualified table name of an object inside a tenant
+	TableName(schema, table string) string
+
+	// ColumnName returns the qualified column name of an object column
+	ColumnName(schema, table, column string) string
+
+	// ColumnListQuery returns a query producing field_code, data_type, foreign_table_name and foreign_field_name
+	ColumnListQuery(schema, table string) (query string, args []any)
+
+	// ForeignKeyQuery returns a query producing foreign_schema, foreign_table and foreign_column of a single column
+	ForeignKeyQuery(schema, table, column string) (query string, args []any)
+
+	// ReferencingKeyQuery returns a query producing child_table, child_column and parent_column of every foreign key pointing at a table
+	ReferencingKeyQuery(schema, table string) (query string, args []any)
+
+	// LikeOperator returns the case-insensitive match operator
+	LikeOperator(negate bool) string
+
+	// QuoteLiteral quotes a string value as a sql string literal
+	QuoteLiteral(value string) string
+
+	// BooleanLiteral returns the literal used for boolean values
+	BooleanLiteral(value bool) string
+
+	// Paginate appends the limit and offset clause to a query, ordered tells whether the query ends with an ORDER BY
+	Paginate(query string, ordered bool, limit, offset int) string
+
+	// Concat joins string expressions, a null part counts as an empty string
+	Concat(parts []string) string
+
+	// DateDiff returns the number of days from start to end
+	DateDiff(end, start string) string
+}
+
+// NewDialect returns the dialect of the given driver name, postgres is used when driver is empty or unknown
+func NewDialect(driver string) Dialect {
+	switch strings.ToLower(driver) {
+	case DialectMySQL, "mariadb":
+		return mysqlDialect{}
+	case DialectSQLite, "sqlite3":
+		return sqliteDialect{}
+	case DialectSQLServer, "mssql":
+		return sqlServerDialect{}
+	default:
+		return postgresDialect{}
+	}
+}
+
+// operatorQuery returns the sql operator of a filter operator using the dialect for case-insensitive match
+func operatorQuery(dialect Dialect, operator entity.FilterOperator) string {
+	switch operator {
+	case entity.FilterOperatorContains:
+		return dialect.LikeOperator(false)
+	case entity.FilterOperatorNotContains:
+		return dialect.LikeOperator(true)
+	}
+
+	return entity.OperatorQueryMap[operator]
+}
+
+// postgres
+
+type postgresDialect struct{}
+
+func (postgresDialect) Name() string {
+	return DialectPostgres
+}
+
+func (postgresDialect) QuoteIdentifier(identifier string) string {
+	// keep plain lower case identifiers as is, so existing schemas created without quotes still resolve
+	if plainIdentifierRegex.MatchString(identifier) {
+		return identifier
+	}
+
+	return `"` + strings.ReplaceAll(identifier, `"`, `""`) + `"`
+}
+
+func (d postgresDialect) TableName(schema, table string) string {
+	return qualify(d, schema, table)
+}
+
+func (d postgresDialect) ColumnName(schema, table, column string) string {
+	return qualify(d, schema, table, column)
+}
+
+func (postgresDialect) ColumnListQuery(schema, table string) (string, []any) {
+	return `
+	SELECT
+		col.column_name as field_code,
+		col.udt_name as data_type,
+		ccu.table_name AS foreign_table_name,
+		ccu.column_name AS foreign_field_name
+	FROM
+		information_schema.columns AS col
+	LEFT JOIN information_schema.key_column_usage AS kcu ON col.table_name = kcu.table_name
+	AND col.column_name = kcu.column_name
+	LEFT JOIN information_schema.constraint_column_usage AS ccu ON kcu.constraint_name = ccu.constraint_name
+	WHERE
+		col.table_schema = ?
+	AND col.table_name = ?
+	`, []any{schema, table}
+}
+
+func (postgresDialect) ForeignKeyQuery(schema, table, column string) (string, []any) {
+	return `
+	SELECT
+		ccu.table_schema AS foreign_schema,
+		ccu.table_name   AS foreign_table,
+		ccu.column_name  AS foreign_column
+	FROM
+		information_schema.table_constraints AS tc
+		JOIN information_schema.key_column_usage AS kcu
+		  ON tc.constraint_name = kcu.constraint_name
+		 AND tc.constraint_schema = kcu.constraint_schema
+		JOIN information_schema.constraint_column_usage AS ccu
+		  ON ccu.constraint_name = tc.constraint_name
+		 AND ccu.constraint_schema = tc.constraint_schema
+	WHERE
+		tc.constraint_type = 'FOREIGN KEY'
+		AND kcu.column_name = ?
+		AND tc.table_name = ?
+		AND tc.table_schema = ?
+	LIMIT 1;
+	`, []any{column, table, schema}
+}
+
+func (postgresDialect) ReferencingKeyQuery(schema, table string) (string, []any) {
+	return `
+	SELECT
+		kcu.table_name  AS child_table,
+		kcu.column_name AS child_column,
+		ccu.column_name AS parent_column
+	FROM
+		information_schema.table_constraints AS tc
+		JOIN information_schema.key_column_usage AS kcu
+		  ON tc.constraint_name = kcu.constraint_name
+		 AND tc.constraint_schema = kcu.constraint_schema
+		JOIN information_schema.constraint_column_usage AS ccu
+		  ON ccu.constraint_name = tc.constraint_name
+		 AND ccu.constraint_schema = tc.constraint_schema
+	WHERE
+		tc.constraint_type = 'FOREIGN KEY'
+		AND ccu.table_name = ?
+		AND ccu.table_schema = ?
+		AND tc.table_schema = ?
+	ORDER BY kcu.table_name, kcu.column_name
+	`, []any{table, schema, schema}
+}
+
+func (postgresDialect) LikeOperator(negate bool) string {
+	if negate {
+		return "NOT ILIKE"
+	}
+
+	return "ILIKE"
+}
+
+func (postgresDialect) QuoteLiteral(value string) string {
+	return standardLiteral(value)
+}
+
+func (postgresDialect) BooleanLiteral(value bool) string {
+	return fmt.Sprintf("%t", value)
+}
+
+func (postgresDialect) Paginate(query string, ordered bool, limit, offset int) string {
+	return fmt.Sprintf("%s LIMIT %d OFFSET %d", query, limit, offset)
+}
+
+func (postgresDialect) Concat(parts []string) string {
+	return fmt.Sprintf("CONCAT(%v)", strings.Join(parts, ", "))
+}
+
+func (postgresDialect) DateDiff(end, start string) string {
+	return fmt.Sprintf("(CAST(%v AS date) - CAST(%v AS date))", end, start)
+}
+
+// mysql
+
+type mysqlDialect struct{}
+
+func (mysqlDialect) Name() string {
+	return DialectMySQL
+}
+
+func (mysqlDialect) QuoteIdentifier(identifier string) string {
+	return "`" + strings.ReplaceAll(identifier, "`", "``") + "`"
+}
+
+func (d mysqlDialect) TableName(schema, table string) string {
+	return qualify(d, schema, table)
+}
+
+func (d mysqlDialect) ColumnName(schema, table, column string) string {
+	return qualify(d, schema, table, column)
+}
+
+func (mysqlDialect) ColumnListQuery(schema, table string) (string, []any) {
+	return `
+	SELECT
+		col.column_name AS field_code,
+		col.data_type AS data_type,
+		kcu.referenced_table_name AS foreign_table_name,
+		kcu.referenced_column_name AS foreign_field_name
+	FROM
+		information_schema.columns AS col
+	LEFT JOIN information_schema.key_column_usage AS kcu ON col.table_schema = kcu.table_schema
+	AND col.table_name = kcu.table_name
+	AND col.column_name = kcu.column_name
+	AND kcu.referenced_table_name IS NOT NULL
+	WHERE
+		col.table_schema = ?
+	AND col.table_name = ?
+	ORDER BY col.ordinal_position
+	`, []any{schema, table}
+}
+
+func (mysqlDialect) ForeignKeyQuery(schema, table, column string) (string, []any) {
+	return `
+	SELECT
+		kcu.referenced_table_schema AS foreign_schema,
+		kcu.referenced_table_name AS foreign_table,
+		kcu.referenced_column_name AS foreign_column
+	FROM
+		information_schema.key_column_usage AS kcu
+	WHERE
+		kcu.referenced_table_name IS NOT NULL
+		AND kcu.column_name = ?
+		AND kcu.table_name = ?
+		AND kcu.table_schema = ?
+	LIMIT 1
+	`, []any{column, table, schema}
+}
+
+func (mysqlDialect) ReferencingKeyQuery(schema, table string) (string, []any) {
+	return `
+	SELECT
+		kcu.table_name AS child_table,
+		kcu.column_name AS child_column,
+		kcu.referenced_column_name AS parent_column
+	FROM
+		information_schema.key_column_usage AS kcu
+	WHERE
+		kcu.referenced_table_name = ?
+		AND kcu.referenced_table_schema = ?
+		AND kcu.table_schema = ?
+	ORDER BY kcu.table_name, kcu.column_name
+	`, []any{table, schema, schema}
+}
+
+func (mysqlDialect) LikeOperator(negate bool) string {
+	// the default mysql collations are already case-insensitive
+	if negate {
+		return "NOT LIKE"
+	}
+
+	return "LIKE"
+}
+
+// QuoteLiteral escapes backslashes as well, mysql reads them as escapes inside string literals
+func (mysqlDialect) QuoteLiteral(value string) string {
+	return "'" + mysqlLiteralReplacer.Replace(value) + "'"
+}
+
+func (mysqlDialect) BooleanLiteral(value bool) string {
+	return fmt.Sprintf("%t", value)
+}
+
+func (mysqlDialect) Paginate(query string, ordered bool, limit, offset int) string {
+	return fmt.Sprintf("%s LIMIT %d OFFSET %d", query, limit, offset)
+}
+
+// Concat uses CONCAT_WS, CONCAT of mysql is null as soon as a part is
+func (mysqlDialect) Concat(parts []string) string {
+	return fmt.Sprintf("CONCAT_WS('', %v)", strings.Join(parts, ", "))
+}
+
+func (mysqlDialect) DateDiff(end, start string) string {
+	return fmt.Sprintf("DATEDIFF(%v, %v)", end, start)
+}
+
+// sqlite
+
+type sqliteDialect struct{}
+
+func (sqliteDialect) Name() string {
+	return DialectSQLite
+}
+
+func (sqliteDialect) QuoteIdentifier(identifier string) string {
+	return `"` + strings.ReplaceAll(identifier, `"`, `""`) + `"`
+}
+
+// TableName ignores the schema, a sqlite data source is a single database file per tenant
+func (d sqliteDialect) TableName(_, table string) string {
+	return d.QuoteIdentifier(table)
+}
+
+func (d sqliteDialect) ColumnName(_, table, column string) string {
+	return qualify(d, table, column)
+}
+
+func (sqliteDialect) ColumnListQuery(_, table string) (string, []any) {
+	return `
+	SELECT
+		col.name AS field_code,
+		LOWER(col.type) AS data_type,
+		fk."table" AS foreign_table_name,
+		fk."to" AS foreign_field_name
+	FROM
+		pragma_table_info(?) AS col
+	LEFT JOIN pragma_foreign_key_list(?) AS fk ON fk."from" = col.name
+	ORDER BY col.cid
+	`, []any{table, table}
+}
+
+func (sqliteDialect) ForeignKeyQuery(_, table, column string) (string, []any) {
+	return `
+	SELECT
+		'main' AS foreign_schema,
+		fk."table" AS foreign_table,
+		fk."to" AS foreign_column
+	FROM
+		pragma_foreign_key_list(?) AS fk
+	WHERE
+		fk."from" = ?
+	LIMIT 1
+	`, []any{table, column}
+}
+
+func (sqliteDialect) ReferencingKeyQuery(_, table string) (string, []any) {
+	return `
+	SELECT
+		tab.name AS child_table,
+		fk."from" AS child_column,
+		fk."to" AS parent_column
+	FROM
+		sqlite_master AS tab
+		JOIN pragma_foreign_key_list(tab.name) AS fk
+	WHERE
+		tab.type = 'table'
+		AND fk."table" = ?
+	ORDER BY tab.name, fk."from"
+	`, []any{table}
+}
+
+func (sqliteDialect) LikeOperator(negate bool) string {
+	// sqlite LIKE is case-insensitive for ASCII characters
+	if negate {
+		return "NOT LIKE"
+	}
+
+	return "LIKE"
+}
+
+func (sqliteDialect) QuoteLiteral(value string) string {
+	return standardLiteral(value)
+}
+
+func (sqliteDialect) BooleanLiteral(value bool) string {
+	if value {
+		return "1"
+	}
+
+	return "0"
+}
+
+func (sqliteDialect) Paginate(query string, ordered bool, limit, offset int) string {
+	return fmt.Sprintf("%s LIMIT %d OFFSET %d", query, limit, offset)
+}
+
+func (sqliteDialect) Concat(parts []string) string {
+	coalesced := make([]string, 0, len(parts))
+	for _, part := range parts {
+		coalesced = append(coalesced, fmt.Sprintf("COALESCE(%v, '')", part))
+	}
+
+	return fmt.Sprintf("(%v)", strings.Join(coalesced, " || "))
+}
+
+func (sqliteDialect) DateDiff(end, start string) string {
+	return fmt.Sprintf("CAST(julianday(date(%v)) - julianday(date(%v)) AS INTEGER)", end, start)
+}
+
+// sql server
+
+type sqlServerDialect struct{}
+
+func (sqlServerDialect) Name() string {
+	return DialectSQLServer
+}
+
+func (sqlServerDialect) QuoteIdentifier(identifier string) string {
+	return "[" + strings.ReplaceAll(identifier, "]", "]]") + "]"
+}
+
+func (d sqlServerDialect) TableName(schema, table string) string {
+	return qualify(d, schema, table)
+}
+
+func (d sqlServerDialect) ColumnName(schema, table, column string) string {
+	return qualify(d, schema, table, column)
+}
+
+func (sqlServerDialect) ColumnListQuery(schema, table string) (string, []any) {
+	return `
+	SELECT
+		col.COLUMN_NAME AS field_code,
+		col.DATA_TYPE AS data_type,
+		ref_tab.name AS foreign_table_name,
+		ref_col.name AS foreign_field_name
+	FROM
+		INFORMATION_SCHEMA.COLUMNS AS col
+	LEFT JOIN sys.foreign_key_columns AS fkc
+		ON fkc.parent_object_id = OBJECT_ID(QUOTENAME(col.TABLE_SCHEMA) + '.' + QUOTENAME(col.TABLE_NAME))
+		AND COL_NAME(fkc.parent_object_id, fkc.parent_column_id) = col.COLUMN_NAME
+	LEFT JOIN sys.tables AS ref_tab ON ref_tab.object_id = fkc.referenced_object_id
+	LEFT JOIN sys.columns AS ref_col ON ref_col.object_id = fkc.referenced_object_id
+		AND ref_col.column_id = fkc.referenced_column_id
+	WHERE
+		col.TABLE_SCHEMA = ?
+	AND col.TABLE_NAME = ?
+	ORDER BY col.ORDINAL_POSITION
+	`, []any{schema, table}
+}
+
+func (sqlServerDialect) ForeignKeyQuery(schema, table, column string) (string, []any) {
+	return `
+	SELECT TOP 1
+		SCHEMA_NAME(ref_tab.schema_id) AS foreign_schema,
+		ref_tab.name AS foreign_table,
+		ref_col.name AS foreign_column
+	FROM
+		sys.foreign_key_columns AS fkc
+		JOIN sys.tables AS tab ON tab.object_id = fkc.parent_object_id
+		JOIN sys.columns AS col ON col.object_id = fkc.parent_object_id
+		 AND col.column_id = fkc.parent_column_id
+		JOIN sys.tables AS ref_tab ON ref_tab.object_id = fkc.referenced_object_id
+		JOIN sys.columns AS ref_col ON ref_col.object_id = fkc.referenced_object_id
+		 AND ref_col.column_id = fkc.referenced_column_id
+	WHERE
+		col.name = ?
+		AND tab.name = ?
+		AND SCHEMA_NAME(tab.schema_id) = ?
+	`, []any{column, table, schema}
+}
+
+func (sqlServerDialect) ReferencingKeyQuery(schema, table string) (string, []any) {
+	return `
+	SELECT
+		tab.name AS child_table,
+		col.name AS child_column,
+		ref_col.name AS parent_column
+	FROM
+		sys.foreign_key_columns AS fkc
+		JOIN sys.tables AS tab ON tab.object_id = fkc.parent_object_id
+		JOIN sys.columns AS col ON col.object_id = fkc.parent_object_id
+		 AND col.column_id = fkc.parent_column_id
+		JOIN sys.tables AS ref_tab ON ref_tab.object_id = fkc.referenced_object_id
+		JOIN sys.columns AS ref_col ON ref_col.object_id = fkc.referenced_object_id
+		 AND ref_col.column_id = fkc.referenced_column_id
+	WHERE
+		ref_tab.name = ?
+		AND SCHEMA_NAME(ref_tab.schema_id) = ?
+		AND SCHEMA_NAME(tab.schema_id) = ?
+	ORDER BY tab.name, col.name
+	`, []any{table, schema, schema}
+}
+
+func (sqlServerDialect) LikeOperator(negate bool) string {
+	// case sensitivity follows the column collation, which is case-insensitive by default
+	if negate {
+		return "NOT LIKE"
+	}
+
+	return "LIKE"
+}
+
+func (sqlServerDialect) QuoteLiteral(value string) string {
+	return standardLiteral(value)
+}
+
+func (sqlServerDialect) BooleanLiteral(value bool) string {
+	if value {
+		return "1"
+	}
+
+	return "0"
+}
+
+// Paginate uses OFFSET FETCH, which requires an ORDER BY clause
+func (sqlServerDialect) Paginate(query string, ordered bool, limit, offset int) string {
+	if !ordered {
+		query = query + " ORDER BY (SELECT NULL)"
+	}
+
+	return fmt.Sprintf("%s OFFSET %d ROWS FETCH NEXT %d ROWS ONLY", query, offset, limit)
+}
+
+func (sqlServerDialect) Concat(parts []string) string {
+	return fmt.Sprintf("CONCAT(%v)", strings.Join(parts, ", "))
+}
+
+func (sqlServerDialect) DateDiff(end, start string) string {
+	return fmt.Sprintf("DATEDIFF(day, %v, %v)", start, end)
+}
+
+func qualify(dialect Dialect, parts ...string) string {
+	quoted := make([]string, 0, len(parts))
+	for _, part := range parts {
+		if part == "" {
+			continue
+		}
+
+		quoted = append(quoted, dialect.QuoteIdentifier(part))
+	}
+
+	return strings.Join(quoted, ".")
+}
+
+// standardLiteral quotes a string literal the sql standard way, by doubling the single quotes
+func standardLiteral(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
+
+// hasTopLevelOrderBy reports whether a hand written query has its own ORDER BY clause. Only the top level counts, an
+// ORDER BY inside parentheses belongs to a subquery or a window function, and string literals are skipped.
+func hasTopLevelOrderBy(query string) bool {
+	upper := strings.ToUpper(query)
+	depth := 0
+	inString := false
+	found := false
+
+	for i := 0; i < len(upper); i++ {
+		switch char := upper[i]; {
+		case char == '\'':
+			inString = !inString
+		case inString:
+		case char == '(':
+			depth++
+		case char == ')':
+			depth--
+		case depth == 0 && strings.HasPrefix(upper[i:], "ORDER BY") && (i == 0 || !isIdentifierChar(upper[i-1])):
+			found = true
+		}
+	}
+
+	return found
+}
+
+func isIdentifierChar(char byte) bool {
+	return char == '_' || (char >= 'A' && char <= 'Z') || (char >= '0' && char <= '9')
+}
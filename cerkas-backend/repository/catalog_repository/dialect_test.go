@@ -0,0 +1,173 @@
+package catalogrepository
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/cerkas/cerkas-backend/core/entity"
+)
+
+func TestQuoteLiteral(t *testing.T) {
+	tests := []struct {
+		driver string
+		value  string
+		want   string
+	}{
+		{DialectPostgres, `it's`, `'it''s'`},
+		{DialectPostgres, `a\`, `'a\'`},
+		{DialectMySQL, `it's`, `'it''s'`},
+		{DialectMySQL, `a\' OR 1=1 -- `, `'a\\'' OR 1=1 -- '`},
+		{DialectMySQL, `a\`, `'a\\'`},
+		{DialectSQLite, `a\'`, `'a\'''`},
+		{DialectSQLServer, `it's`, `'it''s'`},
+	}
+
+	for _, test := range tests {
+		if got := NewDialect(test.driver).QuoteLiteral(test.value); got != test.want {
+			t.Errorf("%v QuoteLiteral(%q) = %q, want %q", test.driver, test.value, got, test.want)
+		}
+	}
+}
+
+func TestHasTopLevelOrderBy(t *testing.T) {
+	tests := []struct {
+		query string
+		want  bool
+	}{
+		{"SELECT * FROM t ORDER BY id", true},
+		{"select * from t order by id desc", true},
+		{"SELECT * FROM (SELECT * FROM t ORDER BY id) AS s", false},
+		{"SELECT ROW_NUMBER() OVER (ORDER BY id) FROM t", false},
+		{"SELECT * FROM t WHERE name = 'x ORDER BY y'", false},
+		{"SELECT * FROM t WHERE name = 'it''s' ORDER BY name", true},
+		{"SELECT reorder BY_x FROM t", false},
+	}
+
+	for _, test := range tests {
+		if got := hasTopLevelOrderBy(test.query); got != test.want {
+			t.Errorf("hasTopLevelOrderBy(%q) = %v, want %v", test.query, got, test.want)
+		}
+	}
+}
+
+func TestSQLServerPaginate(t *testing.T) {
+	dialect := NewDialect(DialectSQLServer)
+
+	got := dialect.Paginate("SELECT ROW_NUMBER() OVER (ORDER BY id) FROM t", false, 10, 20)
+	want := "SELECT ROW_NUMBER() OVER (ORDER BY id) FROM t ORDER BY (SELECT NULL) OFFSET 20 ROWS FETCH NEXT 10 ROWS ONLY"
+	if got != want {
+		t.Errorf("Paginate = %q, want %q", got, want)
+	}
+
+	got = dialect.Paginate("SELECT * FROM t ORDER BY id", true, 10, 0)
+	want = "SELECT * FROM t ORDER BY id OFFSET 0 ROWS FETCH NEXT 10 ROWS ONLY"
+	if got != want {
+		t.Errorf("Paginate = %q, want %q", got, want)
+	}
+}
+
+func TestBuildOrderByDirection(t *testing.T) {
+	request := entity.CatalogQuery{
+		TenantCode: "acme",
+		ObjectCode: "invoice",
+		Orders: []entity.Order{
+			{FieldName: "amount", Direction: "desc"},
+			{FieldName: "code", Direction: "ASC; DROP TABLE acme.invoice"},
+			{FieldName: "name"},
+		},
+	}
+
+	got := buildOrderBy(NewDialect(DialectPostgres), request, nil)
+	want := "acme.invoice.amount DESC, acme.invoice.code ASC, acme.invoice.name ASC"
+	if got != want {
+		t.Errorf("buildOrderBy = %q, want %q", got, want)
+	}
+}
+
+func TestFormatMutationValueInteger(t *testing.T) {
+	dialect := NewDialect(DialectPostgres)
+
+	valid := map[any]string{
+		42:         "42",
+		int64(-7):  "-7",
+		float64(3): "3",
+		" 12 ":     "12",
+	}
+	for value, want := range valid {
+		got, err := formatMutationValue(dialect, entity.DataItem{FieldCode: "quantity", DataType: "integer", Value: value})
+		if err != nil || got != want {
+			t.Errorf("formatMutationValue(%v) = %q, %v, want %q", value, got, err, want)
+		}
+	}
+
+	for _, value := range []any{"1; DELETE FROM acme.invoice", "1.5", 2.5, true} {
+		if got, err := formatMutationValue(dialect, entity.DataItem{FieldCode: "quantity", DataType: "integer", Value: value}); err == nil {
+			t.Errorf("formatMutationValue(%v) = %q, want an error", value, got)
+		}
+	}
+}
+
+func TestFilterCondition(t *testing.T) {
+	tests := []struct {
+		driver       string
+		filter       entity.FilterItem
+		wantOperator string
+		wantValue    string
+	}{
+		{DialectPostgres, entity.FilterItem{Operator: entity.FilterOperatorContains, Value: "50%_off"}, "ILIKE", `'%50\%\_off%' ESCAPE '\'`},
+		{DialectMySQL, entity.FilterItem{Operator: entity.FilterOperatorContains, Value: `a\b`}, "LIKE", `'%a\\\\b%' ESCAPE '\\'`},
+		{DialectSQLServer, entity.FilterItem{Operator: entity.FilterOperatorNotContains, Value: "[x]"}, "NOT LIKE", `'%\[x]%' ESCAPE '\'`},
+		{DialectPostgres, entity.FilterItem{Operator: entity.FilterOperatorIn, Value: []string{"a", "it's"}}, "IN", `('a', 'it''s')`},
+		{DialectPostgres, entity.FilterItem{Operator: entity.FilterOperatorIn, Value: []any{1, "b"}}, "IN", `(1, 'b')`},
+		{DialectPostgres, entity.FilterItem{Operator: entity.FilterOperatorIn, Value: []string{}}, "IN", `(NULL)`},
+		{DialectPostgres, entity.FilterItem{Operator: entity.FilterOperatorNotEqual, Value: nil}, "IS NOT", `NULL`},
+	}
+
+	for _, test := range tests {
+		operator, value, err := filterCondition(NewDialect(test.driver), test.filter)
+		if err != nil || operator != test.wantOperator || value != test.wantValue {
+			t.Errorf("%v filterCondition(%v %v) = %v %v %v, want %v %v", test.driver, test.filter.Operator, test.filter.Value, operator, value, err, test.wantOperator, test.wantValue)
+		}
+	}
+}
+
+func TestBuildFiltersRejectsUnknownOperators(t *testing.T) {
+	filters := func(groupOperator, operator entity.FilterOperator) []entity.FilterGroup {
+		return []entity.FilterGroup{{
+			Operator: groupOperator,
+			Filters: map[string]entity.FilterItem{
+				"name":   {FieldName: "name", Operator: operator, Value: "a"},
+				"status": {FieldName: "status", Operator: entity.FilterOperatorEqual, Value: "open"},
+			},
+		}}
+	}
+
+	tests := []struct {
+		name    string
+		filters []entity.FilterGroup
+		wantErr error
+	}{
+		{"and", filters("and", entity.FilterOperatorEqual), nil},
+		{"or", filters(entity.FilterOperator(entity.FilterOperatorOr), entity.FilterOperatorContains), nil},
+		{"empty group operator", filters("", entity.FilterOperatorEqual), nil},
+		{"injected group operator", filters("OR 1=1) OR (1=1", entity.FilterOperatorEqual), entity.ErrorFilterGroupOperatorInvalid},
+		{"unknown operator", filters("AND", "between"), entity.ErrorFilterOperatorInvalid},
+		{"empty operator", filters("AND", ""), entity.ErrorFilterOperatorInvalid},
+	}
+
+	r := &repository{}
+	for _, test := range tests {
+		request := entity.CatalogQuery{ObjectCode: "invoice", Filters: test.filters}
+		query, err := r.buildFilters(context.Background(), NewDialect(DialectPostgres), request, "invoice", nil)
+		if !errors.Is(err, test.wantErr) {
+			t.Errorf("%v: buildFilters returned %v, want %v", test.name, err, test.wantErr)
+			continue
+		}
+
+		if test.wantErr == nil && !strings.Contains(query, " AND ") && !strings.Contains(query, " OR ") {
+			t.Errorf("%v: buildFilters = %v, the items are not combined", test.name, query)
+		}
+	}
+}
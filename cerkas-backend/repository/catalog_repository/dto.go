@@ -15,6 +15,8 @@ type Tenants struct {
 	Serial    string         `gorm:"column:serial" json:"serial"`
 	Code      string         `gorm:"column:code" json:"code"`
 	Name      string         `gorm:"column:name" json:"name"`
+	Locale    string         `gorm:"column:locale" json:"locale"`
+	Timezone  string         `gorm:"column:timezone" json:"timezone"`
 	CreatedBy string         `gorm:"column:created_by" json:"created_by"`
 	CreatedAt time.Time      `gorm:"column:created_at" json:"created_at"`
 	UpdatedBy string         `gorm:"column:updated_by" json:"updated_by"`
@@ -24,25 +26,55 @@ type Tenants struct {
 }
 
 type DataSource struct {
-	ID           int                    `gorm:"column:id" json:"id"`
-	Serial       string                 `gorm:"column:serial" json:"serial"`
-	Code         string                 `gorm:"column:code" json:"code"`
-	Name         string                 `gorm:"column:name" json:"name"`
-	Description  string                 `gorm:"column:description" json:"description"`
-	Host         string                 `gorm:"column:host" json:"host"`
-	Port         string                 `gorm:"column:port" json:"port"`
-	Username     string                 `gorm:"column:username" json:"username"`
-	Password     string                 `gorm:"column:password" json:"password"`
-	DBName       string                 `gorm:"column:db_name" json:"db_name"`
-	DatabaseName string                 `gorm:"column:database_name" json:"database_name"`
-	Configs      map[string]interface{} `gorm:"column:configs" json:"configs"`
-	TenantSerial string                 `gorm:"column:tenant_serial" json:"tenant_serial"`
-	CreatedBy    string                 `gorm:"column:created_by" json:"created_by"`
-	CreatedAt    time.Time              `gorm:"column:created_at" json:"created_at"`
-	UpdatedBy    string                 `gorm:"column:updated_by" json:"updated_by"`
-	UpdatedAt    time.Time              `gorm:"column:updated_at" json:"updated_at"`
-	DeletedBy    sql.NullString         `gorm:"column:deleted_by" json:"deleted_by"`
-	DeletedAt    gorm.DeletedAt         `gorm:"column:deleted_at" json:"deleted_at"`
+	ID           int            `gorm:"column:id" json:"id"`
+	Serial       string         `gorm:"column:serial" json:"serial"`
+	Code         string         `gorm:"column:code" json:"code"`
+	Name         string         `gorm:"column:name" json:"name"`
+	Description  string         `gorm:"column:description" json:"description"`
+	Host         string         `gorm:"column:host" json:"host"`
+	Port         string         `gorm:"column:port" json:"port"`
+	Username     string         `gorm:"column:username" json:"username"`
+	Password     string         `gorm:"column:password" json:"password"`
+	DBName       string         `gorm:"column:db_name" json:"db_name"`
+	DatabaseName string         `gorm:"column:database_name" json:"database_name"`
+	Driver       string         `gorm:"column:driver" json:"driver"`
+	Configs      string         `gorm:"column:configs" json:"configs"`
+	TenantSerial string         `gorm:"column:tenant_serial" json:"tenant_serial"`
+	CreatedBy    string         `gorm:"column:created_by" json:"created_by"`
+	CreatedAt    time.Time      `gorm:"column:created_at" json:"created_at"`
+	UpdatedBy    string         `gorm:"column:updated_by" json:"updated_by"`
+	UpdatedAt    time.Time      `gorm:"column:updated_at" json:"updated_at"`
+	DeletedBy    sql.NullString `gorm:"column:deleted_by" json:"deleted_by"`
+	DeletedAt    gorm.DeletedAt `gorm:"column:deleted_at" json:"deleted_at"`
+}
+
+func (ds *DataSource) ToEntity() entity.DataSource {
+	// convert configs from string to map
+	configs := make(map[string]interface{})
+	if err := json.Unmarshal([]byte(ds.Configs), &configs); err != nil {
+		configs = nil
+	}
+
+	dbName := ds.DBName
+	if dbName == "" {
+		dbName = ds.DatabaseName
+	}
+
+	return entity.DataSource{
+		ID:          ds.ID,
+		Serial:      ds.Serial,
+		Code:        ds.Code,
+		Name:        ds.Name,
+		Description: ds.Description,
+		Host:        ds.Host,
+		Port:        ds.Port,
+		Username:    ds.Username,
+		Password:    ds.Password,
+		DBName:      dbName,
+		Driver:      ds.Driver,
+		Configs:     configs,
+		Tenant:      entity.Tenants{Serial: ds.TenantSerial},
+	}
 }
 
 type Modules struct {
@@ -121,6 +153,9 @@ type ObjectFields struct {
 	TargetObjectFieldSerial string         `gorm:"column:target_object_field_serial" json:"target_object_field_serial"`
 	Relation                string         `gorm:"column:relation" json:"relation"`
 	IsSystem                bool           `gorm:"column:is_system" json:"is_system"`
+	Kind                    string         `gorm:"column:kind" json:"kind"`
+	Expression              sql.NullString `gorm:"column:expression" json:"expression"`
+	Rollup                  sql.NullString `gorm:"column:rollup" json:"rollup"`
 	CreatedBy               string         `gorm:"column:created_by" json:"created_by"`
 	CreatedAt               time.Time      `gorm:"column:created_at" json:"created_at"`
 	UpdatedBy               string         `gorm:"column:updated_by" json:"updated_by"`
@@ -140,6 +175,14 @@ func (of *ObjectFields) ToEntity() entity.ObjectFields {
 		validationRules = nil
 	}
 
+	var rollup *entity.RollupConfig
+	if of.Rollup.Valid {
+		rollup = &entity.RollupConfig{}
+		if err := json.Unmarshal([]byte(of.Rollup.String), rollup); err != nil {
+			rollup = nil
+		}
+	}
+
 	return entity.ObjectFields{
 		ID:                of.ID,
 		Serial:            of.Serial,
@@ -155,6 +198,9 @@ func (of *ObjectFields) ToEntity() entity.ObjectFields {
 		TargetObjectField: map[string]interface{}{"serial": of.TargetObjectFieldSerial},
 		Relation:          of.Relation,
 		IsSystem:          of.IsSystem,
+		Kind:              of.Kind,
+		Expression:        of.Expression.String,
+		Rollup:            rollup,
 	}
 }
 
@@ -216,3 +262,33 @@ func (fki *ForeignKeyInfo) ToEntity() entity.ForeignKeyInfo {
 		ForeignColumn: fki.ForeignColumn,
 	}
 }
+
+type ReverseRelation struct {
+	ChildTable   string `gorm:"column:child_table"`
+	ChildColumn  string `gorm:"column:child_column"`
+	ParentColumn string `gorm:"column:parent_column"`
+}
+
+func (rr *ReverseRelation) ToEntity() entity.ReverseRelation {
+	return entity.ReverseRelation{
+		ObjectCode:      rr.ChildTable,
+		RelationField:   rr.ChildColumn,
+		ReferencedField: rr.ParentColumn,
+	}
+}
+
+// RollupField is a materialized rollup field with the code of its object
+type RollupField struct {
+	ObjectCode string `gorm:"column:object_code" json:"object_code"`
+	FieldCode  string `gorm:"column:field_code" json:"field_code"`
+	Rollup     string `gorm:"column:rollup" json:"rollup"`
+}
+
+func (rf *RollupField) ToEntity() (entity.RollupField, error) {
+	resp := entity.RollupField{
+		ObjectCode: rf.ObjectCode,
+		FieldCode:  rf.FieldCode,
+	}
+
+	return resp, json.Unmarshal([]byte(rf.Rollup), &resp.Rollup)
+}
@@ -0,0 +1,70 @@
+package catalogrepository
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/cerkas/cerkas-backend/core/entity"
+)
+
+// FileField is a file field of an object with the validation rules of the field and of its data type
+type FileField struct {
+	FieldCode               string `gorm:"column:field_code"`
+	ValidationRules         string `gorm:"column:validation_rules"`
+	DataTypeValidationRules string `gorm:"column:data_type_validation_rules"`
+}
+
+func (ff *FileField) ToEntity() entity.FileField {
+	rules := map[string]any{}
+	for _, source := range []string{ff.DataTypeValidationRules, ff.ValidationRules} {
+		sourceRules := map[string]any{}
+		if err := json.Unmarshal([]byte(source), &sourceRules); err != nil {
+			continue
+		}
+
+		for key, value := range sourceRules {
+			rules[key] = value
+		}
+	}
+
+	return entity.FileField{
+		FieldCode:       ff.FieldCode,
+		ValidationRules: rules,
+	}
+}
+
+// GetFileFields lists the fields of an object with the file data type
+func (r *repository) GetFileFields(ctx context.Context, tenantCode, objectCode string) (resp []entity.FileField, err error) {
+	db := r.db
+	if r.cfg.IsDebugMode {
+		db = db.Debug()
+	}
+
+	results := []FileField{}
+	err = db.Raw(`
+	SELECT
+		object_fields.field_code,
+		COALESCE(object_fields.validation_rules::text, '{}') AS validation_rules,
+		COALESCE(data_types.validation_rules::text, '{}') AS data_type_validation_rules
+	FROM
+		object_fields
+		JOIN objects ON objects.serial = object_fields.object_serial
+		JOIN tenants ON tenants.serial = objects.tenant_serial
+		JOIN data_types ON data_types.serial = object_fields.data_type_serial
+	WHERE
+		tenants.code = ?
+		AND objects.code = ?
+		AND data_types.code = ?
+		AND object_fields.deleted_at IS NULL
+	ORDER BY object_fields.field_code
+	`, tenantCode, objectCode, entity.DataTypeFile).Scan(&results).Error
+	if err != nil {
+		return resp, err
+	}
+
+	for _, result := range results {
+		resp = append(resp, result.ToEntity())
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,621 @@
+package catalogrepository
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/cerkas/cerkas-backend/core/entity"
+)
+
+/*
+	formula fields are object fields computed in sql from an expression over the other fields of their object,
+	they are selected, filtered and ordered like physical columns. examples of expressions:
+
+		price * quantity
+		first_name & ' ' & last_name
+		IF(status = 'done', 'closed', 'open')
+		CASE(score >= 80, 'high', score >= 50, 'medium', 'low')
+		DATEDIFF(due_date, created_at)
+		COALESCE(nickname, customer_serial__name)
+
+	a field is a column of the object, a relation path through foreign keys joined by __ or another formula field.
+	operators are + - * / for numbers, & to concat strings, = != <> < <= > >= to compare and AND OR NOT for conditions.
+	functions are IF(condition, then, else), CASE(condition, value, ..., else), COALESCE(value, ...), CONCAT(value, ...)
+	and DATEDIFF(end, start) in days. rollup fields of the object can be used like columns.
+*/
+
+// formulaColumns are the compiled formula and rollup fields of an object, by field code
+type formulaColumns map[string]formulaColumn
+
+// formulaColumn is the sql of a computed field together with the joins its relation paths need
+type formulaColumn struct {
+	kind      string
+	sql       string
+	joins     map[string]string
+	joinOrder []string
+}
+
+// expression returns the sql expression of a field when it is a formula field
+func (f formulaColumns) expression(fieldCode string) (string, bool) {
+	formula, ok := f[fieldCode]
+	return formula.sql, ok
+}
+
+// codes returns the field codes of the formula fields with their expressions
+func (f formulaColumns) codes() map[string]string {
+	codes := map[string]string{}
+	for fieldCode, formula := range f {
+		codes[fieldCode] = formula.sql
+	}
+
+	return codes
+}
+
+// getComputedFields returns the object fields of an object that are computed instead of stored, of the given kinds
+func (r *repository) getComputedFields(tenantCode, objectCode string, kinds ...string) (resp []ObjectFields, err error) {
+	db := r.db.Model(&ObjectFields{})
+
+	if r.cfg.IsDebugMode {
+		db = db.Debug()
+	}
+
+	err = db.Joins("JOIN objects ON objects.serial = object_fields.object_serial").
+		Joins("JOIN tenants ON tenants.serial = objects.tenant_serial").
+		Where("objects.code = ? AND tenants.code = ? AND object_fields.kind IN ?", objectCode, tenantCode, kinds).
+		Find(&resp).Error
+
+	return resp, err
+}
+
+// getFormulaColumns compiles the formula fields of the request object, they may refer to its rollups. A formula that
+// does not compile fails the request, leaving it out would quietly drop the column from every response.
+func (r *repository) getFormulaColumns(ctx context.Context, dialect Dialect, request entity.CatalogQuery, columnCodes map[string]bool, rollups formulaColumns) (resp formulaColumns, err error) {
+	results, err := r.getComputedFields(request.TenantCode, request.ObjectCode, entity.FieldKindFormula)
+	if err != nil {
+		return resp, err
+	}
+
+	expressions := map[string]string{}
+	for _, result := range results {
+		expressions[result.FieldCode] = result.Expression.String
+	}
+
+	resp = formulaColumns{}
+	for _, fieldCode := range sortedFieldCodes(expressions) {
+		compiler := &formulaCompiler{
+			ctx:         ctx,
+			repo:        r,
+			dialect:     dialect,
+			request:     request,
+			columnCodes: columnCodes,
+			rollups:     rollups,
+			expressions: expressions,
+			compiling:   map[string]bool{},
+			joins:       map[string]string{},
+		}
+
+		sql, err := compiler.compileField(fieldCode)
+		if err != nil {
+			return resp, fmt.Errorf("%w: %v.%v: %v", entity.ErrorFormulaInvalid, request.ObjectCode, fieldCode, err)
+		}
+
+		resp[fieldCode] = formulaColumn{kind: entity.FieldKindFormula, sql: sql, joins: compiler.joins, joinOrder: compiler.joinOrder}
+	}
+
+	return resp, nil
+}
+
+// formulaCompiler compiles the expression of a formula field into a sql expression of the dialect
+type formulaCompiler struct {
+	ctx         context.Context
+	repo        *repository
+	dialect     Dialect
+	request     entity.CatalogQuery
+	columnCodes map[string]bool
+	rollups     formulaColumns
+
+	// expressions are the formula fields of the object, they may refer to one another
+	expressions map[string]string
+	compiling   map[string]bool
+
+	joins     map[string]string
+	joinOrder []string
+
+	tokens []formulaToken
+	pos    int
+}
+
+// compileField compiles a formula field, formula fields referring to it are compiled inline
+func (c *formulaCompiler) compileField(fieldCode string) (string, error) {
+	if c.compiling[fieldCode] {
+		return "", fmt.Errorf("formula field %v refers to itself", fieldCode)
+	}
+
+	tokens, err := tokenizeFormula(c.expressions[fieldCode])
+	if err != nil {
+		return "", err
+	}
+
+	c.compiling[fieldCode] = true
+	defer delete(c.compiling, fieldCode)
+
+	outerTokens, outerPos := c.tokens, c.pos
+	defer func() { c.tokens, c.pos = outerTokens, outerPos }()
+
+	c.tokens, c.pos = tokens, 0
+
+	sql, err := c.parseOr()
+	if err != nil {
+		return "", err
+	}
+
+	if token := c.peek(); token.kind != formulaEOF {
+		return "", fmt.Errorf("unexpected %q", token.text)
+	}
+
+	return sql, nil
+}
+
+// field resolves a field reference into a column, a relation path or another formula field
+func (c *formulaCompiler) field(name string) (string, error) {
+	if strings.Contains(name, "__") {
+		path := strings.Split(name, "__")
+		if !c.columnCodes[path[0]] {
+			return "", fmt.Errorf("unknown field %v", path[0])
+		}
+
+		joinQuery, joinQueryMap := c.repo.HandleChainingJoinQuery(c.ctx, "", name, c.request.ObjectCode, c.request, entity.FilterItem{})
+
+		// keep the joins in the order they were built, a join may use the alias of the one before
+		joinKeys := make([]string, 0, len(joinQueryMap))
+		for joinKey := range joinQueryMap {
+			joinKeys = append(joinKeys, joinKey)
+		}
+		sort.Slice(joinKeys, func(i, j int) bool {
+			return strings.Index(joinQuery, joinQueryMap[joinKeys[i]]) < strings.Index(joinQuery, joinQueryMap[joinKeys[j]])
+		})
+
+		for _, joinKey := range joinKeys {
+			if _, ok := c.joins[joinKey]; !ok {
+				c.joins[joinKey] = joinQueryMap[joinKey]
+				c.joinOrder = append(c.joinOrder, joinKey)
+			}
+		}
+
+		return qualify(c.dialect, name, path[len(path)-1]), nil
+	}
+
+	if c.columnCodes[name] {
+		return c.dialect.ColumnName(c.request.TenantCode, c.request.ObjectCode, name), nil
+	}
+
+	if rollup, ok := c.rollups.expression(name); ok {
+		return "(" + rollup + ")", nil
+	}
+
+	if _, ok := c.expressions[name]; ok {
+		sql, err := c.compileField(name)
+		if err != nil {
+			return "", err
+		}
+
+		return "(" + sql + ")", nil
+	}
+
+	return "", fmt.Errorf("unknown field %v", name)
+}
+
+func (c *formulaCompiler) parseOr() (string, error) {
+	left, err := c.parseAnd()
+	if err != nil {
+		return "", err
+	}
+
+	for c.acceptKeyword("OR") {
+		right, err := c.parseAnd()
+		if err != nil {
+			return "", err
+		}
+
+		left = fmt.Sprintf("(%v OR %v)", left, right)
+	}
+
+	return left, nil
+}
+
+func (c *formulaCompiler) parseAnd() (string, error) {
+	left, err := c.parseNot()
+	if err != nil {
+		return "", err
+	}
+
+	for c.acceptKeyword("AND") {
+		right, err := c.parseNot()
+		if err != nil {
+			return "", err
+		}
+
+		left = fmt.Sprintf("(%v AND %v)", left, right)
+	}
+
+	return left, nil
+}
+
+func (c *formulaCompiler) parseNot() (string, error) {
+	if c.acceptKeyword("NOT") {
+		operand, err := c.parseNot()
+		if err != nil {
+			return "", err
+		}
+
+		return fmt.Sprintf("(NOT %v)", operand), nil
+	}
+
+	return c.parseComparison()
+}
+
+func (c *formulaCompiler) parseComparison() (string, error) {
+	left, err := c.parseConcat()
+	if err != nil {
+		return "", err
+	}
+
+	token := c.peek()
+	if token.kind != formulaOperator {
+		return left, nil
+	}
+
+	operator := token.text
+	switch operator {
+	case "!=":
+		operator = "<>"
+	case "=", "<>", "<", "<=", ">", ">=":
+	default:
+		return left, nil
+	}
+	c.pos++
+
+	right, err := c.parseConcat()
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("(%v %v %v)", left, operator, right), nil
+}
+
+func (c *formulaCompiler) parseConcat() (string, error) {
+	part, err := c.parseAdditive()
+	if err != nil {
+		return "", err
+	}
+
+	parts := []string{part}
+	for c.acceptOperator("&") {
+		part, err := c.parseAdditive()
+		if err != nil {
+			return "", err
+		}
+
+		parts = append(parts, part)
+	}
+
+	if len(parts) == 1 {
+		return parts[0], nil
+	}
+
+	return c.dialect.Concat(parts), nil
+}
+
+func (c *formulaCompiler) parseAdditive() (string, error) {
+	left, err := c.parseTerm()
+	if err != nil {
+		return "", err
+	}
+
+	for {
+		operator := c.peek().text
+		if c.peek().kind != formulaOperator || (operator != "+" && operator != "-") {
+			return left, nil
+		}
+		c.pos++
+
+		right, err := c.parseTerm()
+		if err != nil {
+			return "", err
+		}
+
+		left = fmt.Sprintf("(%v %v %v)", left, operator, right)
+	}
+}
+
+func (c *formulaCompiler) parseTerm() (string, error) {
+	left, err := c.parseUnary()
+	if err != nil {
+		return "", err
+	}
+
+	for {
+		operator := c.peek().text
+		if c.peek().kind != formulaOperator || (operator != "*" && operator != "/") {
+			return left, nil
+		}
+		c.pos++
+
+		right, err := c.parseUnary()
+		if err != nil {
+			return "", err
+		}
+
+		// divide as decimals and give null instead of failing the query on a zero divisor
+		if operator == "/" {
+			left = fmt.Sprintf("(%v * 1.0 / NULLIF(%v, 0))", left, right)
+		} else {
+			left = fmt.Sprintf("(%v * %v)", left, right)
+		}
+	}
+}
+
+func (c *formulaCompiler) parseUnary() (string, error) {
+	if c.acceptOperator("-") {
+		operand, err := c.parseUnary()
+		if err != nil {
+			return "", err
+		}
+
+		return fmt.Sprintf("(-%v)", operand), nil
+	}
+
+	return c.parsePrimary()
+}
+
+func (c *formulaCompiler) parsePrimary() (string, error) {
+	token := c.next()
+
+	switch token.kind {
+	case formulaNumber:
+		return token.text, nil
+	case formulaString:
+		return c.dialect.QuoteLiteral(token.text), nil
+	case formulaOperator:
+		if token.text != "(" {
+			break
+		}
+
+		inner, err := c.parseOr()
+		if err != nil {
+			return "", err
+		}
+
+		if !c.acceptOperator(")") {
+			return "", fmt.Errorf("missing )")
+		}
+
+		return "(" + inner + ")", nil
+	case formulaIdentifier:
+		switch strings.ToUpper(token.text) {
+		case "TRUE":
+			return c.dialect.BooleanLiteral(true), nil
+		case "FALSE":
+			return c.dialect.BooleanLiteral(false), nil
+		case "NULL":
+			return "NULL", nil
+		}
+
+		if c.acceptOperator("(") {
+			return c.parseFunction(token.text)
+		}
+
+		return c.field(token.text)
+	case formulaEOF:
+		return "", fmt.Errorf("unexpected end of expression")
+	}
+
+	return "", fmt.Errorf("unexpected %q", token.text)
+}
+
+// parseFunction compiles a function call, the opening parenthesis is already read
+func (c *formulaCompiler) parseFunction(name string) (string, error) {
+	args := []string{}
+	if !c.acceptOperator(")") {
+		for {
+			arg, err := c.parseOr()
+			if err != nil {
+				return "", err
+			}
+
+			args = append(args, arg)
+
+			if c.acceptOperator(")") {
+				break
+			}
+
+			if !c.acceptOperator(",") {
+				return "", fmt.Errorf("missing , or ) in %v", name)
+			}
+		}
+	}
+
+	switch strings.ToUpper(name) {
+	case "IF":
+		if len(args) != 3 {
+			return "", fmt.Errorf("IF takes a condition, a then and an else value")
+		}
+
+		return fmt.Sprintf("(CASE WHEN %v THEN %v ELSE %v END)", args[0], args[1], args[2]), nil
+	case "CASE":
+		if len(args) < 2 {
+			return "", fmt.Errorf("CASE takes pairs of condition and value, and an optional else value")
+		}
+
+		sql := "(CASE"
+		for i := 0; i+1 < len(args); i += 2 {
+			sql += fmt.Sprintf(" WHEN %v THEN %v", args[i], args[i+1])
+		}
+
+		if len(args)%2 == 1 {
+			sql += fmt.Sprintf(" ELSE %v", args[len(args)-1])
+		}
+
+		return sql + " END)", nil
+	case "COALESCE":
+		if len(args) == 0 {
+			return "", fmt.Errorf("COALESCE takes at least one value")
+		}
+
+		return fmt.Sprintf("COALESCE(%v)", strings.Join(args, ", ")), nil
+	case "CONCAT":
+		if len(args) == 0 {
+			return "", fmt.Errorf("CONCAT takes at least one value")
+		}
+
+		return c.dialect.Concat(args), nil
+	case "DATEDIFF":
+		if len(args) != 2 {
+			return "", fmt.Errorf("DATEDIFF takes an end and a start date")
+		}
+
+		return c.dialect.DateDiff(args[0], args[1]), nil
+	}
+
+	return "", fmt.Errorf("unknown function %v", name)
+}
+
+func (c *formulaCompiler) peek() formulaToken {
+	if c.pos >= len(c.tokens) {
+		return formulaToken{kind: formulaEOF}
+	}
+
+	return c.tokens[c.pos]
+}
+
+func (c *formulaCompiler) next() formulaToken {
+	token := c.peek()
+	if token.kind != formulaEOF {
+		c.pos++
+	}
+
+	return token
+}
+
+func (c *formulaCompiler) acceptOperator(operator string) bool {
+	if token := c.peek(); token.kind == formulaOperator && token.text == operator {
+		c.pos++
+		return true
+	}
+
+	return false
+}
+
+func (c *formulaCompiler) acceptKeyword(keyword string) bool {
+	if token := c.peek(); token.kind == formulaIdentifier && strings.EqualFold(token.text, keyword) {
+		c.pos++
+		return true
+	}
+
+	return false
+}
+
+type formulaTokenKind int
+
+const (
+	formulaEOF formulaTokenKind = iota
+	formulaNumber
+	formulaString
+	formulaIdentifier
+	formulaOperator
+)
+
+type formulaToken struct {
+	kind formulaTokenKind
+	text string
+}
+
+// tokenizeFormula splits an expression into numbers, 'strings', identifiers and operators
+func tokenizeFormula(expression string) (tokens []formulaToken, err error) {
+	runes := []rune(expression)
+
+	for i := 0; i < len(runes); {
+		char := runes[i]
+
+		switch {
+		case unicode.IsSpace(char):
+			i++
+		case unicode.IsDigit(char) || (char == '.' && i+1 < len(runes) && unicode.IsDigit(runes[i+1])):
+			start := i
+			hasPoint := false
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				if runes[i] == '.' {
+					if hasPoint {
+						return nil, fmt.Errorf("invalid number %q", string(runes[start:i+1]))
+					}
+					hasPoint = true
+				}
+				i++
+			}
+
+			tokens = append(tokens, formulaToken{kind: formulaNumber, text: string(runes[start:i])})
+		case char == '\'':
+			text := []rune{}
+			closed := false
+			for i++; i < len(runes); i++ {
+				if runes[i] == '\'' {
+					// a doubled quote is a quote inside the string
+					if i+1 < len(runes) && runes[i+1] == '\'' {
+						text = append(text, '\'')
+						i++
+						continue
+					}
+
+					closed = true
+					i++
+					break
+				}
+
+				text = append(text, runes[i])
+			}
+
+			if !closed {
+				return nil, fmt.Errorf("unterminated string")
+			}
+
+			tokens = append(tokens, formulaToken{kind: formulaString, text: string(text)})
+		case unicode.IsLetter(char) || char == '_':
+			start := i
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_') {
+				i++
+			}
+
+			tokens = append(tokens, formulaToken{kind: formulaIdentifier, text: string(runes[start:i])})
+		default:
+			operator := string(char)
+			if i+1 < len(runes) {
+				if pair := string(runes[i : i+2]); pair == "<=" || pair == ">=" || pair == "<>" || pair == "!=" {
+					operator = pair
+				}
+			}
+
+			if !strings.Contains("+-*/&=<>(),", operator) && len(operator) == 1 {
+				return nil, fmt.Errorf("unexpected %q", operator)
+			}
+
+			tokens = append(tokens, formulaToken{kind: formulaOperator, text: operator})
+			i += len(operator)
+		}
+	}
+
+	return tokens, nil
+}
+
+// sortedFieldCodes returns the field codes of a map in a stable order
+func sortedFieldCodes(values map[string]string) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,36 @@
+package catalogrepository
+
+import "testing"
+
+func TestTokenizeFormulaNumbers(t *testing.T) {
+	tests := []struct {
+		expression string
+		want       string
+		wantErr    bool
+	}{
+		{"12", "12", false},
+		{"1.25", "1.25", false},
+		{".5", ".5", false},
+		{"1.2.3", "", true},
+		{"1..2", "", true},
+	}
+
+	for _, test := range tests {
+		tokens, err := tokenizeFormula(test.expression)
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("tokenizeFormula(%q) = %v, want an error", test.expression, tokens)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("tokenizeFormula(%q) returned %v", test.expression, err)
+			continue
+		}
+
+		if len(tokens) != 1 || tokens[0].kind != formulaNumber || tokens[0].text != test.want {
+			t.Errorf("tokenizeFormula(%q) = %v, want the number %v", test.expression, tokens, test.want)
+		}
+	}
+}
@@ -2,10 +2,16 @@ package catalogrepository
 
 import (
 	"context"
+	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"log"
+	"math"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/cerkas/cerkas-backend/config"
 	"github.com/cerkas/cerkas-backend/core/entity"
@@ -18,6 +24,9 @@ import (
 type repository struct {
 	cfg config.Config
 	db  *gorm.DB
+
+	// dataSources caches the opened data source connections by data source serial
+	dataSources sync.Map
 }
 
 func New(cfg config.Config, db *gorm.DB) repository_intf.CatalogRepository {
@@ -27,30 +36,34 @@ func New(cfg config.Config, db *gorm.DB) repository_intf.CatalogRepository {
 	}
 }
 
+// logQuery prints a query built by hand in debug mode, the queries built by gorm are printed by db.Debug()
+func (r *repository) logQuery(name, query string) {
+	if r.cfg.IsDebugMode {
+		log.Printf("%v: %v", name, query)
+	}
+}
+
 func (r *repository) GetColumnList(ctx context.Context, request entity.CatalogQuery) (columns []map[string]interface{}, columnStrings string, joinQueryMap map[string]string, joinQueryOrder []string, err error) {
+	columns, columnStrings, joinQueryMap, joinQueryOrder, _, err = r.getColumnList(ctx, request)
+	return columns, columnStrings, joinQueryMap, joinQueryOrder, err
+}
+
+// getColumnList lists the columns of the request object like GetColumnList, together with its compiled formula fields
+func (r *repository) getColumnList(ctx context.Context, request entity.CatalogQuery) (columns []map[string]interface{}, columnStrings string, joinQueryMap map[string]string, joinQueryOrder []string, formulas formulaColumns, err error) {
 	joinQueryMapAll := make(map[string]string)
 	joinQueryOrderAll := make([]string, 0)
 
+	source, err := r.resolveDataSource(ctx, request.TenantCode, request.ObjectCode)
+	if err != nil {
+		return columns, columnStrings, joinQueryMap, joinQueryOrder, formulas, err
+	}
+
 	// get list of column from request.ObjectCode
-	listColumnQuery := fmt.Sprintf(`
-	SELECT
-    col.column_name as field_code, 
-		col.udt_name as data_type,
-    ccu.table_name AS foreign_table_name,
-    ccu.column_name AS foreign_field_name
-	FROM
-			information_schema.columns AS col
-	LEFT JOIN information_schema.key_column_usage AS kcu ON col.table_name = kcu.table_name
-	AND col.column_name = kcu.column_name
-	LEFT JOIN information_schema.constraint_column_usage AS ccu ON kcu.constraint_name = ccu.constraint_name
-	WHERE
-		col.table_schema = '%v' 
-	AND col.table_name = '%v'
-	`, request.TenantCode, request.ObjectCode)
+	listColumnQuery, listColumnArgs := source.dialect.ColumnListQuery(request.TenantCode, request.ObjectCode)
 
-	rows, err := r.db.Raw(listColumnQuery).Rows()
+	rows, err := source.db.Raw(listColumnQuery, listColumnArgs...).Rows()
 	if err != nil {
-		return columns, columnStrings, joinQueryMap, joinQueryOrder, err
+		return columns, columnStrings, joinQueryMap, joinQueryOrder, formulas, err
 	}
 	defer rows.Close()
 
@@ -58,24 +71,53 @@ func (r *repository) GetColumnList(ctx context.Context, request entity.CatalogQu
 	for rows.Next() {
 		column := make(map[string]any)
 
-		var columnCode, dataType, foreignTableName, foreignColumnName interface{}
+		var columnCode, dataType, foreignTableName, foreignColumnName sql.NullString
 		if err := rows.Scan(&columnCode, &dataType, &foreignTableName, &foreignColumnName); err != nil {
-			return columns, columnStrings, joinQueryMap, joinQueryOrder, err
+			return columns, columnStrings, joinQueryMap, joinQueryOrder, formulas, err
 		}
 
-		column[entity.FieldDataType] = dataType.(string)
-		column[entity.FieldColumnCode] = columnCode.(string)
-		column[entity.FieldColumnName] = columnCode.(string)
-		column[entity.FieldCompleteColumnCode] = fmt.Sprintf("%v.%v.%v", request.TenantCode, request.ObjectCode, columnCode.(string))
+		column[entity.FieldDataType] = dataType.String
+		column[entity.FieldColumnCode] = columnCode.String
+		column[entity.FieldColumnName] = columnCode.String
+		column[entity.FieldCompleteColumnCode] = fmt.Sprintf("%v.%v.%v", request.TenantCode, request.ObjectCode, columnCode.String)
 
-		if foreignTableName != nil && foreignTableName.(string) != request.ObjectCode && foreignColumnName != nil && foreignColumnName.(string) != "id" {
-			column[entity.FieldForeignTableName] = foreignTableName.(string)
-			column[entity.FieldForeignColumnName] = foreignColumnName.(string)
+		if foreignTableName.Valid && foreignTableName.String != request.ObjectCode && foreignColumnName.Valid && foreignColumnName.String != "id" {
+			column[entity.FieldForeignTableName] = foreignTableName.String
+			column[entity.FieldForeignColumnName] = foreignColumnName.String
 		}
 
 		columns = append(columns, column)
 	}
 
+	// rollup and formula fields are listed after the physical columns, a formula may use a rollup
+	columnCodes := map[string]bool{}
+	for _, column := range columns {
+		columnCodes[column[entity.FieldColumnCode].(string)] = true
+	}
+
+	rollups, err := r.getRollupColumns(ctx, source.dialect, request)
+	if err != nil {
+		return columns, columnStrings, joinQueryMap, joinQueryOrder, formulas, err
+	}
+
+	formulas, err = r.getFormulaColumns(ctx, source.dialect, request, columnCodes, rollups)
+	if err != nil {
+		return columns, columnStrings, joinQueryMap, joinQueryOrder, formulas, err
+	}
+
+	for fieldCode, rollup := range rollups {
+		formulas[fieldCode] = rollup
+	}
+
+	for _, fieldCode := range sortedFieldCodes(formulas.codes()) {
+		columns = append(columns, map[string]any{
+			entity.FieldDataType:           formulas[fieldCode].kind,
+			entity.FieldColumnCode:         fieldCode,
+			entity.FieldColumnName:         fieldCode,
+			entity.FieldCompleteColumnCode: fmt.Sprintf("%v.%v.%v", request.TenantCode, request.ObjectCode, fieldCode),
+		})
+	}
+
 	// filter columns if request.Fields is not empty
 	if len(request.Fields) > 0 {
 		var filteredColumns []map[string]any
@@ -137,7 +179,7 @@ func (r *repository) GetColumnList(ctx context.Context, request entity.CatalogQu
 
 			// after finish iterating columns, if field is not found in columns, return error
 			if !isFound {
-				return columns, columnStrings, joinQueryMap, joinQueryOrder, fmt.Errorf("field %v is not found in table %v", fieldNameKey, request.ObjectCode)
+				return columns, columnStrings, joinQueryMap, joinQueryOrder, formulas, fmt.Errorf("field %v is not found in table %v", fieldNameKey, request.ObjectCode)
 			}
 		}
 
@@ -147,40 +189,81 @@ func (r *repository) GetColumnList(ctx context.Context, request entity.CatalogQu
 	// convert columns to string
 	for i, col := range columns {
 		if i == 0 {
-			columnStrings = col[entity.FieldCompleteColumnCode].(string)
+			columnStrings = selectExpression(source.dialect, request, col, formulas)
 		} else {
-			columnStrings = columnStrings + ", " + col[entity.FieldCompleteColumnCode].(string)
+			columnStrings = columnStrings + ", " + selectExpression(source.dialect, request, col, formulas)
+		}
+	}
+
+	// the formula fields selected, filtered or ordered by need the joins of their relation paths
+	usedFields := []string{}
+	for _, col := range columns {
+		usedFields = append(usedFields, col[entity.FieldColumnCode].(string))
+	}
+	for _, filterGroup := range request.Filters {
+		for fieldName := range filterGroup.Filters {
+			usedFields = append(usedFields, fieldName)
+		}
+	}
+	for _, order := range request.Orders {
+		usedFields = append(usedFields, order.FieldName)
+	}
+
+	for _, fieldCode := range usedFields {
+		formula, ok := formulas[fieldCode]
+		if !ok {
+			continue
+		}
+
+		for _, joinKey := range formula.joinOrder {
+			if _, ok := joinQueryMapAll[joinKey]; !ok {
+				joinQueryMapAll[joinKey] = formula.joins[joinKey]
+				joinQueryOrderAll = append(joinQueryOrderAll, joinKey)
+			}
 		}
 	}
 
-	return columns, columnStrings, joinQueryMapAll, joinQueryOrderAll, err
+	return columns, columnStrings, joinQueryMapAll, joinQueryOrderAll, formulas, err
 }
 
 func (r *repository) GetObjectData(ctx context.Context, request entity.CatalogQuery) (resp entity.CatalogResponse, err error) {
+	source, err := r.resolveDataSource(ctx, request.TenantCode, request.ObjectCode)
+	if err != nil {
+		return resp, err
+	}
+
 	// get list of column from request.ObjectCode
-	completeTableName := request.TenantCode + "." + request.ObjectCode
+	completeTableName := source.dialect.TableName(request.TenantCode, request.ObjectCode)
 
 	// Get list of columns
-	columnsList, columnsString, joinQueryMap, joinQueryOrder, err := r.GetColumnList(ctx, request)
-	fmt.Print("joinQueryMap: ", joinQueryMap)
+	columnsList, columnsString, joinQueryMap, joinQueryOrder, formulas, err := r.getColumnList(ctx, request)
 	if err != nil {
 		return resp, err
 	}
 
 	// Get total data count
-	countQuery := r.getTotalCountQuery(ctx, completeTableName, request, joinQueryMap, joinQueryOrder)
-	resultCount, err := r.db.Raw(countQuery).Rows()
+	countQuery, err := r.getTotalCountQuery(ctx, source.dialect, completeTableName, request, joinQueryMap, joinQueryOrder, formulas)
+	if err != nil {
+		return resp, err
+	}
+
+	resultCount, err := source.db.Raw(countQuery).Rows()
 	if err != nil {
 		return resp, err
 	}
+	defer resultCount.Close()
 
 	for resultCount.Next() {
 		resultCount.Scan(&resp.TotalData)
 	}
 
 	// Get data with pagination
-	dataQuery := r.getDataWithPagination(ctx, columnsString, completeTableName, request, joinQueryMap, joinQueryOrder)
-	rows, err := r.db.Raw(dataQuery).Rows()
+	dataQuery, err := r.getDataWithPagination(ctx, source.dialect, columnsString, completeTableName, request, joinQueryMap, joinQueryOrder, formulas)
+	if err != nil {
+		return resp, err
+	}
+
+	rows, err := source.db.Raw(dataQuery).Rows()
 	if err != nil {
 		return resp, err
 	}
@@ -203,22 +286,76 @@ func (r *repository) GetObjectData(ctx context.Context, request entity.CatalogQu
 	return resp, nil
 }
 
+func (r *repository) GetGroupCounts(ctx context.Context, request entity.CatalogQuery, fieldName string) (resp []entity.GroupCount, err error) {
+	source, err := r.resolveDataSource(ctx, request.TenantCode, request.ObjectCode)
+	if err != nil {
+		return resp, err
+	}
+
+	completeTableName := source.dialect.TableName(request.TenantCode, request.ObjectCode)
+	columnName := source.dialect.ColumnName(request.TenantCode, request.ObjectCode, fieldName)
+
+	// only the joins of the filters and the formula fields are needed, the selected fields do not matter
+	request.Fields = nil
+
+	_, _, joinQueryMap, joinQueryOrder, formulas, err := r.getColumnList(ctx, request)
+	if err != nil {
+		return resp, err
+	}
+
+	if formula, ok := formulas.expression(fieldName); ok {
+		columnName = "(" + formula + ")"
+	}
+
+	groupQuery, err := r.getGroupCountQuery(ctx, source.dialect, completeTableName, columnName, request, joinQueryMap, joinQueryOrder, formulas)
+	if err != nil {
+		return resp, err
+	}
+
+	rows, err := source.db.Raw(groupQuery).Rows()
+	if err != nil {
+		return resp, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		groupCount := entity.GroupCount{}
+		if err := rows.Scan(&groupCount.Value, &groupCount.Count); err != nil {
+			return resp, err
+		}
+
+		if value, ok := groupCount.Value.([]byte); ok {
+			groupCount.Value = string(value)
+		}
+
+		resp = append(resp, groupCount)
+	}
+
+	return resp, nil
+}
+
 func (r *repository) GetObjectDetail(ctx context.Context, request entity.CatalogQuery) (resp map[string]entity.DataItem, err error) {
+	source, err := r.resolveDataSource(ctx, request.TenantCode, request.ObjectCode)
+	if err != nil {
+		return resp, err
+	}
+
 	// get list of column from request.ObjectCode
-	completeTableName := request.TenantCode + "." + request.ObjectCode
+	completeTableName := source.dialect.TableName(request.TenantCode, request.ObjectCode)
 
 	// Get list of columns
-	columnsList, columnsString, _, _, err := r.GetColumnList(ctx, request)
+	columnsList, columnsString, joinQueryMap, joinQueryOrder, err := r.GetColumnList(ctx, request)
 	if err != nil {
 		return resp, err
 	}
 
 	// get single data using serial in request
-	dataQuery := getSingleData(columnsList, columnsString, completeTableName, request)
-	rows, err := r.db.Raw(dataQuery).Rows()
+	dataQuery := getSingleData(source.dialect, columnsString, completeTableName, request, joinQueryMap, joinQueryOrder)
+	rows, err := source.db.Raw(dataQuery).Rows()
 	if err != nil {
 		return resp, err
 	}
+	defer rows.Close()
 
 	for rows.Next() {
 		item, err := util.HandleSingleRow(columnsList, rows, request)
@@ -233,13 +370,18 @@ func (r *repository) GetObjectDetail(ctx context.Context, request entity.Catalog
 }
 
 func (r *repository) GetDataByRawQuery(ctx context.Context, request entity.CatalogQuery) (resp entity.CatalogResponse, err error) {
+	source, err := r.resolveDataSource(ctx, request.TenantCode, request.ObjectCode)
+	if err != nil {
+		return resp, err
+	}
+
 	// run raw query from request.RawQuery
 	rawQuery := request.RawQuery
 
 	// get total data based on rawQuery
 	rawCountQuery := fmt.Sprintf("SELECT SUM(1) as total from (%s) as subquery", rawQuery)
 
-	countRows, err := r.db.Raw(rawCountQuery).Rows()
+	countRows, err := source.db.Raw(rawCountQuery).Rows()
 	if err != nil {
 		return resp, err
 	}
@@ -256,9 +398,9 @@ func (r *repository) GetDataByRawQuery(ctx context.Context, request entity.Catal
 	}
 
 	// add page and page size based on request.Page and request.PageSize
-	rawQuery = fmt.Sprintf("%s LIMIT %d OFFSET %d", rawQuery, request.PageSize, (request.Page-1)*request.PageSize)
+	rawQuery = source.dialect.Paginate(rawQuery, hasTopLevelOrderBy(rawQuery), request.PageSize, (request.Page-1)*request.PageSize)
 
-	rows, err := r.db.Raw(rawQuery).Rows()
+	rows, err := source.db.Raw(rawQuery).Rows()
 	if err != nil {
 		return resp, err
 	}
@@ -311,29 +453,40 @@ func (r *repository) CreateObjectData(ctx context.Context, request entity.DataMu
 	// INSERT INTO table_name (column1, column2, column3, ...)
 	// VALUES (value1, value2, value3, ...);
 
+	source, err := r.resolveDataSource(ctx, request.TenantCode, request.ObjectCode)
+	if err != nil {
+		return resp, err
+	}
+
 	// get list of column from request.ObjectCode
-	completeTableName := request.TenantCode + "." + request.ObjectCode
+	completeTableName := source.dialect.TableName(request.TenantCode, request.ObjectCode)
 
-	// loop through data items and get the values
+	// make sure the serial of the new record is known, so it can be referenced after insert
+	items := request.Items
+	if request.Serial != "" && !hasDataItem(items, "serial") {
+		items = append(items, entity.DataItem{FieldCode: "serial", DataType: "text", Value: request.Serial})
+	}
+
+	computedFields, err := r.computedFieldCodes(request.TenantCode, request.ObjectCode)
+	if err != nil {
+		return resp, err
+	}
+
+	// loop through data items and get the values, computed fields are not stored
 	var columnCodeString string
 	var valueString string
-	for _, item := range request.Items {
-		columnCodeString = columnCodeString + ", " + item.FieldCode
+	for _, item := range items {
+		if computedFields[item.FieldCode] {
+			continue
+		}
 
-		if item.Value == nil {
-			valueString = valueString + ", NULL"
-		} else {
-			switch item.DataType {
-			case "text":
-				valueString = valueString + fmt.Sprintf(", '%v'", item.Value)
-			case "integer":
-				valueString = valueString + fmt.Sprintf(", %v", item.Value)
-			case "boolean":
-				valueString = valueString + fmt.Sprintf(", %v", item.Value)
-			default:
-				valueString = valueString + fmt.Sprintf(", '%v'", item.Value)
-			}
+		value, err := formatMutationValue(source.dialect, item)
+		if err != nil {
+			return resp, err
 		}
+
+		columnCodeString = columnCodeString + ", " + source.dialect.QuoteIdentifier(item.FieldCode)
+		valueString = valueString + ", " + value
 	}
 
 	if len(valueString) == 0 {
@@ -345,10 +498,10 @@ func (r *repository) CreateObjectData(ctx context.Context, request entity.DataMu
 
 	// insert into query string
 	insertQuery := fmt.Sprintf("INSERT INTO %v (%v) VALUES (%v)", completeTableName, columnCodeString, valueString)
-	log.Printf("insertQuery: %v", insertQuery)
+	r.logQuery("insertQuery", insertQuery)
 
 	// execute insert query
-	if err := r.db.Exec(insertQuery).Error; err != nil {
+	if err := source.db.Exec(insertQuery).Error; err != nil {
 		return resp, err
 	}
 
@@ -360,10 +513,122 @@ func (r *repository) UpdateObjectData(ctx context.Context, request entity.DataMu
 	// SET column1 = value1, column2 = value2, ...
 	// WHERE condition;
 
+	if request.Serial == "" {
+		return resp, entity.ErrorSerialEmpty
+	}
+
+	source, err := r.resolveDataSource(ctx, request.TenantCode, request.ObjectCode)
+	if err != nil {
+		return resp, err
+	}
+
+	completeTableName := source.dialect.TableName(request.TenantCode, request.ObjectCode)
+
+	computedFields, err := r.computedFieldCodes(request.TenantCode, request.ObjectCode)
+	if err != nil {
+		return resp, err
+	}
+
+	// loop through data items and build the set clauses, the identifier columns and computed fields are never updated
+	var setClauses []string
+	for _, item := range request.Items {
+		if item.FieldCode == "serial" || item.FieldCode == "id" || computedFields[item.FieldCode] {
+			continue
+		}
+
+		value, err := formatMutationValue(source.dialect, item)
+		if err != nil {
+			return resp, err
+		}
+
+		setClauses = append(setClauses, fmt.Sprintf("%v = %v", source.dialect.QuoteIdentifier(item.FieldCode), value))
+	}
+
+	if len(setClauses) == 0 {
+		return resp, errors.New("no data item found")
+	}
+
+	if !hasDataItem(request.Items, "updated_by") {
+		setClauses = append(setClauses, fmt.Sprintf("updated_by = %v", source.dialect.QuoteLiteral(request.UserSerial)))
+	}
+
+	if !hasDataItem(request.Items, "updated_at") {
+		setClauses = append(setClauses, "updated_at = CURRENT_TIMESTAMP")
+	}
+
+	updateQuery := fmt.Sprintf("UPDATE %v SET %v WHERE %v", completeTableName, strings.Join(setClauses, ", "), recordCondition(source.dialect, completeTableName, request.Serial))
+	r.logQuery("updateQuery", updateQuery)
+
+	result := source.db.Exec(updateQuery)
+	if result.Error != nil {
+		return resp, result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return resp, entity.ErrorNotFound
+	}
+
 	return resp, nil
 }
 
 func (r *repository) DeleteObjectData(ctx context.Context, request entity.DataMutationRequest) (err error) {
+	if request.Serial == "" {
+		return entity.ErrorSerialEmpty
+	}
+
+	source, err := r.resolveDataSource(ctx, request.TenantCode, request.ObjectCode)
+	if err != nil {
+		return err
+	}
+
+	completeTableName := source.dialect.TableName(request.TenantCode, request.ObjectCode)
+
+	// records are soft deleted, so they can still be audited and restored
+	deleteQuery := fmt.Sprintf("UPDATE %v SET deleted_at = CURRENT_TIMESTAMP, deleted_by = %v WHERE %v", completeTableName, source.dialect.QuoteLiteral(request.UserSerial), recordCondition(source.dialect, completeTableName, request.Serial))
+	r.logQuery("deleteQuery", deleteQuery)
+
+	result := source.db.Exec(deleteQuery)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return entity.ErrorNotFound
+	}
+
+	return nil
+}
+
+// RestoreObjectData brings back a soft deleted record
+func (r *repository) RestoreObjectData(ctx context.Context, request entity.DataMutationRequest) (err error) {
+	if request.Serial == "" {
+		return entity.ErrorSerialEmpty
+	}
+
+	source, err := r.resolveDataSource(ctx, request.TenantCode, request.ObjectCode)
+	if err != nil {
+		return err
+	}
+
+	completeTableName := source.dialect.TableName(request.TenantCode, request.ObjectCode)
+
+	identifierColumn := "serial"
+	if !helper.IsUUID(request.Serial) {
+		identifierColumn = "code"
+	}
+
+	restoreQuery := fmt.Sprintf("UPDATE %v SET deleted_at = NULL, deleted_by = NULL WHERE %v.%v = %v AND %v.deleted_at IS NOT NULL", completeTableName, completeTableName, identifierColumn, source.dialect.QuoteLiteral(request.Serial), completeTableName)
+	r.logQuery("restoreQuery", restoreQuery)
+
+	result := source.db.Exec(restoreQuery)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return entity.ErrorNotFound
+	}
+
 	return nil
 }
 
@@ -449,120 +714,182 @@ func (r *repository) GetDataTypeBySerials(ctx context.Context, serials []string)
 }
 
 func (r *repository) GetForeignKeyInfo(ctx context.Context, tableName, columnName, schemaName string) (resp entity.ForeignKeyInfo, err error) {
-	query := `
+	source, err := r.resolveDataSource(ctx, schemaName, tableName)
+	if err != nil {
+		return resp, err
+	}
+
+	query, args := source.dialect.ForeignKeyQuery(schemaName, tableName, columnName)
+
+	result := ForeignKeyInfo{}
+	if err = source.db.Raw(query, args...).Scan(&result).Error; err != nil {
+		return resp, err
+	}
+
+	return result.ToEntity(), nil
+}
+
+// GetReferenceTarget returns the object and field an object field refers to through object_fields.target_object_serial,
+// it is empty when the field refers to nothing
+func (r *repository) GetReferenceTarget(ctx context.Context, tenantCode, objectCode, fieldCode string) (resp entity.ForeignKeyInfo, err error) {
+	db := r.db
+	if r.cfg.IsDebugMode {
+		db = db.Debug()
+	}
+
+	result := ForeignKeyInfo{}
+	err = db.Raw(`
 	SELECT
-		ccu.table_schema AS foreign_schema,
-		ccu.table_name   AS foreign_table,
-		ccu.column_name  AS foreign_column
+		tenants.code AS foreign_schema,
+		target.code AS foreign_table,
+		COALESCE(target_field.field_code, 'serial') AS foreign_column
 	FROM
-		information_schema.table_constraints AS tc
-		JOIN information_schema.key_column_usage AS kcu
-		  ON tc.constraint_name = kcu.constraint_name
-		 AND tc.constraint_schema = kcu.constraint_schema
-		JOIN information_schema.constraint_column_usage AS ccu
-		  ON ccu.constraint_name = tc.constraint_name
-		 AND ccu.constraint_schema = tc.constraint_schema
+		object_fields
+		JOIN objects ON objects.serial = object_fields.object_serial
+		JOIN tenants ON tenants.serial = objects.tenant_serial
+		JOIN objects AS target ON target.serial = object_fields.target_object_serial
+		LEFT JOIN object_fields AS target_field ON target_field.serial = object_fields.target_object_field_serial
 	WHERE
-		tc.constraint_type = 'FOREIGN KEY'
-		AND kcu.column_name = ?
-		AND tc.table_name = ?
-		AND tc.table_schema = ?
-	LIMIT 1;
-	`
-
-	result := ForeignKeyInfo{}
-	if err = r.db.Raw(query, columnName, tableName, schemaName).Scan(&result).Error; err != nil {
+		objects.code = ?
+		AND tenants.code = ?
+		AND object_fields.field_code = ?
+		AND object_fields.deleted_at IS NULL
+	LIMIT 1
+	`, objectCode, tenantCode, fieldCode).Scan(&result).Error
+	if err != nil {
 		return resp, err
 	}
 
 	return result.ToEntity(), nil
 }
 
+// GetReverseRelations lists the fields of other objects referring to an object, from the foreign keys of its
+// data source and from the relations of the object fields
+func (r *repository) GetReverseRelations(ctx context.Context, tenantCode, objectCode string) (resp []entity.ReverseRelation, err error) {
+	source, err := r.resolveDataSource(ctx, tenantCode, objectCode)
+	if err != nil {
+		return resp, err
+	}
+
+	query, args := source.dialect.ReferencingKeyQuery(tenantCode, objectCode)
+
+	foreignKeys := []ReverseRelation{}
+	if err = source.db.Raw(query, args...).Scan(&foreignKeys).Error; err != nil {
+		return resp, err
+	}
+
+	db := r.db
+	if r.cfg.IsDebugMode {
+		db = db.Debug()
+	}
+
+	fieldRelations := []ReverseRelation{}
+	err = db.Raw(`
+	SELECT
+		child.code AS child_table,
+		object_fields.field_code AS child_column,
+		COALESCE(target_field.field_code, 'serial') AS parent_column
+	FROM
+		object_fields
+		JOIN objects AS child ON child.serial = object_fields.object_serial
+		JOIN objects AS parent ON parent.serial = object_fields.target_object_serial
+		JOIN tenants ON tenants.serial = parent.tenant_serial
+		LEFT JOIN object_fields AS target_field ON target_field.serial = object_fields.target_object_field_serial
+	WHERE
+		parent.code = ?
+		AND tenants.code = ?
+		AND COALESCE(object_fields.relation, '') <> ''
+		AND object_fields.deleted_at IS NULL
+	ORDER BY child.code, object_fields.field_code
+	`, objectCode, tenantCode).Scan(&fieldRelations).Error
+	if err != nil {
+		return resp, err
+	}
+
+	for _, relation := range append(foreignKeys, fieldRelations...) {
+		reverseRelation := relation.ToEntity()
+		if !slices.Contains(resp, reverseRelation) {
+			resp = append(resp, reverseRelation)
+		}
+	}
+
+	return resp, nil
+}
+
 // local function
 
 // Helper function to build dynamic filters based on CatalogQuery
-func (r *repository) buildFilters(_ context.Context, request entity.CatalogQuery, tableName string) string {
+func (r *repository) buildFilters(_ context.Context, dialect Dialect, request entity.CatalogQuery, tableName string, formulas formulaColumns) (string, error) {
 	var filterClauses []string
 
 	for _, filterGroup := range request.Filters {
 		var groupClauses []string
 
-		for fieldName, filter := range filterGroup.Filters {
-			operator := entity.OperatorQueryMap[filter.Operator]
-			value := filter.Value
-
-			// handler value of operator is part of entity.OperatorLIKEList, then we should add %
-			if isOperatorInLIKEList(filter.Operator) {
-				value = fmt.Sprintf("%%%v%%", value)
-			}
+		groupOperator, err := filterGroupOperator(filterGroup.Operator)
+		if err != nil {
+			return "", err
+		}
 
+		for fieldName, filter := range filterGroup.Filters {
 			// Create filter conditions based on the field, operator, and value
-			var formattedValue string
-
-			switch v := value.(type) {
-			case string:
-				// Wrap strings in single quotes
-				formattedValue = fmt.Sprintf("'%s'", v)
-			case bool:
-				// Booleans: PostgreSQL uses true/false literals
-				formattedValue = fmt.Sprintf("%t", v)
-			case int, int8, int16, int32, int64:
-				formattedValue = fmt.Sprintf("%d", v)
-			case float32, float64:
-				formattedValue = fmt.Sprintf("%f", v)
-			default:
-				// Fallback to string with single quotes
-				formattedValue = fmt.Sprintf("'%v'", v)
+			operator, formattedValue, err := filterCondition(dialect, filter)
+			if err != nil {
+				return "", err
 			}
 
 			if strings.Contains(fieldName, "__") {
 				foreignFieldSet := strings.Split(fieldName, "__")
 				lastFieldName := foreignFieldSet[1]
 
-				fieldName = fmt.Sprintf("%v.%v", fieldName, lastFieldName)
+				fieldName = qualify(dialect, fieldName, lastFieldName)
+			} else if formula, ok := formulas.expression(fieldName); ok {
+				fieldName = "(" + formula + ")"
 			} else {
 				// Prefix the field name with the table name
-				fieldName = fmt.Sprintf("%v.%v", tableName, fieldName)
+				fieldName = fmt.Sprintf("%v.%v", tableName, dialect.QuoteIdentifier(fieldName))
 			}
 
 			groupClauses = append(groupClauses, fmt.Sprintf("%s %s %s", fieldName, operator, formattedValue))
 		}
 		// Combine the group clauses with the group operator (AND/OR)
-		filterClauses = append(filterClauses, fmt.Sprintf("(%s)", strings.Join(groupClauses, fmt.Sprintf(" %s ", filterGroup.Operator))))
+		filterClauses = append(filterClauses, fmt.Sprintf("(%s)", strings.Join(groupClauses, fmt.Sprintf(" %s ", groupOperator))))
 	}
 
 	filterQuery := strings.Join(filterClauses, " AND ")
 
-	return filterQuery
+	return filterQuery, nil
 }
 
 // Helper function to build dynamic order by clauses
-func buildOrderBy(request entity.CatalogQuery) string {
+func buildOrderBy(dialect Dialect, request entity.CatalogQuery, formulas formulaColumns) string {
 	var orderClauses []string
 	for _, order := range request.Orders {
-		fieldName := fmt.Sprintf("%v.%v.%v", request.TenantCode, request.ObjectCode, order.FieldName)
-		orderClauses = append(orderClauses, fmt.Sprintf("%s %s", fieldName, order.Direction))
+		fieldName := dialect.ColumnName(request.TenantCode, request.ObjectCode, order.FieldName)
+		if formula, ok := formulas.expression(order.FieldName); ok {
+			fieldName = "(" + formula + ")"
+		}
+
+		// the direction is written into the query, anything but desc sorts ascending
+		direction := "ASC"
+		if strings.EqualFold(strings.TrimSpace(order.Direction), "desc") {
+			direction = "DESC"
+		}
+
+		orderClauses = append(orderClauses, fmt.Sprintf("%s %s", fieldName, direction))
 	}
 	return strings.Join(orderClauses, ", ")
 }
 
-func getSingleData(columnList []map[string]interface{}, columnsString, tableName string, request entity.CatalogQuery) string {
+func getSingleData(dialect Dialect, columnsString, tableName string, request entity.CatalogQuery, joinQueryMap map[string]string, joinQueryOrder []string) string {
 	// Start building the base query
 	query := fmt.Sprintf(`
 		SELECT %v
 		FROM %v`, columnsString, tableName)
 
 	// handle join table if any
-	for _, column := range columnList {
-		if column[entity.ForeignTable] != nil {
-			foreignTableName := column[entity.ForeignTable].(map[string]string)[entity.FieldForeignTableName]
-			foreignTableReferenceColumnName := column[entity.ForeignTable].(map[string]string)[entity.ForeignReferenceColumnName]
-
-			joinClause := fmt.Sprintf("LEFT JOIN %v ON %v = %v.%v", foreignTableName, column[entity.FieldForeignColumnName], foreignTableName, foreignTableReferenceColumnName)
-
-			if !strings.Contains(query, joinClause) {
-				query = fmt.Sprintf("%s %s", query, joinClause)
-			}
+	for _, joinKey := range joinQueryOrder {
+		if !strings.Contains(query, joinQueryMap[joinKey]) {
+			query = fmt.Sprintf("%s %s", query, joinQueryMap[joinKey])
 		}
 	}
 
@@ -574,13 +901,13 @@ func getSingleData(columnList []map[string]interface{}, columnsString, tableName
 		identifierColumn = "code"
 	}
 
-	query = query + fmt.Sprintf(" AND %v.%v = '%v'", tableName, identifierColumn, request.Serial)
+	query = query + fmt.Sprintf(" AND %v.%v = %v", tableName, identifierColumn, dialect.QuoteLiteral(request.Serial))
 
 	return query
 }
 
 // Main function to get data with pagination, filters, and orders
-func (r *repository) getDataWithPagination(ctx context.Context, columnsString, tableName string, request entity.CatalogQuery, joinQueryMap map[string]string, joinQueryOrder []string) string {
+func (r *repository) getDataWithPagination(ctx context.Context, dialect Dialect, columnsString, tableName string, request entity.CatalogQuery, joinQueryMap map[string]string, joinQueryOrder []string, formulas formulaColumns) (string, error) {
 	// Start building the base query
 	query := fmt.Sprintf(`SELECT %v FROM %v`, columnsString, tableName)
 
@@ -595,10 +922,7 @@ func (r *repository) getDataWithPagination(ctx context.Context, columnsString, t
 	for _, filterGroup := range request.Filters {
 		for fieldName, filter := range filterGroup.Filters {
 			if strings.Contains(fieldName, "__") {
-				queryResult, joinQueryMap := r.HandleChainingJoinQuery(ctx, query, fieldName, tableName, request, filter)
-				query = queryResult
-
-				fmt.Print("joinQueryMap: ", joinQueryMap)
+				query, _ = r.HandleChainingJoinQuery(ctx, query, fieldName, request.ObjectCode, request, filter)
 			}
 		}
 	}
@@ -607,23 +931,52 @@ func (r *repository) getDataWithPagination(ctx context.Context, columnsString, t
 
 	// Apply dynamic filters if they exist
 	if len(request.Filters) > 0 {
-		query = query + " AND " + r.buildFilters(ctx, request, tableName)
+		filterQuery, err := r.buildFilters(ctx, dialect, request, tableName, formulas)
+		if err != nil {
+			return "", err
+		}
+
+		query = query + " AND " + filterQuery
 	}
 
 	// Apply dynamic order by if they exist
 	if len(request.Orders) > 0 {
-		query = query + " ORDER BY " + buildOrderBy(request)
+		query = query + " ORDER BY " + buildOrderBy(dialect, request, formulas)
 	}
 
 	// Apply pagination (LIMIT and OFFSET)
-	query = fmt.Sprintf("%s LIMIT %d OFFSET %d", query, request.PageSize, (request.Page-1)*request.PageSize)
-	log.Print(query)
+	query = dialect.Paginate(query, len(request.Orders) > 0, request.PageSize, (request.Page-1)*request.PageSize)
+	r.logQuery("selectQuery", query)
 
-	return query
+	return query, nil
+}
+
+func (r *repository) getTotalCountQuery(ctx context.Context, dialect Dialect, tableName string, request entity.CatalogQuery, joinQueryMap map[string]string, joinQueryOrder []string, formulas formulaColumns) (string, error) {
+	query, err := r.getFilteredQuery(ctx, dialect, "COUNT(*)", tableName, request, joinQueryMap, joinQueryOrder, formulas)
+	if err != nil {
+		return "", err
+	}
+
+	r.logQuery("countQuery", query)
+	return query, nil
 }
 
-func (r *repository) getTotalCountQuery(ctx context.Context, tableName string, request entity.CatalogQuery, joinQueryMap map[string]string, joinQueryOrder []string) string {
-	query := fmt.Sprintf(`SELECT COUNT(*) FROM %v`, tableName)
+// getGroupCountQuery counts the filtered records per value of a column
+func (r *repository) getGroupCountQuery(ctx context.Context, dialect Dialect, tableName, columnName string, request entity.CatalogQuery, joinQueryMap map[string]string, joinQueryOrder []string, formulas formulaColumns) (string, error) {
+	query, err := r.getFilteredQuery(ctx, dialect, fmt.Sprintf("%v, COUNT(*)", columnName), tableName, request, joinQueryMap, joinQueryOrder, formulas)
+	if err != nil {
+		return "", err
+	}
+
+	query += fmt.Sprintf(" GROUP BY %v ORDER BY %v", columnName, columnName)
+
+	r.logQuery("groupCountQuery", query)
+	return query, nil
+}
+
+// getFilteredQuery selects selectClause from the live records matching the request filters
+func (r *repository) getFilteredQuery(ctx context.Context, dialect Dialect, selectClause, tableName string, request entity.CatalogQuery, joinQueryMap map[string]string, joinQueryOrder []string, formulas formulaColumns) (string, error) {
+	query := fmt.Sprintf(`SELECT %v FROM %v`, selectClause, tableName)
 
 	// integrate join query if any
 	for _, joinKey := range joinQueryOrder {
@@ -636,10 +989,7 @@ func (r *repository) getTotalCountQuery(ctx context.Context, tableName string, r
 	for _, filterGroup := range request.Filters {
 		for fieldName, filter := range filterGroup.Filters {
 			if strings.Contains(fieldName, "__") {
-				queryResult, joinQueryMap := r.HandleChainingJoinQuery(ctx, query, fieldName, tableName, request, filter)
-				query = queryResult
-
-				fmt.Print("joinQueryMap: ", joinQueryMap)
+				query, _ = r.HandleChainingJoinQuery(ctx, query, fieldName, request.ObjectCode, request, filter)
 			}
 		}
 	}
@@ -648,11 +998,15 @@ func (r *repository) getTotalCountQuery(ctx context.Context, tableName string, r
 
 	// Apply dynamic filters if they exist
 	if len(request.Filters) > 0 {
-		query = query + " AND " + r.buildFilters(ctx, request, tableName)
+		filterQuery, err := r.buildFilters(ctx, dialect, request, tableName, formulas)
+		if err != nil {
+			return "", err
+		}
+
+		query = query + " AND " + filterQuery
 	}
 
-	log.Print(query)
-	return query
+	return query, nil
 }
 
 func (r *repository) HandleChainingJoinQuery(ctx context.Context, query, fieldName, tableName string, request entity.CatalogQuery, filter entity.FilterItem) (updatedQuery string, joinQueryMap map[string]string) {
@@ -660,6 +1014,11 @@ func (r *repository) HandleChainingJoinQuery(ctx context.Context, query, fieldNa
 	joinQueryMap = make(map[string]string)
 	joinQuery := query
 
+	source, err := r.resolveDataSource(ctx, request.TenantCode, request.ObjectCode)
+	if err != nil {
+		return joinQuery, joinQueryMap
+	}
+
 	foreignFieldSet := strings.Split(fieldName, "__")
 	cleanTableName := strings.Split(tableName, ".")
 
@@ -673,7 +1032,7 @@ func (r *repository) HandleChainingJoinQuery(ctx context.Context, query, fieldNa
 	for i, foreignField := range foreignFieldSet {
 		if i < len(foreignFieldSet)-1 {
 			foreignKeyInfo, _ := r.GetForeignKeyInfo(ctx, currentTableName, foreignField, request.TenantCode)
-			foreignTableName := fmt.Sprintf("%v.%v", request.TenantCode, foreignKeyInfo.ForeignTable)
+			foreignTableName := source.dialect.TableName(request.TenantCode, foreignKeyInfo.ForeignTable)
 
 			// check if i is the last element
 			joinAlias := fieldName
@@ -688,10 +1047,10 @@ func (r *repository) HandleChainingJoinQuery(ctx context.Context, query, fieldNa
 				joinTableName = nextJoinAlias
 			}
 
-			foreignFieldName := fmt.Sprintf("%v.%v", joinAliasField, foreignKeyInfo.ForeignColumn)
-			sourceFieldName := fmt.Sprintf("%v.%v", joinTableName, foreignField)
+			foreignFieldName := qualify(source.dialect, joinAliasField, foreignKeyInfo.ForeignColumn)
+			sourceFieldName := qualify(source.dialect, joinTableName, foreignField)
 
-			joinClause := fmt.Sprintf("LEFT JOIN %v as %v ON %v = %v", foreignTableName, joinAlias, foreignFieldName, sourceFieldName)
+			joinClause := fmt.Sprintf("LEFT JOIN %v as %v ON %v = %v", foreignTableName, source.dialect.QuoteIdentifier(joinAlias), foreignFieldName, sourceFieldName)
 			if !strings.Contains(joinQuery, joinClause) {
 				joinQuery = fmt.Sprintf("%s %s", joinQuery, joinClause)
 			}
@@ -707,16 +1066,20 @@ func (r *repository) HandleChainingJoinQuery(ctx context.Context, query, fieldNa
 }
 
 func (r *repository) HandleJoinQuery(ctx context.Context, query, fieldName, tableName string, request entity.CatalogQuery, filter entity.FilterItem) (updatedQuery string) {
+	source, err := r.resolveDataSource(ctx, request.TenantCode, request.ObjectCode)
+	if err != nil {
+		return query
+	}
+
 	// if fieldName contains double underscore, then we need to join the table
 	foreignFieldSet := strings.Split(fieldName, "__")
 
 	// get foreign table name based on fieldName
-	cleanTableName := strings.Split(tableName, ".")
-	foreignKeyInfo, _ := r.GetForeignKeyInfo(ctx, cleanTableName[1], foreignFieldSet[0], request.TenantCode)
+	foreignKeyInfo, _ := r.GetForeignKeyInfo(ctx, request.ObjectCode, foreignFieldSet[0], request.TenantCode)
 
-	foreignTableName := fmt.Sprintf("%v.%v", request.TenantCode, foreignKeyInfo.ForeignTable)
-	foreignFieldName := fmt.Sprintf("%v.%v", foreignTableName, foreignKeyInfo.ForeignColumn)
-	sourceFieldName := fmt.Sprintf("%v.%v", tableName, foreignFieldSet[0])
+	foreignTableName := source.dialect.TableName(request.TenantCode, foreignKeyInfo.ForeignTable)
+	foreignFieldName := fmt.Sprintf("%v.%v", foreignTableName, source.dialect.QuoteIdentifier(foreignKeyInfo.ForeignColumn))
+	sourceFieldName := fmt.Sprintf("%v.%v", tableName, source.dialect.QuoteIdentifier(foreignFieldSet[0]))
 
 	joinClause := fmt.Sprintf("LEFT JOIN %v ON %v = %v", foreignTableName, foreignFieldName, sourceFieldName)
 
@@ -724,35 +1087,178 @@ func (r *repository) HandleJoinQuery(ctx context.Context, query, fieldName, tabl
 		query = fmt.Sprintf("%s %s", query, joinClause)
 	}
 
-	// add filter condition to query
-	operator := entity.OperatorQueryMap[filter.Operator]
-	value := filter.Value
+	// add filter condition to query, an invalid filter is left to buildFilters to report
+	operator, formattedValue, err := filterCondition(source.dialect, filter)
+	if err != nil {
+		return query
+	}
 
-	// handler value of operator is part of entity.OperatorLIKEList, then we should add %
-	if isOperatorInLIKEList(filter.Operator) {
-		value = fmt.Sprintf("%%%v%%", value)
+	// Create filter conditions based on the field, operator, and value
+	query = fmt.Sprintf("%s AND %s %s %s", query, fmt.Sprintf("%v.%v", foreignTableName, source.dialect.QuoteIdentifier(foreignFieldSet[1])), operator, formattedValue)
+
+	return query
+}
+
+// selectExpression returns the dialect specific select expression of a column from GetColumnList
+func selectExpression(dialect Dialect, request entity.CatalogQuery, column map[string]any, formulas formulaColumns) string {
+	columnCode := column[entity.FieldColumnCode].(string)
+
+	if formula, ok := formulas.expression(columnCode); ok {
+		return "(" + formula + ")"
+	}
+
+	// relationship columns are selected from their join alias, e.g. user_serial__name.name
+	if _, ok := column[entity.FieldOriginalFieldCode]; ok {
+		separatorIndex := strings.LastIndex(columnCode, ".")
+		return qualify(dialect, columnCode[:separatorIndex], columnCode[separatorIndex+1:])
+	}
+
+	return dialect.ColumnName(request.TenantCode, request.ObjectCode, columnCode)
+}
+
+// likeEscaper escapes the wildcards of a contains filter, so the value is matched literally
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`, `[`, `\[`)
+
+// filterGroupOperator returns the sql operator combining the items of a filter group, AND when it is not set
+func filterGroupOperator(operator entity.FilterOperator) (string, error) {
+	switch strings.ToUpper(strings.TrimSpace(string(operator))) {
+	case "", string(entity.FilterOperatorAnd):
+		return string(entity.FilterOperatorAnd), nil
+	case string(entity.FilterOperatorOr):
+		return string(entity.FilterOperatorOr), nil
+	}
+
+	return "", fmt.Errorf("%w: %q", entity.ErrorFilterGroupOperatorInvalid, operator)
+}
+
+// filterCondition returns the sql operator and the formatted value of a filter item. A nil value compares with IS NULL,
+// contains filters match the value literally and in filters take a list of values.
+func filterCondition(dialect Dialect, filter entity.FilterItem) (operator, value string, err error) {
+	operator = operatorQuery(dialect, filter.Operator)
+	if operator == "" {
+		return "", "", fmt.Errorf("%w: %q", entity.ErrorFilterOperatorInvalid, filter.Operator)
 	}
 
-	var formattedValue string
+	switch {
+	case filter.Value == nil && (filter.Operator == entity.FilterOperatorEqual || filter.Operator == entity.FilterOperatorNotEqual):
+		operator = "IS"
+		if filter.Operator == entity.FilterOperatorNotEqual {
+			operator = "IS NOT"
+		}
 
+		return operator, "NULL", nil
+	case isOperatorInLIKEList(filter.Operator):
+		pattern := "%" + likeEscaper.Replace(fmt.Sprintf("%v", filter.Value)) + "%"
+		return operator, fmt.Sprintf("%v ESCAPE %v", dialect.QuoteLiteral(pattern), dialect.QuoteLiteral(`\`)), nil
+	case filter.Operator == entity.FilterOperatorIn:
+		return operator, formatValueList(dialect, filter.Value), nil
+	}
+
+	return operator, formatValue(dialect, filter.Value), nil
+}
+
+// formatValueList formats the values of an in filter, an empty list matches nothing
+func formatValueList(dialect Dialect, value any) string {
+	values := []string{}
+	switch v := value.(type) {
+	case []any:
+		for _, item := range v {
+			values = append(values, formatValue(dialect, item))
+		}
+	case []string:
+		for _, item := range v {
+			values = append(values, formatValue(dialect, item))
+		}
+	default:
+		values = append(values, formatValue(dialect, v))
+	}
+
+	if len(values) == 0 {
+		return "(NULL)"
+	}
+
+	return "(" + strings.Join(values, ", ") + ")"
+}
+
+// formatValue formats a filter or mutation value into a sql literal
+func formatValue(dialect Dialect, value any) string {
 	switch v := value.(type) {
 	case string:
 		// Wrap strings in single quotes
-		formattedValue = fmt.Sprintf("'%s'", v)
+		return dialect.QuoteLiteral(v)
 	case bool:
-		// Booleans: PostgreSQL uses true/false literals
-		formattedValue = fmt.Sprintf("%t", v)
+		return dialect.BooleanLiteral(v)
 	case int, int8, int16, int32, int64:
-		formattedValue = fmt.Sprintf("%d", v)
+		return fmt.Sprintf("%d", v)
 	case float32, float64:
-		formattedValue = fmt.Sprintf("%f", v)
+		return fmt.Sprintf("%f", v)
 	default:
 		// Fallback to string with single quotes
-		formattedValue = fmt.Sprintf("'%v'", v)
+		return dialect.QuoteLiteral(fmt.Sprintf("%v", v))
 	}
+}
 
-	// Create filter conditions based on the field, operator, and value
-	query = fmt.Sprintf("%s AND %s %s %s", query, fmt.Sprintf("%v.%v", foreignTableName, foreignFieldSet[1]), operator, formattedValue)
+// formatMutationValue formats the value of a data item for insert and update queries
+func formatMutationValue(dialect Dialect, item entity.DataItem) (string, error) {
+	if item.Value == nil {
+		return "NULL", nil
+	}
 
-	return query
+	// json columns come back from the database as maps and slices
+	switch item.Value.(type) {
+	case map[string]any, []any:
+		if data, err := json.Marshal(item.Value); err == nil {
+			return dialect.QuoteLiteral(string(data)), nil
+		}
+	}
+
+	switch item.DataType {
+	case "text":
+		return dialect.QuoteLiteral(fmt.Sprintf("%v", item.Value)), nil
+	case "integer":
+		return integerLiteral(item)
+	case "boolean":
+		return formatValue(dialect, item.Value), nil
+	default:
+		return dialect.QuoteLiteral(fmt.Sprintf("%v", item.Value)), nil
+	}
+}
+
+// integerLiteral formats the value of an integer item, the value is written unquoted so it must parse as an integer
+func integerLiteral(item entity.DataItem) (string, error) {
+	switch v := item.Value.(type) {
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return fmt.Sprintf("%d", v), nil
+	case float64:
+		// json numbers are decoded as float64
+		if v == math.Trunc(v) && math.Abs(v) < 1<<63 {
+			return strconv.FormatInt(int64(v), 10), nil
+		}
+	case string:
+		if number, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64); err == nil {
+			return strconv.FormatInt(number, 10), nil
+		}
+	}
+
+	return "", fmt.Errorf("%v must be an integer, got %v", item.FieldCode, item.Value)
+}
+
+// recordCondition returns the where clause of a single live record, identified by serial or code
+func recordCondition(dialect Dialect, tableName, serial string) string {
+	identifierColumn := "serial"
+	if !helper.IsUUID(serial) {
+		identifierColumn = "code"
+	}
+
+	return fmt.Sprintf("%v.%v = %v AND %v.deleted_at IS NULL", tableName, identifierColumn, dialect.QuoteLiteral(serial), tableName)
+}
+
+func hasDataItem(items []entity.DataItem, fieldCode string) bool {
+	for _, item := range items {
+		if item.FieldCode == fieldCode {
+			return true
+		}
+	}
+
+	return false
 }
@@ -0,0 +1,86 @@
+package catalogrepository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// PurgeDeletedData removes for good the records of an object soft deleted before a time. It returns the serial and the
+// fieldCodes columns of every removed record, so what the records referred to can be cleaned up.
+func (r *repository) PurgeDeletedData(ctx context.Context, tenantCode, objectCode string, before time.Time, fieldCodes []string) (purged []map[string]any, err error) {
+	source, err := r.resolveDataSource(ctx, tenantCode, objectCode)
+	if err != nil {
+		return nil, err
+	}
+
+	completeTableName := source.dialect.TableName(tenantCode, objectCode)
+	condition := fmt.Sprintf("%v.deleted_at IS NOT NULL AND %v.deleted_at < ?", completeTableName, completeTableName)
+
+	columns := []string{fmt.Sprintf("%v.serial", completeTableName)}
+	for _, fieldCode := range fieldCodes {
+		columns = append(columns, fmt.Sprintf("%v.%v", completeTableName, source.dialect.QuoteIdentifier(fieldCode)))
+	}
+
+	err = source.db.Transaction(func(tx *gorm.DB) error {
+		selectQuery := fmt.Sprintf("SELECT %v FROM %v WHERE %v", strings.Join(columns, ", "), completeTableName, condition)
+		r.logQuery("selectQuery", selectQuery)
+
+		if err := tx.Raw(selectQuery, before).Scan(&purged).Error; err != nil {
+			return err
+		}
+
+		if len(purged) == 0 {
+			return nil
+		}
+
+		serials := []string{}
+		for _, record := range purged {
+			serials = append(serials, source.dialect.QuoteLiteral(fmt.Sprintf("%v", record["serial"])))
+		}
+
+		purgeQuery := fmt.Sprintf("DELETE FROM %v WHERE %v AND %v.serial IN (%v)", completeTableName, condition, completeTableName, strings.Join(serials, ", "))
+		r.logQuery("purgeQuery", purgeQuery)
+
+		return tx.Exec(purgeQuery, before).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return purged, nil
+}
+
+// SnapshotQuery appends the rows of a query to a table of the tenant, in the data source of objectCode. Every row is
+// stamped with the time of the snapshot in a snapshot_at column, the table is created from the columns of the query
+// the first time.
+func (r *repository) SnapshotQuery(ctx context.Context, tenantCode, objectCode, rawQuery, targetTable string) (rows int64, err error) {
+	if strings.TrimSpace(rawQuery) == "" || strings.TrimSpace(targetTable) == "" {
+		return 0, errors.New("snapshot needs a query and a target table")
+	}
+
+	source, err := r.resolveDataSource(ctx, tenantCode, objectCode)
+	if err != nil {
+		return 0, err
+	}
+
+	completeTableName := source.dialect.TableName(tenantCode, targetTable)
+	selectQuery := fmt.Sprintf("SELECT CURRENT_TIMESTAMP AS snapshot_at, snapshot.* FROM (%v) AS snapshot", rawQuery)
+
+	createQuery := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %v AS %v WHERE 1 = 0", completeTableName, selectQuery)
+	r.logQuery("createQuery", createQuery)
+
+	if err := source.db.Exec(createQuery).Error; err != nil {
+		return 0, err
+	}
+
+	insertQuery := fmt.Sprintf("INSERT INTO %v %v", completeTableName, selectQuery)
+	r.logQuery("insertQuery", insertQuery)
+
+	result := source.db.Exec(insertQuery)
+	return result.RowsAffected, result.Error
+}
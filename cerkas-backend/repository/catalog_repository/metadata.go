@@ -0,0 +1,66 @@
+package catalogrepository
+
+import (
+	"context"
+
+	"github.com/cerkas/cerkas-backend/core/entity"
+)
+
+// GetObjectsByTenant lists the objects of a tenant by code
+func (r *repository) GetObjectsByTenant(ctx context.Context, tenantCode string) (resp []entity.Objects, err error) {
+	db := r.db.Model(&Objects{})
+
+	if r.cfg.IsDebugMode {
+		db = db.Debug()
+	}
+
+	results := []Objects{}
+	if err := db.Joins("JOIN tenants ON tenants.serial = objects.tenant_serial").
+		Where("tenants.code = ?", tenantCode).
+		Order("objects.code ASC").
+		Find(&results).Error; err != nil {
+		return resp, err
+	}
+
+	for _, result := range results {
+		resp = append(resp, result.ToEntity())
+	}
+
+	return resp, nil
+}
+
+// GetMetadataVersion returns a version of the objects, the object fields and the data types of a tenant, a hash of
+// their rows that changes whenever one of them is created, updated or deleted
+func (r *repository) GetMetadataVersion(ctx context.Context, tenantCode string) (version string, err error) {
+	db := r.db
+	if r.cfg.IsDebugMode {
+		db = db.Debug()
+	}
+
+	err = db.Raw(`
+	SELECT md5(concat_ws('/',
+		(
+			SELECT string_agg(objects::text, ',' ORDER BY objects.id)
+			FROM objects
+				JOIN tenants ON tenants.serial = objects.tenant_serial
+			WHERE tenants.code = ?
+		),
+		(
+			SELECT string_agg(object_fields::text, ',' ORDER BY object_fields.id)
+			FROM object_fields
+				JOIN objects ON objects.serial = object_fields.object_serial
+				JOIN tenants ON tenants.serial = objects.tenant_serial
+			WHERE tenants.code = ?
+		),
+		(
+			SELECT string_agg(data_types::text, ',' ORDER BY data_types.id)
+			FROM data_types
+		)
+	))
+	`, tenantCode, tenantCode).Scan(&version).Error
+	if err != nil {
+		return version, err
+	}
+
+	return version, nil
+}
@@ -0,0 +1,286 @@
+package catalogrepository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/cerkas/cerkas-backend/core/entity"
+)
+
+/*
+	rollup fields aggregate the records of another object referring to a record, like the line total of an order
+	or the open tickets of an account. a rollup is computed on read by a correlated subquery, so it is selected,
+	filtered and ordered like a physical column:
+
+		(SELECT COALESCE(SUM("rollup_total"."amount"), 0) FROM "tenant"."order_lines" AS "rollup_total"
+			WHERE "rollup_total"."order_serial" = "tenant"."orders"."serial" AND "rollup_total".deleted_at IS NULL)
+
+	a materialized rollup is a physical column of the object instead, recomputed by RefreshRollup whenever a record
+	it aggregates changes.
+*/
+
+// getRollupColumns compiles the rollup fields of the request object computed on read. A rollup that does not compile
+// is left out, so a broken rollup only fails the requests asking for it.
+func (r *repository) getRollupColumns(ctx context.Context, dialect Dialect, request entity.CatalogQuery) (resp formulaColumns, err error) {
+	results, err := r.getComputedFields(request.TenantCode, request.ObjectCode, entity.FieldKindRollup)
+	if err != nil {
+		return resp, err
+	}
+
+	resp = formulaColumns{}
+	for _, result := range results {
+		objectField := result.ToEntity()
+		if objectField.Rollup == nil {
+			log.Printf("rollup field %v.%v has no rollup settings", request.ObjectCode, objectField.FieldCode)
+			continue
+		}
+
+		if objectField.Rollup.Materialized {
+			continue
+		}
+
+		sql, err := r.compileRollup(ctx, dialect, request.TenantCode, request.ObjectCode, objectField.FieldCode, *objectField.Rollup)
+		if err != nil {
+			log.Printf("rollup field %v.%v: %v", request.ObjectCode, objectField.FieldCode, err)
+			continue
+		}
+
+		resp[objectField.FieldCode] = formulaColumn{kind: entity.FieldKindRollup, sql: sql}
+	}
+
+	return resp, nil
+}
+
+// compileRollup builds the correlated subquery aggregating the records of a rollup for a record of objectCode
+func (r *repository) compileRollup(ctx context.Context, dialect Dialect, tenantCode, objectCode, fieldCode string, rollup entity.RollupConfig) (string, error) {
+	relation, err := r.getRollupRelation(ctx, tenantCode, objectCode, rollup)
+	if err != nil {
+		return "", err
+	}
+
+	alias := dialect.QuoteIdentifier("rollup_" + fieldCode)
+
+	aggregate := ""
+	switch strings.ToLower(rollup.Function) {
+	case entity.RollupFunctionCount:
+		aggregate = "COUNT(*)"
+		if rollup.FieldCode != "" {
+			aggregate = fmt.Sprintf("COUNT(%v.%v)", alias, dialect.QuoteIdentifier(rollup.FieldCode))
+		}
+	case entity.RollupFunctionSum:
+		aggregate = fmt.Sprintf("COALESCE(SUM(%v.%v), 0)", alias, dialect.QuoteIdentifier(rollup.FieldCode))
+	case entity.RollupFunctionAvg, entity.RollupFunctionMin, entity.RollupFunctionMax:
+		aggregate = fmt.Sprintf("%v(%v.%v)", strings.ToUpper(rollup.Function), alias, dialect.QuoteIdentifier(rollup.FieldCode))
+	default:
+		return "", fmt.Errorf("unknown rollup function %q", rollup.Function)
+	}
+
+	if rollup.FieldCode == "" && aggregate != "COUNT(*)" {
+		return "", fmt.Errorf("rollup function %v needs a field", rollup.Function)
+	}
+
+	conditions := []string{
+		fmt.Sprintf("%v.%v = %v", alias, dialect.QuoteIdentifier(relation.RelationField), dialect.ColumnName(tenantCode, objectCode, relation.ReferencedField)),
+		fmt.Sprintf("%v.deleted_at IS NULL", alias),
+	}
+
+	// the filters of a rollup are plain fields of the aggregated object, a subquery has no room for joins
+	for _, filterGroup := range rollup.Filters {
+		for fieldName := range filterGroup.Filters {
+			if strings.Contains(fieldName, "__") {
+				return "", fmt.Errorf("rollup filter %v is not a field of %v", fieldName, rollup.ObjectCode)
+			}
+		}
+	}
+
+	if len(rollup.Filters) > 0 {
+		filterQuery, err := r.buildFilters(ctx, dialect, entity.CatalogQuery{
+			TenantCode: tenantCode,
+			ObjectCode: rollup.ObjectCode,
+			Filters:    rollup.Filters,
+		}, alias, nil)
+		if err != nil {
+			return "", err
+		}
+
+		conditions = append(conditions, filterQuery)
+	}
+
+	return fmt.Sprintf("SELECT %v FROM %v AS %v WHERE %v", aggregate, dialect.TableName(tenantCode, rollup.ObjectCode), alias, strings.Join(conditions, " AND ")), nil
+}
+
+// getRollupRelation finds the relation a rollup aggregates through, both objects must live in the same data source
+func (r *repository) getRollupRelation(ctx context.Context, tenantCode, objectCode string, rollup entity.RollupConfig) (relation entity.ReverseRelation, err error) {
+	if rollup.ObjectCode == "" {
+		return relation, errors.New("rollup has no object")
+	}
+
+	source, err := r.resolveDataSource(ctx, tenantCode, objectCode)
+	if err != nil {
+		return relation, err
+	}
+
+	childSource, err := r.resolveDataSource(ctx, tenantCode, rollup.ObjectCode)
+	if err != nil {
+		return relation, err
+	}
+
+	if source.db != childSource.db {
+		return relation, fmt.Errorf("rollup object %v is in another data source", rollup.ObjectCode)
+	}
+
+	relations, err := r.GetReverseRelations(ctx, tenantCode, objectCode)
+	if err != nil {
+		return relation, err
+	}
+
+	matches := []entity.ReverseRelation{}
+	for _, relation := range relations {
+		if relation.ObjectCode == rollup.ObjectCode && (rollup.RelationField == "" || relation.RelationField == rollup.RelationField) {
+			matches = append(matches, relation)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return relation, fmt.Errorf("%v does not refer to %v", rollup.ObjectCode, objectCode)
+	case 1:
+		return matches[0], nil
+	default:
+		return relation, fmt.Errorf("%v refers to %v more than once, set the relation_field of the rollup", rollup.ObjectCode, objectCode)
+	}
+}
+
+// GetMaterializedRollups lists the materialized rollup fields aggregating the records of an object, with their relation
+func (r *repository) GetMaterializedRollups(ctx context.Context, tenantCode, objectCode string) (resp []entity.RollupField, err error) {
+	db := r.db
+	if r.cfg.IsDebugMode {
+		db = db.Debug()
+	}
+
+	results := []RollupField{}
+	err = db.Raw(`
+	SELECT
+		objects.code AS object_code,
+		object_fields.field_code,
+		object_fields.rollup
+	FROM
+		object_fields
+		JOIN objects ON objects.serial = object_fields.object_serial
+		JOIN tenants ON tenants.serial = objects.tenant_serial
+	WHERE
+		tenants.code = ?
+		AND object_fields.kind = ?
+		AND object_fields.rollup->>'object_code' = ?
+		AND COALESCE((object_fields.rollup->>'materialized')::boolean, false)
+		AND object_fields.deleted_at IS NULL
+	ORDER BY objects.code, object_fields.field_code
+	`, tenantCode, entity.FieldKindRollup, objectCode).Scan(&results).Error
+	if err != nil {
+		return resp, err
+	}
+
+	for _, result := range results {
+		rollupField, err := result.ToEntity()
+		if err != nil {
+			return resp, fmt.Errorf("rollup field %v.%v: %w", result.ObjectCode, result.FieldCode, err)
+		}
+
+		rollupField.Relation, err = r.getRollupRelation(ctx, tenantCode, rollupField.ObjectCode, rollupField.Rollup)
+		if err != nil {
+			return resp, fmt.Errorf("rollup field %v.%v: %w", result.ObjectCode, result.FieldCode, err)
+		}
+
+		resp = append(resp, rollupField)
+	}
+
+	return resp, nil
+}
+
+// RefreshRollup recomputes a materialized rollup field for the records whose referenced field is one of values
+func (r *repository) RefreshRollup(ctx context.Context, tenantCode string, rollup entity.RollupField, values []string) (err error) {
+	if len(values) == 0 {
+		return nil
+	}
+
+	_, err = r.refreshRollup(ctx, tenantCode, rollup, values)
+	return err
+}
+
+// RefreshObjectRollups recomputes every materialized rollup field of an object for all its records, it returns
+// the refreshed fields
+func (r *repository) RefreshObjectRollups(ctx context.Context, tenantCode, objectCode string) (resp []string, err error) {
+	results, err := r.getComputedFields(tenantCode, objectCode, entity.FieldKindRollup)
+	if err != nil {
+		return resp, err
+	}
+
+	for _, result := range results {
+		objectField := result.ToEntity()
+		if objectField.Rollup == nil || !objectField.Rollup.Materialized {
+			continue
+		}
+
+		rollup := entity.RollupField{ObjectCode: objectCode, FieldCode: objectField.FieldCode, Rollup: *objectField.Rollup}
+		rollup.Relation, err = r.getRollupRelation(ctx, tenantCode, objectCode, rollup.Rollup)
+		if err != nil {
+			return resp, fmt.Errorf("rollup field %v.%v: %w", objectCode, objectField.FieldCode, err)
+		}
+
+		if _, err = r.refreshRollup(ctx, tenantCode, rollup, nil); err != nil {
+			return resp, fmt.Errorf("rollup field %v.%v: %w", objectCode, objectField.FieldCode, err)
+		}
+
+		resp = append(resp, objectField.FieldCode)
+	}
+
+	return resp, nil
+}
+
+// refreshRollup recomputes a materialized rollup field, for the records whose referenced field is one of values or
+// for all the records when values is nil
+func (r *repository) refreshRollup(ctx context.Context, tenantCode string, rollup entity.RollupField, values []string) (refreshed int64, err error) {
+	source, err := r.resolveDataSource(ctx, tenantCode, rollup.ObjectCode)
+	if err != nil {
+		return 0, err
+	}
+
+	sql, err := r.compileRollup(ctx, source.dialect, tenantCode, rollup.ObjectCode, rollup.FieldCode, rollup.Rollup)
+	if err != nil {
+		return 0, err
+	}
+
+	completeTableName := source.dialect.TableName(tenantCode, rollup.ObjectCode)
+	updateQuery := fmt.Sprintf("UPDATE %v SET %v = (%v)", completeTableName, source.dialect.QuoteIdentifier(rollup.FieldCode), sql)
+
+	if values != nil {
+		formattedValues := []string{}
+		for _, value := range values {
+			formattedValues = append(formattedValues, formatValue(source.dialect, value))
+		}
+
+		updateQuery += fmt.Sprintf(" WHERE %v IN (%v)", source.dialect.ColumnName(tenantCode, rollup.ObjectCode, rollup.Relation.ReferencedField), strings.Join(formattedValues, ", "))
+	}
+	r.logQuery("updateQuery", updateQuery)
+
+	result := source.db.Exec(updateQuery)
+	return result.RowsAffected, result.Error
+}
+
+// computedFieldCodes returns the fields of an object that are computed, they are never written by a mutation
+func (r *repository) computedFieldCodes(tenantCode, objectCode string) (map[string]bool, error) {
+	results, err := r.getComputedFields(tenantCode, objectCode, entity.FieldKindFormula, entity.FieldKindRollup)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := map[string]bool{}
+	for _, result := range results {
+		resp[result.FieldCode] = true
+	}
+
+	return resp, nil
+}
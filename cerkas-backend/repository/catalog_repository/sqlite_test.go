@@ -0,0 +1,82 @@
+package catalogrepository
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/cerkas/cerkas-backend/config"
+	"github.com/cerkas/cerkas-backend/core/entity"
+	"github.com/cerkas/cerkas-backend/pkg/conn"
+)
+
+func TestSQLiteDataSource(t *testing.T) {
+	cfg := config.Config{MaxIdleConns: 1, MaxOpenConns: 1, ConnMaxLifetime: 10}
+	db, err := conn.OpenDataSource(&cfg, conn.DataSourceConfig{Driver: DialectSQLite, DBName: ":memory:"})
+	if err != nil {
+		t.Fatalf("OpenDataSource: %v", err)
+	}
+	defer conn.DbClose(db)
+
+	dialect := NewDialect(DialectSQLite)
+	tableName := dialect.QuoteIdentifier("invoice")
+
+	if err := db.Exec(fmt.Sprintf("CREATE TABLE %v (id INTEGER PRIMARY KEY, name TEXT, quantity INTEGER, paid BOOLEAN)", tableName)).Error; err != nil {
+		t.Fatalf("create table: %v", err)
+	}
+
+	names := []string{`it's`, `a\`, `a\' OR 1=1 --`, `plain`}
+	for i, name := range names {
+		values := []string{}
+		for _, item := range []entity.DataItem{
+			{FieldCode: "name", DataType: "text", Value: name},
+			{FieldCode: "quantity", DataType: "integer", Value: float64(i)},
+			{FieldCode: "paid", DataType: "boolean", Value: i%2 == 0},
+		} {
+			value, err := formatMutationValue(dialect, item)
+			if err != nil {
+				t.Fatalf("formatMutationValue: %v", err)
+			}
+
+			values = append(values, value)
+		}
+
+		if err := db.Exec(fmt.Sprintf("INSERT INTO %v (name, quantity, paid) VALUES (%v)", tableName, strings.Join(values, ", "))).Error; err != nil {
+			t.Fatalf("insert %q: %v", name, err)
+		}
+	}
+
+	for _, name := range names {
+		var count int64
+		query := fmt.Sprintf("SELECT COUNT(*) FROM %v WHERE name = %v", tableName, dialect.QuoteLiteral(name))
+		if err := db.Raw(query).Scan(&count).Error; err != nil || count != 1 {
+			t.Errorf("%q is found %v times, err %v", name, count, err)
+		}
+	}
+
+	// wildcards in a contains filter are matched literally
+	for search, want := range map[string]int64{"_": 0, "%": 0, `\`: 2, "'": 2} {
+		operator, value, err := filterCondition(dialect, entity.FilterItem{Operator: entity.FilterOperatorContains, Value: search})
+		if err != nil {
+			t.Fatalf("filterCondition: %v", err)
+		}
+
+		var count int64
+		query := fmt.Sprintf("SELECT COUNT(*) FROM %v WHERE name %v %v", tableName, operator, value)
+		if err := db.Raw(query).Scan(&count).Error; err != nil || count != want {
+			t.Errorf("contains %q matches %v names, want %v, err %v", search, count, want, err)
+		}
+	}
+
+	request := entity.CatalogQuery{Orders: []entity.Order{{FieldName: "quantity", Direction: "desc"}}}
+	query := dialect.Paginate(fmt.Sprintf("SELECT name FROM %v ORDER BY %v", tableName, buildOrderBy(dialect, request, nil)), true, 2, 1)
+
+	got := []string{}
+	if err := db.Raw(query).Scan(&got).Error; err != nil {
+		t.Fatalf("paginate: %v", err)
+	}
+
+	if len(got) != 2 || got[0] != names[2] || got[1] != names[1] {
+		t.Errorf("second page is %v, want %v", got, []string{names[2], names[1]})
+	}
+}
@@ -0,0 +1,32 @@
+package catalogrepository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/cerkas/cerkas-backend/core/entity"
+	"gorm.io/gorm"
+)
+
+// GetTenantLocale returns the locale and the time zone of a tenant
+func (r *repository) GetTenantLocale(ctx context.Context, tenantCode string) (resp entity.TenantLocale, err error) {
+	db := r.db.Model(&Tenants{})
+
+	if r.cfg.IsDebugMode {
+		db = db.Debug()
+	}
+
+	result := Tenants{}
+	if err := db.Where("code = ?", tenantCode).First(&result).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return resp, entity.ErrorNotFound
+		}
+
+		return resp, err
+	}
+
+	return entity.TenantLocale{
+		Locale:   result.Locale,
+		Timezone: result.Timezone,
+	}, nil
+}
@@ -0,0 +1,74 @@
+package changefeedrepository
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/cerkas/cerkas-backend/config"
+	repository_intf "github.com/cerkas/cerkas-backend/core/repository"
+	"github.com/cerkas/cerkas-backend/pkg/conn"
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+)
+
+// channel is the postgres notification channel of record events, the payload is the outbox event id
+const channel = "cerkas_record_event"
+
+type repository struct {
+	cfg config.Config
+	db  *gorm.DB
+}
+
+func New(cfg config.Config, db *gorm.DB) repository_intf.ChangeFeedRepository {
+	return &repository{
+		cfg: cfg,
+		db:  db,
+	}
+}
+
+func (r *repository) Notify(ctx context.Context, eventID int64) (err error) {
+	db := r.db
+	if r.cfg.IsDebugMode {
+		db = db.Debug()
+	}
+
+	return db.Exec("SELECT pg_notify(?, ?)", channel, strconv.FormatInt(eventID, 10)).Error
+}
+
+// Listen blocks until ctx is done and calls handler for every notified event. The listener reconnects on its own,
+// events notified while it is disconnected are missed.
+func (r *repository) Listen(ctx context.Context, handler func(eventID int64)) (err error) {
+	listener := pq.NewListener(conn.PostgresDSN(&r.cfg), time.Second, time.Minute, func(event pq.ListenerEventType, err error) {
+		if err != nil {
+			log.Println("change feed listener:", err)
+		}
+	})
+	defer listener.Close()
+
+	if err := listener.Listen(channel); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case notification := <-listener.Notify:
+			// a nil notification is sent after a reconnect
+			if notification == nil {
+				continue
+			}
+
+			eventID, err := strconv.ParseInt(notification.Extra, 10, 64)
+			if err != nil {
+				continue
+			}
+
+			handler(eventID)
+		case <-time.After(90 * time.Second):
+			go listener.Ping()
+		}
+	}
+}
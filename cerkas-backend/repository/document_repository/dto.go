@@ -0,0 +1,79 @@
+package documentrepository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/cerkas/cerkas-backend/core/entity"
+	"gorm.io/gorm"
+)
+
+type DocumentTemplate struct {
+	ID         int64          `gorm:"column:id;primaryKey" json:"id"`
+	Serial     string         `gorm:"column:serial" json:"serial"`
+	TenantCode string         `gorm:"column:tenant_code" json:"tenant_code"`
+	ObjectCode string         `gorm:"column:object_code" json:"object_code"`
+	Code       string         `gorm:"column:code" json:"code"`
+	Name       string         `gorm:"column:name" json:"name"`
+	Body       string         `gorm:"column:body" json:"body"`
+	Includes   string         `gorm:"column:includes" json:"includes"`
+	PageSize   string         `gorm:"column:page_size" json:"page_size"`
+	IsActive   bool           `gorm:"column:is_active" json:"is_active"`
+	CreatedBy  string         `gorm:"column:created_by" json:"created_by"`
+	CreatedAt  time.Time      `gorm:"column:created_at" json:"created_at"`
+	UpdatedBy  string         `gorm:"column:updated_by" json:"updated_by"`
+	UpdatedAt  time.Time      `gorm:"column:updated_at" json:"updated_at"`
+	DeletedBy  sql.NullString `gorm:"column:deleted_by" json:"deleted_by"`
+	DeletedAt  gorm.DeletedAt `gorm:"column:deleted_at" json:"deleted_at"`
+}
+
+func (dt *DocumentTemplate) TableName() string {
+	return "document_template"
+}
+
+func (dt *DocumentTemplate) ToEntity() entity.DocumentTemplate {
+	// convert includes from string
+	includes := []entity.Include{}
+	if err := json.Unmarshal([]byte(dt.Includes), &includes); err != nil {
+		includes = nil
+	}
+
+	return entity.DocumentTemplate{
+		ID:         dt.ID,
+		Serial:     dt.Serial,
+		TenantCode: dt.TenantCode,
+		ObjectCode: dt.ObjectCode,
+		Code:       dt.Code,
+		Name:       dt.Name,
+		Body:       dt.Body,
+		Includes:   includes,
+		PageSize:   dt.PageSize,
+		IsActive:   dt.IsActive,
+		CreatedBy:  dt.CreatedBy,
+		CreatedAt:  dt.CreatedAt,
+	}
+}
+
+func NewDocumentTemplate(template entity.DocumentTemplate) (DocumentTemplate, error) {
+	includes, err := json.Marshal(template.Includes)
+	if err != nil {
+		return DocumentTemplate{}, err
+	}
+
+	return DocumentTemplate{
+		Serial:     template.Serial,
+		TenantCode: template.TenantCode,
+		ObjectCode: template.ObjectCode,
+		Code:       template.Code,
+		Name:       template.Name,
+		Body:       template.Body,
+		Includes:   string(includes),
+		PageSize:   template.PageSize,
+		IsActive:   template.IsActive,
+		CreatedBy:  template.CreatedBy,
+		CreatedAt:  template.CreatedAt,
+		UpdatedBy:  template.CreatedBy,
+		UpdatedAt:  template.CreatedAt,
+	}, nil
+}
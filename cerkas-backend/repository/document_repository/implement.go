@@ -0,0 +1,99 @@
+package documentrepository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/cerkas/cerkas-backend/config"
+	"github.com/cerkas/cerkas-backend/core/entity"
+	repository_intf "github.com/cerkas/cerkas-backend/core/repository"
+	"gorm.io/gorm"
+)
+
+type repository struct {
+	cfg config.Config
+	db  *gorm.DB
+}
+
+func New(cfg config.Config, db *gorm.DB) repository_intf.DocumentRepository {
+	return &repository{
+		cfg: cfg,
+		db:  db,
+	}
+}
+
+func (r *repository) CreateTemplate(ctx context.Context, template entity.DocumentTemplate) (resp entity.DocumentTemplate, err error) {
+	record, err := NewDocumentTemplate(template)
+	if err != nil {
+		return resp, err
+	}
+
+	db := r.db.Model(&DocumentTemplate{})
+
+	if r.cfg.IsDebugMode {
+		db = db.Debug()
+	}
+
+	if err := db.Create(&record).Error; err != nil {
+		return resp, err
+	}
+
+	return record.ToEntity(), nil
+}
+
+func (r *repository) GetTemplates(ctx context.Context, tenantCode, objectCode string) (resp []entity.DocumentTemplate, err error) {
+	db := r.db.Model(&DocumentTemplate{})
+
+	if r.cfg.IsDebugMode {
+		db = db.Debug()
+	}
+
+	results := []DocumentTemplate{}
+	if err := db.Where("tenant_code = ? AND object_code = ?", tenantCode, objectCode).Order("code ASC").Find(&results).Error; err != nil {
+		return resp, err
+	}
+
+	for _, result := range results {
+		resp = append(resp, result.ToEntity())
+	}
+
+	return resp, nil
+}
+
+func (r *repository) GetTemplateByCode(ctx context.Context, tenantCode, objectCode, code string) (resp entity.DocumentTemplate, err error) {
+	db := r.db.Model(&DocumentTemplate{})
+
+	if r.cfg.IsDebugMode {
+		db = db.Debug()
+	}
+
+	result := DocumentTemplate{}
+	if err := db.Where("tenant_code = ? AND object_code = ? AND code = ?", tenantCode, objectCode, code).First(&result).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return resp, entity.ErrorNotFound
+		}
+
+		return resp, err
+	}
+
+	return result.ToEntity(), nil
+}
+
+func (r *repository) DeleteTemplate(ctx context.Context, tenantCode, serial string) (err error) {
+	db := r.db.Model(&DocumentTemplate{})
+
+	if r.cfg.IsDebugMode {
+		db = db.Debug()
+	}
+
+	result := db.Where("tenant_code = ? AND serial = ?", tenantCode, serial).Delete(&DocumentTemplate{})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return entity.ErrorNotFound
+	}
+
+	return nil
+}
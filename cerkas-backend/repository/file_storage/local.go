@@ -0,0 +1,92 @@
+package filestorage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/cerkas/cerkas-backend/core/entity"
+	repository_intf "github.com/cerkas/cerkas-backend/core/repository"
+)
+
+type localStorage struct {
+	root string
+}
+
+// NewLocal returns a file storage keeping the files under a directory of the local filesystem
+func NewLocal(root string) repository_intf.FileStorage {
+	return &localStorage{
+		root: root,
+	}
+}
+
+// Save writes the content next to its destination first, so a reader never sees half a file
+func (s *localStorage) Save(ctx context.Context, key string, content io.Reader) (size int64, err error) {
+	path, err := s.path(key)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return 0, err
+	}
+
+	file, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	size, err = io.Copy(file, content)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := file.Close(); err != nil {
+		return 0, err
+	}
+
+	return size, os.Rename(file.Name(), path)
+}
+
+func (s *localStorage) Open(ctx context.Context, key string) (content io.ReadCloser, err error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err = os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, entity.ErrorNotFound
+	}
+
+	return content, err
+}
+
+func (s *localStorage) Delete(ctx context.Context, key string) (err error) {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	return nil
+}
+
+// path maps a key to a file under the root, a key can not leave the root
+func (s *localStorage) path(key string) (string, error) {
+	path := filepath.FromSlash(key)
+	if !filepath.IsLocal(path) {
+		return "", fmt.Errorf("invalid file key %q", key)
+	}
+
+	return filepath.Join(s.root, path), nil
+}
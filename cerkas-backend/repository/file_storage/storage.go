@@ -0,0 +1,22 @@
+package filestorage
+
+import (
+	"fmt"
+
+	"github.com/cerkas/cerkas-backend/config"
+	repository_intf "github.com/cerkas/cerkas-backend/core/repository"
+)
+
+const (
+	DriverLocal = "local"
+)
+
+// New returns the file storage of the configured driver
+func New(cfg config.Config) (repository_intf.FileStorage, error) {
+	switch cfg.FileStorageDriver {
+	case "", DriverLocal:
+		return NewLocal(cfg.FileStorageDir), nil
+	default:
+		return nil, fmt.Errorf("unknown file storage driver %q", cfg.FileStorageDriver)
+	}
+}
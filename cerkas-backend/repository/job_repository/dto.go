@@ -0,0 +1,90 @@
+package jobrepository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/cerkas/cerkas-backend/core/entity"
+)
+
+type Job struct {
+	ID              int64          `gorm:"column:id;primaryKey" json:"id"`
+	Serial          string         `gorm:"column:serial" json:"serial"`
+	TenantCode      string         `gorm:"column:tenant_code" json:"tenant_code"`
+	JobType         string         `gorm:"column:job_type" json:"job_type"`
+	Payload         string         `gorm:"column:payload" json:"payload"`
+	Status          string         `gorm:"column:status" json:"status"`
+	Progress        int            `gorm:"column:progress" json:"progress"`
+	ProgressMessage sql.NullString `gorm:"column:progress_message" json:"progress_message"`
+	Result          sql.NullString `gorm:"column:result" json:"result"`
+	Error           sql.NullString `gorm:"column:error" json:"error"`
+	Attempts        int            `gorm:"column:attempts" json:"attempts"`
+	MaxAttempts     int            `gorm:"column:max_attempts" json:"max_attempts"`
+	CancelRequested bool           `gorm:"column:cancel_requested" json:"cancel_requested"`
+	RunAt           time.Time      `gorm:"column:run_at" json:"run_at"`
+	LockedUntil     sql.NullTime   `gorm:"column:locked_until" json:"locked_until"`
+	CreatedBy       string         `gorm:"column:created_by" json:"created_by"`
+	CreatedAt       time.Time      `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt       time.Time      `gorm:"column:updated_at" json:"updated_at"`
+	StartedAt       sql.NullTime   `gorm:"column:started_at" json:"started_at"`
+	FinishedAt      sql.NullTime   `gorm:"column:finished_at" json:"finished_at"`
+}
+
+func (j *Job) TableName() string {
+	return "job"
+}
+
+func (j *Job) ToEntity() entity.Job {
+	job := entity.Job{
+		ID:              j.ID,
+		Serial:          j.Serial,
+		TenantCode:      j.TenantCode,
+		JobType:         j.JobType,
+		Payload:         json.RawMessage(j.Payload),
+		Status:          j.Status,
+		Progress:        j.Progress,
+		ProgressMessage: j.ProgressMessage.String,
+		Error:           j.Error.String,
+		Attempts:        j.Attempts,
+		MaxAttempts:     j.MaxAttempts,
+		CancelRequested: j.CancelRequested,
+		RunAt:           j.RunAt,
+		CreatedBy:       j.CreatedBy,
+		CreatedAt:       j.CreatedAt,
+	}
+
+	if j.Result.Valid {
+		job.Result = json.RawMessage(j.Result.String)
+	}
+
+	if j.StartedAt.Valid {
+		job.StartedAt = &j.StartedAt.Time
+	}
+
+	if j.FinishedAt.Valid {
+		job.FinishedAt = &j.FinishedAt.Time
+	}
+
+	return job
+}
+
+func NewJob(job entity.Job) Job {
+	payload := string(job.Payload)
+	if payload == "" {
+		payload = "{}"
+	}
+
+	return Job{
+		Serial:      job.Serial,
+		TenantCode:  job.TenantCode,
+		JobType:     job.JobType,
+		Payload:     payload,
+		Status:      job.Status,
+		MaxAttempts: job.MaxAttempts,
+		RunAt:       job.RunAt,
+		CreatedBy:   job.CreatedBy,
+		CreatedAt:   job.CreatedAt,
+		UpdatedAt:   job.CreatedAt,
+	}
+}
@@ -0,0 +1,229 @@
+package jobrepository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/cerkas/cerkas-backend/config"
+	"github.com/cerkas/cerkas-backend/core/entity"
+	repository_intf "github.com/cerkas/cerkas-backend/core/repository"
+	"github.com/cerkas/cerkas-backend/pkg/helper"
+	"gorm.io/gorm"
+)
+
+type repository struct {
+	cfg config.Config
+	db  *gorm.DB
+}
+
+func New(cfg config.Config, db *gorm.DB) repository_intf.JobRepository {
+	return &repository{
+		cfg: cfg,
+		db:  db,
+	}
+}
+
+func (r *repository) CreateJob(ctx context.Context, job entity.Job) (resp entity.Job, err error) {
+	record := NewJob(job)
+
+	db := r.db.Model(&Job{})
+
+	if r.cfg.IsDebugMode {
+		db = db.Debug()
+	}
+
+	if err := db.Create(&record).Error; err != nil {
+		return resp, err
+	}
+
+	return record.ToEntity(), nil
+}
+
+func (r *repository) GetJob(ctx context.Context, tenantCode, serial string) (resp entity.Job, err error) {
+	db := r.db.Model(&Job{})
+
+	if r.cfg.IsDebugMode {
+		db = db.Debug()
+	}
+
+	result := Job{}
+	if err := db.Where("tenant_code = ? AND serial = ?", tenantCode, serial).First(&result).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return resp, entity.ErrorNotFound
+		}
+
+		return resp, err
+	}
+
+	return result.ToEntity(), nil
+}
+
+func (r *repository) GetJobs(ctx context.Context, request entity.JobQuery) (resp []entity.Job, total int64, err error) {
+	limit, offset, _, _ := helper.SetupListParameter(int32(request.Page), int32(request.PageSize))
+
+	db := r.db.Model(&Job{})
+
+	if r.cfg.IsDebugMode {
+		db = db.Debug()
+	}
+
+	db = db.Where("tenant_code = ?", request.TenantCode)
+
+	if request.JobType != "" {
+		db = db.Where("job_type = ?", request.JobType)
+	}
+
+	if request.Status != "" {
+		db = db.Where("status = ?", request.Status)
+	}
+
+	if request.CreatedBy != "" {
+		db = db.Where("created_by = ?", request.CreatedBy)
+	}
+
+	if err := db.Count(&total).Error; err != nil {
+		return resp, total, err
+	}
+
+	results := []Job{}
+	if err := db.Order("id DESC").Limit(int(limit)).Offset(int(offset)).Find(&results).Error; err != nil {
+		return resp, total, err
+	}
+
+	for _, result := range results {
+		resp = append(resp, result.ToEntity())
+	}
+
+	return resp, total, nil
+}
+
+// ClaimJob takes the next due job for a worker and leases it. Workers skip the jobs locked by one another, a running
+// job whose lease expired belongs to a worker that went away and is claimed again.
+func (r *repository) ClaimJob(ctx context.Context, lease time.Duration) (resp entity.Job, claimed bool, err error) {
+	query := fmt.Sprintf(`
+	UPDATE job SET
+		status = ?,
+		attempts = attempts + 1,
+		locked_until = now() + interval '%d seconds',
+		started_at = COALESCE(started_at, now()),
+		updated_at = now()
+	WHERE id = (
+		SELECT id FROM job
+		WHERE (status = ? AND run_at <= now()) OR (status = ? AND locked_until < now())
+		ORDER BY run_at, id
+		LIMIT 1
+		FOR UPDATE SKIP LOCKED
+	)
+	RETURNING *
+	`, int(lease.Seconds()))
+
+	db := r.db
+	if r.cfg.IsDebugMode {
+		db = db.Debug()
+	}
+
+	results := []Job{}
+	if err := db.Raw(query, entity.JobStatusRunning, entity.JobStatusQueued, entity.JobStatusRunning).Scan(&results).Error; err != nil {
+		return resp, false, err
+	}
+
+	if len(results) == 0 {
+		return resp, false, nil
+	}
+
+	return results[0].ToEntity(), true, nil
+}
+
+// UpdateJobProgress stores the progress of a running job and extends its lease, it tells the worker whether the job
+// was cancelled meanwhile. The attempt guards against a worker whose lease expired and whose job was claimed again.
+func (r *repository) UpdateJobProgress(ctx context.Context, job entity.Job, lease time.Duration) (cancelRequested bool, err error) {
+	db := r.db.Model(&Job{})
+
+	if r.cfg.IsDebugMode {
+		db = db.Debug()
+	}
+
+	result := db.Where("serial = ? AND status = ? AND attempts = ?", job.Serial, entity.JobStatusRunning, job.Attempts).Updates(map[string]any{
+		"progress":         job.Progress,
+		"progress_message": sql.NullString{String: job.ProgressMessage, Valid: job.ProgressMessage != ""},
+		"locked_until":     time.Now().Add(lease),
+		"updated_at":       time.Now(),
+	})
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	// a job no longer held by this attempt is as good as cancelled for the worker running it
+	if result.RowsAffected == 0 {
+		return true, nil
+	}
+
+	var current Job
+	if err := r.db.Model(&Job{}).Select("cancel_requested").Where("serial = ?", job.Serial).First(&current).Error; err != nil {
+		return false, err
+	}
+
+	return current.CancelRequested, nil
+}
+
+// FinishJob stores the outcome of an attempt, a job queued again for a retry keeps its progress for the next attempt
+func (r *repository) FinishJob(ctx context.Context, job entity.Job) (err error) {
+	db := r.db.Model(&Job{})
+
+	if r.cfg.IsDebugMode {
+		db = db.Debug()
+	}
+
+	values := map[string]any{
+		"status":           job.Status,
+		"progress":         job.Progress,
+		"progress_message": sql.NullString{String: job.ProgressMessage, Valid: job.ProgressMessage != ""},
+		"result":           sql.NullString{String: string(job.Result), Valid: len(job.Result) > 0},
+		"error":            sql.NullString{String: job.Error, Valid: job.Error != ""},
+		"run_at":           job.RunAt,
+		"locked_until":     nil,
+		"updated_at":       time.Now(),
+	}
+
+	if job.FinishedAt != nil {
+		values["finished_at"] = *job.FinishedAt
+	}
+
+	return db.Where("serial = ? AND status = ? AND attempts = ?", job.Serial, entity.JobStatusRunning, job.Attempts).Updates(values).Error
+}
+
+// CancelJob cancels a queued job right away and asks the worker of a running job to stop
+func (r *repository) CancelJob(ctx context.Context, tenantCode, serial string) (resp entity.Job, err error) {
+	db := r.db
+	if r.cfg.IsDebugMode {
+		db = db.Debug()
+	}
+
+	results := []Job{}
+	err = db.Raw(`
+	UPDATE job SET
+		cancel_requested = true,
+		status = CASE WHEN status = ? THEN ? ELSE status END,
+		finished_at = CASE WHEN status = ? THEN now() ELSE finished_at END,
+		updated_at = now()
+	WHERE tenant_code = ? AND serial = ? AND status IN (?, ?)
+	RETURNING *
+	`, entity.JobStatusQueued, entity.JobStatusCancelled, entity.JobStatusQueued, tenantCode, serial, entity.JobStatusQueued, entity.JobStatusRunning).Scan(&results).Error
+	if err != nil {
+		return resp, err
+	}
+
+	if len(results) == 0 {
+		job, err := r.GetJob(ctx, tenantCode, serial)
+		if err != nil {
+			return resp, err
+		}
+
+		return job, entity.ErrorJobFinished
+	}
+
+	return results[0].ToEntity(), nil
+}
@@ -0,0 +1,106 @@
+package outboxrepository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/cerkas/cerkas-backend/core/entity"
+)
+
+type EventOutbox struct {
+	ID                   int64        `gorm:"column:id;primaryKey" json:"id"`
+	Serial               string       `gorm:"column:serial" json:"serial"`
+	EventType            string       `gorm:"column:event_type" json:"event_type"`
+	TenantCode           string       `gorm:"column:tenant_code" json:"tenant_code"`
+	ProductCode          string       `gorm:"column:product_code" json:"product_code"`
+	ObjectCode           string       `gorm:"column:object_code" json:"object_code"`
+	RecordSerial         string       `gorm:"column:record_serial" json:"record_serial"`
+	ActorSerial          string       `gorm:"column:actor_serial" json:"actor_serial"`
+	Payload              string       `gorm:"column:payload" json:"payload"`
+	Status               string       `gorm:"column:status" json:"status"`
+	Attempts             int          `gorm:"column:attempts" json:"attempts"`
+	CompletedSubscribers string       `gorm:"column:completed_subscribers" json:"completed_subscribers"`
+	NextAttemptAt        time.Time    `gorm:"column:next_attempt_at" json:"next_attempt_at"`
+	LastError            string       `gorm:"column:last_error" json:"last_error"`
+	OccurredAt           time.Time    `gorm:"column:occurred_at" json:"occurred_at"`
+	DispatchedAt         sql.NullTime `gorm:"column:dispatched_at" json:"dispatched_at"`
+}
+
+func (eo *EventOutbox) TableName() string {
+	return "event_outbox"
+}
+
+// eventPayload holds the record values of an event, the rest of the event has its own columns
+type eventPayload struct {
+	Items   []entity.DataItem          `json:"items"`
+	Data    map[string]entity.DataItem `json:"data"`
+	OldData map[string]entity.DataItem `json:"old_data"`
+}
+
+func (eo *EventOutbox) ToEntity() entity.OutboxEvent {
+	// convert payload and completed subscribers from string
+	payload := eventPayload{}
+	if err := json.Unmarshal([]byte(eo.Payload), &payload); err != nil {
+		payload = eventPayload{}
+	}
+
+	completedSubscribers := []string{}
+	if err := json.Unmarshal([]byte(eo.CompletedSubscribers), &completedSubscribers); err != nil {
+		completedSubscribers = nil
+	}
+
+	var dispatchedAt *time.Time
+	if eo.DispatchedAt.Valid {
+		dispatchedAt = &eo.DispatchedAt.Time
+	}
+
+	return entity.OutboxEvent{
+		Event: entity.RecordEvent{
+			ID:           eo.ID,
+			Serial:       eo.Serial,
+			Type:         entity.RecordEventType(eo.EventType),
+			TenantCode:   eo.TenantCode,
+			ProductCode:  eo.ProductCode,
+			ObjectCode:   eo.ObjectCode,
+			RecordSerial: eo.RecordSerial,
+			ActorSerial:  eo.ActorSerial,
+			Items:        payload.Items,
+			Data:         payload.Data,
+			OldData:      payload.OldData,
+			OccurredAt:   eo.OccurredAt,
+		},
+		Status:               eo.Status,
+		Attempts:             eo.Attempts,
+		CompletedSubscribers: completedSubscribers,
+		NextAttemptAt:        eo.NextAttemptAt,
+		LastError:            eo.LastError,
+		DispatchedAt:         dispatchedAt,
+	}
+}
+
+func NewEventOutbox(event entity.RecordEvent) (EventOutbox, error) {
+	payload, err := json.Marshal(eventPayload{
+		Items:   event.Items,
+		Data:    event.Data,
+		OldData: event.OldData,
+	})
+	if err != nil {
+		return EventOutbox{}, err
+	}
+
+	return EventOutbox{
+		Serial:               event.Serial,
+		EventType:            string(event.Type),
+		TenantCode:           event.TenantCode,
+		ProductCode:          event.ProductCode,
+		ObjectCode:           event.ObjectCode,
+		RecordSerial:         event.RecordSerial,
+		ActorSerial:          event.ActorSerial,
+		Payload:              string(payload),
+		Status:               entity.OutboxStatusPending,
+		CompletedSubscribers: "[]",
+		NextAttemptAt:        event.OccurredAt,
+		OccurredAt:           event.OccurredAt,
+	}, nil
+}
@@ -0,0 +1,140 @@
+package outboxrepository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/cerkas/cerkas-backend/config"
+	"github.com/cerkas/cerkas-backend/core/entity"
+	repository_intf "github.com/cerkas/cerkas-backend/core/repository"
+	"github.com/cerkas/cerkas-backend/repository/util"
+	"gorm.io/gorm"
+)
+
+type repository struct {
+	cfg config.Config
+	db  *gorm.DB
+}
+
+func New(cfg config.Config, db *gorm.DB) repository_intf.OutboxRepository {
+	return &repository{
+		cfg: cfg,
+		db:  db,
+	}
+}
+
+// CreateEvents writes the events with the transaction of ctx, so they are only visible once the mutation commits
+func (r *repository) CreateEvents(ctx context.Context, events []entity.RecordEvent) (err error) {
+	if len(events) == 0 {
+		return nil
+	}
+
+	records := make([]EventOutbox, 0, len(events))
+	for _, event := range events {
+		record, err := NewEventOutbox(event)
+		if err != nil {
+			return err
+		}
+
+		records = append(records, record)
+	}
+
+	db := util.ContextDB(ctx, r.db).Model(&EventOutbox{})
+
+	if r.cfg.IsDebugMode {
+		db = db.Debug()
+	}
+
+	return db.Create(&records).Error
+}
+
+// ClaimEvents leases the due events in id order. An event is held back while an older event of the same record
+// is still pending, which keeps the dispatch order per record across relays.
+func (r *repository) ClaimEvents(ctx context.Context, limit int, lease time.Duration) (resp []entity.OutboxEvent, err error) {
+	query := fmt.Sprintf(`
+	UPDATE event_outbox SET next_attempt_at = now() + interval '%d seconds'
+	WHERE id IN (
+		SELECT o.id FROM event_outbox o
+		WHERE o.status = ? AND o.next_attempt_at <= now()
+		AND NOT EXISTS (
+			SELECT 1 FROM event_outbox p
+			WHERE p.tenant_code = o.tenant_code AND p.object_code = o.object_code AND p.record_serial = o.record_serial
+			AND p.status = ? AND p.id < o.id
+		)
+		ORDER BY o.id
+		LIMIT ?
+		FOR UPDATE SKIP LOCKED
+	)
+	RETURNING *
+	`, int(lease.Seconds()))
+
+	db := r.db
+	if r.cfg.IsDebugMode {
+		db = db.Debug()
+	}
+
+	results := []EventOutbox{}
+	if err := db.Raw(query, entity.OutboxStatusPending, entity.OutboxStatusPending, limit).Scan(&results).Error; err != nil {
+		return resp, err
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].ID < results[j].ID
+	})
+
+	for _, result := range results {
+		resp = append(resp, result.ToEntity())
+	}
+
+	return resp, nil
+}
+
+func (r *repository) UpdateEvent(ctx context.Context, event entity.OutboxEvent) (err error) {
+	completedSubscribers, err := json.Marshal(event.CompletedSubscribers)
+	if err != nil {
+		return err
+	}
+
+	dispatchedAt := sql.NullTime{}
+	if event.DispatchedAt != nil {
+		dispatchedAt = sql.NullTime{Time: *event.DispatchedAt, Valid: true}
+	}
+
+	db := r.db.Model(&EventOutbox{})
+
+	if r.cfg.IsDebugMode {
+		db = db.Debug()
+	}
+
+	return db.Where("id = ?", event.Event.ID).Updates(map[string]any{
+		"status":                event.Status,
+		"attempts":              event.Attempts,
+		"completed_subscribers": string(completedSubscribers),
+		"next_attempt_at":       event.NextAttemptAt,
+		"last_error":            event.LastError,
+		"dispatched_at":         dispatchedAt,
+	}).Error
+}
+
+func (r *repository) GetEvent(ctx context.Context, id int64) (resp entity.RecordEvent, err error) {
+	db := r.db.Model(&EventOutbox{})
+
+	if r.cfg.IsDebugMode {
+		db = db.Debug()
+	}
+
+	result := EventOutbox{}
+	if err := db.Where("id = ?", id).Limit(1).Find(&result).Error; err != nil {
+		return resp, err
+	}
+
+	if result.ID == 0 {
+		return resp, entity.ErrorNotFound
+	}
+
+	return result.ToEntity().Event, nil
+}
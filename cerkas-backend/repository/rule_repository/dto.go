@@ -0,0 +1,102 @@
+package rulerepository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/cerkas/cerkas-backend/core/entity"
+	"gorm.io/gorm"
+)
+
+type ObjectRule struct {
+	ID         int64          `gorm:"column:id;primaryKey" json:"id"`
+	Serial     string         `gorm:"column:serial" json:"serial"`
+	TenantCode string         `gorm:"column:tenant_code" json:"tenant_code"`
+	ObjectCode string         `gorm:"column:object_code" json:"object_code"`
+	Name       string         `gorm:"column:name" json:"name"`
+	Timing     string         `gorm:"column:timing" json:"timing"`
+	EventTypes string         `gorm:"column:event_types" json:"event_types"`
+	Condition  string         `gorm:"column:condition" json:"condition"`
+	Actions    string         `gorm:"column:actions" json:"actions"`
+	Sequence   int            `gorm:"column:sequence" json:"sequence"`
+	IsActive   bool           `gorm:"column:is_active" json:"is_active"`
+	CreatedBy  string         `gorm:"column:created_by" json:"created_by"`
+	CreatedAt  time.Time      `gorm:"column:created_at" json:"created_at"`
+	UpdatedBy  string         `gorm:"column:updated_by" json:"updated_by"`
+	UpdatedAt  time.Time      `gorm:"column:updated_at" json:"updated_at"`
+	DeletedBy  sql.NullString `gorm:"column:deleted_by" json:"deleted_by"`
+	DeletedAt  gorm.DeletedAt `gorm:"column:deleted_at" json:"deleted_at"`
+}
+
+func (or *ObjectRule) TableName() string {
+	return "object_rule"
+}
+
+func (or *ObjectRule) ToEntity() entity.ObjectRule {
+	// convert event types, condition and actions from string
+	eventTypes := []string{}
+	if err := json.Unmarshal([]byte(or.EventTypes), &eventTypes); err != nil {
+		eventTypes = nil
+	}
+
+	condition := []entity.FilterGroup{}
+	if err := json.Unmarshal([]byte(or.Condition), &condition); err != nil {
+		condition = nil
+	}
+
+	actions := []entity.RuleAction{}
+	if err := json.Unmarshal([]byte(or.Actions), &actions); err != nil {
+		actions = nil
+	}
+
+	return entity.ObjectRule{
+		ID:         or.ID,
+		Serial:     or.Serial,
+		TenantCode: or.TenantCode,
+		ObjectCode: or.ObjectCode,
+		Name:       or.Name,
+		Timing:     or.Timing,
+		EventTypes: eventTypes,
+		Condition:  condition,
+		Actions:    actions,
+		Sequence:   or.Sequence,
+		IsActive:   or.IsActive,
+		CreatedBy:  or.CreatedBy,
+		CreatedAt:  or.CreatedAt,
+	}
+}
+
+func NewObjectRule(rule entity.ObjectRule) (ObjectRule, error) {
+	eventTypes, err := json.Marshal(rule.EventTypes)
+	if err != nil {
+		return ObjectRule{}, err
+	}
+
+	condition, err := json.Marshal(rule.Condition)
+	if err != nil {
+		return ObjectRule{}, err
+	}
+
+	actions, err := json.Marshal(rule.Actions)
+	if err != nil {
+		return ObjectRule{}, err
+	}
+
+	return ObjectRule{
+		Serial:     rule.Serial,
+		TenantCode: rule.TenantCode,
+		ObjectCode: rule.ObjectCode,
+		Name:       rule.Name,
+		Timing:     rule.Timing,
+		EventTypes: string(eventTypes),
+		Condition:  string(condition),
+		Actions:    string(actions),
+		Sequence:   rule.Sequence,
+		IsActive:   rule.IsActive,
+		CreatedBy:  rule.CreatedBy,
+		CreatedAt:  rule.CreatedAt,
+		UpdatedBy:  rule.CreatedBy,
+		UpdatedAt:  rule.CreatedAt,
+	}, nil
+}
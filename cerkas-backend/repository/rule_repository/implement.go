@@ -0,0 +1,104 @@
+package rulerepository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cerkas/cerkas-backend/config"
+	"github.com/cerkas/cerkas-backend/core/entity"
+	repository_intf "github.com/cerkas/cerkas-backend/core/repository"
+	"github.com/cerkas/cerkas-backend/repository/util"
+	"gorm.io/gorm"
+)
+
+type repository struct {
+	cfg config.Config
+	db  *gorm.DB
+}
+
+func New(cfg config.Config, db *gorm.DB) repository_intf.RuleRepository {
+	return &repository{
+		cfg: cfg,
+		db:  db,
+	}
+}
+
+func (r *repository) CreateRule(ctx context.Context, rule entity.ObjectRule) (resp entity.ObjectRule, err error) {
+	record, err := NewObjectRule(rule)
+	if err != nil {
+		return resp, err
+	}
+
+	db := r.db.Model(&ObjectRule{})
+
+	if r.cfg.IsDebugMode {
+		db = db.Debug()
+	}
+
+	if err := db.Create(&record).Error; err != nil {
+		return resp, err
+	}
+
+	return record.ToEntity(), nil
+}
+
+func (r *repository) GetRules(ctx context.Context, tenantCode, objectCode string) (resp []entity.ObjectRule, err error) {
+	db := r.db.Model(&ObjectRule{})
+
+	if r.cfg.IsDebugMode {
+		db = db.Debug()
+	}
+
+	results := []ObjectRule{}
+	if err := db.Where("tenant_code = ? AND object_code = ?", tenantCode, objectCode).Order("timing ASC, sequence ASC, id ASC").Find(&results).Error; err != nil {
+		return resp, err
+	}
+
+	for _, result := range results {
+		resp = append(resp, result.ToEntity())
+	}
+
+	return resp, nil
+}
+
+// GetActiveRules returns the rules to run for a mutation in their order, it reads in the transaction of the mutation
+func (r *repository) GetActiveRules(ctx context.Context, tenantCode, objectCode, timing, eventType string) (resp []entity.ObjectRule, err error) {
+	db := util.ContextDB(ctx, r.db).Model(&ObjectRule{})
+
+	if r.cfg.IsDebugMode {
+		db = db.Debug()
+	}
+
+	results := []ObjectRule{}
+	if err := db.Where("tenant_code = ? AND object_code = ? AND timing = ? AND is_active = ?", tenantCode, objectCode, timing, true).
+		Where("event_types::jsonb @> ?::jsonb", fmt.Sprintf("[%q]", eventType)).
+		Order("sequence ASC, id ASC").
+		Find(&results).Error; err != nil {
+		return resp, err
+	}
+
+	for _, result := range results {
+		resp = append(resp, result.ToEntity())
+	}
+
+	return resp, nil
+}
+
+func (r *repository) DeleteRule(ctx context.Context, tenantCode, serial string) (err error) {
+	db := r.db.Model(&ObjectRule{})
+
+	if r.cfg.IsDebugMode {
+		db = db.Debug()
+	}
+
+	result := db.Where("tenant_code = ? AND serial = ?", tenantCode, serial).Delete(&ObjectRule{})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return entity.ErrorNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,143 @@
+package schedulerepository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/cerkas/cerkas-backend/core/entity"
+	"gorm.io/gorm"
+)
+
+type ScheduledJob struct {
+	ID             int64          `gorm:"column:id;primaryKey" json:"id"`
+	Serial         string         `gorm:"column:serial" json:"serial"`
+	TenantCode     string         `gorm:"column:tenant_code" json:"tenant_code"`
+	Name           string         `gorm:"column:name" json:"name"`
+	JobType        string         `gorm:"column:job_type" json:"job_type"`
+	CronExpression string         `gorm:"column:cron_expression" json:"cron_expression"`
+	Timezone       sql.NullString `gorm:"column:timezone" json:"timezone"`
+	Config         string         `gorm:"column:config" json:"config"`
+	IsActive       bool           `gorm:"column:is_active" json:"is_active"`
+	NextRunAt      time.Time      `gorm:"column:next_run_at" json:"next_run_at"`
+	LastRunAt      sql.NullTime   `gorm:"column:last_run_at" json:"last_run_at"`
+	CreatedBy      string         `gorm:"column:created_by" json:"created_by"`
+	CreatedAt      time.Time      `gorm:"column:created_at" json:"created_at"`
+	UpdatedBy      string         `gorm:"column:updated_by" json:"updated_by"`
+	UpdatedAt      time.Time      `gorm:"column:updated_at" json:"updated_at"`
+	DeletedBy      sql.NullString `gorm:"column:deleted_by" json:"deleted_by"`
+	DeletedAt      gorm.DeletedAt `gorm:"column:deleted_at" json:"deleted_at"`
+}
+
+func (sj *ScheduledJob) TableName() string {
+	return "scheduled_job"
+}
+
+func (sj *ScheduledJob) ToEntity() entity.ScheduledJob {
+	// convert config from string
+	config := entity.ScheduledJobConfig{}
+	if err := json.Unmarshal([]byte(sj.Config), &config); err != nil {
+		config = entity.ScheduledJobConfig{}
+	}
+
+	job := entity.ScheduledJob{
+		ID:             sj.ID,
+		Serial:         sj.Serial,
+		TenantCode:     sj.TenantCode,
+		Name:           sj.Name,
+		JobType:        sj.JobType,
+		CronExpression: sj.CronExpression,
+		Timezone:       sj.Timezone.String,
+		Config:         config,
+		IsActive:       sj.IsActive,
+		NextRunAt:      sj.NextRunAt,
+		CreatedBy:      sj.CreatedBy,
+		CreatedAt:      sj.CreatedAt,
+	}
+
+	if sj.LastRunAt.Valid {
+		job.LastRunAt = &sj.LastRunAt.Time
+	}
+
+	return job
+}
+
+func NewScheduledJob(job entity.ScheduledJob) (ScheduledJob, error) {
+	config, err := json.Marshal(job.Config)
+	if err != nil {
+		return ScheduledJob{}, err
+	}
+
+	return ScheduledJob{
+		Serial:         job.Serial,
+		TenantCode:     job.TenantCode,
+		Name:           job.Name,
+		JobType:        job.JobType,
+		CronExpression: job.CronExpression,
+		Timezone:       sql.NullString{String: job.Timezone, Valid: job.Timezone != ""},
+		Config:         string(config),
+		IsActive:       job.IsActive,
+		NextRunAt:      job.NextRunAt,
+		CreatedBy:      job.CreatedBy,
+		CreatedAt:      job.CreatedAt,
+		UpdatedBy:      job.CreatedBy,
+		UpdatedAt:      job.CreatedAt,
+	}, nil
+}
+
+type ScheduledJobRun struct {
+	ID         int64          `gorm:"column:id;primaryKey" json:"id"`
+	Serial     string         `gorm:"column:serial" json:"serial"`
+	JobSerial  string         `gorm:"column:job_serial" json:"job_serial"`
+	TenantCode string         `gorm:"column:tenant_code" json:"tenant_code"`
+	JobType    string         `gorm:"column:job_type" json:"job_type"`
+	Status     string         `gorm:"column:status" json:"status"`
+	Result     sql.NullString `gorm:"column:result" json:"result"`
+	Error      sql.NullString `gorm:"column:error" json:"error"`
+	StartedAt  time.Time      `gorm:"column:started_at" json:"started_at"`
+	FinishedAt sql.NullTime   `gorm:"column:finished_at" json:"finished_at"`
+}
+
+func (sjr *ScheduledJobRun) TableName() string {
+	return "scheduled_job_run"
+}
+
+func (sjr *ScheduledJobRun) ToEntity() entity.ScheduledJobRun {
+	run := entity.ScheduledJobRun{
+		ID:         sjr.ID,
+		Serial:     sjr.Serial,
+		JobSerial:  sjr.JobSerial,
+		TenantCode: sjr.TenantCode,
+		JobType:    sjr.JobType,
+		Status:     sjr.Status,
+		Result:     sjr.Result.String,
+		Error:      sjr.Error.String,
+		StartedAt:  sjr.StartedAt,
+	}
+
+	if sjr.FinishedAt.Valid {
+		run.FinishedAt = &sjr.FinishedAt.Time
+	}
+
+	return run
+}
+
+func NewScheduledJobRun(run entity.ScheduledJobRun) ScheduledJobRun {
+	record := ScheduledJobRun{
+		ID:         run.ID,
+		Serial:     run.Serial,
+		JobSerial:  run.JobSerial,
+		TenantCode: run.TenantCode,
+		JobType:    run.JobType,
+		Status:     run.Status,
+		Result:     sql.NullString{String: run.Result, Valid: run.Result != ""},
+		Error:      sql.NullString{String: run.Error, Valid: run.Error != ""},
+		StartedAt:  run.StartedAt,
+	}
+
+	if run.FinishedAt != nil {
+		record.FinishedAt = sql.NullTime{Time: *run.FinishedAt, Valid: true}
+	}
+
+	return record
+}
@@ -0,0 +1,191 @@
+package schedulerepository
+
+import (
+	"context"
+	"time"
+
+	"github.com/cerkas/cerkas-backend/config"
+	"github.com/cerkas/cerkas-backend/core/entity"
+	repository_intf "github.com/cerkas/cerkas-backend/core/repository"
+	"github.com/cerkas/cerkas-backend/pkg/helper"
+	"gorm.io/gorm"
+)
+
+type repository struct {
+	cfg config.Config
+	db  *gorm.DB
+}
+
+func New(cfg config.Config, db *gorm.DB) repository_intf.ScheduleRepository {
+	return &repository{
+		cfg: cfg,
+		db:  db,
+	}
+}
+
+func (r *repository) CreateJob(ctx context.Context, job entity.ScheduledJob) (resp entity.ScheduledJob, err error) {
+	record, err := NewScheduledJob(job)
+	if err != nil {
+		return resp, err
+	}
+
+	db := r.db.Model(&ScheduledJob{})
+
+	if r.cfg.IsDebugMode {
+		db = db.Debug()
+	}
+
+	if err := db.Create(&record).Error; err != nil {
+		return resp, err
+	}
+
+	return record.ToEntity(), nil
+}
+
+func (r *repository) GetJobs(ctx context.Context, tenantCode string) (resp []entity.ScheduledJob, err error) {
+	db := r.db.Model(&ScheduledJob{})
+
+	if r.cfg.IsDebugMode {
+		db = db.Debug()
+	}
+
+	results := []ScheduledJob{}
+	if err := db.Where("tenant_code = ?", tenantCode).Order("id ASC").Find(&results).Error; err != nil {
+		return resp, err
+	}
+
+	for _, result := range results {
+		resp = append(resp, result.ToEntity())
+	}
+
+	return resp, nil
+}
+
+func (r *repository) DeleteJob(ctx context.Context, tenantCode, serial string) (err error) {
+	db := r.db.Model(&ScheduledJob{})
+
+	if r.cfg.IsDebugMode {
+		db = db.Debug()
+	}
+
+	result := db.Where("tenant_code = ? AND serial = ?", tenantCode, serial).Delete(&ScheduledJob{})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return entity.ErrorNotFound
+	}
+
+	return nil
+}
+
+// GetDueJobs lists the active jobs of every tenant whose next run is due, the longest overdue first
+func (r *repository) GetDueJobs(ctx context.Context, now time.Time, limit int) (resp []entity.ScheduledJob, err error) {
+	db := r.db.Model(&ScheduledJob{})
+
+	if r.cfg.IsDebugMode {
+		db = db.Debug()
+	}
+
+	results := []ScheduledJob{}
+	if err := db.Where("is_active = ? AND next_run_at <= ?", true, now).Order("next_run_at ASC").Limit(limit).Find(&results).Error; err != nil {
+		return resp, err
+	}
+
+	for _, result := range results {
+		resp = append(resp, result.ToEntity())
+	}
+
+	return resp, nil
+}
+
+// AdvanceJob moves a job to its next run, only when the job is still due at the run it was read with. A replica that
+// finds the job already advanced must not run it again.
+func (r *repository) AdvanceJob(ctx context.Context, job entity.ScheduledJob, nextRunAt time.Time) (advanced bool, err error) {
+	db := r.db.Model(&ScheduledJob{})
+
+	if r.cfg.IsDebugMode {
+		db = db.Debug()
+	}
+
+	now := time.Now()
+	result := db.Where("serial = ? AND next_run_at = ?", job.Serial, job.NextRunAt).Updates(map[string]any{
+		"next_run_at": nextRunAt,
+		"last_run_at": now,
+		"updated_at":  now,
+	})
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return result.RowsAffected == 1, nil
+}
+
+func (r *repository) CreateRun(ctx context.Context, run entity.ScheduledJobRun) (resp entity.ScheduledJobRun, err error) {
+	record := NewScheduledJobRun(run)
+
+	db := r.db.Model(&ScheduledJobRun{})
+
+	if r.cfg.IsDebugMode {
+		db = db.Debug()
+	}
+
+	if err := db.Create(&record).Error; err != nil {
+		return resp, err
+	}
+
+	return record.ToEntity(), nil
+}
+
+func (r *repository) UpdateRun(ctx context.Context, run entity.ScheduledJobRun) (err error) {
+	record := NewScheduledJobRun(run)
+
+	db := r.db.Model(&ScheduledJobRun{})
+
+	if r.cfg.IsDebugMode {
+		db = db.Debug()
+	}
+
+	return db.Where("serial = ?", run.Serial).Updates(map[string]any{
+		"status":      record.Status,
+		"result":      record.Result,
+		"error":       record.Error,
+		"finished_at": record.FinishedAt,
+	}).Error
+}
+
+func (r *repository) GetRuns(ctx context.Context, request entity.ScheduledJobRunQuery) (resp []entity.ScheduledJobRun, total int64, err error) {
+	limit, offset, _, _ := helper.SetupListParameter(int32(request.Page), int32(request.PageSize))
+
+	db := r.db.Model(&ScheduledJobRun{})
+
+	if r.cfg.IsDebugMode {
+		db = db.Debug()
+	}
+
+	db = db.Where("tenant_code = ?", request.TenantCode)
+
+	if request.JobSerial != "" {
+		db = db.Where("job_serial = ?", request.JobSerial)
+	}
+
+	if request.Status != "" {
+		db = db.Where("status = ?", request.Status)
+	}
+
+	if err := db.Count(&total).Error; err != nil {
+		return resp, total, err
+	}
+
+	results := []ScheduledJobRun{}
+	if err := db.Order("id DESC").Limit(int(limit)).Offset(int(offset)).Find(&results).Error; err != nil {
+		return resp, total, err
+	}
+
+	for _, result := range results {
+		resp = append(resp, result.ToEntity())
+	}
+
+	return resp, total, nil
+}
@@ -0,0 +1,53 @@
+package util
+
+import (
+	"context"
+	"database/sql/driver"
+	"log"
+
+	repository_intf "github.com/cerkas/cerkas-backend/core/repository"
+	"gorm.io/gorm"
+)
+
+type advisoryLocker struct {
+	db *gorm.DB
+}
+
+// NewAdvisoryLocker returns a Locker on postgres session advisory locks. A lock lives on its own connection,
+// so the lock of a replica that dies is released with its connection.
+func NewAdvisoryLocker(db *gorm.DB) repository_intf.Locker {
+	return &advisoryLocker{
+		db: db,
+	}
+}
+
+func (l *advisoryLocker) TryLock(ctx context.Context, key string) (release func(), acquired bool, err error) {
+	sqlDB, err := l.db.DB()
+	if err != nil {
+		return nil, false, err
+	}
+
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock(hashtext($1))", key).Scan(&acquired); err != nil || !acquired {
+		conn.Close()
+		return nil, false, err
+	}
+
+	release = func() {
+		// the lock is released on the connection that took it, whatever the state of the caller context
+		if _, err := conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock(hashtext($1))", key); err != nil {
+			log.Printf("failed to release advisory lock %v: %v", key, err)
+
+			// a connection still holding the lock must not go back to the pool
+			conn.Raw(func(any) error { return driver.ErrBadConn })
+		}
+
+		conn.Close()
+	}
+
+	return release, true, nil
+}
@@ -0,0 +1,40 @@
+package util
+
+import (
+	"context"
+
+	repository_intf "github.com/cerkas/cerkas-backend/core/repository"
+	"gorm.io/gorm"
+)
+
+type txKey struct{}
+
+type transactor struct {
+	db *gorm.DB
+}
+
+func NewTransactor(db *gorm.DB) repository_intf.Transactor {
+	return &transactor{
+		db: db,
+	}
+}
+
+// WithinTransaction joins the transaction of ctx when there is one, otherwise it opens a new one
+func (t *transactor) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	if _, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		return fn(ctx)
+	}
+
+	return t.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(context.WithValue(ctx, txKey{}, tx))
+	})
+}
+
+// ContextDB returns the transaction carried by ctx, or db when there is none
+func ContextDB(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if tx, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		return tx
+	}
+
+	return db
+}
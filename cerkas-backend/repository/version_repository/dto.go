@@ -0,0 +1,50 @@
+package versionrepository
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/cerkas/cerkas-backend/core/entity"
+)
+
+type RecordVersion struct {
+	ID           int64     `gorm:"column:id;primaryKey" json:"id"`
+	Serial       string    `gorm:"column:serial" json:"serial"`
+	TenantCode   string    `gorm:"column:tenant_code" json:"tenant_code"`
+	ProductCode  string    `gorm:"column:product_code" json:"product_code"`
+	ObjectCode   string    `gorm:"column:object_code" json:"object_code"`
+	RecordSerial string    `gorm:"column:record_serial" json:"record_serial"`
+	Version      int       `gorm:"column:version" json:"version"`
+	Action       string    `gorm:"column:action" json:"action"`
+	ActorSerial  string    `gorm:"column:actor_serial" json:"actor_serial"`
+	IsDeleted    bool      `gorm:"column:is_deleted" json:"is_deleted"`
+	Data         string    `gorm:"column:data" json:"data"`
+	CreatedAt    time.Time `gorm:"column:created_at" json:"created_at"`
+}
+
+func (rv *RecordVersion) TableName() string {
+	return "record_version"
+}
+
+func (rv *RecordVersion) ToEntity() entity.RecordVersion {
+	// convert data from string to map
+	data := make(map[string]entity.DataItem)
+	if err := json.Unmarshal([]byte(rv.Data), &data); err != nil {
+		data = nil
+	}
+
+	return entity.RecordVersion{
+		ID:           rv.ID,
+		Serial:       rv.Serial,
+		TenantCode:   rv.TenantCode,
+		ProductCode:  rv.ProductCode,
+		ObjectCode:   rv.ObjectCode,
+		RecordSerial: rv.RecordSerial,
+		Version:      rv.Version,
+		Action:       rv.Action,
+		ActorSerial:  rv.ActorSerial,
+		IsDeleted:    rv.IsDeleted,
+		Data:         data,
+		CreatedAt:    rv.CreatedAt,
+	}
+}
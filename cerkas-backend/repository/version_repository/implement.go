@@ -0,0 +1,136 @@
+package versionrepository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/cerkas/cerkas-backend/config"
+	"github.com/cerkas/cerkas-backend/core/entity"
+	repository_intf "github.com/cerkas/cerkas-backend/core/repository"
+	"gorm.io/gorm"
+)
+
+type repository struct {
+	cfg config.Config
+	db  *gorm.DB
+}
+
+func New(cfg config.Config, db *gorm.DB) repository_intf.VersionRepository {
+	return &repository{
+		cfg: cfg,
+		db:  db,
+	}
+}
+
+// CreateRecordVersion stores a snapshot of a record, the version number is the next number of the record
+func (r *repository) CreateRecordVersion(ctx context.Context, version entity.RecordVersion) (err error) {
+	data, err := json.Marshal(version.Data)
+	if err != nil {
+		return err
+	}
+
+	query := `
+	INSERT INTO record_version (serial, tenant_code, product_code, object_code, record_serial, version, action, actor_serial, is_deleted, data, created_at)
+	SELECT ?, ?, ?, ?, ?, COALESCE(MAX(version), 0) + 1, ?, ?, ?, ?, ?
+	FROM record_version
+	WHERE tenant_code = ? AND object_code = ? AND record_serial = ?
+	ON CONFLICT (serial) DO NOTHING
+	`
+
+	db := r.db
+	if r.cfg.IsDebugMode {
+		db = db.Debug()
+	}
+
+	return db.Exec(query,
+		version.Serial, version.TenantCode, version.ProductCode, version.ObjectCode, version.RecordSerial,
+		version.Action, version.ActorSerial, version.IsDeleted, string(data), version.CreatedAt,
+		version.TenantCode, version.ObjectCode, version.RecordSerial,
+	).Error
+}
+
+func (r *repository) GetRecordVersions(ctx context.Context, tenantCode, objectCode, recordSerial string) (resp []entity.RecordVersion, err error) {
+	db := r.db.Model(&RecordVersion{})
+
+	if r.cfg.IsDebugMode {
+		db = db.Debug()
+	}
+
+	results := []RecordVersion{}
+	if err := db.Where("tenant_code = ? AND object_code = ? AND record_serial = ?", tenantCode, objectCode, recordSerial).Order("version ASC").Find(&results).Error; err != nil {
+		return resp, err
+	}
+
+	for _, result := range results {
+		resp = append(resp, result.ToEntity())
+	}
+
+	return resp, nil
+}
+
+func (r *repository) GetRecordVersion(ctx context.Context, tenantCode, objectCode, recordSerial string, version int) (resp entity.RecordVersion, err error) {
+	db := r.db.Model(&RecordVersion{})
+
+	if r.cfg.IsDebugMode {
+		db = db.Debug()
+	}
+
+	result := RecordVersion{}
+	if err := db.Where("tenant_code = ? AND object_code = ? AND record_serial = ? AND version = ?", tenantCode, objectCode, recordSerial, version).First(&result).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return resp, entity.ErrorNotFound
+		}
+
+		return resp, err
+	}
+
+	return result.ToEntity(), nil
+}
+
+// GetRecordVersionAsOf returns the latest version of a record created at or before asOf
+func (r *repository) GetRecordVersionAsOf(ctx context.Context, tenantCode, objectCode, recordSerial string, asOf time.Time) (resp entity.RecordVersion, err error) {
+	db := r.db.Model(&RecordVersion{})
+
+	if r.cfg.IsDebugMode {
+		db = db.Debug()
+	}
+
+	result := RecordVersion{}
+	if err := db.Where("tenant_code = ? AND object_code = ? AND record_serial = ? AND created_at <= ?", tenantCode, objectCode, recordSerial, asOf).Order("version DESC").First(&result).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return resp, entity.ErrorNotFound
+		}
+
+		return resp, err
+	}
+
+	return result.ToEntity(), nil
+}
+
+// GetObjectVersionsAsOf returns the latest version of every record of an object created at or before asOf
+func (r *repository) GetObjectVersionsAsOf(ctx context.Context, tenantCode, objectCode string, asOf time.Time) (resp []entity.RecordVersion, err error) {
+	query := `
+	SELECT DISTINCT ON (record_serial) *
+	FROM record_version
+	WHERE tenant_code = ? AND object_code = ? AND created_at <= ?
+	ORDER BY record_serial, version DESC
+	`
+
+	db := r.db
+	if r.cfg.IsDebugMode {
+		db = db.Debug()
+	}
+
+	results := []RecordVersion{}
+	if err := db.Raw(query, tenantCode, objectCode, asOf).Scan(&results).Error; err != nil {
+		return resp, err
+	}
+
+	for _, result := range results {
+		resp = append(resp, result.ToEntity())
+	}
+
+	return resp, nil
+}
@@ -1,10 +1,215 @@
 package viewrepository
 
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/cerkas/cerkas-backend/core/entity"
+	"gorm.io/gorm"
+)
+
 type ViewLayout struct {
+	ID           int64          `gorm:"column:id;primaryKey" json:"id"`
+	Serial       string         `gorm:"column:serial" json:"serial"`
+	Code         string         `gorm:"column:code" json:"code"`
+	LayoutConfig string         `gorm:"column:layout_config" json:"layout_config"`
+	CreatedBy    string         `gorm:"column:created_by" json:"created_by"`
+	CreatedAt    time.Time      `gorm:"column:created_at" json:"created_at"`
+	UpdatedBy    string         `gorm:"column:updated_by" json:"updated_by"`
+	UpdatedAt    time.Time      `gorm:"column:updated_at" json:"updated_at"`
+	DeletedBy    sql.NullString `gorm:"column:deleted_by" json:"deleted_by"`
+	DeletedAt    gorm.DeletedAt `gorm:"column:deleted_at" json:"deleted_at"`
+}
+
+func (vl *ViewLayout) TableName() string {
+	return "view_layout"
+}
+
+func (vl *ViewLayout) ToEntity() entity.ViewLayout {
+	// convert layout config from string to map
+	layoutConfig := make(map[string]any)
+	if err := json.Unmarshal([]byte(vl.LayoutConfig), &layoutConfig); err != nil {
+		layoutConfig = nil
+	}
+
+	return entity.ViewLayout{
+		Serial:       vl.Serial,
+		Code:         vl.Code,
+		LayoutConfig: layoutConfig,
+	}
+}
+
+func NewViewLayout(layout entity.ViewLayout) (ViewLayout, error) {
+	layoutConfig, err := json.Marshal(layout.LayoutConfig)
+	if err != nil {
+		return ViewLayout{}, err
+	}
+
+	return ViewLayout{
+		Serial:       layout.Serial,
+		Code:         layout.Code,
+		LayoutConfig: string(layoutConfig),
+	}, nil
 }
 
 type ViewSchema struct {
+	ID            int64          `gorm:"column:id;primaryKey" json:"id"`
+	Serial        string         `gorm:"column:serial" json:"serial"`
+	Code          string         `gorm:"column:code" json:"code"`
+	Name          string         `gorm:"column:name" json:"name"`
+	Query         string         `gorm:"column:query" json:"query"`
+	DisplayField  string         `gorm:"column:display_field" json:"display_field"`
+	StructureType string         `gorm:"column:structure_type" json:"structure_type"`
+	ActionSerial  sql.NullString `gorm:"column:action_serial" json:"action_serial"`
+	IsFavorite    bool           `gorm:"column:is_favorite" json:"is_favorite"`
+	ObjectSerial  string         `gorm:"column:object_serial" json:"object_serial"`
+	FieldSections string         `gorm:"column:field_sections" json:"field_sections"`
+	CreatedBy     string         `gorm:"column:created_by" json:"created_by"`
+	CreatedAt     time.Time      `gorm:"column:created_at" json:"created_at"`
+	UpdatedBy     string         `gorm:"column:updated_by" json:"updated_by"`
+	UpdatedAt     time.Time      `gorm:"column:updated_at" json:"updated_at"`
+	DeletedBy     sql.NullString `gorm:"column:deleted_by" json:"deleted_by"`
+	DeletedAt     gorm.DeletedAt `gorm:"column:deleted_at" json:"deleted_at"`
+}
+
+func (vs *ViewSchema) TableName() string {
+	return "view_schema"
+}
+
+func (vs *ViewSchema) ToEntity() entity.ViewSchema {
+	// convert query, display field and field sections from string to map
+	query := make(map[string]any)
+	if err := json.Unmarshal([]byte(vs.Query), &query); err != nil {
+		query = nil
+	}
+
+	displayField := make(map[string]any)
+	if err := json.Unmarshal([]byte(vs.DisplayField), &displayField); err != nil {
+		displayField = nil
+	}
+
+	fieldSections := make(map[string]any)
+	if err := json.Unmarshal([]byte(vs.FieldSections), &fieldSections); err != nil {
+		fieldSections = nil
+	}
+
+	return entity.ViewSchema{
+		Serial:        vs.Serial,
+		Code:          vs.Code,
+		Name:          vs.Name,
+		Query:         query,
+		DisplayField:  displayField,
+		StructureType: vs.StructureType,
+		ActionSerial:  vs.ActionSerial.String,
+		IsFavorite:    vs.IsFavorite,
+		ObjectSerial:  vs.ObjectSerial,
+		FieldSections: fieldSections,
+	}
+}
+
+func NewViewSchema(schema entity.ViewSchema) (ViewSchema, error) {
+	query, err := json.Marshal(schema.Query)
+	if err != nil {
+		return ViewSchema{}, err
+	}
+
+	displayField, err := json.Marshal(schema.DisplayField)
+	if err != nil {
+		return ViewSchema{}, err
+	}
+
+	fieldSections, err := json.Marshal(schema.FieldSections)
+	if err != nil {
+		return ViewSchema{}, err
+	}
+
+	return ViewSchema{
+		Serial:        schema.Serial,
+		Code:          schema.Code,
+		Name:          schema.Name,
+		Query:         string(query),
+		DisplayField:  string(displayField),
+		StructureType: schema.StructureType,
+		ActionSerial:  sql.NullString{String: schema.ActionSerial, Valid: schema.ActionSerial != ""},
+		IsFavorite:    schema.IsFavorite,
+		ObjectSerial:  schema.ObjectSerial,
+		FieldSections: string(fieldSections),
+	}, nil
 }
 
 type ViewContent struct {
+	ID               int64          `gorm:"column:id;primaryKey" json:"id"`
+	Serial           string         `gorm:"column:serial" json:"serial"`
+	Code             string         `gorm:"column:code" json:"code"`
+	Name             string         `gorm:"column:name" json:"name"`
+	TenantCode       string         `gorm:"column:tenant_code" json:"tenant_code"`
+	ProductCode      string         `gorm:"column:product_code" json:"product_code"`
+	ObjectCode       string         `gorm:"column:object_code" json:"object_code"`
+	OwnerSerial      sql.NullString `gorm:"column:owner_serial" json:"owner_serial"`
+	SharedRole       sql.NullString `gorm:"column:shared_role" json:"shared_role"`
+	ViewSchemaSerial sql.NullString `gorm:"column:view_schema_serial" json:"view_schema_serial"`
+	ViewLayoutSerial sql.NullString `gorm:"column:view_layout_serial" json:"view_layout_serial"`
+	LayoutType       string         `gorm:"column:layout_type" json:"layout_type"`
+	IsDefault        bool           `gorm:"column:is_default" json:"is_default"`
+	IsShownInList    bool           `gorm:"column:is_shown_in_list" json:"is_shown_in_list"`
+	CreatedBy        string         `gorm:"column:created_by" json:"created_by"`
+	CreatedAt        time.Time      `gorm:"column:created_at" json:"created_at"`
+	UpdatedBy        string         `gorm:"column:updated_by" json:"updated_by"`
+	UpdatedAt        time.Time      `gorm:"column:updated_at" json:"updated_at"`
+	DeletedBy        sql.NullString `gorm:"column:deleted_by" json:"deleted_by"`
+	DeletedAt        gorm.DeletedAt `gorm:"column:deleted_at" json:"deleted_at"`
+}
+
+func (vc *ViewContent) TableName() string {
+	return "view_content"
+}
+
+func (vc *ViewContent) ToEntity() entity.ViewContentRecord {
+	return entity.ViewContentRecord{
+		Serial:           vc.Serial,
+		Code:             vc.Code,
+		Name:             vc.Name,
+		TenantCode:       vc.TenantCode,
+		ProductCode:      vc.ProductCode,
+		ObjectCode:       vc.ObjectCode,
+		OwnerSerial:      vc.OwnerSerial.String,
+		SharedRole:       vc.SharedRole.String,
+		ViewSchemaSerial: vc.ViewSchemaSerial.String,
+		ViewLayoutSerial: vc.ViewLayoutSerial.String,
+		LayoutType:       vc.LayoutType,
+		IsDefault:        vc.IsDefault,
+		IsShownInList:    vc.IsShownInList,
+		CreatedAt:        &vc.CreatedAt,
+		UpdatedAt:        &vc.UpdatedAt,
+	}
+}
+
+func NewViewContent(record entity.ViewContentRecord) ViewContent {
+	return ViewContent{
+		Serial:           record.Serial,
+		Code:             record.Code,
+		Name:             record.Name,
+		TenantCode:       record.TenantCode,
+		ProductCode:      record.ProductCode,
+		ObjectCode:       record.ObjectCode,
+		OwnerSerial:      sql.NullString{String: record.OwnerSerial, Valid: record.OwnerSerial != ""},
+		SharedRole:       sql.NullString{String: record.SharedRole, Valid: record.SharedRole != ""},
+		ViewSchemaSerial: sql.NullString{String: record.ViewSchemaSerial, Valid: record.ViewSchemaSerial != ""},
+		ViewLayoutSerial: sql.NullString{String: record.ViewLayoutSerial, Valid: record.ViewLayoutSerial != ""},
+		LayoutType:       record.LayoutType,
+		IsDefault:        record.IsDefault,
+		IsShownInList:    record.IsShownInList,
+	}
+}
+
+type ViewFavorite struct {
+	ID                int64     `gorm:"column:id;primaryKey" json:"id"`
+	UserSerial        string    `gorm:"column:user_serial" json:"user_serial"`
+	ViewContentSerial string    `gorm:"column:view_content_serial" json:"view_content_serial"`
+	CreatedAt         time.Time `gorm:"column:created_at" json:"created_at"`
+}
+
+func (vf *ViewFavorite) TableName() string {
+	return "view_favorite"
 }
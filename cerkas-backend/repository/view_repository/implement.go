@@ -2,12 +2,16 @@ package viewrepository
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/cerkas/cerkas-backend/config"
 	"github.com/cerkas/cerkas-backend/pkg/helper"
 	"github.com/cerkas/cerkas-backend/repository/util"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
 	"github.com/cerkas/cerkas-backend/core/entity"
 	repository_intf "github.com/cerkas/cerkas-backend/core/repository"
@@ -25,49 +29,138 @@ func New(db *gorm.DB, cfg config.Config) repository_intf.ViewRepository {
 	}
 }
 
+// GetViewContentByKeys returns the view of the most specific layer matching request. A layer without a view schema or a
+// view layout inherits the one of the next layer down.
 func (r *repository) GetViewContentByKeys(ctx context.Context, request entity.GetViewContentByKeysRequest) (resp map[string]entity.DataItem, err error) {
-	tenantCode := request.TenantCode
-	if tenantCode == "" {
-		tenantCode = "NULL"
-	} else {
-		tenantCode = fmt.Sprintf("'%s'", tenantCode)
+	chain, err := r.GetViewContentChain(ctx, request)
+	if err != nil || len(chain) == 0 {
+		return resp, err
 	}
 
-	productCode := request.ProductCode
-	if productCode == "" {
-		productCode = "NULL"
-	} else {
-		productCode = fmt.Sprintf("'%s'", productCode)
+	db := util.ContextDB(ctx, r.db)
+
+	if r.cfg.IsDebugMode {
+		db = db.Debug()
 	}
 
-	objectCode := request.ObjectCode
-	if objectCode == "" {
-		objectCode = "NULL"
-	} else {
-		objectCode = fmt.Sprintf("'%s'", objectCode)
+	rows, err := db.Raw("SELECT * FROM view_content WHERE serial = ?", chain[0].Serial).Rows()
+	if err != nil {
+		return resp, err
 	}
+	defer rows.Close()
 
-	viewContentCode := request.ViewContentCode
-	if viewContentCode == "" {
-		viewContentCode = "'default'"
-	} else {
-		viewContentCode = fmt.Sprintf("'%s'", viewContentCode)
+	resp, err = readViewContent(rows, request)
+	if err != nil || resp == nil {
+		return resp, err
+	}
+
+	inherited := map[string]string{}
+	for i := len(chain) - 1; i >= 0; i-- {
+		if chain[i].ViewSchemaSerial != "" {
+			inherited[entity.VIEW_SCHEMA_SERIAL] = chain[i].ViewSchemaSerial
+		}
+
+		if chain[i].ViewLayoutSerial != "" {
+			inherited[entity.VIEW_LAYOUT_SERIAL] = chain[i].ViewLayoutSerial
+		}
 	}
 
+	for key, serial := range inherited {
+		item := resp[key]
+		item.Value = serial
+		resp[key] = item
+	}
+
+	return resp, nil
+}
+
+// GetViewContentChain returns the view of every layer matching request, from the most specific layer to the global one.
+// A layer holds the view with the requested code, or its default view when the code is empty or "default". The user layer
+// holds the views of the user and the views shared with the role of the user, the own views win.
+func (r *repository) GetViewContentChain(ctx context.Context, request entity.GetViewContentByKeysRequest) (resp []entity.ViewContentRecord, err error) {
 	layoutType := request.LayoutType
 	if layoutType == "" {
-		layoutType = "'record'"
+		layoutType = "record"
+	}
+
+	isDefault := request.ViewContentCode == "" || request.ViewContentCode == "default"
+
+	db := util.ContextDB(ctx, r.db).Model(&ViewContent{})
+
+	if r.cfg.IsDebugMode {
+		db = db.Debug()
+	}
+
+	db = db.Where("object_code = ? AND layout_type = ?", request.ObjectCode, layoutType).
+		Where("COALESCE(tenant_code, '') IN ('', ?) AND COALESCE(product_code, '') IN ('', ?)", request.TenantCode, request.ProductCode).
+		Where("code = ? OR (? AND is_default)", request.ViewContentCode, isDefault)
+	if request.UserSerial != "" {
+		db = db.Where("COALESCE(owner_serial, '') = '' OR owner_serial = ? OR (COALESCE(shared_role, '') <> '' AND shared_role = ?)", request.UserSerial, request.UserRole)
 	} else {
-		layoutType = fmt.Sprintf("'%s'", layoutType)
+		db = db.Where("COALESCE(owner_serial, '') = ''")
 	}
 
-	query := fmt.Sprintf("SELECT * FROM get_view_content_all(%s, %s, %s, %s, %s)", tenantCode, productCode, objectCode, viewContentCode, layoutType)
-	rows, err := r.db.Raw(query).Rows()
-	if err != nil {
+	results := []ViewContent{}
+	if err := db.Order("id ASC").Find(&results).Error; err != nil {
 		return resp, err
 	}
-	defer rows.Close()
 
+	// a view with the requested code beats the default view of its layer, an own view beats a shared one
+	rank := func(result ViewContent) int {
+		score := 0
+		if result.Code == request.ViewContentCode {
+			score += 2
+		}
+
+		if result.OwnerSerial.String == request.UserSerial {
+			score++
+		}
+
+		return score
+	}
+
+	layers := map[string]ViewContent{}
+	for _, result := range results {
+		layer := viewLayer(result)
+		if current, ok := layers[layer]; ok && rank(result) <= rank(current) {
+			continue
+		}
+
+		layers[layer] = result
+	}
+
+	for _, layer := range entity.ViewLayers {
+		result, ok := layers[layer]
+		if !ok {
+			continue
+		}
+
+		record := result.ToEntity()
+		record.Layer = layer
+		resp = append(resp, record)
+	}
+
+	return resp, nil
+}
+
+// viewLayer names the layer a view belongs to
+func viewLayer(result ViewContent) string {
+	switch {
+	case result.OwnerSerial.String != "":
+		return entity.ViewLayerUser
+	case result.TenantCode != "" && result.ProductCode != "":
+		return entity.ViewLayerTenantProduct
+	case result.TenantCode != "":
+		return entity.ViewLayerTenant
+	case result.ProductCode != "":
+		return entity.ViewLayerProduct
+	default:
+		return entity.ViewLayerGlobal
+	}
+}
+
+// readViewContent reads the last view content row of rows into data items
+func readViewContent(rows *sql.Rows, request entity.GetViewContentByKeysRequest) (resp map[string]entity.DataItem, err error) {
 	// Get column names
 	columnNames, err := rows.Columns()
 	if err != nil {
@@ -110,3 +203,388 @@ func (r *repository) GetViewContentByKeys(ctx context.Context, request entity.Ge
 
 	return resp, nil
 }
+
+// GetViewContents lists the views of an object owned by ownerSerial, an empty owner lists the shared views
+func (r *repository) GetViewContents(ctx context.Context, tenantCode, productCode, objectCode, layoutType, ownerSerial string) (resp []entity.ViewContentRecord, err error) {
+	db := util.ContextDB(ctx, r.db).Model(&ViewContent{})
+
+	if r.cfg.IsDebugMode {
+		db = db.Debug()
+	}
+
+	db = db.Where("tenant_code = ? AND product_code = ? AND object_code = ?", tenantCode, productCode, objectCode).
+		Where("COALESCE(owner_serial, '') = ?", ownerSerial)
+	if layoutType != "" {
+		db = db.Where("layout_type = ?", layoutType)
+	}
+
+	results := []ViewContent{}
+	if err := db.Order("id ASC").Find(&results).Error; err != nil {
+		return resp, err
+	}
+
+	for _, result := range results {
+		resp = append(resp, result.ToEntity())
+	}
+
+	return resp, nil
+}
+
+func (r *repository) GetViewContent(ctx context.Context, tenantCode, productCode, objectCode, code, layoutType string) (resp entity.ViewContentRecord, err error) {
+	db := util.ContextDB(ctx, r.db).Model(&ViewContent{})
+
+	if r.cfg.IsDebugMode {
+		db = db.Debug()
+	}
+
+	result := ViewContent{}
+	if err := db.Where("tenant_code = ? AND product_code = ? AND object_code = ? AND code = ? AND layout_type = ?", tenantCode, productCode, objectCode, code, layoutType).First(&result).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return resp, entity.ErrorNotFound
+		}
+
+		return resp, err
+	}
+
+	return result.ToEntity(), nil
+}
+
+func (r *repository) CreateViewContent(ctx context.Context, record entity.ViewContentRecord, userSerial string) (resp entity.ViewContentRecord, err error) {
+	db := util.ContextDB(ctx, r.db).Model(&ViewContent{})
+
+	if r.cfg.IsDebugMode {
+		db = db.Debug()
+	}
+
+	result := NewViewContent(record)
+	result.CreatedBy = userSerial
+	result.UpdatedBy = userSerial
+
+	if err := db.Create(&result).Error; err != nil {
+		return resp, err
+	}
+
+	return result.ToEntity(), nil
+}
+
+func (r *repository) UpdateViewContent(ctx context.Context, record entity.ViewContentRecord, userSerial string) (resp entity.ViewContentRecord, err error) {
+	db := util.ContextDB(ctx, r.db).Model(&ViewContent{})
+
+	if r.cfg.IsDebugMode {
+		db = db.Debug()
+	}
+
+	result := NewViewContent(record)
+	result.UpdatedBy = userSerial
+	result.UpdatedAt = time.Now()
+
+	update := db.Where("serial = ?", record.Serial).
+		Select("code", "name", "owner_serial", "shared_role", "view_schema_serial", "view_layout_serial", "is_default", "is_shown_in_list", "updated_by", "updated_at").
+		Updates(&result)
+	if update.Error != nil {
+		return resp, update.Error
+	}
+
+	if update.RowsAffected == 0 {
+		return resp, entity.ErrorNotFound
+	}
+
+	return r.GetViewContent(ctx, record.TenantCode, record.ProductCode, record.ObjectCode, record.Code, record.LayoutType)
+}
+
+func (r *repository) DeleteViewContent(ctx context.Context, serial, userSerial string) (err error) {
+	return r.softDelete(ctx, &ViewContent{}, serial, userSerial)
+}
+
+// SetDefaultViewContent makes a view the only default of its layout type, among the shared views or among the views of its owner
+func (r *repository) SetDefaultViewContent(ctx context.Context, record entity.ViewContentRecord, userSerial string) (err error) {
+	db := util.ContextDB(ctx, r.db).Model(&ViewContent{})
+
+	if r.cfg.IsDebugMode {
+		db = db.Debug()
+	}
+
+	updates := map[string]any{
+		"is_default": false,
+		"updated_by": userSerial,
+		"updated_at": time.Now(),
+	}
+
+	err = db.Where("tenant_code = ? AND product_code = ? AND object_code = ? AND layout_type = ? AND is_default = ? AND serial <> ?", record.TenantCode, record.ProductCode, record.ObjectCode, record.LayoutType, true, record.Serial).
+		Where("COALESCE(owner_serial, '') = ?", record.OwnerSerial).
+		Updates(updates).Error
+	if err != nil {
+		return err
+	}
+
+	updates["is_default"] = true
+
+	db = util.ContextDB(ctx, r.db).Model(&ViewContent{})
+
+	if r.cfg.IsDebugMode {
+		db = db.Debug()
+	}
+
+	return db.Where("serial = ?", record.Serial).Updates(updates).Error
+}
+
+// GetAvailableViewContents lists the views of an object a user can open: the shared views, the own views of the user
+// and the views shared with the role of the user
+func (r *repository) GetAvailableViewContents(ctx context.Context, tenantCode, productCode, objectCode, userSerial, userRole string) (resp []entity.ViewContentRecord, err error) {
+	db := util.ContextDB(ctx, r.db).Model(&ViewContent{})
+
+	if r.cfg.IsDebugMode {
+		db = db.Debug()
+	}
+
+	results := []ViewContent{}
+	err = db.Where("tenant_code = ? AND product_code = ? AND object_code = ?", tenantCode, productCode, objectCode).
+		Where("COALESCE(owner_serial, '') = '' OR owner_serial = ? OR (COALESCE(shared_role, '') <> '' AND shared_role = ?)", userSerial, userRole).
+		Order("layout_type ASC, id ASC").
+		Find(&results).Error
+	if err != nil {
+		return resp, err
+	}
+
+	for _, result := range results {
+		resp = append(resp, result.ToEntity())
+	}
+
+	return resp, nil
+}
+
+func (r *repository) GetFavoriteViewSerials(ctx context.Context, userSerial string) (resp []string, err error) {
+	db := util.ContextDB(ctx, r.db).Model(&ViewFavorite{})
+
+	if r.cfg.IsDebugMode {
+		db = db.Debug()
+	}
+
+	if err := db.Where("user_serial = ?", userSerial).Pluck("view_content_serial", &resp).Error; err != nil {
+		return resp, err
+	}
+
+	return resp, nil
+}
+
+func (r *repository) AddViewFavorite(ctx context.Context, userSerial, viewContentSerial string) (err error) {
+	db := util.ContextDB(ctx, r.db).Model(&ViewFavorite{})
+
+	if r.cfg.IsDebugMode {
+		db = db.Debug()
+	}
+
+	favorite := ViewFavorite{
+		UserSerial:        userSerial,
+		ViewContentSerial: viewContentSerial,
+		CreatedAt:         time.Now(),
+	}
+
+	return db.Clauses(clause.OnConflict{DoNothing: true}).Create(&favorite).Error
+}
+
+func (r *repository) RemoveViewFavorite(ctx context.Context, userSerial, viewContentSerial string) (err error) {
+	db := util.ContextDB(ctx, r.db).Model(&ViewFavorite{})
+
+	if r.cfg.IsDebugMode {
+		db = db.Debug()
+	}
+
+	return db.Where("user_serial = ? AND view_content_serial = ?", userSerial, viewContentSerial).Delete(&ViewFavorite{}).Error
+}
+
+func (r *repository) IsViewSchemaUsed(ctx context.Context, serial string) (used bool, err error) {
+	return r.isViewContentReference(ctx, "view_schema_serial", serial)
+}
+
+func (r *repository) IsViewLayoutUsed(ctx context.Context, serial string) (used bool, err error) {
+	return r.isViewContentReference(ctx, "view_layout_serial", serial)
+}
+
+func (r *repository) GetViewSchema(ctx context.Context, serial string) (resp entity.ViewSchema, err error) {
+	db := util.ContextDB(ctx, r.db).Model(&ViewSchema{})
+
+	if r.cfg.IsDebugMode {
+		db = db.Debug()
+	}
+
+	result := ViewSchema{}
+	if err := db.Where("serial = ?", serial).First(&result).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return resp, entity.ErrorNotFound
+		}
+
+		return resp, err
+	}
+
+	return result.ToEntity(), nil
+}
+
+func (r *repository) CreateViewSchema(ctx context.Context, schema entity.ViewSchema, userSerial string) (resp entity.ViewSchema, err error) {
+	result, err := NewViewSchema(schema)
+	if err != nil {
+		return resp, err
+	}
+
+	db := util.ContextDB(ctx, r.db).Model(&ViewSchema{})
+
+	if r.cfg.IsDebugMode {
+		db = db.Debug()
+	}
+
+	result.CreatedBy = userSerial
+	result.UpdatedBy = userSerial
+
+	if err := db.Create(&result).Error; err != nil {
+		return resp, err
+	}
+
+	return result.ToEntity(), nil
+}
+
+func (r *repository) UpdateViewSchema(ctx context.Context, schema entity.ViewSchema, userSerial string) (resp entity.ViewSchema, err error) {
+	result, err := NewViewSchema(schema)
+	if err != nil {
+		return resp, err
+	}
+
+	db := util.ContextDB(ctx, r.db).Model(&ViewSchema{})
+
+	if r.cfg.IsDebugMode {
+		db = db.Debug()
+	}
+
+	result.UpdatedBy = userSerial
+	result.UpdatedAt = time.Now()
+
+	update := db.Where("serial = ?", schema.Serial).
+		Select("code", "name", "query", "display_field", "structure_type", "action_serial", "is_favorite", "field_sections", "updated_by", "updated_at").
+		Updates(&result)
+	if update.Error != nil {
+		return resp, update.Error
+	}
+
+	if update.RowsAffected == 0 {
+		return resp, entity.ErrorNotFound
+	}
+
+	return r.GetViewSchema(ctx, schema.Serial)
+}
+
+func (r *repository) DeleteViewSchema(ctx context.Context, serial, userSerial string) (err error) {
+	return r.softDelete(ctx, &ViewSchema{}, serial, userSerial)
+}
+
+func (r *repository) GetViewLayout(ctx context.Context, serial string) (resp entity.ViewLayout, err error) {
+	db := util.ContextDB(ctx, r.db).Model(&ViewLayout{})
+
+	if r.cfg.IsDebugMode {
+		db = db.Debug()
+	}
+
+	result := ViewLayout{}
+	if err := db.Where("serial = ?", serial).First(&result).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return resp, entity.ErrorNotFound
+		}
+
+		return resp, err
+	}
+
+	return result.ToEntity(), nil
+}
+
+func (r *repository) CreateViewLayout(ctx context.Context, layout entity.ViewLayout, userSerial string) (resp entity.ViewLayout, err error) {
+	result, err := NewViewLayout(layout)
+	if err != nil {
+		return resp, err
+	}
+
+	db := util.ContextDB(ctx, r.db).Model(&ViewLayout{})
+
+	if r.cfg.IsDebugMode {
+		db = db.Debug()
+	}
+
+	result.CreatedBy = userSerial
+	result.UpdatedBy = userSerial
+
+	if err := db.Create(&result).Error; err != nil {
+		return resp, err
+	}
+
+	return result.ToEntity(), nil
+}
+
+func (r *repository) UpdateViewLayout(ctx context.Context, layout entity.ViewLayout, userSerial string) (resp entity.ViewLayout, err error) {
+	result, err := NewViewLayout(layout)
+	if err != nil {
+		return resp, err
+	}
+
+	db := util.ContextDB(ctx, r.db).Model(&ViewLayout{})
+
+	if r.cfg.IsDebugMode {
+		db = db.Debug()
+	}
+
+	result.UpdatedBy = userSerial
+	result.UpdatedAt = time.Now()
+
+	update := db.Where("serial = ?", layout.Serial).
+		Select("code", "layout_config", "updated_by", "updated_at").
+		Updates(&result)
+	if update.Error != nil {
+		return resp, update.Error
+	}
+
+	if update.RowsAffected == 0 {
+		return resp, entity.ErrorNotFound
+	}
+
+	return r.GetViewLayout(ctx, layout.Serial)
+}
+
+func (r *repository) DeleteViewLayout(ctx context.Context, serial, userSerial string) (err error) {
+	return r.softDelete(ctx, &ViewLayout{}, serial, userSerial)
+}
+
+// local function
+
+// softDelete marks a view row as deleted, model is one of the view dto
+func (r *repository) softDelete(ctx context.Context, model any, serial, userSerial string) (err error) {
+	db := util.ContextDB(ctx, r.db).Model(model)
+
+	if r.cfg.IsDebugMode {
+		db = db.Debug()
+	}
+
+	result := db.Where("serial = ?", serial).Updates(map[string]any{
+		"deleted_by": userSerial,
+		"deleted_at": time.Now(),
+	})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return entity.ErrorNotFound
+	}
+
+	return nil
+}
+
+func (r *repository) isViewContentReference(ctx context.Context, column, serial string) (used bool, err error) {
+	db := util.ContextDB(ctx, r.db).Model(&ViewContent{})
+
+	if r.cfg.IsDebugMode {
+		db = db.Debug()
+	}
+
+	var count int64
+	if err := db.Where(fmt.Sprintf("%v = ?", column), serial).Count(&count).Error; err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
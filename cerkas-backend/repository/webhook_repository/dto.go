@@ -0,0 +1,155 @@
+package webhookrepository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/cerkas/cerkas-backend/core/entity"
+	"gorm.io/gorm"
+)
+
+type WebhookSubscription struct {
+	ID         int64          `gorm:"column:id;primaryKey" json:"id"`
+	Serial     string         `gorm:"column:serial" json:"serial"`
+	TenantCode string         `gorm:"column:tenant_code" json:"tenant_code"`
+	ObjectCode string         `gorm:"column:object_code" json:"object_code"`
+	EventTypes string         `gorm:"column:event_types" json:"event_types"`
+	URL        string         `gorm:"column:url" json:"url"`
+	Secret     string         `gorm:"column:secret" json:"secret"`
+	Filters    string         `gorm:"column:filters" json:"filters"`
+	IsActive   bool           `gorm:"column:is_active" json:"is_active"`
+	CreatedBy  string         `gorm:"column:created_by" json:"created_by"`
+	CreatedAt  time.Time      `gorm:"column:created_at" json:"created_at"`
+	UpdatedBy  string         `gorm:"column:updated_by" json:"updated_by"`
+	UpdatedAt  time.Time      `gorm:"column:updated_at" json:"updated_at"`
+	DeletedBy  sql.NullString `gorm:"column:deleted_by" json:"deleted_by"`
+	DeletedAt  gorm.DeletedAt `gorm:"column:deleted_at" json:"deleted_at"`
+}
+
+func (ws *WebhookSubscription) TableName() string {
+	return "webhook_subscription"
+}
+
+func (ws *WebhookSubscription) ToEntity() entity.WebhookSubscription {
+	// convert event types and filters from string
+	eventTypes := []string{}
+	if err := json.Unmarshal([]byte(ws.EventTypes), &eventTypes); err != nil {
+		eventTypes = nil
+	}
+
+	filters := []entity.FilterGroup{}
+	if err := json.Unmarshal([]byte(ws.Filters), &filters); err != nil {
+		filters = nil
+	}
+
+	return entity.WebhookSubscription{
+		ID:         ws.ID,
+		Serial:     ws.Serial,
+		TenantCode: ws.TenantCode,
+		ObjectCode: ws.ObjectCode,
+		EventTypes: eventTypes,
+		URL:        ws.URL,
+		Secret:     ws.Secret,
+		Filters:    filters,
+		IsActive:   ws.IsActive,
+		CreatedBy:  ws.CreatedBy,
+		CreatedAt:  ws.CreatedAt,
+	}
+}
+
+func NewWebhookSubscription(subscription entity.WebhookSubscription) (WebhookSubscription, error) {
+	eventTypes, err := json.Marshal(subscription.EventTypes)
+	if err != nil {
+		return WebhookSubscription{}, err
+	}
+
+	filters, err := json.Marshal(subscription.Filters)
+	if err != nil {
+		return WebhookSubscription{}, err
+	}
+
+	return WebhookSubscription{
+		Serial:     subscription.Serial,
+		TenantCode: subscription.TenantCode,
+		ObjectCode: subscription.ObjectCode,
+		EventTypes: string(eventTypes),
+		URL:        subscription.URL,
+		Secret:     subscription.Secret,
+		Filters:    string(filters),
+		IsActive:   subscription.IsActive,
+		CreatedBy:  subscription.CreatedBy,
+		CreatedAt:  subscription.CreatedAt,
+		UpdatedBy:  subscription.CreatedBy,
+		UpdatedAt:  subscription.CreatedAt,
+	}, nil
+}
+
+type WebhookDelivery struct {
+	ID                 int64        `gorm:"column:id;primaryKey" json:"id"`
+	Serial             string       `gorm:"column:serial" json:"serial"`
+	EventSerial        string       `gorm:"column:event_serial" json:"event_serial"`
+	SubscriptionSerial string       `gorm:"column:subscription_serial" json:"subscription_serial"`
+	TenantCode         string       `gorm:"column:tenant_code" json:"tenant_code"`
+	EventType          string       `gorm:"column:event_type" json:"event_type"`
+	Payload            string       `gorm:"column:payload" json:"payload"`
+	Status             string       `gorm:"column:status" json:"status"`
+	Attempts           int          `gorm:"column:attempts" json:"attempts"`
+	NextAttemptAt      time.Time    `gorm:"column:next_attempt_at" json:"next_attempt_at"`
+	LastError          string       `gorm:"column:last_error" json:"last_error"`
+	ResponseStatus     int          `gorm:"column:response_status" json:"response_status"`
+	DeliveredAt        sql.NullTime `gorm:"column:delivered_at" json:"delivered_at"`
+	CreatedAt          time.Time    `gorm:"column:created_at" json:"created_at"`
+}
+
+func (wd *WebhookDelivery) TableName() string {
+	return "webhook_delivery"
+}
+
+func (wd *WebhookDelivery) ToEntity() entity.WebhookDelivery {
+	var deliveredAt *time.Time
+	if wd.DeliveredAt.Valid {
+		deliveredAt = &wd.DeliveredAt.Time
+	}
+
+	return entity.WebhookDelivery{
+		ID:                 wd.ID,
+		Serial:             wd.Serial,
+		EventSerial:        wd.EventSerial,
+		SubscriptionSerial: wd.SubscriptionSerial,
+		TenantCode:         wd.TenantCode,
+		EventType:          wd.EventType,
+		Payload:            wd.Payload,
+		Status:             wd.Status,
+		Attempts:           wd.Attempts,
+		NextAttemptAt:      wd.NextAttemptAt,
+		LastError:          wd.LastError,
+		ResponseStatus:     wd.ResponseStatus,
+		DeliveredAt:        deliveredAt,
+		CreatedAt:          wd.CreatedAt,
+	}
+}
+
+func NewWebhookDelivery(delivery entity.WebhookDelivery) WebhookDelivery {
+	deliveredAt := sql.NullTime{}
+	if delivery.DeliveredAt != nil {
+		deliveredAt = sql.NullTime{Time: *delivery.DeliveredAt, Valid: true}
+	}
+
+	return WebhookDelivery{
+		ID:                 delivery.ID,
+		Serial:             delivery.Serial,
+		EventSerial:        delivery.EventSerial,
+		SubscriptionSerial: delivery.SubscriptionSerial,
+		TenantCode:         delivery.TenantCode,
+		EventType:          delivery.EventType,
+		Payload:            delivery.Payload,
+		Status:             delivery.Status,
+		Attempts:           delivery.Attempts,
+		NextAttemptAt:      delivery.NextAttemptAt,
+		LastError:          delivery.LastError,
+		ResponseStatus:     delivery.ResponseStatus,
+		DeliveredAt:        deliveredAt,
+		CreatedAt:          delivery.CreatedAt,
+	}
+}
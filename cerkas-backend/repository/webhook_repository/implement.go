@@ -0,0 +1,232 @@
+package webhookrepository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/cerkas/cerkas-backend/config"
+	"github.com/cerkas/cerkas-backend/core/entity"
+	repository_intf "github.com/cerkas/cerkas-backend/core/repository"
+	"github.com/cerkas/cerkas-backend/pkg/helper"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type repository struct {
+	cfg config.Config
+	db  *gorm.DB
+}
+
+func New(cfg config.Config, db *gorm.DB) repository_intf.WebhookRepository {
+	return &repository{
+		cfg: cfg,
+		db:  db,
+	}
+}
+
+func (r *repository) CreateSubscription(ctx context.Context, subscription entity.WebhookSubscription) (resp entity.WebhookSubscription, err error) {
+	record, err := NewWebhookSubscription(subscription)
+	if err != nil {
+		return resp, err
+	}
+
+	db := r.db.Model(&WebhookSubscription{})
+
+	if r.cfg.IsDebugMode {
+		db = db.Debug()
+	}
+
+	if err := db.Create(&record).Error; err != nil {
+		return resp, err
+	}
+
+	return record.ToEntity(), nil
+}
+
+func (r *repository) GetSubscriptions(ctx context.Context, tenantCode string) (resp []entity.WebhookSubscription, err error) {
+	db := r.db.Model(&WebhookSubscription{})
+
+	if r.cfg.IsDebugMode {
+		db = db.Debug()
+	}
+
+	results := []WebhookSubscription{}
+	if err := db.Where("tenant_code = ?", tenantCode).Order("id ASC").Find(&results).Error; err != nil {
+		return resp, err
+	}
+
+	for _, result := range results {
+		resp = append(resp, result.ToEntity())
+	}
+
+	return resp, nil
+}
+
+func (r *repository) GetSubscriptionsBySerials(ctx context.Context, serials []string) (resp []entity.WebhookSubscription, err error) {
+	if len(serials) == 0 {
+		return resp, nil
+	}
+
+	db := r.db.Model(&WebhookSubscription{})
+
+	if r.cfg.IsDebugMode {
+		db = db.Debug()
+	}
+
+	// deleted subscriptions are included, their pending deliveries are dropped by the worker
+	results := []WebhookSubscription{}
+	if err := db.Unscoped().Where("serial IN ?", serials).Find(&results).Error; err != nil {
+		return resp, err
+	}
+
+	for _, result := range results {
+		item := result.ToEntity()
+		item.IsActive = item.IsActive && !result.DeletedAt.Valid
+
+		resp = append(resp, item)
+	}
+
+	return resp, nil
+}
+
+func (r *repository) GetActiveSubscriptions(ctx context.Context, tenantCode, objectCode, eventType string) (resp []entity.WebhookSubscription, err error) {
+	db := r.db.Model(&WebhookSubscription{})
+
+	if r.cfg.IsDebugMode {
+		db = db.Debug()
+	}
+
+	results := []WebhookSubscription{}
+	if err := db.Where("tenant_code = ? AND object_code = ? AND is_active = ?", tenantCode, objectCode, true).
+		Where("event_types::jsonb @> ?::jsonb", fmt.Sprintf("[%q]", eventType)).
+		Find(&results).Error; err != nil {
+		return resp, err
+	}
+
+	for _, result := range results {
+		resp = append(resp, result.ToEntity())
+	}
+
+	return resp, nil
+}
+
+func (r *repository) DeleteSubscription(ctx context.Context, tenantCode, serial string) (err error) {
+	db := r.db.Model(&WebhookSubscription{})
+
+	if r.cfg.IsDebugMode {
+		db = db.Debug()
+	}
+
+	result := db.Where("tenant_code = ? AND serial = ?", tenantCode, serial).Delete(&WebhookSubscription{})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return entity.ErrorNotFound
+	}
+
+	return nil
+}
+
+func (r *repository) CreateDeliveries(ctx context.Context, deliveries []entity.WebhookDelivery) (err error) {
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	records := make([]WebhookDelivery, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		records = append(records, NewWebhookDelivery(delivery))
+	}
+
+	db := r.db.Model(&WebhookDelivery{})
+
+	if r.cfg.IsDebugMode {
+		db = db.Debug()
+	}
+
+	// a redelivered event finds its deliveries already there
+	return db.Clauses(clause.OnConflict{DoNothing: true}).Create(&records).Error
+}
+
+// ClaimDueDeliveries locks the due deliveries and pushes their next attempt by lease, so other replicas skip them
+// while they are being sent. A worker that dies mid delivery releases them when the lease expires.
+func (r *repository) ClaimDueDeliveries(ctx context.Context, limit int, lease time.Duration) (resp []entity.WebhookDelivery, err error) {
+	query := fmt.Sprintf(`
+	UPDATE webhook_delivery SET next_attempt_at = now() + interval '%d seconds'
+	WHERE id IN (
+		SELECT id FROM webhook_delivery
+		WHERE status IN (?, ?) AND next_attempt_at <= now()
+		ORDER BY next_attempt_at, id
+		LIMIT ?
+		FOR UPDATE SKIP LOCKED
+	)
+	RETURNING *
+	`, int(lease.Seconds()))
+
+	results := []WebhookDelivery{}
+	if err := r.db.Raw(query, entity.WebhookDeliveryStatusPending, entity.WebhookDeliveryStatusRetrying, limit).Scan(&results).Error; err != nil {
+		return resp, err
+	}
+
+	for _, result := range results {
+		resp = append(resp, result.ToEntity())
+	}
+
+	return resp, nil
+}
+
+func (r *repository) UpdateDelivery(ctx context.Context, delivery entity.WebhookDelivery) (err error) {
+	record := NewWebhookDelivery(delivery)
+
+	db := r.db.Model(&WebhookDelivery{})
+
+	if r.cfg.IsDebugMode {
+		db = db.Debug()
+	}
+
+	return db.Where("id = ?", record.ID).Updates(map[string]any{
+		"status":          record.Status,
+		"attempts":        record.Attempts,
+		"next_attempt_at": record.NextAttemptAt,
+		"last_error":      record.LastError,
+		"response_status": record.ResponseStatus,
+		"delivered_at":    record.DeliveredAt,
+	}).Error
+}
+
+func (r *repository) GetDeliveries(ctx context.Context, request entity.WebhookDeliveryQuery) (resp []entity.WebhookDelivery, total int64, err error) {
+	limit, offset, _, _ := helper.SetupListParameter(int32(request.Page), int32(request.PageSize))
+
+	db := r.db.Model(&WebhookDelivery{})
+
+	if r.cfg.IsDebugMode {
+		db = db.Debug()
+	}
+
+	db = db.Where("tenant_code = ?", request.TenantCode)
+
+	if request.SubscriptionSerial != "" {
+		db = db.Where("subscription_serial = ?", request.SubscriptionSerial)
+	}
+
+	if request.Status != "" {
+		db = db.Where("status = ?", request.Status)
+	}
+
+	if err := db.Count(&total).Error; err != nil {
+		return resp, total, err
+	}
+
+	results := []WebhookDelivery{}
+	if err := db.Order("id DESC").Limit(int(limit)).Offset(int(offset)).Find(&results).Error; err != nil {
+		return resp, total, err
+	}
+
+	for _, result := range results {
+		resp = append(resp, result.ToEntity())
+	}
+
+	return resp, total, nil
+}
@@ -0,0 +1,62 @@
+package workflowrepository
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/cerkas/cerkas-backend/core/entity"
+)
+
+type StateMachineField struct {
+	FieldCode    string `gorm:"column:field_code" json:"field_code"`
+	StateMachine string `gorm:"column:state_machine" json:"state_machine"`
+}
+
+type StateTransitionLog struct {
+	ID           int64          `gorm:"column:id;primaryKey" json:"id"`
+	Serial       string         `gorm:"column:serial" json:"serial"`
+	TenantCode   string         `gorm:"column:tenant_code" json:"tenant_code"`
+	ObjectCode   string         `gorm:"column:object_code" json:"object_code"`
+	RecordSerial string         `gorm:"column:record_serial" json:"record_serial"`
+	FieldCode    string         `gorm:"column:field_code" json:"field_code"`
+	Transition   sql.NullString `gorm:"column:transition" json:"transition"`
+	FromState    sql.NullString `gorm:"column:from_state" json:"from_state"`
+	ToState      string         `gorm:"column:to_state" json:"to_state"`
+	ActorSerial  string         `gorm:"column:actor_serial" json:"actor_serial"`
+	CreatedAt    time.Time      `gorm:"column:created_at" json:"created_at"`
+}
+
+func (stl *StateTransitionLog) TableName() string {
+	return "state_transition_log"
+}
+
+func (stl *StateTransitionLog) ToEntity() entity.StateTransitionLog {
+	return entity.StateTransitionLog{
+		ID:           stl.ID,
+		Serial:       stl.Serial,
+		TenantCode:   stl.TenantCode,
+		ObjectCode:   stl.ObjectCode,
+		RecordSerial: stl.RecordSerial,
+		FieldCode:    stl.FieldCode,
+		Transition:   stl.Transition.String,
+		FromState:    stl.FromState.String,
+		ToState:      stl.ToState,
+		ActorSerial:  stl.ActorSerial,
+		CreatedAt:    stl.CreatedAt,
+	}
+}
+
+func NewStateTransitionLog(log entity.StateTransitionLog) StateTransitionLog {
+	return StateTransitionLog{
+		Serial:       log.Serial,
+		TenantCode:   log.TenantCode,
+		ObjectCode:   log.ObjectCode,
+		RecordSerial: log.RecordSerial,
+		FieldCode:    log.FieldCode,
+		Transition:   sql.NullString{String: log.Transition, Valid: log.Transition != ""},
+		FromState:    sql.NullString{String: log.FromState, Valid: log.FromState != ""},
+		ToState:      log.ToState,
+		ActorSerial:  log.ActorSerial,
+		CreatedAt:    log.CreatedAt,
+	}
+}
@@ -0,0 +1,103 @@
+package workflowrepository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/cerkas/cerkas-backend/config"
+	"github.com/cerkas/cerkas-backend/core/entity"
+	repository_intf "github.com/cerkas/cerkas-backend/core/repository"
+	"github.com/cerkas/cerkas-backend/repository/util"
+	"gorm.io/gorm"
+)
+
+type repository struct {
+	cfg config.Config
+	db  *gorm.DB
+}
+
+func New(cfg config.Config, db *gorm.DB) repository_intf.WorkflowRepository {
+	return &repository{
+		cfg: cfg,
+		db:  db,
+	}
+}
+
+// GetStateMachines returns the state machines of the fields of an object, by field code
+func (r *repository) GetStateMachines(ctx context.Context, tenantCode, objectCode string) (resp map[string]entity.StateMachine, err error) {
+	db := r.db
+	if r.cfg.IsDebugMode {
+		db = db.Debug()
+	}
+
+	results := []StateMachineField{}
+	err = db.Raw(`
+	SELECT
+		object_fields.field_code,
+		object_fields.state_machine
+	FROM
+		object_fields
+		JOIN objects ON objects.serial = object_fields.object_serial
+		JOIN tenants ON tenants.serial = objects.tenant_serial
+	WHERE
+		objects.code = ?
+		AND tenants.code = ?
+		AND object_fields.state_machine IS NOT NULL
+		AND object_fields.deleted_at IS NULL
+	`, objectCode, tenantCode).Scan(&results).Error
+	if err != nil {
+		return resp, err
+	}
+
+	resp = map[string]entity.StateMachine{}
+	for _, result := range results {
+		stateMachine := entity.StateMachine{}
+		if err := json.Unmarshal([]byte(result.StateMachine), &stateMachine); err != nil {
+			return resp, fmt.Errorf("state machine of %v.%v: %w", objectCode, result.FieldCode, err)
+		}
+
+		resp[result.FieldCode] = stateMachine
+	}
+
+	return resp, nil
+}
+
+// CreateTransitionLogs records transitions in the transaction of the mutation that ran them
+func (r *repository) CreateTransitionLogs(ctx context.Context, logs []entity.StateTransitionLog) (err error) {
+	if len(logs) == 0 {
+		return nil
+	}
+
+	records := make([]StateTransitionLog, 0, len(logs))
+	for _, log := range logs {
+		records = append(records, NewStateTransitionLog(log))
+	}
+
+	db := util.ContextDB(ctx, r.db).Model(&StateTransitionLog{})
+
+	if r.cfg.IsDebugMode {
+		db = db.Debug()
+	}
+
+	return db.Create(&records).Error
+}
+
+func (r *repository) GetTransitionLogs(ctx context.Context, tenantCode, objectCode, recordSerial string) (resp []entity.StateTransitionLog, err error) {
+	db := r.db.Model(&StateTransitionLog{})
+
+	if r.cfg.IsDebugMode {
+		db = db.Debug()
+	}
+
+	results := []StateTransitionLog{}
+	if err := db.Where("tenant_code = ? AND object_code = ? AND record_serial = ?", tenantCode, objectCode, recordSerial).Order("id ASC").Find(&results).Error; err != nil {
+		return resp, err
+	}
+
+	for _, result := range results {
+		resp = append(resp, result.ToEntity())
+	}
+
+	return resp, nil
+}